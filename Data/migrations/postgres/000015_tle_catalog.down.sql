@@ -0,0 +1,4 @@
+-- Rollback TLE catalog history
+DROP INDEX IF EXISTS idx_tle_element_sets_maneuvers;
+DROP INDEX IF EXISTS idx_tle_element_sets_norad_epoch;
+DROP TABLE IF EXISTS tle_element_sets;
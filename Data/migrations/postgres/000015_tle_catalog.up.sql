@@ -0,0 +1,27 @@
+-- Element set history for the TLE catalog. Every epoch of every object is
+-- kept so that maneuvers and decay can be studied after the fact.
+CREATE TABLE IF NOT EXISTS tle_element_sets (
+    id BIGSERIAL PRIMARY KEY,
+    norad_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    int_designator VARCHAR(16),
+    epoch TIMESTAMP WITH TIME ZONE NOT NULL,
+    line1 CHAR(69) NOT NULL,
+    line2 CHAR(69) NOT NULL,
+    mean_motion DOUBLE PRECISION NOT NULL,
+    mean_motion_dot DOUBLE PRECISION NOT NULL,
+    eccentricity DOUBLE PRECISION NOT NULL,
+    inclination DOUBLE PRECISION NOT NULL,
+    raan DOUBLE PRECISION NOT NULL,
+    arg_perigee DOUBLE PRECISION NOT NULL,
+    mean_anomaly DOUBLE PRECISION NOT NULL,
+    bstar DOUBLE PRECISION NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    maneuver BOOLEAN NOT NULL DEFAULT FALSE,
+    maneuver_reason TEXT,
+    imported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT tle_element_sets_norad_epoch UNIQUE (norad_id, epoch)
+);
+
+CREATE INDEX idx_tle_element_sets_norad_epoch ON tle_element_sets(norad_id, epoch DESC);
+CREATE INDEX idx_tle_element_sets_maneuvers ON tle_element_sets(epoch DESC) WHERE maneuver;
@@ -0,0 +1,154 @@
+// conjunction_screen screens tracked satellites against a TLE catalog for
+// close approaches and writes CCSDS Conjunction Data Messages.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/conjunction"
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+func main() {
+	catalogPath := flag.String("catalog", "", "Catalog of secondary objects (2LE/3LE or CCSDS OMM)")
+	primariesPath := flag.String("primaries", "", "Primary objects file (default: -norad entries from the catalog)")
+	norad := flag.String("norad", strconv.Itoa(satellite.NoradISS), "Comma-separated NORAD IDs of primaries in the catalog")
+	startFlag := flag.String("start", "", "Screening start, RFC 3339 (default: now)")
+	hours := flag.Float64("hours", 24, "Screening window in hours")
+	distance := flag.Float64("distance", conjunction.DefaultConfig().ScreeningDistance, "Screening distance in km")
+	hbr := flag.Float64("hbr", conjunction.DefaultConfig().HardBodyRadius*1000, "Combined hard-body radius in metres")
+	method := flag.String("method", "foster", "Collision probability method: foster or alfano")
+	cdmDir := flag.String("cdm-dir", "", "Write one CDM per conjunction to this directory")
+	outputJSON := flag.Bool("json", false, "Output conjunctions as JSON")
+	flag.Parse()
+
+	log.SetFlags(log.Ltime)
+	if *catalogPath == "" {
+		log.Fatalf("-catalog is required")
+	}
+
+	catalog, err := readCatalog(*catalogPath)
+	if err != nil {
+		log.Fatalf("Failed to read catalog: %v", err)
+	}
+
+	var primaries []*satellite.TLE
+	if *primariesPath != "" {
+		if primaries, err = readCatalog(*primariesPath); err != nil {
+			log.Fatalf("Failed to read primaries: %v", err)
+		}
+	} else {
+		byID := make(map[int]*satellite.TLE, len(catalog))
+		for _, tle := range catalog {
+			byID[tle.SatelliteID] = tle
+		}
+		for _, field := range strings.Split(*norad, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				log.Fatalf("Invalid NORAD ID %q", field)
+			}
+			tle, ok := byID[id]
+			if !ok {
+				log.Fatalf("NORAD ID %d not found in %s", id, *catalogPath)
+			}
+			primaries = append(primaries, tle)
+		}
+	}
+
+	start := time.Now().UTC()
+	if *startFlag != "" {
+		if start, err = time.Parse(time.RFC3339, *startFlag); err != nil {
+			log.Fatalf("Invalid -start: %v", err)
+		}
+	}
+
+	cfg := conjunction.DefaultConfig()
+	cfg.Window = time.Duration(*hours * float64(time.Hour))
+	cfg.ScreeningDistance = *distance
+	cfg.HardBodyRadius = *hbr / 1000
+	switch *method {
+	case "foster":
+		cfg.Method = conjunction.MethodFoster
+	case "alfano":
+		cfg.Method = conjunction.MethodAlfano
+	default:
+		log.Fatalf("Unknown method %q", *method)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	log.Printf("Screening %d primaries against %d objects for %.0f h from %s...",
+		len(primaries), len(catalog), *hours, start.Format(time.RFC3339))
+	screener := conjunction.NewScreener(cfg, nil)
+	conjunctions, err := screener.Screen(ctx, primaries, catalog, start)
+	if err != nil {
+		log.Fatalf("Screening failed: %v", err)
+	}
+
+	if *cdmDir != "" {
+		if err := os.MkdirAll(*cdmDir, 0o755); err != nil {
+			log.Fatalf("Failed to create CDM directory: %v", err)
+		}
+		created := time.Now().UTC()
+		for i := range conjunctions {
+			c := &conjunctions[i]
+			path := filepath.Join(*cdmDir, c.MessageID(cfg.Source)+".cdm")
+			if err := writeCDM(path, c, cfg.Source, created); err != nil {
+				log.Fatalf("Failed to write CDM: %v", err)
+			}
+		}
+		log.Printf("Wrote %d CDMs to %s", len(conjunctions), *cdmDir)
+	}
+
+	if *outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(conjunctions)
+		return
+	}
+
+	if len(conjunctions) == 0 {
+		log.Printf("No approaches within %.1f km", *distance)
+		return
+	}
+	fmt.Printf("%-24s %-6s %-24s %-6s %-20s %10s %10s %10s\n",
+		"PRIMARY", "", "SECONDARY", "", "TCA (UTC)", "MISS (km)", "VREL km/s", "Pc")
+	for _, c := range conjunctions {
+		fmt.Printf("%-24s %-6d %-24s %-6d %-20s %10.3f %10.3f %10.2e\n",
+			c.Primary.Name, c.Primary.NoradID, c.Secondary.Name, c.Secondary.NoradID,
+			c.TCA.Format("2006-01-02 15:04:05"), c.MissDistance, c.RelativeSpeed, c.Probability)
+	}
+}
+
+func readCatalog(path string) ([]*satellite.TLE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return satellite.ReadCatalog(f, "")
+}
+
+func writeCDM(path string, c *conjunction.Conjunction, originator string, created time.Time) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := conjunction.WriteCDM(f, c, originator, created); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
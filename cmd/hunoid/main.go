@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -21,6 +22,8 @@ import (
 	"github.com/asgard/pandora/internal/robotics/control"
 	"github.com/asgard/pandora/internal/robotics/coordination"
 	"github.com/asgard/pandora/internal/robotics/ethics"
+	"github.com/asgard/pandora/internal/robotics/motion"
+	"github.com/asgard/pandora/internal/robotics/navigation"
 	"github.com/asgard/pandora/internal/robotics/vla"
 )
 
@@ -64,6 +67,7 @@ type StepResult struct {
 	Action   vla.ActionType
 	Outcome  string
 	Duration time.Duration
+	Planning *motion.PlanningError // Why an arm motion could not be planned
 }
 
 type Criticality string
@@ -873,22 +877,30 @@ func (e *MissionExecutor) Run(ctx context.Context, mission *MissionPlan) (*Missi
 
 		if err := e.actionRegistry.Execute(ctx, action); err != nil {
 			log.Printf("Action execution failed: %v", err)
+			outcome := "failed"
+			details := map[string]interface{}{
+				"error": err.Error(),
+			}
+			var planErr *motion.PlanningError
+			if errors.As(err, &planErr) {
+				outcome = "planning_failed"
+				details["planning"] = planErr.Details()
+			}
 			report.StepResults = append(report.StepResults, StepResult{
 				StepID:   step.ID,
 				Command:  step.Command,
 				Action:   action.Type,
-				Outcome:  "failed",
+				Outcome:  outcome,
 				Duration: time.Since(stepStart),
+				Planning: planErr,
 			})
-			e.state.SetOutcome("failed")
+			e.state.SetOutcome(outcome)
 			e.audit.Log(AuditEvent{
 				Timestamp: time.Now().UTC(),
 				Type:      "action_failed",
 				MissionID: mission.ID,
 				StepID:    step.ID,
-				Details: map[string]interface{}{
-					"error": err.Error(),
-				},
+				Details:   details,
 			})
 			continue
 		}
@@ -998,6 +1010,9 @@ func writeReport(path string, report *MissionReport) error {
 	builder.WriteString("## Step Outcomes\n\n")
 	for _, result := range report.StepResults {
 		builder.WriteString(fmt.Sprintf("- %s: %s -> %s (%s)\n", result.StepID, result.Command, result.Outcome, result.Duration))
+		if result.Planning != nil {
+			builder.WriteString(fmt.Sprintf("  - Planning: %s\n", result.Planning.Error()))
+		}
 	}
 
 	builder.WriteString("\n## Intervention Decisions\n\n")
@@ -1425,7 +1440,7 @@ func reportTelemetry(ctx context.Context, robot control.HunoidController, interv
 	}
 }
 
-func executeAction(ctx context.Context, robot control.HunoidController, manip control.ManipulatorController, action *vla.Action) error {
+func executeAction(ctx context.Context, robot control.HunoidController, manip control.ManipulatorController, navigator *navigation.Navigator, action *vla.Action) error {
 	switch action.Type {
 	case vla.ActionNavigate:
 		x, _ := action.Parameters["x"].(float64)
@@ -1437,12 +1452,24 @@ func executeAction(ctx context.Context, robot control.HunoidController, manip co
 			Orientation: control.Quaternion{W: 1, X: 0, Y: 0, Z: 0},
 		}
 
-		return robot.MoveTo(ctx, targetPose)
+		if navigator == nil {
+			return robot.MoveTo(ctx, targetPose)
+		}
+		if err := navigator.SetGoal(ctx, targetPose); err != nil {
+			return err
+		}
+		return navigator.Wait(ctx)
 
 	case vla.ActionPickUp:
+		if err := reachActionTarget(ctx, manip, action); err != nil {
+			return err
+		}
 		return manip.CloseGripper()
 
 	case vla.ActionPutDown:
+		if err := reachActionTarget(ctx, manip, action); err != nil {
+			return err
+		}
 		return manip.OpenGripper()
 
 	case vla.ActionOpen:
@@ -1466,6 +1493,22 @@ func executeAction(ctx context.Context, robot control.HunoidController, manip co
 	}
 }
 
+// reachActionTarget moves the gripper to the action's target_x, target_y
+// and target_z parameters, in the arm base frame, when the action has
+// them.
+func reachActionTarget(ctx context.Context, manip control.ManipulatorController, action *vla.Action) error {
+	x, okX := action.Parameters["target_x"].(float64)
+	y, okY := action.Parameters["target_y"].(float64)
+	z, okZ := action.Parameters["target_z"].(float64)
+	if !okX || !okY || !okZ {
+		return nil
+	}
+	if err := manip.ReachTo(ctx, control.Vector3{X: x, Y: y, Z: z}); err != nil {
+		return fmt.Errorf("reach (%.3f, %.3f, %.3f): %w", x, y, z, err)
+	}
+	return nil
+}
+
 type mockHunoidController struct {
 	mu      sync.Mutex
 	pose    control.Pose
@@ -1626,6 +1669,7 @@ func main() {
 	telemetryInterval := flag.Duration("telemetry-interval", 5*time.Second, "Telemetry interval")
 	metricsAddr := flag.String("metrics-addr", ":9092", "Metrics server address")
 	stayAlive := flag.Bool("stay-alive", false, "Keep running after mission completes")
+	scanInterval := flag.Duration("scan-interval", 100*time.Millisecond, "Interval between perception scans fed to the navigator")
 	flag.Parse()
 
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
@@ -1671,10 +1715,30 @@ func main() {
 		}
 		log.Println("Robot controller initialized")
 
-		manipulator, err = control.NewRemoteManipulator(*hunoidID, hunoidEndpoint)
+		remoteManipulator, err := control.NewRemoteManipulator(*hunoidID, hunoidEndpoint)
 		if err != nil {
 			log.Fatalf("Failed to initialize manipulator: %v", err)
 		}
+		if armModel := os.Getenv("HUNOID_ARM_MODEL"); armModel != "" {
+			if err := remoteManipulator.SetArmModel(armModel); err != nil {
+				log.Fatalf("Failed to load arm model: %v", err)
+			}
+			log.Printf("Manipulator kinematics: %s", armModel)
+		}
+		// HUNOID_WORKSPACE_BOXES lists static obstacles as JSON boxes,
+		// [{"name": "table", "min": [x, y, z], "max": [x, y, z]}], in the
+		// arm base frame
+		if workspace := os.Getenv("HUNOID_WORKSPACE_BOXES"); workspace != "" {
+			var boxes []motion.Box
+			if err := json.Unmarshal([]byte(workspace), &boxes); err != nil {
+				log.Fatalf("Invalid HUNOID_WORKSPACE_BOXES: %v", err)
+			}
+			if err := remoteManipulator.SetEnvironment(&motion.Environment{Boxes: boxes}, motion.DefaultConfig()); err != nil {
+				log.Fatalf("Failed to enable motion planning: %v", err)
+			}
+			log.Printf("Manipulator motion planning: %d workspace boxes", len(boxes))
+		}
+		manipulator = remoteManipulator
 		log.Println("Manipulator initialized")
 
 		vlaEndpoint := os.Getenv("VLA_ENDPOINT")
@@ -1687,6 +1751,28 @@ func main() {
 		}
 	}
 	defer vlaModel.Shutdown()
+
+	// HUNOID_MAP points at a map_server YAML file; when set, navigate
+	// actions plan around the mapped obstacles instead of moving straight
+	var navigator *navigation.Navigator
+	if mapPath := os.Getenv("HUNOID_MAP"); mapPath != "" {
+		grid, err := navigation.LoadMap(mapPath, navigation.DefaultGridConfig())
+		if err != nil {
+			log.Fatalf("Failed to load navigation map: %v", err)
+		}
+		navigator = navigation.NewNavigator(robot, grid, navigation.DefaultConfig())
+		width, height := grid.Size()
+		log.Printf("Navigation map: %s (%dx%d at %.2f m)", mapPath, width, height, grid.Config().Resolution)
+
+		// Keep the map and the moving obstacles current from the robot's
+		// 360° perception
+		if source, ok := robot.(navigation.ScanSource); ok {
+			go navigator.FollowScans(ctx, source, *scanInterval)
+			log.Printf("Navigation scans: every %s", *scanInterval)
+		} else {
+			log.Println("Robot controller provides no perception scans; navigation map is static")
+		}
+	}
 	modelInfo := vlaModel.GetModelInfo()
 	log.Printf("VLA Model: %s v%s", modelInfo.Name, modelInfo.Version)
 
@@ -1713,25 +1799,25 @@ func main() {
 
 	actionRegistry := NewActionRegistry()
 	actionRegistry.Register(vla.ActionNavigate, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 	actionRegistry.Register(vla.ActionPickUp, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 	actionRegistry.Register(vla.ActionPutDown, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 	actionRegistry.Register(vla.ActionOpen, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 	actionRegistry.Register(vla.ActionClose, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 	actionRegistry.Register(vla.ActionInspect, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 	actionRegistry.Register(vla.ActionWait, func(ctx context.Context, action *vla.Action) error {
-		return executeAction(ctx, robot, manipulator, action)
+		return executeAction(ctx, robot, manipulator, navigator, action)
 	})
 
 	policyEngine := NewSafetyPolicyEngine(20.0)
@@ -20,8 +20,10 @@ import (
 	"github.com/asgard/pandora/internal/nysus/api"
 	"github.com/asgard/pandora/internal/nysus/events"
 	"github.com/asgard/pandora/internal/nysus/mcp"
+	"github.com/asgard/pandora/internal/platform/conjunction"
 	"github.com/asgard/pandora/internal/platform/db"
 	"github.com/asgard/pandora/internal/platform/observability"
+	"github.com/asgard/pandora/internal/services"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
@@ -170,6 +172,28 @@ func main() {
 		log.Println("AI Agent Coordinator started with specialized agents")
 	}
 
+	// Screen the fleet for conjunctions against the TLE catalog
+	screenCfg := conjunction.DefaultConfig()
+	screenCfg.Source = "nysus"
+	var publisher conjunction.EventPublisher
+	if controlPlane != nil {
+		publisher = controlPlane
+	}
+	screeningService := conjunction.NewService(
+		conjunction.NewScreener(screenCfg, publisher),
+		server.TLECatalog(),
+		services.DefaultTrackingConfig().FleetIDs,
+		time.Hour,
+	)
+	screenCtx, stopScreening := context.WithCancel(context.Background())
+	defer stopScreening()
+	go screeningService.Run(screenCtx)
+
+	// Plan ground station antenna time and keep it current
+	planCtx, stopPlanning := context.WithCancel(context.Background())
+	defer stopPlanning()
+	go server.PassScheduler().Run(planCtx)
+
 	// Start database-driven event publishing if DB is available
 	if pgDB != nil {
 		go startEventPublisher(context.Background(), eventBus, pgDB)
@@ -192,6 +216,7 @@ func main() {
 	log.Println("  - Streams:    GET  /api/streams, /api/streams/stats")
 	log.Println("  - WebSocket:  WS   /ws, /ws/events, /ws/realtime")
 	log.Println("  - Signaling:  WS   /ws/signaling (WebRTC SFU)")
+	log.Println("  - DTN:        GET  /api/dtn/passplan, POST /api/dtn/backlog, POST /api/dtn/orbit")
 	log.Println("  - MCP:        HTTP :8085/mcp/* (LLM tools)")
 
 	// Wait for shutdown signal
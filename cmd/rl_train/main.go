@@ -0,0 +1,118 @@
+// rl_train learns weights for the RL DTN router by replaying a scenario in
+// the discrete-event emulator and rewarding routing decisions with the
+// delivery, expiry or loss of the bundles they forwarded.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+)
+
+func main() {
+	defaults := dtn.DefaultRLTrainingConfig()
+
+	scenarioPath := flag.String("scenario", "configs/dtn/leo_relay.json", "Scenario file (JSON) to train against")
+	episodes := flag.Int("episodes", defaults.Episodes, "Training episodes (one emulated scenario run each)")
+	initialModel := flag.String("model", "", "Model to continue training (default: untrained model over the current features)")
+	checkpointDir := flag.String("checkpoint-dir", "models/checkpoints", "Directory for versioned model checkpoints")
+	checkpointEvery := flag.Int("checkpoint-every", 10, "Episodes between checkpoints (0 = only after the last)")
+	outPath := flag.String("out", "", "Also write the trained model here (e.g. models/rl_router.json)")
+	learningRate := flag.Float64("learning-rate", defaults.Learning.LearningRate, "Weight update step size")
+	exploration := flag.Float64("exploration", defaults.Learning.Exploration, "Initial exploration rate")
+	explorationDecay := flag.Float64("exploration-decay", defaults.Learning.ExplorationDecay, "Exploration multiplier per episode")
+	seed := flag.Int64("seed", defaults.Learning.Seed, "Exploration random seed")
+	outputJSON := flag.Bool("json", false, "Output episode statistics as JSON")
+	verbose := flag.Bool("verbose", false, "Log node activity during emulation")
+	flag.Parse()
+
+	log.SetFlags(log.Ltime)
+
+	scenario, err := dtn.LoadScenario(*scenarioPath)
+	if err != nil {
+		log.Fatalf("Failed to load scenario: %v", err)
+	}
+
+	var initial *dtn.RLRoutingModel
+	if *initialModel != "" {
+		if initial, err = dtn.LoadRLRoutingModel(*initialModel); err != nil {
+			log.Fatalf("Failed to load model: %v", err)
+		}
+		log.Printf("Continuing from model v%d (%d episodes)", initial.Version, initial.Episodes)
+	}
+
+	cfg := defaults
+	cfg.Episodes = *episodes
+	cfg.CheckpointDir = *checkpointDir
+	cfg.CheckpointEvery = *checkpointEvery
+	cfg.Learning.LearningRate = *learningRate
+	cfg.Learning.Exploration = *exploration
+	cfg.Learning.ExplorationDecay = *explorationDecay
+	cfg.Learning.Seed = *seed
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	log.Printf("Training on scenario %q for %d episodes", scenario.Name, cfg.Episodes)
+	if !*verbose {
+		// Keep trainer progress, drop per-node logs
+		log.SetOutput(trainerLog{os.Stderr})
+	}
+	start := time.Now()
+	model, stats, err := dtn.TrainRLRouter(ctx, *scenario, initial, cfg)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Fatalf("Training failed: %v", err)
+	}
+	log.Printf("Trained %d episodes in %s", len(stats), time.Since(start).Round(time.Millisecond))
+
+	if *outPath != "" {
+		if err := dtn.SaveRLRoutingModel(*outPath, model); err != nil {
+			log.Fatalf("Failed to write model: %v", err)
+		}
+		log.Printf("Wrote model v%d to %s", model.Version, *outPath)
+	}
+
+	if *outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			log.Fatalf("Failed to encode statistics: %v", err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "EPISODE\tEXPLORE\tDELIVERED\tEXPIRED\tDROPPED\tRATIO\tMEAN\tREWARD\t")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%d\t%.3f\t%d\t%d\t%d\t%.3f\t%s\t%.2f\t\n",
+			s.Episode, s.Exploration, s.Delivered, s.Expired, s.Dropped,
+			s.DeliveryRatio, s.MeanLatency.Round(time.Second), s.Reward)
+	}
+	w.Flush()
+}
+
+// trainerLog passes through only the trainer's and learner's log lines.
+type trainerLog struct {
+	w io.Writer
+}
+
+func (l trainerLog) Write(p []byte) (int, error) {
+	for _, prefix := range []string{"[RL Trainer]", "[RL Learner]"} {
+		if bytes.Contains(p, []byte(prefix)) {
+			return l.w.Write(p)
+		}
+	}
+	return len(p), nil
+}
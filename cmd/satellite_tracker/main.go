@@ -1,5 +1,6 @@
 // satellite_tracker demonstrates real-time satellite tracking integration.
-// Uses free TLE API for orbit data and optional N2YO API for real-time positions.
+// Uses free TLE API (or a local TLE file) for orbit data, predicts passes
+// locally, and uses the optional N2YO API for real-time positions.
 package main
 
 import (
@@ -23,7 +24,13 @@ func main() {
 	obsAlt := flag.Float64("alt", 10, "Observer altitude in meters")
 	duration := flag.Int("duration", 90, "Propagation duration in minutes")
 	outputJSON := flag.Bool("json", false, "Output as JSON")
-	showPasses := flag.Bool("passes", false, "Show upcoming visual passes (requires N2YO key)")
+	showPasses := flag.Bool("passes", false, "Predict upcoming passes over the observer")
+	days := flag.Int("days", 5, "Pass prediction window in days")
+	minElevation := flag.Float64("min-elevation", satellite.DefaultPassConfig().MinElevation, "Pass elevation mask in degrees")
+	visibleOnly := flag.Bool("visible-only", false, "Only report passes visible to the naked eye")
+	icalPath := flag.String("ical", "", "Write predicted passes to this iCalendar file")
+	tleFile := flag.String("tle-file", "", "Read element sets from this 2LE/3LE or CCSDS OMM file instead of fetching")
+	showOverhead := flag.Bool("overhead", false, "List satellites from -tle-file currently above the elevation mask")
 	flag.Parse()
 
 	log.SetFlags(log.Ltime)
@@ -34,11 +41,42 @@ func main() {
 	cfg.N2YOAPIKey = *n2yoKey
 	client := satellite.NewClient(cfg)
 
-	// Fetch TLE
-	log.Printf("Fetching TLE for NORAD ID %d...", *noradID)
-	tle, err := client.GetTLE(ctx, *noradID)
-	if err != nil {
-		log.Fatalf("Failed to fetch TLE: %v", err)
+	observer := satellite.Observer{
+		Latitude:  *obsLat,
+		Longitude: *obsLon,
+		Altitude:  *obsAlt,
+	}
+
+	// Load the catalog or fetch the TLE
+	var catalog []*satellite.TLE
+	var tle *satellite.TLE
+	if *tleFile != "" {
+		f, err := os.Open(*tleFile)
+		if err != nil {
+			log.Fatalf("Failed to open TLE file: %v", err)
+		}
+		catalog, err = satellite.ReadCatalog(f, "")
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to read TLE file: %v", err)
+		}
+		log.Printf("Loaded %d TLEs from %s", len(catalog), *tleFile)
+		for _, t := range catalog {
+			if t.SatelliteID == *noradID {
+				tle = t
+				break
+			}
+		}
+		if tle == nil {
+			log.Fatalf("NORAD ID %d not found in %s", *noradID, *tleFile)
+		}
+	} else {
+		log.Printf("Fetching TLE for NORAD ID %d...", *noradID)
+		var err error
+		tle, err = client.GetTLE(ctx, *noradID)
+		if err != nil {
+			log.Fatalf("Failed to fetch TLE: %v", err)
+		}
 	}
 
 	log.Printf("Satellite: %s", tle.Name)
@@ -55,21 +93,24 @@ func main() {
 
 	// Compute current position
 	now := time.Now().UTC()
-	lat, lon, alt := propagator.Propagate(now)
+	state, err := propagator.PropagateState(now)
+	if err != nil {
+		log.Fatalf("Failed to propagate %s from epoch %s: %v", tle.Name, propagator.Epoch().Format(time.RFC3339), err)
+	}
+	lat, lon, alt, err := propagator.Propagate(now)
+	if err != nil {
+		log.Fatalf("Failed to propagate %s: %v", tle.Name, err)
+	}
 	log.Printf("Current Position (propagated):")
 	log.Printf("  Latitude:  %.4f°", lat)
 	log.Printf("  Longitude: %.4f°", lon)
 	log.Printf("  Altitude:  %.2f km", alt)
+	log.Printf("  TEME r:    %.3f, %.3f, %.3f km", state.Position[0], state.Position[1], state.Position[2])
+	log.Printf("  TEME v:    %.6f, %.6f, %.6f km/s", state.Velocity[0], state.Velocity[1], state.Velocity[2])
 	fmt.Println()
 
 	// If N2YO key provided, fetch real-time position for comparison
 	if *n2yoKey != "" {
-		observer := satellite.Observer{
-			Latitude:  *obsLat,
-			Longitude: *obsLon,
-			Altitude:  *obsAlt,
-		}
-
 		log.Printf("Fetching real-time position from N2YO API...")
 		positions, err := client.GetPosition(ctx, *noradID, observer, 1)
 		if err != nil {
@@ -85,35 +126,86 @@ func main() {
 			log.Printf("  Eclipsed:  %v", p.Eclipsed)
 			fmt.Println()
 		}
+	}
 
-		// Show visual passes if requested
-		if *showPasses {
-			log.Printf("Fetching upcoming visual passes...")
-			passes, err := client.GetVisualPasses(ctx, *noradID, observer, 5, 60)
-			if err != nil {
-				log.Printf("Warning: Could not fetch passes: %v", err)
-			} else if len(passes) > 0 {
-				log.Printf("Upcoming Visual Passes:")
-				for i, pass := range passes {
-					log.Printf("  Pass %d:", i+1)
-					log.Printf("    Start: %s (Az: %.1f°, El: %.1f°)",
-						pass.StartTime.Format("Jan 02 15:04"), pass.StartAzimuth, pass.StartElevation)
-					log.Printf("    Max:   %s (Az: %.1f°, El: %.1f°)",
-						pass.MaxTime.Format("Jan 02 15:04"), pass.MaxAzimuth, pass.MaxElevation)
-					log.Printf("    End:   %s (Az: %.1f°, El: %.1f°)",
-						pass.EndTime.Format("Jan 02 15:04"), pass.EndAzimuth, pass.EndElevation)
-					log.Printf("    Duration: %ds, Magnitude: %.1f", pass.Duration, pass.Magnitude)
-				}
-				fmt.Println()
+	// Predict passes locally
+	var passes []satellite.Pass
+	if *showPasses || *icalPath != "" {
+		passCfg := satellite.DefaultPassConfig()
+		passCfg.MinElevation = *minElevation
+		all, err := satellite.PredictPasses(tle, observer, now, now.Add(time.Duration(*days)*24*time.Hour), passCfg)
+		if err != nil {
+			log.Fatalf("Failed to predict passes: %v", err)
+		}
+		for _, pass := range all {
+			if !*visibleOnly || pass.Visible {
+				passes = append(passes, pass)
+			}
+		}
+
+		if !*outputJSON {
+			if len(passes) == 0 {
+				log.Printf("No passes above %.0f° found in next %d days", *minElevation, *days)
 			} else {
-				log.Printf("No visual passes found in next 5 days")
+				log.Printf("Upcoming Passes (mask %.0f°):", *minElevation)
+			}
+			for i, pass := range passes {
+				log.Printf("  Pass %d:", i+1)
+				log.Printf("    AOS: %s (Az: %.1f°, El: %.1f°)",
+					pass.AOS.Time.Format("Jan 02 15:04:05"), pass.AOS.Azimuth, pass.AOS.Elevation)
+				log.Printf("    TCA: %s (Az: %.1f°, El: %.1f°, Range: %.0f km)",
+					pass.TCA.Time.Format("Jan 02 15:04:05"), pass.TCA.Azimuth, pass.TCA.Elevation, pass.TCA.Range)
+				log.Printf("    LOS: %s (Az: %.1f°, El: %.1f°)",
+					pass.LOS.Time.Format("Jan 02 15:04:05"), pass.LOS.Azimuth, pass.LOS.Elevation)
+				if pass.Visible {
+					log.Printf("    Duration: %.0fs, visible %s-%s",
+						pass.DurationSeconds, pass.VisibleStart.Format("15:04:05"), pass.VisibleEnd.Format("15:04:05"))
+				} else {
+					log.Printf("    Duration: %.0fs", pass.DurationSeconds)
+				}
+			}
+			fmt.Println()
+		}
+
+		if *icalPath != "" {
+			f, err := os.Create(*icalPath)
+			if err != nil {
+				log.Fatalf("Failed to create calendar: %v", err)
+			}
+			if err := satellite.WritePassCalendar(f, passes, now); err != nil {
+				f.Close()
+				log.Fatalf("Failed to write calendar: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				log.Fatalf("Failed to write calendar: %v", err)
 			}
+			log.Printf("Wrote %d passes to %s", len(passes), *icalPath)
+		}
+	}
+
+	// List what is overhead now
+	var overhead []satellite.SatelliteAbove
+	if *showOverhead {
+		if catalog == nil {
+			log.Fatalf("-overhead requires -tle-file")
+		}
+		overhead = satellite.SatellitesAbove(catalog, observer, now, *minElevation)
+		if !*outputJSON {
+			log.Printf("Satellites above %.0f° now: %d", *minElevation, len(overhead))
+			for _, sat := range overhead {
+				log.Printf("  %-6d %-24s Az %5.1f°  El %4.1f°  Range %6.0f km",
+					sat.SatelliteID, sat.Name, sat.Azimuth, sat.Elevation, sat.Range)
+			}
+			fmt.Println()
 		}
 	}
 
 	// Propagate ground track
 	log.Printf("Computing %d-minute ground track...", *duration)
-	positions := propagator.PropagateRange(now, time.Duration(*duration)*time.Minute, time.Minute)
+	positions, err := propagator.PropagateRange(now, time.Duration(*duration)*time.Minute, time.Minute)
+	if err != nil {
+		log.Printf("Ground track truncated after %d points: %v", len(positions), err)
+	}
 
 	if *outputJSON {
 		output := struct {
@@ -121,12 +213,16 @@ func main() {
 			NoradID    int                            `json:"norad_id"`
 			TLE        *satellite.TLE                 `json:"tle"`
 			Positions  []satellite.PropagatedPosition `json:"positions"`
+			Passes     []satellite.Pass               `json:"passes,omitempty"`
+			Overhead   []satellite.SatelliteAbove     `json:"overhead,omitempty"`
 			ComputedAt string                         `json:"computed_at"`
 		}{
 			Satellite:  tle.Name,
 			NoradID:    *noradID,
 			TLE:        tle,
 			Positions:  positions,
+			Passes:     passes,
+			Overhead:   overhead,
 			ComputedAt: now.Format(time.RFC3339),
 		}
 		enc := json.NewEncoder(os.Stdout)
@@ -5,7 +5,12 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -15,6 +20,7 @@ import (
 
 	"github.com/asgard/pandora/internal/platform/db"
 	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
 )
 
 func main() {
@@ -27,6 +33,26 @@ func main() {
 	initialBattery := flag.Float64("battery", 100.0, "Initial battery percentage (0-100)")
 	rlModel := flag.String("rl-model", "models/rl_router.json", "Path to RL routing model")
 	useRL := flag.Bool("rl", false, "Enable RL-based routing policy")
+	rlLearn := flag.Bool("rl-learn", false, "Update the RL model online from delivery status reports")
+	rlExploration := flag.Float64("rl-exploration", dtn.DefaultRLLearningConfig().Exploration, "Probability of exploring a random next hop while learning")
+	rlCheckpointDir := flag.String("rl-checkpoint-dir", "models/checkpoints", "Directory for versioned RL model checkpoints")
+	rlCheckpointEvery := flag.Duration("rl-checkpoint-every", time.Hour, "Interval between RL model checkpoints while learning")
+	codecName := flag.String("codec", "", "Bundle wire format: legacy or cbor (RFC 9171); defaults to legacy for tcp and cbor for the other transports")
+	transportName := flag.String("transport", "tcp", "Convergence layer: tcp, tcpcl (RFC 9174), udp (RFC 7122) or ltp (RFC 5326)")
+	ltpOWLT := flag.Duration("ltp-owlt", time.Second, "One-way light time used for LTP retransmission timers")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate for TCPCL sessions")
+	tlsKey := flag.String("tls-key", "", "TLS private key for TCPCL sessions")
+	tlsCA := flag.String("tls-ca", "", "CA bundle used to verify TCPCL peers")
+	requireTLS := flag.Bool("require-tls", false, "Reject TCPCL sessions that do not negotiate TLS")
+	bpsecKeys := flag.String("bpsec-keys", "", "JSON file of BPSec keys per security source and destination")
+	bpsecMode := flag.String("bpsec-mode", "none", "BPSec protection for originated bundles: none, sign or encrypt")
+	bpsecRequire := flag.Bool("bpsec-require", false, "Reject delivered bundles without BPSec blocks")
+	bpsecQuarantine := flag.Bool("bpsec-quarantine", false, "Quarantine bundles failing BPSec checks instead of dropping them")
+	contactPlan := flag.String("contact-plan", "", "ION-style contact plan file for contact graph routing")
+	storageDir := flag.String("storage-dir", "", "Directory for crash-safe file-backed bundle storage")
+	nysusURL := flag.String("nysus-url", "", "Nysus API base URL to follow the ground station pass plan from (e.g. http://nysus:8080)")
+	noradID := flag.Int("norad-id", 0, "NORAD ID of this satellite node, whose backlog is reported to Nysus (0 = ground node)")
+	passPlanEvery := flag.Duration("passplan-every", dtn.DefaultPassPlanClientConfig("").Interval, "Interval between pass plan updates from Nysus")
 	flag.Parse()
 
 	if *nodeID == "" || *nodeEID == "" {
@@ -41,43 +67,126 @@ func main() {
 	log.Printf("Energy Aware: %v", *energyAware)
 	log.Printf("RL Routing: %v", *useRL)
 
+	transportKind := strings.ToLower(*transportName)
+	if *codecName == "" && transportKind != "tcp" {
+		*codecName = "cbor"
+	}
+	codec, err := bundle.CodecByName(*codecName)
+	if err != nil {
+		log.Fatalf("Invalid codec: %v", err)
+	}
+	log.Printf("Bundle Codec: %s", codec.Name())
+
 	// Create storage
-	storage, cleanup := buildStorage()
+	storage, cleanup := buildStorage(*storageDir)
 	defer cleanup()
 
 	// Create router
 	var router dtn.Router
+	var cgr *dtn.ContactGraphRouter
+	var planContacts []dtn.Contact
+	var learner *dtn.RLLearner
 	if *useRL {
-		rlRouter, err := dtn.NewRLRoutingAgent(*nodeEID, *rlModel)
+		model, err := dtn.LoadRLRoutingModel(*rlModel)
 		if err != nil {
 			log.Fatalf("Failed to load RL model: %v", err)
 		}
+		rlRouter := dtn.NewRLRoutingAgentWithModel(*nodeEID, model)
 		rlRouter.UpdateEnergy(*nodeID, *initialBattery)
+		if *rlLearn {
+			learnConfig := dtn.DefaultRLLearningConfig()
+			learnConfig.Exploration = *rlExploration
+			learnConfig.Seed = time.Now().UnixNano()
+			learner = dtn.NewRLLearner(model, learnConfig)
+			rlRouter.SetLearner(learner)
+			log.Printf("RL Learning: exploration=%.2f checkpoints=%s every %s", *rlExploration, *rlCheckpointDir, *rlCheckpointEvery)
+		}
 		router = rlRouter
-	} else if *energyAware {
-		energyRouter := dtn.NewEnergyAwareRouter(*nodeEID)
-		energyRouter.UpdateEnergy(*nodeID, *initialBattery)
-		router = energyRouter
 	} else {
-		router = dtn.NewContactGraphRouter(*nodeEID)
+		if *energyAware {
+			energyRouter := dtn.NewEnergyAwareRouter(*nodeEID)
+			energyRouter.UpdateEnergy(*nodeID, *initialBattery)
+			cgr = energyRouter.ContactGraphRouter
+			router = energyRouter
+		} else {
+			cgr = dtn.NewContactGraphRouter(*nodeEID)
+			router = cgr
+		}
+
+		if *contactPlan != "" {
+			planContacts, err = dtn.LoadContactPlan(*contactPlan, time.Now().UTC())
+			if err != nil {
+				log.Fatalf("Failed to load contact plan: %v", err)
+			}
+			cgr.SetContactPlan(planContacts)
+			log.Printf("Contact Plan: %d contacts from %s", len(planContacts), *contactPlan)
+		}
 	}
 
 	// Create node configuration
-	config := dtn.NodeConfig{
-		BufferSize:     *bufferSize,
-		ProcessTimeout: 30 * time.Second,
-		MaxRetries:     3,
-		PurgeInterval:  5 * time.Minute,
-	}
+	config := dtn.DefaultNodeConfig()
+	config.BufferSize = *bufferSize
 
 	// Initialize transport
-	transportConfig := dtn.DefaultTCPTransportConfig()
-	transportConfig.ListenAddress = *listenAddr
-	transport := dtn.NewTCPTransport(*nodeID, transportConfig)
+	var transport dtn.TransportAdapter
+	switch transportKind {
+	case "tcpcl":
+		tcpclConfig := dtn.DefaultTCPCLConfig()
+		tcpclConfig.ListenAddress = *listenAddr
+		tcpclConfig.RequireTLS = *requireTLS
+		tcpclConfig.Codec = codec
+		if *tlsCert != "" {
+			tlsConfig, err := loadTLSConfig(*tlsCert, *tlsKey, *tlsCA)
+			if err != nil {
+				log.Fatalf("Failed to load TLS configuration: %v", err)
+			}
+			tcpclConfig.TLSConfig = tlsConfig
+		}
+		transport = dtn.NewTCPCLTransport(*nodeEID, tcpclConfig)
+	case "udp", "udpcl":
+		udpConfig := dtn.DefaultUDPCLConfig()
+		udpConfig.ListenAddress = *listenAddr
+		udpConfig.Codec = codec
+		transport = dtn.NewUDPCLTransport(*nodeEID, udpConfig)
+	case "ltp":
+		ltpConfig := dtn.DefaultLTPConfig()
+		ltpConfig.ListenAddress = *listenAddr
+		ltpConfig.OWLT = *ltpOWLT
+		ltpConfig.Codec = codec
+		transport = dtn.NewLTPTransport(*nodeEID, ltpConfig)
+	case "tcp":
+		transportConfig := dtn.DefaultTCPTransportConfig()
+		transportConfig.ListenAddress = *listenAddr
+		transportConfig.Codec = codec
+		transport = dtn.NewTCPTransport(*nodeID, transportConfig)
+	default:
+		log.Fatalf("Unknown transport: %s", *transportName)
+	}
+	log.Printf("Transport: %s", *transportName)
 
 	// Create and start node with transport
 	node := dtn.NewNodeWithTransport(*nodeID, *nodeEID, storage, router, transport, config)
 
+	if *bpsecKeys != "" {
+		policy, err := loadSecurityPolicy(*bpsecKeys, *bpsecMode, *bpsecRequire, *bpsecQuarantine)
+		if err != nil {
+			log.Fatalf("Failed to load BPSec configuration: %v", err)
+		}
+		if codec.Name() == "legacy" {
+			log.Printf("Warning: BPSec blocks require the cbor codec; legacy encoding drops them")
+		}
+		node.SetSecurityPolicy(policy)
+		log.Printf("BPSec: mode=%s require=%v quarantine=%v", *bpsecMode, *bpsecRequire, *bpsecQuarantine)
+	}
+
+	if learner != nil {
+		// Delivery and deletion reports for bundles this node routed train the model
+		node.SetStatusReportHandler(func(report *bundle.StatusReport) {
+			learner.RewardStatusReport(report)
+		})
+		go checkpointRLModel(learner, *rlCheckpointDir, *rlCheckpointEvery)
+	}
+
 	if err := node.Start(); err != nil {
 		log.Fatalf("Failed to start node: %v", err)
 	}
@@ -92,6 +201,35 @@ func main() {
 		registerNeighbors(context.Background(), node, transport, neighbors)
 	}
 
+	// Follow the ground station pass plan published by Nysus
+	planCtx, stopPlan := context.WithCancel(context.Background())
+	defer stopPlan()
+	if *nysusURL != "" {
+		if cgr == nil {
+			log.Printf("Warning: pass plans need contact graph routing; ignoring -nysus-url with -rl")
+		} else {
+			planConfig := dtn.DefaultPassPlanClientConfig(*nysusURL)
+			planConfig.NoradID = *noradID
+			planConfig.Interval = *passPlanEvery
+			planClient := dtn.NewPassPlanClient(planConfig, cgr, storage)
+			planClient.SetStaticContacts(planContacts)
+			go planClient.Run(planCtx)
+
+			// Fitted orbits downlinked by satellites refine the Nysus pass predictions
+			node.SetDeliveryHandler(func(b *bundle.Bundle) {
+				if !dtn.IsOrbitDetermination(b.Payload) {
+					return
+				}
+				go func() {
+					if err := planClient.ReportOrbit(planCtx, b.Payload); err != nil {
+						log.Printf("Failed to forward orbit determination: %v", err)
+					}
+				}()
+			})
+			log.Printf("Pass Plan: following %s every %s", *nysusURL, *passPlanEvery)
+		}
+	}
+
 	// Start telemetry reporter
 	go reportTelemetry(node)
 
@@ -104,9 +242,28 @@ func main() {
 	if err := node.Stop(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
+	if learner != nil {
+		if _, err := learner.Checkpoint(*rlCheckpointDir); err != nil {
+			log.Printf("Failed to checkpoint RL model: %v", err)
+		}
+	}
 	log.Println("Sat_Net router stopped")
 }
 
+// checkpointRLModel periodically saves the online-learned RL model.
+func checkpointRLModel(learner *dtn.RLLearner, dir string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := learner.Checkpoint(dir); err != nil {
+			log.Printf("Failed to checkpoint RL model: %v", err)
+		}
+	}
+}
+
 type neighborConfig struct {
 	id        string
 	eid       string
@@ -144,12 +301,26 @@ func parseNeighborConfig(raw string) []neighborConfig {
 	return neighbors
 }
 
-func buildStorage() (dtn.BundleStorage, func()) {
+func buildStorage(storageDir string) (dtn.BundleStorage, func()) {
 	backend := strings.ToLower(strings.TrimSpace(os.Getenv("DTN_STORAGE_BACKEND")))
+	if storageDir != "" {
+		backend = "file"
+	}
 	if backend == "" {
 		backend = "memory"
 	}
 	switch backend {
+	case "file":
+		if storageDir == "" {
+			storageDir = os.Getenv("DTN_STORAGE_DIR")
+		}
+		storage, err := dtn.NewFileBundleStorage(dtn.DefaultFileStorageConfig(storageDir))
+		if err != nil {
+			log.Fatalf("Failed to open file storage: %v", err)
+		}
+		count, _ := storage.Count(context.Background())
+		log.Printf("Using file-backed DTN storage in %s (%d bundles recovered)", storageDir, count)
+		return storage, func() { _ = storage.Close() }
 	case "postgres":
 		cfg, err := db.LoadConfig()
 		if err != nil {
@@ -171,7 +342,80 @@ func buildStorage() (dtn.BundleStorage, func()) {
 	}
 }
 
-func registerNeighbors(ctx context.Context, node *dtn.Node, transport *dtn.TCPTransport, neighbors []neighborConfig) {
+// loadTLSConfig builds a mutual-TLS configuration for TCPCL sessions.
+func loadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// securityKeyEntry is one key in the -bpsec-keys file. Source and
+// destination may be "*" to match any endpoint.
+type securityKeyEntry struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Key         string `json:"key"` // Hex-encoded
+}
+
+// loadSecurityPolicy builds a BPSec policy from a key file and flags.
+func loadSecurityPolicy(path, mode string, require, quarantine bool) (*dtn.SecurityPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	var entries []securityKeyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse key file: %w", err)
+	}
+
+	keys := bundle.NewKeyRing()
+	for _, entry := range entries {
+		key, err := hex.DecodeString(entry.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key for %s -> %s: %w", entry.Source, entry.Destination, err)
+		}
+		keys.SetKey(entry.Source, entry.Destination, key)
+	}
+
+	policy := &dtn.SecurityPolicy{Keys: keys, RequireSecurity: require}
+	switch strings.ToLower(mode) {
+	case "", "none":
+	case "sign":
+		policy.SignOutbound = true
+	case "encrypt":
+		policy.EncryptOutbound = true
+	default:
+		return nil, fmt.Errorf("unknown BPSec mode: %s", mode)
+	}
+	if quarantine {
+		policy.FailureAction = dtn.SecurityActionQuarantine
+	}
+	return policy, nil
+}
+
+func registerNeighbors(ctx context.Context, node *dtn.Node, transport dtn.TransportAdapter, neighbors []neighborConfig) {
 	for _, n := range neighbors {
 		neighbor := &dtn.Neighbor{
 			ID:           n.id,
@@ -5,12 +5,14 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"image/jpeg"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -20,12 +22,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/asgard/pandora/internal/orbital/geolocation"
 	"github.com/asgard/pandora/internal/orbital/hal"
+	"github.com/asgard/pandora/internal/orbital/od"
 	"github.com/asgard/pandora/internal/orbital/tracking"
 	"github.com/asgard/pandora/internal/orbital/vision"
 	"github.com/asgard/pandora/internal/platform/db"
 	"github.com/asgard/pandora/internal/platform/dtn"
 	"github.com/asgard/pandora/internal/platform/observability"
+	"github.com/asgard/pandora/internal/platform/satellite"
 	"github.com/asgard/pandora/pkg/bundle"
 )
 
@@ -185,6 +190,21 @@ func main() {
 		},
 	)
 
+	resolution := getEnvDefault("CAMERA_RESOLUTION", "1920x1080")
+	if bypassHardware {
+		resolution = "640x480" // Mock frames
+	}
+	if geolocator, err := buildGeolocator(gpsCtrl, resolution); err != nil {
+		log.Printf("Detection geolocation disabled: %v", err)
+	} else {
+		tracker.SetGeolocator(geolocator)
+	}
+
+	// Ground acknowledgements drive the alert lifecycle
+	satnetNode.SetDeliveryHandler(func(b *bundle.Bundle) {
+		handleAlertAck(b, tracker, satnetNode, *alertEID)
+	})
+
 	// Start alert processor
 	go processAlerts(ctx, alertChan, satnetNode, *alertEID)
 
@@ -194,6 +214,17 @@ func main() {
 	// Start telemetry loop
 	go runTelemetryLoop(ctx, *satelliteID, powerCtrl, gpsCtrl, satnetNode, *telemetryEID)
 
+	// Orbit determination from the onboard GPS receiver, when one is fitted
+	if gpsAddr := os.Getenv("GPS_RECEIVER_ADDR"); gpsAddr != "" {
+		receiver, err := startGPSReceiver(ctx, *satelliteID, gpsAddr)
+		if err != nil {
+			log.Printf("Orbit determination disabled: %v", err)
+		} else {
+			defer receiver.Shutdown()
+			go runOrbitDetermination(ctx, *satelliteID, receiver, satnetNode, *telemetryEID)
+		}
+	}
+
 	metricsServer := startMetricsServer(*metricsAddr)
 
 	// Wait for shutdown signal
@@ -237,10 +268,11 @@ func runVisionLoop(ctx context.Context, camera hal.CameraController, visionProc
 				for _, det := range detections {
 					log.Printf("  - %s (%.2f confidence)", det.Class, det.Confidence)
 				}
-
-				tracker.ProcessDetections(ctx, detections)
 			}
 
+			// Every frame, so that tracks age through missed detections
+			tracker.ProcessDetections(ctx, detections)
+
 		case <-ctx.Done():
 			return
 		}
@@ -255,11 +287,13 @@ func processAlerts(ctx context.Context, alertChan <-chan tracking.Alert, node *d
 		case alert := <-alertChan:
 			payload := alertPayload{
 				ID:         alert.ID.String(),
+				TrackID:    alert.TrackID.String(),
 				Type:       alert.Type,
 				Confidence: alert.Confidence,
 				Location:   alert.Location,
 				Timestamp:  alert.Timestamp.Format(time.RFC3339Nano),
 				VideoClip:  base64.StdEncoding.EncodeToString(alert.VideoClip),
+				Footprint:  alert.Footprint,
 			}
 
 			data, err := json.Marshal(payload)
@@ -278,6 +312,90 @@ func processAlerts(ctx context.Context, alertChan <-chan tracking.Alert, node *d
 	}
 }
 
+// buildGeolocator projects detections through the imager onto the terrain.
+// The imager is assumed boresighted on the body +Z axis of a
+// nadir-pointing bus; CAMERA_HFOV_DEG sets its field of view and DEM_PATH
+// an optional Esri ASCII elevation grid.
+func buildGeolocator(gpsCtrl hal.GPSController, resolution string) (tracking.Geolocator, error) {
+	width, height, err := parseResolution(resolution)
+	if err != nil {
+		return nil, err
+	}
+	hfov, err := strconv.ParseFloat(getEnvDefault("CAMERA_HFOV_DEG", "10"), 64)
+	if err != nil || hfov <= 0 || hfov >= 180 {
+		return nil, fmt.Errorf("invalid CAMERA_HFOV_DEG %q", os.Getenv("CAMERA_HFOV_DEG"))
+	}
+
+	var terrain geolocation.TerrainModel
+	if demPath := os.Getenv("DEM_PATH"); demPath != "" {
+		grid, err := geolocation.LoadASCIIGrid(demPath)
+		if err != nil {
+			return nil, fmt.Errorf("load DEM: %w", err)
+		}
+		// Fall back to the ellipsoid outside the DEM
+		terrain = geolocation.LayeredTerrain{grid, geolocation.EllipsoidHeight(0)}
+	}
+	projector := geolocation.NewProjector(geolocation.CameraFromFOV(width, height, hfov), terrain)
+
+	return func(ctx context.Context, det vision.Detection) (*geolocation.Footprint, error) {
+		lat, lon, alt, err := gpsCtrl.GetPosition()
+		if err != nil {
+			return nil, err
+		}
+		ve, vn, vu, err := gpsCtrl.GetVelocity()
+		if err != nil {
+			return nil, err
+		}
+		pose := geolocation.PoseFromGeodetic(time.Now().UTC(), lat, lon, alt, [3]float64{ve, vn, vu})
+		return projector.Project(pose, det.BoundingBox)
+	}, nil
+}
+
+func parseResolution(value string) (int, int, error) {
+	parts := strings.SplitN(strings.ToLower(value), "x", 2)
+	if len(parts) == 2 {
+		w, errW := strconv.Atoi(strings.TrimSpace(parts[0]))
+		h, errH := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if errW == nil && errH == nil && w > 0 && h > 0 {
+			return w, h, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid CAMERA_RESOLUTION %q", value)
+}
+
+// handleAlertAck applies an alert acknowledgement from the ground and
+// reports the resulting status back, so the ground sees which
+// acknowledgements reached the satellite.
+func handleAlertAck(b *bundle.Bundle, tracker *tracking.Tracker, node *dtn.Node, alertEID string) {
+	ack, err := tracking.DecodeAck(b.Payload)
+	if err != nil {
+		log.Printf("Ignoring bundle from %s: %v", b.SourceEID, err)
+		return
+	}
+	alert, err := tracker.HandleAck(ack)
+	if err != nil {
+		log.Printf("Alert acknowledgement rejected: %v", err)
+		if errors.Is(err, tracking.ErrUnknownAlert) {
+			return
+		}
+	}
+
+	data, err := json.Marshal(alertStatusPayload{
+		Type:      "alert_status",
+		ID:        alert.ID.String(),
+		Status:    string(alert.Status),
+		Operator:  alert.Operator,
+		Timestamp: alert.UpdatedAt.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		log.Printf("Failed to serialize alert status: %v", err)
+		return
+	}
+	if err := node.CreateBundle(alertEID, data, bundle.PriorityNormal); err != nil {
+		log.Printf("Failed to send alert status bundle: %v", err)
+	}
+}
+
 func runTelemetryLoop(ctx context.Context, satelliteID string, powerCtrl hal.PowerController, gpsCtrl hal.GPSController, node *dtn.Node, telemetryEID string) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
@@ -322,6 +440,70 @@ func runTelemetryLoop(ctx context.Context, satelliteID string, powerCtrl hal.Pow
 	}
 }
 
+func startGPSReceiver(ctx context.Context, satelliteID, address string) (*hal.SpaceGPSController, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GPS_RECEIVER_ADDR %q: %w", address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GPS receiver port %q: %w", portStr, err)
+	}
+	receiver := hal.NewSpaceGPSController(hal.GPSConfig{
+		Protocol:    getEnvDefault("GPS_PROTOCOL", "nmea"),
+		Address:     host,
+		Port:        port,
+		SpaceMode:   true,
+		SatelliteID: satelliteID,
+	})
+	if err := receiver.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	return receiver, nil
+}
+
+// runOrbitDetermination collects GPS fixes and downlinks a fitted orbit
+// every half hour, so ground predictions do not depend on public TLEs.
+func runOrbitDetermination(ctx context.Context, satelliteID string, receiver *hal.SpaceGPSController, node *dtn.Node, telemetryEID string) {
+	collector := od.NewFixCollector(receiver, od.DefaultFixConfig(), 720) // Two hours at 10 s
+	go collector.Run(ctx, 10*time.Second)
+
+	noradID, _ := strconv.Atoi(os.Getenv("SILENUS_NORAD_ID"))
+	determiner := od.NewDeterminer(satellite.OMM{
+		ObjectName: "SILENUS-" + strings.ToUpper(satelliteID),
+		NoradCatID: noradID,
+	}, od.DefaultConfig())
+
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			estimate, err := determiner.Determine(collector.Observations())
+			if err != nil {
+				log.Printf("Orbit determination failed: %v", err)
+				continue
+			}
+			data, err := json.Marshal(orbitPayload{
+				Type:        "orbit_determination",
+				SatelliteID: satelliteID,
+				Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+				Estimate:    estimate,
+			})
+			if err != nil {
+				log.Printf("Failed to serialize orbit estimate: %v", err)
+				continue
+			}
+			if err := node.CreateBundle(telemetryEID, data, bundle.PriorityNormal); err != nil {
+				log.Printf("Failed to send orbit estimate bundle: %v", err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 type mockCamera struct {
 	satelliteID string
 	stopChan    chan struct{}
@@ -414,7 +596,7 @@ type mockGPSController struct{}
 
 func newMockGPSController() *mockGPSController { return &mockGPSController{} }
 func (m *mockGPSController) GetPosition() (lat, lon, alt float64, err error) {
-	return 37.7749, -122.4194, 408000.0, nil
+	return 37.7749, -122.4194, 408.0, nil // km, like the real providers
 }
 func (m *mockGPSController) GetTime() (time.Time, error) {
 	return time.Now().UTC(), nil
@@ -627,11 +809,22 @@ type clipPayload struct {
 
 type alertPayload struct {
 	ID         string  `json:"id"`
+	TrackID    string  `json:"track_id"`
 	Type       string  `json:"type"`
 	Confidence float64 `json:"confidence"`
 	Location   string  `json:"location"`
 	Timestamp  string  `json:"timestamp"`
 	VideoClip  string  `json:"video_clip_base64"`
+
+	Footprint *geolocation.Footprint `json:"footprint,omitempty"`
+}
+
+type alertStatusPayload struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Operator  string `json:"operator,omitempty"`
+	Timestamp string `json:"timestamp"`
 }
 
 type telemetryPayload struct {
@@ -646,6 +839,13 @@ type telemetryPayload struct {
 	Altitude    float64 `json:"alt"`
 }
 
+type orbitPayload struct {
+	Type        string       `json:"type"`
+	SatelliteID string       `json:"satellite_id"`
+	Timestamp   string       `json:"timestamp"`
+	Estimate    *od.Estimate `json:"estimate"`
+}
+
 func registerSatNetNeighbor(node *dtn.Node, transport *dtn.TCPTransport, address string) {
 	neighbor := &dtn.Neighbor{
 		ID:           "satnet_gateway",
@@ -3,6 +3,7 @@ package controlplane
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ const (
 	DomainAutonomy EventDomain = "autonomy"
 	DomainEthics   EventDomain = "ethics"
 	DomainControl  EventDomain = "controlplane"
+	DomainSpace    EventDomain = "space"
 )
 
 // CrossDomainEventType categorizes cross-domain events.
@@ -50,6 +52,9 @@ const (
 	EventControlCommand  CrossDomainEventType = "control.command"
 	EventControlResponse CrossDomainEventType = "control.response"
 	EventControlAlert    CrossDomainEventType = "control.alert"
+
+	// Space situational awareness events
+	EventSpaceConjunction CrossDomainEventType = "space.conjunction"
 )
 
 // Severity levels for cross-domain events.
@@ -162,6 +167,18 @@ type EthicsDecisionEvent struct {
 	Timeout       time.Duration `json:"timeout_ns,omitempty"`
 }
 
+// ConjunctionEvent warns of a close approach between a tracked satellite
+// and another catalog object.
+type ConjunctionEvent struct {
+	CrossDomainEvent
+	PrimaryID    int       `json:"primary_id"`
+	SecondaryID  int       `json:"secondary_id"`
+	TCA          time.Time `json:"tca"`
+	MissDistance float64   `json:"miss_distance_km"`
+	Probability  float64   `json:"probability"`
+	MessageID    string    `json:"message_id,omitempty"` // CDM MESSAGE_ID
+}
+
 // GeoLocation represents geographic coordinates.
 type GeoLocation struct {
 	Latitude  float64 `json:"latitude"`
@@ -267,6 +284,51 @@ func NewDTNBundleEvent(
 	}
 }
 
+// NewConjunctionEvent creates a conjunction event. Severity follows the
+// collision probability; the fields are copied into the payload so they
+// survive publishing as a plain CrossDomainEvent.
+func NewConjunctionEvent(
+	source string,
+	primaryID int,
+	secondaryID int,
+	tca time.Time,
+	missDistance float64,
+	probability float64,
+) ConjunctionEvent {
+	severity := SeverityLow
+	if probability >= 1e-3 {
+		severity = SeverityCritical
+	} else if probability >= 1e-4 {
+		severity = SeverityHigh
+	} else if probability >= 1e-5 {
+		severity = SeverityMedium
+	}
+
+	base := NewCrossDomainEvent(
+		EventSpaceConjunction,
+		DomainSpace,
+		source,
+		severity,
+		fmt.Sprintf("Conjunction between %d and %d at %s, miss %.3f km, Pc %.2e",
+			primaryID, secondaryID, tca.UTC().Format(time.RFC3339), missDistance, probability),
+	)
+	base.RequiresAck = severity == SeverityCritical || severity == SeverityHigh
+	base.Payload["primary_id"] = primaryID
+	base.Payload["secondary_id"] = secondaryID
+	base.Payload["tca"] = tca.UTC().Format(time.RFC3339Nano)
+	base.Payload["miss_distance_km"] = missDistance
+	base.Payload["probability"] = probability
+
+	return ConjunctionEvent{
+		CrossDomainEvent: base,
+		PrimaryID:        primaryID,
+		SecondaryID:      secondaryID,
+		TCA:              tca,
+		MissDistance:     missDistance,
+		Probability:      probability,
+	}
+}
+
 // NewDTNCongestionEvent creates a new DTN congestion event.
 func NewDTNCongestionEvent(
 	nodeID string,
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+)
+
+// handlePassPlan returns the ground station pass plan. POST replans from
+// now before answering.
+func (s *Server) handlePassPlan(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		plan := s.passScheduler.Plan()
+		if plan == nil {
+			s.writeError(w, http.StatusServiceUnavailable, "Pass plan not yet available", "PLAN_UNAVAILABLE")
+			return
+		}
+		s.writeJSON(w, http.StatusOK, plan)
+
+	case http.MethodPost:
+		plan, err := s.passScheduler.Schedule(r.Context(), time.Now())
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), "PLAN_FAILED")
+			return
+		}
+		s.writeJSON(w, http.StatusOK, plan)
+
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED")
+	}
+}
+
+// handleBacklog accepts the backlog a satellite's DTN node reports, which
+// the next pass plan uses to share out antenna time.
+func (s *Server) handleBacklog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	var report dtn.BacklogReport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&report); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if report.NoradID <= 0 {
+		s.writeError(w, http.StatusBadRequest, "norad_id is required", "INVALID_REQUEST")
+		return
+	}
+
+	s.passScheduler.ReportBacklog(report.NoradID, report.Backlog)
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "accepted"})
+}
+
+// handleOrbit accepts orbit determination telemetry forwarded by a ground
+// DTN node and loads the fitted elements into the pass scheduler, so pass
+// predictions follow the satellite's own GPS solution.
+func (s *Server) handleOrbit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+	tle, err := dtn.ParseOrbitDetermination(payload)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), "INVALID_REQUEST")
+		return
+	}
+	if err := s.passScheduler.LoadTLE(tle.SatelliteID, tle); err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, err.Error(), "INVALID_ELEMENTS")
+		return
+	}
+
+	log.Printf("[Nysus] Loaded fitted orbit for NORAD %d (epoch %s)", tle.SatelliteID, tle.Epoch.Format(time.RFC3339))
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "accepted"})
+}
+
+// loadPassSchedulerConfig builds the contact predictor and pass scheduler
+// configuration. DTN_GROUND_STATIONS_JSON replaces the default stations,
+// including their antenna counts and slew rates, and DTN_SLEW_RATE sets the
+// slew rate in degrees/second for stations that do not give one.
+func loadPassSchedulerConfig() (dtn.ContactPredictorConfig, dtn.PassSchedulerConfig, error) {
+	predictorCfg := dtn.DefaultContactPredictorConfig()
+	predictorCfg.N2YOAPIKey = os.Getenv("N2YO_API_KEY")
+	schedulerCfg := dtn.DefaultPassSchedulerConfig()
+
+	if raw := os.Getenv("DTN_GROUND_STATIONS_JSON"); raw != "" {
+		var stations []dtn.GroundStation
+		if err := json.Unmarshal([]byte(raw), &stations); err != nil {
+			return predictorCfg, schedulerCfg, fmt.Errorf("invalid DTN_GROUND_STATIONS_JSON: %w", err)
+		}
+		if len(stations) == 0 {
+			return predictorCfg, schedulerCfg, fmt.Errorf("DTN_GROUND_STATIONS_JSON lists no stations")
+		}
+		for _, gs := range stations {
+			if gs.ID == "" || gs.Antennas < 0 || gs.SlewRate < 0 {
+				return predictorCfg, schedulerCfg, fmt.Errorf("invalid ground station %q in DTN_GROUND_STATIONS_JSON", gs.ID)
+			}
+		}
+		predictorCfg.GroundStations = stations
+	}
+
+	if raw := os.Getenv("DTN_SLEW_RATE"); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate <= 0 {
+			return predictorCfg, schedulerCfg, fmt.Errorf("invalid DTN_SLEW_RATE %q", raw)
+		}
+		schedulerCfg.SlewRate = rate
+	}
+
+	return predictorCfg, schedulerCfg, nil
+}
+
+// newPassScheduler creates the pass scheduler from the environment, falling
+// back to the defaults if the configuration is invalid.
+func newPassScheduler() *dtn.PassScheduler {
+	predictorCfg, schedulerCfg, err := loadPassSchedulerConfig()
+	if err != nil {
+		log.Printf("[Nysus] %v (using default ground stations)", err)
+		predictorCfg = dtn.DefaultContactPredictorConfig()
+		predictorCfg.N2YOAPIKey = os.Getenv("N2YO_API_KEY")
+		schedulerCfg = dtn.DefaultPassSchedulerConfig()
+	}
+	log.Printf("[Nysus] Pass scheduler over %d ground stations", len(predictorCfg.GroundStations))
+	return dtn.NewPassScheduler(dtn.NewContactPredictor(predictorCfg), schedulerCfg)
+}
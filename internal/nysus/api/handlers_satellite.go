@@ -13,10 +13,15 @@ import (
 // SatelliteHandlers provides HTTP handlers for satellite tracking.
 type SatelliteHandlers struct {
 	trackingService *services.SatelliteTrackingService
+	catalog         *satellite.Catalog
 }
 
-// NewSatelliteHandlers creates satellite tracking handlers.
-func NewSatelliteHandlers(apiKey string) *SatelliteHandlers {
+// maxCatalogUpload bounds the size of a catalog import body.
+const maxCatalogUpload = 64 << 20
+
+// NewSatelliteHandlers creates satellite tracking handlers. The catalog
+// serves element set history and is preferred over fetching when fresh.
+func NewSatelliteHandlers(apiKey string, catalog *satellite.Catalog) *SatelliteHandlers {
 	cfg := services.DefaultTrackingConfig()
 	cfg.N2YOAPIKey = apiKey
 
@@ -24,6 +29,7 @@ func NewSatelliteHandlers(apiKey string) *SatelliteHandlers {
 
 	return &SatelliteHandlers{
 		trackingService: service,
+		catalog:         catalog,
 	}
 }
 
@@ -36,6 +42,10 @@ func (h *SatelliteHandlers) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/satellites/contacts", h.handleGetContactWindows)
 	mux.HandleFunc("/api/satellites/above", h.handleGetSatellitesAbove)
 	mux.HandleFunc("/api/satellites/tle", h.handleGetTLE)
+	mux.HandleFunc("/api/satellites/catalog", h.handleGetCatalog)
+	mux.HandleFunc("/api/satellites/catalog/import", h.handleImportCatalog)
+	mux.HandleFunc("/api/satellites/catalog/history", h.handleGetCatalogHistory)
+	mux.HandleFunc("/api/satellites/catalog/maneuvers", h.handleGetManeuvers)
 }
 
 // handleGetPosition returns propagated position for a satellite.
@@ -159,6 +169,14 @@ func (h *SatelliteHandlers) handleGetTLE(w http.ResponseWriter, r *http.Request)
 		noradID = satellite.NoradISS
 	}
 
+	if h.catalog != nil {
+		entry, err := h.catalog.Get(r.Context(), noradID, time.Now().UTC())
+		if err == nil && !entry.Stale {
+			h.writeJSON(w, http.StatusOK, entry.TLE())
+			return
+		}
+	}
+
 	cfg := satellite.DefaultConfig()
 	client := satellite.NewClient(cfg)
 
@@ -171,6 +189,129 @@ func (h *SatelliteHandlers) handleGetTLE(w http.ResponseWriter, r *http.Request)
 	h.writeJSON(w, http.StatusOK, tle)
 }
 
+// handleGetCatalog returns the newest element set of every catalog object.
+// With stale=true only objects whose elements are out of date are listed.
+func (h *SatelliteHandlers) handleGetCatalog(w http.ResponseWriter, r *http.Request) {
+	if h.catalog == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "catalog not configured")
+		return
+	}
+
+	now := time.Now().UTC()
+	var entries []satellite.CatalogEntry
+	var err error
+	if r.URL.Query().Get("stale") == "true" {
+		entries, err = h.catalog.Stale(r.Context(), now)
+	} else {
+		entries, err = h.catalog.Latest(r.Context(), now)
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count":     len(entries),
+		"objects":   entries,
+		"timestamp": now,
+	})
+}
+
+// handleImportCatalog imports a 3LE/TLE or CCSDS OMM (KVN, XML or JSON)
+// file posted as the request body. The format query parameter overrides
+// detection.
+func (h *SatelliteHandlers) handleImportCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.catalog == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "catalog not configured")
+		return
+	}
+
+	format := satellite.CatalogFormat(r.URL.Query().Get("format"))
+	body := http.MaxBytesReader(w, r.Body, maxCatalogUpload)
+	result, err := h.catalog.Import(r.Context(), body, format)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// handleGetCatalogHistory returns the stored epochs of one object.
+func (h *SatelliteHandlers) handleGetCatalogHistory(w http.ResponseWriter, r *http.Request) {
+	if h.catalog == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "catalog not configured")
+		return
+	}
+
+	noradID, err := strconv.Atoi(r.URL.Query().Get("norad_id"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "norad_id required")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 100
+	}
+
+	history, err := h.catalog.History(r.Context(), noradID, limit)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(history) == 0 {
+		h.writeError(w, http.StatusNotFound, satellite.ErrElementSetNotFound.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"norad_id":     noradID,
+		"count":        len(history),
+		"element_sets": history,
+	})
+}
+
+// handleGetManeuvers returns element sets flagged as maneuvers, by default
+// over the last 30 days.
+func (h *SatelliteHandlers) handleGetManeuvers(w http.ResponseWriter, r *http.Request) {
+	if h.catalog == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "catalog not configured")
+		return
+	}
+
+	since := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	if s := r.URL.Query().Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "since must be RFC 3339")
+			return
+		}
+		since = t
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 100
+	}
+
+	maneuvers, err := h.catalog.Maneuvers(r.Context(), since, limit)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"since":     since,
+		"count":     len(maneuvers),
+		"maneuvers": maneuvers,
+	})
+}
+
 func (h *SatelliteHandlers) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -15,8 +15,10 @@ import (
 	"github.com/asgard/pandora/internal/api/webrtc"
 	"github.com/asgard/pandora/internal/nysus/events"
 	"github.com/asgard/pandora/internal/platform/db"
+	"github.com/asgard/pandora/internal/platform/dtn"
 	"github.com/asgard/pandora/internal/platform/observability"
 	"github.com/asgard/pandora/internal/platform/realtime"
+	"github.com/asgard/pandora/internal/platform/satellite"
 	"github.com/asgard/pandora/internal/repositories"
 	"github.com/asgard/pandora/internal/services"
 	"github.com/golang-jwt/jwt/v5"
@@ -39,6 +41,8 @@ type Server struct {
 	chatStore         *chatStore
 	accessCodeService *services.AccessCodeService
 	accessCodeCancel  context.CancelFunc
+	tleCatalog        *satellite.Catalog
+	passScheduler     *dtn.PassScheduler
 }
 
 // Config holds server configuration.
@@ -89,7 +93,10 @@ func NewServer(cfg Config, pgDB *db.PostgresDB, mongoDB *db.MongoDB, eventBus *e
 
 	var streamService *services.StreamService
 	var accessCodeService *services.AccessCodeService
+	var catalogStore satellite.CatalogStore = satellite.NewMemoryCatalogStore()
 	if pgDB != nil {
+		catalogStore = services.NewTLECatalogStore(repositories.NewTLECatalogRepository(pgDB))
+
 		streamRepo := repositories.NewStreamRepository(pgDB, mongoDB)
 		streamService = services.NewStreamService(streamRepo)
 		streamService.SetSFU(sfu)
@@ -119,8 +126,11 @@ func NewServer(cfg Config, pgDB *db.PostgresDB, mongoDB *db.MongoDB, eventBus *e
 		streamService:     streamService,
 		chatStore:         newChatStore(pgDB),
 		accessCodeService: accessCodeService,
+		tleCatalog:        satellite.NewCatalog(catalogStore, satellite.DefaultCatalogConfig()),
 	}
 
+	s.passScheduler = newPassScheduler()
+
 	mux := http.NewServeMux()
 	s.registerRoutes(mux)
 
@@ -135,6 +145,17 @@ func NewServer(cfg Config, pgDB *db.PostgresDB, mongoDB *db.MongoDB, eventBus *e
 	return s
 }
 
+// TLECatalog returns the satellite catalog served under /api/satellites/catalog.
+func (s *Server) TLECatalog() *satellite.Catalog {
+	return s.tleCatalog
+}
+
+// PassScheduler returns the ground station scheduler served under
+// /api/dtn/passplan.
+func (s *Server) PassScheduler() *dtn.PassScheduler {
+	return s.passScheduler
+}
+
 // createWebRTCConfig creates the WebRTC configuration with ICE servers.
 func createWebRTCConfig() pionwebrtc.Configuration {
 	config := pionwebrtc.Configuration{
@@ -280,10 +301,15 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/hunoids", s.handleHunoids)
 	mux.HandleFunc("/api/threats", s.handleThreats)
 
-	// Satellite tracking (N2YO)
-	satelliteHandlers := NewSatelliteHandlers(os.Getenv("N2YO_API_KEY"))
+	// Satellite tracking (N2YO) and TLE catalog
+	satelliteHandlers := NewSatelliteHandlers(os.Getenv("N2YO_API_KEY"), s.tleCatalog)
 	satelliteHandlers.RegisterRoutes(mux)
 
+	// DTN ground station pass plan and the node backlogs that drive it
+	mux.HandleFunc("/api/dtn/passplan", s.handlePassPlan)
+	mux.HandleFunc("/api/dtn/backlog", s.handleBacklog)
+	mux.HandleFunc("/api/dtn/orbit", s.handleOrbit)
+
 	// Streams endpoints (for Hubs)
 	mux.HandleFunc("/api/streams", s.handleStreams)
 	mux.HandleFunc("/api/streams/stats", s.handleStreamStats)
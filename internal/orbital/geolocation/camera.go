@@ -0,0 +1,203 @@
+// Package geolocation projects Silenus detections from image pixels to
+// the ground. A detection's bounding box is cast through a pinhole camera
+// model, the satellite attitude and position, and intersected with a
+// terrain model to give a geodetic footprint with ground sample distance
+// and an error estimate.
+package geolocation
+
+import (
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// Camera is a pinhole camera with Brown–Conrady radial distortion. Camera
+// axes are +X along image columns, +Y along image rows and +Z out along
+// the boresight.
+type Camera struct {
+	Width        int     `json:"width"`  // pixels
+	Height       int     `json:"height"` // pixels
+	FocalLengthX float64 `json:"fx"`     // pixels
+	FocalLengthY float64 `json:"fy"`     // pixels
+	PrincipalX   float64 `json:"cx"`     // pixels
+	PrincipalY   float64 `json:"cy"`     // pixels
+	K1           float64 `json:"k1,omitempty"`
+	K2           float64 `json:"k2,omitempty"`
+
+	// Mount rotates camera axes into satellite body axes. The zero value
+	// mounts the camera with its axes on the body axes, looking along +Z.
+	Mount frames.Matrix `json:"mount"`
+}
+
+// CameraFromFOV returns an undistorted camera with square pixels and the
+// principal point at the image centre.
+func CameraFromFOV(width, height int, horizontalFOV float64) Camera {
+	f := float64(width) / 2 / math.Tan(horizontalFOV*math.Pi/360)
+	return Camera{
+		Width:        width,
+		Height:       height,
+		FocalLengthX: f,
+		FocalLengthY: f,
+		PrincipalX:   float64(width) / 2,
+		PrincipalY:   float64(height) / 2,
+	}
+}
+
+// Ray returns the unit line of sight through a pixel in body axes. Pixel
+// coordinates are continuous, (0, 0) being the top-left corner of the
+// top-left pixel.
+func (c Camera) Ray(u, v float64) [3]float64 {
+	xd := (u - c.PrincipalX) / c.FocalLengthX
+	yd := (v - c.PrincipalY) / c.FocalLengthY
+
+	// Invert the radial distortion by fixed-point iteration
+	x, y := xd, yd
+	if c.K1 != 0 || c.K2 != 0 {
+		for i := 0; i < 10; i++ {
+			r2 := x*x + y*y
+			scale := 1 + c.K1*r2 + c.K2*r2*r2
+			x, y = xd/scale, yd/scale
+		}
+	}
+	return c.mount().MulVec(unit([3]float64{x, y, 1}))
+}
+
+// PixelAngle returns the angle one pixel subtends at the boresight, radians.
+func (c Camera) PixelAngle() float64 {
+	return 1 / math.Sqrt(c.FocalLengthX*c.FocalLengthY)
+}
+
+func (c Camera) mount() frames.Matrix {
+	if c.Mount == (frames.Matrix{}) {
+		return frames.Matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	}
+	return c.Mount
+}
+
+// Quaternion is an attitude quaternion with scalar part W.
+type Quaternion struct {
+	W float64 `json:"w"`
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Matrix returns the rotation the quaternion applies to column vectors.
+// For an attitude quaternion from body to a reference frame it maps body
+// vectors into that frame.
+func (q Quaternion) Matrix() frames.Matrix {
+	n := math.Sqrt(q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if n == 0 {
+		return frames.Matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	}
+	w, x, y, z := q.W/n, q.X/n, q.Y/n, q.Z/n
+	return frames.Matrix{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+// Pose is the satellite's Earth-fixed position, velocity and attitude at
+// the time an image was taken.
+type Pose struct {
+	Time     time.Time  `json:"time"`
+	Position [3]float64 `json:"position"` // ITRF, km
+	Velocity [3]float64 `json:"velocity"` // ITRF, km/s
+
+	// Attitude rotates body axes into ITRF. The zero value is nadir
+	// pointing, see NadirAttitude.
+	Attitude frames.Matrix `json:"attitude"`
+}
+
+// PoseFromInertial builds a pose from a GCRF state (km, km/s) and an
+// attitude quaternion from body to GCRF, as reported by the ADCS.
+func PoseFromInertial(t time.Time, r, v [3]float64, bodyToGCRF Quaternion, eop frames.EOP) Pose {
+	rITRF, vITRF := frames.GCRFToITRF(r, v, t, eop)
+	return Pose{
+		Time:     t,
+		Position: rITRF,
+		Velocity: vITRF,
+		Attitude: frames.GCRFToITRFMatrix(t, eop).Mul(bodyToGCRF.Matrix()),
+	}
+}
+
+// PoseFromGeodetic builds a nadir-pointing pose from a GPS-style fix:
+// geodetic position (degrees, km) and local east/north/up velocity (km/s).
+func PoseFromGeodetic(t time.Time, lat, lon, alt float64, enu [3]float64) Pose {
+	sinLat, cosLat := math.Sincos(lat * math.Pi / 180)
+	sinLon, cosLon := math.Sincos(lon * math.Pi / 180)
+	east := [3]float64{-sinLon, cosLon, 0}
+	north := [3]float64{-sinLat * cosLon, -sinLat * sinLon, cosLat}
+	up := [3]float64{cosLat * cosLon, cosLat * sinLon, sinLat}
+	var v [3]float64
+	for k := range v {
+		v[k] = enu[0]*east[k] + enu[1]*north[k] + enu[2]*up[k]
+	}
+	return Pose{
+		Time:     t,
+		Position: frames.GeodeticToECEF(frames.Geodetic{Latitude: lat, Longitude: lon, Altitude: alt}),
+		Velocity: v,
+	}
+}
+
+// NadirAttitude returns the body to ITRF rotation of a nadir-pointing
+// satellite: +Z along the geodetic nadir, +X along the ground track and
+// +Y completing the right-handed set. A satellite without velocity is
+// given +X due north.
+func NadirAttitude(r, v [3]float64) frames.Matrix {
+	g := frames.ECEFToGeodetic(r)
+	up := frames.GeodeticToECEF(frames.Geodetic{Latitude: g.Latitude, Longitude: g.Longitude, Altitude: 1})
+	down := frames.GeodeticToECEF(frames.Geodetic{Latitude: g.Latitude, Longitude: g.Longitude})
+	z := unit(sub(down, up))
+
+	forward := v
+	if norm(cross(z, forward)) < 1e-9*norm(forward) || norm(forward) == 0 {
+		sinLat, cosLat := math.Sincos(g.Latitude * math.Pi / 180)
+		sinLon, cosLon := math.Sincos(g.Longitude * math.Pi / 180)
+		forward = [3]float64{-sinLat * cosLon, -sinLat * sinLon, cosLat} // North
+	}
+	y := unit(cross(z, forward))
+	x := cross(y, z)
+	return frames.Matrix{
+		{x[0], y[0], z[0]},
+		{x[1], y[1], z[1]},
+		{x[2], y[2], z[2]},
+	}
+}
+
+func (p Pose) attitude() frames.Matrix {
+	if p.Attitude == (frames.Matrix{}) {
+		return NadirAttitude(p.Position, p.Velocity)
+	}
+	return p.Attitude
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func norm(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func unit(a [3]float64) [3]float64 {
+	n := norm(a)
+	if n == 0 {
+		return a
+	}
+	return [3]float64{a[0] / n, a[1] / n, a[2] / n}
+}
@@ -0,0 +1,59 @@
+package geolocation
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// NewFeatureCollection wraps features in a collection.
+func NewFeatureCollection(features []Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// Feature is a GeoJSON feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Coordinates hold a position for a
+// Point and a list of linear rings for a Polygon.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// Feature returns the footprint as a GeoJSON polygon. The ring is closed,
+// counter-clockwise and unwrapped so footprints straddling the antimeridian
+// stay contiguous. The footprint geometry is added to props.
+func (f *Footprint) Feature(id string, props map[string]interface{}) Feature {
+	if props == nil {
+		props = make(map[string]interface{})
+	}
+	props["time"] = f.Time
+	props["center"] = []float64{f.Center.Longitude, f.Center.Latitude}
+	props["gsd_m"] = f.GSD
+	props["uncertainty_m"] = f.Uncertainty
+	props["slant_range_m"] = f.SlantRange
+	props["off_nadir_deg"] = f.OffNadir
+
+	ring := make([][]float64, 0, len(f.Polygon)+1)
+	for _, p := range f.Polygon {
+		ring = append(ring, []float64{unwrap(p.Longitude, f.Center.Longitude), p.Latitude, p.Altitude})
+	}
+	if len(ring) > 0 {
+		ring = append(ring, ring[0])
+	}
+	return Feature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   Geometry{Type: "Polygon", Coordinates: [][][]float64{ring}},
+		Properties: props,
+	}
+}
@@ -0,0 +1,247 @@
+package geolocation
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/orbital/vision"
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// ErrNoIntersection is returned for lines of sight that miss the Earth.
+var ErrNoIntersection = fmt.Errorf("line of sight does not intersect the Earth")
+
+// ErrorBudget lists the knowledge errors a projection inherits, all 1-sigma.
+type ErrorBudget struct {
+	PositionSigma float64 // Satellite position, meters per axis
+	AttitudeSigma float64 // Pointing knowledge, degrees per axis
+	TimingSigma   float64 // Image timestamp, seconds
+	TerrainSigma  float64 // Terrain height, meters
+	PixelSigma    float64 // Detection outline, pixels
+}
+
+// DefaultErrorBudget suits a GPS-equipped smallsat with a star tracker
+// imaging over a 30 m DEM.
+func DefaultErrorBudget() ErrorBudget {
+	return ErrorBudget{
+		PositionSigma: 10,
+		AttitudeSigma: 0.02,
+		TimingSigma:   0.01,
+		TerrainSigma:  16,
+		PixelSigma:    1,
+	}
+}
+
+// Point is a geodetic position.
+type Point struct {
+	Latitude  float64 `json:"lat"` // degrees
+	Longitude float64 `json:"lon"` // degrees
+	Altitude  float64 `json:"alt"` // meters above the ellipsoid
+}
+
+// Footprint is the ground projection of a detection.
+type Footprint struct {
+	Time        time.Time `json:"time"`
+	Center      Point     `json:"center"`
+	Polygon     []Point   `json:"polygon"`     // Outline, counter-clockwise and not closed
+	GSD         float64   `json:"gsd"`         // Ground sample distance at the centre, meters
+	Uncertainty float64   `json:"uncertainty"` // 1-sigma horizontal error of the centre, meters
+	SlantRange  float64   `json:"slant_range"` // meters
+	OffNadir    float64   `json:"off_nadir"`   // degrees
+	Incidence   float64   `json:"incidence"`   // Angle from the local vertical at the ground, degrees
+}
+
+// Projector maps image pixels to the ground.
+type Projector struct {
+	Camera  Camera
+	Terrain TerrainModel // nil projects onto the ellipsoid
+	Errors  ErrorBudget
+}
+
+// NewProjector creates a projector with the default error budget.
+func NewProjector(camera Camera, terrain TerrainModel) *Projector {
+	return &Projector{Camera: camera, Terrain: terrain, Errors: DefaultErrorBudget()}
+}
+
+// ProjectPixel intersects the line of sight through a pixel with the
+// terrain.
+func (p *Projector) ProjectPixel(pose Pose, u, v float64) (Point, error) {
+	ground, _, err := p.intersect(pose, u, v)
+	return ground, err
+}
+
+// Project projects a detection's bounding box. The outline samples each
+// edge of the box so that lens distortion and terrain relief show.
+func (p *Projector) Project(pose Pose, box vision.BoundingBox) (*Footprint, error) {
+	x0, y0 := float64(box.X), float64(box.Y)
+	x1, y1 := x0+float64(box.Width), y0+float64(box.Height)
+	cx, cy := (x0+x1)/2, (y0+y1)/2
+
+	center, los, err := p.intersect(pose, cx, cy)
+	if err != nil {
+		return nil, err
+	}
+	outline := [][2]float64{
+		{x0, y0}, {cx, y0}, {x1, y0}, {x1, cy},
+		{x1, y1}, {cx, y1}, {x0, y1}, {x0, cy},
+	}
+	polygon := make([]Point, 0, len(outline))
+	for _, px := range outline {
+		pt, _, err := p.intersect(pose, px[0], px[1])
+		if err != nil {
+			return nil, fmt.Errorf("project box corner (%.0f, %.0f): %w", px[0], px[1], err)
+		}
+		polygon = append(polygon, pt)
+	}
+	if signedArea(polygon) < 0 {
+		for i, j := 0, len(polygon)-1; i < j; i, j = i+1, j-1 {
+			polygon[i], polygon[j] = polygon[j], polygon[i]
+		}
+	}
+
+	// Ground sample distance from the neighbouring pixels
+	right, _, errR := p.intersect(pose, cx+1, cy)
+	down, _, errD := p.intersect(pose, cx, cy+1)
+	if errR != nil || errD != nil {
+		return nil, fmt.Errorf("ground sample distance: %w", ErrNoIntersection)
+	}
+	gsd := math.Sqrt(distance(center, right) * distance(center, down))
+
+	fp := &Footprint{
+		Time:       pose.Time,
+		Center:     center,
+		Polygon:    polygon,
+		GSD:        gsd,
+		SlantRange: los.rangeKm * 1000,
+		OffNadir:   los.offNadir,
+		Incidence:  los.incidence,
+	}
+	fp.Uncertainty = p.uncertainty(pose, los, gsd)
+	return fp, nil
+}
+
+// lineOfSight describes the geometry of a projected pixel.
+type lineOfSight struct {
+	rangeKm   float64
+	offNadir  float64 // degrees
+	incidence float64 // degrees
+}
+
+// intersect casts a pixel onto the terrain, iterating between the
+// ellipsoid raised to the terrain height and the terrain model.
+func (p *Projector) intersect(pose Pose, u, v float64) (Point, lineOfSight, error) {
+	dir := pose.attitude().MulVec(p.Camera.Ray(u, v))
+	origin := pose.Position
+
+	var ground [3]float64
+	height := 0.0 // km
+	for i := 0; ; i++ {
+		var ok bool
+		if ground, ok = intersectEllipsoid(origin, dir, height); !ok {
+			return Point{}, lineOfSight{}, ErrNoIntersection
+		}
+		if p.Terrain == nil {
+			break
+		}
+		g := frames.ECEFToGeodetic(ground)
+		h, err := p.Terrain.Height(g.Latitude, g.Longitude)
+		if err != nil {
+			return Point{}, lineOfSight{}, fmt.Errorf("terrain at %.5f, %.5f: %w", g.Latitude, g.Longitude, err)
+		}
+		if math.Abs(h/1000-height) < 1e-4 || i == 20 {
+			break
+		}
+		height = h / 1000
+	}
+
+	g := frames.ECEFToGeodetic(ground)
+	pt := Point{Latitude: g.Latitude, Longitude: g.Longitude, Altitude: g.Altitude * 1000}
+
+	los := lineOfSight{rangeKm: norm(sub(ground, origin))}
+	nadir := NadirAttitude(origin, pose.Velocity).MulVec([3]float64{0, 0, 1})
+	los.offNadir = angle(dir, nadir)
+	up := unit(sub(frames.GeodeticToECEF(frames.Geodetic{Latitude: g.Latitude, Longitude: g.Longitude, Altitude: 1}),
+		frames.GeodeticToECEF(frames.Geodetic{Latitude: g.Latitude, Longitude: g.Longitude})))
+	los.incidence = angle(sub(origin, ground), up)
+	return pt, los, nil
+}
+
+// uncertainty combines the error budget into a 1-sigma horizontal error.
+func (p *Projector) uncertainty(pose Pose, los lineOfSight, gsd float64) float64 {
+	e := p.Errors
+	inc := los.incidence * math.Pi / 180
+	cosInc := math.Max(math.Cos(inc), 0.1)
+	rangeM := los.rangeKm * 1000
+
+	// Ground speed of the sub-satellite point
+	speed := norm(pose.Velocity) * 1000 * frames.WGS84SemiMajorAxis / norm(pose.Position)
+
+	pointing := rangeM * e.AttitudeSigma * math.Pi / 180 / cosInc
+	terrain := e.TerrainSigma * math.Tan(inc)
+	if p.Terrain == nil {
+		terrain = 0
+	}
+	timing := speed * e.TimingSigma
+	pixel := gsd * e.PixelSigma
+	position := e.PositionSigma * math.Sqrt(2) // Horizontal share of the 3D error
+	return math.Sqrt(position*position + pointing*pointing + terrain*terrain + timing*timing + pixel*pixel)
+}
+
+// intersectEllipsoid returns the first point where a ray meets the WGS-84
+// ellipsoid raised by height km.
+func intersectEllipsoid(origin, dir [3]float64, height float64) ([3]float64, bool) {
+	a := frames.WGS84SemiMajorAxis + height
+	b := frames.WGS84SemiMajorAxis*(1-frames.WGS84Flattening) + height
+	// Scale z so the ellipsoid becomes a sphere of radius a
+	k := a / b
+	o := [3]float64{origin[0], origin[1], origin[2] * k}
+	d := [3]float64{dir[0], dir[1], dir[2] * k}
+
+	qa := dot(d, d)
+	qb := 2 * dot(o, d)
+	qc := dot(o, o) - a*a
+	disc := qb*qb - 4*qa*qc
+	if disc < 0 {
+		return [3]float64{}, false
+	}
+	t := (-qb - math.Sqrt(disc)) / (2 * qa)
+	if t < 0 {
+		return [3]float64{}, false
+	}
+	return [3]float64{origin[0] + t*dir[0], origin[1] + t*dir[1], origin[2] + t*dir[2]}, true
+}
+
+// distance returns the straight-line distance between two points, meters.
+func distance(a, b Point) float64 {
+	ea := frames.GeodeticToECEF(frames.Geodetic{Latitude: a.Latitude, Longitude: a.Longitude, Altitude: a.Altitude / 1000})
+	eb := frames.GeodeticToECEF(frames.Geodetic{Latitude: b.Latitude, Longitude: b.Longitude, Altitude: b.Altitude / 1000})
+	return norm(sub(ea, eb)) * 1000
+}
+
+func angle(a, b [3]float64) float64 {
+	c := dot(unit(a), unit(b))
+	return math.Acos(math.Max(-1, math.Min(1, c))) * 180 / math.Pi
+}
+
+// signedArea is positive for a counter-clockwise outline in lon/lat.
+func signedArea(polygon []Point) float64 {
+	var sum float64
+	ref := polygon[0].Longitude
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		sum += unwrap(a.Longitude, ref)*b.Latitude - unwrap(b.Longitude, ref)*a.Latitude
+	}
+	return sum / 2
+}
+
+// unwrap returns lon shifted by whole turns to within 180° of ref.
+func unwrap(lon, ref float64) float64 {
+	for lon-ref > 180 {
+		lon -= 360
+	}
+	for lon-ref < -180 {
+		lon += 360
+	}
+	return lon
+}
@@ -0,0 +1,179 @@
+package geolocation
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoTerrain is returned by terrain models without data at a point.
+var ErrNoTerrain = errors.New("no terrain data")
+
+// TerrainModel gives the terrain height above the WGS-84 ellipsoid.
+type TerrainModel interface {
+	Height(lat, lon float64) (float64, error) // meters
+}
+
+// EllipsoidHeight is flat terrain at a constant height above the
+// ellipsoid, meters.
+type EllipsoidHeight float64
+
+// Height implements TerrainModel.
+func (h EllipsoidHeight) Height(_, _ float64) (float64, error) {
+	return float64(h), nil
+}
+
+// LayeredTerrain answers from the first model with data at a point, e.g.
+// local DEM tiles over a coarse global model.
+type LayeredTerrain []TerrainModel
+
+// Height implements TerrainModel.
+func (l LayeredTerrain) Height(lat, lon float64) (float64, error) {
+	for _, model := range l {
+		h, err := model.Height(lat, lon)
+		if err == nil {
+			return h, nil
+		}
+		if !errors.Is(err, ErrNoTerrain) {
+			return 0, err
+		}
+	}
+	return 0, ErrNoTerrain
+}
+
+// ElevationGrid is a regular latitude/longitude grid of heights sampled at
+// cell centres and interpolated bilinearly.
+type ElevationGrid struct {
+	West     float64 // Longitude of the western edge, degrees
+	South    float64 // Latitude of the southern edge, degrees
+	CellSize float64 // degrees
+	Rows     int
+	Cols     int
+	Heights  []float64 // Row-major from the northern row, meters
+	NoData   float64   // Marker for missing samples
+
+	// Undulation is added to every sample. DEMs such as SRTM give heights
+	// above the geoid; set this to the local geoid height to refer them to
+	// the ellipsoid.
+	Undulation float64
+}
+
+// ReadASCIIGrid parses an Esri ASCII raster (.asc), the plain-text DEM
+// format most GIS tools export, with geographic coordinates.
+func ReadASCIIGrid(r io.Reader) (*ElevationGrid, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1<<20), 1<<26)
+	scanner.Split(bufio.ScanWords)
+
+	g := &ElevationGrid{NoData: -9999}
+	var centre bool
+	var values []float64
+	for scanner.Scan() {
+		word := scanner.Text()
+		key := strings.ToLower(word)
+		switch key {
+		case "ncols", "nrows", "xllcorner", "yllcorner", "xllcenter", "yllcenter", "cellsize", "nodata_value":
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("ascii grid: missing value for %s", word)
+			}
+			v, err := strconv.ParseFloat(scanner.Text(), 64)
+			if err != nil {
+				return nil, fmt.Errorf("ascii grid %s: %w", word, err)
+			}
+			switch key {
+			case "ncols":
+				g.Cols = int(v)
+			case "nrows":
+				g.Rows = int(v)
+			case "xllcorner":
+				g.West = v
+			case "yllcorner":
+				g.South = v
+			case "xllcenter":
+				g.West, centre = v, true
+			case "yllcenter":
+				g.South, centre = v, true
+			case "cellsize":
+				g.CellSize = v
+			case "nodata_value":
+				g.NoData = v
+			}
+			continue
+		}
+		v, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ascii grid: unexpected %q", word)
+		}
+		values = append(values, v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ascii grid: %w", err)
+	}
+	if g.Rows <= 0 || g.Cols <= 0 || g.CellSize <= 0 {
+		return nil, fmt.Errorf("ascii grid: header needs ncols, nrows and cellsize")
+	}
+	if len(values) != g.Rows*g.Cols {
+		return nil, fmt.Errorf("ascii grid: %d samples, want %d×%d", len(values), g.Rows, g.Cols)
+	}
+	if centre {
+		g.West -= g.CellSize / 2
+		g.South -= g.CellSize / 2
+	}
+	g.Heights = values
+	return g, nil
+}
+
+// LoadASCIIGrid reads an Esri ASCII raster file.
+func LoadASCIIGrid(path string) (*ElevationGrid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadASCIIGrid(f)
+}
+
+// Height implements TerrainModel. Points outside the grid, or next to
+// missing samples, return ErrNoTerrain.
+func (g *ElevationGrid) Height(lat, lon float64) (float64, error) {
+	lon = g.West + math.Mod(math.Mod(lon-g.West, 360)+360, 360)
+
+	// Fractional indices of the cell centres around the point
+	col := (lon-g.West)/g.CellSize - 0.5
+	row := (g.South+float64(g.Rows)*g.CellSize-lat)/g.CellSize - 0.5
+	if col < -0.5 || row < -0.5 || col > float64(g.Cols)-0.5 || row > float64(g.Rows)-0.5 {
+		return 0, ErrNoTerrain
+	}
+	c0 := clampIndex(int(math.Floor(col)), g.Cols)
+	r0 := clampIndex(int(math.Floor(row)), g.Rows)
+	c1 := clampIndex(c0+1, g.Cols)
+	r1 := clampIndex(r0+1, g.Rows)
+	fc := math.Min(math.Max(col-float64(c0), 0), 1)
+	fr := math.Min(math.Max(row-float64(r0), 0), 1)
+
+	var h [4]float64
+	for i, idx := range [4]int{r0*g.Cols + c0, r0*g.Cols + c1, r1*g.Cols + c0, r1*g.Cols + c1} {
+		h[i] = g.Heights[idx]
+		if h[i] == g.NoData {
+			return 0, ErrNoTerrain
+		}
+	}
+	top := h[0]*(1-fc) + h[1]*fc
+	bottom := h[2]*(1-fc) + h[3]*fc
+	return top*(1-fr) + bottom*fr + g.Undulation, nil
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
@@ -11,6 +11,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+	"github.com/asgard/pandora/internal/platform/satellite"
 )
 
 // SpaceGPSController implements GPSController for space-grade GPS receivers.
@@ -481,9 +484,11 @@ func (g *SpaceGPSController) Shutdown() error {
 
 // TLEPositionCalculator calculates position from TLE when GPS is unavailable
 type TLEPositionCalculator struct {
-	mu  sync.RWMutex
-	tle TLE
-	gps *SpaceGPSController
+	mu         sync.RWMutex
+	tle        TLE
+	propagator *satellite.Propagator
+	tleErr     error
+	gps        *SpaceGPSController
 }
 
 // TLE represents two-line element set for orbit propagation
@@ -502,10 +507,9 @@ type TLE struct {
 
 // NewTLEPositionCalculator creates a hybrid position provider
 func NewTLEPositionCalculator(gps *SpaceGPSController, tle TLE) *TLEPositionCalculator {
-	return &TLEPositionCalculator{
-		gps: gps,
-		tle: tle,
-	}
+	o := &TLEPositionCalculator{gps: gps}
+	o.UpdateTLE(tle)
+	return o
 }
 
 // GetPosition returns position from GPS if available, otherwise propagates TLE
@@ -524,113 +528,40 @@ func (o *TLEPositionCalculator) GetPosition() (lat, lon, alt float64, err error)
 
 func (o *TLEPositionCalculator) propagateTLE(t time.Time) (lat, lon, alt float64, err error) {
 	o.mu.RLock()
-	tle := o.tle
+	tle, propagator, tleErr := o.tle, o.propagator, o.tleErr
 	o.mu.RUnlock()
 
 	if tle.Line1 == "" {
 		return 0, 0, 0, fmt.Errorf("no TLE data available")
 	}
-
-	// Simplified SGP4 propagation
-	// Real implementation would use full SGP4/SDP4 algorithm
-
-	// Time since epoch
-	dt := t.Sub(tle.Epoch).Minutes()
-
-	// Mean motion in rad/min
-	n := tle.MeanMotion * 2 * math.Pi / 1440.0
-
-	// Mean anomaly at time t
-	M := tle.MeanAnomaly + n*dt
-	M = math.Mod(M, 2*math.Pi)
-
-	// Simplified Kepler solver for eccentric anomaly
-	E := M
-	for i := 0; i < 10; i++ {
-		E = M + tle.Eccentricity*math.Sin(E)
+	if tleErr != nil {
+		return 0, 0, 0, tleErr
 	}
 
-	// True anomaly
-	v := 2 * math.Atan2(
-		math.Sqrt(1+tle.Eccentricity)*math.Sin(E/2),
-		math.Sqrt(1-tle.Eccentricity)*math.Cos(E/2),
-	)
-
-	// Argument of latitude
-	u := v + tle.ArgPerigee
-
-	// Semi-major axis (from mean motion)
-	mu := 398600.4418 // km^3/s^2
-	a := math.Pow(mu/math.Pow(n*60, 2), 1.0/3.0)
-
-	// Orbital radius
-	r := a * (1 - tle.Eccentricity*math.Cos(E))
-
-	// Position in orbital plane
-	xOrbital := r * math.Cos(u)
-	yOrbital := r * math.Sin(u)
-
-	// Rotate to Earth-centered coordinates
-	raanRad := tle.RAAN * math.Pi / 180.0
-	inclRad := tle.Inclination * math.Pi / 180.0
-
-	// Earth rotation since epoch
-	gmst := o.calculateGMST(t)
-
-	// ECEF position
-	x := xOrbital*(math.Cos(raanRad)*math.Cos(gmst)+math.Sin(raanRad)*math.Sin(gmst)*math.Cos(inclRad)) -
-		yOrbital*(math.Sin(raanRad)*math.Cos(gmst)-math.Cos(raanRad)*math.Sin(gmst)*math.Cos(inclRad))
-	y := xOrbital*(-math.Cos(raanRad)*math.Sin(gmst)+math.Sin(raanRad)*math.Cos(gmst)*math.Cos(inclRad)) -
-		yOrbital*(math.Sin(raanRad)*math.Sin(gmst)+math.Cos(raanRad)*math.Cos(gmst)*math.Cos(inclRad))
-	z := xOrbital*math.Sin(raanRad)*math.Sin(inclRad) + yOrbital*math.Cos(raanRad)*math.Sin(inclRad)
-
-	// Convert ECEF to geodetic
-	lat, lon, alt = o.ecefToGeodetic(x, y, z)
-
-	return lat, lon, alt * 1000, nil // alt in meters
-}
-
-func (o *TLEPositionCalculator) calculateGMST(t time.Time) float64 {
-	// Calculate Greenwich Mean Sidereal Time
-	jd := float64(t.Unix())/86400.0 + 2440587.5
-	T := (jd - 2451545.0) / 36525.0
-	gmst := 280.46061837 + 360.98564736629*(jd-2451545.0) + 0.000387933*T*T
-	return math.Mod(gmst, 360.0) * math.Pi / 180.0
-}
-
-func (o *TLEPositionCalculator) ecefToGeodetic(x, y, z float64) (lat, lon, alt float64) {
-	// WGS84 parameters
-	a := 6378.137 // km
-	f := 1.0 / 298.257223563
-	_ = a * (1 - f) // b (semi-minor axis) - kept for reference
-	e2 := 2*f - f*f
-
-	// Longitude
-	lon = math.Atan2(y, x) * 180 / math.Pi
-
-	// Iterative latitude calculation
-	p := math.Sqrt(x*x + y*y)
-	lat = math.Atan2(z, p*(1-e2))
-
-	for i := 0; i < 10; i++ {
-		sinLat := math.Sin(lat)
-		N := a / math.Sqrt(1-e2*sinLat*sinLat)
-		lat = math.Atan2(z+e2*N*sinLat, p)
+	state, err := propagator.PropagateState(t)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("propagate TLE: %w", err)
 	}
+	r, _ := frames.TEMEToITRF(state.Position, state.Velocity, t, frames.EOP{})
+	ground := frames.ECEFToGeodetic(r)
 
-	// Altitude
-	sinLat := math.Sin(lat)
-	N := a / math.Sqrt(1-e2*sinLat*sinLat)
-	alt = p/math.Cos(lat) - N
-
-	lat = lat * 180 / math.Pi
-
-	return lat, lon, alt
+	return ground.Latitude, ground.Longitude, ground.Altitude * 1000, nil // alt in meters
 }
 
 // UpdateTLE updates the TLE data
 func (o *TLEPositionCalculator) UpdateTLE(tle TLE) {
+	var propagator *satellite.Propagator
+	var err error
+	if tle.Line1 != "" {
+		propagator, err = satellite.NewPropagator(&satellite.TLE{Name: tle.Name, Line1: tle.Line1, Line2: tle.Line2})
+		if err != nil {
+			err = fmt.Errorf("invalid TLE for %s: %w", tle.Name, err)
+		}
+	}
+
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	o.tle = tle
+	o.propagator = propagator
+	o.tleErr = err
 }
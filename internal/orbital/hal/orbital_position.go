@@ -3,9 +3,11 @@ package hal
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/asgard/pandora/internal/platform/frames"
 	"github.com/asgard/pandora/internal/platform/satellite"
 )
 
@@ -88,8 +90,14 @@ func (r *RealOrbitalPosition) GetPosition() (lat, lon, alt float64, err error) {
 		go r.refreshTLE()
 	}
 
-	lat, lon, alt = propagator.Propagate(time.Now().UTC())
-	return lat, lon, alt, nil
+	now := time.Now().UTC()
+	state, err := propagator.PropagateState(now)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("propagate orbit: %w", err)
+	}
+	pos, _ := frames.TEMEToITRF(state.Position, state.Velocity, now, frames.EOP{})
+	ground := frames.ECEFToGeodetic(pos)
+	return ground.Latitude, ground.Longitude, ground.Altitude, nil
 }
 
 // GetTime returns current UTC time.
@@ -97,31 +105,23 @@ func (r *RealOrbitalPosition) GetTime() (time.Time, error) {
 	return time.Now().UTC(), nil
 }
 
-// GetVelocity returns approximate orbital velocity.
-// For LEO satellites, orbital velocity is approximately 7.8 km/s.
+// GetVelocity returns the Earth-relative velocity in km/s as east (vx),
+// north (vy) and up (vz) components at the sub-satellite point.
 func (r *RealOrbitalPosition) GetVelocity() (vx, vy, vz float64, err error) {
-	// Compute velocity from position changes
 	now := time.Now().UTC()
 
 	r.mu.RLock()
 	propagator := r.propagator
 	r.mu.RUnlock()
 
-	lat1, lon1, _ := propagator.Propagate(now)
-	lat2, lon2, _ := propagator.Propagate(now.Add(time.Second))
-
-	// Approximate velocity in degrees/sec, convert to km/s
-	// 1 degree latitude ≈ 111 km
-	// 1 degree longitude ≈ 111 km * cos(lat)
-	dLat := (lat2 - lat1) * 111.0
-	dLon := (lon2 - lon1) * 111.0 * cosine(lat1)
-
-	// Ground velocity (simplified)
-	vx = dLon // km/s east-west
-	vy = dLat // km/s north-south
-	vz = 0.0  // Altitude change is small
+	state, err := propagator.PropagateState(now)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("propagate orbit: %w", err)
+	}
+	pos, vel := frames.TEMEToITRF(state.Position, state.Velocity, now, frames.EOP{})
+	enu := frames.ENU(frames.ECEFToGeodetic(pos), vel)
 
-	return vx, vy, vz, nil
+	return enu[0], enu[1], enu[2], nil
 }
 
 // refreshTLE updates the TLE data in the background.
@@ -168,26 +168,3 @@ func (r *RealOrbitalPosition) GetSatelliteName() string {
 func (r *RealOrbitalPosition) GetNoradID() int {
 	return r.noradID
 }
-
-// cosine returns cosine of angle in degrees.
-func cosine(degrees float64) float64 {
-	const deg2rad = 0.017453292519943295
-	return cos(degrees * deg2rad)
-}
-
-// cos is a simple cosine implementation.
-func cos(radians float64) float64 {
-	// Taylor series approximation for cosine
-	// cos(x) = 1 - x^2/2! + x^4/4! - x^6/6! + ...
-	x := radians
-	// Normalize to [-π, π]
-	for x > 3.14159265358979 {
-		x -= 2 * 3.14159265358979
-	}
-	for x < -3.14159265358979 {
-		x += 2 * 3.14159265358979
-	}
-
-	x2 := x * x
-	return 1 - x2/2 + x2*x2/24 - x2*x2*x2/720 + x2*x2*x2*x2/40320
-}
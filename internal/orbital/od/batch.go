@@ -0,0 +1,260 @@
+package od
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+// BatchConfig controls the batch least-squares fit.
+type BatchConfig struct {
+	MaxIterations     int     // Gauss–Newton iterations before giving up
+	PositionTolerance float64 // Converged when the epoch position correction is below this, meters
+	RejectSigma       float64 // Residuals beyond this many times the weighted RMS are edited out; 0 keeps all
+}
+
+// DefaultBatchConfig returns a fit converging to centimetres with 5-sigma
+// outlier editing.
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxIterations:     15,
+		PositionTolerance: 0.01,
+		RejectSigma:       5,
+	}
+}
+
+// Solution is the outcome of a batch fit.
+type Solution struct {
+	State      orbital.StateVector `json:"state"`      // GCRF at the solution epoch
+	Covariance Covariance          `json:"covariance"` // Formal covariance of State
+	RMS        float64             `json:"rms"`        // Weighted residual RMS of the last iteration, 1 for a fit matching its sigmas
+	Iterations int                 `json:"iterations"`
+	Used       int                 `json:"used"`     // Observations in the fit
+	Rejected   int                 `json:"rejected"` // Observations edited out as outliers
+}
+
+// BatchLeastSquares fits an epoch state to a span of observations by
+// differential correction through the state transition matrix.
+type BatchLeastSquares struct {
+	propagator *orbital.NumericalPropagator
+	config     BatchConfig
+}
+
+// NewBatchLeastSquares creates a batch estimator over a numerical
+// propagator carrying the force model to fit with.
+func NewBatchLeastSquares(propagator *orbital.NumericalPropagator, cfg BatchConfig) *BatchLeastSquares {
+	return &BatchLeastSquares{propagator: propagator, config: cfg}
+}
+
+// Fit refines guess, whose timestamp is the solution epoch, against the
+// observations.
+func (b *BatchLeastSquares) Fit(observations []Observation, guess orbital.StateVector) (*Solution, error) {
+	obs := make([]Observation, len(observations))
+	copy(obs, observations)
+	sortObservations(obs)
+	if err := validateObservations(obs); err != nil {
+		return nil, err
+	}
+
+	cfg := b.config
+	if cfg.MaxIterations <= 0 {
+		cfg.MaxIterations = 1
+	}
+	x0 := guess
+	edit := math.Inf(1)
+	sol := &Solution{}
+	for iter := 1; iter <= cfg.MaxIterations; iter++ {
+		states, stms, err := b.trajectory(x0, obs)
+		if err != nil {
+			return nil, fmt.Errorf("propagate reference orbit: %w", err)
+		}
+
+		var normal matrix6
+		var rhs [6]float64
+		var sum float64
+		var count, used, rejected int
+		for i, o := range obs {
+			rows, residuals, sigmas := measurement(o, states[i])
+			outlier := false
+			for k := range rows {
+				if math.Abs(residuals[k]/sigmas[k]) > edit {
+					outlier = true
+				}
+			}
+			if outlier {
+				rejected++
+				continue
+			}
+			used++
+			for k, row := range rows {
+				w := 1 / (sigmas[k] * sigmas[k])
+				phi := stms[i][row]
+				for a := 0; a < 6; a++ {
+					rhs[a] += phi[a] * w * residuals[k]
+					for c := 0; c < 6; c++ {
+						normal[a][c] += phi[a] * w * phi[c]
+					}
+				}
+				sum += residuals[k] * residuals[k] * w
+				count++
+			}
+		}
+		if count < 6 {
+			return nil, fmt.Errorf("%d measurements left after editing, need at least 6", count)
+		}
+		cov, err := invert6(normal)
+		if err != nil {
+			return nil, fmt.Errorf("observations do not determine the orbit: %w", err)
+		}
+
+		var dx [6]float64
+		for a := 0; a < 6; a++ {
+			for c := 0; c < 6; c++ {
+				dx[a] += cov[a][c] * rhs[c]
+			}
+		}
+		x := stateSlice(x0)
+		for a := range x {
+			x[a] += dx[a]
+		}
+		x0 = sliceState(x, x0.Timestamp)
+
+		rms := math.Sqrt(sum / float64(count))
+		*sol = Solution{
+			State:      x0,
+			Covariance: Covariance(symmetrize(cov)),
+			RMS:        rms,
+			Iterations: iter,
+			Used:       used,
+			Rejected:   rejected,
+		}
+		if cfg.RejectSigma > 0 {
+			edit = cfg.RejectSigma * math.Max(rms, 1)
+		}
+		if math.Sqrt(dx[0]*dx[0]+dx[1]*dx[1]+dx[2]*dx[2]) < cfg.PositionTolerance {
+			return sol, nil
+		}
+	}
+	return sol, fmt.Errorf("batch fit did not converge in %d iterations", cfg.MaxIterations)
+}
+
+// trajectory propagates the reference orbit to every observation time,
+// forwards and backwards from the epoch, chaining the state transition
+// matrices back to the epoch.
+func (b *BatchLeastSquares) trajectory(epoch orbital.StateVector, obs []Observation) ([]orbital.StateVector, []matrix6, error) {
+	states := make([]orbital.StateVector, len(obs))
+	stms := make([]matrix6, len(obs))
+	split := sort.Search(len(obs), func(i int) bool { return !obs[i].Time.Before(epoch.Timestamp) })
+
+	walk := func(indices []int) error {
+		state, phi := epoch, identity6()
+		for _, i := range indices {
+			var step orbital.StateTransitionMatrix
+			var err error
+			if state, step, err = b.propagator.PropagateWithSTM(state, obs[i].Time); err != nil {
+				return err
+			}
+			phi = mul6(step, phi)
+			states[i], stms[i] = state, phi
+		}
+		return nil
+	}
+	var forward, backward []int
+	for i := split; i < len(obs); i++ {
+		forward = append(forward, i)
+	}
+	for i := split - 1; i >= 0; i-- {
+		backward = append(backward, i)
+	}
+	if err := walk(forward); err != nil {
+		return nil, nil, err
+	}
+	if err := walk(backward); err != nil {
+		return nil, nil, err
+	}
+	return states, stms, nil
+}
+
+// measurement returns the state rows an observation measures with its
+// residuals against a predicted state and their sigmas.
+func measurement(o Observation, predicted orbital.StateVector) (rows []int, residuals, sigmas []float64) {
+	dr := o.Position.Sub(predicted.Position)
+	rows = []int{0, 1, 2}
+	residuals = []float64{dr.X, dr.Y, dr.Z}
+	sigmas = []float64{o.PositionSigma, o.PositionSigma, o.PositionSigma}
+	if o.HasVelocity {
+		dv := o.Velocity.Sub(predicted.Velocity)
+		rows = append(rows, 3, 4, 5)
+		residuals = append(residuals, dv.X, dv.Y, dv.Z)
+		sigmas = append(sigmas, o.VelocitySigma, o.VelocitySigma, o.VelocitySigma)
+	}
+	return rows, residuals, sigmas
+}
+
+func validateObservations(obs []Observation) error {
+	if len(obs) < 2 {
+		return fmt.Errorf("orbit determination needs at least 2 observations, have %d", len(obs))
+	}
+	for _, o := range obs {
+		if o.PositionSigma <= 0 || (o.HasVelocity && o.VelocitySigma <= 0) {
+			return fmt.Errorf("observation at %s has no positive sigma", o.Time.UTC())
+		}
+	}
+	return nil
+}
+
+// InitialGuess estimates a state from the observations to start a fit
+// from. It uses the first observation with velocity, or failing that the
+// Herrick–Gibbs method on three position fixes spread over up to ten
+// minutes at the start of the arc.
+func InitialGuess(observations []Observation) (orbital.StateVector, error) {
+	obs := make([]Observation, len(observations))
+	copy(obs, observations)
+	sortObservations(obs)
+	for _, o := range obs {
+		if o.HasVelocity {
+			return orbital.StateVector{Position: o.Position, Velocity: o.Velocity, Timestamp: o.Time}, nil
+		}
+	}
+	if len(obs) < 3 {
+		return orbital.StateVector{}, fmt.Errorf("initial orbit needs 3 position fixes, have %d", len(obs))
+	}
+
+	const maxArc = 10 * time.Minute
+	first := obs[0]
+	last := len(obs) - 1
+	for last > 2 && obs[last].Time.Sub(first.Time) > maxArc {
+		last--
+	}
+	mid := 1
+	half := first.Time.Add(obs[last].Time.Sub(first.Time) / 2)
+	for i := 1; i < last; i++ {
+		if math.Abs(obs[i].Time.Sub(half).Seconds()) < math.Abs(obs[mid].Time.Sub(half).Seconds()) {
+			mid = i
+		}
+	}
+	if !first.Time.Before(obs[mid].Time) || !obs[mid].Time.Before(obs[last].Time) {
+		return orbital.StateVector{}, fmt.Errorf("initial orbit needs 3 fixes at distinct times")
+	}
+	v := herrickGibbs(first, obs[mid], obs[last])
+	return orbital.StateVector{Position: obs[mid].Position, Velocity: v, Timestamp: obs[mid].Time}, nil
+}
+
+// herrickGibbs returns the velocity at the middle of three closely spaced
+// positions (Vallado, Fundamentals of Astrodynamics, algorithm 55).
+func herrickGibbs(o1, o2, o3 Observation) orbital.Vector3 {
+	t21 := o2.Time.Sub(o1.Time).Seconds()
+	t32 := o3.Time.Sub(o2.Time).Seconds()
+	t31 := o3.Time.Sub(o1.Time).Seconds()
+	term := func(r orbital.Vector3) float64 {
+		m := r.Magnitude()
+		return orbital.EarthMu / (12 * m * m * m)
+	}
+	c1 := -t32 * (1/(t21*t31) + term(o1.Position))
+	c2 := (t32 - t21) * (1/(t21*t32) + term(o2.Position))
+	c3 := t21 * (1/(t32*t31) + term(o3.Position))
+	return o1.Position.Scale(c1).Add(o2.Position.Scale(c2)).Add(o3.Position.Scale(c3))
+}
@@ -0,0 +1,132 @@
+package od
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+// Config configures a Determiner.
+type Config struct {
+	Spacecraft orbital.Spacecraft      // Drag and solar pressure properties; zero mass leaves both out
+	Numerical  orbital.NumericalConfig // Propagator for the fit and the fitted ephemeris
+	Batch      BatchConfig
+	Elements   ElementFitConfig
+	MinArc     time.Duration // Fits need observations spanning at least this
+}
+
+// DefaultConfig returns a configuration fitting arcs of at least 20 minutes.
+func DefaultConfig() Config {
+	return Config{
+		Numerical: orbital.DefaultNumericalConfig(),
+		Batch:     DefaultBatchConfig(),
+		Elements:  DefaultElementFitConfig(),
+		MinArc:    20 * time.Minute,
+	}
+}
+
+// Estimate is a determined orbit: the refined state at the end of the
+// observation arc, its covariance and the SGP4 mean elements fitted to it.
+// It is small enough to downlink as telemetry.
+type Estimate struct {
+	Solution
+	ArcStart time.Time       `json:"arc_start"`
+	ArcEnd   time.Time       `json:"arc_end"`
+	Elements *FittedElements `json:"elements"`
+}
+
+// Determiner runs batch orbit determination for one satellite and fits
+// fresh mean elements to the result.
+type Determiner struct {
+	mu         sync.Mutex
+	config     Config
+	template   satellite.OMM
+	propagator *orbital.NumericalPropagator
+}
+
+// NewDeterminer creates a determiner. The template identifies the
+// satellite in the fitted element sets; its ElementSetNo is advanced with
+// every fit.
+func NewDeterminer(template satellite.OMM, cfg Config) *Determiner {
+	force := orbital.StandardForceModel(cfg.Spacecraft)
+	return &Determiner{
+		config:     cfg,
+		template:   template,
+		propagator: orbital.NewNumericalPropagator(force, cfg.Numerical),
+	}
+}
+
+// Propagator returns the numerical propagator the determiner fits with,
+// for running an EKF from an Estimate.
+func (d *Determiner) Propagator() *orbital.NumericalPropagator {
+	return d.propagator
+}
+
+// Determine fits the observations. The solution epoch is the last
+// observation, where the state is best known for onward prediction.
+func (d *Determiner) Determine(observations []Observation) (*Estimate, error) {
+	obs := make([]Observation, len(observations))
+	copy(obs, observations)
+	sortObservations(obs)
+	if err := validateObservations(obs); err != nil {
+		return nil, err
+	}
+	start, end := obs[0].Time, obs[len(obs)-1].Time
+	if arc := end.Sub(start); arc < d.config.MinArc {
+		return nil, fmt.Errorf("observation arc of %s is shorter than %s", arc, d.config.MinArc)
+	}
+
+	guess, err := InitialGuess(obs)
+	if err != nil {
+		return nil, err
+	}
+	if guess, err = d.propagator.Propagate(guess, end); err != nil {
+		return nil, fmt.Errorf("propagate initial orbit: %w", err)
+	}
+	sol, err := NewBatchLeastSquares(d.propagator, d.config.Batch).Fit(obs, guess)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeris, err := d.ephemeris(sol.State)
+	if err != nil {
+		return nil, fmt.Errorf("fitted ephemeris: %w", err)
+	}
+	d.mu.Lock()
+	d.template.ElementSetNo++
+	template := d.template
+	d.mu.Unlock()
+	template.Epoch = end.UTC()
+	elements, err := FitElements(ephemeris, template, d.config.Elements)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[OrbitDetermination] %s: %d fixes over %s, RMS %.2f, position sigma %.1f m, mean elements RMS %.0f m",
+		elements.TLE.Name, sol.Used, end.Sub(start).Round(time.Second), sol.RMS, sol.Covariance.PositionSigma(), elements.RMS)
+	return &Estimate{
+		Solution: *sol,
+		ArcStart: start,
+		ArcEnd:   end,
+		Elements: elements,
+	}, nil
+}
+
+// ephemeris propagates the solution over the element fit span centred on
+// its epoch.
+func (d *Determiner) ephemeris(state orbital.StateVector) ([]orbital.StateVector, error) {
+	cfg := d.config.Elements
+	step := cfg.Step
+	if step <= 0 {
+		step = 5 * time.Minute
+	}
+	first, err := d.propagator.Propagate(state, state.Timestamp.Add(-cfg.Span/2))
+	if err != nil {
+		return nil, err
+	}
+	return d.propagator.Ephemeris(first, state.Timestamp.Add(cfg.Span/2), step)
+}
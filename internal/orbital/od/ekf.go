@@ -0,0 +1,171 @@
+package od
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+// EKFConfig controls the sequential estimator.
+type EKFConfig struct {
+	ProcessNoise   float64 // Spectral density of unmodelled acceleration, m²/s³
+	InnovationGate float64 // Measurements further than this Mahalanobis distance are rejected; 0 accepts all
+}
+
+// DefaultEKFConfig returns process noise covering drag and solar pressure
+// model errors in LEO, with a 6-sigma innovation gate.
+func DefaultEKFConfig() EKFConfig {
+	return EKFConfig{
+		ProcessNoise:   1e-9,
+		InnovationGate: 6,
+	}
+}
+
+// EKF is an extended Kalman filter over the GCRF state, processing fixes
+// as they arrive. The covariance is propagated through the numerical
+// propagator's state transition matrix with white-noise acceleration
+// process noise.
+type EKF struct {
+	mu         sync.Mutex
+	propagator *orbital.NumericalPropagator
+	config     EKFConfig
+	state      orbital.StateVector
+	cov        matrix6
+	accepted   int
+	rejected   int
+}
+
+// NewEKF starts a filter from an initial state and covariance, typically
+// a batch Solution.
+func NewEKF(propagator *orbital.NumericalPropagator, initial orbital.StateVector, cov Covariance, cfg EKFConfig) *EKF {
+	return &EKF{propagator: propagator, config: cfg, state: initial, cov: matrix6(cov)}
+}
+
+// State returns the current estimate and its covariance.
+func (f *EKF) State() (orbital.StateVector, Covariance) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state, Covariance(f.cov)
+}
+
+// Counts returns the numbers of accepted and rejected measurements.
+func (f *EKF) Counts() (accepted, rejected int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.accepted, f.rejected
+}
+
+// Predict advances the estimate to t without a measurement.
+func (f *EKF) Predict(t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.predict(t)
+}
+
+func (f *EKF) predict(t time.Time) error {
+	if t.Equal(f.state.Timestamp) {
+		return nil
+	}
+	next, stm, err := f.propagator.PropagateWithSTM(f.state, t)
+	if err != nil {
+		return fmt.Errorf("propagate filter state: %w", err)
+	}
+	phi := matrix6(stm)
+	cov := mul6(mul6(phi, f.cov), transpose6(phi))
+
+	// White-noise acceleration on each axis
+	dt := math.Abs(t.Sub(f.state.Timestamp).Seconds())
+	q := f.config.ProcessNoise
+	for i := 0; i < 3; i++ {
+		cov[i][i] += q * dt * dt * dt / 3
+		cov[i][i+3] += q * dt * dt / 2
+		cov[i+3][i] += q * dt * dt / 2
+		cov[i+3][i+3] += q * dt
+	}
+	f.state, f.cov = next, symmetrize(cov)
+	return nil
+}
+
+// Update advances the filter to the observation and incorporates it. It
+// reports whether the measurement passed the innovation gate.
+func (f *EKF) Update(o Observation) (bool, error) {
+	if o.PositionSigma <= 0 || (o.HasVelocity && o.VelocitySigma <= 0) {
+		return false, fmt.Errorf("observation at %s has no positive sigma", o.Time.UTC())
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.predict(o.Time); err != nil {
+		return false, err
+	}
+
+	rows, residuals, sigmas := measurement(o, f.state)
+	m := len(rows)
+
+	// Innovation covariance S = HPHᵀ + R, H selecting the measured rows
+	s := make([][]float64, m)
+	for a, ra := range rows {
+		s[a] = make([]float64, m)
+		for b, rb := range rows {
+			s[a][b] = f.cov[ra][rb]
+		}
+		s[a][a] += sigmas[a] * sigmas[a]
+	}
+	sInv, err := invert(s)
+	if err != nil {
+		return false, fmt.Errorf("innovation covariance: %w", err)
+	}
+
+	if gate := f.config.InnovationGate; gate > 0 {
+		d2 := 0.0
+		for a := 0; a < m; a++ {
+			for b := 0; b < m; b++ {
+				d2 += residuals[a] * sInv[a][b] * residuals[b]
+			}
+		}
+		if d2 > gate*gate {
+			f.rejected++
+			return false, nil
+		}
+	}
+
+	// Gain K = PHᵀS⁻¹
+	k := make([][]float64, 6)
+	for i := 0; i < 6; i++ {
+		k[i] = make([]float64, m)
+		for b := 0; b < m; b++ {
+			for a, ra := range rows {
+				k[i][b] += f.cov[i][ra] * sInv[a][b]
+			}
+		}
+	}
+
+	x := stateSlice(f.state)
+	for i := 0; i < 6; i++ {
+		for b := 0; b < m; b++ {
+			x[i] += k[i][b] * residuals[b]
+		}
+	}
+	f.state = sliceState(x, f.state.Timestamp)
+
+	// Joseph form (I-KH)P(I-KH)ᵀ + KRKᵀ stays positive definite
+	ikh := identity6()
+	for i := 0; i < 6; i++ {
+		for b, rb := range rows {
+			ikh[i][rb] -= k[i][b]
+		}
+	}
+	cov := mul6(mul6(ikh, f.cov), transpose6(ikh))
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			for b := 0; b < m; b++ {
+				cov[i][j] += k[i][b] * sigmas[b] * sigmas[b] * k[j][b]
+			}
+		}
+	}
+	f.cov = symmetrize(cov)
+	f.accepted++
+	return true, nil
+}
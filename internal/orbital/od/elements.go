@@ -0,0 +1,268 @@
+package od
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+	"github.com/asgard/pandora/internal/platform/satellite"
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+// sgp4Mu is the WGS-72 gravitational parameter SGP4 is defined with, km³/s².
+const sgp4Mu = 398600.8
+
+// ElementFitConfig controls the fit of SGP4 mean elements to an ephemeris.
+type ElementFitConfig struct {
+	Span          time.Duration // Ephemeris fitted, centred on the epoch
+	Step          time.Duration // Ephemeris spacing
+	MaxIterations int           // Levenberg–Marquardt iterations
+	Tolerance     float64       // Stop when the RMS improves by less than this fraction
+	FitBStar      bool          // Solve for B* as well as the six elements
+	EOP           frames.EOP    // Earth orientation for the GCRF to TEME rotation
+}
+
+// DefaultElementFitConfig fits half a day of ephemeris, long enough to
+// average the short-period terms SGP4 leaves out, including drag.
+func DefaultElementFitConfig() ElementFitConfig {
+	return ElementFitConfig{
+		Span:          12 * time.Hour,
+		Step:          5 * time.Minute,
+		MaxIterations: 30,
+		Tolerance:     1e-6,
+		FitBStar:      true,
+	}
+}
+
+// FittedElements are SGP4 mean elements fitted to an ephemeris.
+type FittedElements struct {
+	OMM    *satellite.OMM `json:"-"`
+	TLE    *satellite.TLE `json:"tle"`
+	RMS    float64        `json:"rms"` // Position residual RMS of the mean elements, meters
+	Points int            `json:"points"`
+}
+
+// FitElements fits SGP4 mean elements to a GCRF ephemeris by
+// Levenberg–Marquardt on the TEME positions. The template carries the
+// identity of the object (name, catalog number, COSPAR ID, element set
+// number) and the epoch; a zero epoch takes the first ephemeris time.
+func FitElements(ephemeris []orbital.StateVector, template satellite.OMM, cfg ElementFitConfig) (*FittedElements, error) {
+	if len(ephemeris) < 3 {
+		return nil, fmt.Errorf("element fit needs at least 3 ephemeris points, have %d", len(ephemeris))
+	}
+	epoch := template.Epoch
+	if epoch.IsZero() {
+		epoch = ephemeris[0].Timestamp
+	}
+	template.Epoch = epoch.UTC()
+
+	type point struct {
+		minutes float64
+		r       [3]float64 // TEME, km
+	}
+	points := make([]point, len(ephemeris))
+	nearest := 0
+	for i, s := range ephemeris {
+		r, v := frames.GCRFToTEME(
+			[3]float64{s.Position.X / 1000, s.Position.Y / 1000, s.Position.Z / 1000},
+			[3]float64{s.Velocity.X / 1000, s.Velocity.Y / 1000, s.Velocity.Z / 1000},
+			s.Timestamp, cfg.EOP)
+		points[i] = point{minutes: s.Timestamp.Sub(epoch).Minutes(), r: r}
+		if math.Abs(points[i].minutes) < math.Abs(points[nearest].minutes) {
+			nearest = i
+		}
+		if i == nearest {
+			// Osculating elements of the state nearest the epoch start the fit
+			template = osculatingGuess(template, r, v, s.Timestamp.Sub(epoch).Minutes())
+		}
+	}
+
+	params := elementParams(template, cfg.FitBStar)
+	residuals := func(p []float64) ([]float64, error) {
+		omm := paramsOMM(template, p)
+		prop, err := satellite.NewPropagatorFromOMM(omm)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]float64, 0, 3*len(points))
+		for _, pt := range points {
+			s, err := prop.PropagateMinutes(pt.minutes)
+			if err != nil {
+				return nil, err
+			}
+			for k := 0; k < 3; k++ {
+				out = append(out, (pt.r[k]-s.Position[k])*1000)
+			}
+		}
+		return out, nil
+	}
+	cost := func(r []float64) float64 {
+		var sum float64
+		for _, v := range r {
+			sum += v * v
+		}
+		return sum
+	}
+
+	res, err := residuals(params)
+	if err != nil {
+		return nil, fmt.Errorf("initial mean elements: %w", err)
+	}
+	current := cost(res)
+	lambda := 1e-3
+	steps := []float64{1e-8, 1e-7, 1e-7, 1e-7, 1e-7, 1e-7, 1e-6}
+	n := len(params)
+	converged := false
+	for iter := 0; iter < cfg.MaxIterations && !converged; iter++ {
+		// Jacobian by forward differences
+		jac := make([][]float64, n)
+		for j := 0; j < n; j++ {
+			trial := append([]float64(nil), params...)
+			trial[j] += steps[j]
+			shifted, err := residuals(trial)
+			if err != nil {
+				return nil, fmt.Errorf("element partials: %w", err)
+			}
+			jac[j] = make([]float64, len(res))
+			for k := range res {
+				jac[j][k] = (res[k] - shifted[k]) / steps[j] // ∂(model)/∂p
+			}
+		}
+		normal := make([][]float64, n)
+		rhs := make([]float64, n)
+		for a := 0; a < n; a++ {
+			normal[a] = make([]float64, n)
+			for b := 0; b < n; b++ {
+				for k := range res {
+					normal[a][b] += jac[a][k] * jac[b][k]
+				}
+			}
+			for k := range res {
+				rhs[a] += jac[a][k] * res[k]
+			}
+		}
+
+		improved := false
+		for attempt := 0; attempt < 10 && !improved; attempt++ {
+			damped := make([][]float64, n)
+			for a := range normal {
+				damped[a] = append([]float64(nil), normal[a]...)
+				damped[a][a] *= 1 + lambda
+			}
+			delta, err := solveNormal(damped, rhs)
+			if err != nil {
+				lambda *= 10
+				continue
+			}
+			trial := append([]float64(nil), params...)
+			for a := range trial {
+				trial[a] += delta[a]
+			}
+			trialRes, err := residuals(trial)
+			if err != nil || cost(trialRes) >= current {
+				lambda *= 10
+				continue
+			}
+			improved = true
+			gain := (current - cost(trialRes)) / current
+			params, res, current = trial, trialRes, cost(trialRes)
+			lambda = math.Max(lambda/10, 1e-9)
+			converged = gain < cfg.Tolerance
+		}
+		if !improved {
+			break
+		}
+	}
+
+	omm := paramsOMM(template, params)
+	tle, err := omm.TLE()
+	if err != nil {
+		return nil, fmt.Errorf("format fitted elements: %w", err)
+	}
+	return &FittedElements{
+		OMM:    omm,
+		TLE:    tle,
+		RMS:    math.Sqrt(current / float64(len(points))),
+		Points: len(points),
+	}, nil
+}
+
+// elementParams packs the fitted elements as n (rad/min), e·cos ω,
+// e·sin ω, i, Ω and M+ω (radians), and B*, which stay well conditioned
+// for near-circular orbits.
+func elementParams(o satellite.OMM, withBStar bool) []float64 {
+	const deg = math.Pi / 180
+	w := o.ArgPericenter * deg
+	p := []float64{
+		o.MeanMotion * 2 * math.Pi / 1440,
+		o.Eccentricity * math.Cos(w),
+		o.Eccentricity * math.Sin(w),
+		o.Inclination * deg,
+		o.RAAN * deg,
+		o.MeanAnomaly*deg + w,
+	}
+	if withBStar {
+		p = append(p, o.BStar)
+	}
+	return p
+}
+
+// paramsOMM unpacks elementParams onto a copy of the template.
+func paramsOMM(template satellite.OMM, p []float64) *satellite.OMM {
+	const deg = 180 / math.Pi
+	o := template
+	o.MeanMotion = p[0] * 1440 / (2 * math.Pi)
+	o.Eccentricity = math.Min(math.Hypot(p[1], p[2]), 0.999)
+	w := math.Atan2(p[2], p[1])
+	o.ArgPericenter = wrapDegrees(w * deg)
+	o.Inclination = p[3] * deg
+	o.RAAN = wrapDegrees(p[4] * deg)
+	o.MeanAnomaly = wrapDegrees((p[5] - w) * deg)
+	if len(p) > 6 {
+		o.BStar = p[6]
+	}
+	return &o
+}
+
+// osculatingGuess sets the template's elements from a TEME state (km,
+// km/s) taken minutes after the epoch.
+func osculatingGuess(o satellite.OMM, r, v [3]float64, minutes float64) satellite.OMM {
+	const deg = 180 / math.Pi
+	rv := orbital.Vector3{X: r[0], Y: r[1], Z: r[2]}
+	vv := orbital.Vector3{X: v[0], Y: v[1], Z: v[2]}
+	rm, vm := rv.Magnitude(), vv.Magnitude()
+
+	h := rv.Cross(vv)
+	a := 1 / (2/rm - vm*vm/sgp4Mu)
+	ev := rv.Scale(vm*vm/sgp4Mu - 1/rm).Sub(vv.Scale(rv.Dot(vv) / sgp4Mu))
+	e := ev.Magnitude()
+
+	inc := math.Acos(h.Z / h.Magnitude())
+	raan := math.Atan2(h.X, -h.Y)
+	node := orbital.Vector3{X: math.Cos(raan), Y: math.Sin(raan)}
+	normal := h.Normalize().Cross(node)
+
+	w := math.Atan2(ev.Dot(normal), ev.Dot(node))
+	u := math.Atan2(rv.Dot(normal), rv.Dot(node))
+	nu := u - w
+	ecc := 2 * math.Atan(math.Sqrt((1-e)/(1+e))*math.Tan(nu/2))
+	mean := ecc - e*math.Sin(ecc)
+
+	n := math.Sqrt(sgp4Mu/(a*a*a)) * 60 // rad/min
+	o.MeanMotion = n * 1440 / (2 * math.Pi)
+	o.Eccentricity = e
+	o.Inclination = inc * deg
+	o.RAAN = wrapDegrees(raan * deg)
+	o.ArgPericenter = wrapDegrees(w * deg)
+	o.MeanAnomaly = wrapDegrees((mean - n*minutes) * deg)
+	return o
+}
+
+func wrapDegrees(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
@@ -0,0 +1,201 @@
+package od
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+// errSingular is returned when the normal equations cannot be solved.
+var errSingular = errors.New("matrix is singular")
+
+// Covariance is a 6×6 state covariance, position (m) then velocity (m/s).
+type Covariance [6][6]float64
+
+// DiagonalCovariance returns a covariance with independent position and
+// velocity errors of the given standard deviations.
+func DiagonalCovariance(positionSigma, velocitySigma float64) Covariance {
+	var c Covariance
+	for i := 0; i < 3; i++ {
+		c[i][i] = positionSigma * positionSigma
+		c[i+3][i+3] = velocitySigma * velocitySigma
+	}
+	return c
+}
+
+// PositionSigma returns the RSS position uncertainty, meters.
+func (c Covariance) PositionSigma() float64 {
+	return math.Sqrt(c[0][0] + c[1][1] + c[2][2])
+}
+
+// VelocitySigma returns the RSS velocity uncertainty, m/s.
+func (c Covariance) VelocitySigma() float64 {
+	return math.Sqrt(c[3][3] + c[4][4] + c[5][5])
+}
+
+type matrix6 = [6][6]float64
+
+func identity6() matrix6 {
+	var m matrix6
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+func mul6(a, b matrix6) matrix6 {
+	var m matrix6
+	for i := 0; i < 6; i++ {
+		for k := 0; k < 6; k++ {
+			if a[i][k] == 0 {
+				continue
+			}
+			for j := 0; j < 6; j++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func transpose6(a matrix6) matrix6 {
+	var m matrix6
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			m[i][j] = a[j][i]
+		}
+	}
+	return m
+}
+
+// symmetrize removes the rounding asymmetry covariance updates accumulate.
+func symmetrize(a matrix6) matrix6 {
+	for i := 0; i < 6; i++ {
+		for j := i + 1; j < 6; j++ {
+			v := (a[i][j] + a[j][i]) / 2
+			a[i][j], a[j][i] = v, v
+		}
+	}
+	return a
+}
+
+// invert inverts a square matrix by Gauss–Jordan elimination with partial
+// pivoting. The input is left unchanged.
+func invert(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	work := make([][]float64, n)
+	inv := make([][]float64, n)
+	scale := 0.0
+	for i := range a {
+		work[i] = append([]float64(nil), a[i]...)
+		inv[i] = make([]float64, n)
+		inv[i][i] = 1
+		for _, v := range a[i] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(work[row][col]) > math.Abs(work[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(work[pivot][col]) <= 1e-15*scale {
+			return nil, errSingular
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+
+		d := work[col][col]
+		for j := 0; j < n; j++ {
+			work[col][j] /= d
+			inv[col][j] /= d
+		}
+		for row := 0; row < n; row++ {
+			if row == col || work[row][col] == 0 {
+				continue
+			}
+			f := work[row][col]
+			for j := 0; j < n; j++ {
+				work[row][j] -= f * work[col][j]
+				inv[row][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv, nil
+}
+
+// invert6 inverts a 6×6 matrix after scaling it to unit diagonal, which
+// keeps normal matrices mixing meters and m/s well conditioned.
+func invert6(a matrix6) (matrix6, error) {
+	var d [6]float64
+	for i := 0; i < 6; i++ {
+		if a[i][i] <= 0 {
+			return matrix6{}, errSingular
+		}
+		d[i] = 1 / math.Sqrt(a[i][i])
+	}
+	scaled := make([][]float64, 6)
+	for i := 0; i < 6; i++ {
+		scaled[i] = make([]float64, 6)
+		for j := 0; j < 6; j++ {
+			scaled[i][j] = a[i][j] * d[i] * d[j]
+		}
+	}
+	inv, err := invert(scaled)
+	if err != nil {
+		return matrix6{}, err
+	}
+	var m matrix6
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			m[i][j] = inv[i][j] * d[i] * d[j]
+		}
+	}
+	return m, nil
+}
+
+// solveNormal solves normal equations scaled to unit diagonal.
+func solveNormal(a [][]float64, rhs []float64) ([]float64, error) {
+	n := len(a)
+	d := make([]float64, n)
+	scaled := make([][]float64, n)
+	for i := range a {
+		if a[i][i] <= 0 {
+			return nil, errSingular
+		}
+		d[i] = 1 / math.Sqrt(a[i][i])
+	}
+	for i := range a {
+		scaled[i] = make([]float64, n)
+		for j := range a[i] {
+			scaled[i][j] = a[i][j] * d[i] * d[j]
+		}
+	}
+	inv, err := invert(scaled)
+	if err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			x[i] += inv[i][j] * d[i] * d[j] * rhs[j]
+		}
+	}
+	return x, nil
+}
+
+func stateSlice(s orbital.StateVector) [6]float64 {
+	return [6]float64{s.Position.X, s.Position.Y, s.Position.Z, s.Velocity.X, s.Velocity.Y, s.Velocity.Z}
+}
+
+func sliceState(x [6]float64, t time.Time) orbital.StateVector {
+	return orbital.StateVector{
+		Position:  orbital.Vector3{X: x[0], Y: x[1], Z: x[2]},
+		Velocity:  orbital.Vector3{X: x[3], Y: x[4], Z: x[5]},
+		Timestamp: t,
+	}
+}
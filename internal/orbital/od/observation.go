@@ -0,0 +1,209 @@
+// Package od determines satellite orbits from onboard GPS fixes. A batch
+// least-squares fit and an extended Kalman filter refine the state vector
+// and its covariance under the numerical force models of
+// simulation/orbital, and the refined orbit is converted to SGP4 mean
+// elements so that ground systems can predict passes from it.
+package od
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/orbital/hal"
+	"github.com/asgard/pandora/internal/platform/frames"
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+// Observation is a timestamped GPS state in GCRF. Fixes without velocity,
+// such as NMEA position fixes, constrain the position only.
+type Observation struct {
+	Time          time.Time       `json:"time"`
+	Position      orbital.Vector3 `json:"position"` // meters
+	Velocity      orbital.Vector3 `json:"velocity"` // m/s, valid if HasVelocity
+	HasVelocity   bool            `json:"has_velocity"`
+	PositionSigma float64         `json:"position_sigma"`       // meters, per axis
+	VelocitySigma float64         `json:"velocity_sigma"`       // m/s, per axis
+	Source        string          `json:"source,omitempty"`     // e.g. "gps"
+	FixType       string          `json:"fix_type,omitempty"`   // As reported by the receiver
+	Satellites    int             `json:"satellites,omitempty"` // Satellites in the fix
+}
+
+// NewITRFObservation converts an Earth-fixed position (meters) and
+// velocity (m/s) to an Observation. A nil velocity makes a position-only
+// observation.
+func NewITRFObservation(t time.Time, position [3]float64, velocity *[3]float64, positionSigma, velocitySigma float64, eop frames.EOP) Observation {
+	r := [3]float64{position[0] / 1000, position[1] / 1000, position[2] / 1000}
+	var v [3]float64
+	if velocity != nil {
+		v = [3]float64{velocity[0] / 1000, velocity[1] / 1000, velocity[2] / 1000}
+	}
+	rGCRF, vGCRF := frames.ITRFToGCRF(r, v, t, eop)
+	obs := Observation{
+		Time:          t,
+		Position:      orbital.Vector3{X: rGCRF[0] * 1000, Y: rGCRF[1] * 1000, Z: rGCRF[2] * 1000},
+		PositionSigma: positionSigma,
+	}
+	if velocity != nil {
+		obs.Velocity = orbital.Vector3{X: vGCRF[0] * 1000, Y: vGCRF[1] * 1000, Z: vGCRF[2] * 1000}
+		obs.HasVelocity = true
+		obs.VelocitySigma = velocitySigma
+	}
+	return obs
+}
+
+// FixConfig controls how receiver fixes are turned into observations.
+type FixConfig struct {
+	UERE           float64       // User equivalent range error, meters; scaled by the DOP
+	DefaultDOP     float64       // PDOP assumed when the receiver reports none
+	VelocitySigma  float64       // m/s, per axis
+	MinSatellites  int           // Fixes from fewer satellites are dropped
+	MaxVelocityAge time.Duration // Velocity further than this from the position fix is not used
+	EOP            frames.EOP    // Earth orientation for the ITRF to GCRF rotation
+}
+
+// DefaultFixConfig returns settings for a single-frequency space receiver.
+func DefaultFixConfig() FixConfig {
+	return FixConfig{
+		UERE:           5,
+		DefaultDOP:     2,
+		VelocitySigma:  0.1,
+		MinSatellites:  4,
+		MaxVelocityAge: time.Second,
+	}
+}
+
+// ObservationFromGPS converts a receiver fix to an observation. NMEA
+// receivers report geodetic position and ground speed only, so velocity
+// is used only when the receiver fills in the ECEF components. NMEA
+// altitudes are referred to mean sea level, up to about 100 m from the
+// ellipsoid, so space receivers should be set to report ellipsoidal
+// heights.
+func ObservationFromGPS(pos hal.GPSPosition, vel hal.GPSVelocity, cfg FixConfig) (Observation, error) {
+	if pos.Timestamp.IsZero() {
+		return Observation{}, fmt.Errorf("no GPS fix available")
+	}
+	if pos.FixType == "" || pos.FixType == "none" {
+		return Observation{}, fmt.Errorf("GPS fix at %s has no solution", pos.Timestamp.UTC())
+	}
+	if pos.NumSatellites < cfg.MinSatellites {
+		return Observation{}, fmt.Errorf("GPS fix at %s uses %d satellites, need %d", pos.Timestamp.UTC(), pos.NumSatellites, cfg.MinSatellites)
+	}
+
+	dop := math.Hypot(pos.HDOP, pos.VDOP)
+	if pos.VDOP == 0 {
+		dop = pos.HDOP * math.Sqrt(1+1.5*1.5) // VDOP is typically 1.5 HDOP
+	}
+	if dop == 0 {
+		dop = cfg.DefaultDOP
+	}
+	// Each axis carries about a third of the 3D error
+	sigma := cfg.UERE * dop / math.Sqrt(3)
+
+	r := frames.GeodeticToECEF(frames.Geodetic{
+		Latitude:  pos.Latitude,
+		Longitude: pos.Longitude,
+		Altitude:  pos.Altitude / 1000,
+	})
+	r = [3]float64{r[0] * 1000, r[1] * 1000, r[2] * 1000}
+
+	var v *[3]float64
+	age := vel.Timestamp.Sub(pos.Timestamp)
+	if !vel.Timestamp.IsZero() && math.Abs(age.Seconds()) <= cfg.MaxVelocityAge.Seconds() &&
+		(vel.VX != 0 || vel.VY != 0 || vel.VZ != 0) {
+		v = &[3]float64{vel.VX, vel.VY, vel.VZ}
+	}
+
+	obs := NewITRFObservation(pos.Timestamp.UTC(), r, v, sigma, cfg.VelocitySigma, cfg.EOP)
+	obs.Source = "gps"
+	obs.FixType = pos.FixType
+	obs.Satellites = pos.NumSatellites
+	return obs, nil
+}
+
+// GPSSource is the part of hal.SpaceGPSController the collector reads.
+type GPSSource interface {
+	GetFullPosition() hal.GPSPosition
+	GetFullVelocity() hal.GPSVelocity
+}
+
+// FixCollector samples a GPS receiver and keeps the most recent fixes as
+// observations for orbit determination.
+type FixCollector struct {
+	mu      sync.Mutex
+	gps     GPSSource
+	config  FixConfig
+	max     int
+	fixes   []Observation
+	lastFix time.Time
+}
+
+// NewFixCollector creates a collector keeping at most max observations.
+func NewFixCollector(gps GPSSource, cfg FixConfig, max int) *FixCollector {
+	if max <= 0 {
+		max = 1000
+	}
+	return &FixCollector{gps: gps, config: cfg, max: max}
+}
+
+// Sample records the receiver's current fix if it is new and usable.
+func (c *FixCollector) Sample() bool {
+	pos, vel := c.gps.GetFullPosition(), c.gps.GetFullVelocity()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !pos.Timestamp.After(c.lastFix) {
+		return false
+	}
+	obs, err := ObservationFromGPS(pos, vel, c.config)
+	if err != nil {
+		return false
+	}
+	c.lastFix = pos.Timestamp
+	c.fixes = append(c.fixes, obs)
+	if len(c.fixes) > c.max {
+		c.fixes = append(c.fixes[:0], c.fixes[len(c.fixes)-c.max:]...)
+	}
+	return true
+}
+
+// Add records an observation from another source.
+func (c *FixCollector) Add(obs Observation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fixes = append(c.fixes, obs)
+	sortObservations(c.fixes)
+	if len(c.fixes) > c.max {
+		c.fixes = append(c.fixes[:0], c.fixes[len(c.fixes)-c.max:]...)
+	}
+}
+
+// Observations returns a copy of the collected observations in time order.
+func (c *FixCollector) Observations() []Observation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Observation, len(c.fixes))
+	copy(out, c.fixes)
+	return out
+}
+
+// Run samples the receiver every interval until the context is cancelled.
+func (c *FixCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Sample()
+		}
+	}
+}
+
+func sortObservations(obs []Observation) {
+	sort.SliceStable(obs, func(i, j int) bool { return obs[i].Time.Before(obs[j].Time) })
+}
@@ -0,0 +1,113 @@
+package tracking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AckPayloadType marks DTN payloads carrying an AlertAck.
+const AckPayloadType = "alert_ack"
+
+var (
+	// ErrUnknownAlert is returned for acknowledgements of alerts the
+	// tracker did not raise or has already forgotten.
+	ErrUnknownAlert = errors.New("unknown alert")
+	// ErrInvalidTransition is returned for status changes that would move
+	// an alert backwards, e.g. a delayed acknowledgement arriving after
+	// the alert was dispatched.
+	ErrInvalidTransition = errors.New("invalid alert status transition")
+)
+
+// AlertAck is a status change for an alert sent up by the ground segment.
+type AlertAck struct {
+	Type      string      `json:"type"`
+	AlertID   uuid.UUID   `json:"alert_id"`
+	Status    AlertStatus `json:"status"`
+	Operator  string      `json:"operator,omitempty"`
+	Note      string      `json:"note,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// NewAlertAck creates an acknowledgement ready to encode into a bundle.
+func NewAlertAck(alertID uuid.UUID, status AlertStatus, operator string) AlertAck {
+	return AlertAck{
+		Type:      AckPayloadType,
+		AlertID:   alertID,
+		Status:    status,
+		Operator:  operator,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// DecodeAck parses a bundle payload as an AlertAck.
+func DecodeAck(payload []byte) (AlertAck, error) {
+	var ack AlertAck
+	if err := json.Unmarshal(payload, &ack); err != nil {
+		return AlertAck{}, fmt.Errorf("decode alert ack: %w", err)
+	}
+	if ack.Type != AckPayloadType {
+		return AlertAck{}, fmt.Errorf("decode alert ack: payload type %q", ack.Type)
+	}
+	if ack.AlertID == uuid.Nil {
+		return AlertAck{}, fmt.Errorf("decode alert ack: missing alert id")
+	}
+	if ack.Status.rank() < 0 {
+		return AlertAck{}, fmt.Errorf("decode alert ack: unknown status %q", ack.Status)
+	}
+	return ack, nil
+}
+
+// CanTransition reports whether an alert may move from s to next. Alerts
+// only move forward, new → acknowledged → dispatched → resolved, but may
+// skip stages, e.g. when the acknowledgement bundle is lost.
+func (s AlertStatus) CanTransition(next AlertStatus) bool {
+	from, to := s.rank(), next.rank()
+	return from >= 0 && to > from
+}
+
+func (s AlertStatus) rank() int {
+	switch s {
+	case AlertStatusNew:
+		return 0
+	case AlertStatusAcknowledged:
+		return 1
+	case AlertStatusDispatched:
+		return 2
+	case AlertStatusResolved:
+		return 3
+	}
+	return -1
+}
+
+// HandleAck applies a ground acknowledgement and returns the updated
+// alert. Repeated acknowledgements of the current status are accepted
+// without change, as DTN may deliver a bundle more than once.
+func (t *Tracker) HandleAck(ack AlertAck) (Alert, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	alert, ok := t.alerts[ack.AlertID]
+	if !ok {
+		return Alert{}, fmt.Errorf("%w: %s", ErrUnknownAlert, ack.AlertID)
+	}
+	if ack.Status == alert.Status {
+		return *alert, nil
+	}
+	if !alert.Status.CanTransition(ack.Status) {
+		return *alert, fmt.Errorf("%w: %s to %s for alert %s", ErrInvalidTransition, alert.Status, ack.Status, ack.AlertID)
+	}
+
+	alert.Status = ack.Status
+	alert.Operator = ack.Operator
+	alert.UpdatedAt = ack.Timestamp
+	if alert.UpdatedAt.IsZero() {
+		alert.UpdatedAt = time.Now().UTC()
+	}
+	log.Printf("Alert %s %s by %q", ack.AlertID.String()[:8], ack.Status, ack.Operator)
+	return *alert, nil
+}
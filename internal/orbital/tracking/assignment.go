@@ -0,0 +1,101 @@
+package tracking
+
+import "math"
+
+// assign solves the rectangular assignment problem with the Hungarian
+// algorithm, returning for each row the column it is matched to. Rows and
+// columns may be matched only where the cost is finite; unmatched rows get
+// -1.
+func assign(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+	match := make([]int, rows)
+	for i := range match {
+		match[i] = -1
+	}
+	if cols == 0 {
+		return match
+	}
+
+	// Square the problem, pricing forbidden and dummy pairs above any
+	// feasible assignment so they are only used when unavoidable.
+	n := rows
+	if cols > n {
+		n = cols
+	}
+	var maxCost float64
+	for _, row := range cost {
+		for _, c := range row {
+			if !math.IsInf(c, 1) && c > maxCost {
+				maxCost = c
+			}
+		}
+	}
+	forbidden := (maxCost + 1) * float64(n+1)
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n)
+		for j := range a[i] {
+			a[i][j] = forbidden
+			if i < rows && j < cols && !math.IsInf(cost[i][j], 1) {
+				a[i][j] = cost[i][j]
+			}
+		}
+	}
+
+	// Shortest augmenting paths with row and column potentials, 1-based
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1) // p[j] is the row matched to column j
+	way := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for p[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := a[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	for j := 1; j <= n; j++ {
+		i := p[j] - 1
+		if i < rows && j-1 < cols && !math.IsInf(cost[i][j-1], 1) {
+			match[i] = j - 1
+		}
+	}
+	return match
+}
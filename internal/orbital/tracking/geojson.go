@@ -0,0 +1,26 @@
+package tracking
+
+import (
+	"encoding/json"
+
+	"github.com/asgard/pandora/internal/orbital/geolocation"
+)
+
+// ExportGeoJSON renders a batch of alerts as a GeoJSON feature collection
+// for GIS tools and the ground segment map. Alerts without a footprint are
+// left out.
+func ExportGeoJSON(alerts []Alert) ([]byte, error) {
+	features := make([]geolocation.Feature, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.Footprint == nil {
+			continue
+		}
+		features = append(features, alert.Footprint.Feature(alert.ID.String(), map[string]interface{}{
+			"type":       alert.Type,
+			"confidence": alert.Confidence,
+			"status":     alert.Status,
+			"timestamp":  alert.Timestamp,
+		}))
+	}
+	return json.Marshal(geolocation.NewFeatureCollection(features))
+}
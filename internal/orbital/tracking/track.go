@@ -0,0 +1,191 @@
+package tracking
+
+import (
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/orbital/vision"
+	"github.com/google/uuid"
+)
+
+// TrackState is the lifecycle stage of a track.
+type TrackState string
+
+const (
+	// TrackTentative tracks have been seen but not often enough to alert.
+	TrackTentative TrackState = "tentative"
+	// TrackConfirmed tracks have been seen on enough frames to alert.
+	TrackConfirmed TrackState = "confirmed"
+	// TrackLost tracks were confirmed but have not been seen for a while.
+	TrackLost TrackState = "lost"
+)
+
+// TrackerConfig tunes multi-frame association and confirmation.
+type TrackerConfig struct {
+	// MinDetectionConfidence drops detections too weak to start or extend
+	// a track. Alerts still need the accumulated track confidence to reach
+	// the alert criteria.
+	MinDetectionConfidence float64
+	MinIoU                 float64 // Association gate on box overlap
+	ConfirmHits            int     // Frames a tentative track needs to confirm
+	TentativeMisses        int     // Consecutive misses that discard a tentative track
+	MaxMisses              int     // Consecutive misses that lose a confirmed track
+	LostRetention          int     // Frames a lost track is kept for reporting
+	MissLogOdds            float64 // Confidence evidence removed per missed frame
+	MaxLogOdds             float64 // Bound on accumulated confidence evidence
+	AlertRetention         time.Duration
+}
+
+// DefaultTrackerConfig confirms a track after three frames and loses it
+// after five missed frames.
+func DefaultTrackerConfig() TrackerConfig {
+	return TrackerConfig{
+		MinDetectionConfidence: 0.3,
+		MinIoU:                 0.2,
+		ConfirmHits:            3,
+		TentativeMisses:        1,
+		MaxMisses:              5,
+		LostRetention:          10,
+		MissLogOdds:            1.0,
+		MaxLogOdds:             7.0,
+		AlertRetention:         time.Hour,
+	}
+}
+
+// Track follows one object across frames.
+type Track struct {
+	ID         uuid.UUID
+	Class      string
+	State      TrackState
+	Box        vision.BoundingBox // Latest associated box
+	Detection  vision.Detection   // Latest associated detection
+	Confidence float64            // Accumulated over the track's frames
+	Hits       int
+	Misses     int // Consecutive frames without a detection
+	Age        int // Frames since the track started
+	FirstSeen  time.Time
+	LastSeen   time.Time
+	AlertID    uuid.UUID // Zero until the track has raised an alert
+
+	logOdds float64
+	vx, vy  float64 // Box motion, pixels per frame
+}
+
+func newTrack(det vision.Detection, now time.Time, cfg TrackerConfig) *Track {
+	tr := &Track{
+		ID:        uuid.New(),
+		Class:     det.Class,
+		State:     TrackTentative,
+		FirstSeen: now,
+	}
+	tr.hit(det, now, cfg)
+	return tr
+}
+
+// hit associates a detection with the track. Confidence accumulates as
+// log-odds, so repeated detections build belief and a single strong
+// frame cannot on its own reach a high threshold once misses are counted.
+func (tr *Track) hit(det vision.Detection, now time.Time, cfg TrackerConfig) {
+	if tr.Hits > 0 {
+		// Smooth the box motion so targets drifting across a staring
+		// imager stay inside the association gate.
+		dx, dy := boxCenterDelta(tr.Box, det.BoundingBox)
+		steps := float64(tr.Misses + 1)
+		tr.vx = 0.5*tr.vx + 0.5*dx/steps
+		tr.vy = 0.5*tr.vy + 0.5*dy/steps
+	}
+	tr.Box = det.BoundingBox
+	tr.Detection = det
+	tr.Hits++
+	tr.Misses = 0
+	tr.LastSeen = now
+	tr.logOdds = clamp(tr.logOdds+logit(det.Confidence), -cfg.MaxLogOdds, cfg.MaxLogOdds)
+	tr.Confidence = sigmoid(tr.logOdds)
+	if tr.State == TrackTentative && tr.Hits >= cfg.ConfirmHits {
+		tr.State = TrackConfirmed
+	}
+}
+
+// miss records a frame without an associated detection and reports
+// whether the track should be discarded.
+func (tr *Track) miss(cfg TrackerConfig) bool {
+	tr.Misses++
+	switch tr.State {
+	case TrackTentative:
+		return tr.Misses > cfg.TentativeMisses
+	case TrackConfirmed:
+		tr.logOdds = clamp(tr.logOdds-cfg.MissLogOdds, -cfg.MaxLogOdds, cfg.MaxLogOdds)
+		tr.Confidence = sigmoid(tr.logOdds)
+		if tr.Misses >= cfg.MaxMisses {
+			tr.State = TrackLost
+		}
+	case TrackLost:
+		return tr.Misses >= cfg.MaxMisses+cfg.LostRetention
+	}
+	return false
+}
+
+// predicted returns the box expected on the next frame.
+func (tr *Track) predicted() vision.BoundingBox {
+	steps := float64(tr.Misses + 1)
+	box := tr.Box
+	box.X += int(math.Round(tr.vx * steps))
+	box.Y += int(math.Round(tr.vy * steps))
+	return box
+}
+
+// IoU returns the intersection over union of two boxes.
+func IoU(a, b vision.BoundingBox) float64 {
+	x0 := max(a.X, b.X)
+	y0 := max(a.Y, b.Y)
+	x1 := min(a.X+a.Width, b.X+b.Width)
+	y1 := min(a.Y+a.Height, b.Y+b.Height)
+	if x1 <= x0 || y1 <= y0 {
+		return 0
+	}
+	inter := float64((x1 - x0) * (y1 - y0))
+	union := float64(a.Width*a.Height+b.Width*b.Height) - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// associate matches detections to tracks of the same class, maximising
+// the total overlap. It returns the detection index for each track, or -1.
+func associate(tracks []*Track, detections []vision.Detection, minIoU float64) []int {
+	cost := make([][]float64, len(tracks))
+	for i, tr := range tracks {
+		cost[i] = make([]float64, len(detections))
+		box := tr.predicted()
+		for j, det := range detections {
+			cost[i][j] = math.Inf(1)
+			if det.Class != tr.Class {
+				continue
+			}
+			if iou := IoU(box, det.BoundingBox); iou >= minIoU && iou > 0 {
+				cost[i][j] = 1 - iou
+			}
+		}
+	}
+	return assign(cost)
+}
+
+func boxCenterDelta(from, to vision.BoundingBox) (float64, float64) {
+	dx := float64(to.X-from.X) + float64(to.Width-from.Width)/2
+	dy := float64(to.Y-from.Y) + float64(to.Height-from.Height)/2
+	return dx, dy
+}
+
+func logit(p float64) float64 {
+	p = clamp(p, 1e-6, 1-1e-6)
+	return math.Log(p / (1 - p))
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
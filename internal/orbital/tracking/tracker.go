@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/asgard/pandora/internal/orbital/geolocation"
 	"github.com/asgard/pandora/internal/orbital/vision"
 	"github.com/google/uuid"
 )
@@ -14,12 +16,19 @@ import (
 // Alert represents a triggered alert
 type Alert struct {
 	ID         uuid.UUID
+	TrackID    uuid.UUID // Track that raised the alert
 	Type       string
 	Confidence float64
 	Location   string // Could be lat/lon in production
 	VideoClip  []byte // Short video segment
 	Timestamp  time.Time
 	Status     AlertStatus
+	UpdatedAt  time.Time // Last status change
+	Operator   string    // Who last changed the status on the ground
+
+	// Footprint is the detection projected onto the ground, when the
+	// tracker has a geolocator.
+	Footprint *geolocation.Footprint
 }
 
 // AlertStatus represents alert state
@@ -32,61 +41,176 @@ const (
 	AlertStatusResolved     AlertStatus = "resolved"
 )
 
-// Tracker processes detections and generates alerts
+// Tracker associates detections across frames and raises one alert per
+// confirmed track. Alerts then follow the ground's acknowledgements
+// through their lifecycle, see HandleAck.
 type Tracker struct {
 	mu               sync.Mutex
 	criteria         vision.AlertCriteria
+	config           TrackerConfig
 	alertChan        chan<- Alert
-	recentAlerts     map[string]time.Time // Deduplication
+	tracks           []*Track
+	alerts           map[uuid.UUID]*Alert // Raised alerts, without clips
 	locationProvider func(context.Context) (string, error)
 	clipProvider     func(context.Context) ([]byte, error)
+	geolocator       Geolocator
 }
 
+// Geolocator projects a detection onto the ground.
+type Geolocator func(context.Context, vision.Detection) (*geolocation.Footprint, error)
+
 func NewTracker(criteria vision.AlertCriteria, alertChan chan<- Alert, locationProvider func(context.Context) (string, error), clipProvider func(context.Context) ([]byte, error)) *Tracker {
+	return NewTrackerWithConfig(criteria, DefaultTrackerConfig(), alertChan, locationProvider, clipProvider)
+}
+
+// NewTrackerWithConfig creates a tracker with custom association and
+// confirmation settings.
+func NewTrackerWithConfig(criteria vision.AlertCriteria, cfg TrackerConfig, alertChan chan<- Alert, locationProvider func(context.Context) (string, error), clipProvider func(context.Context) ([]byte, error)) *Tracker {
 	return &Tracker{
 		criteria:         criteria,
+		config:           cfg,
 		alertChan:        alertChan,
-		recentAlerts:     make(map[string]time.Time),
+		alerts:           make(map[uuid.UUID]*Alert),
 		locationProvider: locationProvider,
 		clipProvider:     clipProvider,
 	}
 }
 
-// ProcessDetections examines detections and generates alerts
+// SetGeolocator attaches footprints to alerts. With a geolocator the
+// location provider is optional: alerts take their location from the
+// footprint centre.
+func (t *Tracker) SetGeolocator(g Geolocator) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.geolocator = g
+}
+
+// ProcessDetections takes the detections from one frame. It should be
+// called for every frame, including frames without detections, since
+// missed frames age the tracks. Detections are matched to existing tracks
+// by box overlap, and a confirmed track whose accumulated confidence
+// meets the alert criteria raises an alert once.
 func (t *Tracker) ProcessDetections(ctx context.Context, detections []vision.Detection) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	now := time.Now().UTC()
+	candidates := make([]vision.Detection, 0, len(detections))
 	for _, det := range detections {
-		if t.criteria.ShouldAlert(det) {
-			if err := t.generateAlert(ctx, det); err != nil {
-				log.Printf("Failed to generate alert: %v", err)
-				continue
-			}
+		if det.Confidence >= t.config.MinDetectionConfidence && t.watches(det.Class) {
+			candidates = append(candidates, det)
+		}
+	}
+
+	// Lost tracks are kept for reporting only
+	active := make([]*Track, 0, len(t.tracks))
+	for _, tr := range t.tracks {
+		if tr.State != TrackLost {
+			active = append(active, tr)
+		}
+	}
+	matched := make([]bool, len(candidates))
+	updated := make(map[*Track]bool, len(candidates))
+	for i, j := range associate(active, candidates, t.config.MinIoU) {
+		if j >= 0 {
+			active[i].hit(candidates[j], now, t.config)
+			matched[j] = true
+			updated[active[i]] = true
 		}
 	}
 
-	// Clean up old deduplication entries
-	t.cleanupRecentAlerts()
+	kept := t.tracks[:0]
+	for _, tr := range t.tracks {
+		tr.Age++
+		if updated[tr] || !tr.miss(t.config) {
+			kept = append(kept, tr)
+		}
+	}
+	t.tracks = kept
+	for j, det := range candidates {
+		if !matched[j] {
+			tr := newTrack(det, now, t.config)
+			t.tracks = append(t.tracks, tr)
+			updated[tr] = true
+		}
+	}
 
+	for _, tr := range t.tracks {
+		if !updated[tr] || tr.State != TrackConfirmed || tr.AlertID != uuid.Nil {
+			continue
+		}
+		if tr.Confidence < t.criteria.MinConfidence {
+			continue
+		}
+		if err := t.generateAlert(ctx, tr); err != nil {
+			log.Printf("Failed to generate alert: %v", err)
+		}
+	}
+
+	t.cleanupAlerts(now)
 	return nil
 }
 
-func (t *Tracker) generateAlert(ctx context.Context, det vision.Detection) error {
-	// Deduplication: don't alert for same class within 5 minutes
-	if lastTime, exists := t.recentAlerts[det.Class]; exists {
-		if time.Since(lastTime) < 5*time.Minute {
-			return nil // Skip duplicate
+// Tracks returns a snapshot of the current tracks.
+func (t *Tracker) Tracks() []Track {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Track, len(t.tracks))
+	for i, tr := range t.tracks {
+		out[i] = *tr
+	}
+	return out
+}
+
+// Alerts returns the raised alerts still retained, without video clips.
+func (t *Tracker) Alerts() []Alert {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Alert, 0, len(t.alerts))
+	for _, a := range t.alerts {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+func (t *Tracker) watches(class string) bool {
+	for _, c := range t.criteria.AlertClasses {
+		if c == class {
+			return true
 		}
 	}
+	return false
+}
 
-	if t.locationProvider == nil || t.clipProvider == nil {
+func (t *Tracker) generateAlert(ctx context.Context, tr *Track) error {
+	det := tr.Detection
+	if (t.locationProvider == nil && t.geolocator == nil) || t.clipProvider == nil {
 		return fmt.Errorf("alert providers not configured")
 	}
 
-	location, err := t.locationProvider(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to resolve alert location: %w", err)
+	var footprint *geolocation.Footprint
+	if t.geolocator != nil {
+		fp, err := t.geolocator(ctx, det)
+		if err != nil {
+			log.Printf("Failed to geolocate %s detection: %v", det.Class, err)
+		} else {
+			footprint = fp
+		}
+	}
+
+	var location string
+	switch {
+	case footprint != nil:
+		location = fmt.Sprintf("lat=%.6f, lon=%.6f, uncertainty=%.0fm",
+			footprint.Center.Latitude, footprint.Center.Longitude, footprint.Uncertainty)
+	case t.locationProvider != nil:
+		var err error
+		if location, err = t.locationProvider(ctx); err != nil {
+			return fmt.Errorf("failed to resolve alert location: %w", err)
+		}
+	default:
+		return fmt.Errorf("failed to resolve alert location: no footprint")
 	}
 
 	clip, err := t.clipProvider(ctx)
@@ -94,22 +218,30 @@ func (t *Tracker) generateAlert(ctx context.Context, det vision.Detection) error
 		return fmt.Errorf("failed to build alert clip: %w", err)
 	}
 
+	now := time.Now().UTC()
 	alert := Alert{
 		ID:         uuid.New(),
+		TrackID:    tr.ID,
 		Type:       det.Class,
-		Confidence: det.Confidence,
+		Confidence: tr.Confidence,
 		Location:   location,
 		VideoClip:  clip,
-		Timestamp:  time.Now().UTC(),
+		Timestamp:  now,
 		Status:     AlertStatusNew,
+		UpdatedAt:  now,
+		Footprint:  footprint,
 	}
 
-	log.Printf("ALERT GENERATED: %s (confidence: %.2f)", alert.Type, alert.Confidence)
+	log.Printf("ALERT GENERATED: %s (confidence: %.2f, track %s over %d frames)",
+		alert.Type, alert.Confidence, tr.ID.String()[:8], tr.Hits)
 
-	// Send to alert channel (non-blocking)
+	// Send to alert channel (non-blocking); the track retries next frame
 	select {
 	case t.alertChan <- alert:
-		t.recentAlerts[det.Class] = time.Now()
+		tr.AlertID = alert.ID
+		retained := alert
+		retained.VideoClip = nil
+		t.alerts[alert.ID] = &retained
 	default:
 		return fmt.Errorf("alert channel full")
 	}
@@ -117,11 +249,12 @@ func (t *Tracker) generateAlert(ctx context.Context, det vision.Detection) error
 	return nil
 }
 
-func (t *Tracker) cleanupRecentAlerts() {
-	cutoff := time.Now().Add(-5 * time.Minute)
-	for class, timestamp := range t.recentAlerts {
-		if timestamp.Before(cutoff) {
-			delete(t.recentAlerts, class)
+// cleanupAlerts forgets resolved alerts after the retention period.
+func (t *Tracker) cleanupAlerts(now time.Time) {
+	cutoff := now.Add(-t.config.AlertRetention)
+	for id, a := range t.alerts {
+		if a.Status == AlertStatusResolved && a.UpdatedAt.Before(cutoff) {
+			delete(t.alerts, id)
 		}
 	}
 }
@@ -0,0 +1,90 @@
+package conjunction
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// cdmTimeFormat is the CCSDS ASCII time code A form used in CDMs.
+const cdmTimeFormat = "2006-01-02T15:04:05.000"
+
+// WriteCDM writes a conjunction as a CCSDS 508.0-B-1 Conjunction Data
+// Message in keyword=value notation. States are given in GCRF; covariances
+// are RTN. TLE covariances are modelled rather than estimated and carry no
+// velocity terms, so the velocity rows are written as zero.
+func WriteCDM(w io.Writer, c *Conjunction, originator string, created time.Time) error {
+	bw := bufio.NewWriter(w)
+	kv := func(key, value, unit string) {
+		if unit != "" {
+			fmt.Fprintf(bw, "%-36s = %s [%s]\n", key, value, unit)
+		} else {
+			fmt.Fprintf(bw, "%-36s = %s\n", key, value)
+		}
+	}
+	f := func(v float64, prec int) string { return strconv.FormatFloat(v, 'f', prec, 64) }
+	e := func(v float64) string { return strconv.FormatFloat(v, 'E', 6, 64) }
+
+	kv("CCSDS_CDM_VERS", "1.0", "")
+	kv("CREATION_DATE", created.UTC().Format(cdmTimeFormat), "")
+	kv("ORIGINATOR", originator, "")
+	kv("MESSAGE_ID", c.MessageID(originator), "")
+
+	kv("TCA", c.TCA.UTC().Format(cdmTimeFormat), "")
+	kv("MISS_DISTANCE", f(c.MissDistance*1000, 1), "m")
+	kv("RELATIVE_SPEED", f(c.RelativeSpeed*1000, 1), "m/s")
+	kv("RELATIVE_POSITION_R", f(c.RelativePosition[0]*1000, 1), "m")
+	kv("RELATIVE_POSITION_T", f(c.RelativePosition[1]*1000, 1), "m")
+	kv("RELATIVE_POSITION_N", f(c.RelativePosition[2]*1000, 1), "m")
+	kv("RELATIVE_VELOCITY_R", f(c.RelativeVelocity[0]*1000, 1), "m/s")
+	kv("RELATIVE_VELOCITY_T", f(c.RelativeVelocity[1]*1000, 1), "m/s")
+	kv("RELATIVE_VELOCITY_N", f(c.RelativeVelocity[2]*1000, 1), "m/s")
+	kv("COLLISION_PROBABILITY", e(c.Probability), "")
+	kv("COLLISION_PROBABILITY_METHOD", string(c.Method), "")
+
+	for i, obj := range []ObjectState{c.Primary, c.Secondary} {
+		r, v := frames.TEMEToGCRF(obj.Position, obj.Velocity, c.TCA, frames.EOP{})
+		kv("COMMENT", fmt.Sprintf("SGP4 element set epoch %s, hard body radius %s m", obj.Epoch.UTC().Format(cdmTimeFormat), f(c.HardBodyRadius*1000, 1)), "")
+		kv("OBJECT", fmt.Sprintf("OBJECT%d", i+1), "")
+		kv("OBJECT_DESIGNATOR", strconv.Itoa(obj.NoradID), "")
+		kv("CATALOG_NAME", "SATCAT", "")
+		kv("OBJECT_NAME", obj.Name, "")
+		kv("INTERNATIONAL_DESIGNATOR", obj.IntDesignator, "")
+		kv("EPHEMERIS_NAME", "NONE", "")
+		kv("COVARIANCE_METHOD", "DEFAULT", "")
+		kv("MANEUVERABLE", "N/A", "")
+		kv("REF_FRAME", "GCRF", "")
+		kv("X", f(r[0], 6), "km")
+		kv("Y", f(r[1], 6), "km")
+		kv("Z", f(r[2], 6), "km")
+		kv("X_DOT", f(v[0], 9), "km/s")
+		kv("Y_DOT", f(v[1], 9), "km/s")
+		kv("Z_DOT", f(v[2], 9), "km/s")
+
+		// Lower triangle, m²
+		cov := obj.Covariance
+		kv("CR_R", e(cov[0][0]*1e6), "m**2")
+		kv("CT_R", e(cov[1][0]*1e6), "m**2")
+		kv("CT_T", e(cov[1][1]*1e6), "m**2")
+		kv("CN_R", e(cov[2][0]*1e6), "m**2")
+		kv("CN_T", e(cov[2][1]*1e6), "m**2")
+		kv("CN_N", e(cov[2][2]*1e6), "m**2")
+		for _, row := range []string{"CRDOT", "CTDOT", "CNDOT"} {
+			kv(row+"_R", e(0), "m**2/s")
+			kv(row+"_T", e(0), "m**2/s")
+			kv(row+"_N", e(0), "m**2/s")
+			kv(row+"_RDOT", e(0), "m**2/s**2")
+			if row != "CRDOT" {
+				kv(row+"_TDOT", e(0), "m**2/s**2")
+			}
+			if row == "CNDOT" {
+				kv(row+"_NDOT", e(0), "m**2/s**2")
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,176 @@
+package conjunction
+
+import (
+	"math"
+)
+
+// Method selects how collision probability is computed.
+type Method string
+
+const (
+	// MethodFoster integrates the combined position density over the
+	// hard-body circle in polar coordinates (Foster and Estes, 1992).
+	MethodFoster Method = "FOSTER-1992"
+
+	// MethodAlfano reduces the integral to one dimension with error
+	// functions in the principal axes of the covariance (Alfano, 2005).
+	MethodAlfano Method = "ALFANO-2005"
+)
+
+// Covariance is a 3×3 position covariance in km².
+type Covariance [3][3]float64
+
+// EncounterPlane is the short-encounter geometry at TCA: the miss vector
+// and combined covariance projected onto the plane normal to the relative
+// velocity.
+type EncounterPlane struct {
+	Miss       [2]float64    // km
+	Covariance [2][2]float64 // km²
+}
+
+// NewEncounterPlane projects relative position r and velocity v (secondary
+// minus primary) and the combined covariance onto the encounter plane. The
+// first axis points along the miss vector.
+func NewEncounterPlane(r, v [3]float64, combined Covariance) EncounterPlane {
+	z := unit(v)
+	perp := sub(r, scale(z, dot(r, z)))
+	miss := norm(perp)
+	var x [3]float64
+	if miss > 0 {
+		x = scale(perp, 1/miss)
+	} else {
+		x = unit(anyPerpendicular(z))
+	}
+	y := cross(z, x)
+
+	var plane EncounterPlane
+	plane.Miss = [2]float64{miss, 0}
+	axes := [2][3]float64{x, y}
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			plane.Covariance[i][j] = dot(axes[i], mulCov(combined, axes[j]))
+		}
+	}
+	return plane
+}
+
+// Probability returns the probability that the objects pass within
+// hardBodyRadius km of each other.
+func (p EncounterPlane) Probability(hardBodyRadius float64, method Method) float64 {
+	if method == MethodAlfano {
+		return p.alfano(hardBodyRadius)
+	}
+	return p.foster(hardBodyRadius)
+}
+
+// foster integrates the bivariate normal density over the hard-body circle
+// with Simpson's rule in radius and the trapezoidal rule, exact for
+// periodic integrands, in angle.
+func (p EncounterPlane) foster(hbr float64) float64 {
+	const nr, nt = 64, 180
+	c := p.Covariance
+	det := c[0][0]*c[1][1] - c[0][1]*c[1][0]
+	if det <= 0 || hbr <= 0 {
+		return 0
+	}
+	inv := [2][2]float64{{c[1][1] / det, -c[0][1] / det}, {-c[1][0] / det, c[0][0] / det}}
+	norm := 1 / (2 * math.Pi * math.Sqrt(det))
+
+	density := func(x, y float64) float64 {
+		q := x*(inv[0][0]*x+inv[0][1]*y) + y*(inv[1][0]*x+inv[1][1]*y)
+		return norm * math.Exp(-q/2)
+	}
+
+	dr := hbr / nr
+	dt := 2 * math.Pi / nt
+	sum := 0.0
+	for i := 0; i <= nr; i++ {
+		rho := float64(i) * dr
+		w := 2.0
+		switch {
+		case i == 0 || i == nr:
+			w = 1
+		case i%2 == 1:
+			w = 4
+		}
+		ring := 0.0
+		for j := 0; j < nt; j++ {
+			s, co := math.Sincos(float64(j) * dt)
+			ring += density(p.Miss[0]+rho*co, p.Miss[1]+rho*s)
+		}
+		sum += w * rho * ring * dt
+	}
+	return sum * dr / 3
+}
+
+// alfano evaluates the one-dimensional error function form in the
+// principal axes of the covariance with Simpson's rule.
+func (p EncounterPlane) alfano(hbr float64) float64 {
+	const n = 200
+	c := p.Covariance
+	if hbr <= 0 {
+		return 0
+	}
+
+	// Principal axes
+	theta := 0.5 * math.Atan2(2*c[0][1], c[0][0]-c[1][1])
+	s, co := math.Sincos(theta)
+	varX := co*co*c[0][0] + 2*s*co*c[0][1] + s*s*c[1][1]
+	varY := s*s*c[0][0] - 2*s*co*c[0][1] + co*co*c[1][1]
+	if varX <= 0 || varY <= 0 {
+		return 0
+	}
+	sx, sy := math.Sqrt(varX), math.Sqrt(varY)
+	xm := co*p.Miss[0] + s*p.Miss[1]
+	ym := -s*p.Miss[0] + co*p.Miss[1]
+
+	// Substituting x = -R cos φ removes the square-root singularity at the
+	// ends of the chord
+	f := func(phi float64) float64 {
+		sinPhi, cosPhi := math.Sincos(phi)
+		x, half := -hbr*cosPhi, hbr*sinPhi
+		band := math.Erf((ym+half)/(math.Sqrt2*sy)) - math.Erf((ym-half)/(math.Sqrt2*sy))
+		return band * math.Exp(-(xm+x)*(xm+x)/(2*varX)) * hbr * sinPhi
+	}
+
+	h := math.Pi / n
+	sum := f(0) + f(math.Pi)
+	for i := 1; i < n; i++ {
+		w := 2.0
+		if i%2 == 1 {
+			w = 4
+		}
+		sum += w * f(float64(i)*h)
+	}
+	return sum * h / 3 / (2 * math.Sqrt(2*math.Pi) * sx)
+}
+
+func dot(a, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+func norm(a [3]float64) float64 { return math.Sqrt(dot(a, a)) }
+
+func sub(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+
+func scale(a [3]float64, k float64) [3]float64 { return [3]float64{a[0] * k, a[1] * k, a[2] * k} }
+
+func unit(a [3]float64) [3]float64 { return scale(a, 1/norm(a)) }
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func mulCov(c Covariance, v [3]float64) [3]float64 {
+	return [3]float64{dot(c[0], v), dot(c[1], v), dot(c[2], v)}
+}
+
+// anyPerpendicular returns a vector perpendicular to a.
+func anyPerpendicular(a [3]float64) [3]float64 {
+	if math.Abs(a[0]) < 0.9 {
+		return cross(a, [3]float64{1, 0, 0})
+	}
+	return cross(a, [3]float64{0, 1, 0})
+}
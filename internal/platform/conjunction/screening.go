@@ -0,0 +1,371 @@
+// Package conjunction screens tracked satellites against the catalog for
+// close approaches, estimates the probability of collision and reports
+// them as CCSDS Conjunction Data Messages and control plane events.
+package conjunction
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/controlplane"
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+// earthMu is the WGS-72 gravitational parameter used by SGP4, km³/s².
+const earthMu = 398600.8
+
+// Config holds screening parameters.
+type Config struct {
+	Window            time.Duration // Screening span from the start time
+	Step              time.Duration // Coarse sampling step
+	Precision         time.Duration // TCA refinement tolerance
+	ScreeningDistance float64       // Report approaches closer than this, km
+	ApogeePerigeePad  float64       // Margin on the apogee/perigee filter, km
+	HardBodyRadius    float64       // Combined object radius, km
+	Method            Method
+
+	// HighRiskProbability is the Pc at or above which a conjunction is
+	// published to the control plane
+	HighRiskProbability float64
+
+	// CovarianceModel gives an object's RTN position covariance a given
+	// time after its element set epoch. TLEs carry no covariance, so a
+	// growth model stands in until orbit determination provides one.
+	CovarianceModel func(noradID int, sinceEpoch time.Duration) Covariance
+
+	Source string // Event source and CDM originator
+}
+
+// DefaultConfig returns a one-day, 5 km screen with a 20 m hard body.
+func DefaultConfig() Config {
+	return Config{
+		Window:              24 * time.Hour,
+		Step:                60 * time.Second,
+		Precision:           time.Millisecond,
+		ScreeningDistance:   5,
+		ApogeePerigeePad:    10,
+		HardBodyRadius:      0.02,
+		Method:              MethodFoster,
+		HighRiskProbability: 1e-4,
+		CovarianceModel:     DefaultCovarianceModel,
+		Source:              "asgard-conjunction",
+	}
+}
+
+// DefaultCovarianceModel approximates public TLE accuracy: about 100 m
+// radial and cross-track and 500 m in-track at epoch, growing by 50 m and
+// 1 km per day respectively.
+func DefaultCovarianceModel(noradID int, sinceEpoch time.Duration) Covariance {
+	days := math.Abs(sinceEpoch.Hours()) / 24
+	r := 0.1 + 0.05*days
+	t := 0.5 + 1.0*days
+	n := 0.1 + 0.05*days
+	return Covariance{{r * r, 0, 0}, {0, t * t, 0}, {0, 0, n * n}}
+}
+
+// EventPublisher publishes control plane events. The unified control
+// plane implements it.
+type EventPublisher interface {
+	PublishEvent(event controlplane.CrossDomainEvent) error
+}
+
+// ObjectState describes one object at TCA.
+type ObjectState struct {
+	NoradID       int        `json:"norad_id"`
+	Name          string     `json:"name"`
+	IntDesignator string     `json:"int_designator,omitempty"`
+	Epoch         time.Time  `json:"epoch"`      // Element set epoch
+	Position      [3]float64 `json:"position"`   // TEME, km
+	Velocity      [3]float64 `json:"velocity"`   // TEME, km/s
+	Covariance    Covariance `json:"covariance"` // RTN, km²
+}
+
+// Conjunction is a close approach found by screening.
+type Conjunction struct {
+	Primary          ObjectState `json:"primary"`
+	Secondary        ObjectState `json:"secondary"`
+	TCA              time.Time   `json:"tca"`
+	MissDistance     float64     `json:"miss_distance_km"`
+	RelativeSpeed    float64     `json:"relative_speed_kms"`
+	RelativePosition [3]float64  `json:"relative_position_rtn"` // Secondary from primary in the primary's RTN frame, km
+	RelativeVelocity [3]float64  `json:"relative_velocity_rtn"` // km/s
+	Probability      float64     `json:"probability"`
+	Method           Method      `json:"method"`
+	HardBodyRadius   float64     `json:"hard_body_radius_km"`
+}
+
+// MessageID returns a CDM message ID unique to the pair and TCA.
+func (c *Conjunction) MessageID(originator string) string {
+	return fmt.Sprintf("%s_%05d_%05d_%s", originator, c.Primary.NoradID, c.Secondary.NoradID, c.TCA.UTC().Format("20060102T150405"))
+}
+
+// Screener screens primaries against a catalog.
+type Screener struct {
+	cfg       Config
+	publisher EventPublisher
+}
+
+// NewScreener creates a screener. publisher may be nil.
+func NewScreener(cfg Config, publisher EventPublisher) *Screener {
+	if cfg.CovarianceModel == nil {
+		cfg.CovarianceModel = DefaultCovarianceModel
+	}
+	return &Screener{cfg: cfg, publisher: publisher}
+}
+
+// screenObject is a catalog object ready for screening.
+type screenObject struct {
+	tle        *satellite.TLE
+	propagator *satellite.Propagator
+	perigee    float64 // km from Earth centre
+	apogee     float64
+}
+
+func newScreenObject(tle *satellite.TLE) (*screenObject, error) {
+	elements, err := satellite.ParseTLE(tle.Line1, tle.Line2)
+	if err != nil {
+		return nil, err
+	}
+	p, err := satellite.NewPropagator(tle)
+	if err != nil {
+		return nil, err
+	}
+	n := elements.MeanMotion * 2 * math.Pi / 86400
+	a := math.Cbrt(earthMu / (n * n))
+	return &screenObject{
+		tle:        tle,
+		propagator: p,
+		perigee:    a * (1 - elements.Eccentricity),
+		apogee:     a * (1 + elements.Eccentricity),
+	}, nil
+}
+
+// Screen finds every approach closer than the screening distance between
+// each primary and each catalog object over the window from start, closest
+// first. Objects sharing a primary's catalog number are skipped. High-risk
+// conjunctions are published.
+func (s *Screener) Screen(ctx context.Context, primaries, catalog []*satellite.TLE, start time.Time) ([]Conjunction, error) {
+	if s.cfg.Step <= 0 || s.cfg.Precision <= 0 || s.cfg.Window <= 0 {
+		return nil, fmt.Errorf("screening window, step and precision must be positive")
+	}
+
+	prim := make([]*screenObject, 0, len(primaries))
+	for _, tle := range primaries {
+		o, err := newScreenObject(tle)
+		if err != nil {
+			return nil, fmt.Errorf("primary %d: %w", tle.SatelliteID, err)
+		}
+		prim = append(prim, o)
+	}
+	secondaries := make([]*screenObject, 0, len(catalog))
+	for _, tle := range catalog {
+		o, err := newScreenObject(tle)
+		if err != nil {
+			log.Printf("[Conjunction] Skipping %d: %v", tle.SatelliteID, err)
+			continue
+		}
+		secondaries = append(secondaries, o)
+	}
+
+	var (
+		mu      sync.Mutex
+		results []Conjunction
+		wg      sync.WaitGroup
+	)
+	for _, p := range prim {
+		wg.Add(1)
+		go func(p *screenObject) {
+			defer wg.Done()
+			for _, sec := range secondaries {
+				if ctx.Err() != nil {
+					return
+				}
+				if sec.tle.SatelliteID == p.tle.SatelliteID || !s.orbitsOverlap(p, sec) {
+					continue
+				}
+				found := s.screenPair(p, sec, start)
+				mu.Lock()
+				results = append(results, found...)
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].MissDistance < results[j].MissDistance })
+	for i := range results {
+		s.publish(&results[i])
+	}
+	return results, nil
+}
+
+// orbitsOverlap is the apogee/perigee filter: two orbits whose radial
+// shells are further apart than the screening distance cannot meet.
+func (s *Screener) orbitsOverlap(a, b *screenObject) bool {
+	margin := s.cfg.ScreeningDistance + s.cfg.ApogeePerigeePad
+	return math.Max(a.perigee, b.perigee)-math.Min(a.apogee, b.apogee) <= margin
+}
+
+// screenPair samples the relative range and refines each local minimum
+// within the screening distance.
+func (s *Screener) screenPair(p, sec *screenObject, start time.Time) []Conjunction {
+	// rangeRate is proportional to the derivative of the separation
+	rangeRate := func(t time.Time) (float64, bool) {
+		rp, vp, rs, vs, ok := states(p, sec, t)
+		if !ok {
+			return 0, false
+		}
+		return dot(sub(rs, rp), sub(vs, vp)), true
+	}
+
+	var found []Conjunction
+	end := start.Add(s.cfg.Window)
+	t := start
+	prev, ok := rangeRate(t)
+	if !ok {
+		return nil
+	}
+	for t.Before(end) {
+		next := t.Add(s.cfg.Step)
+		cur, ok := rangeRate(next)
+		if !ok {
+			return found
+		}
+		if prev < 0 && cur >= 0 {
+			tca := s.refineTCA(rangeRate, t, next)
+			if c, ok := s.assess(p, sec, tca); ok && c.MissDistance <= s.cfg.ScreeningDistance {
+				found = append(found, c)
+			}
+		}
+		t, prev = next, cur
+	}
+	return found
+}
+
+// refineTCA bisects for the zero of the range rate between lo and hi.
+func (s *Screener) refineTCA(rangeRate func(time.Time) (float64, bool), lo, hi time.Time) time.Time {
+	for hi.Sub(lo) > s.cfg.Precision {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		rr, ok := rangeRate(mid)
+		if !ok {
+			break
+		}
+		if rr < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo.Add(hi.Sub(lo) / 2)
+}
+
+// assess computes the encounter geometry and probability at TCA.
+func (s *Screener) assess(p, sec *screenObject, tca time.Time) (Conjunction, bool) {
+	rp, vp, rs, vs, ok := states(p, sec, tca)
+	if !ok {
+		return Conjunction{}, false
+	}
+	dr, dv := sub(rs, rp), sub(vs, vp)
+
+	primary := s.objectState(p, tca, rp, vp)
+	secondary := s.objectState(sec, tca, rs, vs)
+	combined := addCov(rtnToInertial(primary.Covariance, rp, vp), rtnToInertial(secondary.Covariance, rs, vs))
+	plane := NewEncounterPlane(dr, dv, combined)
+
+	rtn := rtnBasis(rp, vp)
+	return Conjunction{
+		Primary:          primary,
+		Secondary:        secondary,
+		TCA:              tca,
+		MissDistance:     norm(dr),
+		RelativeSpeed:    norm(dv),
+		RelativePosition: [3]float64{dot(rtn[0], dr), dot(rtn[1], dr), dot(rtn[2], dr)},
+		RelativeVelocity: [3]float64{dot(rtn[0], dv), dot(rtn[1], dv), dot(rtn[2], dv)},
+		Probability:      plane.Probability(s.cfg.HardBodyRadius, s.cfg.Method),
+		Method:           s.cfg.Method,
+		HardBodyRadius:   s.cfg.HardBodyRadius,
+	}, true
+}
+
+func (s *Screener) objectState(o *screenObject, tca time.Time, r, v [3]float64) ObjectState {
+	epoch := o.propagator.Epoch()
+	return ObjectState{
+		NoradID:       o.tle.SatelliteID,
+		Name:          o.tle.Name,
+		IntDesignator: satellite.InternationalDesignator(o.tle.Line1),
+		Epoch:         epoch,
+		Position:      r,
+		Velocity:      v,
+		Covariance:    s.cfg.CovarianceModel(o.tle.SatelliteID, tca.Sub(epoch)),
+	}
+}
+
+// publish sends a control plane event for a high-risk conjunction.
+func (s *Screener) publish(c *Conjunction) {
+	if s.publisher == nil || c.Probability < s.cfg.HighRiskProbability {
+		return
+	}
+	event := controlplane.NewConjunctionEvent(s.cfg.Source, c.Primary.NoradID, c.Secondary.NoradID, c.TCA, c.MissDistance, c.Probability)
+	event.MessageID = c.MessageID(s.cfg.Source)
+	event.Payload["message_id"] = event.MessageID
+	event.Payload["primary_name"] = c.Primary.Name
+	event.Payload["secondary_name"] = c.Secondary.Name
+	event.Payload["relative_speed_kms"] = c.RelativeSpeed
+	event.Tags = []string{"conjunction", fmt.Sprintf("norad:%d", c.Primary.NoradID)}
+	if err := s.publisher.PublishEvent(event.CrossDomainEvent); err != nil {
+		log.Printf("[Conjunction] Failed to publish %s: %v", event.MessageID, err)
+	}
+}
+
+func states(p, sec *screenObject, t time.Time) (rp, vp, rs, vs [3]float64, ok bool) {
+	a, err := p.propagator.PropagateState(t)
+	if err != nil {
+		return rp, vp, rs, vs, false
+	}
+	b, err := sec.propagator.PropagateState(t)
+	if err != nil {
+		return rp, vp, rs, vs, false
+	}
+	return a.Position, a.Velocity, b.Position, b.Velocity, true
+}
+
+// rtnBasis returns the radial, transverse and normal unit vectors.
+func rtnBasis(r, v [3]float64) [3][3]float64 {
+	rHat := unit(r)
+	nHat := unit(cross(r, v))
+	return [3][3]float64{rHat, cross(nHat, rHat), nHat}
+}
+
+// rtnToInertial rotates an RTN covariance into the frame of r and v.
+func rtnToInertial(c Covariance, r, v [3]float64) Covariance {
+	b := rtnBasis(r, v)
+	var out Covariance
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					out[i][j] += b[k][i] * c[k][l] * b[l][j]
+				}
+			}
+		}
+	}
+	return out
+}
+
+func addCov(a, b Covariance) Covariance {
+	var out Covariance
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			out[i][j] = a[i][j] + b[i][j]
+		}
+	}
+	return out
+}
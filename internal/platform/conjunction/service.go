@@ -0,0 +1,88 @@
+package conjunction
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+// Service periodically screens a fleet against the newest element sets in
+// the catalog and keeps the latest results.
+type Service struct {
+	screener *Screener
+	catalog  *satellite.Catalog
+	fleet    []int
+	interval time.Duration
+
+	mu         sync.RWMutex
+	latest     []Conjunction
+	screenedAt time.Time
+}
+
+// NewService creates a screening service for the given NORAD IDs.
+func NewService(screener *Screener, catalog *satellite.Catalog, fleet []int, interval time.Duration) *Service {
+	return &Service{
+		screener: screener,
+		catalog:  catalog,
+		fleet:    fleet,
+		interval: interval,
+	}
+}
+
+// Run screens immediately and then every interval until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.ScreenNow(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[Conjunction] Screening failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ScreenNow screens the fleet members present in the catalog.
+func (s *Service) ScreenNow(ctx context.Context) ([]Conjunction, error) {
+	tles, err := s.catalog.TLEs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fleet := make(map[int]bool, len(s.fleet))
+	for _, id := range s.fleet {
+		fleet[id] = true
+	}
+	var primaries []*satellite.TLE
+	for _, tle := range tles {
+		if fleet[tle.SatelliteID] {
+			primaries = append(primaries, tle)
+		}
+	}
+
+	now := time.Now().UTC()
+	results, err := s.screener.Screen(ctx, primaries, tles, now)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Conjunction] Screened %d primaries against %d objects: %d conjunctions",
+		len(primaries), len(tles), len(results))
+
+	s.mu.Lock()
+	s.latest = results
+	s.screenedAt = now
+	s.mu.Unlock()
+	return results, nil
+}
+
+// Latest returns the results of the most recent screening and when it ran.
+func (s *Service) Latest() ([]Conjunction, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest, s.screenedAt
+}
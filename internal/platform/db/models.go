@@ -69,6 +69,29 @@ type Satellite struct {
 	UpdatedAt             time.Time       `db:"updated_at"`
 }
 
+// TLEElementSet is one epoch of a catalog object's element set history
+type TLEElementSet struct {
+	ID             int64          `db:"id"`
+	NoradID        int            `db:"norad_id"`
+	Name           string         `db:"name"`
+	IntDesignator  sql.NullString `db:"int_designator"`
+	Epoch          time.Time      `db:"epoch"`
+	Line1          string         `db:"line1"`
+	Line2          string         `db:"line2"`
+	MeanMotion     float64        `db:"mean_motion"`
+	MeanMotionDot  float64        `db:"mean_motion_dot"`
+	Eccentricity   float64        `db:"eccentricity"`
+	Inclination    float64        `db:"inclination"`
+	RAAN           float64        `db:"raan"`
+	ArgPerigee     float64        `db:"arg_perigee"`
+	MeanAnomaly    float64        `db:"mean_anomaly"`
+	BStar          float64        `db:"bstar"`
+	Source         string         `db:"source"`
+	Maneuver       bool           `db:"maneuver"`
+	ManeuverReason sql.NullString `db:"maneuver_reason"`
+	ImportedAt     time.Time      `db:"imported_at"`
+}
+
 // Hunoid represents a humanoid robot
 type Hunoid struct {
 	ID               uuid.UUID       `db:"id"`
@@ -0,0 +1,254 @@
+package dtn
+
+import (
+	"log"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+	"github.com/google/uuid"
+)
+
+// custodyRecord tracks a bundle this node holds custody of until the next
+// custodian acknowledges it.
+type custodyRecord struct {
+	bundleID uuid.UUID
+	deadline time.Time
+	retries  int
+}
+
+// SetStatusReportHandler registers a callback for status reports addressed
+// to this node.
+func (n *Node) SetStatusReportHandler(handler func(report *bundle.StatusReport)) {
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+	n.statusHandler = handler
+}
+
+// reportStatus sends a status report about b if its flags request one.
+// kind is one of the bundle.FlagReport* flags.
+func (n *Node) reportStatus(b *bundle.Bundle, kind uint64, reason bundle.StatusReason) {
+	// RFC 9171 Section 4.2.3: administrative records never request reports
+	if b.BundleFlags&kind == 0 || b.IsAdminRecord() {
+		return
+	}
+	if b.ReportTo == "" || b.ReportTo == bundle.NullEID {
+		return
+	}
+
+	record := bundle.NewStatusReport(b, kind, reason, n.now())
+	if err := n.sendAdminRecord(b.ReportTo, record); err != nil {
+		log.Printf("[DTN Node %s] Failed to send status report for %s: %v", n.ID, b.ID.String()[:8], err)
+		return
+	}
+	n.recordMetric(func(m *NodeMetrics) { m.StatusReportsSent++ })
+}
+
+// sendAdminRecord wraps record in a bundle and routes it to destination.
+func (n *Node) sendAdminRecord(destination string, record *bundle.AdminRecord) error {
+	b, err := bundle.NewAdminBundle(n.EID, destination, record)
+	if err != nil {
+		return err
+	}
+	b.Priority = bundle.PriorityExpedited
+
+	if destination == n.EID {
+		n.handleAdminRecord(b)
+		return nil
+	}
+	return n.Send(n.ctx, b)
+}
+
+// handleAdminRecord processes a status report or custody signal addressed
+// to this node.
+func (n *Node) handleAdminRecord(b *bundle.Bundle) {
+	record, err := bundle.ParseAdminRecord(b.Payload)
+	if err != nil {
+		log.Printf("[DTN Node %s] Malformed administrative record from %s: %v", n.ID, b.SourceEID, err)
+		n.recordMetric(func(m *NodeMetrics) { m.BundlesDropped++ })
+		return
+	}
+
+	switch record.Type {
+	case bundle.AdminRecordStatusReport:
+		report := record.StatusReport
+		log.Printf("[DTN Node %s] Status report from %s for bundle %s: received=%t forwarded=%t delivered=%t deleted=%t (%s)",
+			n.ID, b.SourceEID, report.Subject.Key(), report.Received.Asserted, report.Forwarded.Asserted,
+			report.Delivered.Asserted, report.Deleted.Asserted, report.Reason)
+
+		n.adminMu.Lock()
+		handler := n.statusHandler
+		n.adminMu.Unlock()
+		if handler != nil {
+			handler(report)
+		}
+	case bundle.AdminRecordCustodySignal:
+		n.handleCustodySignal(b.SourceEID, record.CustodySignal)
+	}
+}
+
+// custodyEnabled reports whether this node takes custody of b.
+func (n *Node) custodyEnabled(b *bundle.Bundle) bool {
+	return n.config.CustodyTimeout > 0 && b.BundleFlags&bundle.FlagCustodyRequested != 0 && !b.IsAdminRecord()
+}
+
+// acceptCustody signals the previous custodian that this node now holds b.
+func (n *Node) acceptCustody(b *bundle.Bundle) {
+	if b.BundleFlags&bundle.FlagCustodyRequested == 0 || b.Custodian == "" || b.Custodian == n.EID {
+		return
+	}
+	if err := n.sendAdminRecord(b.Custodian, bundle.NewCustodySignal(b, true, bundle.ReasonNoInfo)); err != nil {
+		log.Printf("[DTN Node %s] Failed to signal custody of %s to %s: %v", n.ID, b.ID.String()[:8], b.Custodian, err)
+	}
+}
+
+// refuseCustody tells the previous custodian it must keep b.
+func (n *Node) refuseCustody(b *bundle.Bundle, reason bundle.StatusReason) {
+	if b.BundleFlags&bundle.FlagCustodyRequested == 0 || b.Custodian == "" || b.Custodian == n.EID {
+		return
+	}
+	if err := n.sendAdminRecord(b.Custodian, bundle.NewCustodySignal(b, false, reason)); err != nil {
+		log.Printf("[DTN Node %s] Failed to refuse custody of %s to %s: %v", n.ID, b.ID.String()[:8], b.Custodian, err)
+	}
+}
+
+// trackCustody keeps b in storage until a custody signal arrives or the
+// retransmission timer expires.
+func (n *Node) trackCustody(b *bundle.Bundle) {
+	if err := n.storage.Store(n.ctx, b); err != nil {
+		log.Printf("[DTN Node %s] Failed to retain custody of %s: %v", n.ID, b.ID.String()[:8], err)
+		return
+	}
+	n.storage.UpdateStatus(n.ctx, b.ID, StatusInTransit)
+
+	key := bundle.RefOf(b).Key()
+
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+
+	record, exists := n.custody[key]
+	if !exists {
+		record = &custodyRecord{}
+		n.custody[key] = record
+	}
+	record.bundleID = b.ID
+	record.deadline = n.now().Add(n.config.CustodyTimeout)
+}
+
+// restoreCustody rebuilds the custody records of bundles that were in
+// transit when the node last stopped, so they are retransmitted if no
+// custody signal arrives.
+func (n *Node) restoreCustody() {
+	if n.config.CustodyTimeout <= 0 {
+		return
+	}
+	bundles, err := n.storage.List(n.ctx, BundleFilter{Status: StatusInTransit})
+	if err != nil {
+		log.Printf("[DTN Node %s] Failed to restore custody: %v", n.ID, err)
+		return
+	}
+
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+
+	deadline := n.now().Add(n.config.CustodyTimeout)
+	restored := 0
+	for _, b := range bundles {
+		if !n.custodyEnabled(b) || b.Custodian != n.EID {
+			continue
+		}
+		n.custody[bundle.RefOf(b).Key()] = &custodyRecord{bundleID: b.ID, deadline: deadline}
+		restored++
+	}
+	if restored > 0 {
+		log.Printf("[DTN Node %s] Restored custody of %d bundles", n.ID, restored)
+	}
+}
+
+// untrackCustody stops the retransmission timer for b after a failed send;
+// the bundle then follows the regular failed/deferred retry path.
+func (n *Node) untrackCustody(b *bundle.Bundle) {
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+	delete(n.custody, bundle.RefOf(b).Key())
+}
+
+// handleCustodySignal releases or keeps custody based on a downstream signal.
+func (n *Node) handleCustodySignal(from string, signal *bundle.CustodySignal) {
+	key := signal.Subject.Key()
+
+	n.adminMu.Lock()
+	record, exists := n.custody[key]
+	if exists && signal.Accepted {
+		delete(n.custody, key)
+	}
+	n.adminMu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	if !signal.Accepted {
+		log.Printf("[DTN Node %s] %s refused custody of %s (%s), retaining", n.ID, from, key, signal.Reason)
+		return
+	}
+
+	n.storage.Delete(n.ctx, record.bundleID)
+	log.Printf("[DTN Node %s] Custody of %s transferred to %s", n.ID, key, from)
+	n.recordMetric(func(m *NodeMetrics) { m.CustodyTransfers++ })
+}
+
+// checkCustody retransmits bundles whose custody was not acknowledged in
+// time. After MaxRetries attempts the bundle falls back to the failed queue.
+func (n *Node) checkCustody() {
+	now := n.now()
+
+	type expired struct {
+		key    string
+		record custodyRecord
+	}
+	var due []expired
+
+	n.adminMu.Lock()
+	for key, record := range n.custody {
+		if now.Before(record.deadline) {
+			continue
+		}
+		record.retries++
+		record.deadline = now.Add(n.config.CustodyTimeout)
+		due = append(due, expired{key: key, record: *record})
+		if n.config.MaxRetries > 0 && record.retries > n.config.MaxRetries {
+			delete(n.custody, key)
+		}
+	}
+	n.adminMu.Unlock()
+
+	for _, item := range due {
+		id := item.record.bundleID
+		b, err := n.storage.Retrieve(n.ctx, id)
+		if err != nil {
+			n.adminMu.Lock()
+			delete(n.custody, item.key)
+			n.adminMu.Unlock()
+			continue
+		}
+
+		if n.config.MaxRetries > 0 && item.record.retries > n.config.MaxRetries {
+			log.Printf("[DTN Node %s] Custody of %s not acknowledged after %d retries", n.ID, item.key, n.config.MaxRetries)
+			n.storage.UpdateStatus(n.ctx, id, StatusFailed)
+			continue
+		}
+
+		log.Printf("[DTN Node %s] Custody timeout for %s, retransmitting (attempt %d)", n.ID, item.key, item.record.retries)
+		n.recordMetric(func(m *NodeMetrics) { m.CustodyRetransmissions++ })
+		if err := n.Send(n.ctx, b); err != nil {
+			log.Printf("[DTN Node %s] Failed to requeue %s: %v", n.ID, item.key, err)
+		}
+	}
+}
+
+// CustodyCount returns the number of bundles awaiting a custody signal.
+func (n *Node) CustodyCount() int {
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+	return len(n.custody)
+}
@@ -0,0 +1,278 @@
+package dtn
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+// Route is a path through the contact graph computed by CGR.
+type Route struct {
+	Contacts    []Contact
+	NextHop     string    // Receiving node of the first contact
+	ArrivalTime time.Time // Best-case arrival at the destination
+	ExpiresAt   time.Time // End of the earliest-ending contact on the route
+	Confidence  float64   // Product of contact confidences
+}
+
+// cgrRoute is a route expressed as indices into a contactGraph.
+type cgrRoute struct {
+	hops       []int
+	arrival    time.Time
+	expires    time.Time
+	confidence float64
+}
+
+// contactGraph indexes contacts by sending node for route searches.
+// Contacts are the vertices; an edge joins two contacts when the first
+// one's receiver is the second one's sender.
+type contactGraph struct {
+	contacts []Contact
+	out      map[string][]int
+}
+
+func newContactGraph(contacts []Contact) *contactGraph {
+	g := &contactGraph{
+		contacts: contacts,
+		out:      make(map[string][]int),
+	}
+	for i, c := range contacts {
+		g.out[c.From] = append(g.out[c.From], i)
+	}
+	return g
+}
+
+// evaluate computes arrival time, expiry and confidence for a route that
+// starts at the given time. It returns false if a contact on the route
+// ends before the bundle reaches it.
+func (g *contactGraph) evaluate(hops []int, start time.Time) (cgrRoute, bool) {
+	r := cgrRoute{hops: hops, arrival: start, expires: contactForever, confidence: 1}
+	for _, i := range hops {
+		c := &g.contacts[i]
+		if !c.End.After(r.arrival) {
+			return r, false
+		}
+		r.arrival = laterOf(r.arrival, c.Start).Add(c.OWLT)
+		if c.End.Before(r.expires) {
+			r.expires = c.End
+		}
+		if c.Confidence > 0 {
+			r.confidence *= c.Confidence
+		}
+	}
+	return r, true
+}
+
+// shortest finds the earliest-arrival route from source to dest with a
+// Dijkstra search over contacts, skipping excluded contacts and contacts
+// that lead back into any avoided node.
+func (g *contactGraph) shortest(source string, start time.Time, dest string, excluded map[int]bool, avoid map[string]bool) []int {
+	n := len(g.contacts)
+	arrival := make([]time.Time, n)
+	hops := make([]int, n)
+	prev := make([]int, n)
+	reached := make([]bool, n)
+	visited := make([]bool, n)
+
+	onPath := func(i int, node string) bool {
+		for ; i >= 0; i = prev[i] {
+			if g.contacts[i].From == node {
+				return true
+			}
+		}
+		return false
+	}
+
+	queue := &contactQueue{}
+	relax := func(from int, j int, at time.Time, depth int) {
+		c := &g.contacts[j]
+		if excluded[j] || visited[j] || avoid[c.To] || c.To == source || !c.End.After(at) {
+			return
+		}
+		if from >= 0 && onPath(from, c.To) {
+			return
+		}
+		t := laterOf(at, c.Start).Add(c.OWLT)
+		if reached[j] && (t.After(arrival[j]) || (t.Equal(arrival[j]) && depth >= hops[j])) {
+			return
+		}
+		reached[j] = true
+		arrival[j] = t
+		hops[j] = depth
+		prev[j] = from
+		heap.Push(queue, contactQueueItem{index: j, arrival: t, hops: depth})
+	}
+
+	for _, j := range g.out[source] {
+		relax(-1, j, start, 1)
+	}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(contactQueueItem)
+		i := item.index
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+
+		if g.contacts[i].To == dest {
+			path := make([]int, hops[i])
+			for k := len(path) - 1; k >= 0; k-- {
+				path[k] = i
+				i = prev[i]
+			}
+			return path
+		}
+
+		for _, j := range g.out[g.contacts[i].To] {
+			relax(i, j, arrival[i], hops[i]+1)
+		}
+	}
+	return nil
+}
+
+// kShortest returns up to k loop-free routes ordered by arrival time using
+// Yen's algorithm over the contact graph.
+func (g *contactGraph) kShortest(source string, start time.Time, dest string, k int) []cgrRoute {
+	first := g.shortest(source, start, dest, nil, nil)
+	if first == nil {
+		return nil
+	}
+	best, _ := g.evaluate(first, start)
+	routes := []cgrRoute{best}
+
+	var candidates []cgrRoute
+	seen := map[string]bool{hopsKey(first): true}
+
+	for len(routes) < k {
+		last := routes[len(routes)-1].hops
+		for i := range last {
+			root := last[:i]
+			spurNode := source
+			spurTime := start
+			if i > 0 {
+				r, _ := g.evaluate(root, start)
+				spurNode = g.contacts[root[i-1]].To
+				spurTime = r.arrival
+			}
+
+			// Remove the next contact of every known route sharing this root
+			excluded := make(map[int]bool)
+			for _, r := range routes {
+				if len(r.hops) > i && sameHops(r.hops[:i], root) {
+					excluded[r.hops[i]] = true
+				}
+			}
+			// Keep the spur path from revisiting nodes on the root path
+			avoid := map[string]bool{source: true}
+			for _, h := range root {
+				avoid[g.contacts[h].From] = true
+			}
+
+			spur := g.shortest(spurNode, spurTime, dest, excluded, avoid)
+			if spur == nil {
+				continue
+			}
+			hops := append(append([]int(nil), root...), spur...)
+			key := hopsKey(hops)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if r, ok := g.evaluate(hops, start); ok {
+				candidates = append(candidates, r)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return routeLess(candidates[a], candidates[b])
+		})
+		routes = append(routes, candidates[0])
+		candidates = candidates[1:]
+	}
+	return routes
+}
+
+// export converts an internal route into the public Route form.
+func (g *contactGraph) export(r cgrRoute) Route {
+	route := Route{
+		Contacts:    make([]Contact, len(r.hops)),
+		ArrivalTime: r.arrival,
+		ExpiresAt:   r.expires,
+		Confidence:  r.confidence,
+	}
+	for i, h := range r.hops {
+		route.Contacts[i] = g.contacts[h]
+	}
+	if len(route.Contacts) > 0 {
+		route.NextHop = route.Contacts[0].To
+	}
+	return route
+}
+
+// routeLess orders routes by arrival time, then hop count, then by the
+// later expiry.
+func routeLess(a, b cgrRoute) bool {
+	if !a.arrival.Equal(b.arrival) {
+		return a.arrival.Before(b.arrival)
+	}
+	if len(a.hops) != len(b.hops) {
+		return len(a.hops) < len(b.hops)
+	}
+	return a.expires.After(b.expires)
+}
+
+func sameHops(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hopsKey(hops []int) string {
+	key := make([]byte, 0, len(hops)*4)
+	for _, h := range hops {
+		key = append(key, byte(h>>24), byte(h>>16), byte(h>>8), byte(h))
+	}
+	return string(key)
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// contactQueue is a min-heap of contacts ordered by arrival time.
+type contactQueueItem struct {
+	index   int
+	arrival time.Time
+	hops    int
+}
+
+type contactQueue []contactQueueItem
+
+func (q contactQueue) Len() int { return len(q) }
+func (q contactQueue) Less(i, j int) bool {
+	if !q[i].arrival.Equal(q[j].arrival) {
+		return q[i].arrival.Before(q[j].arrival)
+	}
+	return q[i].hops < q[j].hops
+}
+func (q contactQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *contactQueue) Push(x interface{}) { *q = append(*q, x.(contactQueueItem)) }
+func (q *contactQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,59 @@
+package dtn
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time to nodes, routers and storage. Deployed
+// nodes use SystemClock; the network emulator substitutes virtual time so
+// that contact windows and bundle lifetimes play out faster than real time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// SystemClock reads the wall clock.
+var SystemClock Clock = systemClock{}
+
+// clockSetter is implemented by components whose clock can be replaced.
+type clockSetter interface {
+	SetClock(clock Clock)
+}
+
+// VirtualClock is a clock that only moves when told to.
+type VirtualClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewVirtualClock creates a virtual clock reading start.
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start.UTC()}
+}
+
+// Now implements Clock.
+func (c *VirtualClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+// Set moves the clock to t.
+func (c *VirtualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t.UTC()
+}
+
+// Advance moves the clock forward by d.
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,193 @@
+package dtn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Contact is one entry of a contact plan: a scheduled period during which
+// From can transmit to To at Rate bytes per second, with OWLT one-way light
+// time between the two nodes.
+type Contact struct {
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Start      time.Time     `json:"start"`
+	End        time.Time     `json:"end"`
+	Rate       int64         `json:"rate"` // bytes/second (0 = unlimited)
+	OWLT       time.Duration `json:"owlt"`
+	Confidence float64       `json:"confidence"` // 0.0 to 1.0
+}
+
+// Volume returns the total bytes the contact can carry, or -1 when the
+// rate or end time is unbounded.
+func (c *Contact) Volume() int64 {
+	if c.Rate <= 0 || c.End.Equal(contactForever) {
+		return -1
+	}
+	return int64(float64(c.Rate) * c.End.Sub(c.Start).Seconds())
+}
+
+// transmitTime returns how long size bytes occupy the contact.
+func (c *Contact) transmitTime(size int64) time.Duration {
+	if c.Rate <= 0 || size <= 0 {
+		return 0
+	}
+	return time.Duration(float64(size) / float64(c.Rate) * float64(time.Second))
+}
+
+// contactForever marks contacts without a scheduled end, such as
+// neighbors registered without a contact window.
+var contactForever = time.Unix(math.MaxInt32, 0).UTC()
+
+// nodeKey reduces an endpoint ID to the node it names, so that contacts
+// planned for ipn node 2 match the endpoints ipn:2.0 and ipn:2.1. Bare ION
+// node numbers are treated as ipn nodes. Other schemes match exactly.
+func nodeKey(eid string) string {
+	if _, err := strconv.ParseUint(eid, 10, 64); err == nil {
+		return "ipn:" + eid
+	}
+	if strings.HasPrefix(eid, "ipn:") {
+		if dot := strings.IndexByte(eid, '.'); dot > 0 {
+			return eid[:dot]
+		}
+	}
+	return eid
+}
+
+// sameNode reports whether two endpoint IDs belong to the same node.
+func sameNode(a, b string) bool {
+	return nodeKey(a) == nodeKey(b)
+}
+
+// LoadContactPlan reads an ION-style contact plan file. Relative times in
+// the file are offsets from epoch.
+func LoadContactPlan(path string, epoch time.Time) ([]Contact, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open contact plan: %w", err)
+	}
+	defer f.Close()
+	return ParseContactPlan(f, epoch)
+}
+
+// ParseContactPlan parses ionadmin contact and range commands:
+//
+//	a contact <start> <end> <from> <to> <rate> [confidence]
+//	a range <start> <end> <from> <to> <owlt seconds>
+//
+// Times are either "+seconds" relative to epoch or absolute UTC timestamps
+// in the form yyyy/mm/dd-hh:mm:ss. Ranges are symmetric and apply to every
+// contact between the two nodes that starts inside the range interval.
+// Other ionadmin commands and comments are ignored.
+func ParseContactPlan(r io.Reader, epoch time.Time) ([]Contact, error) {
+	type owltRange struct {
+		start, end time.Time
+		a, b       string
+		owlt       time.Duration
+	}
+
+	var contacts []Contact
+	var ranges []owltRange
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "a" || (fields[1] != "contact" && fields[1] != "range") {
+			continue
+		}
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("contact plan line %d: expected at least 7 fields, got %d", lineNum, len(fields))
+		}
+
+		start, err := parsePlanTime(fields[2], epoch)
+		if err != nil {
+			return nil, fmt.Errorf("contact plan line %d: %w", lineNum, err)
+		}
+		end, err := parsePlanTime(fields[3], epoch)
+		if err != nil {
+			return nil, fmt.Errorf("contact plan line %d: %w", lineNum, err)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("contact plan line %d: end time not after start time", lineNum)
+		}
+		from, to := nodeKey(fields[4]), nodeKey(fields[5])
+
+		switch fields[1] {
+		case "contact":
+			rate, err := strconv.ParseFloat(fields[6], 64)
+			if err != nil || rate < 0 {
+				return nil, fmt.Errorf("contact plan line %d: invalid rate %q", lineNum, fields[6])
+			}
+			confidence := 1.0
+			if len(fields) > 7 {
+				confidence, err = strconv.ParseFloat(fields[7], 64)
+				if err != nil || confidence < 0 || confidence > 1 {
+					return nil, fmt.Errorf("contact plan line %d: invalid confidence %q", lineNum, fields[7])
+				}
+			}
+			contacts = append(contacts, Contact{
+				From:       from,
+				To:         to,
+				Start:      start,
+				End:        end,
+				Rate:       int64(rate),
+				Confidence: confidence,
+			})
+		case "range":
+			owlt, err := strconv.ParseFloat(fields[6], 64)
+			if err != nil || owlt < 0 {
+				return nil, fmt.Errorf("contact plan line %d: invalid owlt %q", lineNum, fields[6])
+			}
+			ranges = append(ranges, owltRange{
+				start: start,
+				end:   end,
+				a:     from,
+				b:     to,
+				owlt:  time.Duration(owlt * float64(time.Second)),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read contact plan: %w", err)
+	}
+
+	for i := range contacts {
+		c := &contacts[i]
+		for _, rg := range ranges {
+			between := (rg.a == c.From && rg.b == c.To) || (rg.a == c.To && rg.b == c.From)
+			if between && !c.Start.Before(rg.start) && c.Start.Before(rg.end) {
+				c.OWLT = rg.owlt
+				break
+			}
+		}
+	}
+	return contacts, nil
+}
+
+// parsePlanTime parses an ionadmin time value.
+func parsePlanTime(value string, epoch time.Time) (time.Time, error) {
+	if strings.HasPrefix(value, "+") {
+		secs, err := strconv.ParseFloat(value[1:], 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time %q", value)
+		}
+		return epoch.Add(time.Duration(secs * float64(time.Second))), nil
+	}
+	t, err := time.Parse("2006/01/02-15:04:05", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
@@ -3,10 +3,12 @@ package dtn
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/asgard/pandora/internal/platform/frames"
 	"github.com/asgard/pandora/internal/platform/satellite"
 )
 
@@ -26,7 +28,18 @@ type GroundStation struct {
 	Latitude     float64 `json:"latitude"`
 	Longitude    float64 `json:"longitude"`
 	Altitude     float64 `json:"altitude_m"`
-	MinElevation float64 `json:"min_elevation_deg"` // Minimum elevation for contact
+	MinElevation float64 `json:"min_elevation_deg"`         // Minimum elevation for contact
+	EID          string  `json:"eid,omitempty"`             // DTN endpoint ID
+	Antennas     int     `json:"antennas,omitempty"`        // Passes the station can track at once (0 = 1)
+	SlewRate     float64 `json:"slew_rate_deg_s,omitempty"` // Antenna azimuth slew rate (0 = scheduler default)
+}
+
+// EndpointID returns the station's DTN endpoint ID.
+func (gs GroundStation) EndpointID() string {
+	if gs.EID != "" {
+		return gs.EID
+	}
+	return "dtn://earth/" + gs.ID
 }
 
 // SatelliteNode represents a satellite in the network.
@@ -46,6 +59,8 @@ type PredictedContact struct {
 	MaxElevation  float64   `json:"max_elevation_deg"`
 	DurationSec   int       `json:"duration_seconds"`
 	Quality       float64   `json:"link_quality"` // 0-1 based on elevation
+	StartAzimuth  float64   `json:"start_azimuth_deg"`
+	EndAzimuth    float64   `json:"end_azimuth_deg"`
 }
 
 // ContactPredictorConfig holds configuration.
@@ -130,13 +145,32 @@ func (cp *ContactPredictor) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// LoadTLE sets a configured satellite's orbit from a known TLE instead of
+// fetching it, e.g. for offline or reproducible predictions.
+func (cp *ContactPredictor) LoadTLE(noradID int, tle *satellite.TLE) error {
+	propagator, err := satellite.NewPropagator(tle)
+	if err != nil {
+		return fmt.Errorf("failed to create propagator for NORAD %d: %w", noradID, err)
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.propagators[noradID] = propagator
+	return nil
+}
+
 // PredictContacts returns predicted contact windows for all satellite-ground station pairs.
 func (cp *ContactPredictor) PredictContacts(ctx context.Context, duration time.Duration, step time.Duration) []PredictedContact {
+	return cp.PredictContactsFrom(ctx, time.Now().UTC(), duration, step)
+}
+
+// PredictContactsFrom returns contact windows predicted from start onwards.
+func (cp *ContactPredictor) PredictContactsFrom(ctx context.Context, start time.Time, duration time.Duration, step time.Duration) []PredictedContact {
 	cp.mu.RLock()
 	defer cp.mu.RUnlock()
 
 	var contacts []PredictedContact
-	now := time.Now().UTC()
+	now := start.UTC()
 	endTime := now.Add(duration)
 
 	for _, sat := range cp.satellites {
@@ -146,8 +180,13 @@ func (cp *ContactPredictor) PredictContacts(ctx context.Context, duration time.D
 		}
 
 		for _, gs := range cp.groundStations {
-			gsContacts := cp.predictContactsForPair(propagator, sat, gs, now, endTime, step)
+			gsContacts, err := cp.predictContactsForPair(propagator, sat, gs, now, endTime, step)
 			contacts = append(contacts, gsContacts...)
+			if err != nil {
+				// The orbit is the same for every station; stop at decay
+				log.Printf("[ContactPredictor] Stopping predictions for %s: %v", sat.Name, err)
+				break
+			}
 		}
 	}
 
@@ -155,20 +194,31 @@ func (cp *ContactPredictor) PredictContacts(ctx context.Context, duration time.D
 }
 
 // predictContactsForPair predicts contacts between one satellite and one ground station.
+// If the orbit cannot be propagated past some time, such as after decay, the
+// contacts up to then are returned with the error.
 func (cp *ContactPredictor) predictContactsForPair(
 	propagator *satellite.Propagator,
 	sat SatelliteNode,
 	gs GroundStation,
 	startTime, endTime time.Time,
 	step time.Duration,
-) []PredictedContact {
+) ([]PredictedContact, error) {
 	var contacts []PredictedContact
 	var currentContact *PredictedContact
 	var maxElevation float64
 
+	var lastAzimuth float64
+
+	var propagateErr error
 	for t := startTime; t.Before(endTime); t = t.Add(step) {
-		lat, lon, alt := propagator.Propagate(t)
-		elevation := cp.calculateElevation(lat, lon, alt, gs)
+		look, err := cp.calculateLook(propagator, t, gs)
+		if err != nil {
+			// Close any open contact where propagation stops
+			propagateErr = err
+			endTime = t
+			break
+		}
+		elevation := look.Elevation
 
 		if elevation >= gs.MinElevation {
 			if currentContact == nil {
@@ -178,16 +228,19 @@ func (cp *ContactPredictor) predictContactsForPair(
 					SatelliteName: sat.Name,
 					GroundStation: gs.Name,
 					StartTime:     t,
+					StartAzimuth:  look.Azimuth,
 				}
 				maxElevation = elevation
 			}
 			if elevation > maxElevation {
 				maxElevation = elevation
 			}
+			lastAzimuth = look.Azimuth
 		} else {
 			if currentContact != nil {
 				// End of contact
 				currentContact.EndTime = t
+				currentContact.EndAzimuth = lastAzimuth
 				currentContact.MaxElevation = maxElevation
 				currentContact.DurationSec = int(currentContact.EndTime.Sub(currentContact.StartTime).Seconds())
 				currentContact.Quality = cp.calculateLinkQuality(maxElevation)
@@ -201,53 +254,26 @@ func (cp *ContactPredictor) predictContactsForPair(
 	// Handle contact that extends past endTime
 	if currentContact != nil {
 		currentContact.EndTime = endTime
+		currentContact.EndAzimuth = lastAzimuth
 		currentContact.MaxElevation = maxElevation
 		currentContact.DurationSec = int(currentContact.EndTime.Sub(currentContact.StartTime).Seconds())
 		currentContact.Quality = cp.calculateLinkQuality(maxElevation)
 		contacts = append(contacts, *currentContact)
 	}
 
-	return contacts
+	return contacts, propagateErr
 }
 
-// calculateElevation calculates elevation angle from ground station to satellite.
-func (cp *ContactPredictor) calculateElevation(satLat, satLon, satAlt float64, gs GroundStation) float64 {
-	// Simplified elevation calculation
-	// In production, use proper geodetic calculations
-
-	const earthRadius = 6371.0 // km
-	const deg2rad = 0.017453292519943295
-
-	// Great circle distance
-	dLat := (satLat - gs.Latitude) * deg2rad
-	dLon := (satLon - gs.Longitude) * deg2rad
-
-	a := sin(dLat/2)*sin(dLat/2) +
-		cos(gs.Latitude*deg2rad)*cos(satLat*deg2rad)*
-			sin(dLon/2)*sin(dLon/2)
-	c := 2 * atan2(sqrt(a), sqrt(1-a))
-
-	groundDistance := earthRadius * c // km
-
-	// Satellite height above ground station
-	height := satAlt // km (approximate, ignoring Earth curvature for simplicity)
-
-	// Elevation angle
-	if groundDistance < 0.001 {
-		return 90.0 // Directly overhead
+// calculateLook returns the satellite's look angles from the ground station
+// at t, or the propagation error if the orbit cannot be propagated to t.
+func (cp *ContactPredictor) calculateLook(propagator *satellite.Propagator, t time.Time, gs GroundStation) (frames.LookAngles, error) {
+	state, err := propagator.PropagateState(t)
+	if err != nil {
+		return frames.LookAngles{}, fmt.Errorf("propagate to %s: %w", t.Format(time.RFC3339), err)
 	}
-
-	elevation := atan(height/groundDistance) * 180.0 / 3.14159265358979
-
-	// Account for Earth curvature (simplified)
-	curvatureCorrection := groundDistance / (2 * earthRadius) * 180.0 / 3.14159265358979
-	elevation -= curvatureCorrection
-
-	if elevation < 0 {
-		elevation = -10 // Below horizon
-	}
-
-	return elevation
+	r, v := frames.TEMEToITRF(state.Position, state.Velocity, t, frames.EOP{})
+	site := frames.Geodetic{Latitude: gs.Latitude, Longitude: gs.Longitude, Altitude: gs.Altitude / 1000}
+	return frames.Look(site, r, v), nil
 }
 
 // calculateLinkQuality estimates link quality based on elevation.
@@ -277,82 +303,58 @@ func (cp *ContactPredictor) GetNextContact(ctx context.Context, noradID int) (*P
 	return nil, nil
 }
 
-// UpdateRouterContactGraph updates the DTN router with predicted contacts.
-func (cp *ContactPredictor) UpdateRouterContactGraph(router *EnergyAwareRouter) {
-	contacts := cp.PredictContacts(context.Background(), 4*time.Hour, time.Minute)
-
-	for _, contact := range contacts {
-		neighbor := &Neighbor{
-			ID:           contact.GroundStation,
-			EID:          "dtn://earth/" + contact.GroundStation,
-			LinkQuality:  contact.Quality,
-			IsActive:     time.Now().UTC().After(contact.StartTime) && time.Now().UTC().Before(contact.EndTime),
-			Latency:      50 * time.Millisecond, // Typical LEO latency
-			Bandwidth:    10_000_000,            // 10 Mbps typical
-			ContactStart: contact.StartTime,
-			ContactEnd:   contact.EndTime,
-		}
-		router.UpdateContactGraph(contact.GroundStation, neighbor)
-	}
-}
+// Link characteristics assumed for predicted space-ground contacts.
+const (
+	predictedLinkRate = 1_250_000             // 10 Mbps in bytes/second
+	predictedLinkOWLT = 50 * time.Millisecond // Typical LEO latency
+)
 
-// Math helper functions
-func sin(x float64) float64 {
-	// Normalize to [-π, π]
-	for x > 3.14159265358979 {
-		x -= 2 * 3.14159265358979
-	}
-	for x < -3.14159265358979 {
-		x += 2 * 3.14159265358979
-	}
-	// Taylor series for sine
-	x2 := x * x
-	return x - x*x2/6 + x*x2*x2/120 - x*x2*x2*x2/5040
+// ContactPlan converts predicted passes into a bidirectional contact plan
+// for contact graph routing. Ground stations are addressed by their EID, or
+// dtn://earth/<id> when none is configured.
+func (cp *ContactPredictor) ContactPlan(ctx context.Context, duration time.Duration, step time.Duration) []Contact {
+	return cp.ContactPlanFrom(ctx, time.Now().UTC(), duration, step)
 }
 
-func cos(x float64) float64 {
-	return sin(x + 3.14159265358979/2)
-}
+// ContactPlanFrom converts passes predicted from start onwards into a
+// contact plan, as ContactPlan does.
+func (cp *ContactPredictor) ContactPlanFrom(ctx context.Context, start time.Time, duration time.Duration, step time.Duration) []Contact {
+	predicted := cp.PredictContactsFrom(ctx, start, duration, step)
 
-func sqrt(x float64) float64 {
-	if x <= 0 {
-		return 0
+	cp.mu.RLock()
+	satEIDs := make(map[int]string, len(cp.satellites))
+	for _, sat := range cp.satellites {
+		satEIDs[sat.NoradID] = sat.EID
 	}
-	// Newton's method
-	z := x / 2
-	for i := 0; i < 10; i++ {
-		z = (z + x/z) / 2
+	gsEIDs := make(map[string]string, len(cp.groundStations))
+	for _, gs := range cp.groundStations {
+		gsEIDs[gs.Name] = gs.EndpointID()
 	}
-	return z
-}
+	cp.mu.RUnlock()
 
-func atan(x float64) float64 {
-	// Taylor series for arctan, valid for |x| <= 1
-	if x > 1 {
-		return 3.14159265358979/2 - atan(1/x)
-	}
-	if x < -1 {
-		return -3.14159265358979/2 - atan(1/x)
+	contacts := make([]Contact, 0, 2*len(predicted))
+	for _, pc := range predicted {
+		satEID, gsEID := satEIDs[pc.SatelliteID], gsEIDs[pc.GroundStation]
+		if satEID == "" || gsEID == "" {
+			continue
+		}
+		for _, pair := range [][2]string{{satEID, gsEID}, {gsEID, satEID}} {
+			contacts = append(contacts, Contact{
+				From:       pair[0],
+				To:         pair[1],
+				Start:      pc.StartTime,
+				End:        pc.EndTime,
+				Rate:       predictedLinkRate,
+				OWLT:       predictedLinkOWLT,
+				Confidence: pc.Quality,
+			})
+		}
 	}
-	x2 := x * x
-	return x - x*x2/3 + x*x2*x2/5 - x*x2*x2*x2/7
+	return contacts
 }
 
-func atan2(y, x float64) float64 {
-	if x > 0 {
-		return atan(y / x)
-	}
-	if x < 0 && y >= 0 {
-		return atan(y/x) + 3.14159265358979
-	}
-	if x < 0 && y < 0 {
-		return atan(y/x) - 3.14159265358979
-	}
-	if x == 0 && y > 0 {
-		return 3.14159265358979 / 2
-	}
-	if x == 0 && y < 0 {
-		return -3.14159265358979 / 2
-	}
-	return 0
+// UpdateRouterContactGraph replaces the router's contact plan with the
+// contacts predicted for the next four hours.
+func (cp *ContactPredictor) UpdateRouterContactGraph(router *ContactGraphRouter) {
+	router.SetContactPlan(cp.ContactPlan(context.Background(), 4*time.Hour, time.Minute))
 }
@@ -0,0 +1,818 @@
+package dtn
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// EmulatedRouter names a routing algorithm the emulator can compare.
+type EmulatedRouter string
+
+const (
+	EmulateCGR    EmulatedRouter = "cgr"    // ContactGraphRouter with the scenario's contact plan
+	EmulateEnergy EmulatedRouter = "energy" // EnergyAwareRouter with the contact plan and node energy levels
+	EmulateRL     EmulatedRouter = "rl"     // RLRoutingAgent loaded from Scenario.RLModel, or trained by the emulator's learner
+	EmulateStatic EmulatedRouter = "static" // StaticRouter with Scenario.StaticRoutes
+)
+
+// Scenario describes a reproducible emulation run: the nodes, the schedule
+// of links between them and the traffic they originate.
+type Scenario struct {
+	Name          string
+	Start         time.Time
+	Duration      time.Duration
+	Nodes         []ScenarioNode
+	Contacts      []Contact // Link schedule; From and To name node IDs or EIDs
+	Traffic       []TrafficFlow
+	StaticRoutes  []StaticRoute
+	Routers       []EmulatedRouter // Routers to compare (default: cgr, energy, static, and rl when RLModel is set)
+	RLModel       string           // Path to the RL routing model
+	RetryInterval time.Duration    // How often nodes requeue deferred bundles (default 1m)
+}
+
+// ScenarioNode is one emulated DTN node.
+type ScenarioNode struct {
+	ID     string  `json:"id"`
+	EID    string  `json:"eid"`
+	Energy float64 `json:"energy,omitempty"` // Battery percentage reported to energy-aware routers (0 = not reported)
+}
+
+// TrafficFlow originates Count bundles from one node to another, one every
+// Interval starting Start after the scenario begins.
+type TrafficFlow struct {
+	From     string
+	To       string
+	Start    time.Duration
+	Interval time.Duration
+	Count    int
+	Size     int // Payload bytes
+	Priority uint8
+	Lifetime time.Duration // 0 = bundle default
+}
+
+// StaticRoute is a route installed in Node's StaticRouter. Destination and
+// NextHop are node IDs.
+type StaticRoute struct {
+	Node        string `json:"node"`
+	Destination string `json:"destination"`
+	NextHop     string `json:"next_hop"`
+}
+
+// EmulatorReport summarizes one router's performance over a scenario.
+type EmulatorReport struct {
+	Scenario         string         `json:"scenario"`
+	Router           EmulatedRouter `json:"router"`
+	BundlesCreated   int            `json:"bundles_created"`
+	BundlesDelivered int            `json:"bundles_delivered"`
+	DeliveryRatio    float64        `json:"delivery_ratio"`
+	MeanLatency      time.Duration  `json:"mean_latency"`
+	MedianLatency    time.Duration  `json:"median_latency"`
+	P95Latency       time.Duration  `json:"p95_latency"`
+	MaxLatency       time.Duration  `json:"max_latency"`
+	Transmissions    int64          `json:"transmissions"`     // Bundles and fragments put on links
+	BytesTransmitted int64          `json:"bytes_transmitted"` // Encoded bytes put on links
+	Overhead         float64        `json:"overhead"`          // Transmissions per delivered bundle
+	FragmentsCreated int64          `json:"fragments_created"`
+	BundlesExpired   int64          `json:"bundles_expired"`
+	Events           int            `json:"events"` // Discrete events processed
+}
+
+// errEmulatedLinkDown is returned when a contact closes before or during a
+// transfer.
+var errEmulatedLinkDown = errors.New("emulated link down")
+
+// Emulator runs DTN nodes in a discrete-event simulation. Every node uses
+// the real Node forwarding logic, storage and routers, but reads a virtual
+// clock and exchanges bundles over an in-memory transport whose links come
+// up and go down on the scenario's contact schedule. Links add one-way
+// delay and serialize transfers at the contact rate. Runs are
+// single-threaded and deterministic, so the same scenario always yields
+// the same report.
+type Emulator struct {
+	scenario Scenario
+	contacts []Contact // Link schedule by node ID, ordered by start time
+	plan     []Contact // The same contacts by EID, for contact graph routing
+	nodes    map[string]*ScenarioNode
+	learner  *RLLearner
+}
+
+// NewEmulator validates a scenario and prepares it for emulation.
+func NewEmulator(scenario Scenario) (*Emulator, error) {
+	if scenario.Duration <= 0 {
+		return nil, fmt.Errorf("scenario %q has no duration", scenario.Name)
+	}
+	if scenario.Start.IsZero() {
+		scenario.Start = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	}
+	scenario.Start = scenario.Start.UTC()
+	if scenario.RetryInterval <= 0 {
+		scenario.RetryInterval = time.Minute
+	}
+	if len(scenario.Routers) == 0 {
+		scenario.Routers = []EmulatedRouter{EmulateCGR, EmulateEnergy, EmulateStatic}
+		if scenario.RLModel != "" {
+			scenario.Routers = append(scenario.Routers, EmulateRL)
+		}
+	}
+
+	e := &Emulator{
+		scenario: scenario,
+		nodes:    make(map[string]*ScenarioNode, len(scenario.Nodes)),
+	}
+	for i := range scenario.Nodes {
+		node := &scenario.Nodes[i]
+		if node.ID == "" || node.EID == "" {
+			return nil, fmt.Errorf("scenario node %d needs an ID and EID", i)
+		}
+		if _, dup := e.nodes[node.ID]; dup {
+			return nil, fmt.Errorf("duplicate scenario node %s", node.ID)
+		}
+		e.nodes[node.ID] = node
+	}
+
+	for _, c := range scenario.Contacts {
+		from, err := e.resolve(c.From)
+		if err != nil {
+			return nil, fmt.Errorf("contact %s -> %s: %w", c.From, c.To, err)
+		}
+		to, err := e.resolve(c.To)
+		if err != nil {
+			return nil, fmt.Errorf("contact %s -> %s: %w", c.From, c.To, err)
+		}
+		if !c.End.After(c.Start) {
+			return nil, fmt.Errorf("contact %s -> %s ends before it starts", c.From, c.To)
+		}
+		if c.Confidence == 0 {
+			c.Confidence = 1
+		}
+		c.From, c.To = from, to
+		e.contacts = append(e.contacts, c)
+
+		c.From, c.To = e.nodes[from].EID, e.nodes[to].EID
+		e.plan = append(e.plan, c)
+	}
+	sort.SliceStable(e.contacts, func(i, j int) bool {
+		return e.contacts[i].Start.Before(e.contacts[j].Start)
+	})
+
+	for i, flow := range scenario.Traffic {
+		if _, ok := e.nodes[flow.From]; !ok {
+			return nil, fmt.Errorf("traffic flow %d: unknown source node %s", i, flow.From)
+		}
+		if _, ok := e.nodes[flow.To]; !ok {
+			return nil, fmt.Errorf("traffic flow %d: unknown destination node %s", i, flow.To)
+		}
+		if flow.Priority > bundle.PriorityExpedited {
+			return nil, fmt.Errorf("traffic flow %d: invalid priority %d", i, flow.Priority)
+		}
+	}
+	for _, route := range scenario.StaticRoutes {
+		for _, id := range []string{route.Node, route.Destination, route.NextHop} {
+			if _, ok := e.nodes[id]; !ok {
+				return nil, fmt.Errorf("static route: unknown node %s", id)
+			}
+		}
+	}
+	for _, kind := range scenario.Routers {
+		switch kind {
+		case EmulateCGR, EmulateEnergy, EmulateStatic:
+		case EmulateRL:
+			// Without a model file the RL router needs a learner (SetRLLearner)
+			if scenario.RLModel == "" {
+				continue
+			}
+			if _, err := LoadRLRoutingModel(scenario.RLModel); err != nil {
+				return nil, fmt.Errorf("rl router: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown router %q", kind)
+		}
+	}
+
+	return e, nil
+}
+
+// Scenario returns the scenario with defaults applied.
+func (e *Emulator) Scenario() Scenario {
+	return e.scenario
+}
+
+// SetRLLearner makes RL runs route with the learner's model instead of
+// Scenario.RLModel and report every traffic bundle's outcome to it, so
+// that each run is a training episode.
+func (e *Emulator) SetRLLearner(learner *RLLearner) {
+	e.learner = learner
+}
+
+// resolve maps a node ID or endpoint ID to a scenario node ID.
+func (e *Emulator) resolve(name string) (string, error) {
+	if _, ok := e.nodes[name]; ok {
+		return name, nil
+	}
+	for _, node := range e.scenario.Nodes {
+		if sameNode(node.EID, name) {
+			return node.ID, nil
+		}
+	}
+	return "", fmt.Errorf("unknown node %s", name)
+}
+
+// Compare runs the scenario once per configured router.
+func (e *Emulator) Compare(ctx context.Context) ([]*EmulatorReport, error) {
+	reports := make([]*EmulatorReport, 0, len(e.scenario.Routers))
+	for _, kind := range e.scenario.Routers {
+		report, err := e.Run(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Run emulates the scenario with every node using the given router.
+func (e *Emulator) Run(ctx context.Context, kind EmulatedRouter) (*EmulatorReport, error) {
+	em := &emulation{
+		scenario:  &e.scenario,
+		clock:     NewVirtualClock(e.scenario.Start),
+		end:       e.scenario.Start.Add(e.scenario.Duration),
+		byID:      make(map[string]*emulatedNode),
+		links:     make(map[linkKey]*emulatedLink),
+		created:   make(map[string]*emulatedBundle),
+		delivered: make(map[string]bool),
+	}
+	if kind == EmulateRL {
+		em.learner = e.learner
+	}
+
+	for _, sn := range e.scenario.Nodes {
+		router, err := e.newRouter(kind, sn)
+		if err != nil {
+			return nil, err
+		}
+		config := DefaultNodeConfig()
+		config.RetryInterval = e.scenario.RetryInterval
+
+		en := &emulatedNode{
+			node: NewNode(sn.ID, sn.EID, NewInMemoryStorage(0), router, config),
+		}
+		en.transport = &emulatedTransport{
+			em:        em,
+			nodeID:    sn.ID,
+			connected: make(map[string]bool),
+			receive:   make(chan *bundle.Bundle),
+		}
+		en.node.SetTransport(en.transport)
+		en.node.SetClock(em.clock)
+		en.node.SetDeliveryHandler(em.recordDelivery)
+		em.nodes = append(em.nodes, en)
+		em.byID[sn.ID] = en
+	}
+	defer func() {
+		for _, en := range em.nodes {
+			en.node.cancel()
+		}
+	}()
+
+	em.scheduleContacts(e.contacts)
+	em.scheduleTraffic(e.scenario.Traffic)
+	em.scheduleMaintenance()
+
+	log.Printf("[DTN Emulator] Running %q with %s routing: %d nodes, %d contacts, %s",
+		e.scenario.Name, kind, len(em.nodes), len(e.contacts), e.scenario.Duration)
+	if err := em.run(ctx); err != nil {
+		return nil, err
+	}
+	if em.learner != nil {
+		em.rewardUndelivered()
+	}
+
+	report := em.report()
+	report.Router = kind
+	return report, nil
+}
+
+// newRouter builds a router of the given kind for one node.
+func (e *Emulator) newRouter(kind EmulatedRouter, sn ScenarioNode) (Router, error) {
+	switch kind {
+	case EmulateCGR:
+		r := NewContactGraphRouter(sn.EID)
+		r.SetContactPlan(e.plan)
+		return r, nil
+	case EmulateEnergy:
+		r := NewEnergyAwareRouter(sn.EID)
+		r.SetContactPlan(e.plan)
+		for _, node := range e.scenario.Nodes {
+			if node.Energy > 0 {
+				r.UpdateEnergy(node.ID, node.Energy)
+			}
+		}
+		return r, nil
+	case EmulateRL:
+		var r *RLRoutingAgent
+		if e.learner != nil {
+			r = NewRLRoutingAgentWithModel(sn.EID, e.learner.Model())
+			r.SetLearner(e.learner)
+		} else {
+			if e.scenario.RLModel == "" {
+				return nil, fmt.Errorf("rl router: no model")
+			}
+			var err error
+			if r, err = NewRLRoutingAgent(sn.EID, e.scenario.RLModel); err != nil {
+				return nil, err
+			}
+		}
+		for _, node := range e.scenario.Nodes {
+			if node.Energy > 0 {
+				r.UpdateEnergy(node.ID, node.Energy)
+			}
+		}
+		return r, nil
+	case EmulateStatic:
+		r := NewStaticRouter()
+		for _, route := range e.scenario.StaticRoutes {
+			if route.Node == sn.ID {
+				r.AddRoute(e.nodes[route.Destination].EID, route.NextHop)
+			}
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("unknown router %q", kind)
+}
+
+// emulation is the state of a single emulator run.
+type emulation struct {
+	scenario *Scenario
+	clock    *VirtualClock
+	end      time.Time
+	queue    eventQueue
+	seq      uint64
+
+	nodes []*emulatedNode // In scenario order
+	byID  map[string]*emulatedNode
+	links map[linkKey]*emulatedLink
+
+	bundleSeq     uint64
+	created       map[string]*emulatedBundle // Traffic bundles by identity
+	delivered     map[string]bool
+	latencies     []time.Duration
+	transmissions int64
+	bytes         int64
+	events        int
+	learner       *RLLearner // Rewarded with traffic outcomes in RL runs
+}
+
+// emulatedBundle is a traffic bundle originated during a run.
+type emulatedBundle struct {
+	ref      bundle.BundleRef
+	created  time.Time
+	lifetime time.Duration
+}
+
+type emulatedNode struct {
+	node      *Node
+	transport *emulatedTransport
+}
+
+// linkKey identifies the directed link between two nodes.
+type linkKey struct {
+	from, to string
+}
+
+// emulatedLink carries bundles from one node to another while a contact
+// is open. Transfers are serialized at the contact rate.
+type emulatedLink struct {
+	key       linkKey
+	contact   *Contact // Open contact, nil while the link is down
+	busyUntil time.Time
+}
+
+// emulatorEvent is an action scheduled at a point in virtual time. Events
+// at the same time run in the order they were scheduled.
+type emulatorEvent struct {
+	at  time.Time
+	seq uint64
+	run func()
+}
+
+type eventQueue []*emulatorEvent
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(*emulatorEvent)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
+
+// schedule queues fn to run at the given virtual time.
+func (em *emulation) schedule(at time.Time, fn func()) {
+	em.seq++
+	heap.Push(&em.queue, &emulatorEvent{at: at, seq: em.seq, run: fn})
+}
+
+// every runs fn at first and then once per interval until the scenario ends.
+func (em *emulation) every(first time.Time, interval time.Duration, fn func()) {
+	var tick func()
+	next := first
+	tick = func() {
+		fn()
+		next = next.Add(interval)
+		if !next.After(em.end) {
+			em.schedule(next, tick)
+		}
+	}
+	em.schedule(first, tick)
+}
+
+// run processes events in time order until the queue drains or the
+// scenario ends.
+func (em *emulation) run(ctx context.Context) error {
+	for em.queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ev := heap.Pop(&em.queue).(*emulatorEvent)
+		if ev.at.After(em.end) {
+			break
+		}
+		em.clock.Set(ev.at)
+		ev.run()
+		em.events++
+		em.settle()
+	}
+	em.clock.Set(em.end)
+	return nil
+}
+
+// settle lets every node process its queued bundles at the current time.
+// Processing never advances the clock, so it runs until all queues are empty.
+func (em *emulation) settle() {
+	for busy := true; busy; {
+		busy = false
+		for _, en := range em.nodes {
+			if en.drain() {
+				busy = true
+			}
+		}
+	}
+}
+
+// drain handles queued ingress bundles before egress ones, so the order of
+// processing does not depend on channel scheduling. It returns true if
+// anything was processed.
+func (en *emulatedNode) drain() bool {
+	n := en.node
+	worked := false
+	for {
+		select {
+		case b := <-n.ingressChan:
+			n.handleIncomingBundle(b)
+			worked = true
+			continue
+		default:
+		}
+		select {
+		case b := <-n.egressChan:
+			n.forwardBundle(b)
+			worked = true
+			continue
+		default:
+		}
+		return worked
+	}
+}
+
+// scheduleContacts brings links up and down on the contact schedule.
+func (em *emulation) scheduleContacts(contacts []Contact) {
+	for _, c := range contacts {
+		c := c
+		key := linkKey{from: c.From, to: c.To}
+		link, ok := em.links[key]
+		if !ok {
+			link = &emulatedLink{key: key}
+			em.links[key] = link
+		}
+		em.schedule(c.Start, func() { em.linkUp(link, &c) })
+		em.schedule(c.End, func() { em.linkDown(link, &c) })
+	}
+}
+
+// linkUp opens a contact and registers the receiving node as a neighbor.
+func (em *emulation) linkUp(link *emulatedLink, c *Contact) {
+	link.contact = c
+	link.busyUntil = c.Start
+
+	from, to := em.byID[c.From], em.byID[c.To]
+	from.transport.connected[c.To] = true
+	from.node.RegisterNeighbor(&Neighbor{
+		ID:           c.To,
+		EID:          to.node.EID,
+		Address:      "emulated:" + c.To,
+		LinkQuality:  c.Confidence,
+		IsActive:     true,
+		Latency:      c.OWLT,
+		Bandwidth:    c.Rate,
+		ContactStart: c.Start,
+		ContactEnd:   c.End,
+	})
+
+	// Bundles waiting for this contact need not wait for the retry timer
+	from.node.retryDeferred()
+}
+
+// linkDown closes a contact.
+func (em *emulation) linkDown(link *emulatedLink, c *Contact) {
+	if link.contact != c {
+		return
+	}
+	link.contact = nil
+	em.byID[c.From].node.DisconnectNeighbor(c.To)
+}
+
+// transfer serializes an encoded bundle onto a link. A transfer cut off by
+// the end of the contact delivers the received prefix and reports a
+// partial transfer, as a reliable convergence layer would.
+func (em *emulation) transfer(link *emulatedLink, data []byte) error {
+	c := link.contact
+	if c == nil {
+		return fmt.Errorf("%s -> %s: %w", link.key.from, link.key.to, errEmulatedLinkDown)
+	}
+
+	start := laterOf(em.clock.Now(), link.busyUntil)
+	if !start.Before(c.End) {
+		return fmt.Errorf("%s -> %s: contact ends before transfer starts: %w", link.key.from, link.key.to, errEmulatedLinkDown)
+	}
+
+	finish := start.Add(c.transmitTime(int64(len(data))))
+	if !finish.After(c.End) {
+		link.busyUntil = finish
+		em.carry(link, c, data, finish, false)
+		return nil
+	}
+
+	// The contact closes mid-transfer
+	link.busyUntil = c.End
+	acked := int(math.Min(float64(c.Rate)*c.End.Sub(start).Seconds(), float64(len(data))))
+	partial := &PartialTransferError{
+		NeighborID:    link.key.to,
+		Acked:         uint64(acked),
+		Total:         uint64(len(data)),
+		PayloadOffset: -1,
+		Err:           errEmulatedLinkDown,
+	}
+	if offset, length, err := bundle.PayloadOffset(data); err == nil {
+		partial.PayloadOffset = offset
+		partial.PayloadLength = length
+	}
+	if partial.DeliveredPayload() > 0 {
+		em.carry(link, c, data[:acked], c.End, true)
+	}
+	return partial
+}
+
+// carry delivers data to the far end of the link one light time after the
+// transfer completes.
+func (em *emulation) carry(link *emulatedLink, c *Contact, data []byte, sent time.Time, partial bool) {
+	em.transmissions++
+	em.bytes += int64(len(data))
+
+	em.schedule(sent.Add(c.OWLT), func() {
+		var b *bundle.Bundle
+		var err error
+		if partial {
+			b, err = bundle.UnmarshalPartial(data)
+		} else {
+			b, err = bundle.Unmarshal(data)
+		}
+		if err != nil {
+			log.Printf("[DTN Emulator] Failed to decode bundle on %s -> %s: %v", link.key.from, link.key.to, err)
+			return
+		}
+		n := em.byID[link.key.to].node
+		if err := n.Receive(n.ctx, b); err != nil {
+			log.Printf("[DTN Emulator] Node %s dropped bundle %s: %v", n.ID, b.ID.String()[:8], err)
+		}
+	})
+}
+
+// scheduleTraffic queues bundle creation for every traffic flow.
+func (em *emulation) scheduleTraffic(flows []TrafficFlow) {
+	for _, flow := range flows {
+		flow := flow
+		count := flow.Count
+		if count <= 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			em.schedule(em.scenario.Start.Add(flow.Start+time.Duration(i)*flow.Interval), func() {
+				em.originate(flow)
+			})
+		}
+	}
+}
+
+// originate creates a bundle at the flow's source and queues it for
+// forwarding. It is stored first so that it survives the wait for a route.
+func (em *emulation) originate(flow TrafficFlow) {
+	src, dst := em.byID[flow.From].node, em.byID[flow.To].node
+
+	b, err := bundle.NewPriorityBundle(src.EID, dst.EID, make([]byte, flow.Size), flow.Priority)
+	if err != nil {
+		log.Printf("[DTN Emulator] Cannot create bundle for %s -> %s: %v", flow.From, flow.To, err)
+		return
+	}
+	// Number bundles per run so encoded sizes do not depend on earlier runs
+	em.bundleSeq++
+	b.CreationTimestamp = em.clock.Now().Truncate(time.Millisecond)
+	b.SequenceNumber = em.bundleSeq
+	if flow.Lifetime > 0 {
+		b.SetLifetime(flow.Lifetime)
+	}
+	ref := bundle.RefOf(b)
+	em.created[ref.Key()] = &emulatedBundle{ref: ref, created: b.CreationTimestamp, lifetime: b.Lifetime}
+
+	if err := src.storage.Store(src.ctx, b); err != nil {
+		log.Printf("[DTN Node %s] Failed to store bundle: %v", src.ID, err)
+		return
+	}
+	if err := src.Send(src.ctx, b); err != nil {
+		src.storage.UpdateStatus(src.ctx, b.ID, StatusDeferred)
+	}
+}
+
+// recordDelivery measures the latency of traffic bundles on first delivery.
+func (em *emulation) recordDelivery(b *bundle.Bundle) {
+	key := bundle.RefOf(b).Key()
+	eb, ok := em.created[key]
+	if !ok || em.delivered[key] {
+		return
+	}
+	em.delivered[key] = true
+	latency := em.clock.Now().Sub(eb.created)
+	em.latencies = append(em.latencies, latency)
+	if em.learner != nil {
+		em.learner.Reward(eb.ref, RLDelivered, latency)
+	}
+}
+
+// rewardUndelivered reports the traffic bundles that did not arrive to the
+// learner: expired if their lifetime ran out during the run, dropped if no
+// node still holds them. Bundles still in storage have no outcome yet.
+func (em *emulation) rewardUndelivered() {
+	held := make(map[string]bool)
+	for _, en := range em.nodes {
+		stored, err := en.node.storage.List(en.node.ctx, BundleFilter{})
+		if err != nil {
+			continue
+		}
+		for _, b := range stored {
+			held[rlBundleKey(bundle.RefOf(b))] = true
+		}
+	}
+
+	keys := make([]string, 0, len(em.created))
+	for key := range em.created {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		eb := em.created[key]
+		switch {
+		case em.delivered[key]:
+		case !eb.created.Add(eb.lifetime).After(em.end):
+			em.learner.Reward(eb.ref, RLExpired, 0)
+		case !held[key]:
+			em.learner.Reward(eb.ref, RLDropped, 0)
+		}
+	}
+}
+
+// scheduleMaintenance runs each node's retry, custody and purge passes on
+// the virtual clock.
+func (em *emulation) scheduleMaintenance() {
+	for _, en := range em.nodes {
+		n := en.node
+		em.every(em.scenario.Start.Add(n.config.RetryInterval), n.config.RetryInterval, func() {
+			n.retryDeferred()
+			n.checkCustody()
+		})
+		em.every(em.scenario.Start.Add(n.config.PurgeInterval), n.config.PurgeInterval, n.purgeExpired)
+	}
+}
+
+// report summarizes the run.
+func (em *emulation) report() *EmulatorReport {
+	report := &EmulatorReport{
+		Scenario:         em.scenario.Name,
+		BundlesCreated:   len(em.created),
+		BundlesDelivered: len(em.latencies),
+		Transmissions:    em.transmissions,
+		BytesTransmitted: em.bytes,
+		Events:           em.events,
+	}
+	for _, en := range em.nodes {
+		m := en.node.GetMetrics()
+		report.FragmentsCreated += m.FragmentsCreated
+		report.BundlesExpired += m.BundlesExpired
+	}
+	if report.BundlesCreated > 0 {
+		report.DeliveryRatio = float64(report.BundlesDelivered) / float64(report.BundlesCreated)
+	}
+	if len(em.latencies) == 0 {
+		return report
+	}
+
+	latencies := append([]time.Duration(nil), em.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	report.MeanLatency = total / time.Duration(len(latencies))
+	report.MedianLatency = percentile(latencies, 0.5)
+	report.P95Latency = percentile(latencies, 0.95)
+	report.MaxLatency = latencies[len(latencies)-1]
+	report.Overhead = float64(em.transmissions) / float64(len(latencies))
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// emulatedTransport is a node's TransportAdapter inside the emulator.
+// Bundles are CBOR encoded and carried over the node's emulated links.
+type emulatedTransport struct {
+	em        *emulation
+	nodeID    string
+	connected map[string]bool
+	receive   chan *bundle.Bundle
+}
+
+// Send implements TransportAdapter.
+func (t *emulatedTransport) Send(ctx context.Context, neighborID string, b *bundle.Bundle) error {
+	link, ok := t.em.links[linkKey{from: t.nodeID, to: neighborID}]
+	if !ok || !t.connected[neighborID] {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+	data, err := bundle.CBOR.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to serialize bundle: %w", err)
+	}
+	return t.em.transfer(link, data)
+}
+
+// Receive implements TransportAdapter. The emulator hands bundles to nodes
+// directly, so the channel never delivers.
+func (t *emulatedTransport) Receive() <-chan *bundle.Bundle {
+	return t.receive
+}
+
+// Connect implements TransportAdapter.
+func (t *emulatedTransport) Connect(ctx context.Context, neighborID string, address string) error {
+	t.connected[neighborID] = true
+	return nil
+}
+
+// Disconnect implements TransportAdapter.
+func (t *emulatedTransport) Disconnect(neighborID string) error {
+	delete(t.connected, neighborID)
+	return nil
+}
+
+// IsConnected implements TransportAdapter.
+func (t *emulatedTransport) IsConnected(neighborID string) bool {
+	return t.connected[neighborID]
+}
+
+// Start implements TransportAdapter.
+func (t *emulatedTransport) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop implements TransportAdapter.
+func (t *emulatedTransport) Stop() error {
+	return nil
+}
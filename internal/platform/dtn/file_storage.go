@@ -0,0 +1,963 @@
+package dtn
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+	"github.com/google/uuid"
+)
+
+// SyncPolicy controls when FileBundleStorage flushes writes to disk.
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync after every write
+	SyncInterval                   // fsync in the background every SyncInterval
+	SyncNever                      // Leave flushing to the operating system
+)
+
+// ErrStorageQuota is returned when a bundle cannot fit in the storage quota
+// even after eviction.
+var ErrStorageQuota = errors.New("bundle exceeds storage quota")
+
+// FileStorageConfig configures a FileBundleStorage.
+type FileStorageConfig struct {
+	Dir          string        // Directory holding the segment files
+	MaxBytes     int64         // Quota on stored bundle bytes (0 = unlimited)
+	SegmentSize  int64         // Roll over to a new segment after this many bytes
+	Sync         SyncPolicy    // When to fsync writes
+	SyncInterval time.Duration // Flush period for SyncInterval
+
+	// CompactRatio triggers compaction once dead records make up this
+	// fraction of the sealed segments (0 disables automatic compaction).
+	CompactRatio float64
+}
+
+// DefaultFileStorageConfig returns a configuration for the given directory.
+func DefaultFileStorageConfig(dir string) FileStorageConfig {
+	return FileStorageConfig{
+		Dir:          dir,
+		MaxBytes:     1 << 30, // 1 GiB
+		SegmentSize:  64 << 20,
+		Sync:         SyncAlways,
+		SyncInterval: time.Second,
+		CompactRatio: 0.5,
+	}
+}
+
+// Segment record kinds.
+const (
+	recordPut    byte = 1
+	recordStatus byte = 2
+	recordDelete byte = 3
+)
+
+const (
+	segmentExt       = ".seg"
+	recordHeaderSize = 9 // crc32 + body length + kind
+)
+
+var recordCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// fileEntry indexes the latest stored copy of a bundle.
+type fileEntry struct {
+	segment     uint64
+	offset      int64
+	length      int64 // Record length including header
+	status      BundleStatus
+	storedAt    time.Time
+	priority    uint8
+	size        int
+	source      string
+	destination string
+	expiresAt   time.Time
+}
+
+// segment is one append-only log file.
+type segment struct {
+	id   uint64
+	file *os.File
+	size int64
+	live int64 // Bytes of records still referenced by the index
+}
+
+// FileBundleStorage is an embedded, append-only bundle store for nodes
+// without a database. Bundles, status changes and deletions are appended as
+// checksummed records to segment files; an in-memory index of the latest
+// record per bundle is rebuilt by replaying the segments on open, and a
+// torn record at the end of the log is truncated. Compaction rewrites live
+// records out of sealed segments and removes them.
+type FileBundleStorage struct {
+	mu       sync.RWMutex
+	config   FileStorageConfig
+	index    map[uuid.UUID]*fileEntry
+	segments map[uint64]*segment
+	active   *segment
+	bytes    int64 // Stored bundle bytes counted against MaxBytes
+	dirty    bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewFileBundleStorage opens or creates a file-backed store in cfg.Dir.
+func NewFileBundleStorage(cfg FileStorageConfig) (*FileBundleStorage, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("storage directory required")
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = 64 << 20
+	}
+	if cfg.Sync == SyncInterval && cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = time.Second
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage directory: %w", err)
+	}
+
+	s := &FileBundleStorage{
+		config:   cfg,
+		index:    make(map[uuid.UUID]*fileEntry),
+		segments: make(map[uint64]*segment),
+		stop:     make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		s.closeFiles()
+		return nil, err
+	}
+
+	if cfg.Sync == SyncInterval {
+		s.wg.Add(1)
+		go s.syncLoop()
+	}
+	return s, nil
+}
+
+// Close flushes pending writes and closes the segment files.
+func (s *FileBundleStorage) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.active.file.Sync()
+	if cerr := s.closeFiles(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Store persists a bundle to storage.
+func (s *FileBundleStorage) Store(ctx context.Context, b *bundle.Bundle) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := b.Validate(); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	size := int64(b.Size())
+	if s.config.MaxBytes > 0 && size > s.config.MaxBytes {
+		return fmt.Errorf("%w: %d bytes, quota %d", ErrStorageQuota, size, s.config.MaxBytes)
+	}
+
+	now := time.Now().UTC()
+	body, err := encodePutRecord(b, StatusPending, now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A replaced copy stops counting against the quota once the record
+	// below supersedes it
+	used := func() int64 {
+		if old, exists := s.index[b.ID]; exists {
+			return s.bytes - int64(old.size) + size
+		}
+		return s.bytes + size
+	}
+
+	// Make room within the quota
+	if s.config.MaxBytes > 0 && used() > s.config.MaxBytes {
+		// Evict expired bundles first
+		if _, err := s.evictExpiredLocked(); err != nil {
+			return err
+		}
+
+		// If still over quota, evict lowest priority
+		for used() > s.config.MaxBytes && len(s.index) > 0 {
+			if err := s.evictLowestPriorityLocked(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Keep the old entry until the new record is written, so a failed
+	// append leaves the index matching the log
+	seg, offset, length, err := s.appendLocked(recordPut, body)
+	if err != nil {
+		return err
+	}
+
+	s.dropEntryLocked(b.ID)
+	s.setEntryLocked(b.ID, &fileEntry{
+		segment:     seg.id,
+		offset:      offset,
+		length:      length,
+		status:      StatusPending,
+		storedAt:    now,
+		priority:    b.Priority,
+		size:        b.Size(),
+		source:      b.SourceEID,
+		destination: b.DestinationEID,
+		expiresAt:   b.ExpiresAt(),
+	})
+	return nil
+}
+
+// Retrieve fetches a bundle by ID.
+func (s *FileBundleStorage) Retrieve(ctx context.Context, id uuid.UUID) (*bundle.Bundle, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.index[id]
+	if !exists {
+		return nil, fmt.Errorf("bundle not found: %s", id)
+	}
+	return s.readBundleLocked(entry)
+}
+
+// Delete removes a bundle from storage.
+func (s *FileBundleStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.index[id]; !exists {
+		return fmt.Errorf("bundle not found: %s", id)
+	}
+	if err := s.deleteLocked(id); err != nil {
+		return err
+	}
+	return s.maybeCompactLocked()
+}
+
+// List returns bundles matching the filter criteria.
+func (s *FileBundleStorage) List(ctx context.Context, filter BundleFilter) ([]*bundle.Bundle, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []*fileEntry
+	for _, entry := range s.index {
+		if entry.matches(filter) {
+			results = append(results, entry)
+		}
+	}
+
+	// Sort results
+	switch filter.OrderBy {
+	case "priority":
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].priority > results[j].priority
+		})
+	case "age":
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].storedAt.Before(results[j].storedAt)
+		})
+	case "size":
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].size < results[j].size
+		})
+	default:
+		// Default: priority then age
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].priority != results[j].priority {
+				return results[i].priority > results[j].priority
+			}
+			return results[i].storedAt.Before(results[j].storedAt)
+		})
+	}
+
+	// Apply limit
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+
+	bundles := make([]*bundle.Bundle, 0, len(results))
+	for _, entry := range results {
+		b, err := s.readBundleLocked(entry)
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles, nil
+}
+
+// UpdateStatus changes the status of a bundle.
+func (s *FileBundleStorage) UpdateStatus(ctx context.Context, id uuid.UUID, status BundleStatus) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.index[id]
+	if !exists {
+		return fmt.Errorf("bundle not found: %s", id)
+	}
+	if entry.status == status {
+		return nil
+	}
+
+	if _, _, _, err := s.appendLocked(recordStatus, encodeStatusRecord(id, status)); err != nil {
+		return err
+	}
+	entry.status = status
+	return nil
+}
+
+// GetStatus returns the current status of a bundle.
+func (s *FileBundleStorage) GetStatus(ctx context.Context, id uuid.UUID) (BundleStatus, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.index[id]
+	if !exists {
+		return "", fmt.Errorf("bundle not found: %s", id)
+	}
+	return entry.status, nil
+}
+
+// Count returns the total number of bundles in storage.
+func (s *FileBundleStorage) Count(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.index), nil
+}
+
+// PurgeExpired removes all expired bundles.
+func (s *FileBundleStorage) PurgeExpired(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, err := s.evictExpiredLocked()
+	if err != nil {
+		return count, err
+	}
+	return count, s.maybeCompactLocked()
+}
+
+// Bytes returns the stored bundle bytes counted against the quota.
+func (s *FileBundleStorage) Bytes() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bytes
+}
+
+// Compact rewrites the live records of all sealed segments into the active
+// segment and removes the sealed segments.
+func (s *FileBundleStorage) Compact(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+// matches checks if an indexed bundle matches the filter criteria.
+func (e *fileEntry) matches(filter BundleFilter) bool {
+	if filter.DestinationEID != "" && e.destination != filter.DestinationEID {
+		return false
+	}
+	if filter.SourceEID != "" && e.source != filter.SourceEID {
+		return false
+	}
+	if filter.Status != "" && e.status != filter.Status {
+		return false
+	}
+	if e.priority < filter.MinPriority {
+		return false
+	}
+	if filter.MaxAge > 0 && time.Since(e.storedAt) > filter.MaxAge {
+		return false
+	}
+	return true
+}
+
+// setEntryLocked points the index at a new record for id.
+func (s *FileBundleStorage) setEntryLocked(id uuid.UUID, entry *fileEntry) {
+	if old, exists := s.index[id]; exists {
+		if seg := s.segments[old.segment]; seg != nil {
+			seg.live -= old.length
+		}
+	}
+	s.index[id] = entry
+	s.segments[entry.segment].live += entry.length
+	s.bytes += int64(entry.size)
+}
+
+// deleteLocked appends a tombstone and drops id from the index.
+func (s *FileBundleStorage) deleteLocked(id uuid.UUID) error {
+	if _, _, _, err := s.appendLocked(recordDelete, id[:]); err != nil {
+		return err
+	}
+	s.dropEntryLocked(id)
+	return nil
+}
+
+func (s *FileBundleStorage) dropEntryLocked(id uuid.UUID) {
+	entry, exists := s.index[id]
+	if !exists {
+		return
+	}
+	if seg := s.segments[entry.segment]; seg != nil {
+		seg.live -= entry.length
+	}
+	s.bytes -= int64(entry.size)
+	delete(s.index, id)
+}
+
+// evictExpiredLocked removes expired bundles (caller must hold lock).
+func (s *FileBundleStorage) evictExpiredLocked() (int, error) {
+	now := time.Now().UTC()
+	count := 0
+	for id, entry := range s.index {
+		if now.After(entry.expiresAt) {
+			if err := s.deleteLocked(id); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// evictLowestPriorityLocked removes the lowest priority bundle (caller must hold lock).
+func (s *FileBundleStorage) evictLowestPriorityLocked() error {
+	var lowestID uuid.UUID
+	var lowestPriority uint8 = 255
+	var oldestTime time.Time
+
+	for id, entry := range s.index {
+		if entry.priority < lowestPriority ||
+			(entry.priority == lowestPriority && entry.storedAt.Before(oldestTime)) {
+			lowestID = id
+			lowestPriority = entry.priority
+			oldestTime = entry.storedAt
+		}
+	}
+
+	if lowestID == uuid.Nil {
+		return nil
+	}
+	return s.deleteLocked(lowestID)
+}
+
+// appendLocked writes a record to the active segment, rolling over to a new
+// segment when it is full.
+func (s *FileBundleStorage) appendLocked(kind byte, body []byte) (*segment, int64, int64, error) {
+	if s.active.size > 0 && s.active.size+recordHeaderSize+int64(len(body)) > s.config.SegmentSize {
+		if err := s.rollLocked(); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	record := make([]byte, recordHeaderSize+len(body))
+	binary.BigEndian.PutUint32(record[4:8], uint32(len(body)))
+	record[8] = kind
+	copy(record[recordHeaderSize:], body)
+	binary.BigEndian.PutUint32(record[0:4], crc32.Checksum(record[4:], recordCRC))
+
+	seg := s.active
+	offset := seg.size
+	if _, err := seg.file.Write(record); err != nil {
+		return nil, 0, 0, fmt.Errorf("append to segment %d: %w", seg.id, err)
+	}
+	seg.size += int64(len(record))
+
+	switch s.config.Sync {
+	case SyncAlways:
+		if err := seg.file.Sync(); err != nil {
+			return nil, 0, 0, fmt.Errorf("sync segment %d: %w", seg.id, err)
+		}
+	case SyncInterval:
+		s.dirty = true
+	}
+	return seg, offset, int64(len(record)), nil
+}
+
+// rollLocked seals the active segment and starts a new one.
+func (s *FileBundleStorage) rollLocked() error {
+	if err := s.active.file.Sync(); err != nil {
+		return fmt.Errorf("sync segment %d: %w", s.active.id, err)
+	}
+	return s.openSegmentLocked(s.active.id + 1)
+}
+
+func (s *FileBundleStorage) openSegmentLocked(id uint64) error {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open segment %d: %w", id, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat segment %d: %w", id, err)
+	}
+	seg := &segment{id: id, file: f, size: info.Size()}
+	s.segments[id] = seg
+	s.active = seg
+	return nil
+}
+
+func (s *FileBundleStorage) segmentPath(id uint64) string {
+	return filepath.Join(s.config.Dir, fmt.Sprintf("%016d%s", id, segmentExt))
+}
+
+// maybeCompactLocked compacts when dead records dominate the sealed segments.
+func (s *FileBundleStorage) maybeCompactLocked() error {
+	if s.config.CompactRatio <= 0 {
+		return nil
+	}
+	var total, live int64
+	for id, seg := range s.segments {
+		if id != s.active.id {
+			total += seg.size
+			live += seg.live
+		}
+	}
+	if total == 0 || float64(total-live)/float64(total) < s.config.CompactRatio {
+		return nil
+	}
+	return s.compactLocked()
+}
+
+// compactLocked copies live records out of sealed segments, syncs them and
+// deletes the sealed segment files. A crash part way through leaves both
+// copies on disk; replay keeps the later one, so no data is lost. Segments
+// are removed oldest first, syncing the directory after each, so a crash
+// can never keep a put record whose later delete record is already gone.
+func (s *FileBundleStorage) compactLocked() error {
+	sealed := make(map[uint64]bool)
+	var order []uint64
+	for id := range s.segments {
+		if id != s.active.id {
+			sealed[id] = true
+			order = append(order, id)
+		}
+	}
+	if len(sealed) == 0 {
+		return nil
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+
+	for _, entry := range s.index {
+		if !sealed[entry.segment] {
+			continue
+		}
+		b, err := s.readBundleLocked(entry)
+		if err != nil {
+			return err
+		}
+		body, err := encodePutRecord(b, entry.status, entry.storedAt)
+		if err != nil {
+			return err
+		}
+		seg, offset, length, err := s.appendLocked(recordPut, body)
+		if err != nil {
+			return err
+		}
+		s.segments[entry.segment].live -= entry.length
+		entry.segment, entry.offset, entry.length = seg.id, offset, length
+		seg.live += length
+	}
+	if err := s.active.file.Sync(); err != nil {
+		return fmt.Errorf("sync segment %d: %w", s.active.id, err)
+	}
+
+	removed := 0
+	for _, id := range order {
+		seg := s.segments[id]
+		if seg.live > 0 {
+			// Later segments may hold deletes for records in this one
+			break
+		}
+		seg.file.Close()
+		if err := os.Remove(s.segmentPath(id)); err != nil {
+			return fmt.Errorf("remove segment %d: %w", id, err)
+		}
+		delete(s.segments, id)
+		removed++
+		if err := s.syncDir(); err != nil {
+			return err
+		}
+	}
+	log.Printf("[FileStorage] Compacted %d segments in %s", removed, s.config.Dir)
+	return nil
+}
+
+// syncDir flushes the storage directory so segment removals are durable.
+func (s *FileBundleStorage) syncDir() error {
+	dir, err := os.Open(s.config.Dir)
+	if err != nil {
+		return fmt.Errorf("open storage directory: %w", err)
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("sync storage directory: %w", err)
+	}
+	return nil
+}
+
+// recover replays every segment to rebuild the index.
+func (s *FileBundleStorage) recover() error {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return fmt.Errorf("read storage directory: %w", err)
+	}
+
+	var ids []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if len(ids) == 0 {
+		return s.openSegmentLocked(1)
+	}
+
+	for i, id := range ids {
+		if err := s.openSegmentLocked(id); err != nil {
+			return err
+		}
+		if err := s.replaySegment(s.active, i == len(ids)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaySegment applies the records of one segment to the index. A damaged
+// record ends the segment; in the newest segment it is a torn write and the
+// file is truncated there.
+func (s *FileBundleStorage) replaySegment(seg *segment, last bool) error {
+	var offset int64
+	header := make([]byte, recordHeaderSize)
+	for offset < seg.size {
+		body, err := readRecord(seg.file, offset, seg.size, header)
+		if err != nil {
+			if last {
+				log.Printf("[FileStorage] Truncating torn record in segment %d at offset %d: %v", seg.id, offset, err)
+				if terr := seg.file.Truncate(offset); terr != nil {
+					return fmt.Errorf("truncate segment %d: %w", seg.id, terr)
+				}
+				seg.size = offset
+			} else {
+				log.Printf("[FileStorage] Skipping damaged tail of segment %d at offset %d: %v", seg.id, offset, err)
+			}
+			return nil
+		}
+		length := int64(recordHeaderSize + len(body))
+
+		switch header[8] {
+		case recordPut:
+			meta, err := decodePutRecord(body)
+			if err != nil {
+				return fmt.Errorf("segment %d offset %d: %w", seg.id, offset, err)
+			}
+			s.dropEntryLocked(meta.ID)
+			s.setEntryLocked(meta.ID, &fileEntry{
+				segment:     seg.id,
+				offset:      offset,
+				length:      length,
+				status:      meta.Status,
+				storedAt:    meta.StoredAt,
+				priority:    meta.Bundle.Priority,
+				size:        meta.Bundle.Size(),
+				source:      meta.Bundle.SourceEID,
+				destination: meta.Bundle.DestinationEID,
+				expiresAt:   meta.Bundle.ExpiresAt(),
+			})
+		case recordStatus:
+			if len(body) < 16 {
+				return fmt.Errorf("segment %d offset %d: short status record", seg.id, offset)
+			}
+			id, _ := uuid.FromBytes(body[:16])
+			if entry, ok := s.index[id]; ok {
+				entry.status = BundleStatus(body[16:])
+			}
+		case recordDelete:
+			if len(body) != 16 {
+				return fmt.Errorf("segment %d offset %d: bad delete record", seg.id, offset)
+			}
+			id, _ := uuid.FromBytes(body)
+			s.dropEntryLocked(id)
+		default:
+			return fmt.Errorf("segment %d offset %d: unknown record kind %d", seg.id, offset, header[8])
+		}
+		offset += length
+	}
+	return nil
+}
+
+// readBundleLocked loads the bundle an index entry points at.
+func (s *FileBundleStorage) readBundleLocked(entry *fileEntry) (*bundle.Bundle, error) {
+	seg, ok := s.segments[entry.segment]
+	if !ok {
+		return nil, fmt.Errorf("segment %d missing", entry.segment)
+	}
+	body, err := readRecord(seg.file, entry.offset, seg.size, make([]byte, recordHeaderSize))
+	if err != nil {
+		return nil, fmt.Errorf("read segment %d: %w", seg.id, err)
+	}
+	meta, err := decodePutRecord(body)
+	if err != nil {
+		return nil, err
+	}
+	return meta.Bundle, nil
+}
+
+func (s *FileBundleStorage) syncLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty {
+				if err := s.active.file.Sync(); err != nil {
+					log.Printf("[FileStorage] Sync failed: %v", err)
+				}
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *FileBundleStorage) closeFiles() error {
+	var first error
+	for _, seg := range s.segments {
+		if err := seg.file.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// readRecord reads and verifies the record at offset of a segment of size
+// bytes, returning its body. header receives the record header.
+func readRecord(r io.ReaderAt, offset, size int64, header []byte) ([]byte, error) {
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, fmt.Errorf("short record header: %w", err)
+	}
+	// A damaged length must not size the allocation
+	length := int64(binary.BigEndian.Uint32(header[4:8]))
+	if length > size-offset-recordHeaderSize {
+		return nil, fmt.Errorf("record of %d bytes runs past the end of the segment", length)
+	}
+	body := make([]byte, length)
+	if _, err := r.ReadAt(body, offset+recordHeaderSize); err != nil {
+		return nil, fmt.Errorf("short record body: %w", err)
+	}
+
+	crc := crc32.Update(crc32.Checksum(header[4:], recordCRC), recordCRC, body)
+	if crc != binary.BigEndian.Uint32(header[0:4]) {
+		return nil, fmt.Errorf("record checksum mismatch")
+	}
+	return body, nil
+}
+
+// putRecord is the decoded form of a bundle record.
+type putRecord struct {
+	ID       uuid.UUID
+	Status   BundleStatus
+	StoredAt time.Time
+	Bundle   *bundle.Bundle
+}
+
+// storedMeta is the on-disk form of every bundle field except the payload.
+// Bundle.MarshalJSON is a display format that omits extension blocks and
+// custody state, so the store keeps its own complete record.
+type storedMeta struct {
+	Version           uint8                   `json:"v"`
+	BundleFlags       uint64                  `json:"flags"`
+	DestinationEID    string                  `json:"dst"`
+	SourceEID         string                  `json:"src"`
+	ReportTo          string                  `json:"rpt,omitempty"`
+	CreationTimestamp int64                   `json:"ts"`
+	Lifetime          int64                   `json:"life"`
+	CRCType           uint8                   `json:"crc"`
+	PreviousNode      string                  `json:"prev,omitempty"`
+	HopCount          uint32                  `json:"hops,omitempty"`
+	Priority          uint8                   `json:"pri"`
+	FragmentOffset    uint64                  `json:"foff,omitempty"`
+	TotalADULength    uint64                  `json:"adu,omitempty"`
+	IsFragment        bool                    `json:"frag,omitempty"`
+	SequenceNumber    uint64                  `json:"seq,omitempty"`
+	BundleAge         int64                   `json:"age,omitempty"`
+	Blocks            []bundle.CanonicalBlock `json:"blocks,omitempty"`
+	Custodian         string                  `json:"cust,omitempty"`
+}
+
+// encodePutRecord lays out a bundle record as the bundle ID, stored-at time,
+// status, the bundle metadata as JSON and finally the raw payload.
+func encodePutRecord(b *bundle.Bundle, status BundleStatus, storedAt time.Time) ([]byte, error) {
+	metaJSON, err := json.Marshal(storedMeta{
+		Version:           b.Version,
+		BundleFlags:       b.BundleFlags,
+		DestinationEID:    b.DestinationEID,
+		SourceEID:         b.SourceEID,
+		ReportTo:          b.ReportTo,
+		CreationTimestamp: b.CreationTimestamp.UnixNano(),
+		Lifetime:          int64(b.Lifetime),
+		CRCType:           b.CRCType,
+		PreviousNode:      b.PreviousNode,
+		HopCount:          b.HopCount,
+		Priority:          b.Priority,
+		FragmentOffset:    b.FragmentOffset,
+		TotalADULength:    b.TotalADULength,
+		IsFragment:        b.IsFragment,
+		SequenceNumber:    b.SequenceNumber,
+		BundleAge:         int64(b.BundleAge),
+		Blocks:            b.Blocks,
+		Custodian:         b.Custodian,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode bundle metadata: %w", err)
+	}
+
+	body := make([]byte, 0, 16+8+1+len(status)+4+len(metaJSON)+len(b.Payload))
+	body = append(body, b.ID[:]...)
+	body = binary.BigEndian.AppendUint64(body, uint64(storedAt.UnixNano()))
+	body = append(body, byte(len(status)))
+	body = append(body, status...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(metaJSON)))
+	body = append(body, metaJSON...)
+	body = append(body, b.Payload...)
+	return body, nil
+}
+
+func decodePutRecord(body []byte) (*putRecord, error) {
+	if len(body) < 16+8+1 {
+		return nil, fmt.Errorf("short bundle record")
+	}
+	rec := &putRecord{}
+	copy(rec.ID[:], body[:16])
+	rec.StoredAt = time.Unix(0, int64(binary.BigEndian.Uint64(body[16:24]))).UTC()
+
+	statusLen := int(body[24])
+	pos := 25
+	if len(body) < pos+statusLen+4 {
+		return nil, fmt.Errorf("short bundle record")
+	}
+	rec.Status = BundleStatus(body[pos : pos+statusLen])
+	pos += statusLen
+
+	metaLen := int(binary.BigEndian.Uint32(body[pos : pos+4]))
+	pos += 4
+	if len(body) < pos+metaLen {
+		return nil, fmt.Errorf("short bundle metadata")
+	}
+
+	var meta storedMeta
+	if err := json.Unmarshal(body[pos:pos+metaLen], &meta); err != nil {
+		return nil, fmt.Errorf("decode bundle metadata: %w", err)
+	}
+	rec.Bundle = &bundle.Bundle{
+		ID:                rec.ID,
+		Version:           meta.Version,
+		BundleFlags:       meta.BundleFlags,
+		DestinationEID:    meta.DestinationEID,
+		SourceEID:         meta.SourceEID,
+		ReportTo:          meta.ReportTo,
+		CreationTimestamp: time.Unix(0, meta.CreationTimestamp).UTC(),
+		Lifetime:          time.Duration(meta.Lifetime),
+		Payload:           append([]byte(nil), body[pos+metaLen:]...),
+		CRCType:           meta.CRCType,
+		PreviousNode:      meta.PreviousNode,
+		HopCount:          meta.HopCount,
+		Priority:          meta.Priority,
+		FragmentOffset:    meta.FragmentOffset,
+		TotalADULength:    meta.TotalADULength,
+		IsFragment:        meta.IsFragment,
+		SequenceNumber:    meta.SequenceNumber,
+		BundleAge:         time.Duration(meta.BundleAge),
+		Blocks:            meta.Blocks,
+		Custodian:         meta.Custodian,
+	}
+	return rec, nil
+}
+
+func encodeStatusRecord(id uuid.UUID, status BundleStatus) []byte {
+	return append(append([]byte(nil), id[:]...), status...)
+}
@@ -0,0 +1,300 @@
+package dtn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+	"github.com/google/uuid"
+)
+
+// fragmentOverhead is the headroom reserved per fragment for the fragment
+// offset and total ADU length fields added to the primary block.
+const fragmentOverhead = 64
+
+// Reassembler collects fragments addressed to the local node in bundle
+// storage and rebuilds the original bundle once every part has arrived.
+// Holding fragments in storage lets reassembly survive node restarts when
+// the storage backend is persistent.
+type Reassembler struct {
+	storage BundleStorage
+	mu      sync.Mutex
+}
+
+// NewReassembler creates a reassembler backed by storage.
+func NewReassembler(storage BundleStorage) *Reassembler {
+	return &Reassembler{storage: storage}
+}
+
+// Add records a fragment. It returns the reassembled bundle when the ADU is
+// complete, or nil while fragments are still outstanding.
+func (r *Reassembler) Add(ctx context.Context, frag *bundle.Bundle) (*bundle.Bundle, error) {
+	if !frag.IsFragment {
+		return frag, nil
+	}
+	if frag.ADULength() > bundle.MaxADULength {
+		return nil, fmt.Errorf("fragment %s claims ADU length %d beyond maximum", frag.ID, frag.ADULength())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.storage.Store(ctx, frag); err != nil {
+		return nil, fmt.Errorf("failed to store fragment: %w", err)
+	}
+	if err := r.storage.UpdateStatus(ctx, frag.ID, StatusReassembling); err != nil {
+		return nil, fmt.Errorf("failed to mark fragment: %w", err)
+	}
+
+	held, err := r.storage.List(ctx, BundleFilter{
+		SourceEID: frag.SourceEID,
+		Status:    StatusReassembling,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fragments: %w", err)
+	}
+
+	key := frag.FragmentKey()
+	parts := make([]*bundle.Bundle, 0, len(held))
+	for _, b := range held {
+		if b.IsFragment && b.FragmentKey() == key {
+			parts = append(parts, b)
+		}
+	}
+
+	whole, err := bundle.Reassemble(parts)
+	if errors.Is(err, bundle.ErrIncompleteADU) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts {
+		r.storage.Delete(ctx, part.ID)
+	}
+	return whole, nil
+}
+
+// linkUsage tracks bytes sent to a neighbor during its current contact.
+type linkUsage struct {
+	contactStart time.Time
+	bytes        int64
+}
+
+// linkLimits returns the MTU and remaining contact capacity for a neighbor.
+// A negative capacity means the contact window is unbounded.
+func (n *Node) linkLimits(neighbor *Neighbor) (mtu int64, capacity int64) {
+	mtu = int64(neighbor.MTU)
+	if provider, ok := n.transport.(LinkMTUProvider); ok {
+		if linkMTU := int64(provider.LinkMTU(neighbor.ID)); linkMTU > 0 && (mtu == 0 || linkMTU < mtu) {
+			mtu = linkMTU
+		}
+	}
+
+	capacity = -1
+	if neighbor.Bandwidth > 0 && !neighbor.ContactEnd.IsZero() {
+		remaining := neighbor.ContactEnd.Sub(n.now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		capacity = int64(float64(neighbor.Bandwidth)*remaining.Seconds()) - n.bookedBytes(neighbor)
+		if capacity < 0 {
+			capacity = 0
+		}
+	}
+	return mtu, capacity
+}
+
+// bookedBytes returns how many bytes were already sent in the neighbor's
+// current contact window.
+func (n *Node) bookedBytes(neighbor *Neighbor) int64 {
+	n.usageMu.Lock()
+	defer n.usageMu.Unlock()
+
+	usage, ok := n.linkUsage[neighbor.ID]
+	if !ok || !usage.contactStart.Equal(neighbor.ContactStart) {
+		return 0
+	}
+	return usage.bytes
+}
+
+// bookLinkUsage records bytes sent to a neighbor against its contact window.
+func (n *Node) bookLinkUsage(neighbor *Neighbor, size int64) {
+	n.usageMu.Lock()
+	defer n.usageMu.Unlock()
+
+	usage, ok := n.linkUsage[neighbor.ID]
+	if !ok || !usage.contactStart.Equal(neighbor.ContactStart) {
+		usage = &linkUsage{contactStart: neighbor.ContactStart}
+		n.linkUsage[neighbor.ID] = usage
+	}
+	usage.bytes += size
+}
+
+// forwardFragments proactively fragments b so each piece fits the link MTU,
+// sends what fits in the current contact and defers the rest.
+func (n *Node) forwardFragments(b *bundle.Bundle, neighbor *Neighbor, mtu, capacity int64) {
+	limit := mtu
+	if capacity >= 0 && (limit <= 0 || capacity < limit) {
+		limit = capacity
+	}
+
+	headerSize := int64(b.Size()-len(b.Payload)) + fragmentOverhead
+	maxPayload := limit - headerSize
+	if maxPayload <= 0 {
+		n.releaseBooking(b)
+		if mtu > 0 && mtu <= headerSize {
+			log.Printf("[DTN Node %s] Link MTU %d to %s too small for bundle %s",
+				n.ID, mtu, neighbor.ID, b.ID.String()[:8])
+			n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
+			return
+		}
+		// Contact window exhausted; wait for the next one
+		n.deferBundles(b)
+		return
+	}
+
+	fragments, err := bundle.Fragment(b, int(maxPayload))
+	if err != nil {
+		n.releaseBooking(b)
+		if errors.Is(err, bundle.ErrMustNotFragment) && (mtu <= 0 || int64(b.Size()) <= mtu) {
+			// Fits the link, just not this contact window
+			n.deferBundles(b)
+			return
+		}
+		log.Printf("[DTN Node %s] Cannot fragment bundle %s for %s: %v", n.ID, b.ID.String()[:8], neighbor.ID, err)
+		n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
+		return
+	}
+
+	log.Printf("[DTN Node %s] Fragmented bundle %s into %d fragments for %s",
+		n.ID, b.ID.String()[:8], len(fragments), neighbor.ID)
+	n.recordMetric(func(m *NodeMetrics) { m.FragmentsCreated += int64(len(fragments)) })
+
+	// The fragments replace the original in storage
+	n.storage.Delete(n.ctx, b.ID)
+
+	for i, frag := range fragments {
+		if capacity >= 0 && int64(frag.Size()) > capacity {
+			// Deferred fragments book their own route when retried
+			n.releaseBooking(b)
+			n.deferBundles(fragments[i:]...)
+			return
+		}
+		if err := n.transmit(frag, neighbor); err != nil {
+			var partial *PartialTransferError
+			if errors.As(err, &partial) && partial.DeliveredPayload() > 0 {
+				// transmit stored the undelivered remainder
+				i++
+			}
+			n.releaseBooking(b)
+			n.deferBundles(fragments[i:]...)
+			return
+		}
+		if capacity >= 0 {
+			capacity -= int64(frag.Size())
+		}
+	}
+}
+
+// handlePartialTransfer keeps the payload bytes a peer did not acknowledge
+// as a new fragment (reactive fragmentation).
+func (n *Node) handlePartialTransfer(b *bundle.Bundle, partial *PartialTransferError) {
+	delivered := partial.DeliveredPayload()
+	remainder, err := bundle.FragmentRange(b, delivered, uint64(len(b.Payload)))
+	if err != nil {
+		log.Printf("[DTN Node %s] Cannot keep remainder of bundle %s: %v", n.ID, b.ID.String()[:8], err)
+		n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
+		return
+	}
+
+	log.Printf("[DTN Node %s] Transfer of bundle %s to %s interrupted after %d payload bytes, deferring remainder",
+		n.ID, b.ID.String()[:8], partial.NeighborID, delivered)
+	n.recordMetric(func(m *NodeMetrics) { m.FragmentsCreated++ })
+
+	n.storage.Delete(n.ctx, b.ID)
+	n.deferBundles(remainder)
+}
+
+// deferBundles stores bundles until the next retry pass.
+func (n *Node) deferBundles(bundles ...*bundle.Bundle) {
+	for _, b := range bundles {
+		if err := n.storage.Store(n.ctx, b); err != nil {
+			log.Printf("[DTN Node %s] Failed to defer bundle %s: %v", n.ID, b.ID.String()[:8], err)
+			continue
+		}
+		n.storage.UpdateStatus(n.ctx, b.ID, StatusDeferred)
+	}
+}
+
+// retryDeferred requeues deferred and failed bundles for forwarding. A
+// bundle still failing after MaxRetries passes, such as one whose headers
+// alone exceed the link MTU, is marked undeliverable and left alone.
+func (n *Node) retryDeferred() {
+	active := false
+	for _, neighbor := range n.GetNeighbors() {
+		if neighbor.IsActive {
+			active = true
+			break
+		}
+	}
+	if !active {
+		return
+	}
+
+	n.retryMu.Lock()
+	defer n.retryMu.Unlock()
+
+	for _, status := range []BundleStatus{StatusDeferred, StatusFailed} {
+		bundles, err := n.storage.List(n.ctx, BundleFilter{Status: status, OrderBy: "priority"})
+		if err != nil {
+			log.Printf("[DTN Node %s] Failed to list %s bundles: %v", n.ID, status, err)
+			continue
+		}
+		if status == StatusFailed {
+			n.pruneRetries(bundles)
+		}
+		for _, b := range bundles {
+			if b.DestinationEID == n.EID {
+				continue
+			}
+			if status == StatusFailed {
+				n.retries[b.ID]++
+				if n.config.MaxRetries > 0 && n.retries[b.ID] > n.config.MaxRetries {
+					log.Printf("[DTN Node %s] Bundle %s still failing after %d retries, giving up",
+						n.ID, b.ID.String()[:8], n.config.MaxRetries)
+					n.storage.UpdateStatus(n.ctx, b.ID, StatusUndeliverable)
+					delete(n.retries, b.ID)
+					continue
+				}
+			}
+			// Mark pending first: forwarding may fail again before Send
+			// returns, and that outcome must not be overwritten.
+			n.storage.UpdateStatus(n.ctx, b.ID, StatusPending)
+			if err := n.Send(n.ctx, b); err != nil {
+				// Egress queue full; try again next pass
+				n.storage.UpdateStatus(n.ctx, b.ID, status)
+				return
+			}
+		}
+	}
+}
+
+// pruneRetries forgets retry counts of bundles no longer failed. The
+// caller holds retryMu.
+func (n *Node) pruneRetries(failed []*bundle.Bundle) {
+	current := make(map[uuid.UUID]bool, len(failed))
+	for _, b := range failed {
+		current[b.ID] = true
+	}
+	for id := range n.retries {
+		if !current[id] {
+			delete(n.retries, id)
+		}
+	}
+}
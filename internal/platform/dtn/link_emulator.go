@@ -0,0 +1,279 @@
+package dtn
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LinkEmulatorConfig controls the impairments applied by a LinkEmulator.
+type LinkEmulatorConfig struct {
+	Delay     time.Duration          // One-way propagation delay
+	Jitter    time.Duration          // Extra random delay in [0, Jitter)
+	Loss      float64                // Probability that a datagram is dropped (0.0 to 1.0)
+	Seed      int64                  // Random seed for reproducible loss (0 = time-based)
+	Drop      func(data []byte) bool // Drops datagrams for which it returns true
+	QueueSize int                    // Datagrams buffered per endpoint before tail drop
+}
+
+// LinkEmulatorStats counts datagrams handled by a LinkEmulator.
+type LinkEmulatorStats struct {
+	Sent      uint64
+	Dropped   uint64
+	Delivered uint64
+}
+
+// LinkEmulator is an in-process datagram network with configurable delay
+// and loss. Its endpoints implement net.PacketConn, so datagram convergence
+// layers can run over it in place of UDP sockets. Endpoint addresses are
+// UDP addresses and can be passed to Connect as usual.
+type LinkEmulator struct {
+	config    LinkEmulatorConfig
+	rng       *rand.Rand
+	endpoints map[string]*emulatedConn
+	nextPort  int
+	mu        sync.Mutex
+
+	sent      atomic.Uint64
+	dropped   atomic.Uint64
+	delivered atomic.Uint64
+}
+
+// NewLinkEmulator creates an emulated link shared by all its endpoints.
+func NewLinkEmulator(config LinkEmulatorConfig) *LinkEmulator {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	return &LinkEmulator{
+		config:    config,
+		rng:       rand.New(rand.NewSource(seed)),
+		endpoints: make(map[string]*emulatedConn),
+		nextPort:  40000,
+	}
+}
+
+// ListenPacket opens an endpoint on the emulated link. An empty address or
+// port 0 allocates a free loopback port.
+func (e *LinkEmulator) ListenPacket(address string) (net.PacketConn, error) {
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	if address != "" {
+		resolved, err := net.ResolveUDPAddr("udp", address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid emulator address %q: %w", address, err)
+		}
+		if resolved.IP != nil {
+			addr.IP = resolved.IP
+		}
+		addr.Port = resolved.Port
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if addr.Port == 0 {
+		for {
+			e.nextPort++
+			addr.Port = e.nextPort
+			if _, taken := e.endpoints[addr.String()]; !taken {
+				break
+			}
+		}
+	}
+	if _, taken := e.endpoints[addr.String()]; taken {
+		return nil, fmt.Errorf("emulator address %s already in use", addr)
+	}
+
+	c := &emulatedConn{
+		link:   e,
+		addr:   addr,
+		inbox:  make(chan emulatedDatagram, e.config.QueueSize),
+		closed: make(chan struct{}),
+	}
+	e.endpoints[addr.String()] = c
+	return c, nil
+}
+
+// SetLoss changes the datagram loss probability.
+func (e *LinkEmulator) SetLoss(loss float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.config.Loss = loss
+}
+
+// SetDelay changes the one-way propagation delay.
+func (e *LinkEmulator) SetDelay(delay time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.config.Delay = delay
+}
+
+// Stats returns datagram counters for the link.
+func (e *LinkEmulator) Stats() LinkEmulatorStats {
+	return LinkEmulatorStats{
+		Sent:      e.sent.Load(),
+		Dropped:   e.dropped.Load(),
+		Delivered: e.delivered.Load(),
+	}
+}
+
+// transmit applies loss and delay to a datagram and schedules its delivery.
+func (e *LinkEmulator) transmit(from net.Addr, to string, data []byte) {
+	e.sent.Add(1)
+
+	e.mu.Lock()
+	lost := e.config.Loss > 0 && e.rng.Float64() < e.config.Loss
+	delay := e.config.Delay
+	if e.config.Jitter > 0 {
+		delay += time.Duration(e.rng.Int63n(int64(e.config.Jitter)))
+	}
+	drop := e.config.Drop
+	e.mu.Unlock()
+
+	if lost || (drop != nil && drop(data)) {
+		e.dropped.Add(1)
+		return
+	}
+
+	e.mu.Lock()
+	dst, ok := e.endpoints[to]
+	if !ok {
+		e.mu.Unlock()
+		e.dropped.Add(1)
+		return
+	}
+	// Keep datagrams in order of arrival time, then of transmission
+	d := emulatedDatagram{from: from, data: data, due: time.Now().Add(delay)}
+	i := sort.Search(len(dst.pending), func(i int) bool { return dst.pending[i].due.After(d.due) })
+	dst.pending = append(dst.pending, emulatedDatagram{})
+	copy(dst.pending[i+1:], dst.pending[i:])
+	dst.pending[i] = d
+	e.mu.Unlock()
+
+	time.AfterFunc(delay, func() { e.flush(dst) })
+}
+
+// flush moves datagrams that have crossed the link into the endpoint's
+// inbox. Timers may fire out of order, so every flush delivers all due
+// datagrams in sequence.
+func (e *LinkEmulator) flush(c *emulatedConn) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	for len(c.pending) > 0 && !c.pending[0].due.After(now) {
+		d := c.pending[0]
+		c.pending = c.pending[1:]
+		select {
+		case c.inbox <- d:
+			e.delivered.Add(1)
+		case <-c.closed:
+			e.dropped.Add(1)
+		default:
+			e.dropped.Add(1)
+		}
+	}
+}
+
+func (e *LinkEmulator) remove(c *emulatedConn) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.endpoints[c.addr.String()] == c {
+		delete(e.endpoints, c.addr.String())
+	}
+}
+
+type emulatedDatagram struct {
+	from net.Addr
+	data []byte
+	due  time.Time
+}
+
+// emulatedConn is one endpoint of a LinkEmulator.
+type emulatedConn struct {
+	link      *LinkEmulator
+	addr      *net.UDPAddr
+	inbox     chan emulatedDatagram
+	pending   []emulatedDatagram // in flight, ordered by due time
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	deadlineMu   sync.Mutex
+	readDeadline time.Time
+}
+
+// ReadFrom implements net.PacketConn.
+func (c *emulatedConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.deadlineMu.Lock()
+	deadline := c.readDeadline
+	c.deadlineMu.Unlock()
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case d := <-c.inbox:
+		return copy(p, d.data), d.from, nil
+	case <-c.closed:
+		return 0, nil, net.ErrClosed
+	case <-expired:
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+// WriteTo implements net.PacketConn. Datagrams to unknown addresses are
+// silently discarded, as with UDP.
+func (c *emulatedConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.link.transmit(c.addr, addr.String(), append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// Close implements net.PacketConn.
+func (c *emulatedConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.link.remove(c)
+	})
+	return nil
+}
+
+// LocalAddr implements net.PacketConn.
+func (c *emulatedConn) LocalAddr() net.Addr {
+	return c.addr
+}
+
+// SetDeadline implements net.PacketConn.
+func (c *emulatedConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+// SetReadDeadline implements net.PacketConn. The deadline applies to
+// subsequent ReadFrom calls.
+func (c *emulatedConn) SetReadDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline implements net.PacketConn. Writes never block.
+func (c *emulatedConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,1223 @@
+package dtn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// LTP segment type codes (RFC 5326 Section 3.1.3).
+const (
+	ltpRedData             = 0x0
+	ltpRedCheckpoint       = 0x1
+	ltpRedCheckpointEORP   = 0x2
+	ltpRedCheckpointEOB    = 0x3
+	ltpGreenData           = 0x4
+	ltpGreenEOB            = 0x7
+	ltpReportSegment       = 0x8
+	ltpReportAck           = 0x9
+	ltpCancelFromSender    = 0xc
+	ltpCancelAckToSender   = 0xd
+	ltpCancelFromReceiver  = 0xe
+	ltpCancelAckToReceiver = 0xf
+
+	// ltpClientBundle is the client service ID of the Bundle Protocol.
+	ltpClientBundle = 1
+
+	// ltpMaxClaims bounds the reception claims in one report segment.
+	ltpMaxClaims = 64
+
+	ltpMaxDatagram = 65535
+)
+
+// LTPCancelReason is the reason code carried by cancel segments.
+type LTPCancelReason uint8
+
+const (
+	LTPCancelUserCancelled   LTPCancelReason = 0x00
+	LTPCancelUnreachable     LTPCancelReason = 0x01
+	LTPCancelRetransmitLimit LTPCancelReason = 0x02
+	LTPCancelMiscolored      LTPCancelReason = 0x03
+	LTPCancelSystem          LTPCancelReason = 0x04
+	LTPCancelCycleLimit      LTPCancelReason = 0x05
+)
+
+// String returns the RFC 5326 mnemonic for the reason code.
+func (r LTPCancelReason) String() string {
+	switch r {
+	case LTPCancelUserCancelled:
+		return "USR_CNCLD"
+	case LTPCancelUnreachable:
+		return "UNREACH"
+	case LTPCancelRetransmitLimit:
+		return "RLEXC"
+	case LTPCancelMiscolored:
+		return "MISCOLORED"
+	case LTPCancelSystem:
+		return "SYS_CNCLD"
+	case LTPCancelCycleLimit:
+		return "RXMTCYCEXC"
+	}
+	return fmt.Sprintf("reason(%d)", uint8(r))
+}
+
+// ErrLTPCancelled is returned by Send when the LTP session is cancelled.
+var ErrLTPCancelled = errors.New("ltp: session cancelled")
+
+// LTPConfig holds configuration for the LTP convergence layer.
+type LTPConfig struct {
+	ListenAddress      string         // UDP address for LTP segments (IANA port 1113)
+	EngineID           uint64         // Local engine ID (0 = ipn node number of the local EID)
+	Conn               net.PacketConn // Pre-opened socket, e.g. a LinkEmulator endpoint (overrides ListenAddress)
+	MaxSegmentSize     int            // Block bytes carried per data segment
+	MaxBlockSize       uint64         // Largest block accepted from a peer
+	OWLT               time.Duration  // Default one-way light time of a span
+	TimerMargin        time.Duration  // Processing allowance added to the round trip
+	MaxRetransmissions int            // Checkpoint and report retransmission limit
+	GreenBulk          bool           // Send the payload of bulk-priority bundles as green data
+	Codec              bundle.Codec   // Bundle encoding (RFC 9171 CBOR by default)
+}
+
+// DefaultLTPConfig returns sensible defaults for the LTP convergence layer.
+func DefaultLTPConfig() LTPConfig {
+	return LTPConfig{
+		ListenAddress:      ":1113",
+		MaxSegmentSize:     1024,
+		MaxBlockSize:       100 * 1024 * 1024, // 100MB
+		OWLT:               time.Second,
+		TimerMargin:        500 * time.Millisecond,
+		MaxRetransmissions: 5,
+		Codec:              bundle.CBOR,
+	}
+}
+
+// LTPTransport implements TransportAdapter with a Licklider Transmission
+// Protocol engine (RFC 5326) running over UDP (RFC 7122 Section 4). Each
+// bundle is sent as one LTP block. The red part is delivered reliably
+// using checkpoints, report segments and retransmission timers derived
+// from the span's one-way light time; the green part is sent once.
+type LTPTransport struct {
+	config      LTPConfig
+	localNodeID string
+	engineID    uint64
+
+	conn        net.PacketConn
+	spans       map[string]*ltpSpan // neighbor ID -> span
+	owlts       map[string]time.Duration
+	exports     map[uint64]*ltpExport
+	imports     map[ltpSessionID]*ltpImport
+	nextSession uint64
+	stopped     bool
+	mu          sync.Mutex
+
+	receiveChan chan *bundle.Bundle
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// ltpSpan is the LTP engine of a neighbor.
+type ltpSpan struct {
+	neighborID string
+	addr       net.Addr
+	owlt       time.Duration
+}
+
+type ltpSessionID struct {
+	originator uint64
+	number     uint64
+}
+
+// ltpTimer is a segment awaiting a response, retransmitted on expiry.
+type ltpTimer struct {
+	segment *ltpSegment
+	timer   *time.Timer
+	retries int
+}
+
+// ltpExport is the sending side of a session.
+type ltpExport struct {
+	id             uint64
+	span           *ltpSpan
+	data           []byte
+	redLen         uint64
+	acked          ltpRanges
+	nextCheckpoint uint64
+	checkpoints    map[uint64]*ltpTimer
+	reports        map[uint64]bool
+	finished       bool
+	done           chan error
+}
+
+// ltpImport is the receiving side of a session.
+type ltpImport struct {
+	id         ltpSessionID
+	addr       net.Addr
+	owlt       time.Duration
+	clientID   uint64
+	buf        []byte
+	red        ltpRanges
+	green      ltpRanges
+	redLen     uint64
+	redKnown   bool
+	blockLen   uint64
+	eob        bool
+	nextReport uint64
+	reports    map[uint64]*ltpTimer
+	delivered  bool
+	closed     bool
+	idle       *time.Timer
+}
+
+// NewLTPTransport creates an LTP convergence layer for the local node.
+func NewLTPTransport(localNodeEID string, config LTPConfig) *LTPTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	if config.Codec == nil {
+		config.Codec = bundle.CBOR
+	}
+	if config.MaxSegmentSize <= 0 {
+		config.MaxSegmentSize = 1024
+	}
+	if config.MaxBlockSize == 0 {
+		config.MaxBlockSize = 100 * 1024 * 1024
+	}
+	if config.MaxRetransmissions <= 0 {
+		config.MaxRetransmissions = 5
+	}
+
+	engineID := config.EngineID
+	if engineID == 0 {
+		if node := nodeKey(localNodeEID); strings.HasPrefix(node, "ipn:") {
+			engineID, _ = strconv.ParseUint(strings.TrimPrefix(node, "ipn:"), 10, 64)
+		}
+	}
+	if engineID == 0 {
+		engineID = uint64(rand.Int63())
+	}
+
+	return &LTPTransport{
+		config:      config,
+		localNodeID: localNodeEID,
+		engineID:    engineID,
+		spans:       make(map[string]*ltpSpan),
+		owlts:       make(map[string]time.Duration),
+		exports:     make(map[uint64]*ltpExport),
+		imports:     make(map[ltpSessionID]*ltpImport),
+		nextSession: uint64(rand.Int31()),
+		receiveChan: make(chan *bundle.Bundle, 1000),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// EngineID returns the local LTP engine ID.
+func (t *LTPTransport) EngineID() uint64 {
+	return t.engineID
+}
+
+// Start opens the socket and begins processing LTP segments.
+func (t *LTPTransport) Start(ctx context.Context) error {
+	t.conn = t.config.Conn
+	if t.conn == nil {
+		conn, err := net.ListenPacket("udp", t.config.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("failed to start LTP listener: %w", err)
+		}
+		t.conn = conn
+	}
+
+	log.Printf("[LTP] Engine %d listening on %s as %s", t.engineID, t.conn.LocalAddr(), t.localNodeID)
+
+	t.wg.Add(1)
+	go t.readLoop()
+
+	return nil
+}
+
+// Addr returns the local socket address, or nil before Start.
+func (t *LTPTransport) Addr() net.Addr {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.LocalAddr()
+}
+
+// Stop abandons open sessions and shuts down the transport.
+func (t *LTPTransport) Stop() error {
+	log.Printf("[LTP] Shutting down")
+	t.cancel()
+
+	t.mu.Lock()
+	t.stopped = true
+	for _, exp := range t.exports {
+		t.finishExportLocked(exp, errors.New("ltp: transport stopped"))
+	}
+	for _, imp := range t.imports {
+		imp.stopTimers()
+	}
+	t.imports = make(map[ltpSessionID]*ltpImport)
+	t.mu.Unlock()
+
+	if t.conn != nil {
+		t.conn.Close()
+	}
+
+	t.wg.Wait()
+	close(t.receiveChan)
+
+	return nil
+}
+
+// Connect registers the span to a neighbor's LTP engine. LTP has no
+// connection setup, so no segments are exchanged.
+func (t *LTPTransport) Connect(ctx context.Context, neighborID string, address string) error {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s at %s: %w", neighborID, address, err)
+	}
+
+	t.mu.Lock()
+	owlt, ok := t.owlts[neighborID]
+	if !ok {
+		owlt = t.config.OWLT
+	}
+	t.spans[neighborID] = &ltpSpan{neighborID: neighborID, addr: addr, owlt: owlt}
+	t.mu.Unlock()
+
+	log.Printf("[LTP] Span to %s at %s (OWLT %v)", neighborID, addr, owlt)
+	return nil
+}
+
+// SetOWLT sets the one-way light time to a neighbor, which scales the
+// retransmission timers of sessions on that span.
+func (t *LTPTransport) SetOWLT(neighborID string, owlt time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.owlts[neighborID] = owlt
+	if span, ok := t.spans[neighborID]; ok {
+		span.owlt = owlt
+	}
+}
+
+// Disconnect removes the span to a neighbor.
+func (t *LTPTransport) Disconnect(neighborID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.spans[neighborID]; !ok {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+	delete(t.spans, neighborID)
+	return nil
+}
+
+// IsConnected returns true if a span to the neighbor is registered.
+func (t *LTPTransport) IsConnected(neighborID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.spans[neighborID]
+	return ok
+}
+
+// Receive returns the channel for incoming bundles.
+func (t *LTPTransport) Receive() <-chan *bundle.Bundle {
+	return t.receiveChan
+}
+
+// Send transmits a bundle as one LTP block and waits until the red part is
+// acknowledged. With GreenBulk, the payload of bulk bundles is green and
+// only the bundle headers are sent reliably.
+func (t *LTPTransport) Send(ctx context.Context, neighborID string, b *bundle.Bundle) error {
+	t.mu.Lock()
+	span, ok := t.spans[neighborID]
+	t.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+
+	data, err := t.config.Codec.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to serialize bundle: %w", err)
+	}
+
+	redLen := len(data)
+	if t.config.GreenBulk && b.Priority == bundle.PriorityBulk {
+		if offset, _, err := bundle.PayloadOffset(data); err == nil {
+			redLen = offset
+		}
+	}
+
+	if err := t.transmit(ctx, span, data, uint64(redLen)); err != nil {
+		var partial *PartialTransferError
+		if errors.As(err, &partial) {
+			partial.NeighborID = neighborID
+			partial.PayloadOffset = -1
+			if offset, length, perr := bundle.PayloadOffset(data); perr == nil {
+				partial.PayloadOffset = offset
+				partial.PayloadLength = length
+			}
+		}
+		return err
+	}
+
+	log.Printf("[LTP] Sent bundle %s to %s (%d bytes, %d red)", b.ID.String()[:8], neighborID, len(data), redLen)
+	return nil
+}
+
+// timeout is the retransmission interval for a span: the round trip plus
+// the processing margin (RFC 5326 Section 6.2).
+func (t *LTPTransport) timeout(owlt time.Duration) time.Duration {
+	return 2*owlt + t.config.TimerMargin
+}
+
+// idleTimeout bounds how long an incomplete import waits for segments.
+func (t *LTPTransport) idleTimeout(owlt time.Duration) time.Duration {
+	return time.Duration(t.config.MaxRetransmissions+2) * t.timeout(owlt)
+}
+
+// transmit sends a block and waits for the red part to be acknowledged.
+func (t *LTPTransport) transmit(ctx context.Context, span *ltpSpan, data []byte, redLen uint64) error {
+	t.mu.Lock()
+	if t.stopped || t.conn == nil {
+		t.mu.Unlock()
+		return errors.New("ltp: transport not running")
+	}
+	t.nextSession++
+	exp := &ltpExport{
+		id:             t.nextSession,
+		span:           span,
+		data:           data,
+		redLen:         redLen,
+		nextCheckpoint: uint64(rand.Int31n(1 << 16)),
+		checkpoints:    make(map[uint64]*ltpTimer),
+		reports:        make(map[uint64]bool),
+		done:           make(chan error, 1),
+	}
+	if redLen > 0 {
+		t.exports[exp.id] = exp
+	}
+	t.sendBlockLocked(exp)
+	t.mu.Unlock()
+
+	if redLen == 0 {
+		return nil
+	}
+
+	select {
+	case err := <-exp.done:
+		return err
+	case <-ctx.Done():
+		t.mu.Lock()
+		if !exp.finished {
+			t.cancelExportLocked(exp, LTPCancelUserCancelled)
+		}
+		t.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// sendBlockLocked transmits the red part, ending in a checkpoint, followed
+// by the green part.
+func (t *LTPTransport) sendBlockLocked(exp *ltpExport) {
+	total := uint64(len(exp.data))
+	size := uint64(t.config.MaxSegmentSize)
+
+	for offset := uint64(0); offset < exp.redLen; offset += size {
+		end := min(offset+size, exp.redLen)
+		seg := t.dataSegment(exp, ltpRedData, offset, end)
+		if end < exp.redLen {
+			t.writeLocked(exp.span.addr, seg)
+			continue
+		}
+		seg.typ = ltpRedCheckpointEORP
+		if end == total {
+			seg.typ = ltpRedCheckpointEOB
+		}
+		t.sendCheckpointLocked(exp, seg)
+	}
+
+	for offset := exp.redLen; offset < total; offset += size {
+		end := min(offset+size, total)
+		typ := byte(ltpGreenData)
+		if end == total {
+			typ = ltpGreenEOB
+		}
+		t.writeLocked(exp.span.addr, t.dataSegment(exp, typ, offset, end))
+	}
+}
+
+func (t *LTPTransport) dataSegment(exp *ltpExport, typ byte, offset, end uint64) *ltpSegment {
+	return &ltpSegment{
+		typ:        typ,
+		originator: t.engineID,
+		session:    exp.id,
+		clientID:   ltpClientBundle,
+		offset:     offset,
+		data:       exp.data[offset:end],
+	}
+}
+
+// sendCheckpointLocked assigns a checkpoint serial number to seg, sends it
+// and starts its retransmission timer.
+func (t *LTPTransport) sendCheckpointLocked(exp *ltpExport, seg *ltpSegment) {
+	exp.nextCheckpoint++
+	serial := exp.nextCheckpoint
+	seg.checkpoint = serial
+
+	cp := &ltpTimer{segment: seg}
+	exp.checkpoints[serial] = cp
+	t.writeLocked(exp.span.addr, seg)
+	cp.timer = time.AfterFunc(t.timeout(exp.span.owlt), func() {
+		t.checkpointExpired(exp, serial)
+	})
+}
+
+// checkpointExpired retransmits an unanswered checkpoint, cancelling the
+// session once the retransmission limit is reached.
+func (t *LTPTransport) checkpointExpired(exp *ltpExport, serial uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cp, ok := exp.checkpoints[serial]
+	if !ok || exp.finished || t.stopped {
+		return
+	}
+	cp.retries++
+	if cp.retries > t.config.MaxRetransmissions {
+		log.Printf("[LTP] Session %d to %s: checkpoint %d unanswered after %d retransmissions",
+			exp.id, exp.span.neighborID, serial, t.config.MaxRetransmissions)
+		t.cancelExportLocked(exp, LTPCancelRetransmitLimit)
+		return
+	}
+	t.writeLocked(exp.span.addr, cp.segment)
+	cp.timer.Reset(t.timeout(exp.span.owlt))
+}
+
+// cancelExportLocked sends a cancel segment and fails the session.
+func (t *LTPTransport) cancelExportLocked(exp *ltpExport, reason LTPCancelReason) {
+	t.writeLocked(exp.span.addr, &ltpSegment{
+		typ:        ltpCancelFromSender,
+		originator: t.engineID,
+		session:    exp.id,
+		reason:     reason,
+	})
+	t.finishExportLocked(exp, t.cancelError(exp, reason))
+}
+
+// cancelError wraps ErrLTPCancelled in a PartialTransferError when the
+// peer acknowledged a prefix of the block.
+func (t *LTPTransport) cancelError(exp *ltpExport, reason LTPCancelReason) error {
+	err := fmt.Errorf("%w: %s", ErrLTPCancelled, reason)
+	acked := exp.acked.prefix()
+	total := uint64(len(exp.data))
+	if acked == 0 || acked >= total {
+		return err
+	}
+	return &PartialTransferError{Acked: acked, Total: total, Err: err}
+}
+
+func (t *LTPTransport) finishExportLocked(exp *ltpExport, err error) {
+	if exp.finished {
+		return
+	}
+	exp.finished = true
+	for _, cp := range exp.checkpoints {
+		cp.timer.Stop()
+	}
+	delete(t.exports, exp.id)
+	exp.done <- err
+}
+
+// handleReport processes a report segment for one of our sessions:
+// acknowledge it, record the claims and retransmit any gaps.
+func (t *LTPTransport) handleReport(rs *ltpSegment, from net.Addr) {
+	t.writeLocked(from, &ltpSegment{
+		typ:          ltpReportAck,
+		originator:   rs.originator,
+		session:      rs.session,
+		reportSerial: rs.reportSerial,
+	})
+
+	exp, ok := t.exports[rs.session]
+	if rs.originator != t.engineID || !ok || exp.reports[rs.reportSerial] {
+		return
+	}
+	if rs.upper > uint64(len(exp.data)) {
+		log.Printf("[LTP] Report for session %d claims beyond the block", exp.id)
+		return
+	}
+	exp.reports[rs.reportSerial] = true
+
+	if cp, ok := exp.checkpoints[rs.checkpoint]; ok {
+		cp.timer.Stop()
+		delete(exp.checkpoints, rs.checkpoint)
+	}
+	for _, c := range rs.claims {
+		exp.acked.add(rs.lower+c.offset, rs.lower+c.offset+c.length)
+	}
+	if exp.acked.covers(0, exp.redLen) {
+		t.finishExportLocked(exp, nil)
+		return
+	}
+
+	gaps := exp.acked.gaps(rs.lower, min(rs.upper, exp.redLen))
+	size := uint64(t.config.MaxSegmentSize)
+	for i, gap := range gaps {
+		for offset := gap.offset; offset < gap.offset+gap.length; offset += size {
+			end := min(offset+size, gap.offset+gap.length)
+			seg := t.dataSegment(exp, ltpRedData, offset, end)
+			if i < len(gaps)-1 || end < gap.offset+gap.length {
+				t.writeLocked(exp.span.addr, seg)
+				continue
+			}
+			seg.typ = ltpRedCheckpoint
+			if end == exp.redLen {
+				seg.typ = ltpRedCheckpointEORP
+				if end == uint64(len(exp.data)) {
+					seg.typ = ltpRedCheckpointEOB
+				}
+			}
+			seg.reportSerial = rs.reportSerial
+			t.sendCheckpointLocked(exp, seg)
+		}
+	}
+}
+
+// handleData stores a data segment and answers checkpoints with reports.
+func (t *LTPTransport) handleData(seg *ltpSegment, from net.Addr) {
+	id := ltpSessionID{originator: seg.originator, number: seg.session}
+	imp, ok := t.imports[id]
+	if !ok {
+		owlt := t.owltForAddr(from)
+		imp = &ltpImport{
+			id:         id,
+			addr:       from,
+			owlt:       owlt,
+			clientID:   seg.clientID,
+			nextReport: uint64(rand.Int31n(1 << 16)),
+			reports:    make(map[uint64]*ltpTimer),
+		}
+		imp.idle = time.AfterFunc(t.idleTimeout(owlt), func() {
+			t.importIdle(imp)
+		})
+		t.imports[id] = imp
+	}
+	imp.idle.Reset(t.idleTimeout(imp.owlt))
+
+	// Check the limit before adding, so a crafted offset cannot wrap around
+	if seg.offset > t.config.MaxBlockSize || uint64(len(seg.data)) > t.config.MaxBlockSize-seg.offset {
+		log.Printf("[LTP] Session %d from engine %d exceeds block limit %d", id.number, id.originator, t.config.MaxBlockSize)
+		t.cancelImportLocked(imp, LTPCancelSystem)
+		return
+	}
+	end := seg.offset + uint64(len(seg.data))
+	if imp.closed {
+		// The sender missed our final report; claim the whole red part again
+		if seg.isCheckpoint() {
+			t.sendReportsLocked(imp, seg.checkpoint, end)
+		}
+		return
+	}
+	if uint64(len(imp.buf)) < end {
+		imp.buf = append(imp.buf, make([]byte, end-uint64(len(imp.buf)))...)
+	}
+	copy(imp.buf[seg.offset:end], seg.data)
+
+	if seg.isRed() {
+		imp.red.add(seg.offset, end)
+		if seg.typ == ltpRedCheckpointEORP || seg.typ == ltpRedCheckpointEOB {
+			imp.redLen = end
+			imp.redKnown = true
+		}
+		if seg.typ == ltpRedCheckpointEOB {
+			imp.blockLen = end
+			imp.eob = true
+		}
+		if seg.isCheckpoint() {
+			t.sendReportsLocked(imp, seg.checkpoint, end)
+		}
+	} else {
+		imp.green.add(seg.offset, end)
+		if seg.typ == ltpGreenEOB {
+			imp.blockLen = end
+			imp.eob = true
+			if !imp.redKnown && len(imp.red) == 0 && imp.green.covers(0, 1) {
+				imp.redKnown = true // all-green block
+			}
+		}
+	}
+
+	if imp.delivered || !imp.eob || !imp.redKnown || !imp.red.covers(0, imp.redLen) {
+		return
+	}
+	if !imp.green.covers(imp.redLen, imp.blockLen) {
+		// Give reordered green segments one round trip before giving up on them
+		imp.idle.Reset(t.timeout(imp.owlt))
+		return
+	}
+	t.deliverLocked(imp, true)
+	if len(imp.reports) == 0 {
+		t.closeImportLocked(imp)
+	}
+}
+
+// sendReportsLocked answers a checkpoint with report segments claiming the
+// red data received below upper. Long claim lists are split across
+// several reports with adjacent bounds.
+func (t *LTPTransport) sendReportsLocked(imp *ltpImport, checkpoint, upper uint64) {
+	claims := imp.red.within(0, upper)
+	lower := uint64(0)
+	for len(claims) > 0 {
+		n := min(len(claims), ltpMaxClaims)
+		chunk, rest := claims[:n], claims[n:]
+		bound := upper
+		if len(rest) > 0 {
+			bound = rest[0].offset
+		}
+
+		relative := make([]ltpClaim, len(chunk))
+		for i, c := range chunk {
+			relative[i] = ltpClaim{offset: c.offset - lower, length: c.length}
+		}
+
+		imp.nextReport++
+		serial := imp.nextReport
+		rs := &ltpSegment{
+			typ:          ltpReportSegment,
+			originator:   imp.id.originator,
+			session:      imp.id.number,
+			reportSerial: serial,
+			checkpoint:   checkpoint,
+			upper:        bound,
+			lower:        lower,
+			claims:       relative,
+		}
+		report := &ltpTimer{segment: rs}
+		imp.reports[serial] = report
+		t.writeLocked(imp.addr, rs)
+		report.timer = time.AfterFunc(t.timeout(imp.owlt), func() {
+			t.reportExpired(imp, serial)
+		})
+
+		claims, lower = rest, bound
+	}
+}
+
+// reportExpired retransmits an unacknowledged report segment.
+func (t *LTPTransport) reportExpired(imp *ltpImport, serial uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report, ok := imp.reports[serial]
+	if !ok || imp.closed || t.stopped {
+		return
+	}
+	report.retries++
+	if report.retries <= t.config.MaxRetransmissions {
+		t.writeLocked(imp.addr, report.segment)
+		report.timer.Reset(t.timeout(imp.owlt))
+		return
+	}
+
+	delete(imp.reports, serial)
+	if imp.delivered {
+		if len(imp.reports) == 0 {
+			t.closeImportLocked(imp)
+		}
+		return
+	}
+	log.Printf("[LTP] Session %d from engine %d: report %d unacknowledged after %d retransmissions",
+		imp.id.number, imp.id.originator, serial, t.config.MaxRetransmissions)
+	t.cancelImportLocked(imp, LTPCancelRetransmitLimit)
+}
+
+// handleReportAck stops the timer of an acknowledged report.
+func (t *LTPTransport) handleReportAck(seg *ltpSegment) {
+	imp, ok := t.imports[ltpSessionID{originator: seg.originator, number: seg.session}]
+	if !ok {
+		return
+	}
+	if report, ok := imp.reports[seg.reportSerial]; ok {
+		report.timer.Stop()
+		delete(imp.reports, seg.reportSerial)
+	}
+	if imp.delivered && !imp.closed && len(imp.reports) == 0 {
+		t.closeImportLocked(imp)
+	}
+}
+
+// handleCancel processes cancel segments from either side of a session.
+func (t *LTPTransport) handleCancel(seg *ltpSegment, from net.Addr) {
+	switch seg.typ {
+	case ltpCancelFromSender:
+		t.writeLocked(from, &ltpSegment{typ: ltpCancelAckToSender, originator: seg.originator, session: seg.session})
+		imp, ok := t.imports[ltpSessionID{originator: seg.originator, number: seg.session}]
+		if !ok || imp.closed {
+			return
+		}
+		log.Printf("[LTP] Session %d cancelled by engine %d: %s", seg.session, seg.originator, seg.reason)
+		if !imp.delivered {
+			t.deliverLocked(imp, false)
+		}
+		imp.stopTimers()
+		delete(t.imports, imp.id)
+
+	case ltpCancelFromReceiver:
+		t.writeLocked(from, &ltpSegment{typ: ltpCancelAckToReceiver, originator: seg.originator, session: seg.session})
+		exp, ok := t.exports[seg.session]
+		if seg.originator != t.engineID || !ok {
+			return
+		}
+		log.Printf("[LTP] Session %d cancelled by %s: %s", exp.id, exp.span.neighborID, seg.reason)
+		t.finishExportLocked(exp, t.cancelError(exp, seg.reason))
+	}
+}
+
+// cancelImportLocked sends a cancel segment to the block sender and drops
+// the session, delivering any contiguous prefix as a fragment.
+func (t *LTPTransport) cancelImportLocked(imp *ltpImport, reason LTPCancelReason) {
+	t.writeLocked(imp.addr, &ltpSegment{
+		typ:        ltpCancelFromReceiver,
+		originator: imp.id.originator,
+		session:    imp.id.number,
+		reason:     reason,
+	})
+	if !imp.delivered {
+		t.deliverLocked(imp, false)
+	}
+	imp.stopTimers()
+	delete(t.imports, imp.id)
+}
+
+// closeImportLocked stops an import's report timers and keeps it around
+// for one idle period so late duplicates do not open a new session.
+func (t *LTPTransport) closeImportLocked(imp *ltpImport) {
+	imp.closed = true
+	for _, report := range imp.reports {
+		report.timer.Stop()
+	}
+	imp.idle.Reset(t.idleTimeout(imp.owlt))
+}
+
+// importIdle discards a closed import, delivers a block whose green part
+// is incomplete, or cancels a session whose sender has gone silent.
+func (t *LTPTransport) importIdle(imp *ltpImport) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.imports[imp.id] != imp || t.stopped {
+		return
+	}
+	if imp.closed {
+		delete(t.imports, imp.id)
+		return
+	}
+	if !imp.delivered && imp.eob && imp.redKnown && imp.red.covers(0, imp.redLen) {
+		// Green segments were lost; deliver the block as received
+		t.deliverLocked(imp, false)
+		if len(imp.reports) == 0 {
+			t.closeImportLocked(imp)
+		}
+		return
+	}
+	if imp.delivered {
+		imp.stopTimers()
+		delete(t.imports, imp.id)
+		return
+	}
+	log.Printf("[LTP] Session %d from engine %d timed out", imp.id.number, imp.id.originator)
+	t.cancelImportLocked(imp, LTPCancelSystem)
+}
+
+// deliverLocked decodes the received block. Incomplete blocks are
+// delivered as fragments covering their contiguous prefix (RFC 9171
+// Section 5.8 reactive fragmentation).
+func (t *LTPTransport) deliverLocked(imp *ltpImport, complete bool) {
+	imp.delivered = true
+	if imp.clientID != ltpClientBundle || t.stopped {
+		return
+	}
+
+	var b *bundle.Bundle
+	var err error
+	if complete {
+		b, err = bundle.Unmarshal(imp.buf[:imp.blockLen])
+	} else {
+		prefix := imp.red.prefix()
+		if imp.redKnown && prefix >= imp.redLen {
+			prefix = max(prefix, imp.green.prefixFrom(imp.redLen))
+		}
+		if prefix == 0 {
+			return
+		}
+		b, err = bundle.UnmarshalPartial(imp.buf[:prefix])
+		if err == nil {
+			log.Printf("[LTP] Recovered %d payload bytes of incomplete block %d from engine %d",
+				len(b.Payload), imp.id.number, imp.id.originator)
+		}
+	}
+	if err != nil {
+		log.Printf("[LTP] Failed to decode block %d from engine %d: %v", imp.id.number, imp.id.originator, err)
+		return
+	}
+
+	log.Printf("[LTP] Received bundle %s from engine %d (%d bytes)", b.ID.String()[:8], imp.id.originator, len(imp.buf))
+
+	select {
+	case t.receiveChan <- b:
+	default:
+		log.Printf("[LTP] Receive buffer full, dropping bundle %s", b.ID.String()[:8])
+	}
+}
+
+func (imp *ltpImport) stopTimers() {
+	imp.idle.Stop()
+	for _, report := range imp.reports {
+		report.timer.Stop()
+	}
+}
+
+// owltForAddr returns the OWLT of the span registered for addr.
+func (t *LTPTransport) owltForAddr(addr net.Addr) time.Duration {
+	for _, span := range t.spans {
+		if span.addr.String() == addr.String() {
+			return span.owlt
+		}
+	}
+	return t.config.OWLT
+}
+
+func (t *LTPTransport) writeLocked(addr net.Addr, seg *ltpSegment) {
+	if _, err := t.conn.WriteTo(seg.marshal(), addr); err != nil && t.ctx.Err() == nil {
+		log.Printf("[LTP] Failed to send segment to %s: %v", addr, err)
+	}
+}
+
+// readLoop decodes and dispatches incoming segments.
+func (t *LTPTransport) readLoop() {
+	defer t.wg.Done()
+
+	buf := make([]byte, ltpMaxDatagram)
+	for {
+		n, from, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			if t.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("[LTP] Read error: %v", err)
+			continue
+		}
+
+		seg, err := parseLTPSegment(append([]byte(nil), buf[:n]...))
+		if err != nil {
+			log.Printf("[LTP] Dropping malformed segment from %s: %v", from, err)
+			continue
+		}
+
+		t.mu.Lock()
+		if !t.stopped {
+			switch {
+			case seg.typ <= ltpGreenEOB:
+				t.handleData(seg, from)
+			case seg.typ == ltpReportSegment:
+				t.handleReport(seg, from)
+			case seg.typ == ltpReportAck:
+				t.handleReportAck(seg)
+			case seg.typ == ltpCancelFromSender || seg.typ == ltpCancelFromReceiver:
+				t.handleCancel(seg, from)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// ltpClaim is a reception claim, or any byte range of a block.
+type ltpClaim struct {
+	offset uint64
+	length uint64
+}
+
+// ltpSegment is a decoded LTP segment. Fields not used by the segment
+// type are zero.
+type ltpSegment struct {
+	typ        byte
+	originator uint64
+	session    uint64
+
+	// Data segments
+	clientID uint64
+	offset   uint64
+	data     []byte
+
+	// Checkpoints and report segments
+	checkpoint   uint64
+	reportSerial uint64
+	upper        uint64
+	lower        uint64
+	claims       []ltpClaim
+
+	// Cancel segments
+	reason LTPCancelReason
+}
+
+func (s *ltpSegment) isRed() bool {
+	return s.typ <= ltpRedCheckpointEOB
+}
+
+func (s *ltpSegment) isCheckpoint() bool {
+	return s.typ >= ltpRedCheckpoint && s.typ <= ltpRedCheckpointEOB
+}
+
+// marshal encodes the segment (RFC 5326 Section 3) without extensions.
+func (s *ltpSegment) marshal() []byte {
+	buf := make([]byte, 0, 32+len(s.data))
+	buf = append(buf, s.typ&0x0f) // version 0
+	buf = appendSDNV(buf, s.originator)
+	buf = appendSDNV(buf, s.session)
+	buf = append(buf, 0) // no header or trailer extensions
+
+	switch {
+	case s.typ <= ltpGreenEOB:
+		buf = appendSDNV(buf, s.clientID)
+		buf = appendSDNV(buf, s.offset)
+		buf = appendSDNV(buf, uint64(len(s.data)))
+		if s.isCheckpoint() {
+			buf = appendSDNV(buf, s.checkpoint)
+			buf = appendSDNV(buf, s.reportSerial)
+		}
+		buf = append(buf, s.data...)
+	case s.typ == ltpReportSegment:
+		buf = appendSDNV(buf, s.reportSerial)
+		buf = appendSDNV(buf, s.checkpoint)
+		buf = appendSDNV(buf, s.upper)
+		buf = appendSDNV(buf, s.lower)
+		buf = appendSDNV(buf, uint64(len(s.claims)))
+		for _, c := range s.claims {
+			buf = appendSDNV(buf, c.offset)
+			buf = appendSDNV(buf, c.length)
+		}
+	case s.typ == ltpReportAck:
+		buf = appendSDNV(buf, s.reportSerial)
+	case s.typ == ltpCancelFromSender || s.typ == ltpCancelFromReceiver:
+		buf = append(buf, byte(s.reason))
+	}
+	return buf
+}
+
+// parseLTPSegment decodes a segment, skipping any extensions.
+func parseLTPSegment(data []byte) (*ltpSegment, error) {
+	r := &sdnvReader{data: data}
+	control := r.readByte()
+	if control>>4 != 0 {
+		return nil, fmt.Errorf("unsupported LTP version %d", control>>4)
+	}
+	s := &ltpSegment{typ: control & 0x0f}
+	s.originator = r.readSDNV()
+	s.session = r.readSDNV()
+	extensions := r.readByte()
+	for i := 0; i < int(extensions>>4); i++ {
+		r.readByte() // tag
+		r.readBytes(r.readSDNV())
+	}
+
+	switch {
+	case s.typ == 0x5 || s.typ == 0x6 || s.typ == 0xa || s.typ == 0xb:
+		return nil, fmt.Errorf("undefined segment type %d", s.typ)
+	case s.typ <= ltpGreenEOB:
+		s.clientID = r.readSDNV()
+		s.offset = r.readSDNV()
+		length := r.readSDNV()
+		if s.isCheckpoint() {
+			s.checkpoint = r.readSDNV()
+			s.reportSerial = r.readSDNV()
+		}
+		s.data = r.readBytes(length)
+	case s.typ == ltpReportSegment:
+		s.reportSerial = r.readSDNV()
+		s.checkpoint = r.readSDNV()
+		s.upper = r.readSDNV()
+		s.lower = r.readSDNV()
+		count := r.readSDNV()
+		if count > uint64(len(data)) {
+			return nil, fmt.Errorf("claim count %d exceeds segment size", count)
+		}
+		if s.lower > s.upper {
+			return nil, fmt.Errorf("report lower bound above upper bound")
+		}
+		// Claims are relative to the lower bound; compare by subtraction so
+		// the sums in handleReport cannot wrap around
+		span := s.upper - s.lower
+		s.claims = make([]ltpClaim, 0, count)
+		for i := uint64(0); i < count && r.err == nil; i++ {
+			c := ltpClaim{offset: r.readSDNV(), length: r.readSDNV()}
+			if c.offset > span || c.length > span-c.offset {
+				return nil, fmt.Errorf("reception claim beyond upper bound")
+			}
+			s.claims = append(s.claims, c)
+		}
+	case s.typ == ltpReportAck:
+		s.reportSerial = r.readSDNV()
+	case s.typ == ltpCancelFromSender || s.typ == ltpCancelFromReceiver:
+		s.reason = LTPCancelReason(r.readByte())
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s, nil
+}
+
+// appendSDNV appends v as a Self-Delimiting Numeric Value (RFC 6256).
+func appendSDNV(buf []byte, v uint64) []byte {
+	var tmp [10]byte
+	i := len(tmp) - 1
+	tmp[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		tmp[i] = byte(v&0x7f) | 0x80
+	}
+	return append(buf, tmp[i:]...)
+}
+
+// sdnvReader decodes SDNVs and bytes, recording the first error.
+type sdnvReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *sdnvReader) readByte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.err = errors.New("truncated LTP segment")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *sdnvReader) readSDNV() uint64 {
+	var v uint64
+	for i := 0; i < 10; i++ {
+		b := r.readByte()
+		if r.err != nil {
+			return 0
+		}
+		v = v<<7 | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+	r.err = errors.New("SDNV overflows 64 bits")
+	return 0
+}
+
+func (r *sdnvReader) readBytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		r.err = errors.New("truncated LTP segment")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+// ltpRanges is a sorted set of disjoint byte ranges.
+type ltpRanges []ltpClaim
+
+// add inserts [start, end), merging overlapping and adjacent ranges.
+func (rs *ltpRanges) add(start, end uint64) {
+	if end <= start {
+		return
+	}
+	merged := make(ltpRanges, 0, len(*rs)+1)
+	for _, r := range *rs {
+		rEnd := r.offset + r.length
+		if rEnd < start || r.offset > end {
+			merged = append(merged, r)
+			continue
+		}
+		start = min(start, r.offset)
+		end = max(end, rEnd)
+	}
+	merged = append(merged, ltpClaim{offset: start, length: end - start})
+	sort.Slice(merged, func(i, j int) bool { return merged[i].offset < merged[j].offset })
+	*rs = merged
+}
+
+// covers reports whether [start, end) is fully contained in the set.
+func (rs ltpRanges) covers(start, end uint64) bool {
+	if end <= start {
+		return true
+	}
+	for _, r := range rs {
+		if r.offset <= start && r.offset+r.length >= end {
+			return true
+		}
+	}
+	return false
+}
+
+// prefix returns the length of the range starting at offset 0.
+func (rs ltpRanges) prefix() uint64 {
+	return rs.prefixFrom(0)
+}
+
+// prefixFrom returns the end of the range containing start, or start if
+// no range does.
+func (rs ltpRanges) prefixFrom(start uint64) uint64 {
+	for _, r := range rs {
+		if r.offset <= start && r.offset+r.length >= start {
+			return r.offset + r.length
+		}
+	}
+	return start
+}
+
+// within returns the parts of the set inside [lower, upper).
+func (rs ltpRanges) within(lower, upper uint64) []ltpClaim {
+	var out []ltpClaim
+	for _, r := range rs {
+		start := max(r.offset, lower)
+		end := min(r.offset+r.length, upper)
+		if end > start {
+			out = append(out, ltpClaim{offset: start, length: end - start})
+		}
+	}
+	return out
+}
+
+// gaps returns the parts of [lower, upper) missing from the set.
+func (rs ltpRanges) gaps(lower, upper uint64) []ltpClaim {
+	var out []ltpClaim
+	pos := lower
+	for _, r := range rs.within(lower, upper) {
+		if r.offset > pos {
+			out = append(out, ltpClaim{offset: pos, length: r.offset - pos})
+		}
+		pos = r.offset + r.length
+	}
+	if pos < upper {
+		out = append(out, ltpClaim{offset: pos, length: upper - pos})
+	}
+	return out
+}
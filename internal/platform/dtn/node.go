@@ -2,12 +2,14 @@ package dtn
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/asgard/pandora/pkg/bundle"
+	"github.com/google/uuid"
 )
 
 // Node represents a DTN network node (satellite, ground station, Hunoid, etc.).
@@ -26,6 +28,20 @@ type Node struct {
 	wg          sync.WaitGroup
 	metrics     *NodeMetrics
 	metricsMu   sync.RWMutex
+	config      NodeConfig
+	reassembler *Reassembler
+	linkUsage   map[string]*linkUsage
+	usageMu     sync.Mutex
+	retries     map[uuid.UUID]int // Retry passes per failed bundle
+	retryMu     sync.Mutex
+
+	custody       map[string]*custodyRecord // Keyed by bundle identity
+	statusHandler func(report *bundle.StatusReport)
+	security      *SecurityPolicy
+	adminMu       sync.Mutex
+
+	clock           Clock
+	deliveryHandler func(b *bundle.Bundle)
 }
 
 // Neighbor represents a connected DTN node with link quality information.
@@ -40,18 +56,25 @@ type Neighbor struct {
 	Bandwidth    int64 // bytes per second
 	ContactStart time.Time
 	ContactEnd   time.Time
+	MTU          int // Largest bundle the link carries in bytes (0 = unlimited)
 }
 
 // NodeMetrics tracks node performance statistics.
 type NodeMetrics struct {
-	BundlesReceived   int64
-	BundlesSent       int64
-	BundlesDropped    int64
-	BundlesExpired    int64
-	BytesReceived     int64
-	BytesSent         int64
-	AverageLatency    time.Duration
-	ActiveConnections int
+	BundlesReceived        int64
+	BundlesSent            int64
+	BundlesDropped         int64
+	BundlesExpired         int64
+	BytesReceived          int64
+	BytesSent              int64
+	AverageLatency         time.Duration
+	ActiveConnections      int
+	FragmentsCreated       int64
+	BundlesReassembled     int64
+	StatusReportsSent      int64
+	CustodyTransfers       int64
+	CustodyRetransmissions int64
+	SecurityFailures       int64
 }
 
 // Router interface for selecting the next hop for bundle forwarding.
@@ -60,12 +83,22 @@ type Router interface {
 	UpdateContactGraph(nodeID string, neighbor *Neighbor)
 }
 
+// BookingRouter is implemented by routers that reserve contact capacity when
+// they select a next hop. The node releases the reservation of a bundle that
+// is not sent after all.
+type BookingRouter interface {
+	Router
+	ReleaseBooking(bundleID uuid.UUID)
+}
+
 // NodeConfig contains configuration options for a DTN node.
 type NodeConfig struct {
 	BufferSize     int           // Channel buffer size
 	ProcessTimeout time.Duration // Max time to process a bundle
 	MaxRetries     int           // Retry attempts for failed transmissions
 	PurgeInterval  time.Duration // How often to purge expired bundles
+	RetryInterval  time.Duration // How often to requeue deferred and failed bundles
+	CustodyTimeout time.Duration // Retransmit custody bundles not acknowledged in time (0 disables custody)
 }
 
 // DefaultNodeConfig returns sensible defaults.
@@ -75,6 +108,8 @@ func DefaultNodeConfig() NodeConfig {
 		ProcessTimeout: 30 * time.Second,
 		MaxRetries:     3,
 		PurgeInterval:  5 * time.Minute,
+		RetryInterval:  time.Minute,
+		CustodyTimeout: 5 * time.Minute,
 	}
 }
 
@@ -94,6 +129,12 @@ func NewNode(id, eid string, storage BundleStorage, router Router, config NodeCo
 		ctx:         ctx,
 		cancel:      cancel,
 		metrics:     &NodeMetrics{},
+		config:      config,
+		reassembler: NewReassembler(storage),
+		linkUsage:   make(map[string]*linkUsage),
+		retries:     make(map[uuid.UUID]int),
+		custody:     make(map[string]*custodyRecord),
+		clock:       SystemClock,
 	}
 }
 
@@ -109,12 +150,42 @@ func (n *Node) SetTransport(transport TransportAdapter) {
 	n.transport = transport
 }
 
+// SetClock replaces the node's time source. The router and storage follow
+// when they accept a clock. Call before Start.
+func (n *Node) SetClock(clock Clock) {
+	n.clock = clock
+	if c, ok := n.router.(clockSetter); ok {
+		c.SetClock(clock)
+	}
+	if c, ok := n.storage.(clockSetter); ok {
+		c.SetClock(clock)
+	}
+}
+
+// SetDeliveryHandler registers a callback for bundles delivered to this
+// node, after reassembly and security checks.
+func (n *Node) SetDeliveryHandler(handler func(b *bundle.Bundle)) {
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+	n.deliveryHandler = handler
+}
+
+// now returns the current time according to the node's clock.
+func (n *Node) now() time.Time {
+	return n.clock.Now().UTC()
+}
+
 // Start begins node operations.
 func (n *Node) Start() error {
 	log.Printf("[DTN Node %s] Starting at EID: %s", n.ID, n.EID)
 
+	n.restoreCustody()
+
 	// Start transport if configured
 	if n.transport != nil {
+		if peerAware, ok := n.transport.(PeerAwareTransport); ok {
+			peerAware.SetPeerHandler(n.handlePeerEvent)
+		}
 		if err := n.transport.Start(n.ctx); err != nil {
 			return fmt.Errorf("failed to start transport: %w", err)
 		}
@@ -159,13 +230,15 @@ func (n *Node) Stop() error {
 
 // Receive accepts an incoming bundle for processing.
 func (n *Node) Receive(ctx context.Context, b *bundle.Bundle) error {
+	// Size before handoff: ingress may decrypt the payload in place
+	size := int64(b.Size())
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	case n.ingressChan <- b:
 		n.recordMetric(func(m *NodeMetrics) {
 			m.BundlesReceived++
-			m.BytesReceived += int64(b.Size())
+			m.BytesReceived += size
 		})
 		return nil
 	default:
@@ -191,7 +264,7 @@ func (n *Node) RegisterNeighbor(neighbor *Neighbor) {
 	n.neighborsMu.Lock()
 	defer n.neighborsMu.Unlock()
 
-	neighbor.LastContact = time.Now().UTC()
+	neighbor.LastContact = n.now()
 	n.neighbors[neighbor.ID] = neighbor
 
 	// Update router's contact graph
@@ -199,11 +272,57 @@ func (n *Node) RegisterNeighbor(neighbor *Neighbor) {
 		n.router.UpdateContactGraph(n.ID, neighbor)
 	}
 
+	// Let handshake-based transports map the peer's node ID to this neighbor
+	if peerAware, ok := n.transport.(PeerAwareTransport); ok {
+		peerAware.BindPeer(neighbor.EID, neighbor.ID)
+	}
+
 	n.recordMetric(func(m *NodeMetrics) {
 		m.ActiveConnections = len(n.neighbors)
 	})
 }
 
+// handlePeerEvent updates the neighbor table when a convergence-layer
+// session reports a peer coming up or going down.
+func (n *Node) handlePeerEvent(peer PeerInfo) {
+	if !peer.Connected {
+		n.neighborsMu.Lock()
+		if neighbor, exists := n.neighbors[peer.NeighborID]; exists {
+			neighbor.IsActive = false
+		}
+		n.neighborsMu.Unlock()
+		log.Printf("[DTN Node %s] Session with %s (%s) ended", n.ID, peer.NeighborID, peer.NodeEID)
+		return
+	}
+
+	now := n.now()
+	neighbor := &Neighbor{
+		ID:           peer.NeighborID,
+		EID:          peer.NodeEID,
+		Address:      peer.Address,
+		LinkQuality:  0.5, // Unknown until measured
+		IsActive:     true,
+		ContactStart: now,
+	}
+
+	n.neighborsMu.RLock()
+	if existing, exists := n.neighbors[peer.NeighborID]; exists {
+		copied := *existing
+		neighbor = &copied
+		neighbor.IsActive = true
+		if neighbor.EID == "" {
+			neighbor.EID = peer.NodeEID
+		}
+		if neighbor.Address == "" {
+			neighbor.Address = peer.Address
+		}
+	}
+	n.neighborsMu.RUnlock()
+
+	n.RegisterNeighbor(neighbor)
+	log.Printf("[DTN Node %s] Session with %s (%s) established", n.ID, peer.NeighborID, peer.NodeEID)
+}
+
 // UnregisterNeighbor removes a neighbor node.
 func (n *Node) UnregisterNeighbor(neighborID string) {
 	n.neighborsMu.Lock()
@@ -234,6 +353,7 @@ func (n *Node) GetNeighbors() map[string]*Neighbor {
 			Bandwidth:    v.Bandwidth,
 			ContactStart: v.ContactStart,
 			ContactEnd:   v.ContactEnd,
+			MTU:          v.MTU,
 		}
 	}
 	return result
@@ -266,31 +386,77 @@ func (n *Node) processIngress() {
 // handleIncomingBundle processes a single incoming bundle.
 func (n *Node) handleIncomingBundle(b *bundle.Bundle) {
 	// Validate bundle
-	if err := b.Validate(); err != nil {
+	now := n.now()
+	if err := b.ValidateAt(now); err != nil {
 		log.Printf("[DTN Node %s] Invalid bundle: %v", n.ID, err)
 		n.recordMetric(func(m *NodeMetrics) { m.BundlesDropped++ })
+		reason := bundle.ReasonBlockUnintelligible
+		switch {
+		case b.IsExpiredAt(now):
+			reason = bundle.ReasonLifetimeExpired
+		case b.HopCount > bundle.MaxHopCount:
+			reason = bundle.ReasonHopLimitExceeded
+		}
+		n.reportStatus(b, bundle.FlagReportDeletion, reason)
 		return
 	}
 
+	n.reportStatus(b, bundle.FlagReportReception, bundle.ReasonNoInfo)
+
 	// Check if we are the destination
 	if b.DestinationEID == n.EID {
+		if b.IsFragment {
+			whole, err := n.reassembler.Add(n.ctx, b)
+			if err != nil {
+				log.Printf("[DTN Node %s] Reassembly failed for fragment %s: %v", n.ID, b.ID.String()[:8], err)
+				n.recordMetric(func(m *NodeMetrics) { m.BundlesDropped++ })
+				return
+			}
+			if whole == nil {
+				// Waiting for remaining fragments
+				return
+			}
+			log.Printf("[DTN Node %s] Reassembled bundle %s (%d bytes)", n.ID, whole.ID.String()[:8], len(whole.Payload))
+			n.recordMetric(func(m *NodeMetrics) { m.BundlesReassembled++ })
+			b = whole
+		}
+		if !n.verifySecurity(b, true) {
+			return
+		}
+		if b.IsAdminRecord() {
+			n.handleAdminRecord(b)
+			return
+		}
 		n.deliverLocally(b)
 		return
 	}
 
+	// Verify BIBs we hold keys for; fragments are checked after reassembly
+	if !b.IsFragment && !n.verifySecurity(b, false) {
+		return
+	}
+
 	// Store for forwarding
 	if err := n.storage.Store(n.ctx, b); err != nil {
 		log.Printf("[DTN Node %s] Failed to store bundle: %v", n.ID, err)
+		n.refuseCustody(b, bundle.ReasonDepletedStorage)
+		n.reportStatus(b, bundle.FlagReportDeletion, bundle.ReasonDepletedStorage)
 		return
 	}
 
 	// Increment hop count
-	if err := b.IncrementHop(n.ID); err != nil {
+	if err := b.IncrementHop(n.EID); err != nil {
 		log.Printf("[DTN Node %s] Bundle exceeded hop limit: %v", n.ID, err)
 		n.recordMetric(func(m *NodeMetrics) { m.BundlesDropped++ })
+		n.storage.Delete(n.ctx, b.ID)
+		n.refuseCustody(b, bundle.ReasonHopLimitExceeded)
+		n.reportStatus(b, bundle.FlagReportDeletion, bundle.ReasonHopLimitExceeded)
 		return
 	}
 
+	// The bundle is safely stored; relieve the previous custodian
+	n.acceptCustody(b)
+
 	// Queue for forwarding
 	if err := n.Send(n.ctx, b); err != nil {
 		log.Printf("[DTN Node %s] Failed to queue for egress: %v", n.ID, err)
@@ -306,7 +472,15 @@ func (n *Node) deliverLocally(b *bundle.Bundle) {
 		n.storage.UpdateStatus(n.ctx, b.ID, StatusDelivered)
 	}
 
-	// In a full implementation, this would dispatch to local handlers
+	n.acceptCustody(b)
+	n.reportStatus(b, bundle.FlagReportDelivery, bundle.ReasonNoInfo)
+
+	n.adminMu.Lock()
+	handler := n.deliveryHandler
+	n.adminMu.Unlock()
+	if handler != nil {
+		handler(b)
+	}
 }
 
 // processEgress handles outgoing bundle forwarding.
@@ -332,69 +506,139 @@ func (n *Node) forwardBundle(b *bundle.Bundle) {
 
 	// Select next hop using router
 	nextHop, err := n.router.SelectNextHop(n.ctx, b, neighbors)
+	if errors.Is(err, ErrContactNotStarted) {
+		// Hold the bundle for the planned contact
+		n.deferBundles(b)
+		return
+	}
 	if err != nil {
 		log.Printf("[DTN Node %s] No route to %s: %v", n.ID, b.DestinationEID, err)
 		// Keep in storage for later retry
+		n.deferBundles(b)
 		return
 	}
 
 	neighbor, exists := neighbors[nextHop]
 	if !exists || !neighbor.IsActive {
 		log.Printf("[DTN Node %s] Next hop %s not available", n.ID, nextHop)
+		n.releaseBooking(b)
+		n.deferBundles(b)
 		return
 	}
 
 	if n.transport == nil {
 		log.Printf("[DTN Node %s] Transport not configured; cannot send bundle %s to %s",
 			n.ID, b.ID.String()[:8], nextHop)
+		n.releaseBooking(b)
 		n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
 		return
 	}
 
 	if !n.transport.IsConnected(nextHop) {
 		log.Printf("[DTN Node %s] Transport not connected to %s (EID: %s)", n.ID, nextHop, neighbor.EID)
+		n.releaseBooking(b)
 		n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
 		return
 	}
 
-	if err := n.transport.Send(n.ctx, nextHop, b); err != nil {
-		log.Printf("[DTN Node %s] Failed to send bundle %s to %s: %v",
-			n.ID, b.ID.String()[:8], nextHop, err)
+	// Apply BPSec to bundles this node originates
+	if err := n.protectOutbound(b); err != nil {
+		log.Printf("[DTN Node %s] Cannot secure bundle %s: %v", n.ID, b.ID.String()[:8], err)
+		n.releaseBooking(b)
 		n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
 		return
 	}
 
+	// Take custody before transmission so fragments inherit the custodian
+	if n.custodyEnabled(b) {
+		b.Custodian = n.EID
+	}
+
+	// Fragment bundles that exceed the link MTU or the remaining contact capacity
+	mtu, capacity := n.linkLimits(neighbor)
+	size := int64(b.Size())
+	if (mtu > 0 && size > mtu) || (capacity >= 0 && size > capacity) {
+		n.forwardFragments(b, neighbor, mtu, capacity)
+		return
+	}
+
+	n.transmit(b, neighbor)
+}
+
+// transmit sends a bundle to a neighbor and records the outcome.
+func (n *Node) transmit(b *bundle.Bundle, neighbor *Neighbor) error {
+	// Track custody before sending; the signal can beat the final ack
+	custody := n.custodyEnabled(b) && b.Custodian == n.EID
+	if custody {
+		n.trackCustody(b)
+	}
+
+	if err := n.transport.Send(n.ctx, neighbor.ID, b); err != nil {
+		if custody {
+			n.untrackCustody(b)
+		}
+		// The remainder, if any, books its own route when retried
+		n.releaseBooking(b)
+		var partial *PartialTransferError
+		if errors.As(err, &partial) && partial.DeliveredPayload() > 0 {
+			n.bookLinkUsage(neighbor, int64(partial.Acked))
+			n.handlePartialTransfer(b, partial)
+			return err
+		}
+		log.Printf("[DTN Node %s] Failed to send bundle %s to %s: %v",
+			n.ID, b.ID.String()[:8], neighbor.ID, err)
+		n.storage.UpdateStatus(n.ctx, b.ID, StatusFailed)
+		return err
+	}
+
 	log.Printf("[DTN Node %s] Successfully sent bundle %s to %s via transport",
-		n.ID, b.ID.String()[:8], nextHop)
+		n.ID, b.ID.String()[:8], neighbor.ID)
 
+	n.bookLinkUsage(neighbor, int64(b.Size()))
 	n.recordMetric(func(m *NodeMetrics) {
 		m.BundlesSent++
 		m.BytesSent += int64(b.Size())
 	})
 
 	n.storage.UpdateStatus(n.ctx, b.ID, StatusInTransit)
+	n.reportStatus(b, bundle.FlagReportForwarding, bundle.ReasonNoInfo)
+	return nil
+}
+
+// releaseBooking returns the route capacity the router booked for b.
+func (n *Node) releaseBooking(b *bundle.Bundle) {
+	if booker, ok := n.router.(BookingRouter); ok {
+		booker.ReleaseBooking(b.ID)
+	}
 }
 
 // runMaintenance performs periodic cleanup tasks.
 func (n *Node) runMaintenance() {
 	defer n.wg.Done()
 
-	ticker := time.NewTicker(5 * time.Minute)
+	purgeInterval := n.config.PurgeInterval
+	if purgeInterval <= 0 {
+		purgeInterval = 5 * time.Minute
+	}
+	retryInterval := n.config.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = time.Minute
+	}
+
+	ticker := time.NewTicker(purgeInterval)
 	defer ticker.Stop()
+	retryTicker := time.NewTicker(retryInterval)
+	defer retryTicker.Stop()
 
 	for {
 		select {
 		case <-n.ctx.Done():
 			return
+		case <-retryTicker.C:
+			n.retryDeferred()
+			n.checkCustody()
 		case <-ticker.C:
-			// Purge expired bundles
-			purged, err := n.storage.PurgeExpired(n.ctx)
-			if err != nil {
-				log.Printf("[DTN Node %s] Purge error: %v", n.ID, err)
-			} else if purged > 0 {
-				log.Printf("[DTN Node %s] Purged %d expired bundles", n.ID, purged)
-				n.recordMetric(func(m *NodeMetrics) { m.BundlesExpired += int64(purged) })
-			}
+			n.purgeExpired()
 
 			// Check neighbor health
 			n.checkNeighborHealth()
@@ -402,6 +646,17 @@ func (n *Node) runMaintenance() {
 	}
 }
 
+// purgeExpired removes expired bundles from storage.
+func (n *Node) purgeExpired() {
+	purged, err := n.storage.PurgeExpired(n.ctx)
+	if err != nil {
+		log.Printf("[DTN Node %s] Purge error: %v", n.ID, err)
+	} else if purged > 0 {
+		log.Printf("[DTN Node %s] Purged %d expired bundles", n.ID, purged)
+		n.recordMetric(func(m *NodeMetrics) { m.BundlesExpired += int64(purged) })
+	}
+}
+
 // processTransportReceive handles incoming bundles from the transport layer.
 func (n *Node) processTransportReceive() {
 	defer n.wg.Done()
@@ -443,7 +698,7 @@ func (n *Node) checkNeighborHealth() {
 	n.neighborsMu.Lock()
 	defer n.neighborsMu.Unlock()
 
-	threshold := time.Now().Add(-10 * time.Minute)
+	threshold := n.now().Add(-10 * time.Minute)
 
 	for _, neighbor := range n.neighbors {
 		if neighbor.LastContact.Before(threshold) {
@@ -483,7 +738,7 @@ func (n *Node) ConnectNeighbor(ctx context.Context, neighborID string, address s
 	n.neighborsMu.Lock()
 	if neighbor, exists := n.neighbors[neighborID]; exists {
 		neighbor.IsActive = true
-		neighbor.LastContact = time.Now().UTC()
+		neighbor.LastContact = n.now()
 	}
 	n.neighborsMu.Unlock()
 
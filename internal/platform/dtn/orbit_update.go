@@ -0,0 +1,58 @@
+package dtn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+// OrbitDeterminationType is the telemetry type of the fitted orbits that
+// satellites downlink after on-board GPS orbit determination.
+const OrbitDeterminationType = "orbit_determination"
+
+// ErrNotOrbitDetermination is returned when a payload is telemetry of
+// another type.
+var ErrNotOrbitDetermination = errors.New("payload is not an orbit determination")
+
+// orbitDetermination is the part of an orbit determination payload the
+// ground needs: the mean elements fitted to the on-board solution.
+type orbitDetermination struct {
+	Type        string `json:"type"`
+	SatelliteID string `json:"satellite_id"`
+	Estimate    *struct {
+		Elements *struct {
+			TLE *satellite.TLE `json:"tle"`
+		} `json:"elements"`
+	} `json:"estimate"`
+}
+
+// IsOrbitDetermination reports whether a delivered payload is orbit
+// determination telemetry.
+func IsOrbitDetermination(payload []byte) bool {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(payload, &probe) == nil && probe.Type == OrbitDeterminationType
+}
+
+// ParseOrbitDetermination decodes orbit determination telemetry and returns
+// the fitted element set.
+func ParseOrbitDetermination(payload []byte) (*satellite.TLE, error) {
+	var od orbitDetermination
+	if err := json.Unmarshal(payload, &od); err != nil {
+		return nil, fmt.Errorf("decode orbit determination: %w", err)
+	}
+	if od.Type != OrbitDeterminationType {
+		return nil, ErrNotOrbitDetermination
+	}
+	if od.Estimate == nil || od.Estimate.Elements == nil || od.Estimate.Elements.TLE == nil {
+		return nil, fmt.Errorf("orbit determination from %s carries no fitted elements", od.SatelliteID)
+	}
+	tle := od.Estimate.Elements.TLE
+	if tle.SatelliteID <= 0 {
+		return nil, fmt.Errorf("orbit determination from %s has no NORAD ID", od.SatelliteID)
+	}
+	return tle, nil
+}
@@ -0,0 +1,539 @@
+package dtn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// priorityLevels is the number of bundle priority classes.
+const priorityLevels = int(bundle.PriorityExpedited) + 1
+
+// PassSchedulerConfig controls how antenna time is assigned.
+type PassSchedulerConfig struct {
+	Horizon         time.Duration // How far ahead each plan reaches
+	Step            time.Duration // Pass prediction step
+	Interval        time.Duration // Replanning interval for Run
+	SlewRate        float64       // Azimuth slew rate for stations that do not set one, degrees/second
+	SettleTime      time.Duration // Added to every slew before the link is usable
+	MinPassDuration time.Duration // Passes left shorter than this after slewing are dropped
+
+	// PriorityWeights value one byte of backlog by bundle priority:
+	// bulk, normal, expedited.
+	PriorityWeights [priorityLevels]float64
+
+	// IdleWeight values link capacity for satellites with nothing queued,
+	// so that free antenna time is still used for housekeeping.
+	IdleWeight float64
+
+	// UrgencyHorizon discounts later passes: a pass starting this far into
+	// the plan is worth half as much as one starting now.
+	UrgencyHorizon time.Duration
+
+	Rate int64         // Downlink rate, bytes/second
+	OWLT time.Duration // One-way light time of the space-ground links
+}
+
+// DefaultPassSchedulerConfig returns a four-hour plan for single-antenna
+// stations slewing at 2°/s.
+func DefaultPassSchedulerConfig() PassSchedulerConfig {
+	return PassSchedulerConfig{
+		Horizon:         4 * time.Hour,
+		Step:            30 * time.Second,
+		Interval:        15 * time.Minute,
+		SlewRate:        2,
+		SettleTime:      10 * time.Second,
+		MinPassDuration: time.Minute,
+		PriorityWeights: [priorityLevels]float64{1, 4, 16},
+		IdleWeight:      0.001,
+		UrgencyHorizon:  6 * time.Hour,
+		Rate:            predictedLinkRate,
+		OWLT:            predictedLinkOWLT,
+	}
+}
+
+// Backlog is the data a node holds waiting for a contact, in bytes per
+// bundle priority.
+type Backlog struct {
+	Bytes   [priorityLevels]int64 `json:"bytes"` // Indexed by bundle priority
+	Bundles int                   `json:"bundles"`
+}
+
+// Total returns the queued bytes across all priorities.
+func (b Backlog) Total() int64 {
+	var total int64
+	for _, n := range b.Bytes {
+		total += n
+	}
+	return total
+}
+
+// backlogStatuses are the states in which a stored bundle still needs a
+// contact.
+var backlogStatuses = []BundleStatus{StatusPending, StatusDeferred, StatusFailed}
+
+// MeasureBacklog sums the bundles in storage that are waiting to be sent.
+func MeasureBacklog(ctx context.Context, storage BundleStorage) (Backlog, error) {
+	var backlog Backlog
+	for _, status := range backlogStatuses {
+		bundles, err := storage.List(ctx, BundleFilter{Status: status})
+		if err != nil {
+			return Backlog{}, fmt.Errorf("list %s bundles: %w", status, err)
+		}
+		for _, b := range bundles {
+			if int(b.Priority) >= priorityLevels {
+				continue
+			}
+			backlog.Bytes[b.Priority] += int64(b.Size())
+			backlog.Bundles++
+		}
+	}
+	return backlog, nil
+}
+
+// ScheduledPass is antenna time assigned to one satellite at one ground
+// station. Start and End bound the usable link after slewing; AOS and LOS
+// are the predicted visibility window.
+type ScheduledPass struct {
+	SatelliteID   int           `json:"satellite_id"`
+	SatelliteName string        `json:"satellite_name"`
+	SatelliteEID  string        `json:"satellite_eid"`
+	StationID     string        `json:"station_id"`
+	StationName   string        `json:"station_name"`
+	StationEID    string        `json:"station_eid"`
+	Antenna       int           `json:"antenna"`
+	Start         time.Time     `json:"start"`
+	End           time.Time     `json:"end"`
+	AOS           time.Time     `json:"aos"`
+	LOS           time.Time     `json:"los"`
+	MaxElevation  float64       `json:"max_elevation_deg"`
+	Quality       float64       `json:"link_quality"`
+	Rate          int64         `json:"rate"` // bytes/second
+	OWLT          time.Duration `json:"owlt"`
+	Capacity      int64         `json:"capacity"` // bytes
+	// PlannedBytes is the backlog expected to drain during the pass,
+	// indexed by bundle priority.
+	PlannedBytes [priorityLevels]int64 `json:"planned_bytes"`
+
+	startAzimuth, endAzimuth float64
+}
+
+// PassPlan is an ordered schedule of passes across the ground station
+// network.
+type PassPlan struct {
+	Generated   time.Time       `json:"generated"`
+	Start       time.Time       `json:"start"`
+	End         time.Time       `json:"end"`
+	Passes      []ScheduledPass `json:"passes"`
+	Backlog     map[int]Backlog `json:"backlog"`     // Keyed by NORAD ID, as measured when planning
+	Unscheduled int             `json:"unscheduled"` // Predicted passes left out of the plan
+}
+
+// Contacts converts the plan into a bidirectional contact plan for contact
+// graph routing.
+func (p *PassPlan) Contacts() []Contact {
+	contacts := make([]Contact, 0, 2*len(p.Passes))
+	for _, pass := range p.Passes {
+		for _, pair := range [][2]string{{pass.SatelliteEID, pass.StationEID}, {pass.StationEID, pass.SatelliteEID}} {
+			contacts = append(contacts, Contact{
+				From:       pair[0],
+				To:         pair[1],
+				Start:      pass.Start,
+				End:        pass.End,
+				Rate:       pass.Rate,
+				OWLT:       pass.OWLT,
+				Confidence: pass.Quality,
+			})
+		}
+	}
+	return contacts
+}
+
+// SchedulePasses assigns antenna time to predicted contacts. Passes are
+// chosen greedily by value: the backlog a pass can drain, weighted by
+// bundle priority and discounted by how late the pass starts. Each station
+// tracks at most Antennas passes at once, an antenna must slew from the
+// LOS azimuth of one pass to the AOS azimuth of the next, and a satellite
+// talks to one station at a time. Passes that would lose time to slewing
+// are shortened rather than dropped when enough of them remains.
+func SchedulePasses(predicted []PredictedContact, stations []GroundStation, satellites []SatelliteNode,
+	backlog map[int]Backlog, start time.Time, cfg PassSchedulerConfig) *PassPlan {
+	s := newPassAssignment(stations, satellites, backlog, start, cfg)
+
+	candidates := make([]*PredictedContact, 0, len(predicted))
+	for i := range predicted {
+		pc := &predicted[i]
+		if _, ok := s.stations[pc.GroundStation]; !ok {
+			continue
+		}
+		if _, ok := s.satellites[pc.SatelliteID]; !ok {
+			continue
+		}
+		candidates = append(candidates, pc)
+	}
+
+	for len(candidates) > 0 {
+		bestIdx := -1
+		var best ScheduledPass
+		bestScore := math.Inf(-1)
+		kept := candidates[:0]
+		for _, pc := range candidates {
+			pass, ok := s.fit(pc)
+			if !ok {
+				continue // Cannot fit now and never will as the plan only fills up
+			}
+			kept = append(kept, pc)
+			score := s.score(&pass)
+			if score > bestScore || (score == bestScore && passBefore(&pass, &best)) {
+				bestIdx, best, bestScore = len(kept)-1, pass, score
+			}
+		}
+		candidates = kept
+		if bestIdx < 0 {
+			break
+		}
+		s.commit(best)
+		candidates = append(candidates[:bestIdx], candidates[bestIdx+1:]...)
+	}
+
+	plan := s.plan
+	sort.Slice(plan.Passes, func(i, j int) bool { return passBefore(&plan.Passes[i], &plan.Passes[j]) })
+	plan.Unscheduled = len(predicted) - len(plan.Passes)
+	return plan
+}
+
+// passBefore orders passes by start, then station and antenna.
+func passBefore(a, b *ScheduledPass) bool {
+	if !a.Start.Equal(b.Start) {
+		return a.Start.Before(b.Start)
+	}
+	if a.StationID != b.StationID {
+		return a.StationID < b.StationID
+	}
+	if a.Antenna != b.Antenna {
+		return a.Antenna < b.Antenna
+	}
+	return a.SatelliteID < b.SatelliteID
+}
+
+// passAssignment is the state of one scheduling run.
+type passAssignment struct {
+	cfg        PassSchedulerConfig
+	start      time.Time
+	stations   map[string]GroundStation // Keyed by name, as in PredictedContact
+	satellites map[int]SatelliteNode
+	remaining  map[int]Backlog
+	antennas   map[string][][]*ScheduledPass
+	bySat      map[int][]*ScheduledPass
+	plan       *PassPlan
+}
+
+func newPassAssignment(stations []GroundStation, satellites []SatelliteNode, backlog map[int]Backlog,
+	start time.Time, cfg PassSchedulerConfig) *passAssignment {
+	s := &passAssignment{
+		cfg:        cfg,
+		start:      start,
+		stations:   make(map[string]GroundStation, len(stations)),
+		satellites: make(map[int]SatelliteNode, len(satellites)),
+		remaining:  make(map[int]Backlog, len(backlog)),
+		antennas:   make(map[string][][]*ScheduledPass, len(stations)),
+		bySat:      make(map[int][]*ScheduledPass),
+		plan: &PassPlan{
+			Start:   start,
+			Passes:  []ScheduledPass{},
+			Backlog: make(map[int]Backlog, len(backlog)),
+		},
+	}
+	for _, gs := range stations {
+		s.stations[gs.Name] = gs
+		n := gs.Antennas
+		if n < 1 {
+			n = 1
+		}
+		s.antennas[gs.Name] = make([][]*ScheduledPass, n)
+	}
+	for _, sat := range satellites {
+		s.satellites[sat.NoradID] = sat
+	}
+	for id, b := range backlog {
+		s.remaining[id] = b
+		s.plan.Backlog[id] = b
+	}
+	return s
+}
+
+// slewTime returns how long a station's antenna takes to turn between two
+// azimuths, including settling.
+func (s *passAssignment) slewTime(gs GroundStation, from, to float64) time.Duration {
+	rate := gs.SlewRate
+	if rate <= 0 {
+		rate = s.cfg.SlewRate
+	}
+	if rate <= 0 {
+		return s.cfg.SettleTime
+	}
+	delta := math.Mod(math.Abs(from-to), 360)
+	if delta > 180 {
+		delta = 360 - delta
+	}
+	return s.cfg.SettleTime + time.Duration(delta/rate*float64(time.Second))
+}
+
+// fit finds the antenna offering the longest usable window for a predicted
+// contact, trimmed to leave slew time around the passes already assigned.
+func (s *passAssignment) fit(pc *PredictedContact) (ScheduledPass, bool) {
+	gs := s.stations[pc.GroundStation]
+	var best ScheduledPass
+	found := false
+	for antenna, assigned := range s.antennas[pc.GroundStation] {
+		begin, end := pc.StartTime, pc.EndTime
+		for _, other := range assigned {
+			if !other.Start.After(pc.StartTime) {
+				if free := other.End.Add(s.slewTime(gs, other.endAzimuth, pc.StartAzimuth)); free.After(begin) {
+					begin = free
+				}
+			} else if busy := other.Start.Add(-s.slewTime(gs, pc.EndAzimuth, other.startAzimuth)); busy.Before(end) {
+				end = busy
+			}
+		}
+		if end.Sub(begin) < s.cfg.MinPassDuration || (found && end.Sub(begin) <= best.End.Sub(best.Start)) {
+			continue
+		}
+		best = ScheduledPass{Antenna: antenna, Start: begin, End: end}
+		found = true
+	}
+	if !found {
+		return ScheduledPass{}, false
+	}
+	for _, other := range s.bySat[pc.SatelliteID] {
+		if best.Start.Before(other.End) && other.Start.Before(best.End) {
+			return ScheduledPass{}, false
+		}
+	}
+
+	sat := s.satellites[pc.SatelliteID]
+	best.SatelliteID = pc.SatelliteID
+	best.SatelliteName = sat.Name
+	best.SatelliteEID = sat.EID
+	best.StationID = gs.ID
+	best.StationName = gs.Name
+	best.StationEID = gs.EndpointID()
+	best.AOS = pc.StartTime
+	best.LOS = pc.EndTime
+	best.MaxElevation = pc.MaxElevation
+	best.Quality = pc.Quality
+	best.Rate = s.cfg.Rate
+	best.OWLT = s.cfg.OWLT
+	best.Capacity = int64(float64(s.cfg.Rate) * best.End.Sub(best.Start).Seconds())
+	best.startAzimuth = pc.StartAzimuth
+	best.endAzimuth = pc.EndAzimuth
+	best.PlannedBytes = drain(s.remaining[pc.SatelliteID], best.Capacity)
+	return best, true
+}
+
+// drain returns the bytes a pass of the given capacity sends from a
+// backlog, highest priority first.
+func drain(backlog Backlog, capacity int64) [priorityLevels]int64 {
+	var sent [priorityLevels]int64
+	for p := priorityLevels - 1; p >= 0 && capacity > 0; p-- {
+		n := backlog.Bytes[p]
+		if n > capacity {
+			n = capacity
+		}
+		sent[p] = n
+		capacity -= n
+	}
+	return sent
+}
+
+// score values a candidate pass.
+func (s *passAssignment) score(pass *ScheduledPass) float64 {
+	value := s.cfg.IdleWeight * float64(pass.Capacity) * pass.Quality
+	for p, n := range pass.PlannedBytes {
+		value += s.cfg.PriorityWeights[p] * float64(n)
+	}
+	if s.cfg.UrgencyHorizon > 0 {
+		delay := pass.Start.Sub(s.start)
+		if delay < 0 {
+			delay = 0
+		}
+		value /= 1 + float64(delay)/float64(s.cfg.UrgencyHorizon)
+	}
+	return value
+}
+
+// commit adds a pass to the plan and takes the data it drains off the
+// satellite's backlog.
+func (s *passAssignment) commit(pass ScheduledPass) {
+	s.plan.Passes = append(s.plan.Passes, pass)
+	stored := &pass
+	s.antennas[pass.StationName][pass.Antenna] = append(s.antennas[pass.StationName][pass.Antenna], stored)
+	s.bySat[pass.SatelliteID] = append(s.bySat[pass.SatelliteID], stored)
+
+	remaining := s.remaining[pass.SatelliteID]
+	for p, n := range pass.PlannedBytes {
+		remaining.Bytes[p] -= n
+	}
+	s.remaining[pass.SatelliteID] = remaining
+}
+
+// PassScheduler plans ground station antenna time from predicted contacts
+// and node backlogs, and pushes the plan into contact graph routers.
+type PassScheduler struct {
+	predictor *ContactPredictor
+	config    PassSchedulerConfig
+
+	mu       sync.RWMutex
+	storage  map[int]BundleStorage
+	reported map[int]reportedBacklog
+	routers  []*ContactGraphRouter
+	plan     *PassPlan
+}
+
+// reportedBacklog is a backlog measured by a remote node.
+type reportedBacklog struct {
+	backlog Backlog
+	at      time.Time
+}
+
+// reportTTL is how many replanning intervals a reported backlog stays valid.
+const reportTTL = 3
+
+// NewPassScheduler creates a scheduler over the predictor's ground stations
+// and satellites.
+func NewPassScheduler(predictor *ContactPredictor, cfg PassSchedulerConfig) *PassScheduler {
+	return &PassScheduler{
+		predictor: predictor,
+		config:    cfg,
+		storage:   make(map[int]BundleStorage),
+		reported:  make(map[int]reportedBacklog),
+	}
+}
+
+// AddBacklogSource registers the bundle storage of a satellite's DTN node,
+// whose queued bundles drive how much antenna time the satellite gets.
+func (s *PassScheduler) AddBacklogSource(noradID int, storage BundleStorage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.storage[noradID] = storage
+}
+
+// ReportBacklog records the backlog a satellite's DTN node measured itself,
+// for nodes whose storage lives in another process. A registered backlog
+// source takes precedence, and reports expire after a few replanning
+// intervals.
+func (s *PassScheduler) ReportBacklog(noradID int, backlog Backlog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reported[noradID] = reportedBacklog{backlog: backlog, at: time.Now()}
+}
+
+// LoadTLE replaces a satellite's orbit with a fresher element set, such as
+// one fitted on board and downlinked as orbit determination telemetry. The
+// next plan uses it.
+func (s *PassScheduler) LoadTLE(noradID int, tle *satellite.TLE) error {
+	return s.predictor.LoadTLE(noradID, tle)
+}
+
+// AddRouter registers a router that receives each new plan.
+func (s *PassScheduler) AddRouter(router *ContactGraphRouter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routers = append(s.routers, router)
+}
+
+// Plan returns the most recent plan, or nil before the first.
+func (s *PassScheduler) Plan() *PassPlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.plan
+}
+
+// Schedule predicts contacts from start over the configured horizon,
+// measures each satellite's backlog, builds a plan and pushes it to the
+// registered routers.
+func (s *PassScheduler) Schedule(ctx context.Context, start time.Time) (*PassPlan, error) {
+	start = start.UTC()
+	predicted := s.predictor.PredictContactsFrom(ctx, start, s.config.Horizon, s.config.Step)
+
+	s.mu.RLock()
+	sources := make(map[int]BundleStorage, len(s.storage))
+	for id, storage := range s.storage {
+		sources[id] = storage
+	}
+	backlog := make(map[int]Backlog, len(sources)+len(s.reported))
+	cutoff := time.Now().Add(-reportTTL * s.interval())
+	for id, report := range s.reported {
+		if report.at.After(cutoff) {
+			backlog[id] = report.backlog
+		}
+	}
+	s.mu.RUnlock()
+
+	for id, storage := range sources {
+		b, err := MeasureBacklog(ctx, storage)
+		if err != nil {
+			return nil, fmt.Errorf("backlog for NORAD %d: %w", id, err)
+		}
+		backlog[id] = b
+	}
+
+	s.predictor.mu.RLock()
+	stations := append([]GroundStation(nil), s.predictor.groundStations...)
+	satellites := append([]SatelliteNode(nil), s.predictor.satellites...)
+	s.predictor.mu.RUnlock()
+
+	plan := SchedulePasses(predicted, stations, satellites, backlog, start, s.config)
+	plan.Generated = time.Now().UTC()
+	plan.End = start.Add(s.config.Horizon)
+	contacts := plan.Contacts()
+
+	s.mu.Lock()
+	s.plan = plan
+	routers := append([]*ContactGraphRouter(nil), s.routers...)
+	s.mu.Unlock()
+
+	for _, router := range routers {
+		router.SetContactPlan(contacts)
+	}
+	return plan, nil
+}
+
+// Run loads orbits for the predictor, then replans immediately and every
+// configured interval until ctx is done.
+func (s *PassScheduler) Run(ctx context.Context) {
+	if err := s.predictor.Initialize(ctx); err != nil {
+		log.Printf("[PassScheduler] Failed to initialize contact predictor: %v", err)
+	}
+
+	ticker := time.NewTicker(s.interval())
+	defer ticker.Stop()
+	for {
+		plan, err := s.Schedule(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[PassScheduler] Scheduling failed: %v", err)
+		} else if err == nil {
+			log.Printf("[PassScheduler] Planned %d passes, %d left unscheduled", len(plan.Passes), plan.Unscheduled)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// interval returns the replanning interval.
+func (s *PassScheduler) interval() time.Duration {
+	if s.config.Interval <= 0 {
+		return DefaultPassSchedulerConfig().Interval
+	}
+	return s.config.Interval
+}
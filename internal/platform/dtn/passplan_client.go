@@ -0,0 +1,196 @@
+package dtn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PassPlanClientConfig configures a PassPlanClient.
+type PassPlanClientConfig struct {
+	BaseURL  string        // Nysus API base URL, e.g. http://nysus:8080
+	NoradID  int           // Satellite whose backlog this node reports (0 = do not report)
+	Interval time.Duration // How often to report the backlog and fetch the plan
+	Timeout  time.Duration // Per-request HTTP timeout
+}
+
+// DefaultPassPlanClientConfig returns a configuration that syncs with the
+// Nysus API at baseURL every five minutes.
+func DefaultPassPlanClientConfig(baseURL string) PassPlanClientConfig {
+	return PassPlanClientConfig{
+		BaseURL:  baseURL,
+		Interval: 5 * time.Minute,
+		Timeout:  15 * time.Second,
+	}
+}
+
+// BacklogReport is the body of a backlog report to /api/dtn/backlog.
+type BacklogReport struct {
+	NoradID int     `json:"norad_id"`
+	Backlog Backlog `json:"backlog"`
+}
+
+// PassPlanClient keeps a node's contact graph router on the pass plan that
+// Nysus publishes under /api/dtn/passplan, and reports the node's backlog
+// so the plan can give it antenna time.
+type PassPlanClient struct {
+	config     PassPlanClientConfig
+	router     *ContactGraphRouter
+	storage    BundleStorage
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	static []Contact
+}
+
+// NewPassPlanClient creates a client that applies plans to router and
+// measures the backlog in storage.
+func NewPassPlanClient(cfg PassPlanClientConfig, router *ContactGraphRouter, storage BundleStorage) *PassPlanClient {
+	return &PassPlanClient{
+		config:     cfg,
+		router:     router,
+		storage:    storage,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// SetStaticContacts sets contacts, such as those of a contact plan file,
+// that are kept alongside every fetched plan.
+func (c *PassPlanClient) SetStaticContacts(contacts []Contact) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.static = append([]Contact(nil), contacts...)
+}
+
+// Sync reports the backlog, then fetches the current plan and loads it into
+// the router. A failed report does not stop the plan from being applied.
+func (c *PassPlanClient) Sync(ctx context.Context) (*PassPlan, error) {
+	if c.config.NoradID > 0 {
+		if err := c.ReportBacklog(ctx); err != nil {
+			log.Printf("[PassPlanClient] Failed to report backlog: %v", err)
+		}
+	}
+
+	plan, err := c.FetchPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	contacts := append(append([]Contact(nil), c.static...), plan.Contacts()...)
+	c.mu.Unlock()
+	c.router.SetContactPlan(contacts)
+	return plan, nil
+}
+
+// FetchPlan downloads the current pass plan.
+func (c *PassPlanClient) FetchPlan(ctx context.Context) (*PassPlan, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url("/api/dtn/passplan"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch pass plan: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("pass plan API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var plan PassPlan
+	if err := json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+		return nil, fmt.Errorf("decode pass plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// ReportBacklog measures the node's backlog and posts it to Nysus.
+func (c *PassPlanClient) ReportBacklog(ctx context.Context) error {
+	backlog, err := MeasureBacklog(ctx, c.storage)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(BacklogReport{NoradID: c.config.NoradID, Backlog: backlog})
+	if err != nil {
+		return fmt.Errorf("encode backlog: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url("/api/dtn/backlog"), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post backlog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("backlog API error %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// ReportOrbit forwards orbit determination telemetry delivered to this node
+// to Nysus, which loads the fitted orbit into its contact predictor.
+func (c *PassPlanClient) ReportOrbit(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url("/api/dtn/orbit"), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post orbit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("orbit API error %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Run syncs immediately and then every configured interval until ctx is
+// done.
+func (c *PassPlanClient) Run(ctx context.Context) {
+	interval := c.config.Interval
+	if interval <= 0 {
+		interval = DefaultPassPlanClientConfig("").Interval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		plan, err := c.Sync(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[PassPlanClient] Sync failed: %v", err)
+		} else if err == nil {
+			log.Printf("[PassPlanClient] Loaded %d passes planned at %s", len(plan.Passes), plan.Generated.Format(time.RFC3339))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *PassPlanClient) url(path string) string {
+	return strings.TrimRight(c.config.BaseURL, "/") + path
+}
@@ -3,7 +3,9 @@ package dtn
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/asgard/pandora/internal/platform/db"
 	"github.com/asgard/pandora/pkg/bundle"
@@ -54,6 +56,19 @@ func (s *PostgresBundleStorage) createTable() error {
 		CREATE INDEX IF NOT EXISTS idx_bundles_status ON dtn_bundles(status);
 		CREATE INDEX IF NOT EXISTS idx_bundles_priority ON dtn_bundles(priority DESC);
 		CREATE INDEX IF NOT EXISTS idx_bundles_stored_at ON dtn_bundles(stored_at);
+
+		-- Fragmentation fields (RFC 9171 Section 5.8)
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS sequence_number BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS is_fragment BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS fragment_offset BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS total_adu_length BIGINT NOT NULL DEFAULT 0;
+
+		-- Extension blocks, including BPSec BIB/BCB blocks (RFC 9172)
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS extension_blocks JSONB;
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS bundle_age BIGINT NOT NULL DEFAULT 0;
+
+		-- Custody transfer
+		ALTER TABLE dtn_bundles ADD COLUMN IF NOT EXISTS custodian TEXT NOT NULL DEFAULT '';
 	`
 
 	_, err := s.db.Exec(query)
@@ -66,14 +81,27 @@ func (s *PostgresBundleStorage) Store(ctx context.Context, b *bundle.Bundle) err
 		return fmt.Errorf("invalid bundle: %w", err)
 	}
 
+	var blocks []byte
+	if len(b.Blocks) > 0 {
+		var err error
+		if blocks, err = json.Marshal(b.Blocks); err != nil {
+			return fmt.Errorf("encode extension blocks: %w", err)
+		}
+	}
+
 	query := `
 		INSERT INTO dtn_bundles (
 			id, version, bundle_flags, destination_eid, source_eid,
 			report_to, creation_timestamp, lifetime, payload, crc_type,
-			previous_node, hop_count, priority, status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			previous_node, hop_count, priority, status,
+			sequence_number, is_fragment, fragment_offset, total_adu_length,
+			extension_blocks, bundle_age, custodian
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 		ON CONFLICT (id) DO UPDATE SET
 			status = EXCLUDED.status,
+			extension_blocks = EXCLUDED.extension_blocks,
+			bundle_age = EXCLUDED.bundle_age,
+			custodian = EXCLUDED.custodian,
 			updated_at = NOW()
 	`
 
@@ -92,6 +120,13 @@ func (s *PostgresBundleStorage) Store(ctx context.Context, b *bundle.Bundle) err
 		b.HopCount,
 		b.Priority,
 		StatusPending,
+		int64(b.SequenceNumber),
+		b.IsFragment,
+		int64(b.FragmentOffset),
+		int64(b.TotalADULength),
+		blocks,
+		int64(b.BundleAge),
+		b.Custodian,
 	)
 
 	return err
@@ -102,13 +137,31 @@ func (s *PostgresBundleStorage) Retrieve(ctx context.Context, id uuid.UUID) (*bu
 	query := `
 		SELECT id, version, bundle_flags, destination_eid, source_eid,
 		       report_to, creation_timestamp, lifetime, payload, crc_type,
-		       previous_node, hop_count, priority
+		       previous_node, hop_count, priority,
+		       sequence_number, is_fragment, fragment_offset, total_adu_length,
+		       extension_blocks, bundle_age, custodian
 		FROM dtn_bundles
 		WHERE id = $1
 	`
 
+	b, err := scanBundle(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("bundle not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve bundle: %w", err)
+	}
+
+	return b, nil
+}
+
+// scanBundle reads one row selected with the column list used by Retrieve
+// and List.
+func scanBundle(row interface{ Scan(...interface{}) error }) (*bundle.Bundle, error) {
 	var b bundle.Bundle
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	var blocks []byte
+	var age int64
+	if err := row.Scan(
 		&b.ID,
 		&b.Version,
 		&b.BundleFlags,
@@ -122,15 +175,23 @@ func (s *PostgresBundleStorage) Retrieve(ctx context.Context, id uuid.UUID) (*bu
 		&b.PreviousNode,
 		&b.HopCount,
 		&b.Priority,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("bundle not found: %s", id)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve bundle: %w", err)
+		&b.SequenceNumber,
+		&b.IsFragment,
+		&b.FragmentOffset,
+		&b.TotalADULength,
+		&blocks,
+		&age,
+		&b.Custodian,
+	); err != nil {
+		return nil, err
 	}
 
+	if len(blocks) > 0 {
+		if err := json.Unmarshal(blocks, &b.Blocks); err != nil {
+			return nil, fmt.Errorf("decode extension blocks: %w", err)
+		}
+	}
+	b.BundleAge = time.Duration(age)
 	return &b, nil
 }
 
@@ -157,7 +218,9 @@ func (s *PostgresBundleStorage) Delete(ctx context.Context, id uuid.UUID) error
 func (s *PostgresBundleStorage) List(ctx context.Context, filter BundleFilter) ([]*bundle.Bundle, error) {
 	query := `SELECT id, version, bundle_flags, destination_eid, source_eid,
 	                 report_to, creation_timestamp, lifetime, payload, crc_type,
-	                 previous_node, hop_count, priority
+	                 previous_node, hop_count, priority,
+	                 sequence_number, is_fragment, fragment_offset, total_adu_length,
+	                 extension_blocks, bundle_age, custodian
 	          FROM dtn_bundles
 	          WHERE 1=1`
 	args := []interface{}{}
@@ -217,25 +280,11 @@ func (s *PostgresBundleStorage) List(ctx context.Context, filter BundleFilter) (
 
 	var bundles []*bundle.Bundle
 	for rows.Next() {
-		var b bundle.Bundle
-		if err := rows.Scan(
-			&b.ID,
-			&b.Version,
-			&b.BundleFlags,
-			&b.DestinationEID,
-			&b.SourceEID,
-			&b.ReportTo,
-			&b.CreationTimestamp,
-			&b.Lifetime,
-			&b.Payload,
-			&b.CRCType,
-			&b.PreviousNode,
-			&b.HopCount,
-			&b.Priority,
-		); err != nil {
+		b, err := scanBundle(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan bundle: %w", err)
 		}
-		bundles = append(bundles, &b)
+		bundles = append(bundles, b)
 	}
 
 	return bundles, rows.Err()
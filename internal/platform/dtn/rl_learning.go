@@ -0,0 +1,358 @@
+package dtn
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// RLLearningConfig tunes how an RLLearner turns delivery outcomes into
+// weight updates.
+type RLLearningConfig struct {
+	LearningRate     float64 // Step size of each weight update
+	Exploration      float64 // Probability of choosing a random eligible neighbor
+	ExplorationDecay float64 // Exploration multiplier applied after each episode
+	MinExploration   float64 // Floor for the decayed exploration rate
+	Discount         float64 // Reward discount per hop back from the outcome
+	RewardDelivered  float64
+	RewardExpired    float64
+	RewardDropped    float64
+	LatencyPenalty   float64 // Subtracted from the delivery reward per hour of latency
+	MaxPending       int     // Routing decisions awaiting an outcome before the oldest are forgotten
+	Seed             int64   // Seeds exploration so training runs are reproducible
+}
+
+// DefaultRLLearningConfig returns learning parameters suited to retraining
+// against the emulator.
+func DefaultRLLearningConfig() RLLearningConfig {
+	return RLLearningConfig{
+		LearningRate:     0.05,
+		Exploration:      0.2,
+		ExplorationDecay: 0.95,
+		MinExploration:   0.02,
+		Discount:         0.9,
+		RewardDelivered:  1.0,
+		RewardExpired:    -1.0,
+		RewardDropped:    -0.5,
+		LatencyPenalty:   0.05,
+		MaxPending:       10000,
+		Seed:             1,
+	}
+}
+
+// RLOutcome is the fate of a bundle routed by a learning agent.
+type RLOutcome int
+
+const (
+	RLDelivered RLOutcome = iota
+	RLExpired
+	RLDropped
+)
+
+// String returns the outcome name.
+func (o RLOutcome) String() string {
+	switch o {
+	case RLDelivered:
+		return "delivered"
+	case RLExpired:
+		return "expired"
+	case RLDropped:
+		return "dropped"
+	default:
+		return fmt.Sprintf("outcome(%d)", int(o))
+	}
+}
+
+// RLEpisodeStats summarizes one training episode.
+type RLEpisodeStats struct {
+	Episode       int           `json:"episode"`
+	Exploration   float64       `json:"exploration"`
+	Delivered     int           `json:"delivered"`
+	Expired       int           `json:"expired"`
+	Dropped       int           `json:"dropped"`
+	Reward        float64       `json:"reward"`
+	Updates       int           `json:"updates"` // Routing decisions trained on
+	DeliveryRatio float64       `json:"delivery_ratio,omitempty"`
+	MeanLatency   time.Duration `json:"mean_latency,omitempty"`
+}
+
+// rlDecision is the feature vector of one routing choice.
+type rlDecision struct {
+	priority string
+	features []float64
+}
+
+// RLLearner trains an RLRoutingModel from the outcomes of the routing
+// decisions agents make with it. Each bundle's decisions, one per hop, are
+// held until the bundle is delivered, expires or is dropped; the outcome's
+// reward, discounted by the hops remaining, is then regressed onto the
+// chosen neighbors' scores. Agents sharing a learner train one model.
+type RLLearner struct {
+	mu          sync.Mutex
+	cfg         RLLearningConfig
+	model       *RLRoutingModel // Replaced, never modified, on update
+	rng         *rand.Rand
+	exploration float64
+	pending     map[string][]rlDecision
+	order       []string // Pending keys, oldest first
+	episode     RLEpisodeStats
+}
+
+// NewRLLearner creates a learner that starts from model. The model is
+// copied; use Model to obtain the trained weights.
+func NewRLLearner(model *RLRoutingModel, cfg RLLearningConfig) *RLLearner {
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = DefaultRLLearningConfig().MaxPending
+	}
+	if cfg.Discount <= 0 || cfg.Discount > 1 {
+		cfg.Discount = 1
+	}
+	if cfg.ExplorationDecay <= 0 {
+		cfg.ExplorationDecay = 1
+	}
+	return &RLLearner{
+		cfg:         cfg,
+		model:       model.Clone(),
+		rng:         rand.New(rand.NewSource(cfg.Seed)),
+		exploration: cfg.Exploration,
+		pending:     make(map[string][]rlDecision),
+		episode:     RLEpisodeStats{Episode: model.Episodes + 1, Exploration: cfg.Exploration},
+	}
+}
+
+// Model returns a copy of the current model.
+func (l *RLLearner) Model() *RLRoutingModel {
+	return l.snapshot().Clone()
+}
+
+// Exploration returns the current exploration rate.
+func (l *RLLearner) Exploration() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exploration
+}
+
+// snapshot returns the current model for read-only use.
+func (l *RLLearner) snapshot() *RLRoutingModel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.model
+}
+
+// explore returns the index of a random candidate with probability equal
+// to the exploration rate, or -1 to take the best-scoring one.
+func (l *RLLearner) explore(candidates int) int {
+	if candidates < 2 {
+		return -1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.exploration <= 0 || l.rng.Float64() >= l.exploration {
+		return -1
+	}
+	return l.rng.Intn(candidates)
+}
+
+// record remembers a routing decision for b until its outcome is known.
+func (l *RLLearner) record(b *bundle.Bundle, priority string, features []float64) {
+	key := rlBundleKey(bundle.RefOf(b))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.pending[key]; !ok {
+		l.order = append(l.order, key)
+	}
+	l.pending[key] = append(l.pending[key], rlDecision{priority: priority, features: features})
+
+	for len(l.pending) > l.cfg.MaxPending && len(l.order) > 0 {
+		delete(l.pending, l.order[0])
+		l.order = l.order[1:]
+	}
+	if len(l.order) > 2*l.cfg.MaxPending {
+		l.compactOrder()
+	}
+}
+
+// compactOrder drops keys whose outcome has already been rewarded.
+func (l *RLLearner) compactOrder() {
+	order := l.order[:0]
+	for _, key := range l.order {
+		if _, ok := l.pending[key]; ok {
+			order = append(order, key)
+		}
+	}
+	l.order = order
+}
+
+// Reward trains on the outcome of the bundle identified by ref. Fragments
+// share the decisions of their original bundle. It returns false if no
+// decisions are pending for the bundle.
+func (l *RLLearner) Reward(ref bundle.BundleRef, outcome RLOutcome, latency time.Duration) bool {
+	key := rlBundleKey(ref)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	decisions, ok := l.pending[key]
+	if !ok {
+		return false
+	}
+	delete(l.pending, key)
+
+	var reward float64
+	switch outcome {
+	case RLDelivered:
+		reward = l.cfg.RewardDelivered - l.cfg.LatencyPenalty*latency.Hours()
+		l.episode.Delivered++
+	case RLExpired:
+		reward = l.cfg.RewardExpired
+		l.episode.Expired++
+	case RLDropped:
+		reward = l.cfg.RewardDropped
+		l.episode.Dropped++
+	default:
+		return false
+	}
+	l.episode.Reward += reward
+	l.episode.Updates += len(decisions)
+
+	model := l.model.Clone()
+	for i, d := range decisions {
+		weights, ok := model.PriorityWeights[d.priority]
+		if !ok || len(weights) != len(d.features) {
+			continue
+		}
+		target := reward * math.Pow(l.cfg.Discount, float64(len(decisions)-1-i))
+		step := l.cfg.LearningRate * (target - dot(weights, d.features))
+		for j, x := range d.features {
+			weights[j] += step * x
+		}
+	}
+	l.model = model
+	return true
+}
+
+// RewardStatusReport trains on a delivered or deleted status report for a
+// bundle this node routed. Deletion for lifetime expiry counts as expired;
+// any other deletion counts as dropped.
+func (l *RLLearner) RewardStatusReport(report *bundle.StatusReport) bool {
+	switch {
+	case report.Delivered.Asserted:
+		var latency time.Duration
+		if !report.Delivered.Time.IsZero() && !report.Subject.CreationTimestamp.IsZero() {
+			latency = report.Delivered.Time.Sub(report.Subject.CreationTimestamp)
+		}
+		return l.Reward(report.Subject, RLDelivered, latency)
+	case report.Deleted.Asserted:
+		if report.Reason == bundle.ReasonLifetimeExpired {
+			return l.Reward(report.Subject, RLExpired, 0)
+		}
+		return l.Reward(report.Subject, RLDropped, 0)
+	}
+	return false
+}
+
+// EndEpisode closes a training episode: decisions still awaiting an
+// outcome are forgotten and the exploration rate decays. It returns the
+// episode's statistics.
+func (l *RLLearner) EndEpisode() RLEpisodeStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := l.episode
+	l.pending = make(map[string][]rlDecision)
+	l.order = nil
+
+	model := l.model.Clone()
+	model.Episodes++
+	l.model = model
+
+	l.exploration = math.Max(l.cfg.MinExploration, l.exploration*l.cfg.ExplorationDecay)
+	l.episode = RLEpisodeStats{Episode: model.Episodes + 1, Exploration: l.exploration}
+	return stats
+}
+
+// rlCheckpointPattern matches checkpoint file names written by Checkpoint.
+var rlCheckpointPattern = regexp.MustCompile(`^rl_router_v(\d+)\.json$`)
+
+// Checkpoint writes the current model to dir as rl_router_vNNNN.json and
+// returns its path. The version is one higher than both the model's and
+// any checkpoint already in dir, so checkpoints never overwrite each other.
+func (l *RLLearner) Checkpoint(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create checkpoint directory: %w", err)
+	}
+	latest, err := latestRLCheckpointVersion(dir)
+	if err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	model := l.model.Clone()
+	model.ParentVersion = model.Version
+	if latest > model.Version {
+		model.Version = latest
+	}
+	model.Version++
+	model.TrainedAt = time.Now().UTC().Format(time.RFC3339)
+	l.model = model
+	l.mu.Unlock()
+
+	path := filepath.Join(dir, fmt.Sprintf("rl_router_v%04d.json", model.Version))
+	if err := SaveRLRoutingModel(path, model); err != nil {
+		return "", err
+	}
+	log.Printf("[RL Learner] Checkpointed model v%d (from v%d, %d episodes) to %s",
+		model.Version, model.ParentVersion, model.Episodes, path)
+	return path, nil
+}
+
+// LatestRLCheckpoint returns the path of the highest-versioned checkpoint
+// in dir.
+func LatestRLCheckpoint(dir string) (string, error) {
+	version, err := latestRLCheckpointVersion(dir)
+	if err != nil {
+		return "", err
+	}
+	if version == 0 {
+		return "", fmt.Errorf("no rl checkpoints in %s: %w", dir, os.ErrNotExist)
+	}
+	return filepath.Join(dir, fmt.Sprintf("rl_router_v%04d.json", version)), nil
+}
+
+func latestRLCheckpointVersion(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read checkpoint directory: %w", err)
+	}
+	latest := 0
+	for _, entry := range entries {
+		m := rlCheckpointPattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		if v, err := strconv.Atoi(m[1]); err == nil && v > latest {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+// rlBundleKey identifies the original bundle of a bundle or fragment.
+func rlBundleKey(ref bundle.BundleRef) string {
+	ref.IsFragment = false
+	ref.FragmentOffset = 0
+	ref.FragmentLength = 0
+	return ref.Key()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -15,13 +16,54 @@ import (
 
 type RLRoutingModel struct {
 	Version             int                  `json:"version"`
+	ParentVersion       int                  `json:"parent_version,omitempty"` // Version training started from
 	TrainedAt           string               `json:"trained_at"`
+	Episodes            int                  `json:"episodes,omitempty"` // Training episodes since the first version
 	FeatureOrder        []string             `json:"feature_order"`
 	PriorityWeights     map[string][]float64 `json:"priority_weights"`
 	MinEnergyByPriority map[string]float64   `json:"min_energy_by_priority"`
 	Notes               string               `json:"notes"`
 }
 
+// RLFeatureNames lists the features buildFeatures computes. Models trained
+// before a feature was added score it as zero until they are retrained.
+var RLFeatureNames = []string{
+	"link_quality",
+	"latency_score",
+	"bandwidth",
+	"contact_active",
+	"path_match",
+	"energy_score",
+}
+
+// NewRLRoutingModel returns an untrained model over the current feature set.
+func NewRLRoutingModel() *RLRoutingModel {
+	model := &RLRoutingModel{
+		FeatureOrder:        append([]string(nil), RLFeatureNames...),
+		PriorityWeights:     make(map[string][]float64),
+		MinEnergyByPriority: map[string]float64{"0": 0.3, "1": 0.2, "2": 0.1},
+	}
+	for _, priority := range []string{"0", "1", "2"} {
+		model.PriorityWeights[priority] = make([]float64, len(model.FeatureOrder))
+	}
+	return model
+}
+
+// Clone returns a deep copy of the model.
+func (m *RLRoutingModel) Clone() *RLRoutingModel {
+	clone := *m
+	clone.FeatureOrder = append([]string(nil), m.FeatureOrder...)
+	clone.PriorityWeights = make(map[string][]float64, len(m.PriorityWeights))
+	for priority, weights := range m.PriorityWeights {
+		clone.PriorityWeights[priority] = append([]float64(nil), weights...)
+	}
+	clone.MinEnergyByPriority = make(map[string]float64, len(m.MinEnergyByPriority))
+	for priority, level := range m.MinEnergyByPriority {
+		clone.MinEnergyByPriority[priority] = level
+	}
+	return &clone
+}
+
 func LoadRLRoutingModel(path string) (*RLRoutingModel, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -45,15 +87,57 @@ func LoadRLRoutingModel(path string) (*RLRoutingModel, error) {
 			return nil, fmt.Errorf("weight length mismatch for priority %s", priority)
 		}
 	}
+	for _, feature := range model.FeatureOrder {
+		if !isRLFeature(feature) {
+			return nil, fmt.Errorf("rl model uses unknown feature %q; retrain the model", feature)
+		}
+	}
 
 	return &model, nil
 }
 
+// SaveRLRoutingModel writes a model to path, replacing any existing file
+// atomically.
+func SaveRLRoutingModel(path string, model *RLRoutingModel) error {
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode rl model: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rl_model_*")
+	if err != nil {
+		return fmt.Errorf("write rl model: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write rl model: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write rl model: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write rl model: %w", err)
+	}
+	return nil
+}
+
+func isRLFeature(name string) bool {
+	for _, feature := range RLFeatureNames {
+		if feature == name {
+			return true
+		}
+	}
+	return false
+}
+
 type RLRoutingAgent struct {
 	model       *RLRoutingModel
 	nodeEID     string
 	energyMu    sync.RWMutex
 	energyLevel map[string]float64
+	clock       Clock
+	learner     *RLLearner
 }
 
 func NewRLRoutingAgent(nodeEID, modelPath string) (*RLRoutingAgent, error) {
@@ -61,12 +145,29 @@ func NewRLRoutingAgent(nodeEID, modelPath string) (*RLRoutingAgent, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewRLRoutingAgentWithModel(nodeEID, model), nil
+}
 
+// NewRLRoutingAgentWithModel creates an agent for an already loaded model.
+func NewRLRoutingAgentWithModel(nodeEID string, model *RLRoutingModel) *RLRoutingAgent {
 	return &RLRoutingAgent{
 		model:       model,
 		nodeEID:     nodeEID,
 		energyLevel: make(map[string]float64),
-	}, nil
+		clock:       SystemClock,
+	}
+}
+
+// SetLearner makes the agent route with the learner's weights, explore at
+// its exploration rate and record decisions for training. Several agents
+// may share one learner.
+func (r *RLRoutingAgent) SetLearner(learner *RLLearner) {
+	r.learner = learner
+}
+
+// SetClock replaces the time source used to judge whether contacts are active.
+func (r *RLRoutingAgent) SetClock(clock Clock) {
+	r.clock = clock
 }
 
 func (r *RLRoutingAgent) UpdateEnergy(nodeID string, batteryPercent float64) {
@@ -77,17 +178,22 @@ func (r *RLRoutingAgent) UpdateEnergy(nodeID string, batteryPercent float64) {
 
 func (r *RLRoutingAgent) SelectNextHop(ctx context.Context, b *bundle.Bundle, neighbors map[string]*Neighbor) (string, error) {
 	priorityKey := fmt.Sprintf("%d", b.Priority)
-	weights, ok := r.model.PriorityWeights[priorityKey]
+	model := r.model
+	if r.learner != nil {
+		model = r.learner.snapshot()
+	}
+	weights, ok := model.PriorityWeights[priorityKey]
 	if !ok {
 		return "", fmt.Errorf("missing weights for priority %d", b.Priority)
 	}
 
-	minEnergy := r.model.MinEnergyByPriority[priorityKey]
+	minEnergy := model.MinEnergyByPriority[priorityKey]
 
 	type scored struct {
-		id      string
-		score   float64
-		latency time.Duration
+		id       string
+		score    float64
+		latency  time.Duration
+		features []float64
 	}
 
 	candidates := make([]scored, 0, len(neighbors))
@@ -101,12 +207,13 @@ func (r *RLRoutingAgent) SelectNextHop(ctx context.Context, b *bundle.Bundle, ne
 			continue
 		}
 
-		features := r.buildFeatures(neighbor, b, energyScore)
+		features := r.buildFeatures(model, neighbor, b, energyScore)
 		score := dot(weights, features)
 		candidates = append(candidates, scored{
-			id:      id,
-			score:   score,
-			latency: neighbor.Latency,
+			id:       id,
+			score:    score,
+			latency:  neighbor.Latency,
+			features: features,
 		})
 	}
 
@@ -116,23 +223,33 @@ func (r *RLRoutingAgent) SelectNextHop(ctx context.Context, b *bundle.Bundle, ne
 
 	sort.Slice(candidates, func(i, j int) bool {
 		if candidates[i].score == candidates[j].score {
+			if candidates[i].latency == candidates[j].latency {
+				return candidates[i].id < candidates[j].id
+			}
 			return candidates[i].latency < candidates[j].latency
 		}
 		return candidates[i].score > candidates[j].score
 	})
 
-	return candidates[0].id, nil
+	choice := candidates[0]
+	if r.learner != nil && !b.IsAdminRecord() {
+		if i := r.learner.explore(len(candidates)); i >= 0 {
+			choice = candidates[i]
+		}
+		r.learner.record(b, priorityKey, choice.features)
+	}
+	return choice.id, nil
 }
 
 func (r *RLRoutingAgent) UpdateContactGraph(nodeID string, neighbor *Neighbor) {
 	// No-op for RL router, but kept for Router interface compatibility.
 }
 
-func (r *RLRoutingAgent) buildFeatures(neighbor *Neighbor, b *bundle.Bundle, energyScore float64) []float64 {
+func (r *RLRoutingAgent) buildFeatures(model *RLRoutingModel, neighbor *Neighbor, b *bundle.Bundle, energyScore float64) []float64 {
 	latencyScore := 1.0 - minFloat(float64(neighbor.Latency.Milliseconds())/10000.0, 1.0)
 	bandwidthScore := minFloat(float64(neighbor.Bandwidth)/1000000.0, 1.0)
 	contactActive := 0.0
-	now := time.Now().UTC()
+	now := r.clock.Now().UTC()
 	if neighbor.IsActive && (neighbor.ContactEnd.IsZero() || neighbor.ContactEnd.After(now)) {
 		contactActive = 1.0
 	}
@@ -151,8 +268,8 @@ func (r *RLRoutingAgent) buildFeatures(neighbor *Neighbor, b *bundle.Bundle, ene
 		"energy_score":   energyScore,
 	}
 
-	features := make([]float64, 0, len(r.model.FeatureOrder))
-	for _, key := range r.model.FeatureOrder {
+	features := make([]float64, 0, len(model.FeatureOrder))
+	for _, key := range model.FeatureOrder {
 		features = append(features, featureMap[key])
 	}
 
@@ -0,0 +1,76 @@
+package dtn
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// RLTrainingConfig controls an offline training run.
+type RLTrainingConfig struct {
+	Episodes        int
+	Learning        RLLearningConfig
+	CheckpointDir   string // Versioned checkpoints are written here if set
+	CheckpointEvery int    // Episodes between checkpoints (0 = only after the last)
+}
+
+// DefaultRLTrainingConfig returns the training defaults used by rl_train.
+func DefaultRLTrainingConfig() RLTrainingConfig {
+	return RLTrainingConfig{
+		Episodes: 50,
+		Learning: DefaultRLLearningConfig(),
+	}
+}
+
+// TrainRLRouter learns RL routing weights by running the scenario in the
+// emulator once per episode, with every node routing through one shared
+// learner. Training starts from initial, or from an untrained model over
+// the current feature set if initial is nil. It returns the trained model
+// and per-episode statistics.
+func TrainRLRouter(ctx context.Context, scenario Scenario, initial *RLRoutingModel, cfg RLTrainingConfig) (*RLRoutingModel, []RLEpisodeStats, error) {
+	if cfg.Episodes <= 0 {
+		return nil, nil, fmt.Errorf("training needs at least one episode")
+	}
+	if initial == nil {
+		initial = NewRLRoutingModel()
+	}
+
+	scenario.Routers = []EmulatedRouter{EmulateRL}
+	scenario.RLModel = ""
+	emulator, err := NewEmulator(scenario)
+	if err != nil {
+		return nil, nil, err
+	}
+	learner := NewRLLearner(initial, cfg.Learning)
+	emulator.SetRLLearner(learner)
+
+	stats := make([]RLEpisodeStats, 0, cfg.Episodes)
+	for episode := 1; episode <= cfg.Episodes; episode++ {
+		report, err := emulator.Run(ctx, EmulateRL)
+		if err != nil {
+			return nil, stats, fmt.Errorf("episode %d: %w", episode, err)
+		}
+
+		s := learner.EndEpisode()
+		s.DeliveryRatio = report.DeliveryRatio
+		s.MeanLatency = report.MeanLatency
+		stats = append(stats, s)
+		log.Printf("[RL Trainer] Episode %d: delivered %d/%d (expired %d, dropped %d), reward %.2f, exploration %.3f",
+			s.Episode, report.BundlesDelivered, report.BundlesCreated, s.Expired, s.Dropped, s.Reward, s.Exploration)
+
+		if cfg.CheckpointDir == "" {
+			continue
+		}
+		if episode == cfg.Episodes || (cfg.CheckpointEvery > 0 && episode%cfg.CheckpointEvery == 0) {
+			if _, err := learner.Checkpoint(cfg.CheckpointDir); err != nil {
+				return nil, stats, err
+			}
+		}
+	}
+
+	model := learner.Model()
+	if model.Notes == "" {
+		model.Notes = fmt.Sprintf("trained against scenario %q", scenario.Name)
+	}
+	return model, stats, nil
+}
@@ -2,6 +2,7 @@ package dtn
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -10,14 +11,29 @@ import (
 	"time"
 
 	"github.com/asgard/pandora/pkg/bundle"
+	"github.com/google/uuid"
 )
 
-// ContactGraphRouter implements a Contact Graph Routing algorithm for DTN.
+// ContactGraphRouter implements Contact Graph Routing (CGR) for DTN.
+// Routes are computed over a time-varying contact plan: an earliest-arrival
+// Dijkstra search finds the best route and Yen's algorithm the next best
+// alternatives. Bundles book capacity on the contacts of their route until
+// they are re-routed or the booking is released, and routes are cached per
+// destination until the plan changes.
 // This is suitable for scenarios with predictable contact schedules (e.g., satellite orbits).
 type ContactGraphRouter struct {
 	mu           sync.RWMutex
 	contactGraph map[string]map[string]*ContactWindow
 	nodeEID      string
+
+	plan      []Contact
+	booked    map[contactKey]int64  // Bytes booked per contact
+	bookings  map[uuid.UUID]booking // Route booked by each bundle
+	pruneAt   time.Time             // Next sweep for bookings on closed contacts
+	graph     *contactGraph         // Plan and neighbor contacts, rebuilt on change
+	routes    map[string]*routeCacheEntry
+	maxRoutes int
+	clock     Clock
 }
 
 // ContactWindow represents a scheduled communication opportunity.
@@ -31,18 +47,115 @@ type ContactWindow struct {
 	Reliability float64 // 0.0 to 1.0
 }
 
+// ErrContactNotStarted is returned when the best route to a destination
+// starts with a contact that has not opened yet; the bundle should wait.
+var ErrContactNotStarted = errors.New("next contact has not started")
+
+// defaultMaxRoutes is how many alternative routes CGR keeps per destination.
+const defaultMaxRoutes = 3
+
+// contactKey identifies a contact across plan reloads.
+type contactKey struct {
+	from, to string
+	start    int64
+}
+
+func keyOf(c *Contact) contactKey {
+	return contactKey{from: c.From, to: c.To, start: c.Start.UnixNano()}
+}
+
+// booking records the capacity one bundle holds on its route.
+type booking struct {
+	contacts []contactKey
+	size     int64
+	expires  time.Time // End of the route's last contact
+}
+
+// routeCacheEntry holds routes to one destination node.
+type routeCacheEntry struct {
+	routes  []cgrRoute
+	expires time.Time
+}
+
 // NewContactGraphRouter creates a new CGR router.
 func NewContactGraphRouter(nodeEID string) *ContactGraphRouter {
 	return &ContactGraphRouter{
 		contactGraph: make(map[string]map[string]*ContactWindow),
 		nodeEID:      nodeEID,
+		booked:       make(map[contactKey]int64),
+		bookings:     make(map[uuid.UUID]booking),
+		routes:       make(map[string]*routeCacheEntry),
+		maxRoutes:    defaultMaxRoutes,
+		clock:        SystemClock,
 	}
 }
 
-// SelectNextHop implements the Router interface using Contact Graph Routing.
-func (r *ContactGraphRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle, neighbors map[string]*Neighbor) (string, error) {
+// SetClock replaces the time source used to evaluate contacts.
+func (r *ContactGraphRouter) SetClock(clock Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = clock
+}
+
+// SetContactPlan replaces the contact plan and invalidates cached routes.
+// Capacity already booked on contacts that remain in the plan is kept.
+func (r *ContactGraphRouter) SetContactPlan(contacts []Contact) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan := make([]Contact, 0, len(contacts))
+	keep := make(map[contactKey]int64)
+	for _, c := range contacts {
+		c.From, c.To = nodeKey(c.From), nodeKey(c.To)
+		if c.Confidence == 0 {
+			c.Confidence = 1
+		}
+		plan = append(plan, c)
+		if booked, ok := r.booked[keyOf(&c)]; ok {
+			keep[keyOf(&c)] = booked
+		}
+	}
+	r.plan = plan
+	r.booked = keep
+	r.invalidate()
+}
+
+// ContactPlan returns a copy of the current contact plan.
+func (r *ContactGraphRouter) ContactPlan() []Contact {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	return append([]Contact(nil), r.plan...)
+}
+
+// SetMaxRoutes sets how many routes are computed per destination.
+func (r *ContactGraphRouter) SetMaxRoutes(k int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if k < 1 {
+		k = 1
+	}
+	r.maxRoutes = k
+	r.invalidate()
+}
+
+// Routes returns the routes CGR knows to destEID for a bundle sent at the
+// given time, best first.
+func (r *ContactGraphRouter) Routes(destEID string, at time.Time) []Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached := r.routesTo(destEID, at)
+	routes := make([]Route, 0, len(cached))
+	for _, route := range cached {
+		routes = append(routes, r.graph.export(route))
+	}
+	return routes
+}
+
+// SelectNextHop implements the Router interface using Contact Graph Routing.
+func (r *ContactGraphRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle, neighbors map[string]*Neighbor) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	// Find destination in graph
 	destEID := b.DestinationEID
@@ -54,6 +167,230 @@ func (r *ContactGraphRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle
 		}
 	}
 
+	// Without a contact plan, fall back to scoring the current neighbors
+	if len(r.plan) == 0 {
+		return r.selectByScore(b, neighbors)
+	}
+
+	now := r.clock.Now().UTC()
+	size := int64(b.Size())
+	deadline := b.ExpiresAt()
+
+	// A bundle being re-routed gives up the capacity of its old route
+	r.releaseLocked(b.ID)
+	r.pruneBookingsLocked(now)
+
+	var pending *time.Time
+	for _, route := range r.routesTo(destEID, now) {
+		arrival, ok := r.projectedArrival(route, now, size)
+		if !ok || arrival.After(deadline) {
+			continue
+		}
+
+		first := &r.graph.contacts[route.hops[0]]
+		// Never hand a bundle straight back to the node it came from
+		if b.PreviousNode != "" && sameNode(first.To, b.PreviousNode) {
+			continue
+		}
+		if first.Start.After(now) {
+			if pending == nil || first.Start.Before(*pending) {
+				start := first.Start
+				pending = &start
+			}
+			continue
+		}
+
+		id, neighbor := neighborFor(neighbors, first.To)
+		if neighbor == nil || !neighbor.IsActive {
+			continue
+		}
+
+		held := booking{size: size}
+		for _, h := range route.hops {
+			c := &r.graph.contacts[h]
+			r.booked[keyOf(c)] += size
+			held.contacts = append(held.contacts, keyOf(c))
+			held.expires = c.End
+		}
+		r.bookings[b.ID] = held
+		return id, nil
+	}
+
+	if pending != nil {
+		return "", fmt.Errorf("route to %s opens at %s: %w", destEID, pending.Format(time.RFC3339), ErrContactNotStarted)
+	}
+	return "", fmt.Errorf("no route to destination: %s", destEID)
+}
+
+// UpdateContactGraph adds or updates contact information.
+func (r *ContactGraphRouter) UpdateContactGraph(nodeID string, neighbor *Neighbor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.contactGraph[nodeID] == nil {
+		r.contactGraph[nodeID] = make(map[string]*ContactWindow)
+	}
+
+	r.contactGraph[nodeID][neighbor.ID] = &ContactWindow{
+		FromNode:    r.nodeEID,
+		ToNode:      neighbor.EID,
+		StartTime:   neighbor.ContactStart,
+		EndTime:     neighbor.ContactEnd,
+		Bandwidth:   neighbor.Bandwidth,
+		Latency:     neighbor.Latency,
+		Reliability: neighbor.LinkQuality,
+	}
+	r.invalidate()
+}
+
+// invalidate drops the contact graph and cached routes. Callers hold r.mu.
+func (r *ContactGraphRouter) invalidate() {
+	r.graph = nil
+	r.routes = make(map[string]*routeCacheEntry)
+}
+
+// buildGraph merges the contact plan with contacts learned from neighbor
+// registrations. Callers hold r.mu.
+func (r *ContactGraphRouter) buildGraph() *contactGraph {
+	if r.graph != nil {
+		return r.graph
+	}
+
+	contacts := append([]Contact(nil), r.plan...)
+	for _, windows := range r.contactGraph {
+		for _, w := range windows {
+			c := Contact{
+				From:       nodeKey(w.FromNode),
+				To:         nodeKey(w.ToNode),
+				Start:      w.StartTime,
+				End:        w.EndTime,
+				Rate:       w.Bandwidth,
+				OWLT:       w.Latency,
+				Confidence: w.Reliability,
+			}
+			if c.End.IsZero() {
+				c.End = contactForever
+			}
+			if c.Confidence == 0 {
+				c.Confidence = 1
+			}
+			contacts = append(contacts, c)
+		}
+	}
+
+	r.graph = newContactGraph(contacts)
+	return r.graph
+}
+
+// routesTo returns cached routes to the destination's node, recomputing
+// them when the earliest-expiring route has closed. Callers hold r.mu.
+func (r *ContactGraphRouter) routesTo(destEID string, now time.Time) []cgrRoute {
+	graph := r.buildGraph()
+	dest := nodeKey(destEID)
+
+	if entry, ok := r.routes[dest]; ok && now.Before(entry.expires) {
+		return entry.routes
+	}
+
+	routes := graph.kShortest(nodeKey(r.nodeEID), now, dest, r.maxRoutes)
+	entry := &routeCacheEntry{routes: routes, expires: contactForever}
+	for _, route := range routes {
+		if route.expires.Before(entry.expires) {
+			entry.expires = route.expires
+		}
+	}
+	if len(routes) == 0 {
+		// Retry the search once the plan could offer something new
+		entry.expires = now.Add(time.Minute)
+	}
+	r.routes[dest] = entry
+	return routes
+}
+
+// projectedArrival walks a route with a bundle of the given size, taking
+// transmission time and booked capacity into account. It returns false if
+// the bundle does not fit on one of the contacts. Callers hold r.mu.
+func (r *ContactGraphRouter) projectedArrival(route cgrRoute, now time.Time, size int64) (time.Time, bool) {
+	t := now
+	for _, h := range route.hops {
+		c := &r.graph.contacts[h]
+		if volume := c.Volume(); volume >= 0 && r.booked[keyOf(c)]+size > volume {
+			return t, false
+		}
+		t = laterOf(t, c.Start).Add(c.transmitTime(size))
+		if t.After(c.End) {
+			return t, false
+		}
+		t = t.Add(c.OWLT)
+	}
+	return t, true
+}
+
+// ReleaseBooking returns the capacity a bundle booked on its route, for
+// bundles that were not sent after all.
+func (r *ContactGraphRouter) ReleaseBooking(bundleID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.releaseLocked(bundleID)
+}
+
+// releaseLocked removes a bundle's booking. Callers hold r.mu.
+func (r *ContactGraphRouter) releaseLocked(bundleID uuid.UUID) {
+	held, ok := r.bookings[bundleID]
+	if !ok {
+		return
+	}
+	delete(r.bookings, bundleID)
+	for _, key := range held.contacts {
+		booked, ok := r.booked[key]
+		if !ok {
+			continue
+		}
+		if booked -= held.size; booked > 0 {
+			r.booked[key] = booked
+		} else {
+			delete(r.booked, key)
+		}
+	}
+}
+
+// pruneBookingsLocked forgets, about once a minute, bookings whose route has
+// closed; their capacity can no longer be reused. Callers hold r.mu.
+func (r *ContactGraphRouter) pruneBookingsLocked(now time.Time) {
+	if now.Before(r.pruneAt) {
+		return
+	}
+	r.pruneAt = now.Add(time.Minute)
+	for id, held := range r.bookings {
+		if held.expires.Before(now) {
+			delete(r.bookings, id)
+		}
+	}
+}
+
+// BookedBytes returns the bytes booked on the plan contact from one node to
+// another that starts at the given time.
+func (r *ContactGraphRouter) BookedBytes(from, to string, start time.Time) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.booked[contactKey{from: nodeKey(from), to: nodeKey(to), start: start.UnixNano()}]
+}
+
+// neighborFor finds the neighbor that a contact's receiving node refers to.
+func neighborFor(neighbors map[string]*Neighbor, node string) (string, *Neighbor) {
+	if neighbor, ok := neighbors[node]; ok {
+		return node, neighbor
+	}
+	for id, neighbor := range neighbors {
+		if sameNode(neighbor.EID, node) {
+			return id, neighbor
+		}
+	}
+	return "", nil
+}
+
+// selectByScore ranks active neighbors by link characteristics.
+func (r *ContactGraphRouter) selectByScore(b *bundle.Bundle, neighbors map[string]*Neighbor) (string, error) {
 	// Build list of candidate next hops
 	type candidate struct {
 		neighborID string
@@ -68,16 +405,16 @@ func (r *ContactGraphRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle
 
 		// Calculate routing score based on:
 		// 1. Link quality
-		// 2. Estimated path to destination
+		// 2. Latency
 		// 3. Bundle priority vs available bandwidth
-		score := r.calculateRouteScore(neighbor, destEID, b.Priority)
+		score := r.calculateRouteScore(neighbor, b.Priority)
 		if score > 0 {
 			candidates = append(candidates, candidate{id, score})
 		}
 	}
 
 	if len(candidates) == 0 {
-		return "", fmt.Errorf("no route to destination: %s", destEID)
+		return "", fmt.Errorf("no route to destination: %s", b.DestinationEID)
 	}
 
 	// Sort by score (highest first)
@@ -88,28 +425,8 @@ func (r *ContactGraphRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle
 	return candidates[0].neighborID, nil
 }
 
-// UpdateContactGraph adds or updates contact information.
-func (r *ContactGraphRouter) UpdateContactGraph(nodeID string, neighbor *Neighbor) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	if r.contactGraph[nodeID] == nil {
-		r.contactGraph[nodeID] = make(map[string]*ContactWindow)
-	}
-
-	r.contactGraph[nodeID][neighbor.ID] = &ContactWindow{
-		FromNode:    nodeID,
-		ToNode:      neighbor.ID,
-		StartTime:   neighbor.ContactStart,
-		EndTime:     neighbor.ContactEnd,
-		Bandwidth:   neighbor.Bandwidth,
-		Latency:     neighbor.Latency,
-		Reliability: neighbor.LinkQuality,
-	}
-}
-
 // calculateRouteScore computes a routing score for a neighbor.
-func (r *ContactGraphRouter) calculateRouteScore(neighbor *Neighbor, destEID string, priority uint8) float64 {
+func (r *ContactGraphRouter) calculateRouteScore(neighbor *Neighbor, priority uint8) float64 {
 	score := 0.0
 
 	// Link quality factor (0-1)
@@ -127,32 +444,9 @@ func (r *ContactGraphRouter) calculateRouteScore(neighbor *Neighbor, destEID str
 	priorityBoost := float64(priority) / 2.0 * 0.1
 	score += priorityBoost
 
-	// Check if neighbor is on path to destination
-	if r.isOnPathToDestination(neighbor.EID, destEID) {
-		score += 0.5
-	}
-
 	return score
 }
 
-// isOnPathToDestination checks if a node EID is likely on the path.
-func (r *ContactGraphRouter) isOnPathToDestination(nodeEID, destEID string) bool {
-	// Simple heuristic: check EID prefix matching
-	// e.g., dtn://mars/sat001 is on path to dtn://mars/base
-	if nodeEID == destEID {
-		return true
-	}
-
-	nodeParts := strings.Split(nodeEID, "/")
-	destParts := strings.Split(destEID, "/")
-
-	if len(nodeParts) >= 3 && len(destParts) >= 3 {
-		return nodeParts[2] == destParts[2] // Same domain (e.g., "mars")
-	}
-
-	return false
-}
-
 // EnergyAwareRouter extends CGR with energy awareness for satellite nodes.
 // This is critical for ASGARD satellites operating on limited solar power.
 type EnergyAwareRouter struct {
@@ -171,9 +465,6 @@ func NewEnergyAwareRouter(nodeEID string) *EnergyAwareRouter {
 
 // SelectNextHop implements energy-aware routing.
 func (r *EnergyAwareRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle, neighbors map[string]*Neighbor) (string, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	// Filter neighbors by energy threshold
 	activeNeighbors := make(map[string]*Neighbor)
 	for id, neighbor := range neighbors {
@@ -264,12 +555,17 @@ func (r *StaticRouter) SelectNextHop(ctx context.Context, b *bundle.Bundle, neig
 		}
 	}
 
-	// Fallback: try any active neighbor
+	// Fallback: try any active neighbor, in a stable order
+	ids := make([]string, 0, len(neighbors))
 	for id, neighbor := range neighbors {
 		if neighbor.IsActive {
-			return id, nil
+			ids = append(ids, id)
 		}
 	}
+	if len(ids) > 0 {
+		sort.Strings(ids)
+		return ids[0], nil
+	}
 
 	return "", fmt.Errorf("no route to destination: %s", b.DestinationEID)
 }
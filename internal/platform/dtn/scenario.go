@@ -0,0 +1,258 @@
+package dtn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+// scenarioFile is the JSON form of a Scenario. Durations are Go duration
+// strings and contact times are offsets from the scenario start.
+type scenarioFile struct {
+	Name          string            `json:"name"`
+	Start         time.Time         `json:"start"`
+	Duration      string            `json:"duration"`
+	RetryInterval string            `json:"retry_interval"`
+	Nodes         []ScenarioNode    `json:"nodes"`
+	ContactPlan   string            `json:"contact_plan"` // ION contact plan file
+	Contacts      []scenarioContact `json:"contacts"`
+	Orbits        *scenarioOrbits   `json:"orbits"`
+	Traffic       []scenarioFlow    `json:"traffic"`
+	StaticRoutes  []StaticRoute     `json:"static_routes"`
+	Routers       []EmulatedRouter  `json:"routers"`
+	RLModel       string            `json:"rl_model"`
+}
+
+type scenarioContact struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Start         string  `json:"start"`
+	End           string  `json:"end"`
+	Rate          int64   `json:"rate"` // bytes/second (0 = unlimited)
+	OWLT          string  `json:"owlt"`
+	Confidence    float64 `json:"confidence"`
+	Bidirectional bool    `json:"bidirectional"`
+}
+
+// scenarioOrbits derives space-ground contacts from TLEs.
+type scenarioOrbits struct {
+	Step           string              `json:"step"`
+	Rate           int64               `json:"rate"` // Overrides the predicted link rate
+	OWLT           string              `json:"owlt"` // Overrides the predicted light time
+	Satellites     []scenarioSatellite `json:"satellites"`
+	GroundStations []GroundStation     `json:"ground_stations"` // ID names the scenario node
+}
+
+type scenarioSatellite struct {
+	Node  string `json:"node"`
+	Line1 string `json:"line1"`
+	Line2 string `json:"line2"`
+}
+
+type scenarioFlow struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Start    string `json:"start"`
+	Interval string `json:"interval"`
+	Count    int    `json:"count"`
+	Size     int    `json:"size"`
+	Priority uint8  `json:"priority"`
+	Lifetime string `json:"lifetime"`
+}
+
+// LoadScenario reads a JSON scenario file. The contact schedule combines
+// an ION contact plan, inline contacts and satellite passes predicted from
+// TLEs. Relative file paths are resolved against the scenario's directory.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+
+	var file scenarioFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse scenario: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	resolvePath := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	s := &Scenario{
+		Name:         file.Name,
+		Start:        file.Start.UTC(),
+		Nodes:        file.Nodes,
+		StaticRoutes: file.StaticRoutes,
+		Routers:      file.Routers,
+		RLModel:      resolvePath(file.RLModel),
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if s.Duration, err = parseScenarioDuration("duration", file.Duration); err != nil {
+		return nil, err
+	}
+	if s.RetryInterval, err = parseScenarioDuration("retry_interval", file.RetryInterval); err != nil {
+		return nil, err
+	}
+
+	if file.ContactPlan != "" {
+		contacts, err := LoadContactPlan(resolvePath(file.ContactPlan), s.Start)
+		if err != nil {
+			return nil, err
+		}
+		s.Contacts = append(s.Contacts, contacts...)
+	}
+
+	for i, sc := range file.Contacts {
+		start, err := parseScenarioDuration(fmt.Sprintf("contacts[%d].start", i), sc.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseScenarioDuration(fmt.Sprintf("contacts[%d].end", i), sc.End)
+		if err != nil {
+			return nil, err
+		}
+		owlt, err := parseScenarioDuration(fmt.Sprintf("contacts[%d].owlt", i), sc.OWLT)
+		if err != nil {
+			return nil, err
+		}
+		c := Contact{
+			From:       sc.From,
+			To:         sc.To,
+			Start:      s.Start.Add(start),
+			End:        s.Start.Add(end),
+			Rate:       sc.Rate,
+			OWLT:       owlt,
+			Confidence: sc.Confidence,
+		}
+		s.Contacts = append(s.Contacts, c)
+		if sc.Bidirectional {
+			c.From, c.To = c.To, c.From
+			s.Contacts = append(s.Contacts, c)
+		}
+	}
+
+	if file.Orbits != nil {
+		contacts, err := orbitContacts(file.Orbits, s)
+		if err != nil {
+			return nil, err
+		}
+		s.Contacts = append(s.Contacts, contacts...)
+	}
+
+	for i, sf := range file.Traffic {
+		flow := TrafficFlow{
+			From:     sf.From,
+			To:       sf.To,
+			Count:    sf.Count,
+			Size:     sf.Size,
+			Priority: sf.Priority,
+		}
+		field := fmt.Sprintf("traffic[%d]", i)
+		if flow.Start, err = parseScenarioDuration(field+".start", sf.Start); err != nil {
+			return nil, err
+		}
+		if flow.Interval, err = parseScenarioDuration(field+".interval", sf.Interval); err != nil {
+			return nil, err
+		}
+		if flow.Lifetime, err = parseScenarioDuration(field+".lifetime", sf.Lifetime); err != nil {
+			return nil, err
+		}
+		s.Traffic = append(s.Traffic, flow)
+	}
+
+	return s, nil
+}
+
+// orbitContacts predicts passes of the scenario's satellites over its
+// ground stations and returns them as a contact plan.
+func orbitContacts(orbits *scenarioOrbits, s *Scenario) ([]Contact, error) {
+	step, err := parseScenarioDuration("orbits.step", orbits.Step)
+	if err != nil {
+		return nil, err
+	}
+	if step <= 0 {
+		step = 30 * time.Second
+	}
+	owlt, err := parseScenarioDuration("orbits.owlt", orbits.OWLT)
+	if err != nil {
+		return nil, err
+	}
+
+	eids := make(map[string]string, len(s.Nodes))
+	for _, node := range s.Nodes {
+		eids[node.ID] = node.EID
+	}
+
+	cfg := ContactPredictorConfig{}
+	tles := make(map[int]*satellite.TLE, len(orbits.Satellites))
+	for _, sat := range orbits.Satellites {
+		eid, ok := eids[sat.Node]
+		if !ok {
+			return nil, fmt.Errorf("orbits: unknown satellite node %s", sat.Node)
+		}
+		if len(sat.Line1) < 7 {
+			return nil, fmt.Errorf("orbits: invalid TLE for %s", sat.Node)
+		}
+		noradID, err := strconv.Atoi(strings.TrimSpace(sat.Line1[2:7]))
+		if err != nil {
+			return nil, fmt.Errorf("orbits: invalid catalog number for %s: %w", sat.Node, err)
+		}
+		if _, dup := tles[noradID]; dup {
+			return nil, fmt.Errorf("orbits: NORAD %d assigned to more than one node", noradID)
+		}
+		tles[noradID] = &satellite.TLE{SatelliteID: noradID, Name: sat.Node, Line1: sat.Line1, Line2: sat.Line2}
+		cfg.Satellites = append(cfg.Satellites, SatelliteNode{NoradID: noradID, Name: sat.Node, EID: eid})
+	}
+	for _, gs := range orbits.GroundStations {
+		eid, ok := eids[gs.ID]
+		if !ok {
+			return nil, fmt.Errorf("orbits: unknown ground station node %s", gs.ID)
+		}
+		gs.EID = eid
+		gs.Name = gs.ID // Passes are matched to stations by name
+		cfg.GroundStations = append(cfg.GroundStations, gs)
+	}
+
+	predictor := NewContactPredictor(cfg)
+	for _, sat := range cfg.Satellites {
+		if err := predictor.LoadTLE(sat.NoradID, tles[sat.NoradID]); err != nil {
+			return nil, fmt.Errorf("orbits: %w", err)
+		}
+	}
+
+	contacts := predictor.ContactPlanFrom(context.Background(), s.Start, s.Duration, step)
+	for i := range contacts {
+		if orbits.Rate > 0 {
+			contacts[i].Rate = orbits.Rate
+		}
+		if owlt > 0 {
+			contacts[i].OWLT = owlt
+		}
+	}
+	return contacts, nil
+}
+
+// parseScenarioDuration parses an optional duration field.
+func parseScenarioDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("scenario %s: %w", field, err)
+	}
+	return d, nil
+}
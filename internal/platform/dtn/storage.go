@@ -23,6 +23,11 @@ const (
 	StatusDelivered BundleStatus = "delivered"  // Successfully delivered
 	StatusFailed    BundleStatus = "failed"     // Delivery failed
 	StatusExpired   BundleStatus = "expired"    // TTL exceeded
+
+	StatusDeferred      BundleStatus = "deferred"      // Waiting for the next contact window
+	StatusReassembling  BundleStatus = "reassembling"  // Fragment held until its ADU is complete
+	StatusQuarantined   BundleStatus = "quarantined"   // Failed security checks, held for inspection
+	StatusUndeliverable BundleStatus = "undeliverable" // Failed MaxRetries times; no longer retried
 )
 
 // BundleStorage defines the interface for bundle persistence.
@@ -53,6 +58,7 @@ type storedBundle struct {
 	bundle   *bundle.Bundle
 	status   BundleStatus
 	storedAt time.Time
+	seq      uint64 // Store order, breaks ties between equal storedAt
 }
 
 // InMemoryStorage provides an in-memory bundle store.
@@ -61,6 +67,8 @@ type InMemoryStorage struct {
 	mu      sync.RWMutex
 	bundles map[uuid.UUID]*storedBundle
 	maxSize int
+	seq     uint64
+	clock   Clock
 }
 
 // NewInMemoryStorage creates a new in-memory storage with optional max capacity.
@@ -71,9 +79,17 @@ func NewInMemoryStorage(maxSize int) *InMemoryStorage {
 	return &InMemoryStorage{
 		bundles: make(map[uuid.UUID]*storedBundle),
 		maxSize: maxSize,
+		clock:   SystemClock,
 	}
 }
 
+// SetClock replaces the time source used for expiry and bundle ages.
+func (s *InMemoryStorage) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
 // Store persists a bundle to storage.
 func (s *InMemoryStorage) Store(ctx context.Context, b *bundle.Bundle) error {
 	select {
@@ -82,13 +98,13 @@ func (s *InMemoryStorage) Store(ctx context.Context, b *bundle.Bundle) error {
 	default:
 	}
 
-	if err := b.Validate(); err != nil {
-		return fmt.Errorf("invalid bundle: %w", err)
-	}
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := b.ValidateAt(s.clock.Now()); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+
 	// Check capacity
 	if len(s.bundles) >= s.maxSize {
 		// Evict expired bundles first
@@ -100,10 +116,12 @@ func (s *InMemoryStorage) Store(ctx context.Context, b *bundle.Bundle) error {
 		}
 	}
 
+	s.seq++
 	s.bundles[b.ID] = &storedBundle{
 		bundle:   b.Clone(),
 		status:   StatusPending,
-		storedAt: time.Now().UTC(),
+		storedAt: s.clock.Now().UTC(),
+		seq:      s.seq,
 	}
 
 	return nil
@@ -170,11 +188,14 @@ func (s *InMemoryStorage) List(ctx context.Context, filter BundleFilter) ([]*bun
 	switch filter.OrderBy {
 	case "priority":
 		sort.Slice(results, func(i, j int) bool {
-			return results[i].bundle.Priority > results[j].bundle.Priority
+			if results[i].bundle.Priority != results[j].bundle.Priority {
+				return results[i].bundle.Priority > results[j].bundle.Priority
+			}
+			return storedBefore(results[i], results[j])
 		})
 	case "age":
 		sort.Slice(results, func(i, j int) bool {
-			return results[i].storedAt.Before(results[j].storedAt)
+			return storedBefore(results[i], results[j])
 		})
 	case "size":
 		sort.Slice(results, func(i, j int) bool {
@@ -186,7 +207,7 @@ func (s *InMemoryStorage) List(ctx context.Context, filter BundleFilter) ([]*bun
 			if results[i].bundle.Priority != results[j].bundle.Priority {
 				return results[i].bundle.Priority > results[j].bundle.Priority
 			}
-			return results[i].storedAt.Before(results[j].storedAt)
+			return storedBefore(results[i], results[j])
 		})
 	}
 
@@ -278,17 +299,26 @@ func (s *InMemoryStorage) matchesFilter(stored *storedBundle, filter BundleFilte
 	if stored.bundle.Priority < filter.MinPriority {
 		return false
 	}
-	if filter.MaxAge > 0 && time.Since(stored.storedAt) > filter.MaxAge {
+	if filter.MaxAge > 0 && s.clock.Now().Sub(stored.storedAt) > filter.MaxAge {
 		return false
 	}
 	return true
 }
 
+// storedBefore orders bundles by the time they were stored.
+func storedBefore(a, b *storedBundle) bool {
+	if !a.storedAt.Equal(b.storedAt) {
+		return a.storedAt.Before(b.storedAt)
+	}
+	return a.seq < b.seq
+}
+
 // evictExpiredLocked removes expired bundles (caller must hold lock).
 func (s *InMemoryStorage) evictExpiredLocked() int {
 	count := 0
+	now := s.clock.Now()
 	for id, stored := range s.bundles {
-		if stored.bundle.IsExpired() {
+		if stored.bundle.IsExpiredAt(now) {
 			delete(s.bundles, id)
 			count++
 		}
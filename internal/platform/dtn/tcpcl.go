@@ -0,0 +1,1161 @@
+package dtn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// TCPCLv4 protocol constants (RFC 9174).
+const (
+	tcpclMagic   = "dtn!"
+	tcpclVersion = 4
+
+	tcpclFlagCanTLS = 0x01
+
+	tcpclMsgXferSegment = 0x01
+	tcpclMsgXferAck     = 0x02
+	tcpclMsgXferRefuse  = 0x03
+	tcpclMsgKeepalive   = 0x04
+	tcpclMsgSessTerm    = 0x05
+	tcpclMsgReject      = 0x06
+	tcpclMsgSessInit    = 0x07
+
+	tcpclSegmentEnd   = 0x01
+	tcpclSegmentStart = 0x02
+
+	tcpclSessTermReply = 0x01
+
+	tcpclExtFlagCritical     = 0x01
+	tcpclXferExtTransferLen  = 0x0001
+	tcpclRejectTypeUnknown   = 0x01
+	tcpclRejectUnexpected    = 0x03
+	tcpclMaxExtensionsLength = 64 * 1024
+)
+
+// TCPCLRefuseReason is the reason code carried by XFER_REFUSE.
+type TCPCLRefuseReason uint8
+
+const (
+	RefuseUnknown       TCPCLRefuseReason = 0x00
+	RefuseCompleted     TCPCLRefuseReason = 0x01
+	RefuseNoResources   TCPCLRefuseReason = 0x02
+	RefuseRetransmit    TCPCLRefuseReason = 0x03
+	RefuseNotAcceptable TCPCLRefuseReason = 0x04
+	RefuseExtensionFail TCPCLRefuseReason = 0x05
+	RefuseSessionTerm   TCPCLRefuseReason = 0x06
+)
+
+// TCPCLTermReason is the reason code carried by SESS_TERM.
+type TCPCLTermReason uint8
+
+const (
+	TermUnknown            TCPCLTermReason = 0x00
+	TermIdleTimeout        TCPCLTermReason = 0x01
+	TermVersionMismatch    TCPCLTermReason = 0x02
+	TermBusy               TCPCLTermReason = 0x03
+	TermContactFailure     TCPCLTermReason = 0x04
+	TermResourceExhaustion TCPCLTermReason = 0x05
+)
+
+// ErrTransferRefused is returned by Send when the peer refuses a transfer.
+var ErrTransferRefused = errors.New("tcpcl: transfer refused")
+
+// TCPCLConfig holds configuration for the TCPCLv4 transport.
+type TCPCLConfig struct {
+	ListenAddress     string        // Address to listen on (IANA port 4556)
+	ConnectTimeout    time.Duration // Timeout for TCP connect and session setup
+	KeepaliveInterval time.Duration // Proposed keepalive interval (0 disables)
+	TransferTimeout   time.Duration // Maximum time to wait for a transfer to be acknowledged
+	SegmentMRU        uint64        // Largest segment we accept
+	TransferMRU       uint64        // Largest bundle we accept
+	MaxSegmentSize    uint64        // Largest segment we send (further limited by peer MRU)
+	MaxInboundXfers   int           // Concurrent inbound transfers per session (0 = unlimited)
+	TLSConfig         *tls.Config   // Enables CAN_TLS when set
+	RequireTLS        bool          // Terminate sessions that do not negotiate TLS
+	Codec             bundle.Codec  // Bundle encoding (RFC 9171 CBOR by default)
+}
+
+// DefaultTCPCLConfig returns sensible defaults for the TCPCLv4 transport.
+func DefaultTCPCLConfig() TCPCLConfig {
+	return TCPCLConfig{
+		ListenAddress:     ":4556",
+		ConnectTimeout:    30 * time.Second,
+		KeepaliveInterval: 30 * time.Second,
+		TransferTimeout:   5 * time.Minute,
+		SegmentMRU:        1024 * 1024,       // 1MB
+		TransferMRU:       100 * 1024 * 1024, // 100MB
+		MaxSegmentSize:    64 * 1024,
+		MaxInboundXfers:   16,
+		Codec:             bundle.CBOR,
+	}
+}
+
+// TCPCLTransport implements TransportAdapter using the TCP Convergence
+// Layer Protocol version 4 (RFC 9174).
+type TCPCLTransport struct {
+	config      TCPCLConfig
+	localNodeID string
+
+	sessions    map[string]*tcpclSession // neighbor ID -> session used for sending
+	allSessions map[*tcpclSession]struct{}
+	bindings    map[string]string // peer node EID -> neighbor ID
+	mu          sync.RWMutex
+
+	peerHandler func(PeerInfo)
+	handlerMu   sync.RWMutex
+
+	listener    net.Listener
+	receiveChan chan *bundle.Bundle
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// tcpclSession is an established TCPCLv4 session with one peer.
+type tcpclSession struct {
+	transport  *TCPCLTransport
+	neighborID string
+	peerNodeID string
+	address    string
+	incoming   bool
+	conn       net.Conn
+	reader     *bufio.Reader
+
+	keepalive       time.Duration
+	peerSegmentMRU  uint64
+	peerTransferMRU uint64
+
+	writeMu sync.Mutex
+
+	stateMu        sync.Mutex
+	active         bool
+	nextTransferID uint64
+	pending        map[uint64]*tcpclTransfer
+	inbound        map[uint64]*bytes.Buffer
+	refused        map[uint64]struct{} // Refused transfers whose last segment is still to come
+	closed         chan struct{}
+	closeOnce      sync.Once
+}
+
+// NewTCPCLTransport creates a TCPCLv4 transport that advertises localNodeEID
+// as its node ID.
+func NewTCPCLTransport(localNodeEID string, config TCPCLConfig) *TCPCLTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	if config.Codec == nil {
+		config.Codec = bundle.CBOR
+	}
+
+	return &TCPCLTransport{
+		config:      config,
+		localNodeID: localNodeEID,
+		sessions:    make(map[string]*tcpclSession),
+		allSessions: make(map[*tcpclSession]struct{}),
+		bindings:    make(map[string]string),
+		receiveChan: make(chan *bundle.Bundle, 1000),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetPeerHandler registers a callback for session up/down events.
+func (t *TCPCLTransport) SetPeerHandler(handler func(PeerInfo)) {
+	t.handlerMu.Lock()
+	defer t.handlerMu.Unlock()
+	t.peerHandler = handler
+}
+
+// BindPeer maps a peer node EID to a local neighbor ID.
+func (t *TCPCLTransport) BindPeer(nodeEID, neighborID string) {
+	if nodeEID == "" || neighborID == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bindings[nodeEID] = neighborID
+}
+
+// Start begins listening for incoming TCPCL sessions.
+func (t *TCPCLTransport) Start(ctx context.Context) error {
+	var err error
+	t.listener, err = net.Listen("tcp", t.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to start TCPCL listener: %w", err)
+	}
+
+	log.Printf("[TCPCL] Listening on %s as %s", t.listener.Addr(), t.localNodeID)
+
+	t.wg.Add(1)
+	go t.acceptLoop()
+
+	return nil
+}
+
+// Addr returns the listener address, or nil before Start.
+func (t *TCPCLTransport) Addr() net.Addr {
+	if t.listener == nil {
+		return nil
+	}
+	return t.listener.Addr()
+}
+
+// Stop terminates all sessions and shuts down the transport.
+func (t *TCPCLTransport) Stop() error {
+	log.Printf("[TCPCL] Shutting down")
+	t.cancel()
+
+	if t.listener != nil {
+		t.listener.Close()
+	}
+
+	t.mu.Lock()
+	sessions := make([]*tcpclSession, 0, len(t.allSessions))
+	for s := range t.allSessions {
+		sessions = append(sessions, s)
+	}
+	t.mu.Unlock()
+
+	for _, s := range sessions {
+		s.terminate(TermUnknown)
+	}
+
+	t.wg.Wait()
+	close(t.receiveChan)
+
+	return nil
+}
+
+// Connect establishes a TCPCL session with a neighbor.
+func (t *TCPCLTransport) Connect(ctx context.Context, neighborID string, address string) error {
+	if t.IsConnected(neighborID) {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: t.config.ConnectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s at %s: %w", neighborID, address, err)
+	}
+
+	s, err := t.establish(conn, neighborID, false)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("session setup with %s failed: %w", neighborID, err)
+	}
+
+	log.Printf("[TCPCL] Session established with %s (node %s) at %s", s.neighborID, s.peerNodeID, address)
+	return nil
+}
+
+// Disconnect terminates the session with a neighbor.
+func (t *TCPCLTransport) Disconnect(neighborID string) error {
+	t.mu.RLock()
+	s, ok := t.sessions[neighborID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+
+	s.terminate(TermUnknown)
+	log.Printf("[TCPCL] Disconnected from neighbor %s", neighborID)
+	return nil
+}
+
+// IsConnected returns true if an active session exists for the neighbor.
+func (t *TCPCLTransport) IsConnected(neighborID string) bool {
+	t.mu.RLock()
+	s, ok := t.sessions[neighborID]
+	t.mu.RUnlock()
+
+	return ok && s.isActive()
+}
+
+// Receive returns the channel for incoming bundles.
+func (t *TCPCLTransport) Receive() <-chan *bundle.Bundle {
+	return t.receiveChan
+}
+
+// Send transfers a bundle to a neighbor and waits for the final XFER_ACK.
+func (t *TCPCLTransport) Send(ctx context.Context, neighborID string, b *bundle.Bundle) error {
+	t.mu.RLock()
+	s, ok := t.sessions[neighborID]
+	t.mu.RUnlock()
+
+	if !ok || !s.isActive() {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+
+	data, err := t.config.Codec.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to serialize bundle: %w", err)
+	}
+	if s.peerTransferMRU > 0 && uint64(len(data)) > s.peerTransferMRU {
+		return fmt.Errorf("bundle size %d exceeds peer transfer MRU %d", len(data), s.peerTransferMRU)
+	}
+
+	if err := s.transfer(ctx, data, t.config.TransferTimeout); err != nil {
+		var partial *PartialTransferError
+		if errors.As(err, &partial) {
+			partial.NeighborID = neighborID
+			partial.PayloadOffset = -1
+			if offset, length, perr := bundle.PayloadOffset(data); perr == nil {
+				partial.PayloadOffset = offset
+				partial.PayloadLength = length
+			}
+		}
+		return err
+	}
+
+	log.Printf("[TCPCL] Sent bundle %s to %s (%d bytes)", b.ID.String()[:8], neighborID, len(data))
+	return nil
+}
+
+// LinkMTU implements LinkMTUProvider using the peer's transfer MRU.
+func (t *TCPCLTransport) LinkMTU(neighborID string) int {
+	t.mu.RLock()
+	s, ok := t.sessions[neighborID]
+	t.mu.RUnlock()
+
+	if !ok || s.peerTransferMRU == 0 || s.peerTransferMRU > uint64(math.MaxInt32) {
+		return 0
+	}
+	return int(s.peerTransferMRU)
+}
+
+// SessionInfo returns peer details for a neighbor's session.
+func (t *TCPCLTransport) SessionInfo(neighborID string) (PeerInfo, bool) {
+	t.mu.RLock()
+	s, ok := t.sessions[neighborID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return PeerInfo{}, false
+	}
+	return s.peerInfo(s.isActive()), true
+}
+
+// acceptLoop accepts inbound TCP connections and runs the passive handshake.
+func (t *TCPCLTransport) acceptLoop() {
+	defer t.wg.Done()
+
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) || t.ctx.Err() != nil {
+				return
+			}
+			log.Printf("[TCPCL] Accept error: %v", err)
+			continue
+		}
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			if _, err := t.establish(conn, "", true); err != nil {
+				log.Printf("[TCPCL] Session setup with %s failed: %v", conn.RemoteAddr(), err)
+				conn.Close()
+			}
+		}()
+	}
+}
+
+// establish runs contact header exchange, optional TLS, and SESS_INIT
+// negotiation, then registers and starts the session.
+func (t *TCPCLTransport) establish(conn net.Conn, neighborID string, incoming bool) (*tcpclSession, error) {
+	if t.config.ConnectTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(t.config.ConnectTimeout))
+	}
+
+	var localFlags byte
+	if t.config.TLSConfig != nil {
+		localFlags |= tcpclFlagCanTLS
+	}
+
+	// Contact header: active entity sends first.
+	var peerFlags byte
+	var err error
+	if incoming {
+		if peerFlags, err = readContactHeader(conn); err != nil {
+			if errors.Is(err, errTCPCLVersion) {
+				writeContactHeader(conn, localFlags)
+				writeSessTerm(conn, 0, TermVersionMismatch)
+			}
+			return nil, err
+		}
+		if err := writeContactHeader(conn, localFlags); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := writeContactHeader(conn, localFlags); err != nil {
+			return nil, err
+		}
+		if peerFlags, err = readContactHeader(conn); err != nil {
+			return nil, err
+		}
+	}
+
+	useTLS := localFlags&tcpclFlagCanTLS != 0 && peerFlags&tcpclFlagCanTLS != 0
+	if useTLS {
+		var tlsConn *tls.Conn
+		if incoming {
+			tlsConn = tls.Server(conn, t.config.TLSConfig)
+		} else {
+			cfg := t.config.TLSConfig
+			if cfg.ServerName == "" && !cfg.InsecureSkipVerify {
+				cfg = cfg.Clone()
+				cfg.ServerName, _, _ = net.SplitHostPort(conn.RemoteAddr().String())
+			}
+			tlsConn = tls.Client(conn, cfg)
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake: %w", err)
+		}
+		conn = tlsConn
+	} else if t.config.RequireTLS {
+		writeSessTerm(conn, 0, TermContactFailure)
+		return nil, fmt.Errorf("peer did not negotiate TLS")
+	}
+
+	reader := bufio.NewReader(conn)
+	localInit := sessInit{
+		keepalive:   uint16(t.config.KeepaliveInterval / time.Second),
+		segmentMRU:  t.config.SegmentMRU,
+		transferMRU: t.config.TransferMRU,
+		nodeID:      t.localNodeID,
+	}
+
+	// SESS_INIT: active entity sends first, passive replies.
+	var peerInit sessInit
+	if incoming {
+		if peerInit, err = readSessInit(reader); err != nil {
+			return nil, err
+		}
+		if err := writeSessInit(conn, localInit); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := writeSessInit(conn, localInit); err != nil {
+			return nil, err
+		}
+		if peerInit, err = readSessInit(reader); err != nil {
+			return nil, err
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	keepalive := localInit.keepalive
+	if peerInit.keepalive < keepalive {
+		keepalive = peerInit.keepalive
+	}
+
+	t.mu.Lock()
+	if incoming {
+		neighborID = peerInit.nodeID
+		if bound, ok := t.bindings[peerInit.nodeID]; ok {
+			neighborID = bound
+		}
+	} else {
+		t.bindings[peerInit.nodeID] = neighborID
+	}
+
+	s := &tcpclSession{
+		transport:       t,
+		neighborID:      neighborID,
+		peerNodeID:      peerInit.nodeID,
+		address:         conn.RemoteAddr().String(),
+		incoming:        incoming,
+		conn:            conn,
+		reader:          reader,
+		keepalive:       time.Duration(keepalive) * time.Second,
+		peerSegmentMRU:  peerInit.segmentMRU,
+		peerTransferMRU: peerInit.transferMRU,
+		active:          true,
+		pending:         make(map[uint64]*tcpclTransfer),
+		inbound:         make(map[uint64]*bytes.Buffer),
+		refused:         make(map[uint64]struct{}),
+		closed:          make(chan struct{}),
+	}
+
+	// Keep an existing active session for sending; a simultaneous session
+	// in the other direction still delivers inbound bundles.
+	primary := true
+	if existing, ok := t.sessions[neighborID]; ok && existing.isActive() {
+		primary = false
+	} else {
+		t.sessions[neighborID] = s
+	}
+	t.allSessions[s] = struct{}{}
+	t.mu.Unlock()
+
+	t.wg.Add(1)
+	go s.readLoop()
+	if s.keepalive > 0 {
+		t.wg.Add(1)
+		go s.keepaliveLoop()
+	}
+
+	if primary {
+		t.notifyPeer(s.peerInfo(true))
+	}
+
+	return s, nil
+}
+
+// removeSession unregisters a closed session.
+func (t *TCPCLTransport) removeSession(s *tcpclSession) {
+	t.mu.Lock()
+	delete(t.allSessions, s)
+	wasPrimary := t.sessions[s.neighborID] == s
+	if wasPrimary {
+		delete(t.sessions, s.neighborID)
+	}
+	t.mu.Unlock()
+
+	if wasPrimary && t.ctx.Err() == nil {
+		t.notifyPeer(s.peerInfo(false))
+	}
+}
+
+func (t *TCPCLTransport) notifyPeer(info PeerInfo) {
+	t.handlerMu.RLock()
+	handler := t.peerHandler
+	t.handlerMu.RUnlock()
+
+	if handler != nil {
+		handler(info)
+	}
+}
+
+func (s *tcpclSession) peerInfo(connected bool) PeerInfo {
+	return PeerInfo{
+		NeighborID: s.neighborID,
+		NodeEID:    s.peerNodeID,
+		Address:    s.address,
+		Incoming:   s.incoming,
+		Connected:  connected,
+	}
+}
+
+func (s *tcpclSession) isActive() bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.active
+}
+
+// tcpclTransfer tracks an outbound transfer awaiting acknowledgement.
+type tcpclTransfer struct {
+	done  chan error
+	acked uint64
+}
+
+// transfer segments data into XFER_SEGMENT messages and waits for the final ack.
+func (s *tcpclSession) transfer(ctx context.Context, data []byte, timeout time.Duration) error {
+	segmentSize := s.transport.config.MaxSegmentSize
+	if s.peerSegmentMRU > 0 && (segmentSize == 0 || s.peerSegmentMRU < segmentSize) {
+		segmentSize = s.peerSegmentMRU
+	}
+	if segmentSize == 0 {
+		segmentSize = uint64(len(data))
+	}
+
+	s.stateMu.Lock()
+	if !s.active {
+		s.stateMu.Unlock()
+		return fmt.Errorf("session with %s is not active", s.neighborID)
+	}
+	transferID := s.nextTransferID
+	s.nextTransferID++
+	xfer := &tcpclTransfer{done: make(chan error, 1)}
+	done := xfer.done
+	s.pending[transferID] = xfer
+	s.stateMu.Unlock()
+
+	defer func() {
+		s.stateMu.Lock()
+		delete(s.pending, transferID)
+		s.stateMu.Unlock()
+	}()
+
+	total := uint64(len(data))
+	for offset := uint64(0); offset < total || offset == 0; {
+		end := offset + segmentSize
+		if end > total {
+			end = total
+		}
+
+		var flags byte
+		if offset == 0 {
+			flags |= tcpclSegmentStart
+		}
+		if end == total {
+			flags |= tcpclSegmentEnd
+		}
+
+		if err := s.writeSegment(flags, transferID, total, data[offset:end]); err != nil {
+			s.terminate(TermContactFailure)
+			return s.interrupted(xfer, total, fmt.Errorf("failed to send segment: %w", err))
+		}
+
+		select {
+		case err := <-done:
+			// Peer refused mid-transfer or the session closed
+			if err != nil && !s.isActive() {
+				return s.interrupted(xfer, total, err)
+			}
+			return err
+		default:
+		}
+
+		if end == total {
+			break
+		}
+		offset = end
+	}
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		tm := time.NewTimer(timeout)
+		defer tm.Stop()
+		timer = tm.C
+	}
+
+	select {
+	case err := <-done:
+		if err != nil && !s.isActive() {
+			return s.interrupted(xfer, total, err)
+		}
+		return err
+	case <-s.closed:
+		return s.interrupted(xfer, total, fmt.Errorf("session with %s terminated before transfer %d completed", s.neighborID, transferID))
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer:
+		return fmt.Errorf("transfer %d to %s not acknowledged within %v", transferID, s.neighborID, timeout)
+	}
+}
+
+// interrupted wraps err in a PartialTransferError when the peer acknowledged
+// part of the transfer before the session failed.
+func (s *tcpclSession) interrupted(xfer *tcpclTransfer, total uint64, err error) error {
+	s.stateMu.Lock()
+	acked := xfer.acked
+	s.stateMu.Unlock()
+
+	if acked == 0 || acked >= total {
+		return err
+	}
+	return &PartialTransferError{Acked: acked, Total: total, Err: err}
+}
+
+func (s *tcpclSession) writeSegment(flags byte, transferID, totalLength uint64, payload []byte) error {
+	var buf bytes.Buffer
+	buf.WriteByte(tcpclMsgXferSegment)
+	buf.WriteByte(flags)
+	binary.Write(&buf, binary.BigEndian, transferID)
+	if flags&tcpclSegmentStart != 0 {
+		// Transfer Length extension item (RFC 9174 Section 4.2.1)
+		var ext bytes.Buffer
+		ext.WriteByte(0)
+		binary.Write(&ext, binary.BigEndian, uint16(tcpclXferExtTransferLen))
+		binary.Write(&ext, binary.BigEndian, uint16(8))
+		binary.Write(&ext, binary.BigEndian, totalLength)
+		binary.Write(&buf, binary.BigEndian, uint32(ext.Len()))
+		buf.Write(ext.Bytes())
+	}
+	binary.Write(&buf, binary.BigEndian, uint64(len(payload)))
+	buf.Write(payload)
+
+	return s.write(buf.Bytes())
+}
+
+func (s *tcpclSession) write(msg []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if s.transport.config.TransferTimeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.transport.config.TransferTimeout))
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// terminate sends SESS_TERM (best effort) and closes the session.
+func (s *tcpclSession) terminate(reason TCPCLTermReason) {
+	if s.isActive() {
+		s.writeMu.Lock()
+		s.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		writeSessTerm(s.conn, 0, reason)
+		s.writeMu.Unlock()
+	}
+	s.close()
+}
+
+func (s *tcpclSession) close() {
+	s.closeOnce.Do(func() {
+		s.stateMu.Lock()
+		s.active = false
+		for id, xfer := range s.pending {
+			select {
+			case xfer.done <- fmt.Errorf("%w: session terminating", ErrTransferRefused):
+			default:
+			}
+			delete(s.pending, id)
+		}
+		partial := s.inbound
+		s.inbound = make(map[uint64]*bytes.Buffer)
+		s.stateMu.Unlock()
+
+		s.deliverPartial(partial)
+
+		close(s.closed)
+		s.conn.Close()
+		s.transport.removeSession(s)
+	})
+}
+
+// keepaliveLoop sends KEEPALIVE messages at the negotiated interval.
+func (s *tcpclSession) keepaliveLoop() {
+	defer s.transport.wg.Done()
+
+	ticker := time.NewTicker(s.keepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-s.transport.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write([]byte{tcpclMsgKeepalive}); err != nil {
+				s.close()
+				return
+			}
+		}
+	}
+}
+
+// readLoop processes messages received on an established session.
+func (s *tcpclSession) readLoop() {
+	defer s.transport.wg.Done()
+	defer s.close()
+
+	for {
+		if s.keepalive > 0 {
+			// RFC 9174 Section 5.1.1: idle after twice the keepalive interval
+			s.conn.SetReadDeadline(time.Now().Add(2 * s.keepalive))
+		}
+
+		msgType, err := s.reader.ReadByte()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Printf("[TCPCL] Session with %s idle, terminating", s.neighborID)
+				s.terminate(TermIdleTimeout)
+				return
+			}
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && s.isActive() {
+				log.Printf("[TCPCL] Read error from %s: %v", s.neighborID, err)
+			}
+			return
+		}
+
+		switch msgType {
+		case tcpclMsgXferSegment:
+			err = s.handleSegment()
+		case tcpclMsgXferAck:
+			err = s.handleAck()
+		case tcpclMsgXferRefuse:
+			err = s.handleRefuse()
+		case tcpclMsgKeepalive:
+		case tcpclMsgSessTerm:
+			s.handleSessTerm()
+			return
+		case tcpclMsgReject:
+			var body [2]byte
+			if _, err = io.ReadFull(s.reader, body[:]); err == nil {
+				log.Printf("[TCPCL] %s rejected message 0x%02x (reason 0x%02x)", s.neighborID, body[1], body[0])
+			}
+		case tcpclMsgSessInit:
+			s.write([]byte{tcpclMsgReject, tcpclRejectUnexpected, msgType})
+			s.terminate(TermUnknown)
+			return
+		default:
+			s.write([]byte{tcpclMsgReject, tcpclRejectTypeUnknown, msgType})
+			s.terminate(TermUnknown)
+			return
+		}
+
+		if err != nil {
+			log.Printf("[TCPCL] Protocol error from %s: %v", s.neighborID, err)
+			s.terminate(TermUnknown)
+			return
+		}
+	}
+}
+
+func (s *tcpclSession) handleSegment() error {
+	var hdr struct {
+		Flags      uint8
+		TransferID uint64
+	}
+	if err := binary.Read(s.reader, binary.BigEndian, &hdr); err != nil {
+		return err
+	}
+
+	var declaredLength uint64
+	unsupported := false
+	if hdr.Flags&tcpclSegmentStart != 0 {
+		exts, err := readExtensions(s.reader)
+		if err != nil {
+			return err
+		}
+		for _, ext := range exts {
+			switch {
+			case ext.itemType == tcpclXferExtTransferLen && len(ext.value) == 8:
+				declaredLength = binary.BigEndian.Uint64(ext.value)
+			case ext.flags&tcpclExtFlagCritical != 0:
+				unsupported = true
+			}
+		}
+	}
+
+	// Always consume the segment data, even for a transfer about to be
+	// refused, so the next read starts at a message boundary.
+	var dataLen uint64
+	if err := binary.Read(s.reader, binary.BigEndian, &dataLen); err != nil {
+		return err
+	}
+	if s.transport.config.SegmentMRU > 0 && dataLen > s.transport.config.SegmentMRU {
+		return fmt.Errorf("segment of %d bytes exceeds MRU %d", dataLen, s.transport.config.SegmentMRU)
+	}
+	data := make([]byte, dataLen)
+	if _, err := io.ReadFull(s.reader, data); err != nil {
+		return err
+	}
+
+	mru := s.transport.config.TransferMRU
+	end := hdr.Flags&tcpclSegmentEnd != 0
+
+	s.stateMu.Lock()
+	if _, ok := s.refused[hdr.TransferID]; ok {
+		// Later segment of a refused transfer the peer had already sent.
+		if end {
+			delete(s.refused, hdr.TransferID)
+		}
+		s.stateMu.Unlock()
+		return nil
+	}
+	var reason TCPCLRefuseReason
+	buf, ok := s.inbound[hdr.TransferID]
+	if hdr.Flags&tcpclSegmentStart != 0 {
+		limit := s.transport.config.MaxInboundXfers
+		switch {
+		case unsupported:
+			reason = RefuseExtensionFail
+		case limit > 0 && !ok && len(s.inbound) >= limit:
+			reason = RefuseNoResources
+		default:
+			buf = &bytes.Buffer{}
+			s.inbound[hdr.TransferID] = buf
+			ok = true
+		}
+	}
+	if ok && mru > 0 && (declaredLength > mru || uint64(buf.Len())+dataLen > mru) {
+		reason = RefuseNoResources
+	}
+	if reason != RefuseUnknown {
+		delete(s.inbound, hdr.TransferID)
+		if !end {
+			s.refused[hdr.TransferID] = struct{}{}
+		}
+		s.stateMu.Unlock()
+		return s.refuse(hdr.TransferID, reason)
+	}
+	if !ok {
+		// Segment for an unknown transfer; ignore it.
+		s.stateMu.Unlock()
+		return nil
+	}
+	buf.Write(data)
+	received := uint64(buf.Len())
+	if end {
+		delete(s.inbound, hdr.TransferID)
+	}
+	s.stateMu.Unlock()
+
+	var ack bytes.Buffer
+	ack.WriteByte(tcpclMsgXferAck)
+	ack.WriteByte(hdr.Flags)
+	binary.Write(&ack, binary.BigEndian, hdr.TransferID)
+	binary.Write(&ack, binary.BigEndian, received)
+	if err := s.write(ack.Bytes()); err != nil {
+		return err
+	}
+
+	if !end {
+		return nil
+	}
+
+	b, err := bundle.Unmarshal(buf.Bytes())
+	if err != nil {
+		log.Printf("[TCPCL] Failed to decode bundle from %s: %v", s.neighborID, err)
+		return nil
+	}
+
+	log.Printf("[TCPCL] Received bundle %s from %s (%d bytes)", b.ID.String()[:8], s.neighborID, received)
+
+	select {
+	case s.transport.receiveChan <- b:
+	case <-s.transport.ctx.Done():
+	default:
+		log.Printf("[TCPCL] Receive buffer full, dropping bundle %s", b.ID.String()[:8])
+	}
+	return nil
+}
+
+// deliverPartial turns transfers cut off by session loss into fragments
+// (reactive fragmentation, RFC 9171 Section 5.8) so acknowledged payload
+// bytes are not lost.
+func (s *tcpclSession) deliverPartial(inbound map[uint64]*bytes.Buffer) {
+	if s.transport.ctx.Err() != nil {
+		return
+	}
+	for _, buf := range inbound {
+		if buf.Len() == 0 {
+			continue
+		}
+		b, err := bundle.UnmarshalPartial(buf.Bytes())
+		if err != nil {
+			continue
+		}
+
+		log.Printf("[TCPCL] Recovered %d payload bytes of interrupted bundle from %s", len(b.Payload), s.neighborID)
+
+		select {
+		case s.transport.receiveChan <- b:
+		default:
+			log.Printf("[TCPCL] Receive buffer full, dropping fragment %s", b.ID.String()[:8])
+		}
+	}
+}
+
+func (s *tcpclSession) refuse(transferID uint64, reason TCPCLRefuseReason) error {
+	var msg bytes.Buffer
+	msg.WriteByte(tcpclMsgXferRefuse)
+	msg.WriteByte(byte(reason))
+	binary.Write(&msg, binary.BigEndian, transferID)
+	return s.write(msg.Bytes())
+}
+
+func (s *tcpclSession) handleAck() error {
+	var ack struct {
+		Flags      uint8
+		TransferID uint64
+		Length     uint64
+	}
+	if err := binary.Read(s.reader, binary.BigEndian, &ack); err != nil {
+		return err
+	}
+
+	s.stateMu.Lock()
+	if xfer, ok := s.pending[ack.TransferID]; ok && ack.Length > xfer.acked {
+		xfer.acked = ack.Length
+	}
+	s.stateMu.Unlock()
+
+	if ack.Flags&tcpclSegmentEnd != 0 {
+		s.completeTransfer(ack.TransferID, nil)
+	}
+	return nil
+}
+
+func (s *tcpclSession) handleRefuse() error {
+	var refuse struct {
+		Reason     uint8
+		TransferID uint64
+	}
+	if err := binary.Read(s.reader, binary.BigEndian, &refuse); err != nil {
+		return err
+	}
+
+	var err error
+	if TCPCLRefuseReason(refuse.Reason) != RefuseCompleted {
+		err = fmt.Errorf("%w by %s (reason 0x%02x)", ErrTransferRefused, s.neighborID, refuse.Reason)
+	}
+	s.completeTransfer(refuse.TransferID, err)
+	return nil
+}
+
+func (s *tcpclSession) completeTransfer(transferID uint64, err error) {
+	s.stateMu.Lock()
+	xfer, ok := s.pending[transferID]
+	s.stateMu.Unlock()
+
+	if ok {
+		select {
+		case xfer.done <- err:
+		default:
+		}
+	}
+}
+
+func (s *tcpclSession) handleSessTerm() {
+	var term [2]byte
+	if _, err := io.ReadFull(s.reader, term[:]); err != nil {
+		return
+	}
+
+	log.Printf("[TCPCL] Session terminated by %s (reason 0x%02x)", s.neighborID, term[1])
+
+	if term[0]&tcpclSessTermReply == 0 {
+		s.writeMu.Lock()
+		writeSessTerm(s.conn, tcpclSessTermReply, TCPCLTermReason(term[1]))
+		s.writeMu.Unlock()
+	}
+	s.close()
+}
+
+var errTCPCLVersion = errors.New("tcpcl: unsupported protocol version")
+
+func writeContactHeader(w io.Writer, flags byte) error {
+	_, err := w.Write([]byte{'d', 't', 'n', '!', tcpclVersion, flags})
+	return err
+}
+
+func readContactHeader(r io.Reader) (byte, error) {
+	var hdr [6]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, fmt.Errorf("read contact header: %w", err)
+	}
+	if string(hdr[:4]) != tcpclMagic {
+		return 0, fmt.Errorf("invalid contact header magic %q", hdr[:4])
+	}
+	if hdr[4] != tcpclVersion {
+		return 0, fmt.Errorf("%w: %d", errTCPCLVersion, hdr[4])
+	}
+	return hdr[5], nil
+}
+
+func writeSessTerm(w io.Writer, flags byte, reason TCPCLTermReason) error {
+	_, err := w.Write([]byte{tcpclMsgSessTerm, flags, byte(reason)})
+	return err
+}
+
+// sessInit holds the parameters exchanged in SESS_INIT.
+type sessInit struct {
+	keepalive   uint16
+	segmentMRU  uint64
+	transferMRU uint64
+	nodeID      string
+}
+
+func writeSessInit(w io.Writer, init sessInit) error {
+	var buf bytes.Buffer
+	buf.WriteByte(tcpclMsgSessInit)
+	binary.Write(&buf, binary.BigEndian, init.keepalive)
+	binary.Write(&buf, binary.BigEndian, init.segmentMRU)
+	binary.Write(&buf, binary.BigEndian, init.transferMRU)
+	binary.Write(&buf, binary.BigEndian, uint16(len(init.nodeID)))
+	buf.WriteString(init.nodeID)
+	binary.Write(&buf, binary.BigEndian, uint32(0)) // no session extension items
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readSessInit(r *bufio.Reader) (sessInit, error) {
+	var init sessInit
+
+	msgType, err := r.ReadByte()
+	if err != nil {
+		return init, fmt.Errorf("read SESS_INIT: %w", err)
+	}
+	if msgType != tcpclMsgSessInit {
+		return init, fmt.Errorf("expected SESS_INIT, got message type 0x%02x", msgType)
+	}
+
+	var hdr struct {
+		Keepalive   uint16
+		SegmentMRU  uint64
+		TransferMRU uint64
+		NodeIDLen   uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &hdr); err != nil {
+		return init, fmt.Errorf("read SESS_INIT: %w", err)
+	}
+	nodeID := make([]byte, hdr.NodeIDLen)
+	if _, err := io.ReadFull(r, nodeID); err != nil {
+		return init, fmt.Errorf("read node ID: %w", err)
+	}
+
+	exts, err := readExtensions(r)
+	if err != nil {
+		return init, err
+	}
+	for _, ext := range exts {
+		if ext.flags&tcpclExtFlagCritical != 0 {
+			return init, fmt.Errorf("unsupported critical session extension 0x%04x", ext.itemType)
+		}
+	}
+
+	init.keepalive = hdr.Keepalive
+	init.segmentMRU = hdr.SegmentMRU
+	init.transferMRU = hdr.TransferMRU
+	init.nodeID = string(nodeID)
+	return init, nil
+}
+
+// tcpclExtension is a session or transfer extension item.
+type tcpclExtension struct {
+	flags    uint8
+	itemType uint16
+	value    []byte
+}
+
+// readExtensions reads a length-prefixed list of extension items.
+func readExtensions(r io.Reader) ([]tcpclExtension, error) {
+	var total uint32
+	if err := binary.Read(r, binary.BigEndian, &total); err != nil {
+		return nil, fmt.Errorf("read extensions length: %w", err)
+	}
+	if total > tcpclMaxExtensionsLength {
+		return nil, fmt.Errorf("extension items too large: %d bytes", total)
+	}
+	raw := make([]byte, total)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("read extensions: %w", err)
+	}
+
+	var items []tcpclExtension
+	for len(raw) > 0 {
+		if len(raw) < 5 {
+			return nil, fmt.Errorf("truncated extension item")
+		}
+		item := tcpclExtension{
+			flags:    raw[0],
+			itemType: binary.BigEndian.Uint16(raw[1:3]),
+		}
+		length := int(binary.BigEndian.Uint16(raw[3:5]))
+		if len(raw) < 5+length {
+			return nil, fmt.Errorf("truncated extension item value")
+		}
+		item.value = raw[5 : 5+length]
+		items = append(items, item)
+		raw = raw[5+length:]
+	}
+	return items, nil
+}
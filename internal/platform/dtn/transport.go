@@ -41,6 +41,68 @@ type TransportAdapter interface {
 	Stop() error
 }
 
+// PeerInfo describes a neighbor identified by a convergence-layer handshake.
+type PeerInfo struct {
+	NeighborID string // Local identifier the session is registered under
+	NodeEID    string // Node ID advertised by the peer
+	Address    string // Remote network address
+	Incoming   bool   // True if the peer initiated the session
+	Connected  bool   // False when the session has ended
+}
+
+// PeerAwareTransport is implemented by transports whose handshake reveals
+// the remote node's identity, such as TCPCLv4 SESS_INIT.
+type PeerAwareTransport interface {
+	TransportAdapter
+
+	// SetPeerHandler registers a callback for session up/down events.
+	SetPeerHandler(handler func(PeerInfo))
+
+	// BindPeer maps a peer node EID to a local neighbor ID so inbound
+	// sessions from that node are registered under the known neighbor.
+	BindPeer(nodeEID, neighborID string)
+}
+
+// LinkMTUProvider is implemented by transports that limit the encoded size
+// of a single bundle on a link. The node fragments bundles that exceed it.
+type LinkMTUProvider interface {
+	// LinkMTU returns the largest bundle the neighbor accepts, or 0 if unknown.
+	LinkMTU(neighborID string) int
+}
+
+// PartialTransferError reports a transfer that was cut off after the peer
+// acknowledged part of the encoded bundle, enabling reactive fragmentation.
+type PartialTransferError struct {
+	NeighborID    string
+	Acked         uint64 // Encoded bytes acknowledged by the peer
+	Total         uint64 // Encoded bundle length
+	PayloadOffset int    // Offset of payload data in the encoding (-1 if unknown)
+	PayloadLength uint64 // Declared payload length
+	Err           error
+}
+
+// Error implements error.
+func (e *PartialTransferError) Error() string {
+	return fmt.Sprintf("transfer to %s interrupted after %d of %d bytes: %v", e.NeighborID, e.Acked, e.Total, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *PartialTransferError) Unwrap() error {
+	return e.Err
+}
+
+// DeliveredPayload returns how many payload bytes the peer received.
+func (e *PartialTransferError) DeliveredPayload() uint64 {
+	if e.PayloadOffset < 0 || e.Acked <= uint64(e.PayloadOffset) {
+		return 0
+	}
+	delivered := e.Acked - uint64(e.PayloadOffset)
+	if delivered > e.PayloadLength {
+		delivered = e.PayloadLength
+	}
+	return delivered
+}
+
 // TCPTransportConfig holds configuration for TCP transport.
 type TCPTransportConfig struct {
 	ListenAddress    string        // Address to listen on (e.g., ":4556")
@@ -50,6 +112,7 @@ type TCPTransportConfig struct {
 	MaxMessageSize   int           // Maximum bundle size in bytes
 	ReconnectBackoff time.Duration // Initial backoff for reconnection attempts
 	MaxReconnects    int           // Maximum reconnection attempts (0 = unlimited)
+	Codec            bundle.Codec  // Wire format for outgoing bundles (nil = legacy)
 }
 
 // DefaultTCPTransportConfig returns sensible defaults for TCP transport.
@@ -62,6 +125,7 @@ func DefaultTCPTransportConfig() TCPTransportConfig {
 		MaxMessageSize:   10 * 1024 * 1024, // 10MB
 		ReconnectBackoff: 1 * time.Second,
 		MaxReconnects:    10,
+		Codec:            bundle.Legacy,
 	}
 }
 
@@ -241,7 +305,11 @@ func (t *TCPTransport) Send(ctx context.Context, neighborID string, b *bundle.Bu
 	}
 
 	// Serialize the bundle
-	data, err := bundle.Marshal(b)
+	codec := t.config.Codec
+	if codec == nil {
+		codec = bundle.Legacy
+	}
+	data, err := codec.Marshal(b)
 	if err != nil {
 		return fmt.Errorf("failed to serialize bundle: %w", err)
 	}
@@ -281,6 +349,11 @@ func (t *TCPTransport) Send(ctx context.Context, neighborID string, b *bundle.Bu
 	return nil
 }
 
+// LinkMTU implements LinkMTUProvider.
+func (t *TCPTransport) LinkMTU(neighborID string) int {
+	return t.config.MaxMessageSize
+}
+
 // Receive returns the channel for incoming bundles.
 func (t *TCPTransport) Receive() <-chan *bundle.Bundle {
 	return t.receiveChan
@@ -393,7 +466,7 @@ func (t *TCPTransport) handleConnection(conn *tcpConnection) {
 			return
 		}
 
-		// Deserialize the bundle
+		// Deserialize the bundle (legacy and CBOR framing are auto-detected)
 		b, err := bundle.Unmarshal(data)
 		if err != nil {
 			log.Printf("[TCP Transport] Failed to unmarshal bundle from %s: %v", conn.neighborID, err)
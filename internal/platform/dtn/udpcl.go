@@ -0,0 +1,225 @@
+package dtn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// udpclMaxDatagram is the largest UDP payload over IPv4.
+const udpclMaxDatagram = 65507
+
+// UDPCLConfig holds configuration for the UDP convergence layer.
+type UDPCLConfig struct {
+	ListenAddress   string         // Address to listen on (IANA port 4556)
+	MaxDatagramSize int            // Largest encoded bundle sent or accepted
+	Conn            net.PacketConn // Pre-opened socket, e.g. a LinkEmulator endpoint (overrides ListenAddress)
+	Codec           bundle.Codec   // Bundle encoding (RFC 9171 CBOR by default)
+}
+
+// DefaultUDPCLConfig returns sensible defaults for the UDP convergence layer.
+func DefaultUDPCLConfig() UDPCLConfig {
+	return UDPCLConfig{
+		ListenAddress:   ":4556",
+		MaxDatagramSize: udpclMaxDatagram,
+		Codec:           bundle.CBOR,
+	}
+}
+
+// UDPCLTransport implements TransportAdapter with the UDP convergence layer
+// (RFC 7122 Section 3): each bundle travels in exactly one datagram, with
+// no acknowledgement or retransmission. Bundles larger than the datagram
+// limit are fragmented by the node via LinkMTU.
+type UDPCLTransport struct {
+	config      UDPCLConfig
+	localNodeID string
+
+	conn      net.PacketConn
+	neighbors map[string]net.Addr // neighbor ID -> remote address
+	mu        sync.RWMutex
+
+	receiveChan chan *bundle.Bundle
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewUDPCLTransport creates a UDP convergence layer for the local node.
+func NewUDPCLTransport(localNodeEID string, config UDPCLConfig) *UDPCLTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	if config.Codec == nil {
+		config.Codec = bundle.CBOR
+	}
+	if config.MaxDatagramSize <= 0 || config.MaxDatagramSize > udpclMaxDatagram {
+		config.MaxDatagramSize = udpclMaxDatagram
+	}
+
+	return &UDPCLTransport{
+		config:      config,
+		localNodeID: localNodeEID,
+		neighbors:   make(map[string]net.Addr),
+		receiveChan: make(chan *bundle.Bundle, 1000),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start opens the socket and begins receiving datagrams.
+func (t *UDPCLTransport) Start(ctx context.Context) error {
+	t.conn = t.config.Conn
+	if t.conn == nil {
+		conn, err := net.ListenPacket("udp", t.config.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("failed to start UDPCL listener: %w", err)
+		}
+		t.conn = conn
+	}
+
+	log.Printf("[UDPCL] Listening on %s as %s", t.conn.LocalAddr(), t.localNodeID)
+
+	t.wg.Add(1)
+	go t.readLoop()
+
+	return nil
+}
+
+// Addr returns the local socket address, or nil before Start.
+func (t *UDPCLTransport) Addr() net.Addr {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.LocalAddr()
+}
+
+// Stop closes the socket and shuts down the transport.
+func (t *UDPCLTransport) Stop() error {
+	log.Printf("[UDPCL] Shutting down")
+	t.cancel()
+
+	if t.conn != nil {
+		t.conn.Close()
+	}
+
+	t.wg.Wait()
+	close(t.receiveChan)
+
+	return nil
+}
+
+// Connect records the neighbor's address. UDP is connectionless, so no
+// packets are exchanged.
+func (t *UDPCLTransport) Connect(ctx context.Context, neighborID string, address string) error {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s at %s: %w", neighborID, address, err)
+	}
+
+	t.mu.Lock()
+	t.neighbors[neighborID] = addr
+	t.mu.Unlock()
+
+	log.Printf("[UDPCL] Neighbor %s at %s", neighborID, addr)
+	return nil
+}
+
+// Disconnect forgets the neighbor's address.
+func (t *UDPCLTransport) Disconnect(neighborID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.neighbors[neighborID]; !ok {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+	delete(t.neighbors, neighborID)
+	return nil
+}
+
+// IsConnected returns true if the neighbor's address is known.
+func (t *UDPCLTransport) IsConnected(neighborID string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.neighbors[neighborID]
+	return ok
+}
+
+// Receive returns the channel for incoming bundles.
+func (t *UDPCLTransport) Receive() <-chan *bundle.Bundle {
+	return t.receiveChan
+}
+
+// Send transmits a bundle to a neighbor in a single datagram. Delivery is
+// not confirmed.
+func (t *UDPCLTransport) Send(ctx context.Context, neighborID string, b *bundle.Bundle) error {
+	t.mu.RLock()
+	addr, ok := t.neighbors[neighborID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("not connected to neighbor: %s", neighborID)
+	}
+	if t.conn == nil {
+		return errors.New("udpcl: transport not started")
+	}
+
+	data, err := t.config.Codec.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to serialize bundle: %w", err)
+	}
+	if len(data) > t.config.MaxDatagramSize {
+		return fmt.Errorf("bundle size %d exceeds UDPCL datagram limit %d", len(data), t.config.MaxDatagramSize)
+	}
+
+	if _, err := t.conn.WriteTo(data, addr); err != nil {
+		return fmt.Errorf("failed to send datagram to %s: %w", neighborID, err)
+	}
+
+	log.Printf("[UDPCL] Sent bundle %s to %s (%d bytes)", b.ID.String()[:8], neighborID, len(data))
+	return nil
+}
+
+// LinkMTU implements LinkMTUProvider with the datagram size limit.
+func (t *UDPCLTransport) LinkMTU(neighborID string) int {
+	return t.config.MaxDatagramSize
+}
+
+// readLoop decodes one bundle from each received datagram.
+func (t *UDPCLTransport) readLoop() {
+	defer t.wg.Done()
+
+	buf := make([]byte, udpclMaxDatagram)
+	for {
+		n, from, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			if t.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("[UDPCL] Read error: %v", err)
+			continue
+		}
+		if n > t.config.MaxDatagramSize {
+			log.Printf("[UDPCL] Dropping %d byte datagram from %s: exceeds limit", n, from)
+			continue
+		}
+
+		// Decoded bundles may alias their input, so buf cannot be reused
+		b, err := bundle.Unmarshal(append([]byte(nil), buf[:n]...))
+		if err != nil {
+			log.Printf("[UDPCL] Failed to decode bundle from %s: %v", from, err)
+			continue
+		}
+
+		log.Printf("[UDPCL] Received bundle %s from %s (%d bytes)", b.ID.String()[:8], from, n)
+
+		select {
+		case t.receiveChan <- b:
+		default:
+			log.Printf("[UDPCL] Receive buffer full, dropping bundle %s", b.ID.String()[:8])
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package frames
+
+import "math"
+
+const (
+	arcsec = math.Pi / (180 * 3600)
+	twoPi  = 2 * math.Pi
+
+	// EarthRotationRate is the mean angular velocity of the Earth, rad/s.
+	EarthRotationRate = 7.292115146706979e-5
+)
+
+// Matrix is a 3x3 rotation matrix acting on column vectors.
+type Matrix [3][3]float64
+
+// MulVec returns m·v.
+func (m Matrix) MulVec(v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+// Mul returns m·n.
+func (m Matrix) Mul(n Matrix) Matrix {
+	var out Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			out[i][j] = m[i][0]*n[0][j] + m[i][1]*n[1][j] + m[i][2]*n[2][j]
+		}
+	}
+	return out
+}
+
+// T returns the transpose, which is the inverse of a rotation.
+func (m Matrix) T() Matrix {
+	var out Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			out[i][j] = m[j][i]
+		}
+	}
+	return out
+}
+
+// rotX, rotY and rotZ rotate the coordinate axes by angle radians, so a
+// fixed vector appears to turn by -angle.
+func rotX(angle float64) Matrix {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return Matrix{{1, 0, 0}, {0, c, s}, {0, -s, c}}
+}
+
+func rotY(angle float64) Matrix {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return Matrix{{c, 0, -s}, {0, 1, 0}, {s, 0, c}}
+}
+
+func rotZ(angle float64) Matrix {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return Matrix{{c, s, 0}, {-s, c, 0}, {0, 0, 1}}
+}
+
+// GMST returns Greenwich mean sidereal time in radians for a UT1 Julian
+// date, using the IAU 1982 model.
+func GMST(jdUT1 float64) float64 {
+	t := JulianCenturies(jdUT1)
+	sec := 67310.54841 + (876600*3600+8640184.812866)*t + 0.093104*t*t - 6.2e-6*t*t*t
+	gmst := math.Mod(sec*twoPi/secondsPerDay, twoPi)
+	if gmst < 0 {
+		gmst += twoPi
+	}
+	return gmst
+}
+
+// MeanObliquity returns the IAU 1980 mean obliquity of the ecliptic in
+// radians for a TT Julian date.
+func MeanObliquity(jdTT float64) float64 {
+	t := JulianCenturies(jdTT)
+	return (84381.448 + (-46.8150+(-0.00059+0.001813*t)*t)*t) * arcsec
+}
+
+// nutation80 holds the IAU 1980 nutation series: multipliers of the
+// Delaunay arguments l, l', F, D and Ω, then the longitude and obliquity
+// amplitudes and their rates, in units of 0.1 mas and 0.1 mas per century.
+// Terms below 1 mas are omitted; the truncated series stays within a few
+// mas of the full 106-term model.
+var nutation80 = [][9]float64{
+	{0, 0, 0, 0, 1, -171996, -174.2, 92025, 8.9},
+	{0, 0, 2, -2, 2, -13187, -1.6, 5736, -3.1},
+	{0, 0, 2, 0, 2, -2274, -0.2, 977, -0.5},
+	{0, 0, 0, 0, 2, 2062, 0.2, -895, 0.5},
+	{0, 1, 0, 0, 0, 1426, -3.4, 54, -0.1},
+	{1, 0, 0, 0, 0, 712, 0.1, -7, 0},
+	{0, 1, 2, -2, 2, -517, 1.2, 224, -0.6},
+	{0, 0, 2, 0, 1, -386, -0.4, 200, 0},
+	{1, 0, 2, 0, 2, -301, 0, 129, -0.1},
+	{0, -1, 2, -2, 2, 217, -0.5, -95, 0.3},
+	{1, 0, 0, -2, 0, -158, 0, -1, 0},
+	{0, 0, 2, -2, 1, 129, 0.1, -70, 0},
+	{-1, 0, 2, 0, 2, 123, 0, -53, 0},
+	{1, 0, 0, 0, 1, 63, 0.1, -33, 0},
+	{0, 0, 0, 2, 0, 63, 0, -2, 0},
+	{-1, 0, 2, 2, 2, -59, 0, 26, 0},
+	{-1, 0, 0, 0, 1, -58, -0.1, 32, 0},
+	{1, 0, 2, 0, 1, -51, 0, 27, 0},
+	{2, 0, 0, -2, 0, 48, 0, 1, 0},
+	{-2, 0, 2, 0, 1, 46, 0, -24, 0},
+	{0, 0, 2, 2, 2, -38, 0, 16, 0},
+	{2, 0, 2, 0, 2, -31, 0, 13, 0},
+	{2, 0, 0, 0, 0, 29, 0, -1, 0},
+	{1, 0, 2, -2, 2, 29, 0, -12, 0},
+	{0, 0, 2, 0, 0, 26, 0, -1, 0},
+	{0, 0, 2, -2, 0, -22, 0, 0, 0},
+	{-1, 0, 2, 0, 1, 21, 0, -10, 0},
+	{0, 2, 0, 0, 0, 17, -0.1, 0, 0},
+	{0, 2, 2, -2, 2, -16, 0.1, 7, 0},
+	{-1, 0, 0, 2, 1, 16, 0, -8, 0},
+	{0, 1, 0, 0, 1, -15, 0, 9, 0},
+	{1, 0, 0, -2, 1, -13, 0, 7, 0},
+	{0, -1, 0, 0, 1, -12, 0, 6, 0},
+	{2, 0, -2, 0, 0, 11, 0, 0, 0},
+	{-1, 0, 2, 2, 1, -10, 0, 5, 0},
+}
+
+// delaunay returns the IAU 1980 fundamental arguments l, l', F, D and Ω in
+// radians for t Julian centuries TT since J2000.0.
+func delaunay(t float64) [5]float64 {
+	arg := func(c0, c1, c2, c3, revs float64) float64 {
+		a := (c0+(c1+(c2+c3*t)*t)*t)*arcsec + math.Mod(revs*t, 1.0)*twoPi
+		return math.Mod(a, twoPi)
+	}
+	return [5]float64{
+		arg(485866.733, 715922.633, 31.310, 0.064, 1325),
+		arg(1287099.804, 1292581.224, -0.577, -0.012, 99),
+		arg(335778.877, 295263.137, -13.257, 0.011, 1342),
+		arg(1072261.307, 1105601.328, -6.891, 0.019, 1236),
+		arg(450160.280, -482890.539, 7.455, 0.008, -5),
+	}
+}
+
+// Nutation returns the IAU 1980 nutation in longitude and obliquity in
+// radians for a TT Julian date.
+func Nutation(jdTT float64) (dpsi, deps float64) {
+	t := JulianCenturies(jdTT)
+	fa := delaunay(t)
+	for _, term := range nutation80 {
+		arg := term[0]*fa[0] + term[1]*fa[1] + term[2]*fa[2] + term[3]*fa[3] + term[4]*fa[4]
+		dpsi += (term[5] + term[6]*t) * math.Sin(arg)
+		deps += (term[7] + term[8]*t) * math.Cos(arg)
+	}
+	const unit = 1e-4 * arcsec
+	return dpsi * unit, deps * unit
+}
+
+// EquationOfEquinoxes returns GAST - GMST in radians for a TT Julian date,
+// including the 1994 lunar node terms.
+func EquationOfEquinoxes(jdTT float64) float64 {
+	dpsi, _ := Nutation(jdTT)
+	return equationOfEquinoxes(jdTT, dpsi)
+}
+
+func equationOfEquinoxes(jdTT, dpsi float64) float64 {
+	eq := dpsi * math.Cos(MeanObliquity(jdTT))
+	if jdTT > 2450449.5 {
+		om := delaunay(JulianCenturies(jdTT))[4]
+		eq += (0.00264*math.Sin(om) + 0.000063*math.Sin(2*om)) * arcsec
+	}
+	return eq
+}
+
+// GAST returns Greenwich apparent sidereal time in radians.
+func GAST(jdUT1, jdTT float64) float64 {
+	gast := math.Mod(GMST(jdUT1)+EquationOfEquinoxes(jdTT), twoPi)
+	if gast < 0 {
+		gast += twoPi
+	}
+	return gast
+}
+
+// PrecessionMatrix returns the IAU 1976 precession matrix rotating J2000
+// mean-equator coordinates to the mean equator and equinox of date.
+func PrecessionMatrix(jdTT float64) Matrix {
+	t := JulianCenturies(jdTT)
+	zeta := (2306.2181 + (0.30188+0.017998*t)*t) * t * arcsec
+	z := (2306.2181 + (1.09468+0.018203*t)*t) * t * arcsec
+	theta := (2004.3109 + (-0.42665-0.041833*t)*t) * t * arcsec
+	return rotZ(-z).Mul(rotY(theta)).Mul(rotZ(-zeta))
+}
+
+// NutationMatrix returns the IAU 1980 nutation matrix rotating mean-of-date
+// coordinates to the true equator and equinox of date. ddpsi and ddeps are
+// the observed corrections in arcseconds (EOP.DDPsi, EOP.DDEps).
+func NutationMatrix(jdTT, ddpsi, ddeps float64) Matrix {
+	dpsi, deps := Nutation(jdTT)
+	eps := MeanObliquity(jdTT)
+	return nutationMatrix(eps, dpsi+ddpsi*arcsec, deps+ddeps*arcsec)
+}
+
+func nutationMatrix(eps, dpsi, deps float64) Matrix {
+	return rotX(-(eps + deps)).Mul(rotZ(-dpsi)).Mul(rotX(eps))
+}
+
+// polarMotionMatrix rotates ITRF coordinates into the pseudo Earth-fixed
+// frame of the instantaneous rotation axis. xp and yp are in arcseconds.
+func polarMotionMatrix(xp, yp float64) Matrix {
+	return rotX(yp * arcsec).Mul(rotY(xp * arcsec))
+}
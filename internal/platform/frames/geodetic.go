@@ -0,0 +1,107 @@
+package frames
+
+import "math"
+
+// WGS-84 ellipsoid.
+const (
+	WGS84SemiMajorAxis = 6378.137 // km
+	WGS84Flattening    = 1 / 298.257223563
+
+	wgs84E2 = WGS84Flattening * (2 - WGS84Flattening)
+)
+
+const (
+	deg2rad = math.Pi / 180
+	rad2deg = 180 / math.Pi
+)
+
+// Geodetic is a position relative to the WGS-84 ellipsoid.
+type Geodetic struct {
+	Latitude  float64 `json:"latitude"`  // degrees
+	Longitude float64 `json:"longitude"` // degrees, east positive
+	Altitude  float64 `json:"altitude"`  // km above the ellipsoid
+}
+
+// GeodeticToECEF returns the Earth-fixed position of a geodetic point.
+func GeodeticToECEF(g Geodetic) [3]float64 {
+	sinLat, cosLat := math.Sincos(g.Latitude * deg2rad)
+	sinLon, cosLon := math.Sincos(g.Longitude * deg2rad)
+	n := WGS84SemiMajorAxis / math.Sqrt(1-wgs84E2*sinLat*sinLat)
+	return [3]float64{
+		(n + g.Altitude) * cosLat * cosLon,
+		(n + g.Altitude) * cosLat * sinLon,
+		(n*(1-wgs84E2) + g.Altitude) * sinLat,
+	}
+}
+
+// ECEFToGeodetic converts an Earth-fixed position to geodetic coordinates.
+func ECEFToGeodetic(r [3]float64) Geodetic {
+	p := math.Hypot(r[0], r[1])
+	lon := math.Atan2(r[1], r[0])
+
+	// Iterate on latitude; converges to well under a millimetre in a few
+	// passes for any altitude
+	phi := math.Atan2(r[2], p*(1-wgs84E2))
+	for i := 0; i < 10; i++ {
+		sinPhi := math.Sin(phi)
+		n := WGS84SemiMajorAxis / math.Sqrt(1-wgs84E2*sinPhi*sinPhi)
+		next := math.Atan2(r[2]+n*wgs84E2*sinPhi, p)
+		if math.Abs(next-phi) < 1e-12 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+
+	sinPhi, cosPhi := math.Sincos(phi)
+	n := WGS84SemiMajorAxis / math.Sqrt(1-wgs84E2*sinPhi*sinPhi)
+	var alt float64
+	if math.Abs(cosPhi) > 1e-10 {
+		alt = p/cosPhi - n
+	} else {
+		alt = math.Abs(r[2]) - n*(1-wgs84E2)
+	}
+	return Geodetic{Latitude: phi * rad2deg, Longitude: lon * rad2deg, Altitude: alt}
+}
+
+// ENU rotates an Earth-fixed vector into the local east, north, up frame
+// at a geodetic point.
+func ENU(at Geodetic, d [3]float64) [3]float64 {
+	sinLat, cosLat := math.Sincos(at.Latitude * deg2rad)
+	sinLon, cosLon := math.Sincos(at.Longitude * deg2rad)
+	return [3]float64{
+		-sinLon*d[0] + cosLon*d[1],
+		-sinLat*cosLon*d[0] - sinLat*sinLon*d[1] + cosLat*d[2],
+		cosLat*cosLon*d[0] + cosLat*sinLon*d[1] + sinLat*d[2],
+	}
+}
+
+// LookAngles locates a target as seen from an observer on the ground.
+type LookAngles struct {
+	Azimuth   float64 `json:"azimuth"`    // degrees clockwise from north, [0, 360)
+	Elevation float64 `json:"elevation"`  // degrees above the horizon
+	Range     float64 `json:"range"`      // km
+	RangeRate float64 `json:"range_rate"` // km/s, positive when receding
+}
+
+// Look returns the look angles from an observer to a target with
+// Earth-fixed position r and velocity v.
+func Look(observer Geodetic, r, v [3]float64) LookAngles {
+	site := GeodeticToECEF(observer)
+	rho := [3]float64{r[0] - site[0], r[1] - site[1], r[2] - site[2]}
+	enu := ENU(observer, rho)
+
+	rng := math.Sqrt(rho[0]*rho[0] + rho[1]*rho[1] + rho[2]*rho[2])
+	look := LookAngles{Range: rng}
+	if rng == 0 {
+		look.Elevation = 90
+		return look
+	}
+	look.Elevation = math.Asin(enu[2]/rng) * rad2deg
+	look.Azimuth = math.Atan2(enu[0], enu[1]) * rad2deg
+	if look.Azimuth < 0 {
+		look.Azimuth += 360
+	}
+	look.RangeRate = (rho[0]*v[0] + rho[1]*v[1] + rho[2]*v[2]) / rng
+	return look
+}
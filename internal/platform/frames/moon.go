@@ -0,0 +1,37 @@
+package frames
+
+import (
+	"math"
+	"time"
+)
+
+// MoonPosition returns the geocentric position of the Moon in km in GCRF,
+// from the low-precision lunar theory of Montenbruck and Gill (Satellite
+// Orbits, 3.3.2): several arcminutes in longitude and about 500 km in
+// distance, ample for third-body perturbations.
+func MoonPosition(utc time.Time) [3]float64 {
+	t := JulianCenturies(JulianDate(TT(utc)))
+
+	l0 := (218.31617 + 481267.88088*t - 1.3972*t) * deg2rad // Mean longitude, referred to the J2000 equinox
+	l := (134.96292 + 477198.86753*t) * deg2rad             // Moon's mean anomaly
+	lp := (357.52543 + 35999.04944*t) * deg2rad             // Sun's mean anomaly
+	f := (93.27283 + 483202.01873*t) * deg2rad              // Mean argument of latitude
+	d := (297.85027 + 445267.11135*t) * deg2rad             // Mean elongation from the Sun
+
+	lon := l0 + (22640*math.Sin(l)+769*math.Sin(2*l)-4586*math.Sin(l-2*d)+2370*math.Sin(2*d)-
+		668*math.Sin(lp)-412*math.Sin(2*f)-212*math.Sin(2*l-2*d)-206*math.Sin(l+lp-2*d)+
+		192*math.Sin(l+2*d)-165*math.Sin(lp-2*d)+148*math.Sin(l-lp)-125*math.Sin(d)-
+		110*math.Sin(l+lp)-55*math.Sin(2*f-2*d))*arcsec
+	lat := (18520*math.Sin(f+lon-l0+(412*math.Sin(2*f)+541*math.Sin(lp))*arcsec) -
+		526*math.Sin(f-2*d) + 44*math.Sin(l+f-2*d) - 31*math.Sin(-l+f-2*d) - 25*math.Sin(-2*l+f) -
+		23*math.Sin(lp+f-2*d) + 21*math.Sin(-l+f) + 11*math.Sin(-lp+f-2*d)) * arcsec
+	dist := 385000 - 20905*math.Cos(l) - 3699*math.Cos(2*d-l) - 2956*math.Cos(2*d) - 570*math.Cos(2*l) +
+		246*math.Cos(2*l-2*d) - 205*math.Cos(lp-2*d) - 171*math.Cos(l+2*d) - 152*math.Cos(l+lp-2*d)
+
+	// Ecliptic to equator of J2000
+	const obliquityJ2000 = 23.43929111 * deg2rad
+	sinLon, cosLon := math.Sincos(lon)
+	sinLat, cosLat := math.Sincos(lat)
+	ecl := [3]float64{dist * cosLon * cosLat, dist * sinLon * cosLat, dist * sinLat}
+	return rotX(-obliquityJ2000).MulVec(ecl)
+}
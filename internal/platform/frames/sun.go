@@ -0,0 +1,50 @@
+package frames
+
+import (
+	"math"
+	"time"
+)
+
+// AstronomicalUnit is the mean Earth-Sun distance in km.
+const AstronomicalUnit = 149597870.7
+
+// SunPosition returns the geocentric position of the Sun in km in the mean
+// equator and equinox of date, using the low-precision solar ephemeris of
+// the Astronomical Almanac (about 0.01° through 2050). At that accuracy the
+// frame is interchangeable with TEME.
+func SunPosition(utc time.Time) [3]float64 {
+	t := JulianCenturies(JulianDate(utc))
+
+	meanLon := 280.460 + 36000.771*t
+	meanAnomaly := (357.5291092 + 35999.05034*t) * deg2rad
+	eclipticLon := (meanLon + 1.914666471*math.Sin(meanAnomaly) + 0.019994643*math.Sin(2*meanAnomaly)) * deg2rad
+	distance := (1.000140612 - 0.016708617*math.Cos(meanAnomaly) - 0.000139589*math.Cos(2*meanAnomaly)) * AstronomicalUnit
+	obliquity := (23.439291 - 0.0130042*t) * deg2rad
+
+	sinLon, cosLon := math.Sincos(eclipticLon)
+	return [3]float64{
+		distance * cosLon,
+		distance * math.Cos(obliquity) * sinLon,
+		distance * math.Sin(obliquity) * sinLon,
+	}
+}
+
+// Sunlit reports whether a satellite at TEME position r is outside the
+// Earth's shadow, modelled as a cylinder of the equatorial radius.
+func Sunlit(r, sun [3]float64) bool {
+	sunDist := math.Sqrt(sun[0]*sun[0] + sun[1]*sun[1] + sun[2]*sun[2])
+	s := [3]float64{sun[0] / sunDist, sun[1] / sunDist, sun[2] / sunDist}
+	along := r[0]*s[0] + r[1]*s[1] + r[2]*s[2]
+	if along >= 0 {
+		return true
+	}
+	perp := [3]float64{r[0] - along*s[0], r[1] - along*s[1], r[2] - along*s[2]}
+	return math.Sqrt(perp[0]*perp[0]+perp[1]*perp[1]+perp[2]*perp[2]) > WGS84SemiMajorAxis
+}
+
+// SunElevation returns the Sun's elevation in degrees above an observer's
+// horizon.
+func SunElevation(observer Geodetic, utc time.Time) float64 {
+	sun, _ := TEMEToITRF(SunPosition(utc), [3]float64{}, utc, EOP{})
+	return Look(observer, sun, [3]float64{}).Elevation
+}
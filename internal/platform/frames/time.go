@@ -0,0 +1,137 @@
+// Package frames provides the time scales, reference frames and Earth
+// model shared by ASGARD's orbital code: UTC/TAI/TT/UT1 conversions,
+// TEME/GCRF/ITRF transforms with IAU 1976 precession and IAU 1980
+// nutation, WGS-84 geodetic coordinates and topocentric look angles.
+//
+// ITRF is the Earth-fixed frame; callers that speak of ECEF mean ITRF.
+// Positions are kilometres and velocities kilometres per second.
+package frames
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// JulianDateJ2000 is the Julian date of the J2000.0 epoch (TT).
+	JulianDateJ2000 = 2451545.0
+
+	// julianDateUnix is the Julian date of the Unix epoch.
+	julianDateUnix = 2440587.5
+
+	// ttMinusTAI is the constant offset of Terrestrial Time from TAI.
+	ttMinusTAI = 32.184
+
+	secondsPerDay = 86400.0
+)
+
+// EOP holds the Earth orientation parameters for an instant, as published
+// in IERS Bulletin A. The zero value is a usable approximation, good to
+// about 0.9 s in UT1 and 0.5" in pole position.
+type EOP struct {
+	DUT1  float64 // UT1-UTC, seconds
+	XP    float64 // Polar motion x, arcseconds
+	YP    float64 // Polar motion y, arcseconds
+	DDPsi float64 // Correction to IAU 1980 nutation in longitude, arcseconds
+	DDEps float64 // Correction to IAU 1980 nutation in obliquity, arcseconds
+}
+
+// leapSeconds lists the UTC instants at which TAI-UTC changed since UTC
+// took its current form in 1972.
+var leapSeconds = []struct {
+	start  time.Time
+	offset float64
+}{
+	{time.Date(1972, 1, 1, 0, 0, 0, 0, time.UTC), 10},
+	{time.Date(1972, 7, 1, 0, 0, 0, 0, time.UTC), 11},
+	{time.Date(1973, 1, 1, 0, 0, 0, 0, time.UTC), 12},
+	{time.Date(1974, 1, 1, 0, 0, 0, 0, time.UTC), 13},
+	{time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC), 14},
+	{time.Date(1976, 1, 1, 0, 0, 0, 0, time.UTC), 15},
+	{time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), 16},
+	{time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC), 17},
+	{time.Date(1979, 1, 1, 0, 0, 0, 0, time.UTC), 18},
+	{time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), 19},
+	{time.Date(1981, 7, 1, 0, 0, 0, 0, time.UTC), 20},
+	{time.Date(1982, 7, 1, 0, 0, 0, 0, time.UTC), 21},
+	{time.Date(1983, 7, 1, 0, 0, 0, 0, time.UTC), 22},
+	{time.Date(1985, 7, 1, 0, 0, 0, 0, time.UTC), 23},
+	{time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC), 24},
+	{time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), 25},
+	{time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC), 26},
+	{time.Date(1992, 7, 1, 0, 0, 0, 0, time.UTC), 27},
+	{time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC), 28},
+	{time.Date(1994, 7, 1, 0, 0, 0, 0, time.UTC), 29},
+	{time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC), 30},
+	{time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC), 31},
+	{time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), 32},
+	{time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC), 33},
+	{time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC), 34},
+	{time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC), 35},
+	{time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC), 36},
+	{time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), 37},
+}
+
+// TAIMinusUTC returns the accumulated leap seconds at a UTC instant. Times
+// before 1972 use the 1972 value.
+func TAIMinusUTC(utc time.Time) float64 {
+	for i := len(leapSeconds) - 1; i >= 0; i-- {
+		if !utc.Before(leapSeconds[i].start) {
+			return leapSeconds[i].offset
+		}
+	}
+	return leapSeconds[0].offset
+}
+
+// The time scale conversions below return a time.Time whose clock reading
+// is the instant expressed in the target scale. Its location is still UTC;
+// only JulianDate and arithmetic should be applied to it.
+
+// TAI converts a UTC instant to International Atomic Time.
+func TAI(utc time.Time) time.Time {
+	return utc.Add(seconds(TAIMinusUTC(utc)))
+}
+
+// UTCFromTAI converts International Atomic Time back to UTC.
+func UTCFromTAI(tai time.Time) time.Time {
+	for i := len(leapSeconds) - 1; i >= 0; i-- {
+		offset := seconds(leapSeconds[i].offset)
+		if !tai.Add(-offset).Before(leapSeconds[i].start) {
+			return tai.Add(-offset)
+		}
+	}
+	return tai.Add(-seconds(leapSeconds[0].offset))
+}
+
+// TT converts a UTC instant to Terrestrial Time.
+func TT(utc time.Time) time.Time {
+	return TAI(utc).Add(seconds(ttMinusTAI))
+}
+
+// UT1 converts a UTC instant to UT1 given UT1-UTC in seconds.
+func UT1(utc time.Time, dut1 float64) time.Time {
+	return utc.Add(seconds(dut1))
+}
+
+// JulianDate returns the Julian date of a clock reading. Leap seconds are
+// not counted, so for UTC this is the conventional quasi-Julian date.
+func JulianDate(t time.Time) float64 {
+	return julianDateUnix + (float64(t.Unix())+float64(t.Nanosecond())/1e9)/secondsPerDay
+}
+
+// TimeFromJulianDate is the inverse of JulianDate.
+func TimeFromJulianDate(jd float64) time.Time {
+	days := jd - julianDateUnix
+	whole := math.Floor(days)
+	ns := math.Round((days - whole) * secondsPerDay * 1e9)
+	return time.Unix(int64(whole)*secondsPerDay, int64(ns)).UTC()
+}
+
+// JulianCenturies returns Julian centuries since J2000.0.
+func JulianCenturies(jd float64) float64 {
+	return (jd - JulianDateJ2000) / 36525.0
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
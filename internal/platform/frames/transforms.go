@@ -0,0 +1,107 @@
+package frames
+
+import (
+	"math"
+	"time"
+)
+
+// The frames are related as
+//
+//	GCRF --precession--> MOD --nutation--> TOD --GAST--> PEF --polar motion--> ITRF
+//	                                       TEME --GMST--> PEF
+//
+// TEME is the output frame of SGP4. GCRF is treated as the J2000 mean
+// equator and equinox; the frame bias between them (about 20 mas) is
+// neglected.
+
+// TEMEToITRF converts a TEME state at UTC instant t to the Earth-fixed
+// frame.
+func TEMEToITRF(r, v [3]float64, t time.Time, eop EOP) (rITRF, vITRF [3]float64) {
+	gmst := GMST(JulianDate(UT1(t, eop.DUT1)))
+	rPEF, vPEF := inertialToPEF(rotZ(gmst), r, v)
+	w := polarMotionMatrix(eop.XP, eop.YP).T()
+	return w.MulVec(rPEF), w.MulVec(vPEF)
+}
+
+// ITRFToTEME converts an Earth-fixed state at UTC instant t to TEME.
+func ITRFToTEME(r, v [3]float64, t time.Time, eop EOP) (rTEME, vTEME [3]float64) {
+	gmst := GMST(JulianDate(UT1(t, eop.DUT1)))
+	w := polarMotionMatrix(eop.XP, eop.YP)
+	return pefToInertial(rotZ(gmst).T(), w.MulVec(r), w.MulVec(v))
+}
+
+// TEMEToGCRF converts a TEME state at UTC instant t to GCRF.
+func TEMEToGCRF(r, v [3]float64, t time.Time, eop EOP) (rGCRF, vGCRF [3]float64) {
+	m := temeToGCRFMatrix(t, eop)
+	return m.MulVec(r), m.MulVec(v)
+}
+
+// GCRFToTEME converts a GCRF state at UTC instant t to TEME.
+func GCRFToTEME(r, v [3]float64, t time.Time, eop EOP) (rTEME, vTEME [3]float64) {
+	m := temeToGCRFMatrix(t, eop).T()
+	return m.MulVec(r), m.MulVec(v)
+}
+
+// GCRFToITRF converts a GCRF state at UTC instant t to the Earth-fixed
+// frame.
+func GCRFToITRF(r, v [3]float64, t time.Time, eop EOP) (rITRF, vITRF [3]float64) {
+	rPEF, vPEF := inertialToPEF(gcrfToPEFMatrix(t, eop), r, v)
+	w := polarMotionMatrix(eop.XP, eop.YP).T()
+	return w.MulVec(rPEF), w.MulVec(vPEF)
+}
+
+// ITRFToGCRF converts an Earth-fixed state at UTC instant t to GCRF.
+func ITRFToGCRF(r, v [3]float64, t time.Time, eop EOP) (rGCRF, vGCRF [3]float64) {
+	w := polarMotionMatrix(eop.XP, eop.YP)
+	return pefToInertial(gcrfToPEFMatrix(t, eop).T(), w.MulVec(r), w.MulVec(v))
+}
+
+// GCRFToITRFMatrix returns the rotation taking GCRF coordinates to ITRF at
+// UTC instant t. It suits vectors such as accelerations that carry no
+// rotating-frame terms; use GCRFToITRF for states.
+func GCRFToITRFMatrix(t time.Time, eop EOP) Matrix {
+	return polarMotionMatrix(eop.XP, eop.YP).T().Mul(gcrfToPEFMatrix(t, eop))
+}
+
+// temeToGCRFMatrix rotates TEME to TOD by the equation of the equinoxes,
+// then undoes nutation and precession.
+func temeToGCRFMatrix(t time.Time, eop EOP) Matrix {
+	jdTT := JulianDate(TT(t))
+	dpsi, deps := Nutation(jdTT)
+	dpsi += eop.DDPsi * arcsec
+	deps += eop.DDEps * arcsec
+	eps := MeanObliquity(jdTT)
+
+	todToGCRF := nutationMatrix(eps, dpsi, deps).Mul(PrecessionMatrix(jdTT)).T()
+	return todToGCRF.Mul(rotZ(-dpsi * math.Cos(eps)))
+}
+
+// gcrfToPEFMatrix applies precession, nutation and apparent sidereal time.
+func gcrfToPEFMatrix(t time.Time, eop EOP) Matrix {
+	jdTT := JulianDate(TT(t))
+	dpsi, deps := Nutation(jdTT)
+	dpsi += eop.DDPsi * arcsec
+	deps += eop.DDEps * arcsec
+	eps := MeanObliquity(jdTT)
+
+	gast := GMST(JulianDate(UT1(t, eop.DUT1))) + equationOfEquinoxes(jdTT, dpsi)
+	return rotZ(gast).Mul(nutationMatrix(eps, dpsi, deps)).Mul(PrecessionMatrix(jdTT))
+}
+
+// inertialToPEF rotates a state into the rotating pseudo Earth-fixed frame,
+// removing the Earth's rotation from the velocity.
+func inertialToPEF(m Matrix, r, v [3]float64) (rPEF, vPEF [3]float64) {
+	rPEF = m.MulVec(r)
+	vPEF = m.MulVec(v)
+	vPEF[0] += EarthRotationRate * rPEF[1]
+	vPEF[1] -= EarthRotationRate * rPEF[0]
+	return rPEF, vPEF
+}
+
+// pefToInertial is the inverse of inertialToPEF; m maps PEF to the
+// inertial frame.
+func pefToInertial(m Matrix, rPEF, vPEF [3]float64) (r, v [3]float64) {
+	vPEF[0] -= EarthRotationRate * rPEF[1]
+	vPEF[1] += EarthRotationRate * rPEF[0]
+	return m.MulVec(rPEF), m.MulVec(vPEF)
+}
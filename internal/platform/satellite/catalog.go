@@ -0,0 +1,460 @@
+package satellite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CatalogFormat identifies an element set file encoding.
+type CatalogFormat string
+
+const (
+	FormatTLE     CatalogFormat = "tle"      // Two-line or three-line element sets
+	FormatOMMKVN  CatalogFormat = "omm-kvn"  // CCSDS OMM keyword=value notation
+	FormatOMMXML  CatalogFormat = "omm-xml"  // CCSDS OMM XML
+	FormatOMMJSON CatalogFormat = "omm-json" // CelesTrak/Space-Track OMM JSON
+)
+
+// DetectCatalogFormat guesses the encoding of an element set file from its
+// first non-blank characters.
+func DetectCatalogFormat(data []byte) (CatalogFormat, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return "", fmt.Errorf("empty catalog")
+	case trimmed[0] == '<':
+		return FormatOMMXML, nil
+	case trimmed[0] == '[' || trimmed[0] == '{':
+		return FormatOMMJSON, nil
+	case bytes.HasPrefix(trimmed, []byte("CCSDS_OMM_VERS")):
+		return FormatOMMKVN, nil
+	default:
+		return FormatTLE, nil
+	}
+}
+
+// ReadCatalog parses an element set file. An empty format is detected from
+// the content. OMMs are converted to TLEs.
+func ReadCatalog(r io.Reader, format CatalogFormat) ([]*TLE, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read catalog: %w", err)
+	}
+	if format == "" {
+		if format, err = DetectCatalogFormat(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var messages []*OMM
+	switch format {
+	case FormatTLE:
+		return ReadTLEs(bytes.NewReader(data))
+	case FormatOMMKVN:
+		messages, err = ReadOMMKVN(bytes.NewReader(data))
+	case FormatOMMXML:
+		messages, err = ReadOMMXML(bytes.NewReader(data))
+	case FormatOMMJSON:
+		messages, err = ReadOMMJSON(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unknown catalog format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tles := make([]*TLE, 0, len(messages))
+	for _, o := range messages {
+		tle, err := o.TLE()
+		if err != nil {
+			return nil, err
+		}
+		tles = append(tles, tle)
+	}
+	return tles, nil
+}
+
+// ElementSet is one epoch of a catalog object's history: the TLE with its
+// mean elements broken out for queries, and the result of comparing it
+// with the previous epoch.
+type ElementSet struct {
+	NoradID       int       `json:"norad_id"`
+	Name          string    `json:"name"`
+	IntDesignator string    `json:"int_designator,omitempty"`
+	Epoch         time.Time `json:"epoch"`
+	Line1         string    `json:"line1"`
+	Line2         string    `json:"line2"`
+	MeanMotion    float64   `json:"mean_motion"`     // rev/day
+	MeanMotionDot float64   `json:"mean_motion_dot"` // rev/day², halved
+	Eccentricity  float64   `json:"eccentricity"`
+	Inclination   float64   `json:"inclination"` // degrees
+	RAAN          float64   `json:"raan"`        // degrees
+	ArgPerigee    float64   `json:"arg_perigee"` // degrees
+	MeanAnomaly   float64   `json:"mean_anomaly"`
+	BStar         float64   `json:"bstar"`
+	Source        string    `json:"source"`
+	ImportedAt    time.Time `json:"imported_at"`
+
+	Maneuver       bool   `json:"maneuver"`
+	ManeuverReason string `json:"maneuver_reason,omitempty"`
+}
+
+// NewElementSet parses a TLE into an element set.
+func NewElementSet(tle *TLE) (*ElementSet, error) {
+	elements, err := ParseTLE(tle.Line1, tle.Line2)
+	if err != nil {
+		return nil, err
+	}
+	epoch := time.Date(elements.EpochYear, 1, 1, 0, 0, 0, 0, time.UTC).
+		Add(time.Duration((elements.EpochDay - 1) * 24 * float64(time.Hour)))
+	return &ElementSet{
+		NoradID:       tle.SatelliteID,
+		Name:          tle.Name,
+		IntDesignator: InternationalDesignator(tle.Line1),
+		Epoch:         epoch,
+		Line1:         tle.Line1,
+		Line2:         tle.Line2,
+		MeanMotion:    elements.MeanMotion,
+		MeanMotionDot: elements.MeanMotionDot,
+		Eccentricity:  elements.Eccentricity,
+		Inclination:   elements.Inclination,
+		RAAN:          elements.RAAN,
+		ArgPerigee:    elements.ArgPerigee,
+		MeanAnomaly:   elements.MeanAnomaly,
+		BStar:         elements.BStar,
+		Source:        tle.Source,
+	}, nil
+}
+
+// TLE returns the element set as a TLE for propagation.
+func (e *ElementSet) TLE() *TLE {
+	return &TLE{
+		SatelliteID: e.NoradID,
+		Name:        e.Name,
+		Line1:       e.Line1,
+		Line2:       e.Line2,
+		Epoch:       e.Epoch,
+		RetrievedAt: e.ImportedAt,
+		Source:      e.Source,
+	}
+}
+
+// DeepSpace reports whether the orbit period is 225 minutes or more.
+func (e *ElementSet) DeepSpace() bool {
+	return e.MeanMotion > 0 && 1440/e.MeanMotion >= 225
+}
+
+// ErrElementSetNotFound is returned by a CatalogStore with no matching
+// element set.
+var ErrElementSetNotFound = errors.New("element set not found")
+
+// CatalogStore persists element set history. SaveElementSet reports false
+// when an element set for the same object and epoch is already stored.
+type CatalogStore interface {
+	SaveElementSet(ctx context.Context, es *ElementSet) (bool, error)
+	PreviousElementSet(ctx context.Context, noradID int, before time.Time) (*ElementSet, error)
+	ElementSetHistory(ctx context.Context, noradID int, limit int) ([]*ElementSet, error)
+	LatestElementSets(ctx context.Context) ([]*ElementSet, error)
+	ManeuverElementSets(ctx context.Context, since time.Time, limit int) ([]*ElementSet, error)
+}
+
+// CatalogConfig holds catalog staleness and maneuver detection thresholds.
+type CatalogConfig struct {
+	// Element sets older than this are stale
+	StaleAfter          time.Duration
+	DeepSpaceStaleAfter time.Duration
+
+	// A change in mean motion beyond that predicted by the first
+	// derivative, or in inclination, between consecutive epochs at most
+	// ManeuverWindow apart is reported as a maneuver
+	MeanMotionThreshold  float64 // rev/day
+	InclinationThreshold float64 // degrees
+	ManeuverWindow       time.Duration
+}
+
+// DefaultCatalogConfig returns thresholds suited to public TLEs: low orbits
+// are refreshed several times a day, and the thresholds sit above the
+// epoch-to-epoch noise in the published fits.
+func DefaultCatalogConfig() CatalogConfig {
+	return CatalogConfig{
+		StaleAfter:           3 * 24 * time.Hour,
+		DeepSpaceStaleAfter:  14 * 24 * time.Hour,
+		MeanMotionThreshold:  1e-3,
+		InclinationThreshold: 0.01,
+		ManeuverWindow:       7 * 24 * time.Hour,
+	}
+}
+
+// CatalogImportResult summarises an import.
+type CatalogImportResult struct {
+	Parsed     int           `json:"parsed"`
+	Imported   int           `json:"imported"`
+	Duplicates int           `json:"duplicates"`
+	Maneuvers  []*ElementSet `json:"maneuvers"`
+}
+
+// CatalogEntry is the latest element set for an object and its age.
+type CatalogEntry struct {
+	*ElementSet
+	AgeHours float64 `json:"age_hours"`
+	Stale    bool    `json:"stale"`
+}
+
+// Catalog ingests bulk element set files into a store, keeping every epoch
+// and flagging maneuvers as they arrive.
+type Catalog struct {
+	mu    sync.Mutex // Serialises imports so maneuver checks see prior epochs
+	store CatalogStore
+	cfg   CatalogConfig
+}
+
+// NewCatalog creates a catalog backed by store.
+func NewCatalog(store CatalogStore, cfg CatalogConfig) *Catalog {
+	return &Catalog{store: store, cfg: cfg}
+}
+
+// Import parses an element set file and stores every epoch not already
+// present. An empty format is detected from the content.
+func (c *Catalog) Import(ctx context.Context, r io.Reader, format CatalogFormat) (*CatalogImportResult, error) {
+	tles, err := ReadCatalog(r, format)
+	if err != nil {
+		return nil, err
+	}
+	return c.ImportTLEs(ctx, tles)
+}
+
+// ImportTLEs stores parsed TLEs, oldest epoch first so that each is
+// compared with its predecessor. An epoch that arrives after later ones are
+// already stored is checked against its predecessor only.
+func (c *Catalog) ImportTLEs(ctx context.Context, tles []*TLE) (*CatalogImportResult, error) {
+	sets := make([]*ElementSet, 0, len(tles))
+	for _, tle := range tles {
+		es, err := NewElementSet(tle)
+		if err != nil {
+			return nil, fmt.Errorf("element set %d: %w", tle.SatelliteID, err)
+		}
+		sets = append(sets, es)
+	}
+	sort.SliceStable(sets, func(i, j int) bool { return sets[i].Epoch.Before(sets[j].Epoch) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := &CatalogImportResult{Parsed: len(sets), Maneuvers: make([]*ElementSet, 0)}
+	now := time.Now().UTC()
+	for _, es := range sets {
+		es.ImportedAt = now
+		prev, err := c.store.PreviousElementSet(ctx, es.NoradID, es.Epoch)
+		if err != nil && !errors.Is(err, ErrElementSetNotFound) {
+			return result, fmt.Errorf("look up previous element set for %d: %w", es.NoradID, err)
+		}
+		if prev != nil {
+			es.Maneuver, es.ManeuverReason = c.detectManeuver(prev, es)
+		}
+		saved, err := c.store.SaveElementSet(ctx, es)
+		if err != nil {
+			return result, fmt.Errorf("save element set for %d: %w", es.NoradID, err)
+		}
+		if !saved {
+			result.Duplicates++
+			continue
+		}
+		result.Imported++
+		if es.Maneuver {
+			result.Maneuvers = append(result.Maneuvers, es)
+			log.Printf("[Catalog] Maneuver detected for %s (%d) at %s: %s",
+				es.Name, es.NoradID, es.Epoch.Format(time.RFC3339), es.ManeuverReason)
+		}
+	}
+	return result, nil
+}
+
+// detectManeuver compares consecutive element sets. Mean motion is
+// predicted forward with the first derivative so that drag decay is not
+// mistaken for a burn.
+func (c *Catalog) detectManeuver(prev, cur *ElementSet) (bool, string) {
+	dt := cur.Epoch.Sub(prev.Epoch)
+	if dt <= 0 || dt > c.cfg.ManeuverWindow {
+		return false, ""
+	}
+	days := dt.Hours() / 24
+	predicted := prev.MeanMotion + 2*prev.MeanMotionDot*days
+	var reasons []string
+	if dn := cur.MeanMotion - predicted; math.Abs(dn) > c.cfg.MeanMotionThreshold {
+		reasons = append(reasons, fmt.Sprintf("mean motion changed by %.5f rev/day beyond decay", dn))
+	}
+	if di := cur.Inclination - prev.Inclination; math.Abs(di) > c.cfg.InclinationThreshold {
+		reasons = append(reasons, fmt.Sprintf("inclination changed by %.4f°", di))
+	}
+	if len(reasons) == 0 {
+		return false, ""
+	}
+	return true, strings.Join(reasons, "; ")
+}
+
+// Latest returns the newest element set for every object with its
+// staleness at now, ordered by catalog number.
+func (c *Catalog) Latest(ctx context.Context, now time.Time) ([]CatalogEntry, error) {
+	sets, err := c.store.LatestElementSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]CatalogEntry, 0, len(sets))
+	for _, es := range sets {
+		entries = append(entries, c.entry(es, now))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].NoradID < entries[j].NoradID })
+	return entries, nil
+}
+
+// Stale returns the objects whose newest element set is stale at now.
+func (c *Catalog) Stale(ctx context.Context, now time.Time) ([]CatalogEntry, error) {
+	entries, err := c.Latest(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	stale := make([]CatalogEntry, 0)
+	for _, e := range entries {
+		if e.Stale {
+			stale = append(stale, e)
+		}
+	}
+	return stale, nil
+}
+
+// Get returns the newest element set for an object.
+func (c *Catalog) Get(ctx context.Context, noradID int, now time.Time) (*CatalogEntry, error) {
+	history, err := c.store.ElementSetHistory(ctx, noradID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, ErrElementSetNotFound
+	}
+	entry := c.entry(history[0], now)
+	return &entry, nil
+}
+
+// History returns up to limit epochs for an object, newest first.
+func (c *Catalog) History(ctx context.Context, noradID int, limit int) ([]*ElementSet, error) {
+	return c.store.ElementSetHistory(ctx, noradID, limit)
+}
+
+// Maneuvers returns up to limit element sets flagged as maneuvers with
+// epochs after since, newest first.
+func (c *Catalog) Maneuvers(ctx context.Context, since time.Time, limit int) ([]*ElementSet, error) {
+	return c.store.ManeuverElementSets(ctx, since, limit)
+}
+
+// TLEs returns the newest TLE for every object, for pass prediction and
+// screening over the whole catalog.
+func (c *Catalog) TLEs(ctx context.Context) ([]*TLE, error) {
+	sets, err := c.store.LatestElementSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tles := make([]*TLE, 0, len(sets))
+	for _, es := range sets {
+		tles = append(tles, es.TLE())
+	}
+	return tles, nil
+}
+
+func (c *Catalog) entry(es *ElementSet, now time.Time) CatalogEntry {
+	age := now.Sub(es.Epoch)
+	limit := c.cfg.StaleAfter
+	if es.DeepSpace() {
+		limit = c.cfg.DeepSpaceStaleAfter
+	}
+	return CatalogEntry{ElementSet: es, AgeHours: age.Hours(), Stale: age > limit}
+}
+
+// MemoryCatalogStore is a CatalogStore for tools and tests that run
+// without Postgres.
+type MemoryCatalogStore struct {
+	mu      sync.RWMutex
+	history map[int][]*ElementSet // Ordered by epoch
+}
+
+// NewMemoryCatalogStore creates an empty in-memory store.
+func NewMemoryCatalogStore() *MemoryCatalogStore {
+	return &MemoryCatalogStore{history: make(map[int][]*ElementSet)}
+}
+
+// SaveElementSet implements CatalogStore.
+func (s *MemoryCatalogStore) SaveElementSet(ctx context.Context, es *ElementSet) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sets := s.history[es.NoradID]
+	i := sort.Search(len(sets), func(i int) bool { return !sets[i].Epoch.Before(es.Epoch) })
+	if i < len(sets) && sets[i].Epoch.Equal(es.Epoch) {
+		return false, nil
+	}
+	sets = append(sets, nil)
+	copy(sets[i+1:], sets[i:])
+	sets[i] = es
+	s.history[es.NoradID] = sets
+	return true, nil
+}
+
+// PreviousElementSet implements CatalogStore.
+func (s *MemoryCatalogStore) PreviousElementSet(ctx context.Context, noradID int, before time.Time) (*ElementSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sets := s.history[noradID]
+	i := sort.Search(len(sets), func(i int) bool { return !sets[i].Epoch.Before(before) })
+	if i == 0 {
+		return nil, ErrElementSetNotFound
+	}
+	return sets[i-1], nil
+}
+
+// ElementSetHistory implements CatalogStore.
+func (s *MemoryCatalogStore) ElementSetHistory(ctx context.Context, noradID int, limit int) ([]*ElementSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sets := s.history[noradID]
+	out := make([]*ElementSet, 0, len(sets))
+	for i := len(sets) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		out = append(out, sets[i])
+	}
+	return out, nil
+}
+
+// LatestElementSets implements CatalogStore.
+func (s *MemoryCatalogStore) LatestElementSets(ctx context.Context) ([]*ElementSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*ElementSet, 0, len(s.history))
+	for _, sets := range s.history {
+		out = append(out, sets[len(sets)-1])
+	}
+	return out, nil
+}
+
+// ManeuverElementSets implements CatalogStore.
+func (s *MemoryCatalogStore) ManeuverElementSets(ctx context.Context, since time.Time, limit int) ([]*ElementSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*ElementSet, 0)
+	for _, sets := range s.history {
+		for _, es := range sets {
+			if es.Maneuver && es.Epoch.After(since) {
+				out = append(out, es)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Epoch.After(out[j].Epoch) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -122,6 +122,11 @@ type SatelliteAbove struct {
 	Latitude      float64 `json:"satlat"`
 	Longitude     float64 `json:"satlng"`
 	Altitude      float64 `json:"satalt"` // km
+
+	// Look angles from the observer, set by local prediction only
+	Azimuth   float64 `json:"azimuth,omitempty"`   // degrees
+	Elevation float64 `json:"elevation,omitempty"` // degrees
+	Range     float64 `json:"range,omitempty"`     // km
 }
 
 // Observer represents a ground observer's location.
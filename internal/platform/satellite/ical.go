@@ -0,0 +1,78 @@
+package satellite
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// icalTimeFormat is the RFC 5545 UTC date-time form.
+const icalTimeFormat = "20060102T150405Z"
+
+// WritePassCalendar writes passes as an RFC 5545 iCalendar feed, one event
+// per pass from AOS to LOS. generated is used as the DTSTAMP of every event
+// so that regenerating an unchanged feed produces identical output.
+func WritePassCalendar(w io.Writer, passes []Pass, generated time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(foldICalLine(s))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//ASGARD//Satellite Pass Predictor//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	stamp := generated.UTC().Format(icalTimeFormat)
+	for _, p := range passes {
+		summary := fmt.Sprintf("%s pass, max %.0f°", p.Name, p.TCA.Elevation)
+		if p.Visible {
+			summary += " (visible)"
+		}
+		description := fmt.Sprintf("AOS %s az %.0f°\nTCA %s az %.0f° el %.1f° range %.0f km\nLOS %s az %.0f°",
+			p.AOS.Time.UTC().Format("15:04:05"), p.AOS.Azimuth,
+			p.TCA.Time.UTC().Format("15:04:05"), p.TCA.Azimuth, p.TCA.Elevation, p.TCA.Range,
+			p.LOS.Time.UTC().Format("15:04:05"), p.LOS.Azimuth)
+		if p.Visible {
+			description += fmt.Sprintf("\nVisible %s-%s",
+				p.VisibleStart.UTC().Format("15:04:05"), p.VisibleEnd.UTC().Format("15:04:05"))
+		}
+
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:%d-%d@asgard", p.SatelliteID, p.AOS.Time.Unix()))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + p.AOS.Time.UTC().Format(icalTimeFormat))
+		line("DTEND:" + p.LOS.Time.UTC().Format(icalTimeFormat))
+		line("SUMMARY:" + escapeICalText(summary))
+		line("DESCRIPTION:" + escapeICalText(description))
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escapeICalText escapes a TEXT property value.
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICalLine splits a content line into lines of at most 75 octets,
+// continuing each with a single space. Multi-byte characters are not split.
+func foldICalLine(s string) string {
+	const limit = 75
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
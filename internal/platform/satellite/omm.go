@@ -0,0 +1,380 @@
+package satellite
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OMM is a CCSDS Orbit Mean-Elements Message carrying SGP4 mean elements,
+// as distributed by CelesTrak and Space-Track alongside TLEs.
+type OMM struct {
+	ObjectName         string
+	ObjectID           string // COSPAR ID, e.g. "1998-067A"
+	Epoch              time.Time
+	MeanMotion         float64 // rev/day
+	Eccentricity       float64
+	Inclination        float64 // degrees
+	RAAN               float64 // degrees
+	ArgPericenter      float64 // degrees
+	MeanAnomaly        float64 // degrees
+	EphemerisType      int
+	ClassificationType string
+	NoradCatID         int
+	ElementSetNo       int
+	RevAtEpoch         int
+	BStar              float64 // 1/earth radii
+	MeanMotionDot      float64 // rev/day², already halved as in the TLE
+	MeanMotionDDot     float64 // rev/day³, already divided by six as in the TLE
+}
+
+// ommEpochLayouts are the CCSDS ASCII time forms seen in OMM EPOCH fields.
+var ommEpochLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-002T15:04:05.999999999Z07:00",
+	"2006-002T15:04:05.999999999",
+}
+
+// newOMM builds an OMM from keyword/value pairs, the common form of the
+// KVN, XML and JSON encodings.
+func newOMM(fields map[string]string) (*OMM, error) {
+	o := &OMM{
+		ObjectName:         fields["OBJECT_NAME"],
+		ObjectID:           fields["OBJECT_ID"],
+		ClassificationType: fields["CLASSIFICATION_TYPE"],
+	}
+	if theory := fields["MEAN_ELEMENT_THEORY"]; theory != "" && !strings.EqualFold(theory, "SGP4") && !strings.EqualFold(theory, "SGP/SGP4") {
+		return nil, fmt.Errorf("OMM %s: unsupported mean element theory %q", o.ObjectName, theory)
+	}
+
+	epoch := fields["EPOCH"]
+	if epoch == "" {
+		return nil, fmt.Errorf("OMM %s: missing EPOCH", o.ObjectName)
+	}
+	for _, layout := range ommEpochLayouts {
+		if t, err := time.Parse(layout, epoch); err == nil {
+			o.Epoch = t.UTC()
+			break
+		}
+	}
+	if o.Epoch.IsZero() {
+		return nil, fmt.Errorf("OMM %s: unrecognised EPOCH %q", o.ObjectName, epoch)
+	}
+
+	floats := []struct {
+		key      string
+		dst      *float64
+		required bool
+	}{
+		{"MEAN_MOTION", &o.MeanMotion, true},
+		{"ECCENTRICITY", &o.Eccentricity, true},
+		{"INCLINATION", &o.Inclination, true},
+		{"RA_OF_ASC_NODE", &o.RAAN, true},
+		{"ARG_OF_PERICENTER", &o.ArgPericenter, true},
+		{"MEAN_ANOMALY", &o.MeanAnomaly, true},
+		{"BSTAR", &o.BStar, false},
+		{"MEAN_MOTION_DOT", &o.MeanMotionDot, false},
+		{"MEAN_MOTION_DDOT", &o.MeanMotionDDot, false},
+	}
+	for _, f := range floats {
+		s := fields[f.key]
+		if s == "" {
+			if f.required {
+				return nil, fmt.Errorf("OMM %s: missing %s", o.ObjectName, f.key)
+			}
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("OMM %s: parse %s: %w", o.ObjectName, f.key, err)
+		}
+		*f.dst = v
+	}
+
+	ints := []struct {
+		key      string
+		dst      *int
+		required bool
+	}{
+		{"NORAD_CAT_ID", &o.NoradCatID, true},
+		{"EPHEMERIS_TYPE", &o.EphemerisType, false},
+		{"ELEMENT_SET_NO", &o.ElementSetNo, false},
+		{"REV_AT_EPOCH", &o.RevAtEpoch, false},
+	}
+	for _, f := range ints {
+		s := fields[f.key]
+		if s == "" {
+			if f.required {
+				return nil, fmt.Errorf("OMM %s: missing %s", o.ObjectName, f.key)
+			}
+			continue
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("OMM %s: parse %s: %w", o.ObjectName, f.key, err)
+		}
+		*f.dst = v
+	}
+	return o, nil
+}
+
+// ReadOMMKVN parses one or more OMMs in CCSDS keyword=value notation. Each
+// message starts with CCSDS_OMM_VERS; units in square brackets are ignored.
+func ReadOMMKVN(r io.Reader) ([]*OMM, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read OMM: %w", err)
+	}
+	var messages []*OMM
+	var fields map[string]string
+	flush := func() error {
+		if fields == nil {
+			return nil
+		}
+		o, err := newOMM(fields)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, o)
+		return nil
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("OMM line %d: expected KEYWORD = value", i+1)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if j := strings.Index(value, "["); j >= 0 {
+			value = strings.TrimSpace(value[:j])
+		}
+		if key == "CCSDS_OMM_VERS" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			fields = make(map[string]string)
+		}
+		if fields == nil {
+			return nil, fmt.Errorf("OMM line %d: missing CCSDS_OMM_VERS header", i+1)
+		}
+		fields[key] = value
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ReadOMMXML parses the CCSDS NDM/XML encoding: a single <omm> element or
+// an <ndm> wrapping several.
+func ReadOMMXML(r io.Reader) ([]*OMM, error) {
+	dec := xml.NewDecoder(r)
+	var messages []*OMM
+	var fields map[string]string
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse OMM XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if strings.EqualFold(t.Name.Local, "omm") {
+				fields = make(map[string]string)
+			}
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if fields == nil {
+				continue
+			}
+			if strings.EqualFold(t.Name.Local, "omm") {
+				o, err := newOMM(fields)
+				if err != nil {
+					return nil, err
+				}
+				messages = append(messages, o)
+				fields = nil
+				continue
+			}
+			if value := strings.TrimSpace(text.String()); value != "" {
+				fields[t.Name.Local] = value
+			}
+			text.Reset()
+		}
+	}
+	return messages, nil
+}
+
+// ReadOMMJSON parses the flat JSON encoding used by CelesTrak and
+// Space-Track: an array of objects keyed by OMM keyword, or a single object.
+// Numeric fields may be JSON numbers or strings.
+func ReadOMMJSON(r io.Reader) ([]*OMM, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read OMM: %w", err)
+	}
+	var objects []map[string]interface{}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		var single map[string]interface{}
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("parse OMM JSON: %w", err)
+		}
+		objects = append(objects, single)
+	} else if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("parse OMM JSON: %w", err)
+	}
+
+	messages := make([]*OMM, 0, len(objects))
+	for _, obj := range objects {
+		fields := make(map[string]string, len(obj))
+		for k, v := range obj {
+			switch v := v.(type) {
+			case string:
+				fields[k] = v
+			case float64:
+				fields[k] = strconv.FormatFloat(v, 'g', -1, 64)
+			}
+		}
+		o, err := newOMM(fields)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, o)
+	}
+	return messages, nil
+}
+
+// TLE formats the OMM as a two-line element set so it can be propagated
+// and stored like any other. Catalog numbers above 99999 have no TLE form.
+func (o *OMM) TLE() (*TLE, error) {
+	if o.NoradCatID < 0 || o.NoradCatID > 99999 {
+		return nil, fmt.Errorf("catalog number %d does not fit a TLE", o.NoradCatID)
+	}
+	if o.Eccentricity < 0 || o.Eccentricity >= 1 {
+		return nil, fmt.Errorf("OMM %s: eccentricity %g out of range", o.ObjectName, o.Eccentricity)
+	}
+	class := "U"
+	if o.ClassificationType != "" {
+		class = o.ClassificationType[:1]
+	}
+	ndot, err := formatTLEDecimal(o.MeanMotionDot)
+	if err != nil {
+		return nil, fmt.Errorf("OMM %s: mean motion derivative: %w", o.ObjectName, err)
+	}
+	nddot, err := formatTLEExponent(o.MeanMotionDDot)
+	if err != nil {
+		return nil, fmt.Errorf("OMM %s: mean motion second derivative: %w", o.ObjectName, err)
+	}
+	bstar, err := formatTLEExponent(o.BStar)
+	if err != nil {
+		return nil, fmt.Errorf("OMM %s: bstar: %w", o.ObjectName, err)
+	}
+
+	yearStart := time.Date(o.Epoch.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	epochDay := o.Epoch.Sub(yearStart).Hours()/24 + 1
+
+	line1 := fmt.Sprintf("1 %05d%s %-8s %02d%012.8f %s %s %s %d %4d",
+		o.NoradCatID, class, tleDesignator(o.ObjectID), o.Epoch.Year()%100, epochDay,
+		ndot, nddot, bstar, o.EphemerisType, o.ElementSetNo%10000)
+	line2 := fmt.Sprintf("2 %05d %8.4f %8.4f %07d %8.4f %8.4f %11.8f%5d",
+		o.NoradCatID, o.Inclination, o.RAAN, int(math.Round(o.Eccentricity*1e7)),
+		o.ArgPericenter, o.MeanAnomaly, o.MeanMotion, o.RevAtEpoch%100000)
+	line1 += strconv.Itoa(tleChecksum(line1))
+	line2 += strconv.Itoa(tleChecksum(line2))
+
+	name := o.ObjectName
+	if name == "" {
+		name = strconv.Itoa(o.NoradCatID)
+	}
+	return &TLE{
+		SatelliteID: o.NoradCatID,
+		Name:        name,
+		Line1:       line1,
+		Line2:       line2,
+		Epoch:       o.Epoch,
+		Source:      "omm",
+	}, nil
+}
+
+// tleDesignator converts a COSPAR ID such as "1998-067A" to the TLE form
+// "98067A".
+func tleDesignator(objectID string) string {
+	if len(objectID) < 9 || objectID[4] != '-' {
+		return ""
+	}
+	return objectID[2:4] + objectID[5:]
+}
+
+// formatTLEDecimal formats the first derivative field, e.g. " .00001764".
+func formatTLEDecimal(v float64) (string, error) {
+	if math.Abs(v) >= 1 {
+		return "", fmt.Errorf("%g does not fit the field", v)
+	}
+	sign := " "
+	if v < 0 {
+		sign = "-"
+	}
+	digits := int(math.Round(math.Abs(v) * 1e8))
+	if digits >= 1e8 {
+		return "", fmt.Errorf("%g does not fit the field", v)
+	}
+	return fmt.Sprintf("%s.%08d", sign, digits), nil
+}
+
+// formatTLEExponent formats a value in the TLE assumed-decimal exponent
+// notation, e.g. 0.28098e-4 as " 28098-4". It is the inverse of
+// parseTLEExponent.
+func formatTLEExponent(v float64) (string, error) {
+	if v == 0 {
+		return " 00000-0", nil
+	}
+	sign := " "
+	if v < 0 {
+		sign = "-"
+	}
+	a := math.Abs(v)
+	exp := int(math.Floor(math.Log10(a))) + 1
+	mantissa := int(math.Round(a / math.Pow(10, float64(exp)) * 1e5))
+	if mantissa >= 100000 {
+		mantissa /= 10
+		exp++
+	}
+	if exp < -9 || exp > 9 {
+		return "", fmt.Errorf("%g does not fit the field", v)
+	}
+	expSign := "+"
+	if exp < 0 {
+		expSign = "-"
+	}
+	return fmt.Sprintf("%s%05d%s%d", sign, mantissa, expSign, int(math.Abs(float64(exp)))), nil
+}
+
+// tleChecksum is the modulo-10 sum of the digits in a TLE line, counting
+// minus signs as one.
+func tleChecksum(line string) int {
+	sum := 0
+	for _, c := range line {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return sum % 10
+}
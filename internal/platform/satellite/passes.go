@@ -0,0 +1,252 @@
+package satellite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// PassConfig controls local pass prediction.
+type PassConfig struct {
+	MinElevation    float64       // Elevation mask, degrees
+	Step            time.Duration // Coarse search step; passes shorter than this may be missed
+	Precision       time.Duration // AOS, TCA and LOS are refined to this
+	MaxSunElevation float64       // Observer is dark when the Sun is below this, degrees
+	VisibilityStep  time.Duration // Sampling step for the visibility checks
+}
+
+// DefaultPassConfig returns a 10° mask with civil-twilight darkness.
+func DefaultPassConfig() PassConfig {
+	return PassConfig{
+		MinElevation:    10,
+		Step:            30 * time.Second,
+		Precision:       100 * time.Millisecond,
+		MaxSunElevation: -6,
+		VisibilityStep:  10 * time.Second,
+	}
+}
+
+// PassPoint is the satellite's direction from the observer at one instant.
+type PassPoint struct {
+	Time      time.Time `json:"time"`
+	Azimuth   float64   `json:"azimuth"`   // degrees
+	Elevation float64   `json:"elevation"` // degrees
+	Range     float64   `json:"range"`     // km
+}
+
+// Pass is a locally predicted pass over an observer: acquisition of signal
+// (AOS), time of closest approach (TCA) and loss of signal (LOS) at the
+// elevation mask.
+type Pass struct {
+	SatelliteID     int       `json:"satellite_id"`
+	Name            string    `json:"name"`
+	AOS             PassPoint `json:"aos"`
+	TCA             PassPoint `json:"tca"`
+	LOS             PassPoint `json:"los"`
+	DurationSeconds float64   `json:"duration_seconds"`
+
+	// Visible is set when the satellite is sunlit while the observer is
+	// in darkness for part of the pass.
+	Visible      bool      `json:"visible"`
+	VisibleStart time.Time `json:"visible_start,omitempty"`
+	VisibleEnd   time.Time `json:"visible_end,omitempty"`
+}
+
+// observerGeodetic converts an observer to frames coordinates.
+func observerGeodetic(o Observer) frames.Geodetic {
+	return frames.Geodetic{Latitude: o.Latitude, Longitude: o.Longitude, Altitude: o.Altitude / 1000}
+}
+
+// Look returns the look angles from an observer to the satellite at t.
+func (p *Propagator) Look(observer Observer, t time.Time) (frames.LookAngles, error) {
+	state, err := p.PropagateState(t)
+	if err != nil {
+		return frames.LookAngles{}, err
+	}
+	r, v := frames.TEMEToITRF(state.Position, state.Velocity, t, frames.EOP{})
+	return frames.Look(observerGeodetic(observer), r, v), nil
+}
+
+// PredictPasses finds the passes of a satellite over an observer that begin
+// between start and end. A pass in progress at start is reported from
+// start; a pass in progress at end is followed to its LOS.
+func PredictPasses(tle *TLE, observer Observer, start, end time.Time, cfg PassConfig) ([]Pass, error) {
+	p, err := NewPropagator(tle)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Step <= 0 || cfg.Precision <= 0 {
+		return nil, fmt.Errorf("pass search step and precision must be positive")
+	}
+
+	// Elevation above the mask; decayed orbits are never above it
+	above := func(t time.Time) float64 {
+		look, err := p.Look(observer, t)
+		if err != nil {
+			return -180
+		}
+		return look.Elevation - cfg.MinElevation
+	}
+	point := func(t time.Time) PassPoint {
+		look, _ := p.Look(observer, t)
+		return PassPoint{Time: t, Azimuth: look.Azimuth, Elevation: look.Elevation, Range: look.Range}
+	}
+
+	var passes []Pass
+	t := start
+	prev := above(t)
+	var aos time.Time
+	if prev >= 0 {
+		aos = start
+	}
+	// Follow an open pass past end for at most a day
+	limit := end.Add(24 * time.Hour)
+	for t.Before(limit) {
+		if aos.IsZero() && !t.Before(end) {
+			break
+		}
+		next := t.Add(cfg.Step)
+		cur := above(next)
+		switch {
+		case prev < 0 && cur >= 0:
+			aos = refineCrossing(above, t, next, cfg.Precision)
+		case prev >= 0 && cur < 0 && !aos.IsZero():
+			los := refineCrossing(above, t, next, cfg.Precision)
+			tca := refineMaximum(above, aos, los, cfg.Precision)
+			pass := Pass{
+				SatelliteID:     tle.SatelliteID,
+				Name:            tle.Name,
+				AOS:             point(aos),
+				TCA:             point(tca),
+				LOS:             point(los),
+				DurationSeconds: los.Sub(aos).Seconds(),
+			}
+			markVisibility(p, observer, &pass, cfg)
+			passes = append(passes, pass)
+			aos = time.Time{}
+		}
+		t, prev = next, cur
+	}
+	return passes, nil
+}
+
+// refineCrossing bisects for the time f changes sign between lo and hi.
+func refineCrossing(f func(time.Time) float64, lo, hi time.Time, precision time.Duration) time.Time {
+	rising := f(lo) < 0
+	for hi.Sub(lo) > precision {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if (f(mid) < 0) == rising {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo.Add(hi.Sub(lo) / 2)
+}
+
+// refineMaximum finds the maximum of a unimodal f on [lo, hi] by golden
+// section search.
+func refineMaximum(f func(time.Time) float64, lo, hi time.Time, precision time.Duration) time.Time {
+	const invPhi = 0.6180339887498949
+	a, b := 0.0, hi.Sub(lo).Seconds()
+	at := func(s float64) time.Time { return lo.Add(time.Duration(s * float64(time.Second))) }
+	c, d := b-invPhi*(b-a), a+invPhi*(b-a)
+	fc, fd := f(at(c)), f(at(d))
+	for b-a > precision.Seconds() {
+		if fc > fd {
+			b, d, fd = d, c, fc
+			c = b - invPhi*(b-a)
+			fc = f(at(c))
+		} else {
+			a, c, fc = c, d, fd
+			d = a + invPhi*(b-a)
+			fd = f(at(d))
+		}
+	}
+	return at((a + b) / 2)
+}
+
+// markVisibility samples the pass for times when the satellite is sunlit
+// and the observer is in darkness.
+func markVisibility(p *Propagator, observer Observer, pass *Pass, cfg PassConfig) {
+	site := observerGeodetic(observer)
+	step := cfg.VisibilityStep
+	if step <= 0 {
+		step = 10 * time.Second
+	}
+	for t := pass.AOS.Time; !t.After(pass.LOS.Time); t = t.Add(step) {
+		state, err := p.PropagateState(t)
+		if err != nil {
+			return
+		}
+		if !frames.Sunlit(state.Position, frames.SunPosition(t)) || frames.SunElevation(site, t) >= cfg.MaxSunElevation {
+			continue
+		}
+		if !pass.Visible {
+			pass.Visible = true
+			pass.VisibleStart = t
+		}
+		pass.VisibleEnd = t
+	}
+}
+
+// SatellitesAbove propagates every TLE in a catalog to t and returns those
+// above minElevation for the observer, highest first. It is the local
+// counterpart of Client.GetSatellitesAbove. Element sets that cannot be
+// propagated to t are skipped.
+func SatellitesAbove(catalog []*TLE, observer Observer, t time.Time, minElevation float64) []SatelliteAbove {
+	above := make([]SatelliteAbove, 0)
+	for _, tle := range catalog {
+		p, err := NewPropagator(tle)
+		if err != nil {
+			continue
+		}
+		look, err := p.Look(observer, t)
+		if err != nil || look.Elevation < minElevation {
+			continue
+		}
+		lat, lon, alt, err := p.Propagate(t)
+		if err != nil {
+			continue
+		}
+		above = append(above, SatelliteAbove{
+			SatelliteID:   tle.SatelliteID,
+			Name:          tle.Name,
+			IntDesignator: InternationalDesignator(tle.Line1),
+			Latitude:      lat,
+			Longitude:     lon,
+			Altitude:      alt,
+			Azimuth:       look.Azimuth,
+			Elevation:     look.Elevation,
+			Range:         look.Range,
+		})
+	}
+	sort.Slice(above, func(i, j int) bool {
+		if above[i].Elevation == above[j].Elevation {
+			return above[i].SatelliteID < above[j].SatelliteID
+		}
+		return above[i].Elevation > above[j].Elevation
+	})
+	return above
+}
+
+// InternationalDesignator formats the COSPAR ID in TLE line 1 columns
+// 10-17, e.g. "98067A" as "1998-067A".
+func InternationalDesignator(line1 string) string {
+	if len(line1) < 17 {
+		return ""
+	}
+	id := line1[9:17]
+	year, launch := id[:2], id[2:]
+	if year == "  " {
+		return ""
+	}
+	century := "20"
+	if year >= "57" {
+		century = "19"
+	}
+	return century + year + "-" + strings.TrimRight(launch, " ")
+}
@@ -5,7 +5,10 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
 )
 
 // SGP4Elements holds parsed TLE orbital elements.
@@ -50,21 +53,17 @@ func ParseTLE(line1, line2 string) (*SGP4Elements, error) {
 		return nil, fmt.Errorf("parse epoch day: %w", err)
 	}
 
-	// Mean motion derivatives
-	mmDotStr := strings.TrimSpace(line1[33:43])
-	elements.MeanMotionDot, _ = strconv.ParseFloat(mmDotStr, 64)
-
-	// BStar drag term (in scientific notation format)
-	bstarStr := strings.TrimSpace(line1[53:61])
-	if len(bstarStr) >= 6 {
-		mantissa := bstarStr[:6]
-		expStr := bstarStr[6:]
-		m, _ := strconv.ParseFloat("."+strings.TrimLeft(mantissa, " -+"), 64)
-		if strings.HasPrefix(bstarStr, "-") {
-			m = -m
-		}
-		exp, _ := strconv.Atoi(expStr)
-		elements.BStar = m * math.Pow(10, float64(exp))
+	// Mean motion derivatives. The second derivative and B* use the TLE
+	// exponent notation with an implied leading decimal point.
+	elements.MeanMotionDot, err = strconv.ParseFloat(strings.TrimSpace(line1[33:43]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse mean motion derivative: %w", err)
+	}
+	if elements.MeanMotionDDot, err = parseTLEExponent(line1[44:52]); err != nil {
+		return nil, fmt.Errorf("parse mean motion second derivative: %w", err)
+	}
+	if elements.BStar, err = parseTLEExponent(line1[53:61]); err != nil {
+		return nil, fmt.Errorf("parse bstar: %w", err)
 	}
 
 	// Line 2 parsing
@@ -90,139 +89,182 @@ func ParseTLE(line1, line2 string) (*SGP4Elements, error) {
 	return elements, nil
 }
 
-// Propagator computes satellite positions from TLE data using simplified SGP4.
+// parseTLEExponent parses a TLE field such as " 28098-4" or "-11606-4",
+// meaning 0.28098e-4 and -0.11606e-4.
+func parseTLEExponent(field string) (float64, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, nil
+	}
+	if len(field) < 3 {
+		return 0, fmt.Errorf("malformed exponent field %q", field)
+	}
+	sign := 1.0
+	switch field[0] {
+	case '-':
+		sign = -1
+		field = field[1:]
+	case '+', ' ':
+		field = field[1:]
+	}
+	mantissa, expStr := strings.TrimSpace(field[:len(field)-2]), field[len(field)-2:]
+	if mantissa == "" {
+		mantissa = "0"
+	}
+	m, err := strconv.ParseFloat("0."+mantissa, 64)
+	if err != nil {
+		return 0, err
+	}
+	exp, err := strconv.Atoi(strings.Replace(expStr, "+", "", 1))
+	if err != nil {
+		return 0, err
+	}
+	return sign * m * math.Pow(10, float64(exp)), nil
+}
+
+// Propagator computes satellite positions from TLE data with SGP4, or SDP4
+// for orbits with periods of 225 minutes or more.
 type Propagator struct {
 	elements *SGP4Elements
 	epoch    time.Time
+	mu       sync.Mutex // Deep space integrator state is updated on every call
+	rec      *sgp4Record
+}
+
+// StateVector is a satellite state in the True Equator Mean Equinox (TEME)
+// frame SGP4 works in.
+type StateVector struct {
+	Time     time.Time  `json:"time"`
+	Position [3]float64 `json:"position"` // km
+	Velocity [3]float64 `json:"velocity"` // km/s
 }
 
-// NewPropagator creates a propagator from TLE data.
+// NewPropagator creates a propagator from TLE data. It fails if the
+// elements are invalid or the orbit has already decayed at epoch.
 func NewPropagator(tle *TLE) (*Propagator, error) {
 	elements, err := ParseTLE(tle.Line1, tle.Line2)
 	if err != nil {
 		return nil, err
 	}
+	epochTime := time.Date(elements.EpochYear, 1, 1, 0, 0, 0, 0, time.UTC).
+		Add(time.Duration((elements.EpochDay - 1) * 24 * float64(time.Hour)))
 
-	// Calculate epoch time
-	year := elements.EpochYear
-	dayOfYear := elements.EpochDay
-	days := int(dayOfYear)
-	fraction := dayOfYear - float64(days)
+	// Epoch as a Julian date, kept in floating point for SGP4 and as a
+	// time for callers
+	jdEpoch := frames.JulianDate(time.Date(elements.EpochYear, 1, 1, 0, 0, 0, 0, time.UTC)) + elements.EpochDay - 1
+	p, err := newPropagator(elements, jdEpoch, epochTime)
+	if err != nil {
+		return nil, fmt.Errorf("initialize sgp4 for %d: %w", tle.SatelliteID, err)
+	}
+	return p, nil
+}
 
-	epochTime := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
-	epochTime = epochTime.AddDate(0, 0, days-1)
-	epochTime = epochTime.Add(time.Duration(fraction * 24 * float64(time.Hour)))
+// NewPropagatorFromOMM creates a propagator directly from OMM mean
+// elements, without the rounding of the TLE format.
+func NewPropagatorFromOMM(o *OMM) (*Propagator, error) {
+	yearStart := time.Date(o.Epoch.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := &SGP4Elements{
+		EpochYear:      o.Epoch.Year(),
+		EpochDay:       o.Epoch.Sub(yearStart).Hours()/24 + 1,
+		MeanMotion:     o.MeanMotion,
+		Eccentricity:   o.Eccentricity,
+		Inclination:    o.Inclination,
+		RAAN:           o.RAAN,
+		ArgPerigee:     o.ArgPericenter,
+		MeanAnomaly:    o.MeanAnomaly,
+		BStar:          o.BStar,
+		MeanMotionDot:  o.MeanMotionDot,
+		MeanMotionDDot: o.MeanMotionDDot,
+	}
+	p, err := newPropagator(elements, frames.JulianDate(o.Epoch), o.Epoch.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("initialize sgp4 for %s: %w", o.ObjectName, err)
+	}
+	return p, nil
+}
+
+func newPropagator(elements *SGP4Elements, jdEpoch float64, epoch time.Time) (*Propagator, error) {
+	const deg2rad = math.Pi / 180.0
+	rec, err := sgp4init(
+		jdEpoch-2433281.5,
+		elements.BStar,
+		elements.Eccentricity,
+		elements.ArgPerigee*deg2rad,
+		elements.Inclination*deg2rad,
+		elements.MeanAnomaly*deg2rad,
+		elements.MeanMotion*twoPi/1440.0,
+		elements.RAAN*deg2rad,
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Propagator{
 		elements: elements,
-		epoch:    epochTime,
+		epoch:    epoch,
+		rec:      rec,
 	}, nil
 }
 
-// Propagate computes the satellite position at a given time.
-// Returns latitude, longitude (degrees) and altitude (km).
-func (p *Propagator) Propagate(t time.Time) (lat, lon, alt float64) {
-	// Earth constants
-	const (
-		mu = 398600.4418 // km^3/s^2 - Earth gravitational parameter
-		Re = 6378.137    // km - Earth equatorial radius
-		J2 = 0.00108263  // J2 perturbation coefficient
-	)
-
-	// Minutes since epoch
-	minutesSinceEpoch := t.Sub(p.epoch).Minutes()
-
-	// Convert mean motion from rev/day to rad/min
-	n := p.elements.MeanMotion * 2 * math.Pi / 1440.0
-
-	// Semi-major axis from mean motion (Kepler's third law)
-	// n = sqrt(mu/a^3), so a = (mu/n^2)^(1/3)
-	// But n here is in rad/min, need to convert: n_rad_s = n / 60
-	nRadSec := n / 60.0
-	a := math.Pow(mu/(nRadSec*nRadSec), 1.0/3.0)
-
-	e := p.elements.Eccentricity
-	i := p.elements.Inclination * math.Pi / 180.0
-	omega0 := p.elements.RAAN * math.Pi / 180.0
-	w0 := p.elements.ArgPerigee * math.Pi / 180.0
-	M0 := p.elements.MeanAnomaly * math.Pi / 180.0
-
-	// J2 secular perturbations (rates in rad/min)
-	p0 := a * (1 - e*e)
-	omegaDot := -1.5 * n * J2 * math.Pow(Re/p0, 2) * math.Cos(i)
-	wDot := 0.75 * n * J2 * math.Pow(Re/p0, 2) * (5*math.Cos(i)*math.Cos(i) - 1)
+// Epoch returns the epoch of the element set.
+func (p *Propagator) Epoch() time.Time {
+	return p.epoch
+}
 
-	// Updated orbital elements
-	omega := omega0 + omegaDot*minutesSinceEpoch
-	w := w0 + wDot*minutesSinceEpoch
+// DeepSpace reports whether the propagator uses the SDP4 deep space terms.
+func (p *Propagator) DeepSpace() bool {
+	return p.rec.method == 'd'
+}
 
-	// Mean anomaly at time t
-	M := M0 + n*minutesSinceEpoch
-	M = math.Mod(M, 2*math.Pi)
-	if M < 0 {
-		M += 2 * math.Pi
+// PropagateState computes the TEME position and velocity at a given time.
+// Failures, including decay, are returned as SGP4Error.
+func (p *Propagator) PropagateState(t time.Time) (*StateVector, error) {
+	state, err := p.PropagateMinutes(t.Sub(p.epoch).Minutes())
+	if err != nil {
+		return nil, err
 	}
+	state.Time = t
+	return state, nil
+}
 
-	// Solve Kepler's equation for eccentric anomaly (Newton-Raphson)
-	E := M
-	for iter := 0; iter < 15; iter++ {
-		dE := (E - e*math.Sin(E) - M) / (1 - e*math.Cos(E))
-		E -= dE
-		if math.Abs(dE) < 1e-12 {
-			break
-		}
+// PropagateMinutes computes the TEME state tsince minutes from epoch.
+func (p *Propagator) PropagateMinutes(tsince float64) (*StateVector, error) {
+	p.mu.Lock()
+	r, v, err := p.rec.sgp4(tsince)
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
 	}
+	return &StateVector{
+		Time:     p.epoch.Add(time.Duration(tsince * float64(time.Minute))),
+		Position: r,
+		Velocity: v,
+	}, nil
+}
 
-	// True anomaly
-	sinNu := math.Sqrt(1-e*e) * math.Sin(E) / (1 - e*math.Cos(E))
-	cosNu := (math.Cos(E) - e) / (1 - e*math.Cos(E))
-	nu := math.Atan2(sinNu, cosNu)
-
-	// Distance from Earth center
-	r := a * (1 - e*math.Cos(E))
-
-	// Argument of latitude
-	u := w + nu
-
-	// Position in orbital plane (perifocal coordinates)
-	xPF := r * math.Cos(u)
-	yPF := r * math.Sin(u)
-
-	// Transform to ECI coordinates
-	cosO := math.Cos(omega)
-	sinO := math.Sin(omega)
-	cosI := math.Cos(i)
-	sinI := math.Sin(i)
-
-	xECI := xPF*cosO - yPF*sinO*cosI
-	yECI := xPF*sinO + yPF*cosO*cosI
-	zECI := yPF * sinI
-
-	// Greenwich Mean Sidereal Time
-	jd := timeToJD(t)
-	gmst := jdToGMST(jd)
-
-	// Convert ECI to ECEF (rotate by GMST)
-	cosGMST := math.Cos(gmst)
-	sinGMST := math.Sin(gmst)
-	xECEF := xECI*cosGMST + yECI*sinGMST
-	yECEF := -xECI*sinGMST + yECI*cosGMST
-	zECEF := zECI
-
-	// Convert ECEF to geodetic coordinates (simplified spherical)
-	rMag := math.Sqrt(xECEF*xECEF + yECEF*yECEF + zECEF*zECEF)
-	lat = math.Asin(zECEF/rMag) * 180.0 / math.Pi
-	lon = math.Atan2(yECEF, xECEF) * 180.0 / math.Pi
-	alt = rMag - Re
-
-	return lat, lon, alt
+// Propagate computes the satellite position at a given time.
+// Returns WGS-84 geodetic latitude, longitude (degrees) and altitude (km).
+// Failures, including decay, are returned as SGP4Error.
+func (p *Propagator) Propagate(t time.Time) (lat, lon, alt float64, err error) {
+	state, err := p.PropagateState(t)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	r, _ := frames.TEMEToITRF(state.Position, state.Velocity, t, frames.EOP{})
+	g := frames.ECEFToGeodetic(r)
+	return g.Latitude, g.Longitude, g.Altitude, nil
 }
 
-// PropagateRange returns positions over a time range.
-func (p *Propagator) PropagateRange(start time.Time, duration time.Duration, step time.Duration) []PropagatedPosition {
+// PropagateRange returns positions over a time range. It stops at the
+// first time the orbit cannot be propagated to, such as after decay, and
+// returns the positions up to then along with the error.
+func (p *Propagator) PropagateRange(start time.Time, duration time.Duration, step time.Duration) ([]PropagatedPosition, error) {
 	positions := make([]PropagatedPosition, 0)
 	for t := start; t.Before(start.Add(duration)); t = t.Add(step) {
-		lat, lon, alt := p.Propagate(t)
+		lat, lon, alt, err := p.Propagate(t)
+		if err != nil {
+			return positions, fmt.Errorf("propagate to %s: %w", t.Format(time.RFC3339), err)
+		}
 		positions = append(positions, PropagatedPosition{
 			Time:      t,
 			Latitude:  lat,
@@ -230,7 +272,7 @@ func (p *Propagator) PropagateRange(start time.Time, duration time.Duration, ste
 			Altitude:  alt,
 		})
 	}
-	return positions
+	return positions, nil
 }
 
 // PropagatedPosition represents a computed satellite position.
@@ -240,41 +282,3 @@ type PropagatedPosition struct {
 	Longitude float64   `json:"longitude"`
 	Altitude  float64   `json:"altitude"` // km
 }
-
-// timeToJD converts time to Julian Date.
-func timeToJD(t time.Time) float64 {
-	y := float64(t.Year())
-	m := float64(t.Month())
-	d := float64(t.Day())
-	h := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
-
-	if m <= 2 {
-		y--
-		m += 12
-	}
-
-	A := math.Floor(y / 100)
-	B := 2 - A + math.Floor(A/4)
-
-	jd := math.Floor(365.25*(y+4716)) + math.Floor(30.6001*(m+1)) + d + h/24 + B - 1524.5
-	return jd
-}
-
-// jdToGMST converts Julian Date to Greenwich Mean Sidereal Time (radians).
-func jdToGMST(jd float64) float64 {
-	// Julian centuries from J2000.0
-	T := (jd - 2451545.0) / 36525.0
-
-	// GMST in seconds
-	gmstSec := 67310.54841 +
-		(876600*3600+8640184.812866)*T +
-		0.093104*T*T -
-		6.2e-6*T*T*T
-
-	// Convert to radians
-	gmst := math.Mod(gmstSec*2*math.Pi/86400, 2*math.Pi)
-	if gmst < 0 {
-		gmst += 2 * math.Pi
-	}
-	return gmst
-}
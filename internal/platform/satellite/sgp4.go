@@ -0,0 +1,960 @@
+package satellite
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// This file is a port of the SGP4/SDP4 reference implementation published
+// with Vallado, Crawford, Hujsak and Kelso, "Revisiting Spacetrack Report
+// #3" (AIAA 2006-6753), using WGS-72 constants and the improved operation
+// mode. Variable names follow the reference code so the two can be compared
+// line by line.
+
+const (
+	twoPi = 2 * math.Pi
+	x2o3  = 2.0 / 3.0
+
+	// Inclination divisor guarding the 180 degree singularity.
+	sgp4Temp4 = 1.5e-12
+)
+
+// wgs72 holds the WGS-72 gravity constants used by SGP4.
+var wgs72 = struct {
+	mu, radiusEarthKm, xke, tumin, j2, j3, j4, j3oj2 float64
+}{
+	mu:            398600.8,
+	radiusEarthKm: 6378.135,
+	xke:           60.0 / math.Sqrt(6378.135*6378.135*6378.135/398600.8),
+	tumin:         math.Sqrt(6378.135*6378.135*6378.135/398600.8) / 60.0,
+	j2:            0.001082616,
+	j3:            -0.00000253881,
+	j4:            -0.00000165597,
+	j3oj2:         -0.00000253881 / 0.001082616,
+}
+
+// SGP4Error is a propagation failure. Codes match the reference
+// implementation.
+type SGP4Error int
+
+const (
+	ErrSGP4Eccentricity          SGP4Error = 1 // Mean eccentricity outside [0, 1)
+	ErrSGP4MeanMotion            SGP4Error = 2 // Mean motion not positive
+	ErrSGP4PerturbedEccentricity SGP4Error = 3 // Perturbed eccentricity outside [0, 1]
+	ErrSGP4SemiLatusRectum       SGP4Error = 4 // Negative semi-latus rectum
+	ErrSGP4Decayed               SGP4Error = 6 // Orbit has decayed below the Earth's surface
+)
+
+// Error implements error.
+func (e SGP4Error) Error() string {
+	switch e {
+	case ErrSGP4Eccentricity:
+		return "sgp4: mean eccentricity out of range"
+	case ErrSGP4MeanMotion:
+		return "sgp4: mean motion is not positive"
+	case ErrSGP4PerturbedEccentricity:
+		return "sgp4: perturbed eccentricity out of range"
+	case ErrSGP4SemiLatusRectum:
+		return "sgp4: semi-latus rectum is negative"
+	case ErrSGP4Decayed:
+		return "sgp4: satellite has decayed"
+	default:
+		return fmt.Sprintf("sgp4: error %d", int(e))
+	}
+}
+
+// sgp4Record is the element set and derived coefficients of one satellite
+// (elsetrec in the reference code).
+type sgp4Record struct {
+	isimp  bool
+	method byte // 'n' near Earth, 'd' deep space
+	init   bool
+
+	// Near Earth
+	aycof, con41, cc1, cc4, cc5, d2, d3, d4, delmo, eta, argpdot, omgcof,
+	sinmao, t, t2cof, t3cof, t4cof, t5cof, x1mth2, x7thm1, mdot, nodedot,
+	xlcof, xmcof, nodecf float64
+
+	// Deep space
+	irez                                                                 int
+	d2201, d2211, d3210, d3222, d4410, d4422, d5220, d5232, d5421, d5433 float64
+	dedt, del1, del2, del3, didt, dmdt, dnodt, domdt                     float64
+	e3, ee2, peo, pgho, pho, pinco, plo, se2, se3, sgh2, sgh3, sgh4      float64
+	sh2, sh3, si2, si3, sl2, sl3, sl4, gsto, xfact, xgh2, xgh3, xgh4     float64
+	xh2, xh3, xi2, xi3, xl2, xl3, xl4, xlamo, zmol, zmos, atime, xli     float64
+	xni                                                                  float64
+
+	// Elements
+	bstar, ecco, argpo, inclo, mo, no, nodeo float64
+}
+
+// sgp4init initializes a record from mean elements at epoch (days since
+// 1949 December 31 00:00 UT). Angles are radians and no is the Kozai mean
+// motion in radians per minute.
+func sgp4init(epoch, bstar, ecco, argpo, inclo, mo, no, nodeo float64) (*sgp4Record, error) {
+	s := &sgp4Record{
+		method: 'n',
+		bstar:  bstar,
+		ecco:   ecco,
+		argpo:  argpo,
+		inclo:  inclo,
+		mo:     mo,
+		no:     no,
+		nodeo:  nodeo,
+	}
+	radiusEarthKm := wgs72.radiusEarthKm
+	j2, j4, j3oj2 := wgs72.j2, wgs72.j4, wgs72.j3oj2
+
+	ss := 78.0/radiusEarthKm + 1.0
+	qzms2ttemp := (120.0 - 78.0) / radiusEarthKm
+	qzms2t := qzms2ttemp * qzms2ttemp * qzms2ttemp * qzms2ttemp
+
+	s.init = true
+	s.t = 0
+
+	in := initl(ecco, epoch, inclo, s.no)
+	s.no = in.no
+	s.con41 = in.con41
+	s.gsto = in.gsto
+	ao, con42, cosio, cosio2 := in.ao, in.con42, in.cosio, in.cosio2
+	eccsq, omeosq, posq, rp, rteosq, sinio := in.eccsq, in.omeosq, in.posq, in.rp, in.rteosq, in.sinio
+
+	if omeosq >= 0.0 || s.no >= 0.0 {
+		s.isimp = rp < (220.0/radiusEarthKm + 1.0)
+		sfour := ss
+		qzms24 := qzms2t
+		perige := (rp - 1.0) * radiusEarthKm
+
+		// For perigees below 156 km, s and qoms2t are altered
+		if perige < 156.0 {
+			sfour = perige - 78.0
+			if perige < 98.0 {
+				sfour = 20.0
+			}
+			qzms24temp := (120.0 - sfour) / radiusEarthKm
+			qzms24 = qzms24temp * qzms24temp * qzms24temp * qzms24temp
+			sfour = sfour/radiusEarthKm + 1.0
+		}
+		pinvsq := 1.0 / posq
+
+		tsi := 1.0 / (ao - sfour)
+		s.eta = ao * s.ecco * tsi
+		etasq := s.eta * s.eta
+		eeta := s.ecco * s.eta
+		psisq := math.Abs(1.0 - etasq)
+		coef := qzms24 * math.Pow(tsi, 4.0)
+		coef1 := coef / math.Pow(psisq, 3.5)
+		cc2 := coef1 * s.no * (ao*(1.0+1.5*etasq+eeta*(4.0+etasq)) +
+			0.375*j2*tsi/psisq*s.con41*(8.0+3.0*etasq*(8.0+etasq)))
+		s.cc1 = s.bstar * cc2
+		cc3 := 0.0
+		if s.ecco > 1.0e-4 {
+			cc3 = -2.0 * coef * tsi * j3oj2 * s.no * sinio / s.ecco
+		}
+		s.x1mth2 = 1.0 - cosio2
+		s.cc4 = 2.0 * s.no * coef1 * ao * omeosq *
+			(s.eta*(2.0+0.5*etasq) + s.ecco*(0.5+2.0*etasq) -
+				j2*tsi/(ao*psisq)*(-3.0*s.con41*(1.0-2.0*eeta+etasq*(1.5-0.5*eeta))+
+					0.75*s.x1mth2*(2.0*etasq-eeta*(1.0+etasq))*math.Cos(2.0*s.argpo)))
+		s.cc5 = 2.0 * coef1 * ao * omeosq * (1.0 + 2.75*(etasq+eeta) + eeta*etasq)
+		cosio4 := cosio2 * cosio2
+		temp1 := 1.5 * j2 * pinvsq * s.no
+		temp2 := 0.5 * temp1 * j2 * pinvsq
+		temp3 := -0.46875 * j4 * pinvsq * pinvsq * s.no
+		s.mdot = s.no + 0.5*temp1*rteosq*s.con41 + 0.0625*temp2*rteosq*(13.0-78.0*cosio2+137.0*cosio4)
+		s.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7.0-114.0*cosio2+395.0*cosio4) +
+			temp3*(3.0-36.0*cosio2+49.0*cosio4)
+		xhdot1 := -temp1 * cosio
+		s.nodedot = xhdot1 + (0.5*temp2*(4.0-19.0*cosio2)+2.0*temp3*(3.0-7.0*cosio2))*cosio
+		xpidot := s.argpdot + s.nodedot
+		s.omgcof = s.bstar * cc3 * math.Cos(s.argpo)
+		s.xmcof = 0.0
+		if s.ecco > 1.0e-4 {
+			s.xmcof = -x2o3 * coef * s.bstar / eeta
+		}
+		s.nodecf = 3.5 * omeosq * xhdot1 * s.cc1
+		s.t2cof = 1.5 * s.cc1
+		if math.Abs(cosio+1.0) > 1.5e-12 {
+			s.xlcof = -0.25 * j3oj2 * sinio * (3.0 + 5.0*cosio) / (1.0 + cosio)
+		} else {
+			s.xlcof = -0.25 * j3oj2 * sinio * (3.0 + 5.0*cosio) / sgp4Temp4
+		}
+		s.aycof = -0.5 * j3oj2 * sinio
+		delmotemp := 1.0 + s.eta*math.Cos(s.mo)
+		s.delmo = delmotemp * delmotemp * delmotemp
+		s.sinmao = math.Sin(s.mo)
+		s.x7thm1 = 7.0*cosio2 - 1.0
+
+		// Deep space initialization for periods of 225 minutes or more
+		if twoPi/s.no >= 225.0 {
+			s.method = 'd'
+			s.isimp = true
+			tc := 0.0
+			inclm := s.inclo
+
+			ds := dscom(epoch, s.ecco, s.argpo, tc, s.inclo, s.nodeo, s.no)
+			s.e3, s.ee2, s.peo, s.pgho, s.pho, s.pinco, s.plo = ds.e3, ds.ee2, ds.peo, ds.pgho, ds.pho, ds.pinco, ds.plo
+			s.se2, s.se3, s.sgh2, s.sgh3, s.sgh4, s.sh2, s.sh3 = ds.se2, ds.se3, ds.sgh2, ds.sgh3, ds.sgh4, ds.sh2, ds.sh3
+			s.si2, s.si3, s.sl2, s.sl3, s.sl4 = ds.si2, ds.si3, ds.sl2, ds.sl3, ds.sl4
+			s.xgh2, s.xgh3, s.xgh4, s.xh2, s.xh3 = ds.xgh2, ds.xgh3, ds.xgh4, ds.xh2, ds.xh3
+			s.xi2, s.xi3, s.xl2, s.xl3, s.xl4 = ds.xi2, ds.xi3, ds.xl2, ds.xl3, ds.xl4
+			s.zmol, s.zmos = ds.zmol, ds.zmos
+
+			s.ecco, s.inclo, s.nodeo, s.argpo, s.mo = s.dpper(s.t, inclm, true, s.ecco, s.inclo, s.nodeo, s.argpo, s.mo)
+
+			s.dsinit(ds, s.t, tc, xpidot, eccsq, inclm)
+		}
+
+		// Set variables if not deep space
+		if !s.isimp {
+			cc1sq := s.cc1 * s.cc1
+			s.d2 = 4.0 * ao * tsi * cc1sq
+			temp := s.d2 * tsi * s.cc1 / 3.0
+			s.d3 = (17.0*ao + sfour) * temp
+			s.d4 = 0.5 * temp * ao * tsi * (221.0*ao + 31.0*sfour) * s.cc1
+			s.t3cof = s.d2 + 2.0*cc1sq
+			s.t4cof = 0.25 * (3.0*s.d3 + s.cc1*(12.0*s.d2+10.0*cc1sq))
+			s.t5cof = 0.2 * (3.0*s.d4 + 12.0*s.cc1*s.d3 + 6.0*s.d2*s.d2 + 15.0*cc1sq*(2.0*s.d2+cc1sq))
+		}
+	}
+
+	// Propagate to epoch to finish initialization
+	_, _, err := s.sgp4(0)
+	s.init = false
+	return s, err
+}
+
+// initlResult holds the quantities initl derives from the mean elements.
+type initlResult struct {
+	no, ao, con41, con42, cosio, cosio2, eccsq, omeosq, posq, rp, rteosq, sinio, gsto float64
+}
+
+// initl recovers the Brouwer mean motion and computes common terms.
+func initl(ecco, epoch, inclo, no float64) initlResult {
+	xke, j2 := wgs72.xke, wgs72.j2
+	var r initlResult
+
+	r.eccsq = ecco * ecco
+	r.omeosq = 1.0 - r.eccsq
+	r.rteosq = math.Sqrt(r.omeosq)
+	r.cosio = math.Cos(inclo)
+	r.cosio2 = r.cosio * r.cosio
+
+	// Un-Kozai the mean motion
+	ak := math.Pow(xke/no, x2o3)
+	d1 := 0.75 * j2 * (3.0*r.cosio2 - 1.0) / (r.rteosq * r.omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1.0 - del*del - del*(1.0/3.0+134.0*del*del/81.0))
+	del = d1 / (adel * adel)
+	r.no = no / (1.0 + del)
+
+	r.ao = math.Pow(xke/r.no, x2o3)
+	r.sinio = math.Sin(inclo)
+	po := r.ao * r.omeosq
+	r.con42 = 1.0 - 5.0*r.cosio2
+	r.con41 = -r.con42 - r.cosio2 - r.cosio2
+	r.posq = po * po
+	r.rp = r.ao * (1.0 - ecco)
+
+	r.gsto = frames.GMST(epoch + 2433281.5)
+	return r
+}
+
+// dscomResult holds the deep space common terms computed by dscom.
+type dscomResult struct {
+	snodm, cnodm, sinim, cosim, sinomm, cosomm, day, e3, ee2, em, emsq, gam float64
+	peo, pgho, pho, pinco, plo, rtemsq                                      float64
+	se2, se3, sgh2, sgh3, sgh4, sh2, sh3, si2, si3, sl2, sl3, sl4           float64
+	s1, s2, s3, s4, s5, s6, s7                                              float64
+	ss1, ss2, ss3, ss4, ss5, ss6, ss7                                       float64
+	sz1, sz2, sz3, sz11, sz12, sz13, sz21, sz22, sz23, sz31, sz32, sz33     float64
+	xgh2, xgh3, xgh4, xh2, xh3, xi2, xi3, xl2, xl3, xl4, nm                 float64
+	z1, z2, z3, z11, z12, z13, z21, z22, z23, z31, z32, z33, zmol, zmos     float64
+}
+
+// dscom computes the lunar and solar terms used by the deep space routines.
+func dscom(epoch, ep, argpp, tc, inclp, nodep, np float64) *dscomResult {
+	const (
+		zes    = 0.01675
+		zel    = 0.05490
+		c1ss   = 2.9864797e-6
+		c1l    = 4.7968065e-7
+		zsinis = 0.39785416
+		zcosis = 0.91744867
+		zcosgs = 0.1945905
+		zsings = -0.98088458
+	)
+	r := &dscomResult{}
+
+	r.nm = np
+	r.em = ep
+	r.snodm = math.Sin(nodep)
+	r.cnodm = math.Cos(nodep)
+	r.sinomm = math.Sin(argpp)
+	r.cosomm = math.Cos(argpp)
+	r.sinim = math.Sin(inclp)
+	r.cosim = math.Cos(inclp)
+	r.emsq = r.em * r.em
+	betasq := 1.0 - r.emsq
+	r.rtemsq = math.Sqrt(betasq)
+
+	// Initialize lunar solar terms
+	r.day = epoch + 18261.5 + tc/1440.0
+	xnodce := math.Mod(4.5236020-9.2422029e-4*r.day, twoPi)
+	stem := math.Sin(xnodce)
+	ctem := math.Cos(xnodce)
+	zcosil := 0.91375164 - 0.03568096*ctem
+	zsinil := math.Sqrt(1.0 - zcosil*zcosil)
+	zsinhl := 0.089683511 * stem / zsinil
+	zcoshl := math.Sqrt(1.0 - zsinhl*zsinhl)
+	r.gam = 5.8351514 + 0.0019443680*r.day
+	zx := 0.39785416 * stem / zsinil
+	zy := zcoshl*ctem + 0.91744867*zsinhl*stem
+	zx = math.Atan2(zx, zy)
+	zx = r.gam + zx - xnodce
+	zcosgl := math.Cos(zx)
+	zsingl := math.Sin(zx)
+
+	// Solar terms first, then lunar
+	zcosg := zcosgs
+	zsing := zsings
+	zcosi := zcosis
+	zsini := zsinis
+	zcosh := r.cnodm
+	zsinh := r.snodm
+	cc := c1ss
+	xnoi := 1.0 / r.nm
+
+	for lsflg := 1; lsflg <= 2; lsflg++ {
+		a1 := zcosg*zcosh + zsing*zcosi*zsinh
+		a3 := -zsing*zcosh + zcosg*zcosi*zsinh
+		a7 := -zcosg*zsinh + zsing*zcosi*zcosh
+		a8 := zsing * zsini
+		a9 := zsing*zsinh + zcosg*zcosi*zcosh
+		a10 := zcosg * zsini
+		a2 := r.cosim*a7 + r.sinim*a8
+		a4 := r.cosim*a9 + r.sinim*a10
+		a5 := -r.sinim*a7 + r.cosim*a8
+		a6 := -r.sinim*a9 + r.cosim*a10
+
+		x1 := a1*r.cosomm + a2*r.sinomm
+		x2 := a3*r.cosomm + a4*r.sinomm
+		x3 := -a1*r.sinomm + a2*r.cosomm
+		x4 := -a3*r.sinomm + a4*r.cosomm
+		x5 := a5 * r.sinomm
+		x6 := a6 * r.sinomm
+		x7 := a5 * r.cosomm
+		x8 := a6 * r.cosomm
+
+		r.z31 = 12.0*x1*x1 - 3.0*x3*x3
+		r.z32 = 24.0*x1*x2 - 6.0*x3*x4
+		r.z33 = 12.0*x2*x2 - 3.0*x4*x4
+		r.z1 = 3.0*(a1*a1+a2*a2) + r.z31*r.emsq
+		r.z2 = 6.0*(a1*a3+a2*a4) + r.z32*r.emsq
+		r.z3 = 3.0*(a3*a3+a4*a4) + r.z33*r.emsq
+		r.z11 = -6.0*a1*a5 + r.emsq*(-24.0*x1*x7-6.0*x3*x5)
+		r.z12 = -6.0*(a1*a6+a3*a5) + r.emsq*(-24.0*(x2*x7+x1*x8)-6.0*(x3*x6+x4*x5))
+		r.z13 = -6.0*a3*a6 + r.emsq*(-24.0*x2*x8-6.0*x4*x6)
+		r.z21 = 6.0*a2*a5 + r.emsq*(24.0*x1*x5-6.0*x3*x7)
+		r.z22 = 6.0*(a4*a5+a2*a6) + r.emsq*(24.0*(x2*x5+x1*x6)-6.0*(x4*x7+x3*x8))
+		r.z23 = 6.0*a4*a6 + r.emsq*(24.0*x2*x6-6.0*x4*x8)
+		r.z1 = r.z1 + r.z1 + betasq*r.z31
+		r.z2 = r.z2 + r.z2 + betasq*r.z32
+		r.z3 = r.z3 + r.z3 + betasq*r.z33
+		r.s3 = cc * xnoi
+		r.s2 = -0.5 * r.s3 / r.rtemsq
+		r.s4 = r.s3 * r.rtemsq
+		r.s1 = -15.0 * r.em * r.s4
+		r.s5 = x1*x3 + x2*x4
+		r.s6 = x2*x3 + x1*x4
+		r.s7 = x2*x4 - x1*x3
+
+		if lsflg == 1 {
+			r.ss1, r.ss2, r.ss3, r.ss4, r.ss5, r.ss6, r.ss7 = r.s1, r.s2, r.s3, r.s4, r.s5, r.s6, r.s7
+			r.sz1, r.sz2, r.sz3 = r.z1, r.z2, r.z3
+			r.sz11, r.sz12, r.sz13 = r.z11, r.z12, r.z13
+			r.sz21, r.sz22, r.sz23 = r.z21, r.z22, r.z23
+			r.sz31, r.sz32, r.sz33 = r.z31, r.z32, r.z33
+			zcosg = zcosgl
+			zsing = zsingl
+			zcosi = zcosil
+			zsini = zsinil
+			zcosh = zcoshl*r.cnodm + zsinhl*r.snodm
+			zsinh = r.snodm*zcoshl - r.cnodm*zsinhl
+			cc = c1l
+		}
+	}
+
+	r.zmol = math.Mod(4.7199672+0.22997150*r.day-r.gam, twoPi)
+	r.zmos = math.Mod(6.2565837+0.017201977*r.day, twoPi)
+
+	// Solar terms
+	r.se2 = 2.0 * r.ss1 * r.ss6
+	r.se3 = 2.0 * r.ss1 * r.ss7
+	r.si2 = 2.0 * r.ss2 * r.sz12
+	r.si3 = 2.0 * r.ss2 * (r.sz13 - r.sz11)
+	r.sl2 = -2.0 * r.ss3 * r.sz2
+	r.sl3 = -2.0 * r.ss3 * (r.sz3 - r.sz1)
+	r.sl4 = -2.0 * r.ss3 * (-21.0 - 9.0*r.emsq) * zes
+	r.sgh2 = 2.0 * r.ss4 * r.sz32
+	r.sgh3 = 2.0 * r.ss4 * (r.sz33 - r.sz31)
+	r.sgh4 = -18.0 * r.ss4 * zes
+	r.sh2 = -2.0 * r.ss2 * r.sz22
+	r.sh3 = -2.0 * r.ss2 * (r.sz23 - r.sz21)
+
+	// Lunar terms
+	r.ee2 = 2.0 * r.s1 * r.s6
+	r.e3 = 2.0 * r.s1 * r.s7
+	r.xi2 = 2.0 * r.s2 * r.z12
+	r.xi3 = 2.0 * r.s2 * (r.z13 - r.z11)
+	r.xl2 = -2.0 * r.s3 * r.z2
+	r.xl3 = -2.0 * r.s3 * (r.z3 - r.z1)
+	r.xl4 = -2.0 * r.s3 * (-21.0 - 9.0*r.emsq) * zel
+	r.xgh2 = 2.0 * r.s4 * r.z32
+	r.xgh3 = 2.0 * r.s4 * (r.z33 - r.z31)
+	r.xgh4 = -18.0 * r.s4 * zel
+	r.xh2 = -2.0 * r.s2 * r.z22
+	r.xh3 = -2.0 * r.s2 * (r.z23 - r.z21)
+	return r
+}
+
+// dpper applies lunar-solar periodics to the elements. During
+// initialization only the epoch offsets are computed.
+func (s *sgp4Record) dpper(t, inclo float64, init bool, ep, inclp, nodep, argpp, mp float64) (float64, float64, float64, float64, float64) {
+	const (
+		zns = 1.19459e-5
+		zes = 0.01675
+		znl = 1.5835218e-4
+		zel = 0.05490
+	)
+
+	// Time varying periodics
+	zm := s.zmos + zns*t
+	if init {
+		zm = s.zmos
+	}
+	zf := zm + 2.0*zes*math.Sin(zm)
+	sinzf := math.Sin(zf)
+	f2 := 0.5*sinzf*sinzf - 0.25
+	f3 := -0.5 * sinzf * math.Cos(zf)
+	ses := s.se2*f2 + s.se3*f3
+	sis := s.si2*f2 + s.si3*f3
+	sls := s.sl2*f2 + s.sl3*f3 + s.sl4*sinzf
+	sghs := s.sgh2*f2 + s.sgh3*f3 + s.sgh4*sinzf
+	shs := s.sh2*f2 + s.sh3*f3
+	zm = s.zmol + znl*t
+	if init {
+		zm = s.zmol
+	}
+	zf = zm + 2.0*zel*math.Sin(zm)
+	sinzf = math.Sin(zf)
+	f2 = 0.5*sinzf*sinzf - 0.25
+	f3 = -0.5 * sinzf * math.Cos(zf)
+	sel := s.ee2*f2 + s.e3*f3
+	sil := s.xi2*f2 + s.xi3*f3
+	sll := s.xl2*f2 + s.xl3*f3 + s.xl4*sinzf
+	sghl := s.xgh2*f2 + s.xgh3*f3 + s.xgh4*sinzf
+	shll := s.xh2*f2 + s.xh3*f3
+	pe := ses + sel
+	pinc := sis + sil
+	pl := sls + sll
+	pgh := sghs + sghl
+	ph := shs + shll
+
+	if init {
+		return ep, inclp, nodep, argpp, mp
+	}
+
+	pe -= s.peo
+	pinc -= s.pinco
+	pl -= s.plo
+	pgh -= s.pgho
+	ph -= s.pho
+	inclp += pinc
+	ep += pe
+	sinip := math.Sin(inclp)
+	cosip := math.Cos(inclp)
+
+	// Apply periodics directly above 0.2 rad of perturbed inclination,
+	// otherwise with the Lyddane modification
+	if inclp >= 0.2 {
+		ph /= sinip
+		pgh -= cosip * ph
+		argpp += pgh
+		nodep += ph
+		mp += pl
+	} else {
+		sinop := math.Sin(nodep)
+		cosop := math.Cos(nodep)
+		alfdp := sinip * sinop
+		betdp := sinip * cosop
+		dalf := ph*cosop + pinc*cosip*sinop
+		dbet := -ph*sinop + pinc*cosip*cosop
+		alfdp += dalf
+		betdp += dbet
+		nodep = math.Mod(nodep, twoPi)
+		xls := mp + argpp + cosip*nodep
+		dls := pl + pgh - pinc*nodep*sinip
+		xls += dls
+		xnoh := nodep
+		nodep = math.Atan2(alfdp, betdp)
+		if math.Abs(xnoh-nodep) > math.Pi {
+			if nodep < xnoh {
+				nodep += twoPi
+			} else {
+				nodep -= twoPi
+			}
+		}
+		mp += pl
+		argpp = xls - mp - cosip*nodep
+	}
+	return ep, inclp, nodep, argpp, mp
+}
+
+// dsinit initializes the deep space secular and resonance terms.
+func (s *sgp4Record) dsinit(ds *dscomResult, t, tc, xpidot, eccsq, inclm float64) {
+	const (
+		q22    = 1.7891679e-6
+		q31    = 2.1460748e-6
+		q33    = 2.2123015e-7
+		root22 = 1.7891679e-6
+		root44 = 7.3636953e-9
+		root54 = 2.1765803e-9
+		rptim  = 4.37526908801129966e-3 // 7.29211514668855e-5 rad/s
+		root32 = 3.7393792e-7
+		root52 = 1.1428639e-7
+		znl    = 1.5835218e-4
+		zns    = 1.19459e-5
+	)
+	xke := wgs72.xke
+	cosim, sinim, emsq, em, nm := ds.cosim, ds.sinim, ds.emsq, ds.em, ds.nm
+
+	// Resonance: 1 = one day (geosynchronous), 2 = half day (Molniya, GPS)
+	s.irez = 0
+	if nm < 0.0052359877 && nm > 0.0034906585 {
+		s.irez = 1
+	}
+	if nm >= 8.26e-3 && nm <= 9.24e-3 && em >= 0.5 {
+		s.irez = 2
+	}
+
+	// Solar terms
+	ses := ds.ss1 * zns * ds.ss5
+	sis := ds.ss2 * zns * (ds.sz11 + ds.sz13)
+	sls := -zns * ds.ss3 * (ds.sz1 + ds.sz3 - 14.0 - 6.0*emsq)
+	sghs := ds.ss4 * zns * (ds.sz31 + ds.sz33 - 6.0)
+	shs := -zns * ds.ss2 * (ds.sz21 + ds.sz23)
+	if inclm < 5.2359877e-2 || inclm > math.Pi-5.2359877e-2 {
+		shs = 0.0
+	}
+	if sinim != 0.0 {
+		shs /= sinim
+	}
+	sgs := sghs - cosim*shs
+
+	// Lunar terms
+	s.dedt = ses + ds.s1*znl*ds.s5
+	s.didt = sis + ds.s2*znl*(ds.z11+ds.z13)
+	s.dmdt = sls - znl*ds.s3*(ds.z1+ds.z3-14.0-6.0*emsq)
+	sghl := ds.s4 * znl * (ds.z31 + ds.z33 - 6.0)
+	shll := -znl * ds.s2 * (ds.z21 + ds.z23)
+	if inclm < 5.2359877e-2 || inclm > math.Pi-5.2359877e-2 {
+		shll = 0.0
+	}
+	s.domdt = sgs + sghl
+	s.dnodt = shs
+	if sinim != 0.0 {
+		s.domdt -= cosim / sinim * shll
+		s.dnodt += shll / sinim
+	}
+
+	// Deep space resonance effects
+	theta := math.Mod(s.gsto+tc*rptim, twoPi)
+	em += s.dedt * t
+
+	if s.irez != 0 {
+		aonv := math.Pow(nm/xke, x2o3)
+
+		// Geopotential resonance for 12 hour orbits
+		if s.irez == 2 {
+			cosisq := cosim * cosim
+			emo := em
+			em = s.ecco
+			emsqo := emsq
+			emsq = eccsq
+			eoc := em * emsq
+			g201 := -0.306 - (em-0.64)*0.440
+
+			var g211, g310, g322, g410, g422, g520, g521, g532, g533 float64
+			if em <= 0.65 {
+				g211 = 3.616 - 13.2470*em + 16.2900*emsq
+				g310 = -19.302 + 117.3900*em - 228.4190*emsq + 156.5910*eoc
+				g322 = -18.9068 + 109.7927*em - 214.6334*emsq + 146.5816*eoc
+				g410 = -41.122 + 242.6940*em - 471.0940*emsq + 313.9530*eoc
+				g422 = -146.407 + 841.8800*em - 1629.014*emsq + 1083.4350*eoc
+				g520 = -532.114 + 3017.977*em - 5740.032*emsq + 3708.2760*eoc
+			} else {
+				g211 = -72.099 + 331.819*em - 508.738*emsq + 266.724*eoc
+				g310 = -346.844 + 1582.851*em - 2415.925*emsq + 1246.113*eoc
+				g322 = -342.585 + 1554.908*em - 2366.899*emsq + 1215.972*eoc
+				g410 = -1052.797 + 4758.686*em - 7193.992*emsq + 3651.957*eoc
+				g422 = -3581.690 + 16178.110*em - 24462.770*emsq + 12422.520*eoc
+				if em > 0.715 {
+					g520 = -5149.66 + 29936.92*em - 54087.36*emsq + 31324.56*eoc
+				} else {
+					g520 = 1464.74 - 4664.75*em + 3763.64*emsq
+				}
+			}
+			if em < 0.7 {
+				g533 = -919.22770 + 4988.6100*em - 9064.7700*emsq + 5542.21*eoc
+				g521 = -822.71072 + 4568.6173*em - 8491.4146*emsq + 5337.524*eoc
+				g532 = -853.66600 + 4690.2500*em - 8624.7700*emsq + 5341.4*eoc
+			} else {
+				g533 = -37995.780 + 161616.52*em - 229838.20*emsq + 109377.94*eoc
+				g521 = -51752.104 + 218913.95*em - 309468.16*emsq + 146349.42*eoc
+				g532 = -40023.880 + 170470.89*em - 242699.48*emsq + 115605.82*eoc
+			}
+
+			sini2 := sinim * sinim
+			f220 := 0.75 * (1.0 + 2.0*cosim + cosisq)
+			f221 := 1.5 * sini2
+			f321 := 1.875 * sinim * (1.0 - 2.0*cosim - 3.0*cosisq)
+			f322 := -1.875 * sinim * (1.0 + 2.0*cosim - 3.0*cosisq)
+			f441 := 35.0 * sini2 * f220
+			f442 := 39.3750 * sini2 * sini2
+			f522 := 9.84375 * sinim * (sini2*(1.0-2.0*cosim-5.0*cosisq) +
+				0.33333333*(-2.0+4.0*cosim+6.0*cosisq))
+			f523 := sinim * (4.92187512*sini2*(-2.0-4.0*cosim+10.0*cosisq) +
+				6.56250012*(1.0+2.0*cosim-3.0*cosisq))
+			f542 := 29.53125 * sinim * (2.0 - 8.0*cosim + cosisq*(-12.0+8.0*cosim+10.0*cosisq))
+			f543 := 29.53125 * sinim * (-2.0 - 8.0*cosim + cosisq*(12.0+8.0*cosim-10.0*cosisq))
+			xno2 := nm * nm
+			ainv2 := aonv * aonv
+			temp1 := 3.0 * xno2 * ainv2
+			temp := temp1 * root22
+			s.d2201 = temp * f220 * g201
+			s.d2211 = temp * f221 * g211
+			temp1 *= aonv
+			temp = temp1 * root32
+			s.d3210 = temp * f321 * g310
+			s.d3222 = temp * f322 * g322
+			temp1 *= aonv
+			temp = 2.0 * temp1 * root44
+			s.d4410 = temp * f441 * g410
+			s.d4422 = temp * f442 * g422
+			temp1 *= aonv
+			temp = temp1 * root52
+			s.d5220 = temp * f522 * g520
+			s.d5232 = temp * f523 * g532
+			temp = 2.0 * temp1 * root54
+			s.d5421 = temp * f542 * g521
+			s.d5433 = temp * f543 * g533
+			s.xlamo = math.Mod(s.mo+s.nodeo+s.nodeo-theta-theta, twoPi)
+			s.xfact = s.mdot + s.dmdt + 2.0*(s.nodedot+s.dnodt-rptim) - s.no
+			em = emo
+			emsq = emsqo
+		}
+
+		// Synchronous resonance terms
+		if s.irez == 1 {
+			g200 := 1.0 + emsq*(-2.5+0.8125*emsq)
+			g310 := 1.0 + 2.0*emsq
+			g300 := 1.0 + emsq*(-6.0+6.60937*emsq)
+			f220 := 0.75 * (1.0 + cosim) * (1.0 + cosim)
+			f311 := 0.9375*sinim*sinim*(1.0+3.0*cosim) - 0.75*(1.0+cosim)
+			f330 := 1.0 + cosim
+			f330 = 1.875 * f330 * f330 * f330
+			s.del1 = 3.0 * nm * nm * aonv * aonv
+			s.del2 = 2.0 * s.del1 * f220 * g200 * q22
+			s.del3 = 3.0 * s.del1 * f330 * g300 * q33 * aonv
+			s.del1 = s.del1 * f311 * g310 * q31 * aonv
+			s.xlamo = math.Mod(s.mo+s.nodeo+s.argpo-theta, twoPi)
+			s.xfact = s.mdot + xpidot - rptim + s.dmdt + s.domdt + s.dnodt - s.no
+		}
+
+		// Initialize the integrator
+		s.xli = s.xlamo
+		s.xni = s.no
+		s.atime = 0.0
+	}
+}
+
+// dspace applies deep space secular effects and integrates the resonance
+// terms to time t.
+func (s *sgp4Record) dspace(t, tc, em, argpm, inclm, mm, nodem, nm float64) (float64, float64, float64, float64, float64, float64) {
+	const (
+		fasx2 = 0.13130908
+		fasx4 = 2.8843198
+		fasx6 = 0.37448087
+		g22   = 5.7686396
+		g32   = 0.95240898
+		g44   = 1.8014998
+		g52   = 1.0508330
+		g54   = 4.4108898
+		rptim = 4.37526908801129966e-3
+		stepp = 720.0
+		stepn = -720.0
+		step2 = 259200.0
+	)
+
+	theta := math.Mod(s.gsto+tc*rptim, twoPi)
+	em += s.dedt * t
+	inclm += s.didt * t
+	argpm += s.domdt * t
+	nodem += s.dnodt * t
+	mm += s.dmdt * t
+
+	// Update resonances by Euler-Maclaurin integration, restarting from
+	// epoch when t moves back toward it
+	if s.irez == 0 {
+		return em, argpm, inclm, mm, nodem, nm
+	}
+	if s.atime == 0.0 || t*s.atime <= 0.0 || math.Abs(t) < math.Abs(s.atime) {
+		s.atime = 0.0
+		s.xni = s.no
+		s.xli = s.xlamo
+	}
+	delt := stepn
+	if t > 0.0 {
+		delt = stepp
+	}
+
+	var ft, xndt, xldot, xnddt float64
+	for {
+		if s.irez != 2 {
+			// Near-synchronous resonance terms
+			xndt = s.del1*math.Sin(s.xli-fasx2) + s.del2*math.Sin(2.0*(s.xli-fasx4)) +
+				s.del3*math.Sin(3.0*(s.xli-fasx6))
+			xldot = s.xni + s.xfact
+			xnddt = s.del1*math.Cos(s.xli-fasx2) +
+				2.0*s.del2*math.Cos(2.0*(s.xli-fasx4)) +
+				3.0*s.del3*math.Cos(3.0*(s.xli-fasx6))
+			xnddt *= xldot
+		} else {
+			// Near half-day resonance terms
+			xomi := s.argpo + s.argpdot*s.atime
+			x2omi := xomi + xomi
+			x2li := s.xli + s.xli
+			xndt = s.d2201*math.Sin(x2omi+s.xli-g22) + s.d2211*math.Sin(s.xli-g22) +
+				s.d3210*math.Sin(xomi+s.xli-g32) + s.d3222*math.Sin(-xomi+s.xli-g32) +
+				s.d4410*math.Sin(x2omi+x2li-g44) + s.d4422*math.Sin(x2li-g44) +
+				s.d5220*math.Sin(xomi+s.xli-g52) + s.d5232*math.Sin(-xomi+s.xli-g52) +
+				s.d5421*math.Sin(xomi+x2li-g54) + s.d5433*math.Sin(-xomi+x2li-g54)
+			xldot = s.xni + s.xfact
+			xnddt = s.d2201*math.Cos(x2omi+s.xli-g22) + s.d2211*math.Cos(s.xli-g22) +
+				s.d3210*math.Cos(xomi+s.xli-g32) + s.d3222*math.Cos(-xomi+s.xli-g32) +
+				s.d5220*math.Cos(xomi+s.xli-g52) + s.d5232*math.Cos(-xomi+s.xli-g52) +
+				2.0*(s.d4410*math.Cos(x2omi+x2li-g44)+
+					s.d4422*math.Cos(x2li-g44)+s.d5421*math.Cos(xomi+x2li-g54)+
+					s.d5433*math.Cos(-xomi+x2li-g54))
+			xnddt *= xldot
+		}
+
+		if math.Abs(t-s.atime) < stepp {
+			ft = t - s.atime
+			break
+		}
+		s.xli += xldot*delt + xndt*step2
+		s.xni += xndt*delt + xnddt*step2
+		s.atime += delt
+	}
+
+	nm = s.xni + xndt*ft + xnddt*ft*ft*0.5
+	xl := s.xli + xldot*ft + xndt*ft*ft*0.5
+	if s.irez != 1 {
+		mm = xl - 2.0*nodem + 2.0*theta
+	} else {
+		mm = xl - nodem - argpm + theta
+	}
+	return em, argpm, inclm, mm, nodem, nm
+}
+
+// sgp4 propagates the record to tsince minutes from epoch and returns the
+// TEME position (km) and velocity (km/s).
+func (s *sgp4Record) sgp4(tsince float64) (r, v [3]float64, err error) {
+	radiusEarthKm, xke, j2, j3oj2 := wgs72.radiusEarthKm, wgs72.xke, wgs72.j2, wgs72.j3oj2
+	vkmpersec := radiusEarthKm * xke / 60.0
+
+	s.t = tsince
+
+	// Secular gravity and atmospheric drag
+	xmdf := s.mo + s.mdot*s.t
+	argpdf := s.argpo + s.argpdot*s.t
+	nodedf := s.nodeo + s.nodedot*s.t
+	argpm := argpdf
+	mm := xmdf
+	t2 := s.t * s.t
+	nodem := nodedf + s.nodecf*t2
+	tempa := 1.0 - s.cc1*s.t
+	tempe := s.bstar * s.cc4 * s.t
+	templ := s.t2cof * t2
+
+	if !s.isimp {
+		delomg := s.omgcof * s.t
+		delmtemp := 1.0 + s.eta*math.Cos(xmdf)
+		delm := s.xmcof * (delmtemp*delmtemp*delmtemp - s.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * s.t
+		t4 := t3 * s.t
+		tempa = tempa - s.d2*t2 - s.d3*t3 - s.d4*t4
+		tempe += s.bstar * s.cc5 * (math.Sin(mm) - s.sinmao)
+		templ += s.t3cof*t3 + t4*(s.t4cof+s.t*s.t5cof)
+	}
+
+	nm := s.no
+	em := s.ecco
+	inclm := s.inclo
+	if s.method == 'd' {
+		tc := s.t
+		em, argpm, inclm, mm, nodem, nm = s.dspace(s.t, tc, em, argpm, inclm, mm, nodem, nm)
+	}
+
+	if nm <= 0.0 {
+		return r, v, ErrSGP4MeanMotion
+	}
+	am := math.Pow(xke/nm, x2o3) * tempa * tempa
+	nm = xke / math.Pow(am, 1.5)
+	em -= tempe
+
+	if em >= 1.0 || em < -0.001 {
+		return r, v, ErrSGP4Eccentricity
+	}
+	if em < 1.0e-6 {
+		em = 1.0e-6
+	}
+	mm += s.no * templ
+	xlm := mm + argpm + nodem
+
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+
+	sinim := math.Sin(inclm)
+	cosim := math.Cos(inclm)
+
+	// Lunar-solar periodics
+	ep := em
+	xincp := inclm
+	argpp := argpm
+	nodep := nodem
+	mp := mm
+	sinip := sinim
+	cosip := cosim
+	if s.method == 'd' {
+		ep, xincp, nodep, argpp, mp = s.dpper(s.t, s.inclo, false, ep, xincp, nodep, argpp, mp)
+		if xincp < 0.0 {
+			xincp = -xincp
+			nodep += math.Pi
+			argpp -= math.Pi
+		}
+		if ep < 0.0 || ep > 1.0 {
+			return r, v, ErrSGP4PerturbedEccentricity
+		}
+	}
+
+	// Long period periodics
+	if s.method == 'd' {
+		sinip = math.Sin(xincp)
+		cosip = math.Cos(xincp)
+		s.aycof = -0.5 * j3oj2 * sinip
+		if math.Abs(cosip+1.0) > 1.5e-12 {
+			s.xlcof = -0.25 * j3oj2 * sinip * (3.0 + 5.0*cosip) / (1.0 + cosip)
+		} else {
+			s.xlcof = -0.25 * j3oj2 * sinip * (3.0 + 5.0*cosip) / sgp4Temp4
+		}
+	}
+	axnl := ep * math.Cos(argpp)
+	temp := 1.0 / (am * (1.0 - ep*ep))
+	aynl := ep*math.Sin(argpp) + temp*s.aycof
+	xl := mp + argpp + nodep + temp*s.xlcof*axnl
+
+	// Solve Kepler's equation
+	u := math.Mod(xl-nodep, twoPi)
+	eo1 := u
+	tem5 := 9999.9
+	var sineo1, coseo1 float64
+	for ktr := 1; math.Abs(tem5) >= 1.0e-12 && ktr <= 10; ktr++ {
+		sineo1 = math.Sin(eo1)
+		coseo1 = math.Cos(eo1)
+		tem5 = 1.0 - coseo1*axnl - sineo1*aynl
+		tem5 = (u - aynl*coseo1 + axnl*sineo1 - eo1) / tem5
+		if math.Abs(tem5) >= 0.95 {
+			if tem5 > 0.0 {
+				tem5 = 0.95
+			} else {
+				tem5 = -0.95
+			}
+		}
+		eo1 += tem5
+	}
+
+	// Short period preliminary quantities
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1.0 - el2)
+	if pl < 0.0 {
+		return r, v, ErrSGP4SemiLatusRectum
+	}
+
+	rl := am * (1.0 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1.0 - el2)
+	temp = esine / (1.0 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := (cosu + cosu) * sinu
+	cos2u := 1.0 - 2.0*sinu*sinu
+	temp = 1.0 / pl
+	temp1 := 0.5 * j2 * temp
+	temp2 := temp1 * temp
+
+	// Short period periodics
+	if s.method == 'd' {
+		cosisq := cosip * cosip
+		s.con41 = 3.0*cosisq - 1.0
+		s.x1mth2 = 1.0 - cosisq
+		s.x7thm1 = 7.0*cosisq - 1.0
+	}
+	mrt := rl*(1.0-1.5*temp2*betal*s.con41) + 0.5*temp1*s.x1mth2*cos2u
+	su -= 0.25 * temp2 * s.x7thm1 * sin2u
+	xnode := nodep + 1.5*temp2*cosip*sin2u
+	xinc := xincp + 1.5*temp2*cosip*sinip*cos2u
+	mvt := rdotl - nm*temp1*s.x1mth2*sin2u/xke
+	rvdot := rvdotl + nm*temp1*(s.x1mth2*cos2u+1.5*s.con41)/xke
+
+	// Orientation vectors
+	sinsu := math.Sin(su)
+	cossu := math.Cos(su)
+	snod := math.Sin(xnode)
+	cnod := math.Cos(xnode)
+	sini := math.Sin(xinc)
+	cosi := math.Cos(xinc)
+	xmx := -snod * cosi
+	xmy := cnod * cosi
+	ux := xmx*sinsu + cnod*cossu
+	uy := xmy*sinsu + snod*cossu
+	uz := sini * sinsu
+	vx := xmx*cossu - cnod*sinsu
+	vy := xmy*cossu - snod*sinsu
+	vz := sini * cossu
+
+	r = [3]float64{mrt * ux * radiusEarthKm, mrt * uy * radiusEarthKm, mrt * uz * radiusEarthKm}
+	v = [3]float64{
+		(mvt*ux + rvdot*vx) * vkmpersec,
+		(mvt*uy + rvdot*vy) * vkmpersec,
+		(mvt*uz + rvdot*vz) * vkmpersec,
+	}
+
+	if mrt < 1.0 {
+		return r, v, ErrSGP4Decayed
+	}
+	return r, v, nil
+}
@@ -0,0 +1,84 @@
+package satellite
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadTLEs parses element sets in two-line or three-line (name line first)
+// format, as published by CelesTrak and Space-Track. Blank lines are
+// ignored.
+func ReadTLEs(r io.Reader) ([]*TLE, error) {
+	scanner := bufio.NewScanner(r)
+	var tles []*TLE
+	var name string
+	var line1 string
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \r")
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "1 ") && line1 == "":
+			line1 = line
+		case strings.HasPrefix(line, "2 ") && line1 != "":
+			tle, err := newTLEFromLines(name, line1, line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			tles = append(tles, tle)
+			name, line1 = "", ""
+		case line1 != "":
+			return nil, fmt.Errorf("line %d: expected TLE line 2", lineNo)
+		default:
+			name = strings.TrimSpace(strings.TrimPrefix(line, "0 "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read TLEs: %w", err)
+	}
+	if line1 != "" {
+		return nil, fmt.Errorf("line %d: TLE line 2 missing", lineNo)
+	}
+	return tles, nil
+}
+
+// LoadTLEFile reads a two-line or three-line element file.
+func LoadTLEFile(path string) ([]*TLE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open TLE file: %w", err)
+	}
+	defer f.Close()
+	return ReadTLEs(f)
+}
+
+func newTLEFromLines(name, line1, line2 string) (*TLE, error) {
+	elements, err := ParseTLE(line1, line2)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(line1[2:7]))
+	if err != nil {
+		return nil, fmt.Errorf("parse catalog number: %w", err)
+	}
+	if name == "" {
+		name = strconv.Itoa(id)
+	}
+	epoch := time.Date(elements.EpochYear, 1, 1, 0, 0, 0, 0, time.UTC).
+		Add(time.Duration((elements.EpochDay - 1) * 24 * float64(time.Hour)))
+	return &TLE{
+		SatelliteID: id,
+		Name:        name,
+		Line1:       line1,
+		Line2:       line2,
+		Epoch:       epoch,
+		Source:      "file",
+	}, nil
+}
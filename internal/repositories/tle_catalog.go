@@ -0,0 +1,181 @@
+// Package repositories provides data access layer for database operations.
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/db"
+)
+
+// TLECatalogRepository stores the element set history of the TLE catalog.
+type TLECatalogRepository struct {
+	db *db.PostgresDB
+}
+
+// NewTLECatalogRepository creates a new TLE catalog repository.
+func NewTLECatalogRepository(pgDB *db.PostgresDB) *TLECatalogRepository {
+	return &TLECatalogRepository{db: pgDB}
+}
+
+const tleElementSetColumns = `
+	id, norad_id, name, int_designator, epoch, line1, line2,
+	mean_motion, mean_motion_dot, eccentricity, inclination, raan,
+	arg_perigee, mean_anomaly, bstar, source, maneuver, maneuver_reason,
+	imported_at`
+
+// Insert stores an element set. It returns false without error when the
+// object already has an element set at that epoch.
+func (r *TLECatalogRepository) Insert(ctx context.Context, es *db.TLEElementSet) (bool, error) {
+	query := `
+		INSERT INTO tle_element_sets (
+			norad_id, name, int_designator, epoch, line1, line2,
+			mean_motion, mean_motion_dot, eccentricity, inclination, raan,
+			arg_perigee, mean_anomaly, bstar, source, maneuver, maneuver_reason,
+			imported_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (norad_id, epoch) DO NOTHING
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		es.NoradID,
+		es.Name,
+		es.IntDesignator,
+		es.Epoch,
+		es.Line1,
+		es.Line2,
+		es.MeanMotion,
+		es.MeanMotionDot,
+		es.Eccentricity,
+		es.Inclination,
+		es.RAAN,
+		es.ArgPerigee,
+		es.MeanAnomaly,
+		es.BStar,
+		es.Source,
+		es.Maneuver,
+		es.ManeuverReason,
+		es.ImportedAt,
+	).Scan(&es.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert element set: %w", err)
+	}
+	return true, nil
+}
+
+// GetPrevious retrieves the newest element set for an object with an epoch
+// before the given time.
+func (r *TLECatalogRepository) GetPrevious(ctx context.Context, noradID int, before time.Time) (*db.TLEElementSet, error) {
+	query := `SELECT ` + tleElementSetColumns + `
+		FROM tle_element_sets
+		WHERE norad_id = $1 AND epoch < $2
+		ORDER BY epoch DESC
+		LIMIT 1
+	`
+
+	es, err := scanTLEElementSet(r.db.QueryRowContext(ctx, query, noradID, before))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous element set: %w", err)
+	}
+	return es, nil
+}
+
+// GetHistory retrieves up to limit element sets for an object, newest first.
+func (r *TLECatalogRepository) GetHistory(ctx context.Context, noradID int, limit int) ([]*db.TLEElementSet, error) {
+	query := `SELECT ` + tleElementSetColumns + `
+		FROM tle_element_sets
+		WHERE norad_id = $1
+		ORDER BY epoch DESC
+		LIMIT $2
+	`
+
+	return r.queryElementSets(ctx, query, noradID, nullLimit(limit))
+}
+
+// GetLatest retrieves the newest element set for every object.
+func (r *TLECatalogRepository) GetLatest(ctx context.Context) ([]*db.TLEElementSet, error) {
+	query := `SELECT DISTINCT ON (norad_id) ` + tleElementSetColumns + `
+		FROM tle_element_sets
+		ORDER BY norad_id, epoch DESC
+	`
+
+	return r.queryElementSets(ctx, query)
+}
+
+// GetManeuvers retrieves up to limit element sets flagged as maneuvers with
+// epochs after since, newest first.
+func (r *TLECatalogRepository) GetManeuvers(ctx context.Context, since time.Time, limit int) ([]*db.TLEElementSet, error) {
+	query := `SELECT ` + tleElementSetColumns + `
+		FROM tle_element_sets
+		WHERE maneuver AND epoch > $1
+		ORDER BY epoch DESC
+		LIMIT $2
+	`
+
+	return r.queryElementSets(ctx, query, since, nullLimit(limit))
+}
+
+func (r *TLECatalogRepository) queryElementSets(ctx context.Context, query string, args ...interface{}) ([]*db.TLEElementSet, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query element sets: %w", err)
+	}
+	defer rows.Close()
+
+	var sets []*db.TLEElementSet
+	for rows.Next() {
+		es, err := scanTLEElementSet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan element set: %w", err)
+		}
+		sets = append(sets, es)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate element sets: %w", err)
+	}
+	return sets, nil
+}
+
+func scanTLEElementSet(row interface{ Scan(...interface{}) error }) (*db.TLEElementSet, error) {
+	es := &db.TLEElementSet{}
+	err := row.Scan(
+		&es.ID,
+		&es.NoradID,
+		&es.Name,
+		&es.IntDesignator,
+		&es.Epoch,
+		&es.Line1,
+		&es.Line2,
+		&es.MeanMotion,
+		&es.MeanMotionDot,
+		&es.Eccentricity,
+		&es.Inclination,
+		&es.RAAN,
+		&es.ArgPerigee,
+		&es.MeanAnomaly,
+		&es.BStar,
+		&es.Source,
+		&es.Maneuver,
+		&es.ManeuverReason,
+		&es.ImportedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+// nullLimit maps a non-positive limit to SQL NULL, which LIMIT treats as
+// no limit.
+func nullLimit(limit int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+}
@@ -0,0 +1,119 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+	"github.com/asgard/pandora/internal/robotics/motion"
+	"github.com/asgard/pandora/internal/robotics/perception"
+)
+
+// toolDown is the grasp orientation used for point targets: the tool z
+// axis pointing straight down, as in the URScript pose p[x, y, z, 0, π, 0].
+var toolDown = kinematics.RotY(math.Pi)
+
+// trajectoryPeriod spaces the points of trajectories sent to controllers
+// that interpolate them themselves.
+const trajectoryPeriod = 20 * time.Millisecond
+
+// solveReach converts a Cartesian reach target into a joint goal for the
+// chain, seeded from the current joints. A tool-down grasp is preferred;
+// targets the arm can only reach at another orientation fall back to a
+// position-only solution. Unreachable targets return a
+// *motion.PlanningError.
+func solveReach(chain *kinematics.Chain, seed []float64, position Vector3) ([]float64, error) {
+	if len(seed) != chain.DOF() {
+		seed = make([]float64, chain.DOF())
+	}
+	target := kinematics.Translation(position.X, position.Y, position.Z).Mul(toolDown)
+	cfg := kinematics.DefaultIKConfig()
+	q, err := chain.Inverse(target, seed, cfg)
+	if err == nil {
+		return q, nil
+	}
+	cfg.PositionOnly = true
+	q, posErr := chain.Inverse(target, seed, cfg)
+	if posErr != nil {
+		return nil, &motion.PlanningError{
+			Reason: motion.ReasonUnreachable,
+			Link:   -1,
+			Err:    fmt.Errorf("no joint goal for (%.3f, %.3f, %.3f) on %s: %w", position.X, position.Y, position.Z, chain.Name, posErr),
+		}
+	}
+	return q, nil
+}
+
+// planReach plans and times a collision-free motion from start to a reach
+// target, preferring a tool-down grasp as solveReach does. Objects
+// enclosing the target are what the arm is reaching for, so they are not
+// obstacles.
+func planReach(ctx context.Context, chain *kinematics.Chain, env *motion.Environment, cfg motion.Config, start []float64, position Vector3) (*motion.Trajectory, error) {
+	if len(start) != chain.DOF() {
+		start = make([]float64, chain.DOF())
+	}
+	reachEnv := *env
+	ignore := env.Ignore
+	reachEnv.Ignore = func(obj *perception.TrackedObject) bool {
+		return encloses(obj, position, env.ObjectRadius) || (ignore != nil && ignore(obj))
+	}
+	planner := motion.NewPlanner(chain, &reachEnv, cfg)
+
+	target := kinematics.Translation(position.X, position.Y, position.Z).Mul(toolDown)
+	ik := kinematics.DefaultIKConfig()
+	path, err := planner.PlanToPose(ctx, start, target, ik)
+	var planErr *motion.PlanningError
+	if errors.As(err, &planErr) && planErr.Reason == motion.ReasonUnreachable {
+		ik.PositionOnly = true
+		path, err = planner.PlanToPose(ctx, start, target, ik)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return planner.Parameterize(path)
+}
+
+// encloses reports whether a tracked object, taken at its bounding box or
+// as a cube of radius around its position, contains p.
+func encloses(obj *perception.TrackedObject, p Vector3, radius float64) bool {
+	if radius <= 0 {
+		radius = 0.05
+	}
+	bb := obj.BoundingBox
+	if !(bb.Max.X > bb.Min.X && bb.Max.Y > bb.Min.Y && bb.Max.Z > bb.Min.Z) {
+		c := obj.Position
+		bb = perception.BoundingBox3D{
+			Min: perception.Vector3{X: c.X - radius, Y: c.Y - radius, Z: c.Z - radius},
+			Max: perception.Vector3{X: c.X + radius, Y: c.Y + radius, Z: c.Z + radius},
+		}
+	}
+	return p.X >= bb.Min.X && p.X <= bb.Max.X &&
+		p.Y >= bb.Min.Y && p.Y <= bb.Max.Y &&
+		p.Z >= bb.Min.Z && p.Z <= bb.Max.Z
+}
+
+// trajectoryPayload encodes a trajectory for JSON endpoints, resampled
+// every trajectoryPeriod.
+func trajectoryPayload(traj *motion.Trajectory) []map[string]interface{} {
+	var points []map[string]interface{}
+	for _, p := range traj.Resample(trajectoryPeriod) {
+		points = append(points, map[string]interface{}{
+			"time":       p.Time.Seconds(),
+			"positions":  p.Positions,
+			"velocities": p.Velocities,
+		})
+	}
+	return points
+}
+
+// toolPosition returns the tool centre point of the chain at q.
+func toolPosition(chain *kinematics.Chain, q []float64) (Vector3, error) {
+	pose, err := chain.Forward(q)
+	if err != nil {
+		return Vector3{}, err
+	}
+	return Vector3{X: pose.P[0], Y: pose.P[1], Z: pose.P[2]}, nil
+}
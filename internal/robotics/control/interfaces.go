@@ -85,6 +85,12 @@ type ManipulatorController interface {
 	ReachTo(ctx context.Context, position Vector3) error
 }
 
+// VelocityController is implemented by mobile bases that take planar
+// velocity commands in their body frame, for closed-loop navigation.
+type VelocityController interface {
+	SetVelocity(ctx context.Context, linear, angular float64) error
+}
+
 // NavigationController handles autonomous movement
 type NavigationController interface {
 	SetGoal(ctx context.Context, goal Pose) error
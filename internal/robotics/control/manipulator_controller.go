@@ -7,12 +7,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"math"
 	"net"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/asgard/pandora/internal/robotics/gripper"
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+	"github.com/asgard/pandora/internal/robotics/modbus"
+	"github.com/asgard/pandora/internal/robotics/motion"
+	"github.com/asgard/pandora/internal/robotics/rtde"
 )
 
 // RealManipulator implements ManipulatorController for real robot arm/gripper control.
@@ -21,10 +28,16 @@ type RealManipulator struct {
 	mu            sync.RWMutex
 	config        ManipulatorConfig
 	conn          io.ReadWriteCloser
+	rtde          *rtde.Client // UR protocol only
+	modbus        *modbus.Client
+	gripper       gripper.Gripper // Modbus gripper driver, if configured
+	gripperStatus gripper.Status
 	httpClient    *http.Client
 	gripperState  float64
 	armPosition   Vector3
 	jointStates   []float64
+	chain         *kinematics.Chain // nil for arms without a kinematic model
+	environment   *motion.Environment
 	isInitialized bool
 	stopChan      chan struct{}
 }
@@ -35,8 +48,9 @@ type ManipulatorConfig struct {
 	Protocol string `json:"protocol"`
 
 	// Connection settings
-	Address string `json:"address"`
-	Port    int    `json:"port"`
+	Address    string `json:"address"`
+	Port       int    `json:"port"`       // RTDE port for UR arms, default 30004
+	ScriptPort int    `json:"scriptPort"` // UR secondary interface, default 30002
 
 	// Arm configuration
 	ArmModel    string  `json:"armModel"` // e.g., "ur5e", "kinova-gen3", "franka"
@@ -48,11 +62,19 @@ type ManipulatorConfig struct {
 	GripperModel    string  `json:"gripperModel"`    // e.g., "robotiq-2f85", "wsg50"
 	GripperMaxWidth float64 `json:"gripperMaxWidth"` // meters
 	GripperForce    float64 `json:"gripperForce"`    // Newtons
+	GripperSpeed    float64 `json:"gripperSpeed"`    // m/s
+
+	// Modbus TCP gripper connection; the "modbus" protocol uses Address
+	// and Port instead
+	GripperAddress string `json:"gripperAddress"`
+	GripperPort    int    `json:"gripperPort"`
+	GripperUnitID  byte   `json:"gripperUnitId"` // Defaults to the model's factory ID
 
 	// Motion settings
 	MaxJointVelocity float64 `json:"maxJointVelocity"` // rad/s
 	MaxLinearSpeed   float64 `json:"maxLinearSpeed"`   // m/s
-	Acceleration     float64 `json:"acceleration"`     // m/s^2
+	Acceleration     float64 `json:"acceleration"`     // m/s^2 for Cartesian moves, rad/s^2 per joint for planned motions
+	MotionPlanner    string  `json:"motionPlanner"`    // "rrt-connect" (default) or "rrt-star"
 
 	// Safety settings
 	ForceLimit  float64 `json:"forceLimit"`  // Newtons
@@ -68,27 +90,55 @@ type GripperState struct {
 
 // NewRealManipulator creates a new manipulator controller
 func NewRealManipulator(config ManipulatorConfig) *RealManipulator {
+	if config.ArmModel == "" {
+		config.ArmModel = "ur5e"
+	}
+	chain, err := kinematics.Model(config.ArmModel)
+	if err != nil {
+		log.Printf("[Manipulator] %v; reach targets will be sent as Cartesian poses (not supported over UR RTDE)", err)
+		chain = nil
+	}
 	if config.NumJoints == 0 {
 		config.NumJoints = 6
+		if chain != nil {
+			config.NumJoints = chain.DOF()
+		}
+	}
+	if chain != nil && config.NumJoints != chain.DOF() {
+		log.Printf("[Manipulator] %s has %d joints, configured %d; kinematics disabled", config.ArmModel, chain.DOF(), config.NumJoints)
+		chain = nil
 	}
 	if config.ReachRadius == 0 {
 		config.ReachRadius = 0.85 // Default UR5 reach
+		if chain != nil {
+			config.ReachRadius = chain.Reach()
+		}
 	}
 	if config.GripperMaxWidth == 0 {
 		config.GripperMaxWidth = 0.085 // Robotiq 2F-85
 	}
+	if config.GripperForce == 0 {
+		config.GripperForce = 40
+	}
+	if config.GripperSpeed == 0 {
+		config.GripperSpeed = 0.1
+	}
 	if config.MaxLinearSpeed == 0 {
 		config.MaxLinearSpeed = 0.5 // 0.5 m/s default
 	}
 	if config.MaxJointVelocity == 0 {
 		config.MaxJointVelocity = 1.0 // 1 rad/s default
 	}
+	if config.Acceleration == 0 {
+		config.Acceleration = 1.2
+	}
 
 	return &RealManipulator{
 		config:       config,
 		gripperState: 1.0, // Start open
 		armPosition:  Vector3{X: 0.3, Y: 0, Z: 0.5},
 		jointStates:  make([]float64, config.NumJoints),
+		chain:        chain,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -118,6 +168,12 @@ func (m *RealManipulator) Initialize(ctx context.Context) error {
 		return err
 	}
 
+	if m.config.Protocol != "modbus" && m.config.GripperAddress != "" {
+		if err := m.initGripper(ctx, m.config.GripperAddress, m.config.GripperPort); err != nil {
+			return err
+		}
+	}
+
 	// Start state polling
 	m.stopChan = make(chan struct{})
 	go m.statePollingLoop(ctx)
@@ -127,20 +183,23 @@ func (m *RealManipulator) Initialize(ctx context.Context) error {
 }
 
 func (m *RealManipulator) initUR(ctx context.Context) error {
-	// Universal Robots use multiple ports:
-	// 30001: Primary interface (10 Hz)
-	// 30002: Secondary interface (10 Hz)
-	// 30003: Real-time interface (125 Hz)
-	// 30004: RTDE (Real-Time Data Exchange)
-
-	addr := net.JoinHostPort(m.config.Address, strconv.Itoa(30003)) // Real-time interface
+	// Universal Robots state and setpoints go through RTDE (port 30004);
+	// programs, including the servo loop, through the secondary interface
+	// (port 30002)
+	cfg := rtde.DefaultConfig(m.config.Address)
+	if m.config.Port != 0 {
+		cfg.Port = m.config.Port
+	}
+	if m.config.ScriptPort != 0 {
+		cfg.ScriptPort = m.config.ScriptPort
+	}
 
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	client, err := rtde.Dial(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to connect to UR robot: %w", err)
 	}
 
-	m.conn = conn
+	m.rtde = client
 	return nil
 }
 
@@ -159,18 +218,41 @@ func (m *RealManipulator) initROS2(ctx context.Context) error {
 
 func (m *RealManipulator) initModbus(ctx context.Context) error {
 	// Modbus TCP for industrial grippers
-	port := m.config.Port
-	if port == 0 {
-		port = 502 // Default Modbus port
+	return m.initGripper(ctx, m.config.Address, m.config.Port)
+}
+
+// initGripper connects to a Modbus TCP gripper and activates it.
+func (m *RealManipulator) initGripper(ctx context.Context, address string, port int) error {
+	model := m.config.GripperModel
+	if model == "" {
+		model = "robotiq-2f85"
+	}
+	cfg := modbus.DefaultConfig(address)
+	if port != 0 {
+		cfg.Port = port
+	}
+	cfg.UnitID = gripper.DefaultUnitID(model)
+	if m.config.GripperUnitID != 0 {
+		cfg.UnitID = m.config.GripperUnitID
 	}
-	addr := net.JoinHostPort(m.config.Address, strconv.Itoa(port))
 
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	client, err := modbus.Dial(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to connect via Modbus: %w", err)
 	}
+	driver, err := gripper.New(model, client)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	if err := driver.Activate(ctx); err != nil {
+		client.Close()
+		return fmt.Errorf("failed to activate %s gripper: %w", model, err)
+	}
 
-	m.conn = conn
+	m.modbus = client
+	m.gripper = driver
+	m.config.GripperMaxWidth = driver.MaxWidth()
 	return nil
 }
 
@@ -216,34 +298,29 @@ func (m *RealManipulator) updateState() {
 		m.updateStateUR()
 	case "ros2":
 		m.updateStateROS2()
-	case "modbus":
-		m.updateStateModbus()
 	case "http":
 		m.updateStateHTTP()
 	}
+	if m.gripper != nil {
+		m.updateGripperState()
+	}
 }
 
 func (m *RealManipulator) updateStateUR() {
-	if m.conn == nil {
+	if m.rtde == nil {
 		return
 	}
 
-	// Read UR real-time state packet (1108 bytes for UR5e)
-	buf := make([]byte, 1108)
-	n, err := m.conn.Read(buf)
-	if err != nil || n < 200 {
+	state, err := m.rtde.State()
+	if err != nil {
 		return
 	}
-
-	// Parse packet
-	// Offset 252: Actual joint positions (6 * 8 bytes)
 	for i := 0; i < m.config.NumJoints && i < 6; i++ {
-		offset := 252 + i*8
-		m.jointStates[i] = math.Float64frombits(binary.BigEndian.Uint64(buf[offset : offset+8]))
+		m.jointStates[i] = state.JointPositions[i]
 	}
 
-	// Forward kinematics to get TCP position
-	m.armPosition = m.calculateForwardKinematics()
+	// The controller reports the TCP pose with its own calibration
+	m.armPosition = Vector3{X: state.TCPPose[0], Y: state.TCPPose[1], Z: state.TCPPose[2]}
 }
 
 func (m *RealManipulator) updateStateROS2() {
@@ -267,38 +344,19 @@ func (m *RealManipulator) updateStateROS2() {
 	for i := 0; i < m.config.NumJoints && i*8+8 <= n; i++ {
 		m.jointStates[i] = math.Float64frombits(binary.BigEndian.Uint64(buf[i*8 : i*8+8]))
 	}
+	m.armPosition = m.calculateForwardKinematics()
 }
 
-func (m *RealManipulator) updateStateModbus() {
-	if m.conn == nil {
-		return
-	}
-
-	// Modbus read holding registers
-	request := []byte{
-		0x00, 0x01, // Transaction ID
-		0x00, 0x00, // Protocol ID
-		0x00, 0x06, // Length
-		0x01,       // Unit ID
-		0x03,       // Function code (read holding registers)
-		0x00, 0x00, // Start address
-		0x00, 0x10, // Number of registers
-	}
-
-	if _, err := m.conn.Write(request); err != nil {
-		return
-	}
+func (m *RealManipulator) updateGripperState() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
 
-	buf := make([]byte, 256)
-	n, err := m.conn.Read(buf)
-	if err != nil || n < 9 {
+	status, err := m.gripper.Status(ctx)
+	if err != nil {
 		return
 	}
-
-	// Parse gripper position
-	if n >= 11 {
-		m.gripperState = float64(binary.BigEndian.Uint16(buf[9:11])) / 255.0
-	}
+	m.gripperStatus = status
+	m.gripperState = status.Width / m.gripper.MaxWidth()
 }
 
 func (m *RealManipulator) updateStateHTTP() {
@@ -325,40 +383,34 @@ func (m *RealManipulator) updateStateHTTP() {
 	m.armPosition = state.Position
 }
 
+// calculateForwardKinematics returns the tool position for the current
+// joint states, or the last known position without a kinematic model.
 func (m *RealManipulator) calculateForwardKinematics() Vector3 {
-	// Simplified forward kinematics for 6-DOF arm
-	// Real implementation would use DH parameters and proper FK
-
-	// UR5 DH parameters (simplified)
-	d := []float64{0.089, 0, 0, 0.109, 0.094, 0.082}
-	a := []float64{0, -0.425, -0.392, 0, 0, 0}
-
-	x, y, z := 0.0, 0.0, 0.0
-
-	for i, q := range m.jointStates {
-		if i >= len(d) {
-			break
-		}
-		x += a[i] * math.Cos(q)
-		y += a[i] * math.Sin(q)
-		z += d[i]
+	if m.chain == nil {
+		return m.armPosition
 	}
-
-	return Vector3{X: x, Y: y, Z: z}
+	position, err := toolPosition(m.chain, m.jointStates)
+	if err != nil {
+		return m.armPosition
+	}
+	return position
 }
 
 // OpenGripper opens the gripper
 func (m *RealManipulator) OpenGripper() error {
 	m.mu.Lock()
 	protocol := m.config.Protocol
+	driver := m.gripper
 	m.mu.Unlock()
 
+	if driver != nil {
+		return m.gripperCommandDriver(driver, false)
+	}
+
 	var err error
 	switch protocol {
 	case "ur":
 		err = m.gripperCommandUR(1.0, 0.5) // Position 1.0 (open), speed 0.5
-	case "modbus":
-		err = m.gripperCommandModbus(255) // 255 = fully open
 	case "http":
 		err = m.gripperCommandHTTP(1.0)
 	case "ros2":
@@ -380,14 +432,17 @@ func (m *RealManipulator) OpenGripper() error {
 func (m *RealManipulator) CloseGripper() error {
 	m.mu.Lock()
 	protocol := m.config.Protocol
+	driver := m.gripper
 	m.mu.Unlock()
 
+	if driver != nil {
+		return m.gripperCommandDriver(driver, true)
+	}
+
 	var err error
 	switch protocol {
 	case "ur":
 		err = m.gripperCommandUR(0.0, 0.5)
-	case "modbus":
-		err = m.gripperCommandModbus(0)
 	case "http":
 		err = m.gripperCommandHTTP(0.0)
 	case "ros2":
@@ -406,36 +461,51 @@ func (m *RealManipulator) CloseGripper() error {
 }
 
 func (m *RealManipulator) gripperCommandUR(position, speed float64) error {
-	if m.conn == nil {
+	if m.rtde == nil {
 		return fmt.Errorf("not connected")
 	}
 
-	// URScript command for Robotiq gripper
+	// URScript command for Robotiq gripper; this replaces the servo
+	// program, which the next move reinstalls
 	script := fmt.Sprintf("rq_move_and_wait_for_pos(%d, %d, %d)\n",
 		int(position*255), int(speed*255), 100) // position, speed, force
 
-	_, err := m.conn.Write([]byte(script))
-	return err
+	return m.rtde.SendScript(context.Background(), script)
 }
 
-func (m *RealManipulator) gripperCommandModbus(position byte) error {
-	if m.conn == nil {
-		return fmt.Errorf("not connected")
-	}
+// gripperCommandDriver opens or closes a Modbus gripper and waits for the
+// fingers to stop. Closing grips at the configured force; the resulting
+// opening reflects any object held.
+func (m *RealManipulator) gripperCommandDriver(driver gripper.Gripper, grip bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	m.mu.RLock()
+	cmd := gripper.Command{Speed: m.config.GripperSpeed, Force: m.config.GripperForce}
+	m.mu.RUnlock()
 
-	// Modbus write single register
-	request := []byte{
-		0x00, 0x02, // Transaction ID
-		0x00, 0x00, // Protocol ID
-		0x00, 0x06, // Length
-		0x01,       // Unit ID
-		0x06,       // Function code (write single register)
-		0x00, 0x00, // Register address
-		0x00, position, // Value
+	var err error
+	if grip {
+		err = driver.Grip(ctx, cmd)
+	} else {
+		cmd.Width = driver.MaxWidth()
+		err = driver.Move(ctx, cmd)
+	}
+	if err != nil {
+		return err
+	}
+	// Let the fingers start before polling for the stop
+	time.Sleep(20 * time.Millisecond)
+	status, err := gripper.WaitIdle(ctx, driver, 20*time.Millisecond)
+	if err != nil {
+		return err
 	}
 
-	_, err := m.conn.Write(request)
-	return err
+	m.mu.Lock()
+	m.gripperStatus = status
+	m.gripperState = status.Width / driver.MaxWidth()
+	m.mu.Unlock()
+	return nil
 }
 
 func (m *RealManipulator) gripperCommandHTTP(position float64) error {
@@ -485,11 +555,26 @@ func (m *RealManipulator) GetGripperState() (float64, error) {
 	return m.gripperState, nil
 }
 
+// GetGripperStatus returns the latest status of a Modbus gripper,
+// including whether it holds an object.
+func (m *RealManipulator) GetGripperStatus() (gripper.Status, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.gripper == nil {
+		return gripper.Status{}, fmt.Errorf("no Modbus gripper configured")
+	}
+	return m.gripperStatus, nil
+}
+
 // ReachTo moves the arm end-effector to a target position
 func (m *RealManipulator) ReachTo(ctx context.Context, position Vector3) error {
 	m.mu.Lock()
 	reachRadius := m.config.ReachRadius
 	protocol := m.config.Protocol
+	chain := m.chain
+	env := m.environment
+	seed := append([]float64(nil), m.jointStates...)
 	m.mu.Unlock()
 
 	// Validate reachability
@@ -498,57 +583,118 @@ func (m *RealManipulator) ReachTo(ctx context.Context, position Vector3) error {
 		return fmt.Errorf("position out of reach: %.3fm (max %.3fm)", distance, reachRadius)
 	}
 
+	// Resolve the joint goal, and with an environment the whole motion, up
+	// front so unreachable targets and blocked paths are rejected before
+	// anything moves
+	var joints []float64
+	var traj *motion.Trajectory
+	switch {
+	case chain != nil && env != nil:
+		var err error
+		if traj, err = planReach(ctx, chain, env, m.planningConfig(), seed, position); err != nil {
+			log.Printf("[Manipulator] Reach to (%.3f, %.3f, %.3f) not planned: %v", position.X, position.Y, position.Z, err)
+			return err
+		}
+		joints = traj.Points[len(traj.Points)-1].Positions
+	case chain != nil:
+		var err error
+		if joints, err = solveReach(chain, seed, position); err != nil {
+			return err
+		}
+	}
+
 	switch protocol {
 	case "ur":
-		return m.moveToUR(ctx, position)
+		return m.moveToUR(ctx, position, joints, traj)
 	case "ros2":
-		return m.moveToROS2(ctx, position)
+		return m.moveToROS2(ctx, position, joints, traj)
 	case "http":
-		return m.moveToHTTP(ctx, position)
+		return m.moveToHTTP(ctx, position, joints, traj)
 	default:
 		return fmt.Errorf("move not supported on %s", protocol)
 	}
 }
 
-func (m *RealManipulator) moveToUR(ctx context.Context, position Vector3) error {
-	if m.conn == nil {
+func (m *RealManipulator) moveToUR(ctx context.Context, position Vector3, joints []float64, traj *motion.Trajectory) error {
+	if m.rtde == nil {
 		return fmt.Errorf("not connected")
 	}
 
-	// URScript moveL command
-	script := fmt.Sprintf("movel(p[%.4f, %.4f, %.4f, 0, 3.14, 0], a=%.2f, v=%.2f)\n",
-		position.X, position.Y, position.Z,
-		m.config.Acceleration, m.config.MaxLinearSpeed)
-
-	_, err := m.conn.Write([]byte(script))
-	if err != nil {
-		return err
+	// Moving to a Cartesian point needs a solved joint goal; guessing a tool
+	// orientation for moveL could drive the arm somewhere unplanned
+	if len(joints) != 6 {
+		return fmt.Errorf("UR move to (%.3f, %.3f, %.3f) needs a 6-joint IK solution, got %d joints",
+			position.X, position.Y, position.Z, len(joints))
 	}
 
-	m.mu.Lock()
-	m.armPosition = position
-	m.mu.Unlock()
-
+	// Stream the planned trajectory, or a direct one to the joint goal,
+	// through the servo loop
+	if traj != nil {
+		err := m.rtde.Follow(ctx, traj.Duration(), func(t time.Duration) [6]float64 {
+			var q [6]float64
+			copy(q[:], traj.Sample(t))
+			return q
+		})
+		if err != nil {
+			return fmt.Errorf("UR move failed: %w", err)
+		}
+		return nil
+	}
+	var goal [6]float64
+	copy(goal[:], joints)
+	if err := m.rtde.ServoTo(ctx, goal, m.config.MaxJointVelocity); err != nil {
+		return fmt.Errorf("UR move failed: %w", err)
+	}
 	return nil
 }
 
-func (m *RealManipulator) moveToROS2(ctx context.Context, position Vector3) error {
+func (m *RealManipulator) moveToROS2(ctx context.Context, position Vector3, joints []float64, traj *motion.Trajectory) error {
 	if m.conn == nil {
 		return fmt.Errorf("not connected")
 	}
+	if traj != nil {
+		return m.trajectoryROS2(traj)
+	}
 
-	cmd := make([]byte, 32)
+	// Byte 2 carries the number of joint goals appended after the position
+	cmd := make([]byte, 32+8*len(joints))
 	cmd[0] = 0xAA
 	cmd[1] = 0x03 // Move command
+	cmd[2] = byte(len(joints))
 	binary.BigEndian.PutUint64(cmd[8:16], math.Float64bits(position.X))
 	binary.BigEndian.PutUint64(cmd[16:24], math.Float64bits(position.Y))
 	binary.BigEndian.PutUint64(cmd[24:32], math.Float64bits(position.Z))
+	for i, q := range joints {
+		binary.BigEndian.PutUint64(cmd[32+8*i:40+8*i], math.Float64bits(q))
+	}
+
+	_, err := m.conn.Write(cmd)
+	return err
+}
+
+// trajectoryROS2 sends a planned trajectory: byte 2 carries the number of
+// joints and bytes 4-8 the number of points, each point a time in seconds
+// followed by the joint positions.
+func (m *RealManipulator) trajectoryROS2(traj *motion.Trajectory) error {
+	points := traj.Resample(trajectoryPeriod)
+	joints := len(points[0].Positions)
+	cmd := make([]byte, 8, 8+8*len(points)*(joints+1))
+	cmd[0] = 0xAA
+	cmd[1] = 0x04 // Trajectory command
+	cmd[2] = byte(joints)
+	binary.BigEndian.PutUint32(cmd[4:8], uint32(len(points)))
+	for _, p := range points {
+		cmd = binary.BigEndian.AppendUint64(cmd, math.Float64bits(p.Time.Seconds()))
+		for _, q := range p.Positions {
+			cmd = binary.BigEndian.AppendUint64(cmd, math.Float64bits(q))
+		}
+	}
 
 	_, err := m.conn.Write(cmd)
 	return err
 }
 
-func (m *RealManipulator) moveToHTTP(ctx context.Context, position Vector3) error {
+func (m *RealManipulator) moveToHTTP(ctx context.Context, position Vector3, joints []float64, traj *motion.Trajectory) error {
 	url := fmt.Sprintf("http://%s:%d/api/arm/moveto", m.config.Address, m.config.Port)
 
 	payload := map[string]interface{}{
@@ -559,6 +705,12 @@ func (m *RealManipulator) moveToHTTP(ctx context.Context, position Vector3) erro
 		},
 		"speed": m.config.MaxLinearSpeed,
 	}
+	if len(joints) > 0 {
+		payload["joints"] = joints
+	}
+	if traj != nil {
+		payload["trajectory"] = trajectoryPayload(traj)
+	}
 	body, _ := json.Marshal(payload)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
@@ -576,6 +728,32 @@ func (m *RealManipulator) moveToHTTP(ctx context.Context, position Vector3) erro
 	return nil
 }
 
+// SetEnvironment enables collision-aware reaching: ReachTo plans a path
+// around the environment's obstacles and follows it within the joint
+// velocity and acceleration limits. A nil environment restores direct
+// moves. Arms without a kinematic model cannot plan.
+func (m *RealManipulator) SetEnvironment(env *motion.Environment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if env != nil && m.chain == nil {
+		return fmt.Errorf("motion planning needs a kinematic model for %s", m.config.ArmModel)
+	}
+	m.environment = env
+	return nil
+}
+
+func (m *RealManipulator) planningConfig() motion.Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg := motion.DefaultConfig()
+	if m.config.MotionPlanner != "" {
+		cfg.Algorithm = motion.Algorithm(m.config.MotionPlanner)
+	}
+	cfg.MaxJointVelocity = m.config.MaxJointVelocity
+	cfg.MaxJointAcceleration = m.config.Acceleration
+	return cfg
+}
+
 // GetJointStates returns current joint positions
 func (m *RealManipulator) GetJointStates() []float64 {
 	m.mu.RLock()
@@ -586,6 +764,18 @@ func (m *RealManipulator) GetJointStates() []float64 {
 	return result
 }
 
+// GetURState returns the latest RTDE state of a UR arm: joint positions,
+// TCP pose and force, and robot and safety modes.
+func (m *RealManipulator) GetURState() (rtde.State, error) {
+	m.mu.RLock()
+	client := m.rtde
+	m.mu.RUnlock()
+	if client == nil {
+		return rtde.State{}, fmt.Errorf("not connected to a UR arm")
+	}
+	return client.State()
+}
+
 // GetPosition returns current end-effector position
 func (m *RealManipulator) GetPosition() Vector3 {
 	m.mu.RLock()
@@ -605,6 +795,15 @@ func (m *RealManipulator) Shutdown() error {
 	if m.conn != nil {
 		m.conn.Close()
 	}
+	if m.rtde != nil {
+		m.rtde.Close()
+		m.rtde = nil
+	}
+	if m.modbus != nil {
+		m.modbus.Close()
+		m.modbus = nil
+		m.gripper = nil
+	}
 
 	m.isInitialized = false
 	return nil
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/asgard/pandora/internal/robotics/perception"
 )
 
 // RemoteHunoid implements HunoidController via HTTP endpoints.
@@ -112,6 +114,16 @@ func (h *RemoteHunoid) GetBatteryPercent() float64 {
 	return status.BatteryPercent
 }
 
+// GetScan returns the robot's latest 360° perception scan. Object
+// positions and velocities are relative to the robot.
+func (h *RemoteHunoid) GetScan() (*perception.ScanResult360, error) {
+	var scan perception.ScanResult360
+	if err := h.getJSON("/hunoids/"+h.id+"/scan", &scan); err != nil {
+		return nil, err
+	}
+	return &scan, nil
+}
+
 func (h *RemoteHunoid) getJSON(path string, out interface{}) error {
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, h.baseURL+path, nil)
 	if err != nil {
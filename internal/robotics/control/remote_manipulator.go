@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+	"github.com/asgard/pandora/internal/robotics/motion"
 )
 
 // RemoteManipulator implements ManipulatorController via HTTP endpoints.
@@ -15,6 +19,12 @@ type RemoteManipulator struct {
 	hunoidID string
 	baseURL  string
 	client   *http.Client
+
+	mu       sync.Mutex
+	chain    *kinematics.Chain // Set by SetArmModel
+	joints   []float64         // Last joint positions reported by the endpoint
+	env      *motion.Environment
+	planning motion.Config
 }
 
 // NewRemoteManipulator creates a new remote manipulator controller.
@@ -34,6 +44,35 @@ func NewRemoteManipulator(hunoidID, baseURL string) (*RemoteManipulator, error)
 	}, nil
 }
 
+// SetArmModel enables inverse kinematics for the named arm, so reach
+// requests carry a joint goal alongside the Cartesian target.
+func (m *RemoteManipulator) SetArmModel(model string) error {
+	chain, err := kinematics.Model(model)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.chain = chain
+	m.joints = make([]float64, chain.DOF())
+	m.mu.Unlock()
+	return nil
+}
+
+// SetEnvironment enables collision-aware reaching: reach requests carry a
+// trajectory planned around the environment's obstacles under cfg's joint
+// limits. It needs an arm model; a nil environment restores direct
+// reaches.
+func (m *RemoteManipulator) SetEnvironment(env *motion.Environment, cfg motion.Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if env != nil && m.chain == nil {
+		return fmt.Errorf("motion planning needs an arm model")
+	}
+	m.env = env
+	m.planning = cfg
+	return nil
+}
+
 func (m *RemoteManipulator) OpenGripper() error {
 	return m.postJSON(context.Background(), "/hunoids/"+m.hunoidID+"/manipulator/open", nil, nil)
 }
@@ -44,17 +83,52 @@ func (m *RemoteManipulator) CloseGripper() error {
 
 func (m *RemoteManipulator) GetGripperState() (float64, error) {
 	var resp struct {
-		State float64 `json:"state"`
+		State  float64   `json:"state"`
+		Joints []float64 `json:"joints"`
 	}
 	if err := m.getJSON("/hunoids/"+m.hunoidID+"/manipulator/state", &resp); err != nil {
 		return 0, err
 	}
+	m.mu.Lock()
+	if m.chain != nil && len(resp.Joints) == m.chain.DOF() {
+		m.joints = resp.Joints
+	}
+	m.mu.Unlock()
 	return resp.State, nil
 }
 
 func (m *RemoteManipulator) ReachTo(ctx context.Context, position Vector3) error {
 	payload := map[string]interface{}{"position": position}
-	return m.postJSON(ctx, "/hunoids/"+m.hunoidID+"/manipulator/reach", payload, nil)
+
+	m.mu.Lock()
+	chain, seed := m.chain, append([]float64(nil), m.joints...)
+	env, cfg := m.env, m.planning
+	m.mu.Unlock()
+	switch {
+	case chain != nil && env != nil:
+		traj, err := planReach(ctx, chain, env, cfg, seed, position)
+		if err != nil {
+			return err
+		}
+		payload["joints"] = traj.Points[len(traj.Points)-1].Positions
+		payload["trajectory"] = trajectoryPayload(traj)
+	case chain != nil:
+		joints, err := solveReach(chain, seed, position)
+		if err != nil {
+			return err
+		}
+		payload["joints"] = joints
+	}
+
+	if err := m.postJSON(ctx, "/hunoids/"+m.hunoidID+"/manipulator/reach", payload, nil); err != nil {
+		return err
+	}
+	if chain != nil {
+		m.mu.Lock()
+		m.joints = payload["joints"].([]float64)
+		m.mu.Unlock()
+	}
+	return nil
 }
 
 func (m *RemoteManipulator) getJSON(path string, out interface{}) error {
@@ -0,0 +1,119 @@
+// Package gripper drives industrial grippers over Modbus TCP: the Robotiq
+// 2F-85 and the Schunk WSG-50, behind one interface for position, speed
+// and force control with object detection.
+package gripper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/modbus"
+)
+
+var (
+	// ErrUnknownModel is returned by New for unsupported grippers.
+	ErrUnknownModel = errors.New("unknown gripper model")
+	// ErrFault is returned when the gripper reports a fault.
+	ErrFault = errors.New("gripper fault")
+	// ErrNotReady is returned when the gripper does not finish activation
+	// or homing in time.
+	ErrNotReady = errors.New("gripper not ready")
+)
+
+// Command is a finger motion. Values outside the gripper's range are
+// clamped to it.
+type Command struct {
+	Width float64 // Opening between the fingers, meters
+	Speed float64 // m/s
+	Force float64 // Newtons
+}
+
+// Status is the gripper state.
+type Status struct {
+	Width          float64 // Opening between the fingers, meters
+	Moving         bool
+	ObjectDetected bool // Fingers stopped on an object before the target
+	Ready          bool // Activated or homed
+	Fault          string
+}
+
+// Gripper is a parallel gripper.
+type Gripper interface {
+	// Model returns the configuration name of the gripper.
+	Model() string
+	// MaxWidth returns the full stroke in meters.
+	MaxWidth() float64
+	// Activate initializes the gripper; the Robotiq activates and the WSG
+	// homes. It returns once the gripper is ready.
+	Activate(ctx context.Context) error
+	// Move starts a motion to the commanded width without gripping.
+	Move(ctx context.Context, cmd Command) error
+	// Grip starts closing to the commanded width, stopping at the commanded
+	// force when the fingers meet an object.
+	Grip(ctx context.Context, cmd Command) error
+	// Stop halts the fingers.
+	Stop(ctx context.Context) error
+	// Status reads the gripper state.
+	Status(ctx context.Context) (Status, error)
+}
+
+// New returns the driver for a gripper model, by the names used in
+// manipulator configuration: robotiq-2f85 and wsg50.
+func New(model string, client *modbus.Client) (Gripper, error) {
+	switch normalizeModel(model) {
+	case "robotiq-2f85":
+		return NewRobotiq2F85(client), nil
+	case "wsg50":
+		return NewWSG50(client), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownModel, model)
+}
+
+// DefaultUnitID returns the factory Modbus unit ID of a gripper model.
+func DefaultUnitID(model string) byte {
+	if normalizeModel(model) == "robotiq-2f85" {
+		return 9
+	}
+	return 1
+}
+
+func normalizeModel(model string) string {
+	switch strings.ToLower(strings.TrimSpace(model)) {
+	case "robotiq-2f85", "robotiq-2f-85", "2f85", "2f-85", "robotiq":
+		return "robotiq-2f85"
+	case "wsg50", "wsg-50", "schunk-wsg50", "schunk-wsg-50", "wsg":
+		return "wsg50"
+	}
+	return ""
+}
+
+// WaitIdle polls the gripper until the fingers stop, returning the final
+// status. A fault ends the wait with ErrFault.
+func WaitIdle(ctx context.Context, g Gripper, poll time.Duration) (Status, error) {
+	for {
+		status, err := g.Status(ctx)
+		if err != nil {
+			return status, err
+		}
+		if status.Fault != "" {
+			return status, fmt.Errorf("%w: %s", ErrFault, status.Fault)
+		}
+		if !status.Moving {
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// scale maps v from [lo, hi] onto [0, 1], clamped.
+func scale(v, lo, hi float64) float64 {
+	return math.Max(0, math.Min(1, (v-lo)/(hi-lo)))
+}
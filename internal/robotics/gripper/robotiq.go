@@ -0,0 +1,151 @@
+package gripper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/modbus"
+)
+
+// Robotiq 2F-85 register map: the robot output (command) registers start
+// at 1000 and the input (status) registers at 2000, each register holding
+// two of the gripper's byte-wide fields.
+const (
+	robotiqCommandRegister = 1000
+	robotiqStatusRegister  = 2000
+
+	// Action request bits
+	robotiqACT = 1 << 0 // Activate
+	robotiqGTO = 1 << 3 // Go to requested position
+	robotiqATR = 1 << 4 // Automatic release
+
+	robotiqStroke   = 0.085 // m
+	robotiqMinSpeed = 0.020 // m/s
+	robotiqMaxSpeed = 0.150
+	robotiqMinForce = 20.0 // N
+	robotiqMaxForce = 235.0
+)
+
+var robotiqFaults = map[byte]string{
+	0x05: "action delayed, activation must complete first",
+	0x07: "activation bit must be set before action",
+	0x08: "maximum operating temperature exceeded",
+	0x09: "no communication for at least one second",
+	0x0A: "under minimum operating voltage",
+	0x0B: "automatic release in progress",
+	0x0C: "internal fault",
+	0x0D: "activation fault",
+	0x0E: "overcurrent triggered",
+	0x0F: "automatic release completed",
+}
+
+// Robotiq2F85 drives a Robotiq 2F-85 adaptive gripper.
+type Robotiq2F85 struct {
+	client *modbus.Client
+	// ActivationTimeout bounds Activate; activation strokes the fingers
+	// fully, which takes a few seconds.
+	ActivationTimeout time.Duration
+}
+
+// NewRobotiq2F85 returns a driver for a 2F-85 on client, unit 9 by
+// default.
+func NewRobotiq2F85(client *modbus.Client) *Robotiq2F85 {
+	return &Robotiq2F85{client: client, ActivationTimeout: 10 * time.Second}
+}
+
+// Model returns "robotiq-2f85".
+func (g *Robotiq2F85) Model() string { return "robotiq-2f85" }
+
+// MaxWidth returns the 85 mm stroke.
+func (g *Robotiq2F85) MaxWidth() float64 { return robotiqStroke }
+
+// Activate resets and activates the gripper, waiting for activation to
+// complete.
+func (g *Robotiq2F85) Activate(ctx context.Context) error {
+	if err := g.write(ctx, 0, 0, 0, 0); err != nil {
+		return fmt.Errorf("robotiq reset: %w", err)
+	}
+	if err := g.write(ctx, robotiqACT, 0, 0, 0); err != nil {
+		return fmt.Errorf("robotiq activate: %w", err)
+	}
+	deadline := time.Now().Add(g.ActivationTimeout)
+	for {
+		status, err := g.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Ready {
+			return nil
+		}
+		if status.Fault != "" {
+			return fmt.Errorf("%w: %s", ErrFault, status.Fault)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("robotiq activation: %w", ErrNotReady)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Move starts a motion to the commanded width. The 2F-85 always grips:
+// it stops on any object at the commanded force.
+func (g *Robotiq2F85) Move(ctx context.Context, cmd Command) error {
+	return g.Grip(ctx, cmd)
+}
+
+// Grip starts closing to the commanded width at the commanded speed and
+// force.
+func (g *Robotiq2F85) Grip(ctx context.Context, cmd Command) error {
+	position := byte(math.Round(255 * (1 - scale(cmd.Width, 0, robotiqStroke))))
+	speed := byte(math.Round(255 * scale(cmd.Speed, robotiqMinSpeed, robotiqMaxSpeed)))
+	force := byte(math.Round(255 * scale(cmd.Force, robotiqMinForce, robotiqMaxForce)))
+	return g.write(ctx, robotiqACT|robotiqGTO, position, speed, force)
+}
+
+// Stop clears the go-to bit, halting the fingers where they are.
+func (g *Robotiq2F85) Stop(ctx context.Context) error {
+	return g.write(ctx, robotiqACT, 0, 0, 0)
+}
+
+func (g *Robotiq2F85) write(ctx context.Context, action, position, speed, force byte) error {
+	return g.client.WriteMultipleRegisters(ctx, robotiqCommandRegister, []uint16{
+		uint16(action) << 8, // Action request, reserved
+		uint16(position),    // Reserved, position request
+		uint16(speed)<<8 | uint16(force),
+	})
+}
+
+// Status reads the gripper status registers.
+func (g *Robotiq2F85) Status(ctx context.Context) (Status, error) {
+	regs, err := g.client.ReadInputRegisters(ctx, robotiqStatusRegister, 3)
+	if err != nil {
+		return Status{}, fmt.Errorf("robotiq status: %w", err)
+	}
+	gripperStatus := byte(regs[0] >> 8)
+	fault := byte(regs[1] >> 8)
+	position := byte(regs[2] >> 8)
+
+	goTo := gripperStatus&robotiqGTO != 0
+	activation := (gripperStatus >> 4) & 0x3
+	object := (gripperStatus >> 6) & 0x3
+
+	status := Status{
+		Width:          robotiqStroke * (1 - float64(position)/255),
+		Ready:          activation == 3,
+		Moving:         goTo && object == 0,
+		ObjectDetected: object == 1 || object == 2,
+	}
+	if fault != 0 {
+		status.Fault = robotiqFaults[fault]
+		if status.Fault == "" {
+			status.Fault = fmt.Sprintf("fault 0x%02x", fault)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,180 @@
+package gripper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/modbus"
+)
+
+// Schunk WSG-50 fieldbus process image over Modbus TCP. The command block
+// at holding register 0 is a control word followed by width, speed and
+// force as IEEE 754 floats in two registers, high word first. The status
+// block at input register 0 mirrors it with a status word and a fault
+// code. Every new command toggles the handshake bit, which the gripper
+// echoes once it has taken the command.
+const (
+	wsgCommandRegister = 0
+	wsgStatusRegister  = 0
+	wsgStatusRegisters = 8
+
+	// Control word bits
+	wsgAcknowledge = 1 << 0 // Acknowledge fault
+	wsgHome        = 1 << 1
+	wsgPrePosition = 1 << 2 // Move without gripping
+	wsgGrip        = 1 << 3
+	wsgRelease     = 1 << 4
+	wsgStop        = 1 << 5
+	wsgHandshake   = 1 << 15
+
+	// Status word bits
+	wsgReady      = 1 << 0
+	wsgReferenced = 1 << 1
+	wsgMoving     = 1 << 2
+	wsgHolding    = 1 << 4 // Part gripped
+	wsgLostPart   = 1 << 6
+	wsgFault      = 1 << 7
+
+	wsgStroke   = 0.110 // m
+	wsgMinSpeed = 0.005 // m/s
+	wsgMaxSpeed = 0.420
+	wsgMinForce = 5.0 // N
+	wsgMaxForce = 80.0
+)
+
+// WSG50 drives a Schunk WSG-50 servo-electric gripper.
+type WSG50 struct {
+	client    *modbus.Client
+	mu        sync.Mutex
+	handshake uint16
+	// HomingTimeout bounds Activate.
+	HomingTimeout time.Duration
+}
+
+// NewWSG50 returns a driver for a WSG-50 on client.
+func NewWSG50(client *modbus.Client) *WSG50 {
+	return &WSG50{client: client, HomingTimeout: 10 * time.Second}
+}
+
+// Model returns "wsg50".
+func (g *WSG50) Model() string { return "wsg50" }
+
+// MaxWidth returns the 110 mm stroke.
+func (g *WSG50) MaxWidth() float64 { return wsgStroke }
+
+// Activate acknowledges any fault and homes the fingers, waiting until the
+// gripper is referenced.
+func (g *WSG50) Activate(ctx context.Context) error {
+	if _, err := g.command(ctx, wsgAcknowledge, Command{}); err != nil {
+		return fmt.Errorf("wsg acknowledge: %w", err)
+	}
+	if _, err := g.command(ctx, wsgHome, Command{}); err != nil {
+		return fmt.Errorf("wsg home: %w", err)
+	}
+	deadline := time.Now().Add(g.HomingTimeout)
+	for {
+		status, err := g.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Ready && !status.Moving {
+			return nil
+		}
+		if status.Fault != "" {
+			return fmt.Errorf("%w: %s", ErrFault, status.Fault)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wsg homing: %w", ErrNotReady)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Move pre-positions the fingers, or releases a held part when opening.
+func (g *WSG50) Move(ctx context.Context, cmd Command) error {
+	status, err := g.Status(ctx)
+	if err != nil {
+		return err
+	}
+	control := uint16(wsgPrePosition)
+	if status.ObjectDetected {
+		control = wsgRelease
+	}
+	_, err = g.command(ctx, control, cmd)
+	return err
+}
+
+// Grip closes on a part at the commanded force.
+func (g *WSG50) Grip(ctx context.Context, cmd Command) error {
+	_, err := g.command(ctx, wsgGrip, cmd)
+	return err
+}
+
+// Stop halts the fingers.
+func (g *WSG50) Stop(ctx context.Context) error {
+	_, err := g.command(ctx, wsgStop, Command{})
+	return err
+}
+
+// command writes the command block and reads back the status block in one
+// read/write transaction.
+func (g *WSG50) command(ctx context.Context, control uint16, cmd Command) (Status, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.handshake ^= wsgHandshake
+	width := wsgStroke * scale(cmd.Width, 0, wsgStroke)
+	speed := wsgMinSpeed + (wsgMaxSpeed-wsgMinSpeed)*scale(cmd.Speed, wsgMinSpeed, wsgMaxSpeed)
+	force := wsgMinForce + (wsgMaxForce-wsgMinForce)*scale(cmd.Force, wsgMinForce, wsgMaxForce)
+
+	values := []uint16{control | g.handshake}
+	values = appendFloat(values, width*1000) // mm
+	values = appendFloat(values, speed*1000) // mm/s
+	values = appendFloat(values, force)
+	regs, err := g.client.ReadWriteMultipleRegisters(ctx, wsgStatusRegister, wsgStatusRegisters, wsgCommandRegister, values)
+	if err != nil {
+		return Status{}, fmt.Errorf("wsg command: %w", err)
+	}
+	return wsgStatus(regs), nil
+}
+
+// Status reads the status block.
+func (g *WSG50) Status(ctx context.Context) (Status, error) {
+	regs, err := g.client.ReadInputRegisters(ctx, wsgStatusRegister, wsgStatusRegisters)
+	if err != nil {
+		return Status{}, fmt.Errorf("wsg status: %w", err)
+	}
+	return wsgStatus(regs), nil
+}
+
+func wsgStatus(regs []uint16) Status {
+	word := regs[0]
+	status := Status{
+		Width:          float64(readFloat(regs[1:3])) / 1000,
+		Ready:          word&wsgReady != 0 && word&wsgReferenced != 0,
+		Moving:         word&wsgMoving != 0,
+		ObjectDetected: word&wsgHolding != 0,
+	}
+	switch {
+	case word&wsgFault != 0:
+		status.Fault = fmt.Sprintf("fault code %d", regs[7])
+	case word&wsgLostPart != 0:
+		status.Fault = "part lost"
+	}
+	return status
+}
+
+func appendFloat(regs []uint16, v float64) []uint16 {
+	bits := math.Float32bits(float32(v))
+	return append(regs, uint16(bits>>16), uint16(bits))
+}
+
+func readFloat(regs []uint16) float32 {
+	return math.Float32frombits(uint32(regs[0])<<16 | uint32(regs[1]))
+}
@@ -0,0 +1,178 @@
+package kinematics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrJointCount is returned when a joint vector does not match the chain.
+	ErrJointCount = errors.New("joint count mismatch")
+	// ErrJointLimits is returned for configurations outside the joint limits.
+	ErrJointLimits = errors.New("joint limits exceeded")
+)
+
+// Joint is a revolute joint: a fixed transform from the previous joint's
+// frame followed by a rotation about Axis.
+type Joint struct {
+	Name        string
+	Origin      Transform
+	Axis        [3]float64 // Unit axis in the joint frame
+	Lower       float64    // -Inf for continuous joints
+	Upper       float64    // +Inf for continuous joints
+	MaxVelocity float64    // rad/s, zero if unknown
+}
+
+// Within reports whether q is inside the joint limits.
+func (j Joint) Within(q float64) bool {
+	return q >= j.Lower-1e-9 && q <= j.Upper+1e-9
+}
+
+// Clamp limits q to the joint range.
+func (j Joint) Clamp(q float64) float64 {
+	return math.Max(j.Lower, math.Min(j.Upper, q))
+}
+
+// Chain is a serial chain of revolute joints from the arm base to the tool
+// centre point.
+type Chain struct {
+	Name   string
+	Base   Transform // World to the first joint's parent frame
+	Joints []Joint
+	Tool   Transform // Last joint frame to the tool centre point
+
+	// ur holds the DH table of Universal Robots arms, enabling the
+	// closed-form inverse.
+	ur *urParams
+}
+
+// DH is a row of a Denavit–Hartenberg table.
+type DH struct {
+	A      float64 // Link length
+	Alpha  float64 // Link twist
+	D      float64 // Link offset
+	Offset float64 // Added to the joint angle
+}
+
+// JointSpec carries the limits of a DH joint.
+type JointSpec struct {
+	Name        string
+	Lower       float64
+	Upper       float64
+	MaxVelocity float64
+}
+
+// NewDHChain builds a chain from a standard (distal) DH table, where joint
+// i rotates about z(i-1) and the link transform is
+// Rz(θ+offset)·Tz(d)·Tx(a)·Rx(α).
+func NewDHChain(name string, table []DH, specs []JointSpec) (*Chain, error) {
+	if len(table) != len(specs) {
+		return nil, fmt.Errorf("%s: %d DH rows for %d joints: %w", name, len(table), len(specs), ErrJointCount)
+	}
+	c := &Chain{Name: name, Base: Identity(), Tool: Identity()}
+	origin := Identity()
+	for i, row := range table {
+		c.Joints = append(c.Joints, specs[i].joint(origin.Mul(RotZ(row.Offset))))
+		origin = Translation(0, 0, row.D).Mul(Translation(row.A, 0, 0)).Mul(RotX(row.Alpha))
+	}
+	c.Tool = origin
+	return c, nil
+}
+
+// NewModifiedDHChain builds a chain from a modified (proximal, Craig) DH
+// table, where the link transform is Rx(α)·Tx(a)·Rz(θ+offset)·Tz(d).
+func NewModifiedDHChain(name string, table []DH, specs []JointSpec) (*Chain, error) {
+	if len(table) != len(specs) {
+		return nil, fmt.Errorf("%s: %d DH rows for %d joints: %w", name, len(table), len(specs), ErrJointCount)
+	}
+	c := &Chain{Name: name, Base: Identity(), Tool: Identity()}
+	for i, row := range table {
+		// Tz(d) commutes with the joint rotation about z
+		origin := RotX(row.Alpha).Mul(Translation(row.A, 0, row.D)).Mul(RotZ(row.Offset))
+		c.Joints = append(c.Joints, specs[i].joint(origin))
+	}
+	return c, nil
+}
+
+func (s JointSpec) joint(origin Transform) Joint {
+	lower, upper := s.Lower, s.Upper
+	if lower == 0 && upper == 0 {
+		lower, upper = math.Inf(-1), math.Inf(1)
+	}
+	return Joint{
+		Name:        s.Name,
+		Origin:      origin,
+		Axis:        [3]float64{0, 0, 1},
+		Lower:       lower,
+		Upper:       upper,
+		MaxVelocity: s.MaxVelocity,
+	}
+}
+
+// DOF returns the number of joints.
+func (c *Chain) DOF() int {
+	return len(c.Joints)
+}
+
+// Forward returns the tool centre point pose for joint positions q.
+func (c *Chain) Forward(q []float64) (Transform, error) {
+	frames, err := c.JointFrames(q)
+	if err != nil {
+		return Transform{}, err
+	}
+	return frames[len(frames)-1].Mul(c.Tool), nil
+}
+
+// JointFrames returns each joint's frame after its rotation, in world
+// axes.
+func (c *Chain) JointFrames(q []float64) ([]Transform, error) {
+	if len(q) != len(c.Joints) {
+		return nil, fmt.Errorf("%s: %d joint positions for %d joints: %w", c.Name, len(q), len(c.Joints), ErrJointCount)
+	}
+	frames := make([]Transform, len(c.Joints))
+	t := c.Base
+	for i, j := range c.Joints {
+		t = t.Mul(j.Origin).Mul(AxisAngle(j.Axis, q[i]))
+		frames[i] = t
+	}
+	return frames, nil
+}
+
+// CheckLimits returns an error naming the first joint outside its limits.
+func (c *Chain) CheckLimits(q []float64) error {
+	if len(q) != len(c.Joints) {
+		return fmt.Errorf("%s: %d joint positions for %d joints: %w", c.Name, len(q), len(c.Joints), ErrJointCount)
+	}
+	for i, j := range c.Joints {
+		if !j.Within(q[i]) {
+			return fmt.Errorf("%s joint %s at %.3f rad outside [%.3f, %.3f]: %w", c.Name, j.Name, q[i], j.Lower, j.Upper, ErrJointLimits)
+		}
+	}
+	return nil
+}
+
+// Reach returns an upper bound on the distance from the first joint to
+// the tool centre point.
+func (c *Chain) Reach() float64 {
+	var reach float64
+	for i := 1; i < len(c.Joints); i++ {
+		reach += norm(c.Joints[i].Origin.P)
+	}
+	return reach + norm(c.Tool.P)
+}
+
+// normalizeInto shifts q by whole turns towards ref and into the joint's
+// limits, reporting whether the joint can reach the angle at all.
+func normalizeInto(j Joint, q, ref float64) (float64, bool) {
+	q = ref + math.Remainder(q-ref, 2*math.Pi)
+	if j.Within(q) {
+		return q, true
+	}
+	for _, alt := range []float64{q - 2*math.Pi, q + 2*math.Pi} {
+		if j.Within(alt) {
+			return alt, true
+		}
+	}
+	return q, false
+}
@@ -0,0 +1,179 @@
+package kinematics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// ErrNoSolution is returned when no joint configuration within the limits
+// reaches the target.
+var ErrNoSolution = errors.New("no inverse kinematics solution")
+
+// IKConfig tunes the numerical inverse kinematics.
+type IKConfig struct {
+	MaxIterations        int     // Per attempt
+	PositionTolerance    float64 // meters
+	OrientationTolerance float64 // radians
+	Damping              float64 // Damped least-squares λ near singularities
+	MaxStep              float64 // Largest joint change per iteration, radians
+	Restarts             int     // Random restarts after the seeded attempt
+	RandomSeed           int64
+
+	// PositionOnly ignores the target orientation, as for reach targets
+	// given as a point.
+	PositionOnly bool
+	// OrientationWeight trades orientation error against position error,
+	// meters per radian.
+	OrientationWeight float64
+}
+
+// DefaultIKConfig solves to 0.1 mm and 1 mrad.
+func DefaultIKConfig() IKConfig {
+	return IKConfig{
+		MaxIterations:        200,
+		PositionTolerance:    1e-4,
+		OrientationTolerance: 1e-3,
+		Damping:              0.05,
+		MaxStep:              0.25,
+		Restarts:             10,
+		RandomSeed:           1,
+		OrientationWeight:    0.3,
+	}
+}
+
+// Inverse returns the joint configuration reaching target closest to
+// seed. Universal Robots arms use the closed-form solution for full pose
+// targets; other chains, and position-only targets, are solved
+// numerically.
+func (c *Chain) Inverse(target Transform, seed []float64, cfg IKConfig) ([]float64, error) {
+	if len(seed) != len(c.Joints) {
+		return nil, fmt.Errorf("%s: seed has %d joints, want %d: %w", c.Name, len(seed), len(c.Joints), ErrJointCount)
+	}
+	if c.ur != nil && !cfg.PositionOnly {
+		solutions, err := c.InverseAnalytic(target)
+		if err == nil {
+			if q := closest(solutions, seed, c.Joints); q != nil {
+				return q, nil
+			}
+		}
+	}
+	return c.InverseNumerical(target, seed, cfg)
+}
+
+// InverseNumerical solves by damped least squares from the seed, then from
+// random configurations within the joint limits. Joints are clamped to
+// their limits at every step.
+func (c *Chain) InverseNumerical(target Transform, seed []float64, cfg IKConfig) ([]float64, error) {
+	if len(seed) != len(c.Joints) {
+		return nil, fmt.Errorf("%s: seed has %d joints, want %d: %w", c.Name, len(seed), len(c.Joints), ErrJointCount)
+	}
+	rng := rand.New(rand.NewSource(cfg.RandomSeed))
+	bestErr := math.Inf(1)
+	for attempt := 0; attempt <= cfg.Restarts; attempt++ {
+		q := make([]float64, len(seed))
+		for i, j := range c.Joints {
+			if attempt == 0 {
+				q[i] = j.Clamp(seed[i])
+			} else {
+				lo, hi := math.Max(j.Lower, -math.Pi), math.Min(j.Upper, math.Pi)
+				q[i] = lo + rng.Float64()*(hi-lo)
+			}
+		}
+		solved, residual := c.descend(q, target, cfg)
+		if solved {
+			return q, nil
+		}
+		bestErr = math.Min(bestErr, residual)
+	}
+	return nil, fmt.Errorf("%s: best position error %.4f m after %d attempts: %w", c.Name, bestErr, cfg.Restarts+1, ErrNoSolution)
+}
+
+// descend iterates q towards the target in place, returning whether it
+// converged and the final position error.
+func (c *Chain) descend(q []float64, target Transform, cfg IKConfig) (bool, float64) {
+	rows := 6
+	if cfg.PositionOnly {
+		rows = 3
+	}
+	weights := []float64{1, 1, 1, cfg.OrientationWeight, cfg.OrientationWeight, cfg.OrientationWeight}
+	e := make([]float64, rows)
+
+	best, stalled := math.Inf(1), 0
+	for iter := 0; iter < cfg.MaxIterations; iter++ {
+		pose, err := c.Forward(q)
+		if err != nil {
+			return false, math.Inf(1)
+		}
+		dp, dr := PoseError(pose, target)
+		posErr, rotErr := norm(dp), norm(dr)
+		if posErr < cfg.PositionTolerance && (cfg.PositionOnly || rotErr < cfg.OrientationTolerance) {
+			return true, posErr
+		}
+
+		// Give up on attempts stuck against a limit or in a local minimum
+		total := posErr
+		if !cfg.PositionOnly {
+			total += cfg.OrientationWeight * rotErr
+		}
+		if total < best*0.999 {
+			best, stalled = total, 0
+		} else if stalled++; stalled > 20 {
+			return false, posErr
+		}
+
+		jac, err := c.Jacobian(q)
+		if err != nil {
+			return false, posErr
+		}
+		copy(e, dp[:])
+		if !cfg.PositionOnly {
+			copy(e[3:], dr[:])
+		}
+		dq, err := jac.dampedSolve(e, weights[:rows], cfg.Damping)
+		if err != nil {
+			return false, posErr
+		}
+
+		var largest float64
+		for _, d := range dq {
+			largest = math.Max(largest, math.Abs(d))
+		}
+		step := 1.0
+		if cfg.MaxStep > 0 && largest > cfg.MaxStep {
+			step = cfg.MaxStep / largest
+		}
+		for i, j := range c.Joints {
+			q[i] = j.Clamp(q[i] + step*dq[i])
+		}
+	}
+	pose, _ := c.Forward(q)
+	dp, _ := PoseError(pose, target)
+	return false, norm(dp)
+}
+
+// closest returns the solution with the smallest joint motion from seed,
+// taking each joint the short way round where its limits allow.
+func closest(solutions [][]float64, seed []float64, joints []Joint) []float64 {
+	var best []float64
+	bestCost := math.Inf(1)
+	for _, s := range solutions {
+		q := make([]float64, len(s))
+		var cost float64
+		feasible := true
+		for i, j := range joints {
+			var ok bool
+			if q[i], ok = normalizeInto(j, s[i], seed[i]); !ok {
+				feasible = false
+				break
+			}
+			d := q[i] - seed[i]
+			cost += d * d
+		}
+		if feasible && cost < bestCost {
+			best, bestCost = q, cost
+		}
+	}
+	return best
+}
@@ -0,0 +1,216 @@
+package kinematics
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingular is returned when a linear system has no stable solution.
+var ErrSingular = errors.New("singular configuration")
+
+// Jacobian is the 6×n geometric Jacobian in world axes at the tool centre
+// point: rows 0–2 map joint rates to linear velocity, rows 3–5 to angular
+// velocity.
+type Jacobian [6][]float64
+
+// Jacobian returns the geometric Jacobian at q.
+func (c *Chain) Jacobian(q []float64) (Jacobian, error) {
+	frames, err := c.JointFrames(q)
+	if err != nil {
+		return Jacobian{}, err
+	}
+	tcp := frames[len(frames)-1].Mul(c.Tool).P
+	var jac Jacobian
+	for r := range jac {
+		jac[r] = make([]float64, len(q))
+	}
+	for i, f := range frames {
+		axis := f.Rotate(c.Joints[i].Axis)
+		linear := cross(axis, sub(tcp, f.P))
+		for k := 0; k < 3; k++ {
+			jac[k][i] = linear[k]
+			jac[k+3][i] = axis[k]
+		}
+	}
+	return jac, nil
+}
+
+// Condition describes how close a configuration is to a singularity.
+type Condition struct {
+	MinSingularValue float64 // Smallest singular value of the Jacobian
+	MaxSingularValue float64
+	Manipulability   float64 // Yoshikawa's measure, √det(J·Jᵀ)
+	ConditionNumber  float64 // Ratio of the largest to smallest singular value
+}
+
+// Singular reports whether the smallest singular value is below the
+// threshold.
+func (c Condition) Singular(threshold float64) bool {
+	return c.MinSingularValue < threshold
+}
+
+// Condition returns the singularity measures of the full Jacobian at q.
+func (c *Chain) Condition(q []float64) (Condition, error) {
+	jac, err := c.Jacobian(q)
+	if err != nil {
+		return Condition{}, err
+	}
+	return jac.condition(6), nil
+}
+
+// PositionCondition returns the singularity measures of the linear
+// velocity rows only, for tasks that do not constrain orientation.
+func (c *Chain) PositionCondition(q []float64) (Condition, error) {
+	jac, err := c.Jacobian(q)
+	if err != nil {
+		return Condition{}, err
+	}
+	return jac.condition(3), nil
+}
+
+func (jac Jacobian) condition(rows int) Condition {
+	// Singular values are the square roots of the eigenvalues of J·Jᵀ
+	jjt := make([][]float64, rows)
+	for i := 0; i < rows; i++ {
+		jjt[i] = make([]float64, rows)
+		for j := 0; j < rows; j++ {
+			for k := range jac[i] {
+				jjt[i][j] += jac[i][k] * jac[j][k]
+			}
+		}
+	}
+	eig := symmetricEigenvalues(jjt)
+	if len(jac[0]) < rows {
+		// A redundant direction is always missing
+		eig[0] = 0
+	}
+	cond := Condition{Manipulability: 1}
+	for _, e := range eig {
+		cond.Manipulability *= math.Max(e, 0)
+	}
+	cond.Manipulability = math.Sqrt(cond.Manipulability)
+	cond.MinSingularValue = math.Sqrt(math.Max(eig[0], 0))
+	cond.MaxSingularValue = math.Sqrt(math.Max(eig[len(eig)-1], 0))
+	cond.ConditionNumber = math.Inf(1)
+	if cond.MinSingularValue > 0 {
+		cond.ConditionNumber = cond.MaxSingularValue / cond.MinSingularValue
+	}
+	return cond
+}
+
+// dampedSolve returns the damped least-squares joint step
+// Jᵀ(J·Jᵀ + λ²I)⁻¹·e for the first rows of the Jacobian, with each row
+// scaled by its weight.
+func (jac Jacobian) dampedSolve(e []float64, weights []float64, lambda float64) ([]float64, error) {
+	rows, n := len(e), len(jac[0])
+	a := make([][]float64, rows)
+	b := make([]float64, rows)
+	for i := 0; i < rows; i++ {
+		a[i] = make([]float64, rows)
+		for j := 0; j < rows; j++ {
+			for k := 0; k < n; k++ {
+				a[i][j] += weights[i] * jac[i][k] * weights[j] * jac[j][k]
+			}
+		}
+		a[i][i] += lambda * lambda
+		b[i] = weights[i] * e[i]
+	}
+	y, err := solve(a, b)
+	if err != nil {
+		return nil, err
+	}
+	dq := make([]float64, n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < rows; i++ {
+			dq[k] += weights[i] * jac[i][k] * y[i]
+		}
+	}
+	return dq, nil
+}
+
+// solve solves a·x = b by Gaussian elimination with partial pivoting. a
+// and b are overwritten.
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-14 {
+			return nil, ErrSingular
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[r][k] -= f * a[col][k]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := b[r]
+		for k := r + 1; k < n; k++ {
+			s -= a[r][k] * x[k]
+		}
+		x[r] = s / a[r][r]
+	}
+	return x, nil
+}
+
+// symmetricEigenvalues returns the eigenvalues of a symmetric matrix in
+// ascending order, by cyclic Jacobi rotations.
+func symmetricEigenvalues(m [][]float64) []float64 {
+	n := len(m)
+	a := make([][]float64, n)
+	for i := range m {
+		a[i] = append([]float64(nil), m[i]...)
+	}
+	for sweep := 0; sweep < 50; sweep++ {
+		var off float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += a[i][j] * a[i][j]
+			}
+		}
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(a[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+			}
+		}
+	}
+	eig := make([]float64, n)
+	for i := range eig {
+		eig[i] = a[i][i]
+	}
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && eig[j] < eig[j-1]; j-- {
+			eig[j], eig[j-1] = eig[j-1], eig[j]
+		}
+	}
+	return eig
+}
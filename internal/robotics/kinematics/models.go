@@ -0,0 +1,121 @@
+package kinematics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrUnknownModel is returned by Model for arms without a built-in chain.
+var ErrUnknownModel = errors.New("unknown arm model")
+
+// Model returns the kinematic chain of a supported arm, by the names used
+// in manipulator configuration: ur3e, ur5e, ur10e, kinova-gen3 and franka.
+// Each call returns a new chain, so callers may set its Base and Tool.
+func Model(name string) (*Chain, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "ur3e":
+		return UR3e(), nil
+	case "ur5e", "ur5":
+		return UR5e(), nil
+	case "ur10e":
+		return UR10e(), nil
+	case "kinova-gen3", "gen3", "kinova":
+		return KinovaGen3(), nil
+	case "franka", "franka-panda", "panda":
+		return FrankaPanda(), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownModel, name)
+}
+
+// urSpecs returns UR e-series limits: ±360° on every joint.
+func urSpecs(velocities [6]float64) []JointSpec {
+	names := []string{"shoulder_pan", "shoulder_lift", "elbow", "wrist_1", "wrist_2", "wrist_3"}
+	specs := make([]JointSpec, 6)
+	for i := range specs {
+		specs[i] = JointSpec{Name: names[i], Lower: -2 * math.Pi, Upper: 2 * math.Pi, MaxVelocity: velocities[i]}
+	}
+	return specs
+}
+
+func mustChain(c *Chain, err error) *Chain {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// UR3e returns the Universal Robots UR3e, flange as tool.
+func UR3e() *Chain {
+	v := [6]float64{math.Pi, math.Pi, math.Pi, 2 * math.Pi, 2 * math.Pi, 2 * math.Pi}
+	return mustChain(NewURChain("ur3e", 0.15185, -0.24355, -0.2132, 0.13105, 0.08535, 0.0921, urSpecs(v)))
+}
+
+// UR5e returns the Universal Robots UR5e, flange as tool.
+func UR5e() *Chain {
+	v := [6]float64{math.Pi, math.Pi, math.Pi, math.Pi, math.Pi, math.Pi}
+	return mustChain(NewURChain("ur5e", 0.1625, -0.425, -0.3922, 0.1333, 0.0997, 0.0996, urSpecs(v)))
+}
+
+// UR10e returns the Universal Robots UR10e, flange as tool.
+func UR10e() *Chain {
+	v := [6]float64{2 * math.Pi / 3, 2 * math.Pi / 3, math.Pi, math.Pi, math.Pi, math.Pi}
+	return mustChain(NewURChain("ur10e", 0.1807, -0.6127, -0.57155, 0.17415, 0.11985, 0.11655, urSpecs(v)))
+}
+
+// KinovaGen3 returns the 7-DOF Kinova Gen3 from Kinova's classic DH
+// table, with the tool at the interface module. At zero the arm stands
+// straight up.
+func KinovaGen3() *Chain {
+	deg := math.Pi / 180
+	large, small := 79.64*deg, 69.91*deg
+	table := []DH{
+		{Alpha: math.Pi / 2, D: -(0.1564 + 0.1284)},
+		{Alpha: math.Pi / 2, D: -(0.0054 + 0.0064), Offset: math.Pi},
+		{Alpha: math.Pi / 2, D: -(0.2104 + 0.2104), Offset: math.Pi},
+		{Alpha: math.Pi / 2, D: -(0.0064 + 0.0064), Offset: math.Pi},
+		{Alpha: math.Pi / 2, D: -(0.2084 + 0.1059), Offset: math.Pi},
+		{Alpha: math.Pi / 2, Offset: math.Pi},
+		{Alpha: math.Pi, D: -(0.1059 + 0.0615), Offset: math.Pi},
+	}
+	inf := math.Inf(1)
+	specs := []JointSpec{
+		{Name: "joint_1", Lower: -inf, Upper: inf, MaxVelocity: large},
+		{Name: "joint_2", Lower: -128.9 * deg, Upper: 128.9 * deg, MaxVelocity: large},
+		{Name: "joint_3", Lower: -inf, Upper: inf, MaxVelocity: large},
+		{Name: "joint_4", Lower: -147.8 * deg, Upper: 147.8 * deg, MaxVelocity: large},
+		{Name: "joint_5", Lower: -inf, Upper: inf, MaxVelocity: small},
+		{Name: "joint_6", Lower: -120.3 * deg, Upper: 120.3 * deg, MaxVelocity: small},
+		{Name: "joint_7", Lower: -inf, Upper: inf, MaxVelocity: small},
+	}
+	c := mustChain(NewDHChain("kinova-gen3", table, specs))
+	c.Base = RotX(math.Pi) // Kinova's frame 0 points down
+	return c
+}
+
+// FrankaPanda returns the 7-DOF Franka Emika Panda from Franka's modified
+// DH table, with the tool at the flange.
+func FrankaPanda() *Chain {
+	table := []DH{
+		{D: 0.333},
+		{Alpha: -math.Pi / 2},
+		{Alpha: math.Pi / 2, D: 0.316},
+		{Alpha: math.Pi / 2, A: 0.0825},
+		{Alpha: -math.Pi / 2, A: -0.0825, D: 0.384},
+		{Alpha: math.Pi / 2},
+		{Alpha: math.Pi / 2, A: 0.088},
+	}
+	specs := []JointSpec{
+		{Name: "panda_joint1", Lower: -2.8973, Upper: 2.8973, MaxVelocity: 2.175},
+		{Name: "panda_joint2", Lower: -1.7628, Upper: 1.7628, MaxVelocity: 2.175},
+		{Name: "panda_joint3", Lower: -2.8973, Upper: 2.8973, MaxVelocity: 2.175},
+		{Name: "panda_joint4", Lower: -3.0718, Upper: -0.0698, MaxVelocity: 2.175},
+		{Name: "panda_joint5", Lower: -2.8973, Upper: 2.8973, MaxVelocity: 2.61},
+		{Name: "panda_joint6", Lower: -0.0175, Upper: 3.7525, MaxVelocity: 2.61},
+		{Name: "panda_joint7", Lower: -2.8973, Upper: 2.8973, MaxVelocity: 2.61},
+	}
+	c := mustChain(NewModifiedDHChain("franka", table, specs))
+	c.Tool = Translation(0, 0, 0.107)
+	return c
+}
@@ -0,0 +1,215 @@
+// Package kinematics provides forward and inverse kinematics for the
+// serial arms Hunoid manipulators are built on. Chains are described by
+// Denavit–Hartenberg tables or URDF joints; inverse kinematics is solved
+// analytically for Universal Robots arms and by damped least squares for
+// any chain, and Jacobians drive Cartesian velocity control.
+//
+// Lengths are in meters and angles in radians throughout.
+package kinematics
+
+import "math"
+
+// Transform is a rigid transform: a rotation followed by a translation.
+type Transform struct {
+	R [3][3]float64
+	P [3]float64
+}
+
+// Identity returns the identity transform.
+func Identity() Transform {
+	return Transform{R: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}}
+}
+
+// Translation returns a pure translation.
+func Translation(x, y, z float64) Transform {
+	t := Identity()
+	t.P = [3]float64{x, y, z}
+	return t
+}
+
+// RotX returns a rotation about the x axis.
+func RotX(a float64) Transform {
+	s, c := math.Sincos(a)
+	return Transform{R: [3][3]float64{{1, 0, 0}, {0, c, -s}, {0, s, c}}}
+}
+
+// RotY returns a rotation about the y axis.
+func RotY(a float64) Transform {
+	s, c := math.Sincos(a)
+	return Transform{R: [3][3]float64{{c, 0, s}, {0, 1, 0}, {-s, 0, c}}}
+}
+
+// RotZ returns a rotation about the z axis.
+func RotZ(a float64) Transform {
+	s, c := math.Sincos(a)
+	return Transform{R: [3][3]float64{{c, -s, 0}, {s, c, 0}, {0, 0, 1}}}
+}
+
+// AxisAngle returns a rotation of angle about a unit axis.
+func AxisAngle(axis [3]float64, angle float64) Transform {
+	x, y, z := axis[0], axis[1], axis[2]
+	s, c := math.Sincos(angle)
+	v := 1 - c
+	return Transform{R: [3][3]float64{
+		{c + x*x*v, x*y*v - z*s, x*z*v + y*s},
+		{y*x*v + z*s, c + y*y*v, y*z*v - x*s},
+		{z*x*v - y*s, z*y*v + x*s, c + z*z*v},
+	}}
+}
+
+// FromRPY returns the URDF fixed-axis roll, pitch, yaw rotation.
+func FromRPY(roll, pitch, yaw float64) Transform {
+	return RotZ(yaw).Mul(RotY(pitch)).Mul(RotX(roll))
+}
+
+// FromQuaternion returns the rotation of a unit quaternion.
+func FromQuaternion(w, x, y, z float64) Transform {
+	n := math.Sqrt(w*w + x*x + y*y + z*z)
+	if n == 0 {
+		return Identity()
+	}
+	w, x, y, z = w/n, x/n, y/n, z/n
+	return Transform{R: [3][3]float64{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}}
+}
+
+// Quaternion returns the rotation as a unit quaternion with w >= 0.
+func (t Transform) Quaternion() (w, x, y, z float64) {
+	r := t.R
+	tr := r[0][0] + r[1][1] + r[2][2]
+	switch {
+	case tr > 0:
+		s := math.Sqrt(tr+1) * 2
+		w, x, y, z = s/4, (r[2][1]-r[1][2])/s, (r[0][2]-r[2][0])/s, (r[1][0]-r[0][1])/s
+	case r[0][0] > r[1][1] && r[0][0] > r[2][2]:
+		s := math.Sqrt(1+r[0][0]-r[1][1]-r[2][2]) * 2
+		w, x, y, z = (r[2][1]-r[1][2])/s, s/4, (r[0][1]+r[1][0])/s, (r[0][2]+r[2][0])/s
+	case r[1][1] > r[2][2]:
+		s := math.Sqrt(1+r[1][1]-r[0][0]-r[2][2]) * 2
+		w, x, y, z = (r[0][2]-r[2][0])/s, (r[0][1]+r[1][0])/s, s/4, (r[1][2]+r[2][1])/s
+	default:
+		s := math.Sqrt(1+r[2][2]-r[0][0]-r[1][1]) * 2
+		w, x, y, z = (r[1][0]-r[0][1])/s, (r[0][2]+r[2][0])/s, (r[1][2]+r[2][1])/s, s/4
+	}
+	if w < 0 {
+		w, x, y, z = -w, -x, -y, -z
+	}
+	return w, x, y, z
+}
+
+// Mul returns the composition t·u, applying u first.
+func (t Transform) Mul(u Transform) Transform {
+	var out Transform
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			out.R[i][j] = t.R[i][0]*u.R[0][j] + t.R[i][1]*u.R[1][j] + t.R[i][2]*u.R[2][j]
+		}
+	}
+	out.P = t.Apply(u.P)
+	return out
+}
+
+// Apply transforms a point.
+func (t Transform) Apply(p [3]float64) [3]float64 {
+	v := t.Rotate(p)
+	return [3]float64{v[0] + t.P[0], v[1] + t.P[1], v[2] + t.P[2]}
+}
+
+// Rotate rotates a vector, ignoring the translation.
+func (t Transform) Rotate(v [3]float64) [3]float64 {
+	return [3]float64{
+		t.R[0][0]*v[0] + t.R[0][1]*v[1] + t.R[0][2]*v[2],
+		t.R[1][0]*v[0] + t.R[1][1]*v[1] + t.R[1][2]*v[2],
+		t.R[2][0]*v[0] + t.R[2][1]*v[1] + t.R[2][2]*v[2],
+	}
+}
+
+// Inverse returns the inverse transform.
+func (t Transform) Inverse() Transform {
+	var out Transform
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			out.R[i][j] = t.R[j][i]
+		}
+	}
+	p := out.Rotate(t.P)
+	out.P = [3]float64{-p[0], -p[1], -p[2]}
+	return out
+}
+
+// PoseError returns the twist-like error from the current pose to a
+// target in base axes: the translation and the rotation vector of
+// target·currentᵀ.
+func PoseError(current, target Transform) (position, rotation [3]float64) {
+	position = sub(target.P, current.P)
+	var re [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			re[i][j] = target.R[i][0]*current.R[j][0] + target.R[i][1]*current.R[j][1] + target.R[i][2]*current.R[j][2]
+		}
+	}
+	return position, rotationVector(re)
+}
+
+// rotationVector returns the axis-angle vector of a rotation matrix.
+func rotationVector(r [3][3]float64) [3]float64 {
+	c := math.Max(-1, math.Min(1, (r[0][0]+r[1][1]+r[2][2]-1)/2))
+	angle := math.Acos(c)
+	v := [3]float64{r[2][1] - r[1][2], r[0][2] - r[2][0], r[1][0] - r[0][1]}
+	if angle < 1e-9 {
+		return [3]float64{v[0] / 2, v[1] / 2, v[2] / 2}
+	}
+	if math.Pi-angle < 1e-6 {
+		// Near a half turn the antisymmetric part vanishes; take the axis
+		// from the diagonal.
+		axis := [3]float64{
+			math.Sqrt(math.Max(0, (r[0][0]+1)/2)),
+			math.Sqrt(math.Max(0, (r[1][1]+1)/2)),
+			math.Sqrt(math.Max(0, (r[2][2]+1)/2)),
+		}
+		switch {
+		case axis[0] >= axis[1] && axis[0] >= axis[2]:
+			axis[1] = math.Copysign(axis[1], r[0][1])
+			axis[2] = math.Copysign(axis[2], r[0][2])
+		case axis[1] >= axis[2]:
+			axis[0] = math.Copysign(axis[0], r[0][1])
+			axis[2] = math.Copysign(axis[2], r[1][2])
+		default:
+			axis[0] = math.Copysign(axis[0], r[0][2])
+			axis[1] = math.Copysign(axis[1], r[1][2])
+		}
+		return scale(unit(axis), angle)
+	}
+	return scale(v, angle/(2*math.Sin(angle)))
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func scale(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func norm(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func unit(a [3]float64) [3]float64 {
+	n := norm(a)
+	if n == 0 {
+		return a
+	}
+	return scale(a, 1/n)
+}
@@ -0,0 +1,116 @@
+package kinematics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoAnalyticSolver is returned by InverseAnalytic for chains without a
+// closed-form solution.
+var ErrNoAnalyticSolver = errors.New("no analytic inverse kinematics for this chain")
+
+// urParams is the DH table of a Universal Robots arm. All UR arms share
+// the twists (π/2, 0, 0, π/2, -π/2, 0), which makes the wrist spherical
+// enough for a closed-form inverse.
+type urParams struct {
+	table []DH
+	last  Transform // Link transform of the last DH row, up to the flange
+}
+
+// NewURChain builds a Universal Robots arm from the d and a values of its
+// DH table, with the closed-form inverse enabled.
+func NewURChain(name string, d1, a2, a3, d4, d5, d6 float64, specs []JointSpec) (*Chain, error) {
+	table := []DH{
+		{D: d1, Alpha: math.Pi / 2},
+		{A: a2},
+		{A: a3},
+		{D: d4, Alpha: math.Pi / 2},
+		{D: d5, Alpha: -math.Pi / 2},
+		{D: d6},
+	}
+	c, err := NewDHChain(name, table, specs)
+	if err != nil {
+		return nil, err
+	}
+	c.ur = &urParams{table: table, last: c.Tool}
+	return c, nil
+}
+
+func dhTransform(row DH, theta float64) Transform {
+	return RotZ(theta + row.Offset).Mul(Translation(row.A, 0, row.D)).Mul(RotX(row.Alpha))
+}
+
+// InverseAnalytic returns every joint configuration within the limits
+// reaching target, up to eight for a UR arm: shoulder left or right,
+// elbow up or down, wrist flipped or not. At a wrist singularity, where
+// joints 4 and 6 align, joint 6 is set to zero.
+func (c *Chain) InverseAnalytic(target Transform) ([][]float64, error) {
+	if c.ur == nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, ErrNoAnalyticSolver)
+	}
+	dh := c.ur.table
+	a2, a3, d4, d6 := dh[1].A, dh[2].A, dh[3].D, dh[5].D
+
+	// Flange pose in the DH base frame
+	t06 := c.Base.Inverse().Mul(target).Mul(c.Tool.Inverse()).Mul(c.ur.last)
+
+	var raw [][]float64
+	p05 := t06.Apply([3]float64{0, 0, -d6})
+	r := math.Hypot(p05[0], p05[1])
+	if r < math.Abs(d4) {
+		return nil, fmt.Errorf("%s: target inside the shoulder cylinder: %w", c.Name, ErrNoSolution)
+	}
+	psi := math.Atan2(p05[1], p05[0])
+	phi := math.Acos(d4 / r)
+	for _, q1 := range []float64{psi + phi + math.Pi/2, psi - phi + math.Pi/2} {
+		s1, c1 := math.Sincos(q1)
+		c5 := (t06.P[0]*s1 - t06.P[1]*c1 - d4) / d6
+		if math.Abs(c5) > 1+1e-9 {
+			continue
+		}
+		c5 = math.Max(-1, math.Min(1, c5))
+		for _, q5 := range []float64{math.Acos(c5), -math.Acos(c5)} {
+			s5 := math.Sin(q5)
+			q6 := 0.0
+			if math.Abs(s5) > 1e-9 {
+				q6 = math.Atan2((-t06.R[0][1]*s1+t06.R[1][1]*c1)/s5, (t06.R[0][0]*s1-t06.R[1][0]*c1)/s5)
+			}
+
+			t14 := dhTransform(dh[0], q1).Inverse().Mul(t06).
+				Mul(dhTransform(dh[5], q6).Inverse()).Mul(dhTransform(dh[4], q5).Inverse())
+			// Joints 2 and 3 form a planar two-link arm in the x-y plane
+			// of frame 1
+			px, py := t14.P[0], t14.P[1]
+			c3 := (px*px + py*py - a2*a2 - a3*a3) / (2 * a2 * a3)
+			if math.Abs(c3) > 1+1e-9 {
+				continue
+			}
+			c3 = math.Max(-1, math.Min(1, c3))
+			for _, q3 := range []float64{math.Acos(c3), -math.Acos(c3)} {
+				s3 := math.Sin(q3)
+				q2 := math.Atan2(py, px) - math.Atan2(a3*s3, a2+a3*c3)
+				t34 := dhTransform(dh[2], q3).Inverse().Mul(dhTransform(dh[1], q2).Inverse()).Mul(t14)
+				q4 := math.Atan2(t34.R[1][0], t34.R[0][0])
+				raw = append(raw, []float64{q1, q2, q3, q4, q5, q6})
+			}
+		}
+	}
+
+	var solutions [][]float64
+	for _, s := range raw {
+		ok := true
+		for i, j := range c.Joints {
+			if s[i], ok = normalizeInto(j, s[i], 0); !ok {
+				break
+			}
+		}
+		if ok {
+			solutions = append(solutions, s)
+		}
+	}
+	if len(solutions) == 0 {
+		return nil, fmt.Errorf("%s: %w", c.Name, ErrNoSolution)
+	}
+	return solutions, nil
+}
@@ -0,0 +1,143 @@
+package kinematics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type urdfRobot struct {
+	Name   string      `xml:"name,attr"`
+	Joints []urdfJoint `xml:"joint"`
+}
+
+type urdfJoint struct {
+	Name   string `xml:"name,attr"`
+	Type   string `xml:"type,attr"`
+	Parent struct {
+		Link string `xml:"link,attr"`
+	} `xml:"parent"`
+	Child struct {
+		Link string `xml:"link,attr"`
+	} `xml:"child"`
+	Origin *struct {
+		XYZ string `xml:"xyz,attr"`
+		RPY string `xml:"rpy,attr"`
+	} `xml:"origin"`
+	Axis *struct {
+		XYZ string `xml:"xyz,attr"`
+	} `xml:"axis"`
+	Limit *struct {
+		Lower    *float64 `xml:"lower,attr"`
+		Upper    *float64 `xml:"upper,attr"`
+		Velocity float64  `xml:"velocity,attr"`
+	} `xml:"limit"`
+}
+
+// ParseURDF reads the chain between two links of a URDF robot
+// description. Fixed joints are folded into the neighbouring transforms;
+// revolute and continuous joints become chain joints.
+func ParseURDF(r io.Reader, baseLink, tipLink string) (*Chain, error) {
+	var robot urdfRobot
+	if err := xml.NewDecoder(r).Decode(&robot); err != nil {
+		return nil, fmt.Errorf("parse urdf: %w", err)
+	}
+	byChild := make(map[string]urdfJoint, len(robot.Joints))
+	for _, j := range robot.Joints {
+		byChild[j.Child.Link] = j
+	}
+
+	// Walk from the tip up to the base
+	var path []urdfJoint
+	for link := tipLink; link != baseLink; {
+		j, ok := byChild[link]
+		if !ok {
+			return nil, fmt.Errorf("urdf %s: no path from %s to %s", robot.Name, baseLink, tipLink)
+		}
+		path = append(path, j)
+		link = j.Parent.Link
+	}
+
+	c := &Chain{Name: robot.Name, Base: Identity(), Tool: Identity()}
+	pending := Identity()
+	for i := len(path) - 1; i >= 0; i-- {
+		uj := path[i]
+		origin, err := uj.origin()
+		if err != nil {
+			return nil, err
+		}
+		pending = pending.Mul(origin)
+		switch uj.Type {
+		case "fixed":
+			continue
+		case "revolute", "continuous":
+		default:
+			return nil, fmt.Errorf("urdf %s: joint %s has unsupported type %q", robot.Name, uj.Name, uj.Type)
+		}
+
+		joint := Joint{
+			Name:   uj.Name,
+			Origin: pending,
+			Axis:   [3]float64{1, 0, 0}, // URDF default
+			Lower:  math.Inf(-1),
+			Upper:  math.Inf(1),
+		}
+		if uj.Axis != nil {
+			axis, err := parseTriple(uj.Axis.XYZ)
+			if err != nil {
+				return nil, fmt.Errorf("urdf joint %s axis: %w", uj.Name, err)
+			}
+			joint.Axis = unit(axis)
+		}
+		if uj.Limit != nil {
+			if uj.Type == "revolute" && uj.Limit.Lower != nil && uj.Limit.Upper != nil {
+				joint.Lower, joint.Upper = *uj.Limit.Lower, *uj.Limit.Upper
+			}
+			joint.MaxVelocity = uj.Limit.Velocity
+		}
+		c.Joints = append(c.Joints, joint)
+		pending = Identity()
+	}
+	if len(c.Joints) == 0 {
+		return nil, fmt.Errorf("urdf %s: no movable joints between %s and %s", robot.Name, baseLink, tipLink)
+	}
+	c.Tool = pending
+	return c, nil
+}
+
+func (j urdfJoint) origin() (Transform, error) {
+	if j.Origin == nil {
+		return Identity(), nil
+	}
+	xyz, err := parseTriple(j.Origin.XYZ)
+	if err != nil {
+		return Transform{}, fmt.Errorf("urdf joint %s origin xyz: %w", j.Name, err)
+	}
+	rpy, err := parseTriple(j.Origin.RPY)
+	if err != nil {
+		return Transform{}, fmt.Errorf("urdf joint %s origin rpy: %w", j.Name, err)
+	}
+	return Translation(xyz[0], xyz[1], xyz[2]).Mul(FromRPY(rpy[0], rpy[1], rpy[2])), nil
+}
+
+func parseTriple(s string) ([3]float64, error) {
+	var out [3]float64
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return out, nil
+	}
+	if len(fields) != 3 {
+		return out, fmt.Errorf("want three values, got %q", s)
+	}
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return out, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package kinematics
+
+import "math"
+
+// Twist is a Cartesian velocity of the tool centre point in world axes.
+type Twist struct {
+	Linear  [3]float64 // m/s
+	Angular [3]float64 // rad/s
+}
+
+// VelocityConfig tunes Cartesian velocity resolution.
+type VelocityConfig struct {
+	// SingularityThreshold is the smallest singular value below which
+	// damping ramps in and commands are flagged as near a singularity.
+	SingularityThreshold float64
+	// MaxDamping is the damped least-squares λ applied at the singularity
+	// itself.
+	MaxDamping   float64
+	PositionOnly bool // Track the linear velocity only
+}
+
+// DefaultVelocityConfig damps within 2 cm/rad of a singularity.
+func DefaultVelocityConfig() VelocityConfig {
+	return VelocityConfig{
+		SingularityThreshold: 0.02,
+		MaxDamping:           0.05,
+	}
+}
+
+// VelocityCommand is a joint velocity command resolved from a twist.
+type VelocityCommand struct {
+	JointVelocities []float64 // rad/s
+	// Scale is below one when the command was slowed uniformly to respect
+	// the joint velocity limits; the direction of motion is kept.
+	Scale           float64
+	Condition       Condition
+	NearSingularity bool
+}
+
+// ResolveTwist maps a tool velocity to joint velocities through the
+// Jacobian. Near singularities the inverse is damped, trading tracking
+// accuracy for bounded joint rates, and the command is flagged so callers
+// can slow down or re-plan.
+func (c *Chain) ResolveTwist(q []float64, twist Twist, cfg VelocityConfig) (VelocityCommand, error) {
+	jac, err := c.Jacobian(q)
+	if err != nil {
+		return VelocityCommand{}, err
+	}
+	rows := 6
+	if cfg.PositionOnly {
+		rows = 3
+	}
+	cond := jac.condition(rows)
+
+	// Damping ramps from zero at the threshold to MaxDamping at the
+	// singularity.
+	var lambda float64
+	near := cond.Singular(cfg.SingularityThreshold)
+	if near && cfg.SingularityThreshold > 0 {
+		ratio := cond.MinSingularValue / cfg.SingularityThreshold
+		lambda = cfg.MaxDamping * math.Sqrt(1-ratio*ratio)
+	}
+
+	e := []float64{twist.Linear[0], twist.Linear[1], twist.Linear[2], twist.Angular[0], twist.Angular[1], twist.Angular[2]}
+	weights := []float64{1, 1, 1, 1, 1, 1}
+	qdot, err := jac.dampedSolve(e[:rows], weights[:rows], lambda)
+	if err != nil {
+		// Exactly singular and undamped; damp regardless
+		if qdot, err = jac.dampedSolve(e[:rows], weights[:rows], math.Max(cfg.MaxDamping, 1e-3)); err != nil {
+			return VelocityCommand{}, err
+		}
+		near = true
+	}
+
+	scale := 1.0
+	for i, j := range c.Joints {
+		if j.MaxVelocity > 0 && math.Abs(qdot[i])*scale > j.MaxVelocity {
+			scale = j.MaxVelocity / math.Abs(qdot[i])
+		}
+	}
+	for i := range qdot {
+		qdot[i] *= scale
+	}
+	return VelocityCommand{JointVelocities: qdot, Scale: scale, Condition: cond, NearSingularity: near}, nil
+}
+
+// ServoTwist returns the twist moving a tool from current towards target
+// at gain per second, capped at the given linear and angular speeds. Fed
+// through ResolveTwist every control cycle it servos the tool in a straight
+// line.
+func ServoTwist(current, target Transform, gain, maxLinear, maxAngular float64) Twist {
+	dp, dr := PoseError(current, target)
+	linear := scale(dp, gain)
+	if n := norm(linear); maxLinear > 0 && n > maxLinear {
+		linear = scale(linear, maxLinear/n)
+	}
+	angular := scale(dr, gain)
+	if n := norm(angular); maxAngular > 0 && n > maxAngular {
+		angular = scale(angular, maxAngular/n)
+	}
+	return Twist{Linear: linear, Angular: angular}
+}
@@ -0,0 +1,420 @@
+// Package modbus implements a Modbus TCP master: the bit and register
+// access function codes 1 to 6, 15, 16 and 23, with transaction IDs,
+// per-request timeouts and exception decoding.
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultPort is the Modbus TCP port.
+const DefaultPort = 502
+
+// Function codes.
+const (
+	FuncReadCoils                  byte = 0x01
+	FuncReadDiscreteInputs         byte = 0x02
+	FuncReadHoldingRegisters       byte = 0x03
+	FuncReadInputRegisters         byte = 0x04
+	FuncWriteSingleCoil            byte = 0x05
+	FuncWriteSingleRegister        byte = 0x06
+	FuncWriteMultipleCoils         byte = 0x0F
+	FuncWriteMultipleRegisters     byte = 0x10
+	FuncReadWriteMultipleRegisters byte = 0x17
+)
+
+// Quantity limits of the Modbus application protocol.
+const (
+	maxReadBits       = 2000
+	maxReadRegisters  = 125
+	maxWriteBits      = 1968
+	maxWriteRegisters = 123
+	maxRWWrite        = 121
+)
+
+// mbapSize is the Modbus application protocol header: transaction ID,
+// protocol ID, length and unit ID.
+const mbapSize = 7
+
+var (
+	// ErrQuantity is returned for requests outside the protocol limits.
+	ErrQuantity = errors.New("modbus: quantity out of range")
+	// ErrResponse is returned for responses that do not match the request.
+	ErrResponse = errors.New("modbus: invalid response")
+)
+
+// ExceptionCode is the code of a Modbus exception response.
+type ExceptionCode byte
+
+// Exception codes.
+const (
+	IllegalFunction                    ExceptionCode = 0x01
+	IllegalDataAddress                 ExceptionCode = 0x02
+	IllegalDataValue                   ExceptionCode = 0x03
+	ServerDeviceFailure                ExceptionCode = 0x04
+	Acknowledge                        ExceptionCode = 0x05
+	ServerDeviceBusy                   ExceptionCode = 0x06
+	MemoryParityError                  ExceptionCode = 0x08
+	GatewayPathUnavailable             ExceptionCode = 0x0A
+	GatewayTargetDeviceFailedToRespond ExceptionCode = 0x0B
+)
+
+func (c ExceptionCode) String() string {
+	switch c {
+	case IllegalFunction:
+		return "illegal function"
+	case IllegalDataAddress:
+		return "illegal data address"
+	case IllegalDataValue:
+		return "illegal data value"
+	case ServerDeviceFailure:
+		return "server device failure"
+	case Acknowledge:
+		return "acknowledge"
+	case ServerDeviceBusy:
+		return "server device busy"
+	case MemoryParityError:
+		return "memory parity error"
+	case GatewayPathUnavailable:
+		return "gateway path unavailable"
+	case GatewayTargetDeviceFailedToRespond:
+		return "gateway target device failed to respond"
+	}
+	return "exception 0x" + strconv.FormatUint(uint64(c), 16)
+}
+
+// Exception is an exception response from the server.
+type Exception struct {
+	Function byte
+	Code     ExceptionCode
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("modbus: function 0x%02x: %s", e.Function, e.Code)
+}
+
+// Config holds Modbus TCP client configuration.
+type Config struct {
+	Address     string
+	Port        int  // 502
+	UnitID      byte // Slave address behind a gateway; 0xFF or 0 for direct devices
+	Timeout     time.Duration
+	DialTimeout time.Duration
+}
+
+// DefaultConfig returns the configuration for a server at address.
+func DefaultConfig(address string) Config {
+	return Config{
+		Address:     address,
+		Port:        DefaultPort,
+		UnitID:      1,
+		Timeout:     time.Second,
+		DialTimeout: 5 * time.Second,
+	}
+}
+
+// Client is a Modbus TCP master. Requests are serialized on one
+// connection, which is re-established after I/O errors.
+type Client struct {
+	cfg  Config
+	mu   sync.Mutex
+	conn net.Conn
+	txID uint16
+}
+
+// Dial connects to a Modbus TCP server.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second
+	}
+	c := &Client{cfg: cfg}
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.cfg.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Address, strconv.Itoa(c.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("modbus dial: %w", err)
+	}
+	c.conn = conn
+	return nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// send performs one transaction and returns the response PDU data after
+// the function code.
+func (c *Client) send(ctx context.Context, function byte, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	c.txID++
+	txID := c.txID
+	frame := make([]byte, mbapSize+1, mbapSize+1+len(data))
+	binary.BigEndian.PutUint16(frame[0:2], txID)
+	binary.BigEndian.PutUint16(frame[2:4], 0) // Modbus protocol
+	binary.BigEndian.PutUint16(frame[4:6], uint16(2+len(data)))
+	frame[6] = c.cfg.UnitID
+	frame[7] = function
+	frame = append(frame, data...)
+
+	deadline := time.Now().Add(c.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+
+	resp, err := c.exchange(frame, txID)
+	if err != nil {
+		// The stream position is unknown after an error; start afresh
+		c.conn.Close()
+		c.conn = nil
+		return nil, fmt.Errorf("modbus function 0x%02x: %w", function, err)
+	}
+	if resp[0] == function|0x80 {
+		if len(resp) < 2 {
+			return nil, fmt.Errorf("%w: short exception", ErrResponse)
+		}
+		return nil, &Exception{Function: function, Code: ExceptionCode(resp[1])}
+	}
+	if resp[0] != function {
+		return nil, fmt.Errorf("%w: function 0x%02x in reply to 0x%02x", ErrResponse, resp[0], function)
+	}
+	return resp[1:], nil
+}
+
+// exchange writes a frame and reads the response PDU, skipping stale
+// responses to earlier, timed-out transactions.
+func (c *Client) exchange(frame []byte, txID uint16) ([]byte, error) {
+	if _, err := c.conn.Write(frame); err != nil {
+		return nil, err
+	}
+	for {
+		var header [mbapSize]byte
+		if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[4:6]))
+		if binary.BigEndian.Uint16(header[2:4]) != 0 || length < 2 || length > 254 {
+			return nil, fmt.Errorf("%w: bad header % x", ErrResponse, header)
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(c.conn, pdu); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(header[0:2]) != txID {
+			continue
+		}
+		if header[6] != c.cfg.UnitID {
+			return nil, fmt.Errorf("%w: unit %d in reply to unit %d", ErrResponse, header[6], c.cfg.UnitID)
+		}
+		return pdu, nil
+	}
+}
+
+func addressQuantity(address, quantity uint16) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b[0:2], address)
+	binary.BigEndian.PutUint16(b[2:4], quantity)
+	return b
+}
+
+func checkQuantity(quantity, limit int) error {
+	if quantity < 1 || quantity > limit {
+		return fmt.Errorf("%w: %d, limit %d", ErrQuantity, quantity, limit)
+	}
+	return nil
+}
+
+func (c *Client) readBits(ctx context.Context, function byte, address, quantity uint16) ([]bool, error) {
+	if err := checkQuantity(int(quantity), maxReadBits); err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, function, addressQuantity(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	n := (int(quantity) + 7) / 8
+	if len(resp) != 1+n || int(resp[0]) != n {
+		return nil, fmt.Errorf("%w: %d byte bit response for %d bits", ErrResponse, len(resp), quantity)
+	}
+	bits := make([]bool, quantity)
+	for i := range bits {
+		bits[i] = resp[1+i/8]&(1<<(i%8)) != 0
+	}
+	return bits, nil
+}
+
+func (c *Client) readRegisters(ctx context.Context, function byte, address, quantity uint16) ([]uint16, error) {
+	if err := checkQuantity(int(quantity), maxReadRegisters); err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, function, addressQuantity(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	return decodeRegisters(resp, quantity)
+}
+
+func decodeRegisters(resp []byte, quantity uint16) ([]uint16, error) {
+	if len(resp) != 1+2*int(quantity) || int(resp[0]) != 2*int(quantity) {
+		return nil, fmt.Errorf("%w: %d byte register response for %d registers", ErrResponse, len(resp), quantity)
+	}
+	regs := make([]uint16, quantity)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(resp[1+2*i:])
+	}
+	return regs, nil
+}
+
+func appendRegisters(b []byte, values []uint16) []byte {
+	b = append(b, byte(2*len(values)))
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	return b
+}
+
+// ReadCoils reads quantity coils from address (function 1).
+func (c *Client) ReadCoils(ctx context.Context, address, quantity uint16) ([]bool, error) {
+	return c.readBits(ctx, FuncReadCoils, address, quantity)
+}
+
+// ReadDiscreteInputs reads quantity discrete inputs from address
+// (function 2).
+func (c *Client) ReadDiscreteInputs(ctx context.Context, address, quantity uint16) ([]bool, error) {
+	return c.readBits(ctx, FuncReadDiscreteInputs, address, quantity)
+}
+
+// ReadHoldingRegisters reads quantity holding registers from address
+// (function 3).
+func (c *Client) ReadHoldingRegisters(ctx context.Context, address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(ctx, FuncReadHoldingRegisters, address, quantity)
+}
+
+// ReadInputRegisters reads quantity input registers from address
+// (function 4).
+func (c *Client) ReadInputRegisters(ctx context.Context, address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(ctx, FuncReadInputRegisters, address, quantity)
+}
+
+// WriteSingleCoil sets one coil (function 5).
+func (c *Client) WriteSingleCoil(ctx context.Context, address uint16, value bool) error {
+	var v uint16
+	if value {
+		v = 0xFF00
+	}
+	req := addressQuantity(address, v)
+	resp, err := c.send(ctx, FuncWriteSingleCoil, req)
+	if err != nil {
+		return err
+	}
+	return checkEcho(resp, req)
+}
+
+// WriteSingleRegister writes one holding register (function 6).
+func (c *Client) WriteSingleRegister(ctx context.Context, address, value uint16) error {
+	req := addressQuantity(address, value)
+	resp, err := c.send(ctx, FuncWriteSingleRegister, req)
+	if err != nil {
+		return err
+	}
+	return checkEcho(resp, req)
+}
+
+// WriteMultipleCoils writes consecutive coils from address (function 15).
+func (c *Client) WriteMultipleCoils(ctx context.Context, address uint16, values []bool) error {
+	if err := checkQuantity(len(values), maxWriteBits); err != nil {
+		return err
+	}
+	req := addressQuantity(address, uint16(len(values)))
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	req = append(req, byte(len(packed)))
+	req = append(req, packed...)
+	resp, err := c.send(ctx, FuncWriteMultipleCoils, req)
+	if err != nil {
+		return err
+	}
+	return checkEcho(resp, req[:4])
+}
+
+// WriteMultipleRegisters writes consecutive holding registers from address
+// (function 16).
+func (c *Client) WriteMultipleRegisters(ctx context.Context, address uint16, values []uint16) error {
+	if err := checkQuantity(len(values), maxWriteRegisters); err != nil {
+		return err
+	}
+	req := appendRegisters(addressQuantity(address, uint16(len(values))), values)
+	resp, err := c.send(ctx, FuncWriteMultipleRegisters, req)
+	if err != nil {
+		return err
+	}
+	return checkEcho(resp, req[:4])
+}
+
+// ReadWriteMultipleRegisters writes values from writeAddress, then reads
+// readQuantity registers from readAddress, in one transaction
+// (function 23).
+func (c *Client) ReadWriteMultipleRegisters(ctx context.Context, readAddress, readQuantity, writeAddress uint16, values []uint16) ([]uint16, error) {
+	if err := checkQuantity(int(readQuantity), maxReadRegisters); err != nil {
+		return nil, err
+	}
+	if err := checkQuantity(len(values), maxRWWrite); err != nil {
+		return nil, err
+	}
+	req := addressQuantity(readAddress, readQuantity)
+	req = append(req, addressQuantity(writeAddress, uint16(len(values)))...)
+	req = appendRegisters(req, values)
+	resp, err := c.send(ctx, FuncReadWriteMultipleRegisters, req)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRegisters(resp, readQuantity)
+}
+
+func checkEcho(resp, want []byte) error {
+	if len(resp) != len(want) {
+		return fmt.Errorf("%w: %d byte write response", ErrResponse, len(resp))
+	}
+	for i := range want {
+		if resp[i] != want[i] {
+			return fmt.Errorf("%w: write echo % x, sent % x", ErrResponse, resp, want)
+		}
+	}
+	return nil
+}
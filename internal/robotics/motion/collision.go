@@ -0,0 +1,195 @@
+package motion
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+	"github.com/asgard/pandora/internal/robotics/perception"
+)
+
+// Box is an axis-aligned obstacle.
+type Box struct {
+	Name string     `json:"name"`
+	Min  [3]float64 `json:"min"`
+	Max  [3]float64 `json:"max"`
+}
+
+// distance returns the distance from p to the box, zero inside it.
+func (b Box) distance(p [3]float64) float64 {
+	var sum float64
+	for i := range p {
+		d := math.Max(b.Min[i]-p[i], math.Max(0, p[i]-b.Max[i]))
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func (b Box) intersects(c Box) bool {
+	for i := 0; i < 3; i++ {
+		if b.Min[i] > c.Max[i] || b.Max[i] < c.Min[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Environment is what the arm must not touch: fixed workspace boxes such
+// as tables, walls and the robot's own body, and the objects tracked in a
+// perception octree. Objects are taken at their bounding box, or as a
+// cube of ObjectRadius around their position when the box is empty.
+type Environment struct {
+	Boxes   []Box
+	Objects *perception.Octree
+
+	ObjectRadius float64 // meters, 0.05 when zero
+	Padding      float64 // Safety margin added around every obstacle, meters
+
+	// Ignore excludes tracked objects from collision checking, such as the
+	// object being grasped.
+	Ignore func(obj *perception.TrackedObject) bool
+}
+
+// snapshot returns the obstacles that may lie within radius of center.
+// The octree is read once, so a plan is checked against one consistent
+// view of the scene.
+func (e *Environment) snapshot(center [3]float64, radius float64) []Box {
+	if e == nil {
+		return nil
+	}
+	region := Box{
+		Min: [3]float64{center[0] - radius, center[1] - radius, center[2] - radius},
+		Max: [3]float64{center[0] + radius, center[1] + radius, center[2] + radius},
+	}
+	var obstacles []Box
+	for _, b := range e.Boxes {
+		b = b.padded(e.Padding)
+		if b.intersects(region) {
+			obstacles = append(obstacles, b)
+		}
+	}
+	if e.Objects == nil {
+		return obstacles
+	}
+	objectRadius := e.ObjectRadius
+	if objectRadius <= 0 {
+		objectRadius = 0.05
+	}
+	// Objects are indexed by position; widen the query so large objects
+	// centred outside the region are still found.
+	margin := radius + 1.0
+	found := e.Objects.QueryBox(
+		perception.Vector3{X: center[0] - margin, Y: center[1] - margin, Z: center[2] - margin},
+		perception.Vector3{X: center[0] + margin, Y: center[1] + margin, Z: center[2] + margin},
+	)
+	for _, obj := range found {
+		if e.Ignore != nil && e.Ignore(obj) {
+			continue
+		}
+		b := objectBox(obj, objectRadius).padded(e.Padding)
+		if b.intersects(region) {
+			obstacles = append(obstacles, b)
+		}
+	}
+	return obstacles
+}
+
+func (b Box) padded(margin float64) Box {
+	for i := 0; i < 3; i++ {
+		b.Min[i] -= margin
+		b.Max[i] += margin
+	}
+	return b
+}
+
+func objectBox(obj *perception.TrackedObject, radius float64) Box {
+	name := fmt.Sprintf("%s %s", obj.ClassType, obj.ID)
+	bb := obj.BoundingBox
+	if bb.Max.X > bb.Min.X && bb.Max.Y > bb.Min.Y && bb.Max.Z > bb.Min.Z {
+		return Box{
+			Name: name,
+			Min:  [3]float64{bb.Min.X, bb.Min.Y, bb.Min.Z},
+			Max:  [3]float64{bb.Max.X, bb.Max.Y, bb.Max.Z},
+		}
+	}
+	p := obj.Position
+	return Box{
+		Name: name,
+		Min:  [3]float64{p.X - radius, p.Y - radius, p.Z - radius},
+		Max:  [3]float64{p.X + radius, p.Y + radius, p.Z + radius},
+	}
+}
+
+// checker tests arm configurations against a fixed set of obstacles. The
+// arm is modelled as spheres swept along the segments joining successive
+// joint origins and the tool centre point, from the shoulder outwards:
+// the base link cannot move, so it is not checked.
+type checker struct {
+	chain      *kinematics.Chain
+	obstacles  []Box
+	radius     float64
+	resolution float64
+}
+
+// collision returns the first obstacle touched at q and the link touching
+// it.
+func (c *checker) collision(q []float64) (Box, int, bool) {
+	if len(c.obstacles) == 0 {
+		return Box{}, -1, false
+	}
+	frames, err := c.chain.JointFrames(q)
+	if err != nil {
+		return Box{}, -1, false
+	}
+	points := make([][3]float64, 0, len(frames))
+	for _, f := range frames[1:] {
+		points = append(points, f.P)
+	}
+	points = append(points, frames[len(frames)-1].Mul(c.chain.Tool).P)
+
+	for link := 0; link+1 < len(points); link++ {
+		a, b := points[link], points[link+1]
+		length := math.Sqrt(sq(b[0]-a[0]) + sq(b[1]-a[1]) + sq(b[2]-a[2]))
+		n := int(math.Ceil(length / c.radius))
+		for k := 0; k <= n; k++ {
+			t := 0.0
+			if n > 0 {
+				t = float64(k) / float64(n)
+			}
+			p := [3]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1]), a[2] + t*(b[2]-a[2])}
+			for _, o := range c.obstacles {
+				if o.distance(p) < c.radius {
+					return o, link, true
+				}
+			}
+		}
+	}
+	return Box{}, -1, false
+}
+
+func (c *checker) free(q []float64) bool {
+	_, _, hit := c.collision(q)
+	return !hit
+}
+
+// motionFree checks the straight joint-space motion from a to b, which is
+// assumed free at a, at the configured resolution.
+func (c *checker) motionFree(a, b []float64) bool {
+	if len(c.obstacles) == 0 {
+		return true
+	}
+	n := int(math.Ceil(distance(a, b) / c.resolution))
+	q := make([]float64, len(a))
+	for k := 1; k <= n; k++ {
+		t := float64(k) / float64(n)
+		for i := range q {
+			q[i] = a[i] + t*(b[i]-a[i])
+		}
+		if !c.free(q) {
+			return false
+		}
+	}
+	return true
+}
+
+func sq(x float64) float64 { return x * x }
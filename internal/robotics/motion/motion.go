@@ -0,0 +1,189 @@
+// Package motion plans collision-free joint-space motions for Hunoid
+// manipulators. Paths are found with RRT-Connect or RRT*, checked by
+// sweeping spheres along the arm's links through the tracked objects of a
+// perception octree and static workspace boxes, shortened by random
+// shortcutting, and timed by a time-optimal parameterization under joint
+// velocity and acceleration limits.
+//
+// Joint positions are in radians; obstacle and link geometry in meters,
+// in the frame the chain's Base transform maps into.
+package motion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Algorithm selects the path planner.
+type Algorithm string
+
+const (
+	// RRTConnect grows trees from the start and the goal towards each
+	// other and returns the first path found. It is fast but the path is
+	// not optimized beyond shortcutting.
+	RRTConnect Algorithm = "rrt-connect"
+	// RRTStar grows one tree from the start, rewiring it towards shorter
+	// paths, and refines the best path until the iteration or time budget
+	// runs out.
+	RRTStar Algorithm = "rrt-star"
+)
+
+// Config tunes planning and trajectory timing.
+type Config struct {
+	Algorithm          Algorithm
+	MaxIterations      int           // Tree extensions before giving up
+	Timeout            time.Duration // Planning time budget
+	StepSize           float64       // Longest tree edge, radians
+	GoalBias           float64       // RRT*: probability of sampling the goal
+	RewireRadius       float64       // RRT*: neighbourhood for parent choice and rewiring, radians
+	Resolution         float64       // Largest joint step between collision checks along a motion, radians
+	ShortcutIterations int           // Random shortcut attempts on the found path
+	GoalCandidates     int           // Inverse kinematics solutions tried for pose targets
+	RandomSeed         int64
+
+	// LinkRadius is the radius of the spheres swept along each link. It
+	// should cover the thickest part of the arm and gripper.
+	LinkRadius float64 // meters
+
+	MaxJointVelocity     float64 // rad/s, applied on top of the chain's joint limits; 0 uses the chain's
+	MaxJointAcceleration float64 // rad/s²
+	BlendDeviation       float64 // Largest corner cut when blending waypoints, radians
+}
+
+// DefaultConfig plans with RRT-Connect within one second.
+func DefaultConfig() Config {
+	return Config{
+		Algorithm:            RRTConnect,
+		MaxIterations:        5000,
+		Timeout:              time.Second,
+		StepSize:             0.3,
+		GoalBias:             0.05,
+		RewireRadius:         0.8,
+		Resolution:           0.05,
+		ShortcutIterations:   100,
+		GoalCandidates:       8,
+		RandomSeed:           1,
+		LinkRadius:           0.06,
+		MaxJointVelocity:     1.0,
+		MaxJointAcceleration: 2.0,
+		BlendDeviation:       0.05,
+	}
+}
+
+// Reason classifies a planning failure.
+type Reason string
+
+const (
+	// ReasonInvalidRequest: the start or goal has the wrong number of
+	// joints or lies outside the joint limits.
+	ReasonInvalidRequest Reason = "invalid_request"
+	// ReasonStartInCollision: the arm already touches an obstacle.
+	ReasonStartInCollision Reason = "start_in_collision"
+	// ReasonGoalInCollision: every joint goal for the target collides.
+	ReasonGoalInCollision Reason = "goal_in_collision"
+	// ReasonUnreachable: inverse kinematics found no joint goal.
+	ReasonUnreachable Reason = "unreachable"
+	// ReasonNoPath: the iteration budget ran out without a path.
+	ReasonNoPath Reason = "no_path"
+	// ReasonTimeout: the time budget ran out without a path.
+	ReasonTimeout Reason = "timeout"
+)
+
+// ErrPlanningFailed matches every PlanningError with errors.Is.
+var ErrPlanningFailed = errors.New("motion planning failed")
+
+// PlanningError reports why no motion was planned.
+type PlanningError struct {
+	Reason     Reason
+	Algorithm  Algorithm
+	Obstacle   string // Obstacle in collision, for start and goal collisions
+	Link       int    // Link in collision, counted from the shoulder; -1 if none
+	Iterations int
+	Elapsed    time.Duration
+	Err        error // Underlying kinematics or context error
+}
+
+func (e *PlanningError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "motion planning failed: %s", e.Reason)
+	if e.Obstacle != "" {
+		fmt.Fprintf(&b, ": link %d hits %s", e.Link, e.Obstacle)
+	}
+	if e.Iterations > 0 {
+		fmt.Fprintf(&b, " after %d iterations of %s in %v", e.Iterations, e.Algorithm, e.Elapsed.Round(time.Millisecond))
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	return b.String()
+}
+
+// Unwrap exposes ErrPlanningFailed and the underlying error.
+func (e *PlanningError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrPlanningFailed}
+	}
+	return []error{ErrPlanningFailed, e.Err}
+}
+
+// Details returns the failure as fields for audit logs and mission
+// reports.
+func (e *PlanningError) Details() map[string]interface{} {
+	details := map[string]interface{}{
+		"reason": string(e.Reason),
+	}
+	if e.Algorithm != "" {
+		details["algorithm"] = string(e.Algorithm)
+	}
+	if e.Obstacle != "" {
+		details["obstacle"] = e.Obstacle
+		details["link"] = e.Link
+	}
+	if e.Iterations > 0 {
+		details["iterations"] = e.Iterations
+		details["elapsed_ms"] = e.Elapsed.Milliseconds()
+	}
+	if e.Err != nil {
+		details["cause"] = e.Err.Error()
+	}
+	return details
+}
+
+// Path is a sequence of joint configurations joined by straight
+// joint-space motions.
+type Path [][]float64
+
+// Length returns the joint-space length of the path.
+func (p Path) Length() float64 {
+	var length float64
+	for i := 1; i < len(p); i++ {
+		length += distance(p[i-1], p[i])
+	}
+	return length
+}
+
+func distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// steer returns the configuration at most step from from towards to.
+func steer(from, to []float64, step float64) []float64 {
+	d := distance(from, to)
+	q := make([]float64, len(to))
+	if d <= step {
+		copy(q, to)
+		return q
+	}
+	for i := range q {
+		q[i] = from[i] + (to[i]-from[i])*step/d
+	}
+	return q
+}
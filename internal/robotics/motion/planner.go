@@ -0,0 +1,440 @@
+package motion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+)
+
+// Planner plans collision-free motions for one arm in one environment.
+type Planner struct {
+	chain *kinematics.Chain
+	env   *Environment
+	cfg   Config
+}
+
+// NewPlanner returns a planner for chain. A nil environment plans in free
+// space.
+func NewPlanner(chain *kinematics.Chain, env *Environment, cfg Config) *Planner {
+	return &Planner{chain: chain, env: env, cfg: cfg}
+}
+
+// Config returns the planner configuration.
+func (p *Planner) Config() Config {
+	return p.cfg
+}
+
+// checker snapshots the environment around the arm.
+func (p *Planner) checker() *checker {
+	reach := p.chain.Reach() + p.cfg.LinkRadius
+	return &checker{
+		chain:      p.chain,
+		obstacles:  p.env.snapshot(p.chain.Base.P, reach),
+		radius:     p.cfg.LinkRadius,
+		resolution: p.cfg.Resolution,
+	}
+}
+
+// Check returns a PlanningError if the arm at q touches an obstacle.
+func (p *Planner) Check(q []float64) error {
+	if err := p.chain.CheckLimits(q); err != nil {
+		return &PlanningError{Reason: ReasonInvalidRequest, Link: -1, Err: err}
+	}
+	if o, link, hit := p.checker().collision(q); hit {
+		return &PlanningError{Reason: ReasonStartInCollision, Obstacle: o.Name, Link: link}
+	}
+	return nil
+}
+
+// Plan returns a collision-free path from start to goal. Failures are
+// returned as a *PlanningError.
+func (p *Planner) Plan(ctx context.Context, start, goal []float64) (Path, error) {
+	chk := p.checker()
+	if err := p.validate(chk, start, ReasonStartInCollision); err != nil {
+		return nil, err
+	}
+	if err := p.validate(chk, goal, ReasonGoalInCollision); err != nil {
+		return nil, err
+	}
+	return p.plan(ctx, chk, start, goal)
+}
+
+// PlanToPose returns a collision-free path from start to a joint goal
+// reaching target. Every inverse kinematics solution found is tried,
+// nearest first, until one is both collision-free and connected.
+func (p *Planner) PlanToPose(ctx context.Context, start []float64, target kinematics.Transform, ik kinematics.IKConfig) (Path, error) {
+	goals, err := p.goalCandidates(start, target, ik)
+	if err != nil {
+		return nil, &PlanningError{Reason: ReasonUnreachable, Link: -1, Err: err}
+	}
+	chk := p.checker()
+	var collided, last *PlanningError
+	for _, goal := range goals {
+		if err := p.validate(chk, goal, ReasonGoalInCollision); err != nil {
+			if collided == nil {
+				errors.As(err, &collided)
+			}
+			continue
+		}
+		path, err := p.plan(ctx, chk, start, goal)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.As(err, &last) || last.Reason == ReasonStartInCollision || last.Reason == ReasonInvalidRequest || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	if last != nil {
+		return nil, last
+	}
+	return nil, collided
+}
+
+// goalCandidates returns distinct joint goals for target, nearest to start
+// first: every closed-form solution for Universal Robots pose targets,
+// otherwise numerical solutions from start and from random seeds.
+func (p *Planner) goalCandidates(start []float64, target kinematics.Transform, ik kinematics.IKConfig) ([][]float64, error) {
+	if len(start) != p.chain.DOF() {
+		return nil, fmt.Errorf("%s: start has %d joints, want %d: %w", p.chain.Name, len(start), p.chain.DOF(), kinematics.ErrJointCount)
+	}
+	var goals [][]float64
+	if !ik.PositionOnly {
+		if solutions, err := p.chain.InverseAnalytic(target); err == nil {
+			goals = solutions
+		}
+	}
+	if len(goals) == 0 {
+		rng := rand.New(rand.NewSource(p.cfg.RandomSeed))
+		seed := start
+		var firstErr error
+		for k := 0; k < p.cfg.GoalCandidates; k++ {
+			q, err := p.chain.InverseNumerical(target, seed, ik)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if err == nil && !containsNear(goals, q) {
+				goals = append(goals, q)
+			}
+			seed = p.sample(rng)
+			ik.RandomSeed++
+		}
+		if len(goals) == 0 {
+			return nil, firstErr
+		}
+	}
+	sort.SliceStable(goals, func(i, j int) bool {
+		return distance(goals[i], start) < distance(goals[j], start)
+	})
+	if len(goals) > p.cfg.GoalCandidates && p.cfg.GoalCandidates > 0 {
+		goals = goals[:p.cfg.GoalCandidates]
+	}
+	return goals, nil
+}
+
+func containsNear(qs [][]float64, q []float64) bool {
+	for _, other := range qs {
+		if distance(other, q) < 1e-3 {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that q is within the limits and collision-free,
+// reporting a collision with reason.
+func (p *Planner) validate(chk *checker, q []float64, reason Reason) error {
+	if err := p.chain.CheckLimits(q); err != nil {
+		return &PlanningError{Reason: ReasonInvalidRequest, Link: -1, Err: err}
+	}
+	if o, link, hit := chk.collision(q); hit {
+		return &PlanningError{Reason: reason, Obstacle: o.Name, Link: link}
+	}
+	return nil
+}
+
+func (p *Planner) plan(ctx context.Context, chk *checker, start, goal []float64) (Path, error) {
+	if err := p.validate(chk, start, ReasonStartInCollision); err != nil {
+		return nil, err
+	}
+	if chk.motionFree(start, goal) {
+		return Path{clone(start), clone(goal)}, nil
+	}
+
+	rng := rand.New(rand.NewSource(p.cfg.RandomSeed))
+	began := time.Now()
+	deadline := began.Add(p.cfg.Timeout)
+	var path Path
+	var iterations int
+	var err error
+	switch p.cfg.Algorithm {
+	case RRTStar:
+		path, iterations, err = p.rrtStar(ctx, chk, rng, deadline, start, goal)
+	default:
+		path, iterations, err = p.rrtConnect(ctx, chk, rng, deadline, start, goal)
+	}
+	if err != nil {
+		var planErr *PlanningError
+		if errors.As(err, &planErr) {
+			planErr.Algorithm = p.algorithm()
+			planErr.Iterations = iterations
+			planErr.Elapsed = time.Since(began)
+		}
+		return nil, err
+	}
+	return p.shortcut(chk, rng, path), nil
+}
+
+func (p *Planner) algorithm() Algorithm {
+	if p.cfg.Algorithm == RRTStar {
+		return RRTStar
+	}
+	return RRTConnect
+}
+
+// stopped reports whether planning must end, and why.
+func stopped(ctx context.Context, deadline time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return &PlanningError{Reason: ReasonTimeout, Link: -1, Err: err}
+	}
+	if time.Now().After(deadline) {
+		return &PlanningError{Reason: ReasonTimeout, Link: -1}
+	}
+	return nil
+}
+
+// sample draws a configuration uniformly within the joint limits, one
+// turn either way for unlimited joints.
+func (p *Planner) sample(rng *rand.Rand) []float64 {
+	q := make([]float64, p.chain.DOF())
+	for i, j := range p.chain.Joints {
+		lo := math.Max(j.Lower, -2*math.Pi)
+		hi := math.Min(j.Upper, 2*math.Pi)
+		q[i] = lo + rng.Float64()*(hi-lo)
+	}
+	return q
+}
+
+type node struct {
+	q        []float64
+	parent   int
+	cost     float64
+	children []int
+}
+
+type tree struct {
+	nodes []node
+}
+
+func newTree(root []float64) *tree {
+	return &tree{nodes: []node{{q: clone(root), parent: -1}}}
+}
+
+func (t *tree) add(q []float64, parent int) int {
+	i := len(t.nodes)
+	cost := t.nodes[parent].cost + distance(t.nodes[parent].q, q)
+	t.nodes = append(t.nodes, node{q: q, parent: parent, cost: cost})
+	t.nodes[parent].children = append(t.nodes[parent].children, i)
+	return i
+}
+
+func (t *tree) nearest(q []float64) int {
+	best, bestDist := 0, math.Inf(1)
+	for i, n := range t.nodes {
+		if d := distance(n.q, q); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func (t *tree) near(q []float64, radius float64) []int {
+	var result []int
+	for i, n := range t.nodes {
+		if distance(n.q, q) <= radius {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// path returns the configurations from the root to node i.
+func (t *tree) path(i int) Path {
+	var path Path
+	for ; i >= 0; i = t.nodes[i].parent {
+		path = append(path, t.nodes[i].q)
+	}
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+	return path
+}
+
+// reparent moves node i under parent and updates the cost of its subtree.
+func (t *tree) reparent(i, parent int) {
+	old := t.nodes[i].parent
+	children := t.nodes[old].children
+	for k, c := range children {
+		if c == i {
+			t.nodes[old].children = append(children[:k:k], children[k+1:]...)
+			break
+		}
+	}
+	t.nodes[i].parent = parent
+	t.nodes[parent].children = append(t.nodes[parent].children, i)
+
+	stack := []int{i}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		pn := t.nodes[t.nodes[n].parent]
+		t.nodes[n].cost = pn.cost + distance(pn.q, t.nodes[n].q)
+		stack = append(stack, t.nodes[n].children...)
+	}
+}
+
+type extendResult int
+
+const (
+	trapped extendResult = iota
+	advanced
+	reached
+)
+
+// extend grows t one step towards target.
+func (p *Planner) extend(chk *checker, t *tree, target []float64) (extendResult, int) {
+	n := t.nearest(target)
+	q := steer(t.nodes[n].q, target, p.cfg.StepSize)
+	if !chk.motionFree(t.nodes[n].q, q) {
+		return trapped, -1
+	}
+	i := t.add(q, n)
+	if distance(q, target) < 1e-9 {
+		return reached, i
+	}
+	return advanced, i
+}
+
+// connect extends t towards target until it arrives or is blocked.
+func (p *Planner) connect(chk *checker, t *tree, target []float64) (extendResult, int) {
+	for {
+		result, i := p.extend(chk, t, target)
+		if result != advanced {
+			return result, i
+		}
+	}
+}
+
+// rrtConnect alternates between the start and goal trees: one extends
+// towards a random sample, the other connects to the new node.
+func (p *Planner) rrtConnect(ctx context.Context, chk *checker, rng *rand.Rand, deadline time.Time, start, goal []float64) (Path, int, error) {
+	a, b := newTree(start), newTree(goal)
+	swapped := false
+	for iter := 1; iter <= p.cfg.MaxIterations; iter++ {
+		if err := stopped(ctx, deadline); err != nil {
+			return nil, iter, err
+		}
+		result, i := p.extend(chk, a, p.sample(rng))
+		if result != trapped {
+			if result, j := p.connect(chk, b, a.nodes[i].q); result == reached {
+				fromA, fromB := a.path(i), b.path(j)
+				if swapped {
+					fromA, fromB = fromB, fromA
+				}
+				path := fromA
+				for k := len(fromB) - 2; k >= 0; k-- {
+					path = append(path, fromB[k])
+				}
+				return path, iter, nil
+			}
+		}
+		a, b = b, a
+		swapped = !swapped
+	}
+	return nil, p.cfg.MaxIterations, &PlanningError{Reason: ReasonNoPath, Link: -1}
+}
+
+// rrtStar grows an asymptotically optimal tree from the start, choosing
+// each new node's cheapest collision-free parent among its neighbours and
+// rewiring the neighbours through it. Every new node that sees the goal
+// is a candidate path end; once a path is found it keeps refining until
+// the iteration or time budget is spent.
+func (p *Planner) rrtStar(ctx context.Context, chk *checker, rng *rand.Rand, deadline time.Time, start, goal []float64) (Path, int, error) {
+	t := newTree(start)
+	best, bestCost := -1, math.Inf(1)
+	iter := 1
+	for ; iter <= p.cfg.MaxIterations; iter++ {
+		if err := stopped(ctx, deadline); err != nil {
+			if best >= 0 {
+				break
+			}
+			return nil, iter, err
+		}
+		target := goal
+		if rng.Float64() >= p.cfg.GoalBias {
+			target = p.sample(rng)
+		}
+		n := t.nearest(target)
+		q := steer(t.nodes[n].q, target, p.cfg.StepSize)
+		if !chk.motionFree(t.nodes[n].q, q) {
+			continue
+		}
+
+		neighbours := t.near(q, math.Max(p.cfg.RewireRadius, p.cfg.StepSize))
+		parent, cost := n, t.nodes[n].cost+distance(t.nodes[n].q, q)
+		for _, j := range neighbours {
+			c := t.nodes[j].cost + distance(t.nodes[j].q, q)
+			if c < cost && j != n && chk.motionFree(t.nodes[j].q, q) {
+				parent, cost = j, c
+			}
+		}
+		i := t.add(q, parent)
+		for _, j := range neighbours {
+			if j == parent {
+				continue
+			}
+			if cost+distance(q, t.nodes[j].q) < t.nodes[j].cost && chk.motionFree(q, t.nodes[j].q) {
+				t.reparent(j, i)
+			}
+		}
+
+		// Any node with a free straight motion to the goal completes a path
+		if d := distance(q, goal); t.nodes[i].cost+d < bestCost && chk.motionFree(q, goal) {
+			best, bestCost = i, t.nodes[i].cost+d
+		}
+		// Rewiring may have shortened the path to the best node
+		if best >= 0 {
+			bestCost = t.nodes[best].cost + distance(t.nodes[best].q, goal)
+		}
+	}
+	if best < 0 {
+		return nil, p.cfg.MaxIterations, &PlanningError{Reason: ReasonNoPath, Link: -1}
+	}
+	path := t.path(best)
+	if distance(path[len(path)-1], goal) > 1e-9 {
+		path = append(path, clone(goal))
+	}
+	return path, iter, nil
+}
+
+// shortcut removes waypoints by joining random pairs of configurations
+// along the path with straight motions where they are collision-free.
+func (p *Planner) shortcut(chk *checker, rng *rand.Rand, path Path) Path {
+	for k := 0; k < p.cfg.ShortcutIterations && len(path) > 2; k++ {
+		i := rng.Intn(len(path) - 2)
+		j := i + 2 + rng.Intn(len(path)-i-2)
+		if chk.motionFree(path[i], path[j]) {
+			path = append(path[:i+1], path[j:]...)
+		}
+	}
+	return path
+}
+
+func clone(q []float64) []float64 {
+	return append([]float64(nil), q...)
+}
@@ -0,0 +1,468 @@
+package motion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrLimits is returned when trajectory limits are missing or not
+// positive.
+var ErrLimits = errors.New("invalid trajectory limits")
+
+// Limits bounds joint motion along a trajectory.
+type Limits struct {
+	Velocity     []float64 // rad/s per joint
+	Acceleration []float64 // rad/s² per joint
+}
+
+// Limits returns the chain's joint velocity limits capped at the
+// configured maximum, and the configured acceleration limit.
+func (p *Planner) Limits() Limits {
+	n := p.chain.DOF()
+	limits := Limits{Velocity: make([]float64, n), Acceleration: make([]float64, n)}
+	for i, j := range p.chain.Joints {
+		v := j.MaxVelocity
+		if p.cfg.MaxJointVelocity > 0 && (v <= 0 || p.cfg.MaxJointVelocity < v) {
+			v = p.cfg.MaxJointVelocity
+		}
+		limits.Velocity[i] = v
+		limits.Acceleration[i] = p.cfg.MaxJointAcceleration
+	}
+	return limits
+}
+
+// Parameterize times path within the planner's limits.
+func (p *Planner) Parameterize(path Path) (*Trajectory, error) {
+	return TimeParameterize(path, p.Limits(), p.cfg.BlendDeviation)
+}
+
+// TrajectoryPoint is a timed joint state.
+type TrajectoryPoint struct {
+	Time          time.Duration
+	Positions     []float64
+	Velocities    []float64
+	Accelerations []float64
+}
+
+// Trajectory is a densely sampled joint trajectory.
+type Trajectory struct {
+	Points []TrajectoryPoint
+}
+
+// Duration returns the time from the first to the last point.
+func (t *Trajectory) Duration() time.Duration {
+	if len(t.Points) == 0 {
+		return 0
+	}
+	return t.Points[len(t.Points)-1].Time
+}
+
+// Sample returns the joint positions at time at, interpolating linearly
+// between points and holding the ends.
+func (t *Trajectory) Sample(at time.Duration) []float64 {
+	return t.state(at).Positions
+}
+
+// Resample returns the trajectory state every period from the start,
+// always ending with the last point, for controllers that interpolate
+// trajectories themselves.
+func (t *Trajectory) Resample(period time.Duration) []TrajectoryPoint {
+	var points []TrajectoryPoint
+	duration := t.Duration()
+	for at := time.Duration(0); at < duration; at += period {
+		points = append(points, t.state(at))
+	}
+	return append(points, t.state(duration))
+}
+
+func (t *Trajectory) state(at time.Duration) TrajectoryPoint {
+	points := t.Points
+	k := sort.Search(len(points), func(i int) bool { return points[i].Time >= at })
+	switch {
+	case k == 0:
+		return copyPoint(points[0], at)
+	case k == len(points):
+		return copyPoint(points[len(points)-1], at)
+	}
+	a, b := points[k-1], points[k]
+	f := float64(at-a.Time) / float64(b.Time-a.Time)
+	return TrajectoryPoint{
+		Time:          at,
+		Positions:     lerp(a.Positions, b.Positions, f),
+		Velocities:    lerp(a.Velocities, b.Velocities, f),
+		Accelerations: lerp(a.Accelerations, b.Accelerations, f),
+	}
+}
+
+func copyPoint(p TrajectoryPoint, at time.Duration) TrajectoryPoint {
+	return TrajectoryPoint{
+		Time:          at,
+		Positions:     clone(p.Positions),
+		Velocities:    clone(p.Velocities),
+		Accelerations: clone(p.Accelerations),
+	}
+}
+
+func lerp(a, b []float64, f float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range out {
+		out[i] = a[i] + f*(b[i]-a[i])
+	}
+	return out
+}
+
+// TimeParameterize returns the fastest trajectory along path within
+// limits, starting and ending at rest. Following the path's corners
+// exactly would force a stop at each waypoint, so corners are first
+// replaced by circular blends cutting at most deviation radians off them
+// (Kunz and Stilman, 2012). Speed along the blended path is then found by
+// phase-plane integration: accelerating as hard as the limits allow from
+// the start, braking as hard as they allow into the end, and never
+// exceeding the velocity limit curve in between.
+func TimeParameterize(path Path, limits Limits, deviation float64) (*Trajectory, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	n := len(path[0])
+	if len(limits.Velocity) != n || len(limits.Acceleration) != n {
+		return nil, fmt.Errorf("%d velocity and %d acceleration limits for %d joints: %w", len(limits.Velocity), len(limits.Acceleration), n, ErrLimits)
+	}
+	for i := 0; i < n; i++ {
+		if !(limits.Velocity[i] > 0) || !(limits.Acceleration[i] > 0) {
+			return nil, fmt.Errorf("joint %d: velocity %.3f, acceleration %.3f: %w", i, limits.Velocity[i], limits.Acceleration[i], ErrLimits)
+		}
+	}
+
+	samples := sampleSegments(blendPath(path, deviation))
+	if len(samples) == 1 {
+		zero := make([]float64, n)
+		return &Trajectory{Points: []TrajectoryPoint{{Positions: samples[0].q, Velocities: zero, Accelerations: zero}}}, nil
+	}
+
+	// Velocity limit curve in path speed ṡ
+	maxSpeed := make([]float64, len(samples))
+	for k, smp := range samples {
+		if smp.stop {
+			continue
+		}
+		v := math.Inf(1)
+		for i, d := range smp.dq {
+			if math.Abs(d) > 1e-12 {
+				v = math.Min(v, limits.Velocity[i]/math.Abs(d))
+			}
+		}
+		maxSpeed[k] = smp.accelerationSpeedLimit(v, limits.Acceleration)
+	}
+
+	// Forward pass accelerating, backward pass braking
+	speed := make([]float64, len(samples))
+	for k := 0; k+1 < len(samples); k++ {
+		h := samples[k+1].s - samples[k].s
+		_, hi1, ok1 := samples[k].accelerationRange(speed[k], limits.Acceleration)
+		_, hi2, ok2 := samples[k+1].accelerationRange(speed[k], limits.Acceleration)
+		hi := math.Min(hi1, hi2)
+		if !ok1 || !ok2 {
+			hi = 0
+		}
+		next := speed[k]*speed[k] + 2*h*hi
+		speed[k+1] = math.Min(maxSpeed[k+1], math.Sqrt(math.Max(0, next)))
+	}
+	speed[len(samples)-1] = 0
+	for k := len(samples) - 1; k > 0; k-- {
+		h := samples[k].s - samples[k-1].s
+		lo1, _, ok1 := samples[k].accelerationRange(speed[k], limits.Acceleration)
+		lo2, _, ok2 := samples[k-1].accelerationRange(speed[k], limits.Acceleration)
+		lo := math.Max(lo1, lo2)
+		if !ok1 || !ok2 {
+			lo = 0
+		}
+		prev := speed[k]*speed[k] - 2*h*lo
+		speed[k-1] = math.Min(speed[k-1], math.Sqrt(math.Max(0, prev)))
+	}
+
+	minAcceleration := math.Inf(1)
+	for _, a := range limits.Acceleration {
+		minAcceleration = math.Min(minAcceleration, a)
+	}
+	points := make([]TrajectoryPoint, len(samples))
+	var t float64
+	for k, smp := range samples {
+		var sdd float64
+		switch {
+		case k+1 < len(samples):
+			h := samples[k+1].s - smp.s
+			sdd = (speed[k+1]*speed[k+1] - speed[k]*speed[k]) / (2 * h)
+		default:
+			h := smp.s - samples[k-1].s
+			sdd = (speed[k]*speed[k] - speed[k-1]*speed[k-1]) / (2 * h)
+		}
+		if k > 0 {
+			h := smp.s - samples[k-1].s
+			if sum := speed[k-1] + speed[k]; sum > 1e-9 {
+				t += 2 * h / sum
+			} else {
+				t += math.Sqrt(4 * h / minAcceleration)
+			}
+		}
+		point := TrajectoryPoint{
+			Time:          time.Duration(t * float64(time.Second)),
+			Positions:     smp.q,
+			Velocities:    make([]float64, n),
+			Accelerations: make([]float64, n),
+		}
+		for i := 0; i < n; i++ {
+			point.Velocities[i] = smp.dq[i] * speed[k]
+			point.Accelerations[i] = smp.dq[i]*sdd + smp.ddq[i]*speed[k]*speed[k]
+		}
+		points[k] = point
+	}
+	return &Trajectory{Points: points}, nil
+}
+
+// segment is a piece of the blended path parameterized by joint-space arc
+// length.
+type segment interface {
+	length() float64
+	// at returns the position and its first and second derivatives with
+	// respect to arc length.
+	at(s float64) (q, dq, ddq []float64)
+}
+
+type linearSegment struct {
+	start, dir []float64
+	len        float64
+}
+
+func (l linearSegment) length() float64 { return l.len }
+
+func (l linearSegment) at(s float64) (q, dq, ddq []float64) {
+	q = make([]float64, len(l.start))
+	for i := range q {
+		q[i] = l.start[i] + s*l.dir[i]
+	}
+	return q, l.dir, make([]float64, len(q))
+}
+
+// circularSegment is an arc of radius about center in the plane spanned by
+// the orthonormal x and y, starting at center + radius·x.
+type circularSegment struct {
+	center, x, y  []float64
+	radius, angle float64
+}
+
+func (c circularSegment) length() float64 { return c.radius * c.angle }
+
+func (c circularSegment) at(s float64) (q, dq, ddq []float64) {
+	sin, cos := math.Sincos(s / c.radius)
+	n := len(c.center)
+	q, dq, ddq = make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		q[i] = c.center[i] + c.radius*(c.x[i]*cos+c.y[i]*sin)
+		dq[i] = -c.x[i]*sin + c.y[i]*cos
+		ddq[i] = -(c.x[i]*cos + c.y[i]*sin) / c.radius
+	}
+	return q, dq, ddq
+}
+
+// pathPiece is a segment and whether the arm must stop at its end, at a
+// corner that could not be blended.
+type pathPiece struct {
+	segment
+	stop bool
+}
+
+// blendPath joins the waypoints with straight segments and replaces each
+// corner with a circular arc tangent to both sides, cutting at most
+// deviation off the corner and using at most half of either side.
+func blendPath(path Path, deviation float64) []pathPiece {
+	var points Path
+	for _, q := range path {
+		if len(points) == 0 || distance(points[len(points)-1], q) > 1e-9 {
+			points = append(points, q)
+		}
+	}
+	if len(points) == 1 {
+		return []pathPiece{{segment: linearSegment{start: points[0], dir: make([]float64, len(points[0]))}}}
+	}
+
+	dirs := make([][]float64, len(points)-1)
+	lengths := make([]float64, len(points)-1)
+	for i := range dirs {
+		lengths[i] = distance(points[i], points[i+1])
+		dirs[i] = make([]float64, len(points[i]))
+		for j := range dirs[i] {
+			dirs[i][j] = (points[i+1][j] - points[i][j]) / lengths[i]
+		}
+	}
+
+	var pieces []pathPiece
+	current := points[0]
+	for i := 0; i < len(dirs); i++ {
+		end := points[i+1]
+		var blend *circularSegment
+		var cut float64
+		stop := false
+		if i+1 < len(dirs) {
+			blend, cut = cornerBlend(points[i+1], dirs[i], dirs[i+1], lengths[i], lengths[i+1], deviation)
+			if blend != nil {
+				end = offset(points[i+1], dirs[i], -cut)
+			} else {
+				stop = dot(dirs[i], dirs[i+1]) < 1-1e-9
+			}
+		}
+		if l := distance(current, end); l > 1e-12 {
+			pieces = append(pieces, pathPiece{segment: linearSegment{start: current, dir: dirs[i], len: l}, stop: stop})
+		} else if stop && len(pieces) > 0 {
+			pieces[len(pieces)-1].stop = true
+		}
+		if blend != nil {
+			pieces = append(pieces, pathPiece{segment: *blend})
+			current = offset(points[i+1], dirs[i+1], cut)
+		} else {
+			current = end
+		}
+	}
+	return pieces
+}
+
+// cornerBlend returns the arc replacing the corner at point between unit
+// directions in and out, and the distance it cuts from either side, or
+// nil when the path is straight, reverses, or cannot be cut.
+func cornerBlend(point, in, out []float64, inLength, outLength, deviation float64) (*circularSegment, float64) {
+	cosAngle := math.Max(-1, math.Min(1, dot(in, out)))
+	angle := math.Acos(cosAngle)
+	if angle < 1e-6 || math.Pi-angle < 1e-6 || deviation <= 0 {
+		return nil, 0
+	}
+	half := angle / 2
+	cut := math.Min(math.Min(inLength/2, outLength/2), deviation*math.Sin(half)/(1-math.Cos(half)))
+	radius := cut / math.Tan(half)
+
+	n := len(point)
+	bisector := make([]float64, n)
+	for i := range bisector {
+		bisector[i] = out[i] - in[i]
+	}
+	bisector = normalized(bisector)
+	center := make([]float64, n)
+	for i := range center {
+		center[i] = point[i] + bisector[i]*radius/math.Cos(half)
+	}
+	start := offset(point, in, -cut)
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = start[i] - center[i]
+	}
+	return &circularSegment{
+		center: center,
+		x:      normalized(x),
+		y:      in,
+		radius: radius,
+		angle:  angle,
+	}, cut
+}
+
+// pathSample is the path geometry at arc length s.
+type pathSample struct {
+	s          float64
+	q, dq, ddq []float64
+	stop       bool // Speed must be zero here
+}
+
+// sampleSegments samples the pieces at most sampleStep apart, always
+// including every piece's ends.
+func sampleSegments(pieces []pathPiece) []pathSample {
+	const sampleStep = 0.005 // radians
+	var samples []pathSample
+	var offsetS float64
+	for p, piece := range pieces {
+		length := piece.length()
+		m := int(math.Ceil(length / sampleStep))
+		if m < 1 {
+			m = 1
+		}
+		for k := 0; k < m; k++ {
+			s := length * float64(k) / float64(m)
+			q, dq, ddq := piece.at(s)
+			samples = append(samples, pathSample{s: offsetS + s, q: q, dq: dq, ddq: ddq, stop: k == 0 && (p == 0 || pieces[p-1].stop)})
+		}
+		offsetS += length
+		if p == len(pieces)-1 {
+			q, dq, ddq := piece.at(length)
+			if length == 0 {
+				return samples[:1]
+			}
+			samples = append(samples, pathSample{s: offsetS, q: q, dq: dq, ddq: ddq, stop: true})
+		}
+	}
+	return samples
+}
+
+// accelerationRange returns the path accelerations s̈ at path speed sd
+// keeping every joint within its acceleration limit, where joint i
+// accelerates at dq[i]·s̈ + ddq[i]·ṡ².
+func (p pathSample) accelerationRange(sd float64, limits []float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(-1), math.Inf(1)
+	for i, d := range p.dq {
+		centripetal := p.ddq[i] * sd * sd
+		if math.Abs(d) < 1e-12 {
+			if math.Abs(centripetal) > limits[i]*(1+1e-9) {
+				return 0, 0, false
+			}
+			continue
+		}
+		a, b := (-limits[i]-centripetal)/d, (limits[i]-centripetal)/d
+		if a > b {
+			a, b = b, a
+		}
+		lo, hi = math.Max(lo, a), math.Min(hi, b)
+	}
+	return lo, hi, lo <= hi
+}
+
+// accelerationSpeedLimit lowers the speed limit v until some path
+// acceleration satisfies every joint's acceleration limit, which on
+// blends bounds the centripetal term.
+func (p pathSample) accelerationSpeedLimit(v float64, limits []float64) float64 {
+	if _, _, ok := p.accelerationRange(v, limits); ok {
+		return v
+	}
+	lo, hi := 0.0, v
+	for iter := 0; iter < 50; iter++ {
+		mid := (lo + hi) / 2
+		if _, _, ok := p.accelerationRange(mid, limits); ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+func offset(q, dir []float64, d float64) []float64 {
+	out := make([]float64, len(q))
+	for i := range q {
+		out[i] = q[i] + d*dir[i]
+	}
+	return out
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func normalized(v []float64) []float64 {
+	n := math.Sqrt(dot(v, v))
+	out := make([]float64, len(v))
+	for i := range v {
+		out[i] = v[i] / n
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package navigation
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrNoPath is returned when the goal cannot be reached from the start.
+	ErrNoPath = errors.New("no path to goal")
+	// ErrBlocked is returned when the start or goal cell is blocked.
+	ErrBlocked = errors.New("cell blocked")
+)
+
+// edgeCost returns the cost of stepping between neighbouring cells: the
+// step length in cells, or +Inf if either end is blocked or a diagonal
+// step would cut the corner of a blocked cell.
+func edgeCost(m *Costmap, a, b Cell) float64 {
+	if m.Blocked(a) || m.Blocked(b) {
+		return math.Inf(1)
+	}
+	if a.X != b.X && a.Y != b.Y {
+		if m.Blocked(Cell{X: a.X, Y: b.Y}) || m.Blocked(Cell{X: b.X, Y: a.Y}) {
+			return math.Inf(1)
+		}
+		return math.Sqrt2
+	}
+	return 1
+}
+
+// octile is the 8-connected distance between cells ignoring obstacles.
+func octile(a, b Cell) float64 {
+	dx, dy := float64(abs(a.X-b.X)), float64(abs(a.Y-b.Y))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+// AStar returns the shortest 8-connected path from start to goal,
+// inclusive.
+func AStar(m *Costmap, start, goal Cell) ([]Cell, error) {
+	if m.Blocked(start) {
+		return nil, fmt.Errorf("start %v: %w", start, ErrBlocked)
+	}
+	if m.Blocked(goal) {
+		return nil, fmt.Errorf("goal %v: %w", goal, ErrBlocked)
+	}
+	w, h := m.Size()
+	index := func(c Cell) int { return c.Y*w + c.X }
+	g := make([]float64, w*h)
+	for i := range g {
+		g[i] = math.Inf(1)
+	}
+	parent := make([]int32, w*h)
+	closed := make([]bool, w*h)
+
+	open := &cellQueue{}
+	g[index(start)] = 0
+	parent[index(start)] = -1
+	heap.Push(open, queued{cell: start, key: [2]float64{octile(start, goal)}})
+	for open.Len() > 0 {
+		u := heap.Pop(open).(queued).cell
+		ui := index(u)
+		if closed[ui] {
+			continue
+		}
+		if u == goal {
+			var path []Cell
+			for i := ui; i >= 0; i = int(parent[i]) {
+				path = append(path, Cell{X: i % w, Y: i / w})
+			}
+			for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+				path[l], path[r] = path[r], path[l]
+			}
+			return path, nil
+		}
+		closed[ui] = true
+		for _, d := range neighbours {
+			v := Cell{X: u.X + d.X, Y: u.Y + d.Y}
+			cost := edgeCost(m, u, v)
+			if math.IsInf(cost, 1) || closed[index(v)] {
+				continue
+			}
+			if gv := g[ui] + cost; gv < g[index(v)] {
+				g[index(v)] = gv
+				parent[index(v)] = int32(ui)
+				heap.Push(open, queued{cell: v, key: [2]float64{gv + octile(v, goal)}})
+			}
+		}
+	}
+	return nil, fmt.Errorf("%v to %v: %w", start, goal, ErrNoPath)
+}
+
+// queued is a cell in a priority queue, ordered lexicographically by key.
+type queued struct {
+	cell Cell
+	key  [2]float64
+}
+
+// less compares keys, treating first components within rounding error as
+// equal so that D* Lite's tie-break on the second is not lost to sums
+// taken in a different order.
+func less(a, b [2]float64) bool {
+	const eps = 1e-9
+	return a[0] < b[0]-eps || (a[0] <= b[0]+eps && a[1] < b[1])
+}
+
+// cellQueue is a min-heap of cells that may hold stale duplicates.
+type cellQueue []queued
+
+func (q cellQueue) Len() int            { return len(q) }
+func (q cellQueue) Less(i, j int) bool  { return less(q[i].key, q[j].key) }
+func (q cellQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *cellQueue) Push(x interface{}) { *q = append(*q, x.(queued)) }
+func (q *cellQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
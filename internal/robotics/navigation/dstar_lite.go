@@ -0,0 +1,161 @@
+package navigation
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// DStarLite is an incremental planner (Koenig & Likhachev, 2002). It
+// searches backwards from the goal, so when the robot moves and the map
+// changes only the affected part of the search is repaired rather than
+// planning from scratch.
+type DStarLite struct {
+	m           *Costmap
+	start, goal Cell
+	last        Cell
+	km          float64
+	g, rhs      []float64
+	open        cellQueue
+}
+
+// NewDStarLite prepares a search from start to goal over m.
+func NewDStarLite(m *Costmap, start, goal Cell) *DStarLite {
+	w, h := m.Size()
+	d := &DStarLite{
+		m:     m,
+		start: start,
+		goal:  goal,
+		last:  start,
+		g:     make([]float64, w*h),
+		rhs:   make([]float64, w*h),
+	}
+	for i := range d.g {
+		d.g[i] = math.Inf(1)
+		d.rhs[i] = math.Inf(1)
+	}
+	if m.Blocked(goal) {
+		return d
+	}
+	d.rhs[d.index(goal)] = 0
+	heap.Push(&d.open, queued{cell: goal, key: d.key(goal)})
+	return d
+}
+
+func (d *DStarLite) index(c Cell) int {
+	return c.Y*d.m.width + c.X
+}
+
+func (d *DStarLite) key(c Cell) [2]float64 {
+	i := d.index(c)
+	k := math.Min(d.g[i], d.rhs[i])
+	return [2]float64{k + octile(d.start, c) + d.km, k}
+}
+
+// MoveStart records that the robot has moved to c.
+func (d *DStarLite) MoveStart(c Cell) {
+	d.km += octile(d.last, c)
+	d.last = c
+	d.start = c
+}
+
+// UpdateCosts switches the search to a new costmap in which the listed
+// cells changed.
+func (d *DStarLite) UpdateCosts(m *Costmap, changed []Cell) {
+	d.m = m
+	seen := make(map[Cell]bool)
+	for _, c := range changed {
+		for _, n := range append([]Cell{{}}, neighbours...) {
+			u := Cell{X: c.X + n.X, Y: c.Y + n.Y}
+			if seen[u] || u.X < 0 || u.Y < 0 || u.X >= m.width || u.Y >= m.height {
+				continue
+			}
+			seen[u] = true
+			d.updateVertex(u)
+		}
+	}
+}
+
+func (d *DStarLite) updateVertex(u Cell) {
+	i := d.index(u)
+	if u != d.goal {
+		best := math.Inf(1)
+		for _, n := range neighbours {
+			s := Cell{X: u.X + n.X, Y: u.Y + n.Y}
+			if cost := edgeCost(d.m, u, s); !math.IsInf(cost, 1) {
+				best = math.Min(best, cost+d.g[d.index(s)])
+			}
+		}
+		d.rhs[i] = best
+	}
+	if d.g[i] != d.rhs[i] {
+		heap.Push(&d.open, queued{cell: u, key: d.key(u)})
+	}
+}
+
+// computeShortestPath expands inconsistent cells until the start is
+// consistent. The queue may hold stale entries; they are skipped or
+// requeued with their current key when popped.
+func (d *DStarLite) computeShortestPath() {
+	si := d.index(d.start)
+	for d.open.Len() > 0 && (less(d.open[0].key, d.key(d.start)) || d.rhs[si] != d.g[si]) {
+		top := heap.Pop(&d.open).(queued)
+		u := top.cell
+		i := d.index(u)
+		if d.g[i] == d.rhs[i] {
+			continue
+		}
+		if k := d.key(u); less(top.key, k) {
+			heap.Push(&d.open, queued{cell: u, key: k})
+			continue
+		}
+		if d.g[i] > d.rhs[i] {
+			d.g[i] = d.rhs[i]
+		} else {
+			d.g[i] = math.Inf(1)
+			d.updateVertex(u)
+		}
+		for _, n := range neighbours {
+			s := Cell{X: u.X + n.X, Y: u.Y + n.Y}
+			if !d.m.Blocked(s) {
+				d.updateVertex(s)
+			}
+		}
+	}
+}
+
+// Plan returns the current shortest path from the start to the goal,
+// inclusive.
+func (d *DStarLite) Plan() ([]Cell, error) {
+	if d.m.Blocked(d.start) {
+		return nil, fmt.Errorf("start %v: %w", d.start, ErrBlocked)
+	}
+	if d.m.Blocked(d.goal) {
+		return nil, fmt.Errorf("goal %v: %w", d.goal, ErrBlocked)
+	}
+	d.computeShortestPath()
+	if math.IsInf(d.g[d.index(d.start)], 1) {
+		return nil, fmt.Errorf("%v to %v: %w", d.start, d.goal, ErrNoPath)
+	}
+
+	path := []Cell{d.start}
+	for u := d.start; u != d.goal; {
+		best, next := math.Inf(1), u
+		for _, n := range neighbours {
+			s := Cell{X: u.X + n.X, Y: u.Y + n.Y}
+			cost := edgeCost(d.m, u, s)
+			if math.IsInf(cost, 1) {
+				continue
+			}
+			if cost += d.g[d.index(s)]; cost < best {
+				best, next = cost, s
+			}
+		}
+		if math.IsInf(best, 1) || len(path) > len(d.g) {
+			return nil, fmt.Errorf("%v to %v: %w", d.start, d.goal, ErrNoPath)
+		}
+		u = next
+		path = append(path, u)
+	}
+	return path, nil
+}
@@ -0,0 +1,243 @@
+package navigation
+
+import (
+	"math"
+	"time"
+)
+
+// DWAConfig tunes the dynamic-window local planner.
+type DWAConfig struct {
+	MaxSpeed    float64 // m/s
+	MinSpeed    float64 // m/s; negative allows reversing
+	MaxYawRate  float64 // rad/s
+	MaxAccel    float64 // m/s²
+	MaxYawAccel float64 // rad/s²
+
+	SpeedResolution   float64 // m/s between sampled speeds
+	YawRateResolution float64 // rad/s between sampled yaw rates
+
+	Period      time.Duration // Control period the window spans
+	Step        time.Duration // Rollout integration step
+	PredictTime time.Duration // Rollout horizon
+
+	// Score weights. Heading favours facing the target after the first
+	// step, Progress passing close to it within the horizon, Clearance
+	// keeping away from obstacles and Speed moving fast.
+	HeadingWeight   float64
+	ProgressWeight  float64
+	ClearanceWeight float64
+	SpeedWeight     float64
+}
+
+// DefaultDWAConfig suits a walking-pace humanoid base.
+func DefaultDWAConfig() DWAConfig {
+	return DWAConfig{
+		MaxSpeed:          0.8,
+		MinSpeed:          0,
+		MaxYawRate:        1.5,
+		MaxAccel:          0.8,
+		MaxYawAccel:       3.0,
+		SpeedResolution:   0.05,
+		YawRateResolution: 0.1,
+		Period:            100 * time.Millisecond,
+		Step:              50 * time.Millisecond,
+		PredictTime:       2 * time.Second,
+		HeadingWeight:     0.6,
+		ProgressWeight:    1.0,
+		ClearanceWeight:   0.4,
+		SpeedWeight:       0.2,
+	}
+}
+
+// Velocity is a planar velocity command.
+type Velocity struct {
+	Linear  float64 // m/s along the heading
+	Angular float64 // rad/s counter-clockwise
+}
+
+// MovingObstacle is a tracked object predicted at constant velocity.
+type MovingObstacle struct {
+	ID     string
+	X, Y   float64 // Map position, meters
+	VX, VY float64 // m/s
+	Radius float64 // meters
+}
+
+// Obstacles describes what the local planner must avoid. Clearances are
+// measured from the robot's edge, so a value at or below zero is a
+// collision.
+type Obstacles struct {
+	// Clearance returns the distance from a robot centred at (x, y) to the
+	// nearest static obstacle.
+	Clearance func(x, y float64) float64
+	// Moving obstacles, with Radius already including the robot's.
+	Moving []MovingObstacle
+}
+
+// clearance returns the free distance around (x, y) at time t into the
+// rollout.
+func (o Obstacles) clearance(x, y, t float64) float64 {
+	c := math.Inf(1)
+	if o.Clearance != nil {
+		c = o.Clearance(x, y)
+	}
+	for _, m := range o.Moving {
+		c = math.Min(c, math.Hypot(x-(m.X+m.VX*t), y-(m.Y+m.VY*t))-m.Radius)
+	}
+	return c
+}
+
+// rollout is one sampled command and where it leads.
+type rollout struct {
+	cmd       Velocity
+	poses     []Pose2D
+	end       Pose2D
+	clearance float64
+	heading   float64
+	progress  float64
+}
+
+// closest returns how near the rollout passes to a point, so that fast
+// commands are not penalised for carrying on past the target within the
+// horizon.
+func (r rollout) closest(x, y float64) float64 {
+	d := math.Inf(1)
+	for _, p := range r.poses {
+		d = math.Min(d, math.Hypot(x-p.X, y-p.Y))
+	}
+	return d
+}
+
+// DWA is a dynamic-window local planner (Fox, Burgard & Thrun, 1997).
+// Each period it samples the velocities reachable under the acceleration
+// limits, simulates them against static and moving obstacles, drops those
+// that collide or cannot stop in time, and picks the best scoring.
+type DWA struct {
+	cfg DWAConfig
+}
+
+// NewDWA returns a local planner.
+func NewDWA(cfg DWAConfig) *DWA {
+	return &DWA{cfg: cfg}
+}
+
+// Config returns the planner configuration.
+func (d *DWA) Config() DWAConfig {
+	return d.cfg
+}
+
+// Plan returns the command to steer from pose, currently moving at
+// current, towards the target point. It reports false when every command
+// in the window collides, in which case the robot should stop.
+func (d *DWA) Plan(pose Pose2D, current Velocity, targetX, targetY float64, obstacles Obstacles) (Velocity, bool) {
+	cfg := d.cfg
+	period := cfg.Period.Seconds()
+	// A measured velocity can sit slightly outside the limits; keep the
+	// window on the feasible side.
+	current.Linear = clamp(current.Linear, cfg.MinSpeed, cfg.MaxSpeed)
+	current.Angular = clamp(current.Angular, -cfg.MaxYawRate, cfg.MaxYawRate)
+	minV := math.Max(cfg.MinSpeed, current.Linear-cfg.MaxAccel*period)
+	maxV := math.Min(cfg.MaxSpeed, current.Linear+cfg.MaxAccel*period)
+	minW := math.Max(-cfg.MaxYawRate, current.Angular-cfg.MaxYawAccel*period)
+	maxW := math.Min(cfg.MaxYawRate, current.Angular+cfg.MaxYawAccel*period)
+
+	startDist := math.Hypot(targetX-pose.X, targetY-pose.Y)
+	var candidates []rollout
+	for _, v := range samples(minV, maxV, cfg.SpeedResolution) {
+		for _, w := range samples(minW, maxW, cfg.YawRateResolution) {
+			r, ok := d.simulate(pose, Velocity{Linear: v, Angular: w}, obstacles)
+			if !ok {
+				continue
+			}
+			next := r.poses[0]
+			bearing := math.Atan2(targetY-next.Y, targetX-next.X)
+			r.heading = math.Pi - math.Abs(normalizeAngle(bearing-next.Yaw))
+			r.progress = startDist - r.closest(targetX, targetY)
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		return Velocity{}, false
+	}
+
+	minProgress, maxProgress := math.Inf(1), math.Inf(-1)
+	for _, r := range candidates {
+		minProgress = math.Min(minProgress, r.progress)
+		maxProgress = math.Max(maxProgress, r.progress)
+	}
+	best, bestScore := candidates[0], math.Inf(-1)
+	for _, r := range candidates {
+		progress := 0.0
+		if maxProgress > minProgress {
+			progress = (r.progress - minProgress) / (maxProgress - minProgress)
+		}
+		score := cfg.HeadingWeight*r.heading/math.Pi +
+			cfg.ProgressWeight*progress +
+			cfg.ClearanceWeight*math.Min(r.clearance, 1) +
+			cfg.SpeedWeight*math.Abs(r.cmd.Linear)/cfg.MaxSpeed
+		if score > bestScore {
+			best, bestScore = r, score
+		}
+	}
+	return best.cmd, true
+}
+
+// simulate rolls a constant command forward over the horizon. The command
+// is admissible if the robot stays clear and could brake to a stop within
+// the clearance it has. A robot that already starts too close, having
+// been cut off by a moving object, may not get any closer.
+func (d *DWA) simulate(pose Pose2D, cmd Velocity, obstacles Obstacles) (rollout, bool) {
+	dt := d.cfg.Step.Seconds()
+	steps := int(math.Ceil(d.cfg.PredictTime.Seconds() / dt))
+	limit := math.Min(0, obstacles.clearance(pose.X, pose.Y, 0))
+	r := rollout{cmd: cmd, end: pose, clearance: math.Inf(1), poses: make([]Pose2D, 0, steps)}
+	for k := 1; k <= steps; k++ {
+		r.end = Integrate(r.end, cmd, dt)
+		r.poses = append(r.poses, r.end)
+		r.clearance = math.Min(r.clearance, obstacles.clearance(r.end.X, r.end.Y, float64(k)*dt))
+		if r.clearance <= 0 && r.clearance < limit {
+			return r, false
+		}
+	}
+	if limit < 0 {
+		return r, true
+	}
+	return r, math.Abs(cmd.Linear) <= math.Sqrt(2*r.clearance*d.cfg.MaxAccel)
+}
+
+// Integrate advances a pose under a constant command for dt seconds.
+func Integrate(p Pose2D, cmd Velocity, dt float64) Pose2D {
+	if math.Abs(cmd.Angular) < 1e-9 {
+		sin, cos := math.Sincos(p.Yaw)
+		return Pose2D{X: p.X + cmd.Linear*dt*cos, Y: p.Y + cmd.Linear*dt*sin, Yaw: p.Yaw}
+	}
+	yaw := p.Yaw + cmd.Angular*dt
+	r := cmd.Linear / cmd.Angular
+	return Pose2D{
+		X:   p.X + r*(math.Sin(yaw)-math.Sin(p.Yaw)),
+		Y:   p.Y - r*(math.Cos(yaw)-math.Cos(p.Yaw)),
+		Yaw: normalizeAngle(yaw),
+	}
+}
+
+// samples returns evenly spaced values covering [lo, hi], including both
+// ends.
+func samples(lo, hi, step float64) []float64 {
+	if hi <= lo {
+		return []float64{lo}
+	}
+	n := int(math.Max(1, math.Ceil((hi-lo)/step-1e-9)))
+	values := make([]float64, n+1)
+	for i := range values {
+		values[i] = lo + (hi-lo)*float64(i)/float64(n)
+	}
+	return values
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
+
+func normalizeAngle(a float64) float64 {
+	return math.Remainder(a, 2*math.Pi)
+}
@@ -0,0 +1,456 @@
+// Package navigation moves Hunoid bases through mapped space. A 2D
+// occupancy grid is built from 360° perception scans with log-odds
+// updates; A* or D* Lite plans a global path over it, inflated by the
+// robot's radius; and a dynamic-window local planner follows the path
+// while keeping clear of tracked objects on the move. Maps are saved and
+// loaded in the ROS map_server PGM+YAML format.
+//
+// Positions are in meters and headings in radians, in the map frame: x
+// east, y north, yaw counter-clockwise from x.
+package navigation
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/asgard/pandora/internal/robotics/perception"
+)
+
+// ErrOutsideMap is returned for positions beyond the grid.
+var ErrOutsideMap = errors.New("position outside the map")
+
+// Cell indexes the grid, x along columns and y along rows from the
+// origin corner.
+type Cell struct {
+	X, Y int
+}
+
+// Pose2D is a planar pose.
+type Pose2D struct {
+	X, Y float64
+	Yaw  float64
+}
+
+// CellState classifies a cell by its occupancy probability.
+type CellState int
+
+const (
+	Unknown CellState = iota
+	Free
+	Occupied
+)
+
+// GridConfig sizes the grid and tunes scan integration.
+type GridConfig struct {
+	Resolution float64 // meters per cell
+	Width      int     // cells
+	Height     int     // cells
+	OriginX    float64 // Map position of the corner of cell (0, 0)
+	OriginY    float64
+
+	// Log-odds added per observation, clamped to [MinLogOdds, MaxLogOdds]
+	// so cells stay responsive to change
+	HitLogOdds  float64
+	MissLogOdds float64
+	MinLogOdds  float64
+	MaxLogOdds  float64
+
+	OccupiedThreshold float64 // Probability above which a cell is occupied
+	FreeThreshold     float64 // Probability below which a cell is free
+
+	MaxRange     float64 // Scan objects farther than this are ignored, meters
+	ObjectRadius float64 // Footprint of objects without a bounding box, meters
+	MovingSpeed  float64 // Objects at least this fast are not mapped, m/s
+}
+
+// DefaultGridConfig covers 40 m × 40 m at 5 cm centred on the map origin,
+// with map_server's occupancy thresholds.
+func DefaultGridConfig() GridConfig {
+	return GridConfig{
+		Resolution:        0.05,
+		Width:             800,
+		Height:            800,
+		OriginX:           -20,
+		OriginY:           -20,
+		HitLogOdds:        0.85, // p = 0.7
+		MissLogOdds:       -0.4, // p = 0.4
+		MinLogOdds:        -2.0, // p = 0.12
+		MaxLogOdds:        3.5,  // p = 0.97
+		OccupiedThreshold: 0.65,
+		FreeThreshold:     0.196,
+		MaxRange:          20,
+		ObjectRadius:      0.15,
+		MovingSpeed:       0.2,
+	}
+}
+
+// maxClearance caps the clearance field, meters.
+const maxClearance = 3.0
+
+// OccupancyGrid is a log-odds occupancy map. It is safe for concurrent
+// use.
+type OccupancyGrid struct {
+	mu      sync.RWMutex
+	cfg     GridConfig
+	logOdds []float64
+	version uint64
+
+	clearance        []float64 // Distance to the nearest occupied cell, meters
+	clearanceVersion uint64
+}
+
+// NewOccupancyGrid returns a grid with every cell unknown.
+func NewOccupancyGrid(cfg GridConfig) *OccupancyGrid {
+	return &OccupancyGrid{
+		cfg:              cfg,
+		logOdds:          make([]float64, cfg.Width*cfg.Height),
+		clearanceVersion: math.MaxUint64,
+	}
+}
+
+// Config returns the grid configuration.
+func (g *OccupancyGrid) Config() GridConfig {
+	return g.cfg
+}
+
+// Size returns the grid dimensions in cells.
+func (g *OccupancyGrid) Size() (width, height int) {
+	return g.cfg.Width, g.cfg.Height
+}
+
+// Version increases whenever a cell changes state.
+func (g *OccupancyGrid) Version() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.version
+}
+
+// WorldToCell returns the cell containing a map position.
+func (g *OccupancyGrid) WorldToCell(x, y float64) (Cell, bool) {
+	c := Cell{
+		X: int(math.Floor((x - g.cfg.OriginX) / g.cfg.Resolution)),
+		Y: int(math.Floor((y - g.cfg.OriginY) / g.cfg.Resolution)),
+	}
+	return c, g.Contains(c)
+}
+
+// CellToWorld returns the map position of a cell's centre.
+func (g *OccupancyGrid) CellToWorld(c Cell) (x, y float64) {
+	return g.cfg.OriginX + (float64(c.X)+0.5)*g.cfg.Resolution,
+		g.cfg.OriginY + (float64(c.Y)+0.5)*g.cfg.Resolution
+}
+
+// Contains reports whether c lies on the grid.
+func (g *OccupancyGrid) Contains(c Cell) bool {
+	return c.X >= 0 && c.Y >= 0 && c.X < g.cfg.Width && c.Y < g.cfg.Height
+}
+
+func (g *OccupancyGrid) index(c Cell) int {
+	return c.Y*g.cfg.Width + c.X
+}
+
+// Probability returns the occupancy probability of a cell; cells off the
+// grid are unknown, at 0.5.
+func (g *OccupancyGrid) Probability(c Cell) float64 {
+	if !g.Contains(c) {
+		return 0.5
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return probability(g.logOdds[g.index(c)])
+}
+
+// State classifies a cell.
+func (g *OccupancyGrid) State(c Cell) CellState {
+	if !g.Contains(c) {
+		return Unknown
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.state(g.logOdds[g.index(c)])
+}
+
+func (g *OccupancyGrid) state(l float64) CellState {
+	p := probability(l)
+	switch {
+	case p > g.cfg.OccupiedThreshold:
+		return Occupied
+	case p < g.cfg.FreeThreshold:
+		return Free
+	}
+	return Unknown
+}
+
+// SetProbability overwrites a cell's occupancy probability, clamped to
+// the configured log-odds range.
+func (g *OccupancyGrid) SetProbability(c Cell, p float64) {
+	if !g.Contains(c) {
+		return
+	}
+	l := math.Log(p / (1 - p))
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.set(c, math.Max(g.cfg.MinLogOdds, math.Min(g.cfg.MaxLogOdds, l)))
+}
+
+// set stores a log-odds value, counting state changes. The caller holds
+// the lock.
+func (g *OccupancyGrid) set(c Cell, l float64) {
+	i := g.index(c)
+	before := g.state(g.logOdds[i])
+	g.logOdds[i] = l
+	if g.state(l) != before {
+		g.version++
+	}
+}
+
+// update adds log-odds evidence to a cell. The caller holds the lock.
+func (g *OccupancyGrid) update(c Cell, delta float64) {
+	if !g.Contains(c) {
+		return
+	}
+	l := g.logOdds[g.index(c)] + delta
+	g.set(c, math.Max(g.cfg.MinLogOdds, math.Min(g.cfg.MaxLogOdds, l)))
+}
+
+// InsertRay integrates one range observation from the sensor at (x0, y0)
+// to (x1, y1): cells along the ray are observed free, and the end cell
+// occupied if hit is set.
+func (g *OccupancyGrid) InsertRay(x0, y0, x1, y1 float64, hit bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.insertRay(x0, y0, x1, y1, hit, nil)
+}
+
+// insertRay marks cells from the sensor towards the end free, stopping
+// at the end cell or the first cell in stop. The caller holds the lock.
+func (g *OccupancyGrid) insertRay(x0, y0, x1, y1 float64, hit bool, stop map[Cell]bool) {
+	from, _ := g.WorldToCell(x0, y0)
+	to, _ := g.WorldToCell(x1, y1)
+	traverse(from, to, func(c Cell) bool {
+		if c == to || stop[c] {
+			return false
+		}
+		g.update(c, g.cfg.MissLogOdds)
+		return true
+	})
+	if hit {
+		g.update(to, g.cfg.HitLogOdds)
+	}
+}
+
+// traverse visits the cells on the line from a to b with Bresenham's
+// algorithm until visit returns false.
+func traverse(a, b Cell, visit func(Cell) bool) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	err := dx + dy
+	c := a
+	for {
+		if !visit(c) || c == b {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			c.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			c.Y += sy
+		}
+	}
+}
+
+// InsertScan integrates the objects of a 360° scan taken by a sensor at
+// pose. Object positions are relative to the sensor, x forward. Each
+// object clears the cells between the sensor and itself; objects slower
+// than MovingSpeed mark their footprint occupied, while faster ones are
+// left to the local planner.
+func (g *OccupancyGrid) InsertScan(sensor Pose2D, scan *perception.ScanResult360) {
+	if scan == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := range scan.Objects {
+		obj := &scan.Objects[i]
+		if math.Hypot(obj.Position.X, obj.Position.Y) > g.cfg.MaxRange {
+			continue
+		}
+		x, y := sensorToMap(sensor, obj.Position.X, obj.Position.Y)
+		footprint := g.footprint(x, y, footprintRadius(obj, g.cfg.ObjectRadius))
+		g.insertRay(sensor.X, sensor.Y, x, y, false, footprint)
+		if math.Hypot(obj.Velocity.X, obj.Velocity.Y) >= g.cfg.MovingSpeed {
+			continue
+		}
+		for c := range footprint {
+			g.update(c, g.cfg.HitLogOdds)
+		}
+	}
+}
+
+// footprint returns the cells within radius of a map position.
+func (g *OccupancyGrid) footprint(x, y, radius float64) map[Cell]bool {
+	cells := make(map[Cell]bool)
+	centre, _ := g.WorldToCell(x, y)
+	n := int(math.Ceil(radius / g.cfg.Resolution))
+	for dy := -n; dy <= n; dy++ {
+		for dx := -n; dx <= n; dx++ {
+			c := Cell{X: centre.X + dx, Y: centre.Y + dy}
+			cx, cy := g.CellToWorld(c)
+			if g.Contains(c) && math.Hypot(cx-x, cy-y) <= radius+g.cfg.Resolution/2 {
+				cells[c] = true
+			}
+		}
+	}
+	return cells
+}
+
+// footprintRadius returns the planar radius of an object: half the
+// diagonal of its bounding box, or the default radius.
+func footprintRadius(obj *perception.TrackedObject, radius float64) float64 {
+	bb := obj.BoundingBox
+	if bb.Max.X > bb.Min.X && bb.Max.Y > bb.Min.Y {
+		return math.Hypot(bb.Max.X-bb.Min.X, bb.Max.Y-bb.Min.Y) / 2
+	}
+	return radius
+}
+
+func sensorToMap(sensor Pose2D, x, y float64) (float64, float64) {
+	sin, cos := math.Sincos(sensor.Yaw)
+	return sensor.X + cos*x - sin*y, sensor.Y + sin*x + cos*y
+}
+
+// Clearance returns the distance from a map position to the edge of the
+// nearest occupied cell, capped at 3 m.
+func (g *OccupancyGrid) Clearance(x, y float64) float64 {
+	c, ok := g.WorldToCell(x, y)
+	if !ok {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.updateClearance()
+	return g.edgeClearance(g.index(c))
+}
+
+// edgeClearance converts the centre-to-centre distance held in the
+// clearance field to one measured from the obstacle cell's edge. The
+// caller holds the lock.
+func (g *OccupancyGrid) edgeClearance(i int) float64 {
+	return math.Max(0, g.clearance[i]-g.cfg.Resolution/2)
+}
+
+// updateClearance recomputes the clearance field after the map changes,
+// growing outwards from every occupied cell and keeping, per cell, the
+// distance to the nearest source found. The caller holds the lock.
+func (g *OccupancyGrid) updateClearance() {
+	if g.clearanceVersion == g.version {
+		return
+	}
+	g.clearanceVersion = g.version
+	n := len(g.logOdds)
+	if g.clearance == nil {
+		g.clearance = make([]float64, n)
+	}
+	source := make([]int32, n)
+	var queue []int32
+	for i := range g.logOdds {
+		if g.state(g.logOdds[i]) == Occupied {
+			g.clearance[i] = 0
+			source[i] = int32(i)
+			queue = append(queue, int32(i))
+		} else {
+			g.clearance[i] = maxClearance
+			source[i] = -1
+		}
+	}
+	w := g.cfg.Width
+	for head := 0; head < len(queue); head++ {
+		i := int(queue[head])
+		x, y := i%w, i/w
+		s := int(source[i])
+		sx, sy := s%w, s/w
+		for _, d := range neighbours {
+			nx, ny := x+d.X, y+d.Y
+			if nx < 0 || ny < 0 || nx >= w || ny >= g.cfg.Height {
+				continue
+			}
+			j := ny*w + nx
+			dist := math.Hypot(float64(nx-sx), float64(ny-sy)) * g.cfg.Resolution
+			if dist < g.clearance[j] {
+				g.clearance[j] = dist
+				source[j] = int32(s)
+				queue = append(queue, int32(j))
+			}
+		}
+	}
+}
+
+// neighbours are the 8-connected cell offsets.
+var neighbours = []Cell{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{1, 1}, {1, -1}, {-1, 1}, {-1, -1},
+}
+
+func probability(l float64) float64 {
+	return 1 - 1/(1+math.Exp(l))
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Costmap is a snapshot of the cells a round robot cannot be centred in:
+// occupied cells inflated by its radius, and optionally unknown cells.
+type Costmap struct {
+	width, height int
+	blocked       []bool
+}
+
+// Costmap snapshots the grid for a robot of the given radius.
+func (g *OccupancyGrid) Costmap(radius float64, unknownBlocked bool) *Costmap {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.updateClearance()
+	m := &Costmap{width: g.cfg.Width, height: g.cfg.Height, blocked: make([]bool, len(g.logOdds))}
+	for i, l := range g.logOdds {
+		m.blocked[i] = g.edgeClearance(i) < radius || (unknownBlocked && g.state(l) == Unknown)
+	}
+	return m
+}
+
+// Size returns the costmap dimensions in cells.
+func (m *Costmap) Size() (width, height int) {
+	return m.width, m.height
+}
+
+// Blocked reports whether c is off the map or too close to an obstacle.
+func (m *Costmap) Blocked(c Cell) bool {
+	if c.X < 0 || c.Y < 0 || c.X >= m.width || c.Y >= m.height {
+		return true
+	}
+	return m.blocked[c.Y*m.width+c.X]
+}
+
+// Diff returns the cells blocked in one costmap and not the other.
+func (m *Costmap) Diff(other *Costmap) []Cell {
+	var cells []Cell
+	for i := range m.blocked {
+		if m.blocked[i] != other.blocked[i] {
+			cells = append(cells, Cell{X: i % m.width, Y: i / m.width})
+		}
+	}
+	return cells
+}
@@ -0,0 +1,249 @@
+package navigation
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PGM pixel values written by map_saver.
+const (
+	pixelFree     = 254
+	pixelOccupied = 0
+	pixelUnknown  = 205
+)
+
+// SaveMap writes the grid as a map_server map: a YAML metadata file at
+// yamlPath and a trinary PGM image beside it with the same base name.
+func SaveMap(yamlPath string, g *OccupancyGrid) error {
+	cfg := g.Config()
+	imagePath := strings.TrimSuffix(yamlPath, filepath.Ext(yamlPath)) + ".pgm"
+
+	var img bytes.Buffer
+	fmt.Fprintf(&img, "P5\n# CREATOR: pandora navigation %.3f m/pix\n%d %d\n255\n", cfg.Resolution, cfg.Width, cfg.Height)
+	g.mu.RLock()
+	// Image rows run top to bottom, so the first row is the highest y.
+	for y := cfg.Height - 1; y >= 0; y-- {
+		for x := 0; x < cfg.Width; x++ {
+			switch g.state(g.logOdds[y*cfg.Width+x]) {
+			case Free:
+				img.WriteByte(pixelFree)
+			case Occupied:
+				img.WriteByte(pixelOccupied)
+			default:
+				img.WriteByte(pixelUnknown)
+			}
+		}
+	}
+	g.mu.RUnlock()
+	if err := os.WriteFile(imagePath, img.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write map image: %w", err)
+	}
+
+	meta := fmt.Sprintf("image: %s\nmode: trinary\nresolution: %g\norigin: [%g, %g, 0.0]\nnegate: 0\noccupied_thresh: %g\nfree_thresh: %g\n",
+		filepath.Base(imagePath), cfg.Resolution, cfg.OriginX, cfg.OriginY, cfg.OccupiedThreshold, cfg.FreeThreshold)
+	if err := os.WriteFile(yamlPath, []byte(meta), 0o644); err != nil {
+		return fmt.Errorf("failed to write map metadata: %w", err)
+	}
+	return nil
+}
+
+// LoadMap reads a map_server map. Size, resolution and origin come from
+// the file; cfg supplies the scan update parameters for further mapping.
+// The trinary and scale modes are supported.
+func LoadMap(yamlPath string, cfg GridConfig) (*OccupancyGrid, error) {
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map metadata: %w", err)
+	}
+	meta, err := parseMapYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid map metadata %s: %w", yamlPath, err)
+	}
+
+	image := meta.image
+	if !filepath.IsAbs(image) {
+		image = filepath.Join(filepath.Dir(yamlPath), image)
+	}
+	f, err := os.Open(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open map image: %w", err)
+	}
+	defer f.Close()
+	width, height, pixels, err := readPGM(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("invalid map image %s: %w", image, err)
+	}
+
+	cfg.Resolution = meta.resolution
+	cfg.Width, cfg.Height = width, height
+	cfg.OriginX, cfg.OriginY = meta.origin[0], meta.origin[1]
+	g := NewOccupancyGrid(cfg)
+	for row := 0; row < height; row++ {
+		y := height - 1 - row
+		for x := 0; x < width; x++ {
+			occ := float64(255-pixels[row*width+x]) / 255
+			if meta.negate {
+				occ = 1 - occ
+			}
+			var l float64
+			switch {
+			case occ > meta.occupiedThresh:
+				l = cfg.MaxLogOdds
+			case occ < meta.freeThresh:
+				l = cfg.MinLogOdds
+			case meta.mode == "scale":
+				p := math.Max(1e-6, math.Min(1-1e-6, occ))
+				l = math.Log(p / (1 - p))
+			}
+			g.logOdds[y*width+x] = l
+		}
+	}
+	return g, nil
+}
+
+// mapMetadata is the subset of map_server's YAML keys used here.
+type mapMetadata struct {
+	image          string
+	mode           string
+	resolution     float64
+	origin         [3]float64
+	negate         bool
+	occupiedThresh float64
+	freeThresh     float64
+}
+
+// parseMapYAML reads map_server metadata, a flat mapping of scalars plus
+// the origin sequence.
+func parseMapYAML(data []byte) (mapMetadata, error) {
+	meta := mapMetadata{mode: "trinary", occupiedThresh: 0.65, freeThresh: 0.196}
+	seen := make(map[string]bool)
+	for n, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return meta, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "image":
+			meta.image = value
+		case "mode":
+			meta.mode = value
+		case "resolution":
+			meta.resolution, err = strconv.ParseFloat(value, 64)
+		case "negate":
+			var v int
+			v, err = strconv.Atoi(value)
+			meta.negate = v != 0
+		case "occupied_thresh":
+			meta.occupiedThresh, err = strconv.ParseFloat(value, 64)
+		case "free_thresh":
+			meta.freeThresh, err = strconv.ParseFloat(value, 64)
+		case "origin":
+			parts := strings.Split(strings.Trim(value, "[] "), ",")
+			if len(parts) != 3 {
+				return meta, fmt.Errorf("line %d: origin must be [x, y, yaw]", n+1)
+			}
+			for i, p := range parts {
+				if meta.origin[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return meta, fmt.Errorf("line %d: %s: %w", n+1, key, err)
+		}
+	}
+
+	for _, key := range []string{"image", "resolution", "origin"} {
+		if !seen[key] {
+			return meta, fmt.Errorf("missing %s", key)
+		}
+	}
+	if meta.resolution <= 0 {
+		return meta, fmt.Errorf("resolution must be positive")
+	}
+	if meta.origin[2] != 0 {
+		return meta, fmt.Errorf("rotated maps are not supported")
+	}
+	if meta.mode != "trinary" && meta.mode != "scale" {
+		return meta, fmt.Errorf("unsupported mode %q", meta.mode)
+	}
+	return meta, nil
+}
+
+// readPGM reads a binary (P5) 8-bit greyscale image.
+func readPGM(r *bufio.Reader) (width, height int, pixels []byte, err error) {
+	var header [4]int
+	magic, err := pgmToken(r)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if magic != "P5" {
+		return 0, 0, nil, fmt.Errorf("unsupported format %q, want P5", magic)
+	}
+	for i := 1; i < len(header); i++ {
+		tok, err := pgmToken(r)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if header[i], err = strconv.Atoi(tok); err != nil || header[i] <= 0 {
+			return 0, 0, nil, fmt.Errorf("invalid header field %q", tok)
+		}
+	}
+	width, height, maxval := header[1], header[2], header[3]
+	if maxval > 255 {
+		return 0, 0, nil, fmt.Errorf("16-bit images are not supported")
+	}
+	pixels = make([]byte, width*height)
+	if _, err := io.ReadFull(r, pixels); err != nil {
+		return 0, 0, nil, fmt.Errorf("truncated image: %w", err)
+	}
+	if maxval != 255 {
+		for i, p := range pixels {
+			pixels[i] = byte(int(p) * 255 / maxval)
+		}
+	}
+	return width, height, pixels, nil
+}
+
+// pgmToken returns the next header token, skipping whitespace and
+// comments, and consumes the single whitespace byte that ends it.
+func pgmToken(r *bufio.Reader) (string, error) {
+	var tok []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("truncated header: %w", err)
+		}
+		switch {
+		case b == '#' && len(tok) == 0:
+			if _, err := r.ReadString('\n'); err != nil {
+				return "", fmt.Errorf("truncated header: %w", err)
+			}
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			if len(tok) > 0 {
+				return string(tok), nil
+			}
+		default:
+			tok = append(tok, b)
+		}
+	}
+}
@@ -0,0 +1,594 @@
+package navigation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/control"
+	"github.com/asgard/pandora/internal/robotics/perception"
+)
+
+var (
+	// ErrNoGoal is returned when no goal is set.
+	ErrNoGoal = errors.New("no navigation goal")
+	// ErrGoalCancelled is returned by Wait when the goal was cancelled or
+	// replaced.
+	ErrGoalCancelled = errors.New("navigation goal cancelled")
+	// ErrStuck is returned by Wait when the robot stopped making progress.
+	ErrStuck = errors.New("no progress towards goal")
+)
+
+// Planner names a global path planner.
+type Planner string
+
+const (
+	PlannerDStarLite Planner = "dstar-lite"
+	PlannerAStar     Planner = "astar"
+)
+
+// Config tunes the navigator.
+type Config struct {
+	Planner       Planner
+	RobotRadius   float64 // Footprint radius the map is inflated by, meters
+	UnknownIsFree bool    // Plan through unexplored cells
+
+	GoalTolerance     float64       // meters
+	LookaheadDistance float64       // How far along the path the local planner aims, meters
+	StuckTimeout      time.Duration // Give up after this long without progress
+	ObstacleTimeout   time.Duration // Forget moving objects not seen for this long
+
+	DWA DWAConfig
+}
+
+// DefaultConfig returns navigator defaults for a Hunoid base.
+func DefaultConfig() Config {
+	return Config{
+		Planner:           PlannerDStarLite,
+		RobotRadius:       0.3,
+		UnknownIsFree:     true,
+		GoalTolerance:     0.15,
+		LookaheadDistance: 0.8,
+		StuckTimeout:      10 * time.Second,
+		ObstacleTimeout:   time.Second,
+		DWA:               DefaultDWAConfig(),
+	}
+}
+
+// trackedObstacle is a moving object in the map frame as last seen.
+type trackedObstacle struct {
+	MovingObstacle
+	seen time.Time
+}
+
+// Navigator drives a robot to goal poses over an occupancy grid. It
+// implements control.NavigationController. Each control period it
+// repairs the global path if the map changed, aims the dynamic-window
+// planner at a point LookaheadDistance along it, and sends the chosen
+// command: as a velocity if the robot is a control.VelocityController,
+// and otherwise as the pose one period ahead along the chosen arc.
+type Navigator struct {
+	robot control.MotionController
+	grid  *OccupancyGrid
+	cfg   Config
+	dwa   *DWA
+
+	mu       sync.RWMutex
+	goal     *control.Pose
+	target   Pose2D
+	active   bool
+	reached  bool
+	err      error
+	path     []Cell
+	velocity Velocity
+	moving   map[string]trackedObstacle
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewNavigator returns a navigator for robot over grid.
+func NewNavigator(robot control.MotionController, grid *OccupancyGrid, cfg Config) *Navigator {
+	return &Navigator{
+		robot:  robot,
+		grid:   grid,
+		cfg:    cfg,
+		dwa:    NewDWA(cfg.DWA),
+		moving: make(map[string]trackedObstacle),
+	}
+}
+
+// Map returns the navigator's occupancy grid.
+func (n *Navigator) Map() *OccupancyGrid {
+	return n.grid
+}
+
+// SetGoal plans a path to goal and starts driving along it, replacing
+// any current goal. Only the goal's x, y and heading are used; the
+// heading is not enforced on arrival. Navigation stops when ctx is done.
+func (n *Navigator) SetGoal(ctx context.Context, goal control.Pose) error {
+	n.CancelGoal()
+
+	pose, err := n.pose()
+	if err != nil {
+		return err
+	}
+	target := Pose2D{X: goal.Position.X, Y: goal.Position.Y, Yaw: yawOf(goal.Orientation)}
+	goalCell, ok := n.grid.WorldToCell(target.X, target.Y)
+	if !ok {
+		return fmt.Errorf("goal (%.2f, %.2f): %w", target.X, target.Y, ErrOutsideMap)
+	}
+	p := newPathPlanner(n.grid, n.cfg, goalCell)
+	path, err := p.plan(pose)
+	if err != nil {
+		return fmt.Errorf("failed to plan to (%.2f, %.2f): %w", target.X, target.Y, err)
+	}
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	n.mu.Lock()
+	n.goal = &goal
+	n.target = target
+	n.active, n.reached, n.err = true, false, nil
+	n.path = path
+	n.velocity = Velocity{}
+	n.cancel, n.done = cancel, done
+	n.mu.Unlock()
+
+	log.Printf("[Navigator] Goal (%.2f, %.2f): %d cell path via %s", target.X, target.Y, len(path), n.cfg.Planner)
+	go n.run(loopCtx, p, done)
+	return nil
+}
+
+// GetCurrentGoal returns the goal being driven to or last reached.
+func (n *Navigator) GetCurrentGoal() (control.Pose, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.goal == nil {
+		return control.Pose{}, ErrNoGoal
+	}
+	return *n.goal, nil
+}
+
+// CancelGoal stops the robot and abandons the current goal.
+func (n *Navigator) CancelGoal() error {
+	n.mu.Lock()
+	cancel, done := n.cancel, n.done
+	n.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	return nil
+}
+
+// IsGoalReached reports whether the robot arrived at the current goal.
+func (n *Navigator) IsGoalReached() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.reached
+}
+
+// GetNavigationStatus reports progress towards the goal. The estimated
+// time assumes the remaining path is driven at full speed.
+func (n *Navigator) GetNavigationStatus() control.NavigationStatus {
+	pose, _ := n.pose()
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	status := control.NavigationStatus{Active: n.active}
+	if n.goal == nil {
+		return status
+	}
+	status.DistanceToGoal = math.Hypot(n.target.X-pose.X, n.target.Y-pose.Y)
+	if n.active {
+		remaining := n.remaining(pose)
+		status.EstimatedTime = time.Duration(remaining / n.cfg.DWA.MaxSpeed * float64(time.Second))
+		sin, cos := math.Sincos(pose.Yaw)
+		status.CurrentVelocity = control.Vector3{X: n.velocity.Linear * cos, Y: n.velocity.Linear * sin}
+	}
+	return status
+}
+
+// Wait blocks until the current goal is reached, abandoned or ctx is
+// done, returning why navigation ended.
+func (n *Navigator) Wait(ctx context.Context) error {
+	n.mu.RLock()
+	done := n.done
+	n.mu.RUnlock()
+	if done == nil {
+		return ErrNoGoal
+	}
+	select {
+	case <-done:
+		return n.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err returns why the last goal ended unsuccessfully, or nil.
+func (n *Navigator) Err() error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.err
+}
+
+// Path returns the current global path as map positions, each headed
+// towards the next.
+func (n *Navigator) Path() []Pose2D {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	path := make([]Pose2D, len(n.path))
+	for i, c := range n.path {
+		path[i].X, path[i].Y = n.grid.CellToWorld(c)
+		if i > 0 {
+			path[i-1].Yaw = math.Atan2(path[i].Y-path[i-1].Y, path[i].X-path[i-1].X)
+			path[i].Yaw = path[i-1].Yaw
+		}
+	}
+	return path
+}
+
+// UpdateScan integrates a 360° scan taken at the robot's current pose
+// into the map and refreshes the moving objects the local planner
+// avoids. Object positions and velocities are relative to the robot.
+func (n *Navigator) UpdateScan(scan *perception.ScanResult360) error {
+	if scan == nil {
+		return nil
+	}
+	pose, err := n.pose()
+	if err != nil {
+		return err
+	}
+	n.grid.InsertScan(pose, scan)
+
+	seen := scan.Timestamp
+	if seen.IsZero() {
+		seen = time.Now()
+	}
+	gridCfg := n.grid.Config()
+	sin, cos := math.Sincos(pose.Yaw)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := range scan.Objects {
+		obj := &scan.Objects[i]
+		if math.Hypot(obj.Velocity.X, obj.Velocity.Y) < gridCfg.MovingSpeed {
+			continue
+		}
+		x, y := sensorToMap(pose, obj.Position.X, obj.Position.Y)
+		n.moving[obj.ID] = trackedObstacle{
+			MovingObstacle: MovingObstacle{
+				ID:     obj.ID,
+				X:      x,
+				Y:      y,
+				VX:     cos*obj.Velocity.X - sin*obj.Velocity.Y,
+				VY:     sin*obj.Velocity.X + cos*obj.Velocity.Y,
+				Radius: footprintRadius(obj, gridCfg.ObjectRadius),
+			},
+			seen: seen,
+		}
+	}
+	return nil
+}
+
+// ScanSource provides the robot's latest 360° scan, with object positions
+// and velocities relative to the robot.
+type ScanSource interface {
+	GetScan() (*perception.ScanResult360, error)
+}
+
+// FollowScans polls source every period and feeds each new scan to
+// UpdateScan until ctx is done. Failures are logged once until the source
+// recovers.
+func (n *Navigator) FollowScans(ctx context.Context, source ScanSource, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	var last time.Time
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		scan, err := source.GetScan()
+		if err == nil && scan != nil && !scan.Timestamp.IsZero() && !scan.Timestamp.After(last) {
+			continue // Already integrated
+		}
+		if err == nil {
+			err = n.UpdateScan(scan)
+		}
+		if err != nil {
+			if !failing {
+				log.Printf("[Navigator] Scan update failed: %v", err)
+			}
+			failing = true
+			continue
+		}
+		if failing {
+			log.Printf("[Navigator] Scan updates resumed")
+		}
+		failing = false
+		if scan != nil {
+			last = scan.Timestamp
+		}
+	}
+}
+
+// run is the control loop for one goal.
+func (n *Navigator) run(ctx context.Context, p *pathPlanner, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(n.cfg.DWA.Period)
+	defer ticker.Stop()
+
+	best := math.Inf(1)
+	lastProgress := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			n.finish(false, ErrGoalCancelled)
+			return
+		case <-ticker.C:
+		}
+
+		pose, err := n.pose()
+		if err != nil {
+			n.finish(false, err)
+			return
+		}
+		n.mu.RLock()
+		target := n.target
+		n.mu.RUnlock()
+		dist := math.Hypot(target.X-pose.X, target.Y-pose.Y)
+		if dist <= n.cfg.GoalTolerance {
+			log.Printf("[Navigator] Goal (%.2f, %.2f) reached", target.X, target.Y)
+			n.finish(true, nil)
+			return
+		}
+		if dist < best-0.05 {
+			best, lastProgress = dist, time.Now()
+		} else if time.Since(lastProgress) > n.cfg.StuckTimeout {
+			n.finish(false, fmt.Errorf("%.2f m from goal after %v: %w", dist, n.cfg.StuckTimeout, ErrStuck))
+			return
+		}
+
+		if p.stale() {
+			path, err := p.plan(pose)
+			if err != nil {
+				// Keep the old path; the map may clear or the robot may
+				// come unstuck before the timeout.
+				log.Printf("[Navigator] Replanning failed: %v", err)
+			} else {
+				n.mu.Lock()
+				n.path = path
+				n.mu.Unlock()
+			}
+		}
+
+		carrotX, carrotY := n.carrot(pose)
+		cmd, ok := n.dwa.Plan(pose, n.currentVelocity(), carrotX, carrotY, n.obstacles())
+		if !ok {
+			cmd = Velocity{}
+		}
+		// Slow along the same arc so the robot can brake at the goal.
+		if limit := math.Sqrt(2 * n.cfg.DWA.MaxAccel * dist); math.Abs(cmd.Linear) > limit {
+			scale := limit / math.Abs(cmd.Linear)
+			cmd.Linear *= scale
+			cmd.Angular *= scale
+		}
+		if err := n.command(ctx, pose, cmd); err != nil {
+			if ctx.Err() != nil {
+				n.finish(false, ErrGoalCancelled)
+				return
+			}
+			n.finish(false, fmt.Errorf("failed to command robot: %w", err))
+			return
+		}
+		n.mu.Lock()
+		n.velocity = cmd
+		n.mu.Unlock()
+	}
+}
+
+// finish stops the robot and records how the goal ended.
+func (n *Navigator) finish(reached bool, err error) {
+	if err := n.stop(); err != nil {
+		log.Printf("[Navigator] Failed to stop robot: %v", err)
+	}
+	if err != nil && !errors.Is(err, ErrGoalCancelled) {
+		log.Printf("[Navigator] Goal abandoned: %v", err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.active, n.reached, n.err = false, reached, err
+	n.velocity = Velocity{}
+	n.cancel = nil
+}
+
+func (n *Navigator) stop() error {
+	if v, ok := n.robot.(control.VelocityController); ok {
+		if err := v.SetVelocity(context.Background(), 0, 0); err != nil {
+			return err
+		}
+	}
+	return n.robot.Stop()
+}
+
+// command sends a velocity, or the pose it leads to after one period.
+func (n *Navigator) command(ctx context.Context, pose Pose2D, cmd Velocity) error {
+	if v, ok := n.robot.(control.VelocityController); ok {
+		return v.SetVelocity(ctx, cmd.Linear, cmd.Angular)
+	}
+	next := Integrate(pose, cmd, n.cfg.DWA.Period.Seconds())
+	return n.robot.MoveTo(ctx, control.Pose{
+		Position:    control.Vector3{X: next.X, Y: next.Y},
+		Orientation: quaternionOf(next.Yaw),
+		Timestamp:   time.Now().UTC(),
+	})
+}
+
+func (n *Navigator) currentVelocity() Velocity {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.velocity
+}
+
+// carrot returns the point LookaheadDistance along the path beyond the
+// path cell nearest the robot, or the goal if that is closer.
+func (n *Navigator) carrot(pose Pose2D) (float64, float64) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	nearest := n.nearest(pose)
+	for _, c := range n.path[nearest:] {
+		x, y := n.grid.CellToWorld(c)
+		if math.Hypot(x-pose.X, y-pose.Y) >= n.cfg.LookaheadDistance {
+			return x, y
+		}
+	}
+	return n.target.X, n.target.Y
+}
+
+// nearest returns the index of the path cell closest to pose. The caller
+// holds the lock.
+func (n *Navigator) nearest(pose Pose2D) int {
+	best, index := math.Inf(1), 0
+	for i, c := range n.path {
+		x, y := n.grid.CellToWorld(c)
+		if d := math.Hypot(x-pose.X, y-pose.Y); d < best {
+			best, index = d, i
+		}
+	}
+	return index
+}
+
+// remaining returns the path length left from pose. The caller holds the
+// lock.
+func (n *Navigator) remaining(pose Pose2D) float64 {
+	if len(n.path) == 0 {
+		return math.Hypot(n.target.X-pose.X, n.target.Y-pose.Y)
+	}
+	i := n.nearest(pose)
+	x, y := n.grid.CellToWorld(n.path[i])
+	length := math.Hypot(x-pose.X, y-pose.Y)
+	for ; i+1 < len(n.path); i++ {
+		x0, y0 := n.grid.CellToWorld(n.path[i])
+		x1, y1 := n.grid.CellToWorld(n.path[i+1])
+		length += math.Hypot(x1-x0, y1-y0)
+	}
+	return length
+}
+
+// obstacles returns what the local planner must avoid now: the mapped
+// obstacles and the moving objects seen recently, advanced to the present.
+func (n *Navigator) obstacles() Obstacles {
+	radius := n.cfg.RobotRadius
+	o := Obstacles{
+		Clearance: func(x, y float64) float64 { return n.grid.Clearance(x, y) - radius },
+	}
+	now := time.Now()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for id, t := range n.moving {
+		age := now.Sub(t.seen)
+		if age > n.cfg.ObstacleTimeout {
+			delete(n.moving, id)
+			continue
+		}
+		m := t.MovingObstacle
+		m.X += m.VX * age.Seconds()
+		m.Y += m.VY * age.Seconds()
+		m.Radius += radius
+		o.Moving = append(o.Moving, m)
+	}
+	return o
+}
+
+func (n *Navigator) pose() (Pose2D, error) {
+	p, err := n.robot.GetCurrentPose()
+	if err != nil {
+		return Pose2D{}, fmt.Errorf("failed to read robot pose: %w", err)
+	}
+	return Pose2D{X: p.Position.X, Y: p.Position.Y, Yaw: yawOf(p.Orientation)}, nil
+}
+
+// pathPlanner keeps the global plan for one goal in step with the map.
+type pathPlanner struct {
+	grid    *OccupancyGrid
+	cfg     Config
+	goal    Cell
+	costmap *Costmap
+	version uint64
+	dstar   *DStarLite
+}
+
+func newPathPlanner(grid *OccupancyGrid, cfg Config, goal Cell) *pathPlanner {
+	return &pathPlanner{grid: grid, cfg: cfg, goal: goal}
+}
+
+// stale reports whether the map changed since the last plan.
+func (p *pathPlanner) stale() bool {
+	return p.costmap == nil || p.grid.Version() != p.version
+}
+
+// plan returns a path from pose to the goal over the current map. A
+// robot already inside the inflated obstacles plans from the nearest
+// cell outside them.
+func (p *pathPlanner) plan(pose Pose2D) ([]Cell, error) {
+	start, ok := p.grid.WorldToCell(pose.X, pose.Y)
+	if !ok {
+		return nil, fmt.Errorf("robot at (%.2f, %.2f): %w", pose.X, pose.Y, ErrOutsideMap)
+	}
+	version := p.grid.Version()
+	costmap := p.grid.Costmap(p.cfg.RobotRadius, !p.cfg.UnknownIsFree)
+	start = escape(costmap, start, int(math.Ceil(p.cfg.RobotRadius/p.grid.Config().Resolution))+1)
+
+	if p.cfg.Planner == PlannerAStar {
+		p.costmap, p.version = costmap, version
+		return AStar(costmap, start, p.goal)
+	}
+	if p.dstar == nil {
+		p.dstar = NewDStarLite(costmap, start, p.goal)
+	} else {
+		p.dstar.MoveStart(start)
+		p.dstar.UpdateCosts(costmap, costmap.Diff(p.costmap))
+	}
+	p.costmap, p.version = costmap, version
+	return p.dstar.Plan()
+}
+
+// escape returns c if it is free, or else the nearest free cell within
+// limit cells of it.
+func escape(m *Costmap, c Cell, limit int) Cell {
+	if !m.Blocked(c) {
+		return c
+	}
+	best, bestDist := c, math.Inf(1)
+	for dy := -limit; dy <= limit; dy++ {
+		for dx := -limit; dx <= limit; dx++ {
+			n := Cell{X: c.X + dx, Y: c.Y + dy}
+			if d := math.Hypot(float64(dx), float64(dy)); d < bestDist && !m.Blocked(n) {
+				best, bestDist = n, d
+			}
+		}
+	}
+	return best
+}
+
+// yawOf returns the heading of an orientation about the vertical axis.
+func yawOf(q control.Quaternion) float64 {
+	if q == (control.Quaternion{}) {
+		return 0
+	}
+	return math.Atan2(2*(q.W*q.Z+q.X*q.Y), 1-2*(q.Y*q.Y+q.Z*q.Z))
+}
+
+func quaternionOf(yaw float64) control.Quaternion {
+	sin, cos := math.Sincos(yaw / 2)
+	return control.Quaternion{W: cos, Z: sin}
+}
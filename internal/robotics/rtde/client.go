@@ -0,0 +1,460 @@
+package rtde
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort is the RTDE port of a UR controller.
+	DefaultPort = 30004
+	// DefaultScriptPort is the secondary interface, which accepts URScript
+	// programs.
+	DefaultScriptPort = 30002
+	// ProtocolVersion is the RTDE protocol version the client speaks.
+	ProtocolVersion = 2
+)
+
+// Safety modes reported in the safety_mode output.
+const (
+	SafetyNormal         int32 = 1
+	SafetyReduced        int32 = 2
+	SafetyProtectiveStop int32 = 3
+	SafetyRecovery       int32 = 4
+	SafetySafeguardStop  int32 = 5
+	SafetySystemEStop    int32 = 6
+	SafetyRobotEStop     int32 = 7
+	SafetyViolation      int32 = 8
+	SafetyFault          int32 = 9
+)
+
+// RuntimePlaying is the runtime_state of a running URScript program.
+const RuntimePlaying uint32 = 2
+
+var (
+	// ErrProtocolVersion is returned when the controller rejects the
+	// protocol version.
+	ErrProtocolVersion = errors.New("rtde: protocol version rejected")
+	// ErrVariableNotFound is returned when a recipe names a variable the
+	// controller does not provide.
+	ErrVariableNotFound = errors.New("rtde: variable not found")
+	// ErrInputInUse is returned when another client already writes an
+	// input register.
+	ErrInputInUse = errors.New("rtde: input in use")
+	// ErrNotStarted is returned when the controller refuses to start
+	// synchronization.
+	ErrNotStarted = errors.New("rtde: synchronization not started")
+	// ErrNoState is returned before the first data package arrives.
+	ErrNoState = errors.New("rtde: no state received")
+	// ErrStaleState is returned when data packages stop arriving while the
+	// connection is still open.
+	ErrStaleState = errors.New("rtde: state is stale")
+	// ErrClosed is returned after the connection is lost or closed.
+	ErrClosed = errors.New("rtde: connection closed")
+)
+
+// Outputs is the output recipe the client subscribes to.
+var Outputs = []string{
+	"timestamp",
+	"actual_q",
+	"actual_TCP_pose",
+	"actual_TCP_force",
+	"robot_mode",
+	"safety_mode",
+	"runtime_state",
+}
+
+// Input registers 24 to 47 are reserved for external RTDE clients; the
+// servo loop reads its mode, watchdog tick and joint setpoint from them.
+const (
+	servoModeIndex     = 24
+	servoTickIndex     = 25
+	servoFirstRegister = 24 // input_double_register_24 to 29
+
+	servoModeRegister = "input_int_register_24"
+	servoTickRegister = "input_int_register_25"
+)
+
+// Servo modes written to servoModeRegister.
+const (
+	servoIdle  int32 = 0
+	servoTrack int32 = 1
+)
+
+// Inputs is the input recipe the client writes setpoints through.
+var Inputs = func() []string {
+	names := []string{servoModeRegister, servoTickRegister}
+	for i := 0; i < 6; i++ {
+		names = append(names, "input_double_register_"+strconv.Itoa(servoFirstRegister+i))
+	}
+	return names
+}()
+
+// Config holds RTDE client configuration.
+type Config struct {
+	Address     string
+	Port        int     // RTDE port, 30004
+	ScriptPort  int     // Secondary interface for the servo program, 30002
+	Frequency   float64 // Output rate in Hz; 125 on CB3, up to 500 on e-Series
+	DialTimeout time.Duration
+
+	// Servo loop tuning, passed to servoj
+	ServoGain      float64 // Proportional gain, 100 to 2000
+	ServoLookahead float64 // seconds, 0.03 to 0.2
+	// WatchdogTimeout stops the arm when setpoints stop arriving for this
+	// long while tracking.
+	WatchdogTimeout time.Duration
+	// SettleTolerance is how close, in radians per joint, ServoTo waits for
+	// the arm to come to the goal.
+	SettleTolerance float64
+	SettleTimeout   time.Duration
+	// StaleCycles is how many control cycles may pass without a data
+	// package before State reports the last one as stale.
+	StaleCycles int
+}
+
+// DefaultConfig returns the configuration for a controller at address.
+func DefaultConfig(address string) Config {
+	return Config{
+		Address:         address,
+		Port:            DefaultPort,
+		ScriptPort:      DefaultScriptPort,
+		Frequency:       125,
+		DialTimeout:     5 * time.Second,
+		ServoGain:       300,
+		ServoLookahead:  0.1,
+		WatchdogTimeout: 100 * time.Millisecond,
+		SettleTolerance: 1e-3,
+		SettleTimeout:   2 * time.Second,
+		StaleCycles:     10,
+	}
+}
+
+// ControllerVersion is the software version of the UR controller.
+type ControllerVersion struct {
+	Major, Minor, Bugfix, Build uint32
+}
+
+func (v ControllerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Bugfix, v.Build)
+}
+
+// State is one output data package.
+type State struct {
+	Timestamp      float64    // Controller uptime, seconds
+	JointPositions [6]float64 // radians
+	TCPPose        [6]float64 // x, y, z in meters, then a rotation vector
+	TCPForce       [6]float64 // Newtons and Newton-meters at the TCP
+	RobotMode      int32
+	SafetyMode     int32
+	RuntimeState   uint32
+	Received       time.Time
+}
+
+// SafetyOK reports whether the arm may move: normal or reduced mode.
+func (s State) SafetyOK() bool {
+	return s.SafetyMode == SafetyNormal || s.SafetyMode == SafetyReduced
+}
+
+// Client is an RTDE connection to a UR controller.
+type Client struct {
+	cfg     Config
+	conn    net.Conn
+	version ControllerVersion
+	outputs *Recipe
+	inputs  *Recipe
+
+	writeMu sync.Mutex
+	tick    int32
+	servo   bool // The servo program was the last one sent
+
+	mu       sync.RWMutex
+	state    State
+	hasState bool
+	err      error
+	done     chan struct{}
+}
+
+// Dial connects to the controller, negotiates the protocol version, sets
+// up the output and input recipes and starts synchronization.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	if cfg.ScriptPort == 0 {
+		cfg.ScriptPort = DefaultScriptPort
+	}
+	if cfg.Frequency <= 0 {
+		cfg.Frequency = 125
+	}
+	if cfg.StaleCycles <= 0 {
+		cfg.StaleCycles = DefaultConfig(cfg.Address).StaleCycles
+	}
+	dialer := net.Dialer{Timeout: cfg.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Address, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("rtde dial: %w", err)
+	}
+	c := &Client{cfg: cfg, conn: conn, done: make(chan struct{})}
+	r := bufio.NewReader(conn)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := c.handshake(r); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	go c.readLoop(r)
+	return c, nil
+}
+
+func (c *Client) handshake(r *bufio.Reader) error {
+	var version [2]byte
+	binary.BigEndian.PutUint16(version[:], ProtocolVersion)
+	resp, err := c.request(r, pkgRequestProtocolVersion, version[:])
+	if err != nil {
+		return err
+	}
+	if len(resp) < 1 || resp[0] != 1 {
+		return fmt.Errorf("%w: version %d", ErrProtocolVersion, ProtocolVersion)
+	}
+
+	if resp, err = c.request(r, pkgGetURControlVersion, nil); err != nil {
+		return err
+	}
+	if len(resp) < 16 {
+		return fmt.Errorf("%w: %d byte controller version", ErrFraming, len(resp))
+	}
+	c.version = ControllerVersion{
+		Major:  binary.BigEndian.Uint32(resp[0:4]),
+		Minor:  binary.BigEndian.Uint32(resp[4:8]),
+		Bugfix: binary.BigEndian.Uint32(resp[8:12]),
+		Build:  binary.BigEndian.Uint32(resp[12:16]),
+	}
+
+	// Protocol version 2 prefixes the output variables with the rate
+	setup := binary.BigEndian.AppendUint64(nil, math.Float64bits(c.cfg.Frequency))
+	setup = append(setup, strings.Join(Outputs, ",")...)
+	if resp, err = c.request(r, pkgSetupOutputs, setup); err != nil {
+		return err
+	}
+	if c.outputs, err = parseRecipe(Outputs, resp); err != nil {
+		return err
+	}
+	if err := checkRecipe(c.outputs, "NOT_FOUND", ErrVariableNotFound); err != nil {
+		return err
+	}
+
+	if resp, err = c.request(r, pkgSetupInputs, []byte(strings.Join(Inputs, ","))); err != nil {
+		return err
+	}
+	if c.inputs, err = parseRecipe(Inputs, resp); err != nil {
+		return err
+	}
+	if err := checkRecipe(c.inputs, "NOT_FOUND", ErrVariableNotFound); err != nil {
+		return err
+	}
+	if err := checkRecipe(c.inputs, "IN_USE", ErrInputInUse); err != nil {
+		return err
+	}
+
+	if resp, err = c.request(r, pkgStart, nil); err != nil {
+		return err
+	}
+	if len(resp) < 1 || resp[0] != 1 {
+		return ErrNotStarted
+	}
+	log.Printf("[RTDE] Connected to controller %s at %s, %.0f Hz", c.version, c.cfg.Address, c.cfg.Frequency)
+	return nil
+}
+
+// checkRecipe fails when the controller marked any variable with marker.
+func checkRecipe(recipe *Recipe, marker string, sentinel error) error {
+	var bad []string
+	for i, typ := range recipe.Types {
+		if typ == marker {
+			bad = append(bad, recipe.Names[i])
+		}
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("%w: %s", sentinel, strings.Join(bad, ", "))
+	}
+	if recipe.ID == 0 {
+		return fmt.Errorf("%w: recipe rejected", sentinel)
+	}
+	return nil
+}
+
+// request sends a package and waits for the reply of the same type,
+// logging text messages that arrive in between.
+func (c *Client) request(r *bufio.Reader, typ byte, payload []byte) ([]byte, error) {
+	if err := c.write(typ, payload); err != nil {
+		return nil, fmt.Errorf("rtde request %c: %w", typ, err)
+	}
+	for {
+		got, resp, err := readPackage(r)
+		if err != nil {
+			return nil, fmt.Errorf("rtde response %c: %w", typ, err)
+		}
+		switch got {
+		case typ:
+			return resp, nil
+		case pkgTextMessage:
+			logText(resp)
+		default:
+			return nil, fmt.Errorf("%w: got package %c waiting for %c", ErrFraming, got, typ)
+		}
+	}
+}
+
+func (c *Client) write(typ byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writePackage(c.conn, typ, payload)
+}
+
+// logText logs a version 2 text message: message, source and warning
+// level, the strings prefixed by their uint8 lengths.
+func logText(payload []byte) {
+	var fields []string
+	for i := 0; i < 2 && len(payload) > 0; i++ {
+		n := int(payload[0])
+		if 1+n > len(payload) {
+			break
+		}
+		fields = append(fields, string(payload[1:1+n]))
+		payload = payload[1+n:]
+	}
+	for len(fields) < 2 {
+		fields = append(fields, "")
+	}
+	log.Printf("[RTDE] %s: %s", fields[1], fields[0])
+}
+
+func (c *Client) readLoop(r *bufio.Reader) {
+	defer close(c.done)
+	for {
+		typ, payload, err := readPackage(r)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch typ {
+		case pkgDataPackage:
+			values, err := c.outputs.decode(payload)
+			if err != nil {
+				c.fail(err)
+				return
+			}
+			state := stateFrom(values)
+			c.mu.Lock()
+			c.state, c.hasState = state, true
+			c.mu.Unlock()
+		case pkgTextMessage:
+			logText(payload)
+		}
+	}
+}
+
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	c.mu.Unlock()
+}
+
+func stateFrom(values map[string]interface{}) State {
+	s := State{Received: time.Now()}
+	s.Timestamp, _ = values["timestamp"].(float64)
+	s.JointPositions, _ = values["actual_q"].([6]float64)
+	s.TCPPose, _ = values["actual_TCP_pose"].([6]float64)
+	s.TCPForce, _ = values["actual_TCP_force"].([6]float64)
+	s.RobotMode, _ = values["robot_mode"].(int32)
+	s.SafetyMode, _ = values["safety_mode"].(int32)
+	s.RuntimeState, _ = values["runtime_state"].(uint32)
+	return s
+}
+
+// Version returns the controller software version.
+func (c *Client) Version() ControllerVersion {
+	return c.version
+}
+
+// Frequency returns the negotiated output rate in Hz.
+func (c *Client) Frequency() float64 {
+	return c.cfg.Frequency
+}
+
+// State returns the latest output data package. It fails with
+// ErrStaleState if no package arrived for StaleCycles control cycles, so
+// that servo loops stop rather than act on an old position.
+func (c *Client) State() (State, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.err != nil {
+		return State{}, c.err
+	}
+	if !c.hasState {
+		return State{}, ErrNoState
+	}
+	maxAge := time.Duration(float64(c.cfg.StaleCycles) * float64(time.Second) / c.cfg.Frequency)
+	if age := time.Since(c.state.Received); age > maxAge {
+		return State{}, fmt.Errorf("%w: last data package %v ago", ErrStaleState, age.Round(time.Millisecond))
+	}
+	return c.state, nil
+}
+
+// SetServoTarget writes a joint setpoint for the servo loop to track.
+func (c *Client) SetServoTarget(q [6]float64) error {
+	return c.sendInputs(servoTrack, q)
+}
+
+// Idle tells the servo loop to stop the arm and wait.
+func (c *Client) Idle() error {
+	return c.sendInputs(servoIdle, [6]float64{})
+}
+
+func (c *Client) sendInputs(mode int32, q [6]float64) error {
+	c.mu.RLock()
+	err := c.err
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	// The servo loop stops the arm when the tick stops changing
+	c.tick++
+	values := map[string]interface{}{
+		servoModeRegister: mode,
+		servoTickRegister: c.tick,
+	}
+	for i, v := range q {
+		values["input_double_register_"+strconv.Itoa(servoFirstRegister+i)] = v
+	}
+	payload, err := c.inputs.encode(values)
+	if err != nil {
+		return err
+	}
+	return writePackage(c.conn, pkgDataPackage, payload)
+}
+
+// Close pauses synchronization and closes the connection.
+func (c *Client) Close() error {
+	c.write(pkgPause, nil)
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
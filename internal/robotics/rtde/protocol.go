@@ -0,0 +1,292 @@
+// Package rtde implements the Universal Robots Real-Time Data Exchange
+// protocol (port 30004): protocol version negotiation, output and input
+// recipes, and the data packages streamed between the controller and a
+// client. Motion is commanded through a URScript servo loop that reads
+// setpoints from the RTDE input registers.
+package rtde
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Package types of the RTDE protocol, version 2.
+const (
+	pkgRequestProtocolVersion = 'V'
+	pkgGetURControlVersion    = 'v'
+	pkgTextMessage            = 'M'
+	pkgDataPackage            = 'U'
+	pkgSetupOutputs           = 'O'
+	pkgSetupInputs            = 'I'
+	pkgStart                  = 'S'
+	pkgPause                  = 'P'
+)
+
+// headerSize is the uint16 package size, which includes the header, and
+// the uint8 package type.
+const headerSize = 3
+
+// maxPackageSize bounds incoming packages; the controller never sends
+// more than a few kilobytes.
+const maxPackageSize = 1 << 16
+
+var (
+	// ErrFraming is returned for packages whose size or layout does not
+	// match the protocol.
+	ErrFraming = errors.New("rtde: malformed package")
+	// ErrUnknownType is returned for variable types the client cannot
+	// encode or decode.
+	ErrUnknownType = errors.New("rtde: unknown variable type")
+)
+
+// writePackage frames payload as an RTDE package of the given type.
+func writePackage(w io.Writer, typ byte, payload []byte) error {
+	size := headerSize + len(payload)
+	if size > math.MaxUint16 {
+		return fmt.Errorf("%w: %d byte payload", ErrFraming, len(payload))
+	}
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint16(buf[0:2], uint16(size))
+	buf[2] = typ
+	copy(buf[headerSize:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readPackage reads one RTDE package, returning its type and payload.
+func readPackage(r *bufio.Reader) (byte, []byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	size := int(binary.BigEndian.Uint16(header[0:2]))
+	if size < headerSize || size > maxPackageSize {
+		return 0, nil, fmt.Errorf("%w: size %d", ErrFraming, size)
+	}
+	payload := make([]byte, size-headerSize)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[2], payload, nil
+}
+
+// typeSize returns the encoded size of an RTDE variable type.
+func typeSize(typ string) (int, error) {
+	switch typ {
+	case "BOOL", "UINT8":
+		return 1, nil
+	case "UINT32", "INT32":
+		return 4, nil
+	case "UINT64", "DOUBLE":
+		return 8, nil
+	case "VECTOR3D":
+		return 24, nil
+	case "VECTOR6D":
+		return 48, nil
+	case "VECTOR6INT32", "VECTOR6UINT32":
+		return 24, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownType, typ)
+}
+
+// decodeValue decodes one variable. Vectors decode to fixed-size arrays:
+// [3]float64, [6]float64, [6]int32 and [6]uint32.
+func decodeValue(typ string, b []byte) (interface{}, error) {
+	size, err := typeSize(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < size {
+		return nil, fmt.Errorf("%w: %s needs %d bytes, have %d", ErrFraming, typ, size, len(b))
+	}
+	be := binary.BigEndian
+	switch typ {
+	case "BOOL":
+		return b[0] != 0, nil
+	case "UINT8":
+		return b[0], nil
+	case "UINT32":
+		return be.Uint32(b), nil
+	case "INT32":
+		return int32(be.Uint32(b)), nil
+	case "UINT64":
+		return be.Uint64(b), nil
+	case "DOUBLE":
+		return math.Float64frombits(be.Uint64(b)), nil
+	case "VECTOR3D":
+		var v [3]float64
+		for i := range v {
+			v[i] = math.Float64frombits(be.Uint64(b[8*i:]))
+		}
+		return v, nil
+	case "VECTOR6D":
+		var v [6]float64
+		for i := range v {
+			v[i] = math.Float64frombits(be.Uint64(b[8*i:]))
+		}
+		return v, nil
+	case "VECTOR6INT32":
+		var v [6]int32
+		for i := range v {
+			v[i] = int32(be.Uint32(b[4*i:]))
+		}
+		return v, nil
+	default: // VECTOR6UINT32
+		var v [6]uint32
+		for i := range v {
+			v[i] = be.Uint32(b[4*i:])
+		}
+		return v, nil
+	}
+}
+
+// appendValue appends the encoding of v as typ to b.
+func appendValue(b []byte, typ string, v interface{}) ([]byte, error) {
+	be := binary.BigEndian
+	mismatch := fmt.Errorf("rtde: %T is not a %s", v, typ)
+	switch typ {
+	case "BOOL":
+		x, ok := v.(bool)
+		if !ok {
+			return nil, mismatch
+		}
+		if x {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case "UINT8":
+		x, ok := v.(uint8)
+		if !ok {
+			return nil, mismatch
+		}
+		return append(b, x), nil
+	case "UINT32":
+		x, ok := v.(uint32)
+		if !ok {
+			return nil, mismatch
+		}
+		return be.AppendUint32(b, x), nil
+	case "INT32":
+		x, ok := v.(int32)
+		if !ok {
+			return nil, mismatch
+		}
+		return be.AppendUint32(b, uint32(x)), nil
+	case "UINT64":
+		x, ok := v.(uint64)
+		if !ok {
+			return nil, mismatch
+		}
+		return be.AppendUint64(b, x), nil
+	case "DOUBLE":
+		x, ok := v.(float64)
+		if !ok {
+			return nil, mismatch
+		}
+		return be.AppendUint64(b, math.Float64bits(x)), nil
+	case "VECTOR3D":
+		x, ok := v.([3]float64)
+		if !ok {
+			return nil, mismatch
+		}
+		for _, f := range x {
+			b = be.AppendUint64(b, math.Float64bits(f))
+		}
+		return b, nil
+	case "VECTOR6D":
+		x, ok := v.([6]float64)
+		if !ok {
+			return nil, mismatch
+		}
+		for _, f := range x {
+			b = be.AppendUint64(b, math.Float64bits(f))
+		}
+		return b, nil
+	case "VECTOR6INT32":
+		x, ok := v.([6]int32)
+		if !ok {
+			return nil, mismatch
+		}
+		for _, n := range x {
+			b = be.AppendUint32(b, uint32(n))
+		}
+		return b, nil
+	case "VECTOR6UINT32":
+		x, ok := v.([6]uint32)
+		if !ok {
+			return nil, mismatch
+		}
+		for _, n := range x {
+			b = be.AppendUint32(b, n)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownType, typ)
+}
+
+// Recipe is a negotiated list of variables exchanged in data packages.
+type Recipe struct {
+	ID    uint8
+	Names []string
+	Types []string
+}
+
+// parseRecipe reads a setup response: the recipe ID followed by the
+// comma-separated variable types.
+func parseRecipe(names []string, payload []byte) (*Recipe, error) {
+	if len(payload) < 1 {
+		return nil, fmt.Errorf("%w: empty setup response", ErrFraming)
+	}
+	types := strings.Split(string(payload[1:]), ",")
+	if len(types) != len(names) {
+		return nil, fmt.Errorf("%w: %d types for %d variables", ErrFraming, len(types), len(names))
+	}
+	return &Recipe{ID: payload[0], Names: names, Types: types}, nil
+}
+
+// decode reads the values of a data package payload, recipe ID included.
+func (r *Recipe) decode(payload []byte) (map[string]interface{}, error) {
+	if len(payload) < 1 || payload[0] != r.ID {
+		return nil, fmt.Errorf("%w: data package not for recipe %d", ErrFraming, r.ID)
+	}
+	values := make(map[string]interface{}, len(r.Names))
+	offset := 1
+	for i, typ := range r.Types {
+		size, err := typeSize(typ)
+		if err != nil {
+			return nil, err
+		}
+		v, err := decodeValue(typ, payload[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("rtde variable %s: %w", r.Names[i], err)
+		}
+		values[r.Names[i]] = v
+		offset += size
+	}
+	if offset != len(payload) {
+		return nil, fmt.Errorf("%w: %d trailing bytes in data package", ErrFraming, len(payload)-offset)
+	}
+	return values, nil
+}
+
+// encode builds a data package payload, recipe ID included. Every
+// variable of the recipe must be present.
+func (r *Recipe) encode(values map[string]interface{}) ([]byte, error) {
+	b := []byte{r.ID}
+	for i, name := range r.Names {
+		v, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("rtde: no value for input %s", name)
+		}
+		var err error
+		if b, err = appendValue(b, r.Types[i], v); err != nil {
+			return nil, fmt.Errorf("rtde variable %s: %w", name, err)
+		}
+	}
+	return b, nil
+}
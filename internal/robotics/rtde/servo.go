@@ -0,0 +1,237 @@
+package rtde
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSafetyStop is returned when the controller leaves normal or reduced
+// safety mode during a motion.
+var ErrSafetyStop = errors.New("rtde: arm stopped by safety system")
+
+// ServoScript returns the URScript program that streams the setpoints
+// written by SetServoTarget into servoj. When the client goes idle, or the
+// watchdog tick stops changing for WatchdogTimeout, the arm is stopped.
+func ServoScript(cfg Config) string {
+	period := 1 / cfg.Frequency
+	missed := int(math.Ceil(cfg.WatchdogTimeout.Seconds() / period))
+	if missed < 1 {
+		missed = 1
+	}
+	registers := make([]string, 6)
+	for i := range registers {
+		registers[i] = fmt.Sprintf("read_input_float_register(%d)", servoFirstRegister+i)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "def asgard_servo():\n")
+	fmt.Fprintf(&b, "  last_tick = read_input_integer_register(%d)\n", servoTickIndex)
+	fmt.Fprintf(&b, "  missed = 0\n")
+	fmt.Fprintf(&b, "  moving = False\n")
+	fmt.Fprintf(&b, "  while True:\n")
+	fmt.Fprintf(&b, "    mode = read_input_integer_register(%d)\n", servoModeIndex)
+	fmt.Fprintf(&b, "    tick = read_input_integer_register(%d)\n", servoTickIndex)
+	fmt.Fprintf(&b, "    if tick == last_tick:\n")
+	fmt.Fprintf(&b, "      missed = missed + 1\n")
+	fmt.Fprintf(&b, "    else:\n")
+	fmt.Fprintf(&b, "      missed = 0\n")
+	fmt.Fprintf(&b, "    end\n")
+	fmt.Fprintf(&b, "    last_tick = tick\n")
+	fmt.Fprintf(&b, "    if mode == %d and missed < %d:\n", servoTrack, missed)
+	fmt.Fprintf(&b, "      q = [%s]\n", strings.Join(registers, ", "))
+	fmt.Fprintf(&b, "      servoj(q, t=%.4f, lookahead_time=%.3f, gain=%.0f)\n", period, cfg.ServoLookahead, cfg.ServoGain)
+	fmt.Fprintf(&b, "      moving = True\n")
+	fmt.Fprintf(&b, "    else:\n")
+	fmt.Fprintf(&b, "      if moving:\n")
+	fmt.Fprintf(&b, "        stopj(2.0)\n")
+	fmt.Fprintf(&b, "        moving = False\n")
+	fmt.Fprintf(&b, "      end\n")
+	fmt.Fprintf(&b, "      sync()\n")
+	fmt.Fprintf(&b, "    end\n")
+	fmt.Fprintf(&b, "  end\n")
+	fmt.Fprintf(&b, "end\n")
+	fmt.Fprintf(&b, "asgard_servo()\n")
+	return b.String()
+}
+
+// SendScript sends a URScript program to the controller's secondary
+// interface, replacing any running program.
+func (c *Client) SendScript(ctx context.Context, script string) error {
+	c.writeMu.Lock()
+	c.servo = false
+	c.writeMu.Unlock()
+	return c.sendScript(ctx, script)
+}
+
+func (c *Client) sendScript(ctx context.Context, script string) error {
+	dialer := net.Dialer{Timeout: c.cfg.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Address, strconv.Itoa(c.cfg.ScriptPort)))
+	if err != nil {
+		return fmt.Errorf("urscript dial: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(script)); err != nil {
+		return fmt.Errorf("urscript send: %w", err)
+	}
+	return nil
+}
+
+// StartServo installs the servo program unless it is already running.
+// Sending any other program, such as a gripper command, stops it.
+func (c *Client) StartServo(ctx context.Context) error {
+	c.writeMu.Lock()
+	installed := c.servo
+	c.writeMu.Unlock()
+	if state, err := c.State(); err == nil && installed && state.RuntimeState == RuntimePlaying {
+		return nil
+	}
+	// Go idle first so the new program does not act on a stale setpoint
+	if err := c.Idle(); err != nil {
+		return err
+	}
+	if err := c.sendScript(ctx, ServoScript(c.cfg)); err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	c.servo = true
+	c.writeMu.Unlock()
+
+	// Setpoints sent before the program runs would be dropped
+	deadline := time.Now().Add(c.cfg.SettleTimeout)
+	for {
+		state, err := c.State()
+		if err != nil {
+			return err
+		}
+		if state.RuntimeState == RuntimePlaying {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("rtde: servo program not running after %v", c.cfg.SettleTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(float64(time.Second) / c.cfg.Frequency)):
+		}
+	}
+}
+
+// ServoTo streams a joint trajectory from the current position to goal
+// through the servo loop, one setpoint per control cycle, then waits for
+// the arm to settle. The trajectory is a quintic in time with the peak
+// joint velocity at maxVelocity.
+func (c *Client) ServoTo(ctx context.Context, goal [6]float64, maxVelocity float64) error {
+	if maxVelocity <= 0 {
+		return fmt.Errorf("rtde: max joint velocity must be positive")
+	}
+	state, err := c.State()
+	if err != nil {
+		return err
+	}
+	start := state.JointPositions
+
+	var span float64
+	for i := range goal {
+		span = math.Max(span, math.Abs(goal[i]-start[i]))
+	}
+	// The quintic 10s³ - 15s⁴ + 6s⁵ peaks at 15/8 of the mean velocity
+	duration := time.Duration(1.875 * span / maxVelocity * float64(time.Second))
+	return c.Follow(ctx, duration, func(t time.Duration) [6]float64 {
+		s := 1.0
+		if duration > 0 {
+			s = math.Min(1, float64(t)/float64(duration))
+		}
+		blend := s * s * s * (10 - 15*s + 6*s*s)
+		var q [6]float64
+		for i := range q {
+			q[i] = start[i] + blend*(goal[i]-start[i])
+		}
+		return q
+	})
+}
+
+// Follow streams setpoint(t) through the servo loop for t from one control
+// cycle up to duration, then holds setpoint(duration) until the arm
+// settles. The caller's trajectory must respect the arm's velocity and
+// acceleration limits; servoj tracks it as given.
+func (c *Client) Follow(ctx context.Context, duration time.Duration, setpoint func(t time.Duration) [6]float64) error {
+	state, err := c.State()
+	if err != nil {
+		return err
+	}
+	if !state.SafetyOK() {
+		return fmt.Errorf("%w: safety mode %d", ErrSafetyStop, state.SafetyMode)
+	}
+	if err := c.StartServo(ctx); err != nil {
+		return err
+	}
+
+	period := time.Duration(float64(time.Second) / c.cfg.Frequency)
+	steps := int(math.Ceil(duration.Seconds() / period.Seconds()))
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	stop := func(err error) error {
+		c.Idle()
+		return err
+	}
+	for k := 1; k <= steps; k++ {
+		t := time.Duration(k) * period
+		if t > duration {
+			t = duration
+		}
+		if err := c.SetServoTarget(setpoint(t)); err != nil {
+			return stop(err)
+		}
+		select {
+		case <-ctx.Done():
+			return stop(ctx.Err())
+		case <-ticker.C:
+		}
+		if state, err := c.State(); err != nil {
+			return stop(err)
+		} else if !state.SafetyOK() {
+			return stop(fmt.Errorf("%w: safety mode %d", ErrSafetyStop, state.SafetyMode))
+		}
+	}
+
+	// Hold the goal until the arm settles
+	goal := setpoint(duration)
+	deadline := time.Now().Add(c.cfg.SettleTimeout)
+	for {
+		if err := c.SetServoTarget(goal); err != nil {
+			return stop(err)
+		}
+		state, err := c.State()
+		if err != nil {
+			return stop(err)
+		}
+		if !state.SafetyOK() {
+			return stop(fmt.Errorf("%w: safety mode %d", ErrSafetyStop, state.SafetyMode))
+		}
+		settled := true
+		for i := range goal {
+			if math.Abs(state.JointPositions[i]-goal[i]) > c.cfg.SettleTolerance {
+				settled = false
+				break
+			}
+		}
+		if settled {
+			return c.Idle()
+		}
+		if time.Now().After(deadline) {
+			return stop(fmt.Errorf("rtde: arm did not settle within %v", c.cfg.SettleTimeout))
+		}
+		select {
+		case <-ctx.Done():
+			return stop(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,6 +3,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -113,7 +114,10 @@ func (s *SatelliteTrackingService) GetPosition(ctx context.Context, noradID int)
 
 	// If we have a propagator, use it
 	if exists && propagator != nil {
-		lat, lon, alt := propagator.Propagate(now)
+		lat, lon, alt, err := propagator.Propagate(now)
+		if err != nil {
+			return nil, fmt.Errorf("propagate %d: %w", noradID, err)
+		}
 		return &TrackedSatellite{
 			NoradID:   noradID,
 			Name:      tle.Name,
@@ -142,7 +146,10 @@ func (s *SatelliteTrackingService) GetPosition(ctx context.Context, noradID int)
 	s.propagators[noradID] = propagator
 	s.mu.Unlock()
 
-	lat, lon, alt := propagator.Propagate(now)
+	lat, lon, alt, err := propagator.Propagate(now)
+	if err != nil {
+		return nil, fmt.Errorf("propagate %d: %w", noradID, err)
+	}
 	return &TrackedSatellite{
 		NoradID:   noradID,
 		Name:      tle.Name,
@@ -188,7 +195,11 @@ func (s *SatelliteTrackingService) GetFleetPositions(ctx context.Context) []Trac
 
 	for noradID, propagator := range s.propagators {
 		tle := s.fleetTLEs[noradID]
-		lat, lon, alt := propagator.Propagate(now)
+		lat, lon, alt, err := propagator.Propagate(now)
+		if err != nil {
+			log.Printf("[SatelliteTracking] Skipping %s: %v", tle.Name, err)
+			continue
+		}
 
 		positions = append(positions, TrackedSatellite{
 			NoradID:   noradID,
@@ -235,7 +246,10 @@ func (s *SatelliteTrackingService) GetGroundTrack(ctx context.Context, noradID i
 	}
 
 	now := time.Now().UTC()
-	positions := propagator.PropagateRange(now, duration, time.Minute)
+	positions, err := propagator.PropagateRange(now, duration, time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("ground track for %d: %w", noradID, err)
+	}
 
 	return &GroundTrack{
 		NoradID:   noradID,
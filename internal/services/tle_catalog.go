@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/db"
+	"github.com/asgard/pandora/internal/platform/satellite"
+	"github.com/asgard/pandora/internal/repositories"
+)
+
+// TLECatalogStore persists the satellite catalog's element set history in
+// Postgres.
+type TLECatalogStore struct {
+	repo *repositories.TLECatalogRepository
+}
+
+// NewTLECatalogStore creates a catalog store backed by the repository.
+func NewTLECatalogStore(repo *repositories.TLECatalogRepository) *TLECatalogStore {
+	return &TLECatalogStore{repo: repo}
+}
+
+// SaveElementSet implements satellite.CatalogStore.
+func (s *TLECatalogStore) SaveElementSet(ctx context.Context, es *satellite.ElementSet) (bool, error) {
+	return s.repo.Insert(ctx, toTLEElementSetRecord(es))
+}
+
+// PreviousElementSet implements satellite.CatalogStore.
+func (s *TLECatalogStore) PreviousElementSet(ctx context.Context, noradID int, before time.Time) (*satellite.ElementSet, error) {
+	rec, err := s.repo.GetPrevious(ctx, noradID, before)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, satellite.ErrElementSetNotFound
+	}
+	return fromTLEElementSetRecord(rec), nil
+}
+
+// ElementSetHistory implements satellite.CatalogStore.
+func (s *TLECatalogStore) ElementSetHistory(ctx context.Context, noradID int, limit int) ([]*satellite.ElementSet, error) {
+	recs, err := s.repo.GetHistory(ctx, noradID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromTLEElementSetRecords(recs), nil
+}
+
+// LatestElementSets implements satellite.CatalogStore.
+func (s *TLECatalogStore) LatestElementSets(ctx context.Context) ([]*satellite.ElementSet, error) {
+	recs, err := s.repo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromTLEElementSetRecords(recs), nil
+}
+
+// ManeuverElementSets implements satellite.CatalogStore.
+func (s *TLECatalogStore) ManeuverElementSets(ctx context.Context, since time.Time, limit int) ([]*satellite.ElementSet, error) {
+	recs, err := s.repo.GetManeuvers(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromTLEElementSetRecords(recs), nil
+}
+
+func toTLEElementSetRecord(es *satellite.ElementSet) *db.TLEElementSet {
+	return &db.TLEElementSet{
+		NoradID:        es.NoradID,
+		Name:           es.Name,
+		IntDesignator:  sql.NullString{String: es.IntDesignator, Valid: es.IntDesignator != ""},
+		Epoch:          es.Epoch,
+		Line1:          es.Line1,
+		Line2:          es.Line2,
+		MeanMotion:     es.MeanMotion,
+		MeanMotionDot:  es.MeanMotionDot,
+		Eccentricity:   es.Eccentricity,
+		Inclination:    es.Inclination,
+		RAAN:           es.RAAN,
+		ArgPerigee:     es.ArgPerigee,
+		MeanAnomaly:    es.MeanAnomaly,
+		BStar:          es.BStar,
+		Source:         es.Source,
+		Maneuver:       es.Maneuver,
+		ManeuverReason: sql.NullString{String: es.ManeuverReason, Valid: es.ManeuverReason != ""},
+		ImportedAt:     es.ImportedAt,
+	}
+}
+
+func fromTLEElementSetRecord(rec *db.TLEElementSet) *satellite.ElementSet {
+	return &satellite.ElementSet{
+		NoradID:        rec.NoradID,
+		Name:           rec.Name,
+		IntDesignator:  rec.IntDesignator.String,
+		Epoch:          rec.Epoch.UTC(),
+		Line1:          rec.Line1,
+		Line2:          rec.Line2,
+		MeanMotion:     rec.MeanMotion,
+		MeanMotionDot:  rec.MeanMotionDot,
+		Eccentricity:   rec.Eccentricity,
+		Inclination:    rec.Inclination,
+		RAAN:           rec.RAAN,
+		ArgPerigee:     rec.ArgPerigee,
+		MeanAnomaly:    rec.MeanAnomaly,
+		BStar:          rec.BStar,
+		Source:         rec.Source,
+		ImportedAt:     rec.ImportedAt.UTC(),
+		Maneuver:       rec.Maneuver,
+		ManeuverReason: rec.ManeuverReason.String,
+	}
+}
+
+func fromTLEElementSetRecords(recs []*db.TLEElementSet) []*satellite.ElementSet {
+	sets := make([]*satellite.ElementSet, 0, len(recs))
+	for _, rec := range recs {
+		sets = append(sets, fromTLEElementSetRecord(rec))
+	}
+	return sets
+}
@@ -0,0 +1,207 @@
+package orbital
+
+import (
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// Constants of the perturbation models
+const (
+	SunMu             = 1.32712440018e20 // m³/s²
+	MoonMu            = 4.9028e12        // m³/s²
+	SolarPressure1AU  = 4.56e-6          // Solar radiation pressure at 1 AU, N/m²
+	EarthRotationRate = frames.EarthRotationRate
+)
+
+// ForceModel contributes an acceleration to a spacecraft at GCRF position
+// r (meters) and velocity v (m/s).
+type ForceModel interface {
+	Acceleration(t time.Time, r, v Vector3) Vector3
+}
+
+// ForceModels sums the accelerations of several force models.
+type ForceModels []ForceModel
+
+// Acceleration implements ForceModel.
+func (f ForceModels) Acceleration(t time.Time, r, v Vector3) Vector3 {
+	var a Vector3
+	for _, model := range f {
+		a = a.Add(model.Acceleration(t, r, v))
+	}
+	return a
+}
+
+// Spacecraft holds the physical properties the surface forces depend on.
+type Spacecraft struct {
+	Mass                    float64 `json:"mass"`                     // kg
+	DragArea                float64 `json:"drag_area"`                // m²
+	DragCoefficient         float64 `json:"drag_coefficient"`         // Cd
+	SRPArea                 float64 `json:"srp_area"`                 // m²
+	ReflectivityCoefficient float64 `json:"reflectivity_coefficient"` // Cr, 1 absorbs and 2 reflects fully
+}
+
+// ThirdBody is the differential attraction of the Sun or Moon.
+type ThirdBody struct {
+	Mu       float64
+	Position func(t time.Time) Vector3 // GCRF, meters
+}
+
+// SunGravity returns the solar third-body perturbation.
+func SunGravity() *ThirdBody {
+	return &ThirdBody{Mu: SunMu, Position: SunPosition}
+}
+
+// MoonGravity returns the lunar third-body perturbation.
+func MoonGravity() *ThirdBody {
+	return &ThirdBody{Mu: MoonMu, Position: MoonPosition}
+}
+
+// Acceleration implements ForceModel.
+func (b *ThirdBody) Acceleration(t time.Time, r, _ Vector3) Vector3 {
+	body := b.Position(t)
+	d := body.Sub(r)
+	dm, bm := d.Magnitude(), body.Magnitude()
+	return d.Scale(b.Mu / (dm * dm * dm)).Sub(body.Scale(b.Mu / (bm * bm * bm)))
+}
+
+// SunPosition returns the Sun's position in GCRF, meters.
+func SunPosition(t time.Time) Vector3 {
+	// frames.SunPosition is referred to the equator of date
+	mod := frames.SunPosition(t)
+	p := frames.PrecessionMatrix(frames.JulianDate(frames.TT(t))).T().MulVec(mod)
+	return Vector3{X: p[0] * 1000, Y: p[1] * 1000, Z: p[2] * 1000}
+}
+
+// MoonPosition returns the Moon's position in GCRF, meters.
+func MoonPosition(t time.Time) Vector3 {
+	p := frames.MoonPosition(t)
+	return Vector3{X: p[0] * 1000, Y: p[1] * 1000, Z: p[2] * 1000}
+}
+
+// SolarRadiationPressure is the cannonball solar radiation pressure model
+// with a cylindrical Earth shadow.
+type SolarRadiationPressure struct {
+	Spacecraft Spacecraft
+}
+
+// Acceleration implements ForceModel.
+func (p *SolarRadiationPressure) Acceleration(t time.Time, r, _ Vector3) Vector3 {
+	sc := p.Spacecraft
+	if sc.Mass <= 0 || sc.SRPArea <= 0 {
+		return Vector3{}
+	}
+	sun := SunPosition(t)
+	km := func(v Vector3) [3]float64 { return [3]float64{v.X / 1000, v.Y / 1000, v.Z / 1000} }
+	if !frames.Sunlit(km(r), km(sun)) {
+		return Vector3{}
+	}
+	away := r.Sub(sun)
+	d := away.Magnitude()
+	au := frames.AstronomicalUnit * 1000
+	magnitude := SolarPressure1AU * sc.ReflectivityCoefficient * sc.SRPArea / sc.Mass * (au / d) * (au / d)
+	return away.Scale(magnitude / d)
+}
+
+// AtmosphereModel gives the neutral density at a GCRF position.
+type AtmosphereModel interface {
+	Density(t time.Time, r Vector3) float64 // kg/m³
+}
+
+// exponentialLayers is the exponential atmosphere of Vallado,
+// Fundamentals of Astrodynamics, table 8-4: base altitude (km), base
+// density (kg/m³) and scale height (km).
+var exponentialLayers = [][3]float64{
+	{0, 1.225, 7.249},
+	{25, 3.899e-2, 6.349},
+	{30, 1.774e-2, 6.682},
+	{40, 3.972e-3, 7.554},
+	{50, 1.057e-3, 8.382},
+	{60, 3.206e-4, 7.714},
+	{70, 8.770e-5, 6.549},
+	{80, 1.905e-5, 5.799},
+	{90, 3.396e-6, 5.382},
+	{100, 5.297e-7, 5.877},
+	{110, 9.661e-8, 7.263},
+	{120, 2.438e-8, 9.473},
+	{130, 8.484e-9, 12.636},
+	{140, 3.845e-9, 16.149},
+	{150, 2.070e-9, 22.523},
+	{180, 5.464e-10, 29.740},
+	{200, 2.789e-10, 37.105},
+	{250, 7.248e-11, 45.546},
+	{300, 2.418e-11, 53.628},
+	{350, 9.518e-12, 53.298},
+	{400, 3.725e-12, 58.515},
+	{450, 1.585e-12, 60.828},
+	{500, 6.967e-13, 63.822},
+	{600, 1.454e-13, 71.835},
+	{700, 3.614e-14, 88.667},
+	{800, 1.170e-14, 124.64},
+	{900, 5.245e-15, 181.05},
+	{1000, 3.019e-15, 268.00},
+}
+
+// ExponentialAtmosphere is a static piecewise-exponential density model.
+// It ignores solar and geomagnetic activity; plug in an NRLMSISE-00
+// implementation through AtmosphereModel where that matters.
+type ExponentialAtmosphere struct{}
+
+// Density implements AtmosphereModel. Altitude is geodetic; the z axes of
+// GCRF and ITRF differ by well under a degree, which is ignored.
+func (ExponentialAtmosphere) Density(_ time.Time, r Vector3) float64 {
+	h := frames.ECEFToGeodetic([3]float64{r.X / 1000, r.Y / 1000, r.Z / 1000}).Altitude
+	return ExponentialDensity(h)
+}
+
+// ExponentialDensity returns the exponential model density at a geodetic
+// altitude in km.
+func ExponentialDensity(altitudeKm float64) float64 {
+	if altitudeKm < 0 {
+		altitudeKm = 0
+	}
+	layer := exponentialLayers[0]
+	for _, l := range exponentialLayers {
+		if altitudeKm < l[0] {
+			break
+		}
+		layer = l
+	}
+	return layer[1] * math.Exp(-(altitudeKm-layer[0])/layer[2])
+}
+
+// AtmosphericDrag decelerates the spacecraft against an atmosphere that
+// co-rotates with the Earth.
+type AtmosphericDrag struct {
+	Spacecraft Spacecraft
+	Atmosphere AtmosphereModel
+}
+
+// Acceleration implements ForceModel.
+func (d *AtmosphericDrag) Acceleration(t time.Time, r, v Vector3) Vector3 {
+	sc := d.Spacecraft
+	if sc.Mass <= 0 || sc.DragArea <= 0 {
+		return Vector3{}
+	}
+	rho := d.Atmosphere.Density(t, r)
+	if rho == 0 {
+		return Vector3{}
+	}
+	rel := v.Sub(Vector3{Z: EarthRotationRate}.Cross(r))
+	return rel.Scale(-0.5 * sc.DragCoefficient * sc.DragArea / sc.Mass * rho * rel.Magnitude())
+}
+
+// StandardForceModel returns the full model for a spacecraft: EGM96
+// gravity through degree and order 4, exponential-atmosphere drag, solar
+// radiation pressure and Sun and Moon third-body attraction.
+func StandardForceModel(sc Spacecraft) ForceModels {
+	gravity, _ := NewGravityField(EGM96(), 4, 4) // Within the built-in model
+	return ForceModels{
+		gravity,
+		&AtmosphericDrag{Spacecraft: sc, Atmosphere: ExponentialAtmosphere{}},
+		&SolarRadiationPressure{Spacecraft: sc},
+		SunGravity(),
+		MoonGravity(),
+	}
+}
@@ -0,0 +1,260 @@
+package orbital
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// GravityModel is a set of fully normalized spherical harmonic
+// coefficients of the Earth's gravity field.
+type GravityModel struct {
+	Name      string
+	GM        float64 // m³/s²
+	Radius    float64 // Reference radius, meters
+	MaxDegree int
+	C, S      [][]float64 // Indexed [n][m]
+}
+
+// newGravityModel allocates coefficient tables up to maxDegree.
+func newGravityModel(name string, gm, radius float64, maxDegree int) *GravityModel {
+	m := &GravityModel{Name: name, GM: gm, Radius: radius, MaxDegree: maxDegree}
+	m.C = make([][]float64, maxDegree+1)
+	m.S = make([][]float64, maxDegree+1)
+	for n := range m.C {
+		m.C[n] = make([]float64, n+1)
+		m.S[n] = make([]float64, n+1)
+	}
+	m.C[0][0] = 1
+	return m
+}
+
+// egm96 holds the EGM96 coefficients through degree and order 4:
+// n, m, C̄nm, S̄nm.
+var egm96 = [][4]float64{
+	{2, 0, -0.484165371736e-03, 0},
+	{2, 1, -0.186987635955e-09, 0.119528012031e-08},
+	{2, 2, 0.243914352398e-05, -0.140016683654e-05},
+	{3, 0, 0.957254173792e-06, 0},
+	{3, 1, 0.202998882184e-05, 0.248513158716e-06},
+	{3, 2, 0.904627768605e-06, -0.619025944205e-06},
+	{3, 3, 0.721072657057e-06, 0.141435626958e-05},
+	{4, 0, 0.539873863789e-06, 0},
+	{4, 1, -0.536321616971e-06, -0.473440265853e-06},
+	{4, 2, 0.350694105785e-06, 0.662671572540e-06},
+	{4, 3, 0.990771803829e-06, -0.200928369177e-06},
+	{4, 4, -0.188560802735e-06, 0.308853169333e-06},
+}
+
+// EGM96 returns the built-in EGM96 model through degree and order 4. For
+// higher degrees load the full model with ReadGravityModel.
+func EGM96() *GravityModel {
+	m := newGravityModel("EGM96", 3.986004415e14, 6378136.3, 4)
+	for _, c := range egm96 {
+		n, k := int(c[0]), int(c[1])
+		m.C[n][k], m.S[n][k] = c[2], c[3]
+	}
+	return m
+}
+
+// ReadGravityModel parses a gravity field in the ICGEM .gfc format, as
+// distributed for EGM96 and EGM2008. Coefficients above maxDegree are
+// skipped; a maxDegree of zero keeps them all.
+func ReadGravityModel(r io.Reader, maxDegree int) (*GravityModel, error) {
+	scanner := bufio.NewScanner(r)
+	header := make(map[string]string)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "end_of_head" {
+			break
+		}
+		if len(fields) >= 2 {
+			header[fields[0]] = fields[1]
+		}
+	}
+
+	gm, err := parseGFCFloat(header["earth_gravity_constant"])
+	if err != nil {
+		return nil, fmt.Errorf("gravity model earth_gravity_constant: %w", err)
+	}
+	radius, err := parseGFCFloat(header["radius"])
+	if err != nil {
+		return nil, fmt.Errorf("gravity model radius: %w", err)
+	}
+	fileDegree, err := strconv.Atoi(header["max_degree"])
+	if err != nil {
+		return nil, fmt.Errorf("gravity model max_degree: %w", err)
+	}
+	if norm := header["norm"]; norm != "" && norm != "fully_normalized" {
+		return nil, fmt.Errorf("gravity model normalization %q not supported", norm)
+	}
+	if maxDegree <= 0 || maxDegree > fileDegree {
+		maxDegree = fileDegree
+	}
+
+	m := newGravityModel(header["modelname"], gm, radius, maxDegree)
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] != "gfc" || len(fields) < 5 {
+			return nil, fmt.Errorf("gravity model line %d: expected gfc n m C S", lineNo)
+		}
+		n, errN := strconv.Atoi(fields[1])
+		k, errM := strconv.Atoi(fields[2])
+		if errN != nil || errM != nil || k > n || n < 0 || k < 0 {
+			return nil, fmt.Errorf("gravity model line %d: invalid degree or order", lineNo)
+		}
+		if n > maxDegree {
+			continue
+		}
+		c, errC := parseGFCFloat(fields[3])
+		s, errS := parseGFCFloat(fields[4])
+		if errC != nil || errS != nil {
+			return nil, fmt.Errorf("gravity model line %d: invalid coefficient", lineNo)
+		}
+		m.C[n][k], m.S[n][k] = c, s
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read gravity model: %w", err)
+	}
+	return m, nil
+}
+
+// parseGFCFloat accepts Fortran D exponents as well as E.
+func parseGFCFloat(s string) (float64, error) {
+	return strconv.ParseFloat(strings.NewReplacer("D", "E", "d", "e").Replace(s), 64)
+}
+
+// GravityField evaluates a gravity model truncated to a degree and order,
+// including the central term. Accelerations are computed in ITRF with the
+// recursions of Montenbruck and Gill (Satellite Orbits, 3.2) and rotated to
+// GCRF; degrees up to about 70 are numerically safe.
+type GravityField struct {
+	GM     float64
+	Radius float64
+	Degree int
+	Order  int
+	EOP    frames.EOP
+
+	c, s [][]float64 // Unnormalized coefficients
+}
+
+// NewGravityField truncates a model to the given degree and order. A
+// degree of zero is point-mass gravity.
+func NewGravityField(model *GravityModel, degree, order int) (*GravityField, error) {
+	if degree < 0 || degree > model.MaxDegree {
+		return nil, fmt.Errorf("gravity degree %d outside the %s model's 0-%d", degree, model.Name, model.MaxDegree)
+	}
+	if order < 0 || order > degree {
+		return nil, fmt.Errorf("gravity order %d must be between 0 and the degree %d", order, degree)
+	}
+	g := &GravityField{GM: model.GM, Radius: model.Radius, Degree: degree, Order: order}
+	g.c = make([][]float64, degree+1)
+	g.s = make([][]float64, degree+1)
+	for n := 0; n <= degree; n++ {
+		g.c[n] = make([]float64, n+1)
+		g.s[n] = make([]float64, n+1)
+		for m := 0; m <= n && m <= order; m++ {
+			norm := normalization(n, m)
+			g.c[n][m] = model.C[n][m] * norm
+			g.s[n][m] = model.S[n][m] * norm
+		}
+	}
+	return g, nil
+}
+
+// normalization returns the factor converting a fully normalized
+// coefficient of degree n and order m to its unnormalized value.
+func normalization(n, m int) float64 {
+	// (n-m)!/(n+m)! as a running product to stay in range
+	ratio := 1.0
+	for k := n - m + 1; k <= n+m; k++ {
+		ratio /= float64(k)
+	}
+	delta := 2.0
+	if m == 0 {
+		delta = 1
+	}
+	return math.Sqrt(delta * float64(2*n+1) * ratio)
+}
+
+// Acceleration implements ForceModel.
+func (g *GravityField) Acceleration(t time.Time, r, _ Vector3) Vector3 {
+	if g.Degree < 2 {
+		rr := r.Magnitude()
+		return r.Scale(-g.GM / (rr * rr * rr))
+	}
+	rot := frames.GCRFToITRFMatrix(t, g.EOP)
+	body := rot.MulVec([3]float64{r.X, r.Y, r.Z})
+	a := rot.T().MulVec(g.bodyAcceleration(body))
+	return Vector3{X: a[0], Y: a[1], Z: a[2]}
+}
+
+// bodyAcceleration evaluates the field at an Earth-fixed position.
+func (g *GravityField) bodyAcceleration(r [3]float64) [3]float64 {
+	nmax, mmax := g.Degree, g.Order
+	size := nmax + 2
+	v := make([][]float64, size)
+	w := make([][]float64, size)
+	for n := range v {
+		v[n] = make([]float64, size)
+		w[n] = make([]float64, size)
+	}
+
+	r2 := r[0]*r[0] + r[1]*r[1] + r[2]*r[2]
+	rho := g.Radius * g.Radius / r2
+	x0, y0, z0 := g.Radius*r[0]/r2, g.Radius*r[1]/r2, g.Radius*r[2]/r2
+
+	// Zonal terms V(n,0)
+	v[0][0] = g.Radius / math.Sqrt(r2)
+	v[1][0] = z0 * v[0][0]
+	for n := 2; n <= nmax+1; n++ {
+		v[n][0] = (float64(2*n-1)*z0*v[n-1][0] - float64(n-1)*rho*v[n-2][0]) / float64(n)
+	}
+	// Tesseral and sectorial terms
+	for m := 1; m <= mmax+1; m++ {
+		v[m][m] = float64(2*m-1) * (x0*v[m-1][m-1] - y0*w[m-1][m-1])
+		w[m][m] = float64(2*m-1) * (x0*w[m-1][m-1] + y0*v[m-1][m-1])
+		if m <= nmax {
+			v[m+1][m] = float64(2*m+1) * z0 * v[m][m]
+			w[m+1][m] = float64(2*m+1) * z0 * w[m][m]
+		}
+		for n := m + 2; n <= nmax+1; n++ {
+			v[n][m] = (float64(2*n-1)*z0*v[n-1][m] - float64(n+m-1)*rho*v[n-2][m]) / float64(n-m)
+			w[n][m] = (float64(2*n-1)*z0*w[n-1][m] - float64(n+m-1)*rho*w[n-2][m]) / float64(n-m)
+		}
+	}
+
+	var ax, ay, az float64
+	for m := 0; m <= mmax; m++ {
+		for n := m; n <= nmax; n++ {
+			c, s := g.c[n][m], g.s[n][m]
+			if m == 0 {
+				ax -= c * v[n+1][1]
+				ay -= c * w[n+1][1]
+				az -= float64(n+1) * c * v[n+1][0]
+				continue
+			}
+			fac := 0.5 * float64((n-m+1)*(n-m+2))
+			ax += 0.5*(-c*v[n+1][m+1]-s*w[n+1][m+1]) + fac*(c*v[n+1][m-1]+s*w[n+1][m-1])
+			ay += 0.5*(-c*w[n+1][m+1]+s*v[n+1][m+1]) + fac*(-c*w[n+1][m-1]+s*v[n+1][m-1])
+			az += float64(n-m+1) * (-c*v[n+1][m] - s*w[n+1][m])
+		}
+	}
+	scale := g.GM / (g.Radius * g.Radius)
+	return [3]float64{ax * scale, ay * scale, az * scale}
+}
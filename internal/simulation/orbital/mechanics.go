@@ -11,6 +11,8 @@ import (
 	"math"
 	"sync"
 	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
 )
 
 // Physical constants
@@ -269,45 +271,19 @@ func (op *OrbitalPredictor) propagateStep() {
 
 // calculateGroundTrack converts ECI position to ground coordinates
 func (op *OrbitalPredictor) calculateGroundTrack(state StateVector) GroundTrack {
-	r := state.Position.Magnitude()
-
-	// Calculate latitude (declination)
-	lat := math.Asin(state.Position.Z / r)
-
-	// Calculate longitude (right ascension minus Earth rotation)
-	lon := math.Atan2(state.Position.Y, state.Position.X)
-
-	// Account for Earth's rotation (simplified)
-	gmst := op.getGMST(state.Timestamp)
-	lon = lon - gmst
-
-	// Normalize longitude to -π to π
-	for lon > math.Pi {
-		lon -= 2 * math.Pi
-	}
-	for lon < -math.Pi {
-		lon += 2 * math.Pi
-	}
+	// The frames package works in kilometres
+	r := [3]float64{state.Position.X / 1000, state.Position.Y / 1000, state.Position.Z / 1000}
+	rECEF, _ := frames.GCRFToITRF(r, [3]float64{}, state.Timestamp, frames.EOP{})
+	ground := frames.ECEFToGeodetic(rECEF)
 
 	return GroundTrack{
-		Latitude:  lat * 180 / math.Pi,
-		Longitude: lon * 180 / math.Pi,
-		Altitude:  r - EarthRadius,
+		Latitude:  ground.Latitude,
+		Longitude: ground.Longitude,
+		Altitude:  ground.Altitude * 1000,
 		Timestamp: state.Timestamp,
 	}
 }
 
-// getGMST returns Greenwich Mean Sidereal Time in radians
-func (op *OrbitalPredictor) getGMST(t time.Time) float64 {
-	// J2000.0 epoch
-	j2000 := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
-	daysSinceJ2000 := t.Sub(j2000).Hours() / 24.0
-
-	// Simplified GMST calculation
-	gmst := 4.894961212823756 + 6.300388098984957*daysSinceJ2000
-	return math.Mod(gmst, 2*math.Pi)
-}
-
 // elementsToState converts Keplerian elements to state vector
 func (op *OrbitalPredictor) elementsToState(el OrbitalElements) StateVector {
 	a := el.SemiMajorAxis
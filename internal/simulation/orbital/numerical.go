@@ -0,0 +1,314 @@
+package orbital
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+// ErrDecayed is returned when a propagated orbit falls below the decay
+// altitude.
+var ErrDecayed = errors.New("orbit decayed")
+
+// NumericalConfig controls the numerical propagator's step size.
+type NumericalConfig struct {
+	RelTol        float64       // Relative error allowed per step
+	AbsTol        float64       // Absolute error allowed per step, meters and m/s
+	InitialStep   time.Duration // First trial step
+	MinStep       time.Duration // Propagation fails if the step must shrink below this
+	MaxStep       time.Duration // Upper bound on the step
+	DecayAltitude float64       // Propagation stops with ErrDecayed below this geodetic altitude, meters (0 disables)
+}
+
+// DefaultNumericalConfig returns tolerances good to about a metre per day
+// in LEO, stopping at the Kármán line.
+func DefaultNumericalConfig() NumericalConfig {
+	return NumericalConfig{
+		RelTol:        1e-11,
+		AbsTol:        1e-6,
+		InitialStep:   10 * time.Second,
+		MinStep:       time.Millisecond,
+		MaxStep:       10 * time.Minute,
+		DecayAltitude: AtmosphereHeight,
+	}
+}
+
+// StateTransitionMatrix maps a change in the initial state (position then
+// velocity) to the change in the propagated state.
+type StateTransitionMatrix [6][6]float64
+
+// NumericalPropagator integrates the equations of motion in GCRF under a
+// force model with the adaptive Dormand–Prince 5(4) method.
+type NumericalPropagator struct {
+	force  ForceModel
+	config NumericalConfig
+}
+
+// NewNumericalPropagator creates a propagator for a force model. The model
+// must include the Earth's gravity, e.g. a GravityField.
+func NewNumericalPropagator(force ForceModel, cfg NumericalConfig) *NumericalPropagator {
+	return &NumericalPropagator{force: force, config: cfg}
+}
+
+// Propagate advances a state to t, forwards or backwards.
+func (p *NumericalPropagator) Propagate(initial StateVector, t time.Time) (StateVector, error) {
+	y := stateToSlice(initial, false)
+	if _, err := p.integrate(initial.Timestamp, y, t, p.config.InitialStep); err != nil {
+		return StateVector{}, err
+	}
+	return sliceToState(y, t), nil
+}
+
+// PropagateWithSTM advances a state to t and integrates the variational
+// equations alongside it. The Jacobian of the force model is taken by
+// central differences, so every force model is accounted for at the cost
+// of twelve extra evaluations per step.
+func (p *NumericalPropagator) PropagateWithSTM(initial StateVector, t time.Time) (StateVector, StateTransitionMatrix, error) {
+	y := stateToSlice(initial, true)
+	if _, err := p.integrate(initial.Timestamp, y, t, p.config.InitialStep); err != nil {
+		return StateVector{}, StateTransitionMatrix{}, err
+	}
+	var stm StateTransitionMatrix
+	for i := 0; i < 6; i++ {
+		copy(stm[i][:], y[6+6*i:12+6*i])
+	}
+	return sliceToState(y, t), stm, nil
+}
+
+// Ephemeris propagates from initial to end and returns the state every
+// step, including both ends. If the orbit decays the states up to the last
+// full step are returned with ErrDecayed.
+func (p *NumericalPropagator) Ephemeris(initial StateVector, end time.Time, step time.Duration) ([]StateVector, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("ephemeris step must be positive")
+	}
+	states := []StateVector{initial}
+	y := stateToSlice(initial, false)
+	h := p.config.InitialStep
+	for at := initial.Timestamp; at.Before(end); {
+		next := at.Add(step)
+		if next.After(end) {
+			next = end
+		}
+		var err error
+		if h, err = p.integrate(at, y, next, h); err != nil {
+			return states, err
+		}
+		states = append(states, sliceToState(y, next))
+		at = next
+	}
+	return states, nil
+}
+
+func stateToSlice(s StateVector, withSTM bool) []float64 {
+	size := 6
+	if withSTM {
+		size = 42
+	}
+	y := make([]float64, size)
+	y[0], y[1], y[2] = s.Position.X, s.Position.Y, s.Position.Z
+	y[3], y[4], y[5] = s.Velocity.X, s.Velocity.Y, s.Velocity.Z
+	if withSTM {
+		for i := 0; i < 6; i++ {
+			y[6+7*i] = 1 // Identity
+		}
+	}
+	return y
+}
+
+func sliceToState(y []float64, t time.Time) StateVector {
+	return StateVector{
+		Position:  Vector3{X: y[0], Y: y[1], Z: y[2]},
+		Velocity:  Vector3{X: y[3], Y: y[4], Z: y[5]},
+		Timestamp: t,
+	}
+}
+
+// derivative returns the equations of motion, and of the state transition
+// matrix when y carries one.
+func (p *NumericalPropagator) derivative(epoch time.Time) func(t float64, y, dy []float64) {
+	return func(t float64, y, dy []float64) {
+		at := epoch.Add(time.Duration(t * float64(time.Second)))
+		r := Vector3{X: y[0], Y: y[1], Z: y[2]}
+		v := Vector3{X: y[3], Y: y[4], Z: y[5]}
+		a := p.force.Acceleration(at, r, v)
+		dy[0], dy[1], dy[2] = v.X, v.Y, v.Z
+		dy[3], dy[4], dy[5] = a.X, a.Y, a.Z
+		if len(y) == 6 {
+			return
+		}
+
+		// Jacobian of the acceleration by central differences
+		var jac [3][6]float64
+		dr := math.Max(1, 1e-7*r.Magnitude())
+		dv := math.Max(1e-3, 1e-7*v.Magnitude())
+		for j := 0; j < 6; j++ {
+			var plus, minus Vector3
+			var delta float64
+			if j < 3 {
+				delta = dr
+				plus = p.force.Acceleration(at, r.Add(unitAxis(j, delta)), v)
+				minus = p.force.Acceleration(at, r.Sub(unitAxis(j, delta)), v)
+			} else {
+				delta = dv
+				plus = p.force.Acceleration(at, r, v.Add(unitAxis(j-3, delta)))
+				minus = p.force.Acceleration(at, r, v.Sub(unitAxis(j-3, delta)))
+			}
+			diff := plus.Sub(minus).Scale(1 / (2 * delta))
+			jac[0][j], jac[1][j], jac[2][j] = diff.X, diff.Y, diff.Z
+		}
+
+		// dΦ/dt = A·Φ with A = [0 I; ∂a/∂r ∂a/∂v]
+		phi := y[6:42]
+		out := dy[6:42]
+		for k := 0; k < 6; k++ {
+			for i := 0; i < 3; i++ {
+				out[6*i+k] = phi[6*(i+3)+k]
+			}
+			for i := 0; i < 3; i++ {
+				var sum float64
+				for j := 0; j < 6; j++ {
+					sum += jac[i][j] * phi[6*j+k]
+				}
+				out[6*(i+3)+k] = sum
+			}
+		}
+	}
+}
+
+func unitAxis(i int, length float64) Vector3 {
+	switch i {
+	case 0:
+		return Vector3{X: length}
+	case 1:
+		return Vector3{Y: length}
+	default:
+		return Vector3{Z: length}
+	}
+}
+
+// Dormand–Prince 5(4) coefficients
+var (
+	dpC = [7]float64{0, 1.0 / 5, 3.0 / 10, 4.0 / 5, 8.0 / 9, 1, 1}
+	dpA = [7][6]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	}
+	dpB  = [7]float64{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84, 0}
+	dpB4 = [7]float64{5179.0 / 57600, 0, 7571.0 / 16695, 393.0 / 640, -92097.0 / 339200, 187.0 / 2100, 1.0 / 40}
+)
+
+// integrate advances y from start to end in place and returns the step
+// size to continue with. Only the orbital state enters the error estimate.
+func (p *NumericalPropagator) integrate(start time.Time, y []float64, end time.Time, h0 time.Duration) (time.Duration, error) {
+	cfg := p.config
+	span := end.Sub(start).Seconds()
+	if span == 0 {
+		return h0, nil
+	}
+	dir := 1.0
+	if span < 0 {
+		dir = -1
+	}
+	hmax := cfg.MaxStep.Seconds()
+	if hmax <= 0 {
+		hmax = math.Abs(span)
+	}
+	h := math.Min(math.Abs(h0.Seconds()), hmax)
+	if h <= 0 {
+		h = math.Min(60, hmax)
+	}
+	h *= dir
+
+	f := p.derivative(start)
+	n := len(y)
+	var k [7][]float64
+	for i := range k {
+		k[i] = make([]float64, n)
+	}
+	tmp := make([]float64, n)
+	ynew := make([]float64, n)
+	f(0, y, k[0])
+
+	t := 0.0
+	next := h
+	for dir*(span-t) > 0 {
+		last := false
+		next = h
+		if dir*(t+h-span) >= 0 {
+			h = span - t
+			last = true
+		}
+
+		for s := 1; s < 7; s++ {
+			for i := 0; i < n; i++ {
+				sum := 0.0
+				for j := 0; j < s; j++ {
+					sum += dpA[s][j] * k[j][i]
+				}
+				tmp[i] = y[i] + h*sum
+			}
+			if s == 6 {
+				copy(ynew, tmp) // The last stage is the fifth-order solution
+			}
+			f(t+dpC[s]*h, tmp, k[s])
+		}
+
+		errNorm := 0.0
+		for i := 0; i < 6; i++ {
+			e := 0.0
+			for s := 0; s < 7; s++ {
+				e += (dpB[s] - dpB4[s]) * k[s][i]
+			}
+			scale := cfg.AbsTol + cfg.RelTol*math.Max(math.Abs(y[i]), math.Abs(ynew[i]))
+			errNorm += (h * e / scale) * (h * e / scale)
+		}
+		errNorm = math.Sqrt(errNorm / 6)
+
+		factor := 5.0
+		if errNorm > 0 {
+			factor = math.Min(5, math.Max(0.2, 0.9*math.Pow(errNorm, -0.2)))
+		}
+		if errNorm <= 1 {
+			if last {
+				t = span
+			} else {
+				t += h
+			}
+			copy(y, ynew)
+			k[0], k[6] = k[6], k[0]
+			if err := p.checkDecay(start, t, y); err != nil {
+				return 0, err
+			}
+		} else {
+			factor = math.Min(1, factor)
+		}
+
+		h = dir * math.Min(math.Abs(h)*factor, hmax)
+		if math.Abs(h) < cfg.MinStep.Seconds() && dir*(span-t) > cfg.MinStep.Seconds() {
+			return 0, fmt.Errorf("step size fell below %s at %s", cfg.MinStep, start.Add(time.Duration(t*float64(time.Second))))
+		}
+	}
+	// A final step shortened to land on end says nothing about the next
+	return time.Duration(math.Max(math.Abs(h), math.Abs(next)) * float64(time.Second)), nil
+}
+
+// checkDecay stops propagation below the decay altitude.
+func (p *NumericalPropagator) checkDecay(start time.Time, t float64, y []float64) error {
+	if p.config.DecayAltitude <= 0 {
+		return nil
+	}
+	h := frames.ECEFToGeodetic([3]float64{y[0] / 1000, y[1] / 1000, y[2] / 1000}).Altitude * 1000
+	if h < p.config.DecayAltitude {
+		return fmt.Errorf("%w at %s, altitude %.1f km", ErrDecayed, start.Add(time.Duration(t*float64(time.Second))).UTC(), h/1000)
+	}
+	return nil
+}
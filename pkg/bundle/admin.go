@@ -0,0 +1,381 @@
+package bundle
+
+import (
+	"fmt"
+	"time"
+)
+
+// AdminRecordType identifies the content of an administrative record.
+type AdminRecordType uint64
+
+// Administrative record types. Status reports are defined by RFC 9171
+// Section 6.1.1; custody signals follow the BIBE custody signal code point.
+const (
+	AdminRecordStatusReport  AdminRecordType = 1
+	AdminRecordCustodySignal AdminRecordType = 4
+)
+
+// StatusReason explains why a status report or custody signal was issued
+// (RFC 9171 Section 6.1.1).
+type StatusReason uint64
+
+const (
+	ReasonNoInfo                    StatusReason = 0
+	ReasonLifetimeExpired           StatusReason = 1
+	ReasonForwardedUnidirectional   StatusReason = 2
+	ReasonTransmissionCanceled      StatusReason = 3
+	ReasonDepletedStorage           StatusReason = 4
+	ReasonDestinationUnintelligible StatusReason = 5
+	ReasonNoKnownRoute              StatusReason = 6
+	ReasonNoTimelyContact           StatusReason = 7
+	ReasonBlockUnintelligible       StatusReason = 8
+	ReasonHopLimitExceeded          StatusReason = 9
+	ReasonTrafficPared              StatusReason = 10
+	ReasonBlockUnsupported          StatusReason = 11
+)
+
+// String returns a human-readable reason.
+func (r StatusReason) String() string {
+	switch r {
+	case ReasonNoInfo:
+		return "no additional information"
+	case ReasonLifetimeExpired:
+		return "lifetime expired"
+	case ReasonForwardedUnidirectional:
+		return "forwarded over unidirectional link"
+	case ReasonTransmissionCanceled:
+		return "transmission canceled"
+	case ReasonDepletedStorage:
+		return "depleted storage"
+	case ReasonDestinationUnintelligible:
+		return "destination endpoint ID unintelligible"
+	case ReasonNoKnownRoute:
+		return "no known route to destination"
+	case ReasonNoTimelyContact:
+		return "no timely contact with next node"
+	case ReasonBlockUnintelligible:
+		return "block unintelligible"
+	case ReasonHopLimitExceeded:
+		return "hop limit exceeded"
+	case ReasonTrafficPared:
+		return "traffic pared"
+	case ReasonBlockUnsupported:
+		return "block unsupported"
+	default:
+		return fmt.Sprintf("reason %d", uint64(r))
+	}
+}
+
+// BundleRef identifies the subject bundle of a status report or custody
+// signal by its RFC 9171 bundle identity.
+type BundleRef struct {
+	SourceEID         string
+	CreationTimestamp time.Time
+	SequenceNumber    uint64
+	IsFragment        bool
+	FragmentOffset    uint64
+	FragmentLength    uint64
+}
+
+// RefOf returns the identity of b.
+func RefOf(b *Bundle) BundleRef {
+	ref := BundleRef{
+		SourceEID:         b.SourceEID,
+		CreationTimestamp: b.CreationTimestamp,
+		SequenceNumber:    b.SequenceNumber,
+	}
+	if b.IsFragment {
+		ref.IsFragment = true
+		ref.FragmentOffset = b.FragmentOffset
+		ref.FragmentLength = uint64(len(b.Payload))
+	}
+	return ref
+}
+
+// Key returns a string that is equal for equal bundle identities.
+func (r BundleRef) Key() string {
+	key := fmt.Sprintf("%s|%d|%d", r.SourceEID, DTNTime(r.CreationTimestamp), r.SequenceNumber)
+	if r.IsFragment {
+		key += fmt.Sprintf("|%d|%d", r.FragmentOffset, r.FragmentLength)
+	}
+	return key
+}
+
+// StatusAssertion records whether a status item is asserted and, if the
+// subject requested it, when.
+type StatusAssertion struct {
+	Asserted bool
+	Time     time.Time
+}
+
+// StatusReport is a bundle status report (RFC 9171 Section 6.1.1).
+type StatusReport struct {
+	Received  StatusAssertion
+	Forwarded StatusAssertion
+	Delivered StatusAssertion
+	Deleted   StatusAssertion
+	Reason    StatusReason
+	Subject   BundleRef
+}
+
+// CustodySignal tells the current custodian whether the next node accepted
+// custody of a bundle.
+type CustodySignal struct {
+	Accepted bool
+	Reason   StatusReason
+	Subject  BundleRef
+}
+
+// AdminRecord is the payload of a bundle with FlagAdminRecord set.
+type AdminRecord struct {
+	Type          AdminRecordType
+	StatusReport  *StatusReport
+	CustodySignal *CustodySignal
+}
+
+// NewStatusReport builds a status report about subject. kind is one of
+// FlagReportReception, FlagReportForwarding, FlagReportDelivery or
+// FlagReportDeletion and selects the asserted status item.
+func NewStatusReport(subject *Bundle, kind uint64, reason StatusReason, at time.Time) *AdminRecord {
+	assertion := StatusAssertion{Asserted: true}
+	if subject.BundleFlags&FlagStatusTimeRequested != 0 {
+		assertion.Time = at.UTC().Truncate(time.Millisecond)
+	}
+
+	report := &StatusReport{Reason: reason, Subject: RefOf(subject)}
+	switch kind {
+	case FlagReportReception:
+		report.Received = assertion
+	case FlagReportForwarding:
+		report.Forwarded = assertion
+	case FlagReportDelivery:
+		report.Delivered = assertion
+	case FlagReportDeletion:
+		report.Deleted = assertion
+	}
+	return &AdminRecord{Type: AdminRecordStatusReport, StatusReport: report}
+}
+
+// NewCustodySignal builds a custody signal about subject.
+func NewCustodySignal(subject *Bundle, accepted bool, reason StatusReason) *AdminRecord {
+	return &AdminRecord{
+		Type: AdminRecordCustodySignal,
+		CustodySignal: &CustodySignal{
+			Accepted: accepted,
+			Reason:   reason,
+			Subject:  RefOf(subject),
+		},
+	}
+}
+
+// NewAdminBundle wraps an administrative record in a bundle.
+func NewAdminBundle(source, destination string, record *AdminRecord) (*Bundle, error) {
+	payload, err := record.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	b := NewBundle(source, destination, payload)
+	b.BundleFlags |= FlagAdminRecord
+	b.ReportTo = NullEID
+	return b, nil
+}
+
+// IsAdminRecord reports whether the bundle carries an administrative record.
+func (b *Bundle) IsAdminRecord() bool {
+	return b.BundleFlags&FlagAdminRecord != 0
+}
+
+// Marshal encodes the record as the CBOR array [type, content].
+func (r *AdminRecord) Marshal() ([]byte, error) {
+	var w cborWriter
+	w.writeArray(2)
+	w.writeUint(uint64(r.Type))
+
+	switch r.Type {
+	case AdminRecordStatusReport:
+		if r.StatusReport == nil {
+			return nil, fmt.Errorf("status report record has no report")
+		}
+		rep := r.StatusReport
+		w.writeArray(refFields(rep.Subject) + 2)
+		w.writeArray(4)
+		for _, item := range []StatusAssertion{rep.Received, rep.Forwarded, rep.Delivered, rep.Deleted} {
+			encodeAssertion(&w, item)
+		}
+		w.writeUint(uint64(rep.Reason))
+		if err := encodeRef(&w, rep.Subject); err != nil {
+			return nil, err
+		}
+	case AdminRecordCustodySignal:
+		if r.CustodySignal == nil {
+			return nil, fmt.Errorf("custody signal record has no signal")
+		}
+		sig := r.CustodySignal
+		w.writeArray(refFields(sig.Subject) + 2)
+		w.writeBool(sig.Accepted)
+		w.writeUint(uint64(sig.Reason))
+		if err := encodeRef(&w, sig.Subject); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported administrative record type %d", r.Type)
+	}
+
+	return w.bytes(), nil
+}
+
+// ParseAdminRecord decodes the payload of an administrative record bundle.
+func ParseAdminRecord(payload []byte) (*AdminRecord, error) {
+	r := newCBORReader(payload)
+	if n, err := r.readArray(); err != nil || n != 2 {
+		return nil, fmt.Errorf("administrative record: expected 2-element array")
+	}
+	recordType, err := r.readUint()
+	if err != nil {
+		return nil, fmt.Errorf("administrative record type: %w", err)
+	}
+
+	record := &AdminRecord{Type: AdminRecordType(recordType)}
+	n, err := r.readArray()
+	if err != nil {
+		return nil, fmt.Errorf("administrative record content: %w", err)
+	}
+
+	switch record.Type {
+	case AdminRecordStatusReport:
+		if n != 4 && n != 6 {
+			return nil, fmt.Errorf("status report has %d fields", n)
+		}
+		rep := &StatusReport{}
+		if items, err := r.readArray(); err != nil || items != 4 {
+			return nil, fmt.Errorf("status report: expected 4 status items")
+		}
+		for _, item := range []*StatusAssertion{&rep.Received, &rep.Forwarded, &rep.Delivered, &rep.Deleted} {
+			if *item, err = decodeAssertion(r); err != nil {
+				return nil, fmt.Errorf("status item: %w", err)
+			}
+		}
+		reason, err := r.readUint()
+		if err != nil {
+			return nil, fmt.Errorf("status report reason: %w", err)
+		}
+		rep.Reason = StatusReason(reason)
+		if rep.Subject, err = decodeRef(r, n-2); err != nil {
+			return nil, fmt.Errorf("status report subject: %w", err)
+		}
+		record.StatusReport = rep
+	case AdminRecordCustodySignal:
+		if n != 4 && n != 6 {
+			return nil, fmt.Errorf("custody signal has %d fields", n)
+		}
+		sig := &CustodySignal{}
+		if sig.Accepted, err = r.readBool(); err != nil {
+			return nil, fmt.Errorf("custody disposition: %w", err)
+		}
+		reason, err := r.readUint()
+		if err != nil {
+			return nil, fmt.Errorf("custody signal reason: %w", err)
+		}
+		sig.Reason = StatusReason(reason)
+		if sig.Subject, err = decodeRef(r, n-2); err != nil {
+			return nil, fmt.Errorf("custody signal subject: %w", err)
+		}
+		record.CustodySignal = sig
+	default:
+		return nil, fmt.Errorf("unsupported administrative record type %d", recordType)
+	}
+
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("administrative record: %d trailing bytes", r.remaining())
+	}
+	return record, nil
+}
+
+// refFields returns the number of array elements a BundleRef occupies.
+func refFields(ref BundleRef) int {
+	if ref.IsFragment {
+		return 4
+	}
+	return 2
+}
+
+// encodeRef writes source EID, [creation time, sequence] and, for
+// fragments, the fragment offset and length.
+func encodeRef(w *cborWriter, ref BundleRef) error {
+	if err := encodeEIDString(w, ref.SourceEID); err != nil {
+		return fmt.Errorf("subject source: %w", err)
+	}
+	w.writeArray(2)
+	w.writeUint(DTNTime(ref.CreationTimestamp))
+	w.writeUint(ref.SequenceNumber)
+	if ref.IsFragment {
+		w.writeUint(ref.FragmentOffset)
+		w.writeUint(ref.FragmentLength)
+	}
+	return nil
+}
+
+func decodeRef(r *cborReader, fields int) (BundleRef, error) {
+	var ref BundleRef
+	eid, err := decodeEID(r)
+	if err != nil {
+		return ref, err
+	}
+	ref.SourceEID = eid.String()
+
+	if n, err := r.readArray(); err != nil || n != 2 {
+		return ref, fmt.Errorf("expected creation timestamp array")
+	}
+	ts, err := r.readUint()
+	if err != nil {
+		return ref, err
+	}
+	ref.CreationTimestamp = FromDTNTime(ts)
+	if ref.SequenceNumber, err = r.readUint(); err != nil {
+		return ref, err
+	}
+
+	if fields == 4 {
+		ref.IsFragment = true
+		if ref.FragmentOffset, err = r.readUint(); err != nil {
+			return ref, err
+		}
+		if ref.FragmentLength, err = r.readUint(); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func encodeAssertion(w *cborWriter, a StatusAssertion) {
+	if a.Asserted && !a.Time.IsZero() {
+		w.writeArray(2)
+		w.writeBool(true)
+		w.writeUint(DTNTime(a.Time))
+		return
+	}
+	w.writeArray(1)
+	w.writeBool(a.Asserted)
+}
+
+func decodeAssertion(r *cborReader) (StatusAssertion, error) {
+	var a StatusAssertion
+	n, err := r.readArray()
+	if err != nil {
+		return a, err
+	}
+	if n != 1 && n != 2 {
+		return a, fmt.Errorf("status item has %d fields", n)
+	}
+	if a.Asserted, err = r.readBool(); err != nil {
+		return a, err
+	}
+	if n == 2 {
+		ts, err := r.readUint()
+		if err != nil {
+			return a, err
+		}
+		a.Time = FromDTNTime(ts)
+	}
+	return a, nil
+}
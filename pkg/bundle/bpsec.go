@@ -0,0 +1,726 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+)
+
+// BPSec block types (RFC 9172 Section 11.1).
+const (
+	BlockTypeBIB uint64 = 11 // Block Integrity Block
+	BlockTypeBCB uint64 = 12 // Block Confidentiality Block
+)
+
+// Default security context identifiers (RFC 9173 Section 5.1).
+const (
+	SecurityContextHMACSHA2 uint64 = 1
+	SecurityContextAESGCM   uint64 = 2
+)
+
+// BIB-HMAC-SHA2 variants (RFC 9173 Section 3.3.1).
+const (
+	HMACSHA256 uint64 = 5
+	HMACSHA384 uint64 = 6
+	HMACSHA512 uint64 = 7
+)
+
+// BCB-AES-GCM variants (RFC 9173 Section 4.3.2).
+const (
+	AES128GCM uint64 = 1
+	AES256GCM uint64 = 3
+)
+
+// Integrity and AAD scope flags (RFC 9173 Sections 3.3.3 and 4.3.4).
+const (
+	ScopePrimary        uint64 = 0x1
+	ScopeTargetHeader   uint64 = 0x2
+	ScopeSecurityHeader uint64 = 0x4
+	ScopeAll                   = ScopePrimary | ScopeTargetHeader | ScopeSecurityHeader
+)
+
+// Security context parameter and result identifiers.
+const (
+	paramHMACVariant   uint64 = 1
+	paramHMACScope     uint64 = 3
+	paramAESIV         uint64 = 1
+	paramAESVariant    uint64 = 2
+	paramAESWrappedKey uint64 = 3
+	paramAESScope      uint64 = 4
+	resultHMAC         uint64 = 1
+	resultAuthTag      uint64 = 1
+	securityFlagParams uint64 = 0x1
+)
+
+// BPSec reason codes for status reports (RFC 9172 Section 11.2).
+const (
+	ReasonMissingSecurityOperation     StatusReason = 12
+	ReasonUnknownSecurityOperation     StatusReason = 13
+	ReasonUnexpectedSecurityOperation  StatusReason = 14
+	ReasonFailedSecurityOperation      StatusReason = 15
+	ReasonConflictingSecurityOperation StatusReason = 16
+)
+
+var (
+	// ErrSecurityKeyMissing is returned when no key is configured for a
+	// security source and bundle destination.
+	ErrSecurityKeyMissing = errors.New("bpsec: no key for security source")
+
+	// ErrIntegrityFailed is returned when a BIB signature does not match.
+	ErrIntegrityFailed = errors.New("bpsec: integrity check failed")
+
+	// ErrDecryptionFailed is returned when a BCB target fails authentication.
+	ErrDecryptionFailed = errors.New("bpsec: decryption failed")
+
+	// ErrUnsupportedContext is returned for security contexts other than the
+	// RFC 9173 defaults.
+	ErrUnsupportedContext = errors.New("bpsec: unsupported security context")
+
+	// ErrSecurityConflict is returned when a requested operation conflicts
+	// with an existing one (RFC 9172 Section 3.9).
+	ErrSecurityConflict = errors.New("bpsec: conflicting security operation")
+)
+
+// KeyLookup resolves the key shared between a security source and a bundle
+// destination.
+type KeyLookup interface {
+	Key(source, destination string) ([]byte, bool)
+}
+
+// KeyRing stores symmetric keys per security source and destination. Either
+// side may be "*" to match any endpoint.
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+// NewKeyRing creates an empty key ring.
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: make(map[string][]byte)}
+}
+
+// SetKey registers the key used between source and destination.
+func (k *KeyRing) SetKey(source, destination string, key []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[source+"|"+destination] = append([]byte(nil), key...)
+}
+
+// Key implements KeyLookup, preferring exact matches over wildcards.
+func (k *KeyRing) Key(source, destination string) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, name := range []string{source + "|" + destination, source + "|*", "*|" + destination, "*|*"} {
+		if key, ok := k.keys[name]; ok {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// SecurityBlock is the abstract security block carried by BIBs and BCBs
+// (RFC 9172 Section 3.6).
+type SecurityBlock struct {
+	Targets []uint64
+	Context uint64
+	Source  string
+	Params  map[uint64][]byte   // Encoded CBOR parameter values by ID
+	Results []map[uint64][]byte // Encoded CBOR result values by ID, one map per target
+}
+
+// Sign adds a BIB-HMAC-SHA2 block protecting the payload, using the key
+// shared between source and the bundle destination.
+func Sign(b *Bundle, source string, key []byte, variant uint64) error {
+	if hasTarget(b, BlockTypeBCB, PayloadBlockNumber) {
+		return fmt.Errorf("%w: payload is already encrypted", ErrSecurityConflict)
+	}
+	if hasTarget(b, BlockTypeBIB, PayloadBlockNumber) {
+		return fmt.Errorf("%w: payload already has a BIB", ErrSecurityConflict)
+	}
+	if _, err := hmacHash(variant); err != nil {
+		return err
+	}
+
+	blk := CanonicalBlock{Type: BlockTypeBIB, Number: nextBlockNumber(b), CRCType: CRCNone}
+	asb := &SecurityBlock{
+		Targets: []uint64{PayloadBlockNumber},
+		Context: SecurityContextHMACSHA2,
+		Source:  source,
+		Params: map[uint64][]byte{
+			paramHMACVariant: encodeUint(variant),
+			paramHMACScope:   encodeUint(ScopeAll),
+		},
+	}
+
+	mac, err := computeHMAC(b, blk, asb, PayloadBlockNumber, key)
+	if err != nil {
+		return err
+	}
+	asb.Results = []map[uint64][]byte{{resultHMAC: encodeBytes(mac)}}
+
+	if blk.Data, err = asb.Marshal(); err != nil {
+		return err
+	}
+	b.Blocks = append(b.Blocks, blk)
+	return nil
+}
+
+// Encrypt adds a BCB-AES-GCM block and encrypts the payload in place. The
+// AES variant follows the key length (16 or 32 bytes).
+func Encrypt(b *Bundle, source string, key []byte) error {
+	if hasTarget(b, BlockTypeBCB, PayloadBlockNumber) {
+		return fmt.Errorf("%w: payload is already encrypted", ErrSecurityConflict)
+	}
+	if hasTarget(b, BlockTypeBIB, PayloadBlockNumber) {
+		// Encrypting the BIB with the same IV would reuse a GCM nonce; AES-GCM
+		// already authenticates the payload, so use one or the other.
+		return fmt.Errorf("%w: payload already has a BIB", ErrSecurityConflict)
+	}
+
+	variant, err := aesVariant(key)
+	if err != nil {
+		return err
+	}
+	iv := make([]byte, 12)
+	if _, err := rand.Read(iv); err != nil {
+		return fmt.Errorf("bpsec: failed to generate IV: %w", err)
+	}
+
+	blk := CanonicalBlock{Type: BlockTypeBCB, Number: nextBlockNumber(b), Flags: BlockFlagReplicate, CRCType: CRCNone}
+	asb := &SecurityBlock{
+		Targets: []uint64{PayloadBlockNumber},
+		Context: SecurityContextAESGCM,
+		Source:  source,
+		Params: map[uint64][]byte{
+			paramAESIV:      encodeBytes(iv),
+			paramAESVariant: encodeUint(variant),
+			paramAESScope:   encodeUint(ScopeAll),
+		},
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	aad, err := securityScope(b, blk, asb, PayloadBlockNumber, ScopeAll)
+	if err != nil {
+		return err
+	}
+	sealed := aead.Seal(nil, iv, b.Payload, aad)
+	tagStart := len(sealed) - aead.Overhead()
+
+	asb.Results = []map[uint64][]byte{{resultAuthTag: encodeBytes(sealed[tagStart:])}}
+	if blk.Data, err = asb.Marshal(); err != nil {
+		return err
+	}
+	b.Payload = sealed[:tagStart]
+	b.Blocks = append(b.Blocks, blk)
+	return nil
+}
+
+// HasSecurity reports whether b carries BIBs or BCBs.
+func HasSecurity(b *Bundle) (bib, bcb bool) {
+	for _, blk := range b.Blocks {
+		switch blk.Type {
+		case BlockTypeBIB:
+			bib = true
+		case BlockTypeBCB:
+			bcb = true
+		}
+	}
+	return bib, bcb
+}
+
+// ProcessSecurity verifies the security blocks of a received bundle. As the
+// security acceptor (the bundle destination) it decrypts BCB targets and
+// removes all security blocks after successful checks. Otherwise it acts as
+// a verifier: BIBs with a known key are checked and left in place, and BCBs
+// are passed through untouched.
+func ProcessSecurity(b *Bundle, keys KeyLookup, acceptor bool) error {
+	if acceptor {
+		if err := decryptAll(b, keys); err != nil {
+			return err
+		}
+	}
+
+	var kept []CanonicalBlock
+	for _, blk := range b.Blocks {
+		if blk.Type != BlockTypeBIB {
+			kept = append(kept, blk)
+			continue
+		}
+		asb, err := ParseSecurityBlock(blk.Data)
+		if err != nil {
+			return fmt.Errorf("BIB %d: %w", blk.Number, err)
+		}
+		if asb.Context != SecurityContextHMACSHA2 {
+			return fmt.Errorf("BIB %d: %w %d", blk.Number, ErrUnsupportedContext, asb.Context)
+		}
+
+		key, ok := keys.Key(asb.Source, b.DestinationEID)
+		if !ok {
+			if acceptor {
+				return fmt.Errorf("%w %s", ErrSecurityKeyMissing, asb.Source)
+			}
+			kept = append(kept, blk)
+			continue
+		}
+		for i, target := range asb.Targets {
+			if i >= len(asb.Results) {
+				return fmt.Errorf("BIB %d: missing result for target %d", blk.Number, target)
+			}
+			expected, err := decodeBytesValue(asb.Results[i][resultHMAC])
+			if err != nil {
+				return fmt.Errorf("BIB %d: %w", blk.Number, err)
+			}
+			mac, err := computeHMAC(b, blk, asb, target, key)
+			if err != nil {
+				return fmt.Errorf("BIB %d: %w", blk.Number, err)
+			}
+			if !hmac.Equal(mac, expected) {
+				return fmt.Errorf("%w for block %d", ErrIntegrityFailed, target)
+			}
+		}
+		if !acceptor {
+			kept = append(kept, blk)
+		}
+	}
+	b.Blocks = kept
+	return nil
+}
+
+// decryptAll decrypts every BCB target in place and removes the BCBs. When
+// a BCB carries a wrapped key, the configured key is the key-encryption key
+// and the content-encryption key is unwrapped from the block.
+func decryptAll(b *Bundle, keys KeyLookup) error {
+	var kept []CanonicalBlock
+	for _, blk := range b.Blocks {
+		if blk.Type != BlockTypeBCB {
+			kept = append(kept, blk)
+			continue
+		}
+		asb, err := ParseSecurityBlock(blk.Data)
+		if err != nil {
+			return fmt.Errorf("BCB %d: %w", blk.Number, err)
+		}
+		if asb.Context != SecurityContextAESGCM {
+			return fmt.Errorf("BCB %d: %w %d", blk.Number, ErrUnsupportedContext, asb.Context)
+		}
+		key, ok := keys.Key(asb.Source, b.DestinationEID)
+		if !ok {
+			return fmt.Errorf("%w %s", ErrSecurityKeyMissing, asb.Source)
+		}
+		if raw, ok := asb.Params[paramAESWrappedKey]; ok {
+			wrapped, err := decodeBytesValue(raw)
+			if err != nil {
+				return fmt.Errorf("BCB %d: invalid wrapped key", blk.Number)
+			}
+			if key, err = unwrapKey(key, wrapped); err != nil {
+				return fmt.Errorf("BCB %d: %w", blk.Number, err)
+			}
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return err
+		}
+		iv, err := decodeBytesValue(asb.Params[paramAESIV])
+		if err != nil || len(iv) != aead.NonceSize() {
+			return fmt.Errorf("BCB %d: invalid IV", blk.Number)
+		}
+		scope := asb.paramUint(paramAESScope, ScopeAll)
+
+		for i, target := range asb.Targets {
+			data, err := targetData(b, target)
+			if err != nil {
+				return fmt.Errorf("BCB %d: %w", blk.Number, err)
+			}
+			if i >= len(asb.Results) {
+				return fmt.Errorf("BCB %d: missing result for target %d", blk.Number, target)
+			}
+			tag, err := decodeBytesValue(asb.Results[i][resultAuthTag])
+			if err != nil {
+				return fmt.Errorf("BCB %d: %w", blk.Number, err)
+			}
+			aad, err := securityScope(b, blk, asb, target, scope)
+			if err != nil {
+				return err
+			}
+			plain, err := aead.Open(nil, iv, append(append([]byte(nil), *data...), tag...), aad)
+			if err != nil {
+				return fmt.Errorf("%w for block %d", ErrDecryptionFailed, target)
+			}
+			*data = plain
+		}
+	}
+	b.Blocks = kept
+	return nil
+}
+
+// computeHMAC returns the BIB-HMAC-SHA2 result for one target.
+func computeHMAC(b *Bundle, secBlk CanonicalBlock, asb *SecurityBlock, target uint64, key []byte) ([]byte, error) {
+	newHash, err := hmacHash(asb.paramUint(paramHMACVariant, HMACSHA384))
+	if err != nil {
+		return nil, err
+	}
+	ippt, err := securityScope(b, secBlk, asb, target, asb.paramUint(paramHMACScope, ScopeAll))
+	if err != nil {
+		return nil, err
+	}
+
+	var w cborWriter
+	w.writeRaw(ippt)
+	if target != 0 {
+		// A primary block target is covered by the scope prefix alone
+		data, err := targetData(b, target)
+		if err != nil {
+			return nil, err
+		}
+		w.writeBytes(*data)
+	}
+
+	mac := hmac.New(newHash, key)
+	mac.Write(w.bytes())
+	return mac.Sum(nil), nil
+}
+
+// securityScope builds the integrity-protected plaintext prefix or the
+// additional authenticated data (RFC 9173 Sections 3.7 and 4.7).
+func securityScope(b *Bundle, secBlk CanonicalBlock, asb *SecurityBlock, target, scope uint64) ([]byte, error) {
+	var w cborWriter
+	w.writeUint(scope)
+
+	if scope&ScopePrimary != 0 || target == 0 {
+		primary, err := EncodePrimaryBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		w.writeRaw(primary)
+	}
+	if scope&ScopeTargetHeader != 0 && target != 0 {
+		blockType, flags, err := targetHeader(b, target)
+		if err != nil {
+			return nil, err
+		}
+		w.writeUint(blockType)
+		w.writeUint(target)
+		w.writeUint(flags)
+	}
+	if scope&ScopeSecurityHeader != 0 {
+		w.writeUint(secBlk.Type)
+		w.writeUint(secBlk.Number)
+		w.writeUint(secBlk.Flags)
+	}
+	return w.bytes(), nil
+}
+
+// targetData returns a pointer to a target block's type-specific data.
+func targetData(b *Bundle, number uint64) (*[]byte, error) {
+	if number == PayloadBlockNumber {
+		return &b.Payload, nil
+	}
+	for i := range b.Blocks {
+		if b.Blocks[i].Number == number {
+			return &b.Blocks[i].Data, nil
+		}
+	}
+	return nil, fmt.Errorf("security target block %d not found", number)
+}
+
+func targetHeader(b *Bundle, number uint64) (uint64, uint64, error) {
+	if number == PayloadBlockNumber {
+		return BlockTypePayload, 0, nil
+	}
+	for _, blk := range b.Blocks {
+		if blk.Number == number {
+			return blk.Type, blk.Flags, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("security target block %d not found", number)
+}
+
+// hasTarget reports whether a security block of blockType targets number.
+func hasTarget(b *Bundle, blockType, number uint64) bool {
+	for _, blk := range b.Blocks {
+		if blk.Type != blockType {
+			continue
+		}
+		asb, err := ParseSecurityBlock(blk.Data)
+		if err != nil {
+			continue
+		}
+		for _, target := range asb.Targets {
+			if target == number {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextBlockNumber returns an unused block number for a new extension block.
+// Numbers below 100 are left for blocks the codec emits on encoding.
+func nextBlockNumber(b *Bundle) uint64 {
+	next := uint64(100)
+	for _, blk := range b.Blocks {
+		if blk.Number >= next {
+			next = blk.Number + 1
+		}
+	}
+	return next
+}
+
+func hmacHash(variant uint64) (func() hash.Hash, error) {
+	switch variant {
+	case HMACSHA256:
+		return sha256.New, nil
+	case HMACSHA384:
+		return sha512.New384, nil
+	case HMACSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("bpsec: unsupported HMAC variant %d", variant)
+	}
+}
+
+func aesVariant(key []byte) (uint64, error) {
+	switch len(key) {
+	case 16:
+		return AES128GCM, nil
+	case 32:
+		return AES256GCM, nil
+	default:
+		return 0, fmt.Errorf("bpsec: AES-GCM key must be 16 or 32 bytes, got %d", len(key))
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if _, err := aesVariant(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("bpsec: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyWrapIV is the default initial value of AES Key Wrap (RFC 3394
+// Section 2.2.3.1).
+var keyWrapIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// unwrapKey recovers a content-encryption key wrapped with AES Key Wrap
+// (RFC 3394 Section 2.2.2), as carried in the BCB wrapped key parameter.
+func unwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, fmt.Errorf("%w: wrapped key of %d bytes", ErrDecryptionFailed, len(wrapped))
+	}
+	if _, err := aesVariant(kek); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("bpsec: %w", err)
+	}
+
+	n := len(wrapped)/8 - 1
+	var a [8]byte
+	copy(a[:], wrapped[:8])
+	key := append([]byte(nil), wrapped[8:]...)
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := binary.BigEndian.Uint64(a[:]) ^ uint64(n*j+i)
+			binary.BigEndian.PutUint64(buf[:8], t)
+			copy(buf[8:], key[(i-1)*8:i*8])
+			block.Decrypt(buf[:], buf[:])
+			copy(a[:], buf[:8])
+			copy(key[(i-1)*8:i*8], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a[:], keyWrapIV[:]) != 1 {
+		return nil, fmt.Errorf("%w: key unwrap integrity check", ErrDecryptionFailed)
+	}
+	return key, nil
+}
+
+// Marshal encodes the abstract security block as a CBOR sequence.
+func (s *SecurityBlock) Marshal() ([]byte, error) {
+	var w cborWriter
+	w.writeArray(len(s.Targets))
+	for _, t := range s.Targets {
+		w.writeUint(t)
+	}
+	w.writeUint(s.Context)
+
+	flags := uint64(0)
+	if len(s.Params) > 0 {
+		flags |= securityFlagParams
+	}
+	w.writeUint(flags)
+	if err := encodeEIDString(&w, s.Source); err != nil {
+		return nil, fmt.Errorf("security source: %w", err)
+	}
+	if len(s.Params) > 0 {
+		writeIDValues(&w, s.Params)
+	}
+
+	w.writeArray(len(s.Results))
+	for _, result := range s.Results {
+		writeIDValues(&w, result)
+	}
+	return w.bytes(), nil
+}
+
+// ParseSecurityBlock decodes the abstract security block of a BIB or BCB.
+func ParseSecurityBlock(data []byte) (*SecurityBlock, error) {
+	r := newCBORReader(data)
+	s := &SecurityBlock{}
+
+	n, err := r.readArray()
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("bpsec: invalid security targets")
+	}
+	seen := make(map[uint64]bool, n)
+	for i := 0; i < n; i++ {
+		t, err := r.readUint()
+		if err != nil {
+			return nil, fmt.Errorf("bpsec: security target: %w", err)
+		}
+		if seen[t] {
+			return nil, fmt.Errorf("bpsec: duplicate security target %d", t)
+		}
+		seen[t] = true
+		s.Targets = append(s.Targets, t)
+	}
+
+	if s.Context, err = r.readUint(); err != nil {
+		return nil, fmt.Errorf("bpsec: security context: %w", err)
+	}
+	flags, err := r.readUint()
+	if err != nil {
+		return nil, fmt.Errorf("bpsec: security flags: %w", err)
+	}
+	eid, err := decodeEID(r)
+	if err != nil {
+		return nil, fmt.Errorf("bpsec: security source: %w", err)
+	}
+	s.Source = eid.String()
+
+	if flags&securityFlagParams != 0 {
+		if s.Params, err = readIDValues(r); err != nil {
+			return nil, fmt.Errorf("bpsec: parameters: %w", err)
+		}
+	}
+
+	n, err = r.readArray()
+	if err != nil || n != len(s.Targets) {
+		return nil, fmt.Errorf("bpsec: expected %d security results", len(s.Targets))
+	}
+	for i := 0; i < n; i++ {
+		result, err := readIDValues(r)
+		if err != nil {
+			return nil, fmt.Errorf("bpsec: results: %w", err)
+		}
+		s.Results = append(s.Results, result)
+	}
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("bpsec: %d trailing bytes", r.remaining())
+	}
+	return s, nil
+}
+
+// paramUint returns an unsigned parameter value, or def if absent.
+func (s *SecurityBlock) paramUint(id, def uint64) uint64 {
+	raw, ok := s.Params[id]
+	if !ok {
+		return def
+	}
+	v, err := newCBORReader(raw).readUint()
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// writeIDValues writes [[id, value], ...] sorted by id for deterministic output.
+func writeIDValues(w *cborWriter, values map[uint64][]byte) {
+	ids := make([]uint64, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	w.writeArray(len(ids))
+	for _, id := range ids {
+		w.writeArray(2)
+		w.writeUint(id)
+		w.writeRaw(values[id])
+	}
+}
+
+func readIDValues(r *cborReader) (map[uint64][]byte, error) {
+	n, err := r.readArray()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[uint64][]byte, n)
+	for i := 0; i < n; i++ {
+		if pair, err := r.readArray(); err != nil || pair != 2 {
+			return nil, fmt.Errorf("expected [id, value] pair")
+		}
+		id, err := r.readUint()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.skip()
+		if err != nil {
+			return nil, err
+		}
+		values[id] = append([]byte(nil), value...)
+	}
+	return values, nil
+}
+
+func encodeUint(v uint64) []byte {
+	var w cborWriter
+	w.writeUint(v)
+	return append([]byte(nil), w.bytes()...)
+}
+
+func encodeBytes(b []byte) []byte {
+	var w cborWriter
+	w.writeBytes(b)
+	return append([]byte(nil), w.bytes()...)
+}
+
+func decodeBytesValue(raw []byte) ([]byte, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("missing value")
+	}
+	return newCBORReader(raw).readBytes()
+}
+
+// SecurityReason maps a BPSec processing error to a status report reason.
+func SecurityReason(err error) StatusReason {
+	switch {
+	case err == nil:
+		return ReasonNoInfo
+	case errors.Is(err, ErrSecurityConflict):
+		return ReasonConflictingSecurityOperation
+	case errors.Is(err, ErrUnsupportedContext):
+		return ReasonUnknownSecurityOperation
+	default:
+		return ReasonFailedSecurityOperation
+	}
+}
@@ -0,0 +1,760 @@
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Block type codes (RFC 9171 Section 9.1).
+const (
+	BlockTypePrimary      uint64 = 0 // Pseudo-type used to target the primary block
+	BlockTypePayload      uint64 = 1
+	BlockTypePreviousNode uint64 = 6
+	BlockTypeBundleAge    uint64 = 7
+	BlockTypeHopCount     uint64 = 10
+
+	// BlockTypeASGARDMetadata is a private-use block (RFC 9171 Section 9.1,
+	// range 192-255) carrying the ASGARD bundle UUID and priority so they
+	// survive a round trip through the standard encoding.
+	BlockTypeASGARDMetadata uint64 = 192
+
+	// BlockTypeCustody is a private-use block carrying the EID of the node
+	// currently holding custody of the bundle.
+	BlockTypeCustody uint64 = 193
+)
+
+// Block processing control flags (RFC 9171 Section 4.2.4).
+const (
+	BlockFlagReplicate            uint64 = 0x01
+	BlockFlagReportIfUnprocessed  uint64 = 0x02
+	BlockFlagDeleteIfUnprocessed  uint64 = 0x04
+	BlockFlagDiscardIfUnprocessed uint64 = 0x10
+)
+
+// PayloadBlockNumber is the fixed block number of the payload block.
+const PayloadBlockNumber uint64 = 1
+
+// DTNEpoch is the reference time for BPv7 timestamps (2000-01-01T00:00:00Z).
+var DTNEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// bundleIDNamespace derives stable UUIDs for bundles created by foreign nodes.
+var bundleIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("urn:asgard:bpv7:bundle"))
+
+// CanonicalBlock is a BPv7 extension block that is carried through
+// encoding unchanged (e.g. BPSec blocks or blocks from other implementations).
+type CanonicalBlock struct {
+	Type    uint64 `json:"type"`
+	Number  uint64 `json:"number"`
+	Flags   uint64 `json:"flags"`
+	CRCType uint8  `json:"crcType"`
+	Data    []byte `json:"data"`
+}
+
+// Clone returns a deep copy of the block.
+func (c CanonicalBlock) Clone() CanonicalBlock {
+	data := make([]byte, len(c.Data))
+	copy(data, c.Data)
+	c.Data = data
+	return c
+}
+
+// DTNTime converts a wall-clock time to milliseconds since the DTN epoch.
+// The zero time maps to 0, which BPv7 uses for nodes without an accurate clock.
+func DTNTime(t time.Time) uint64 {
+	if t.IsZero() || !t.After(DTNEpoch) {
+		return 0
+	}
+	return uint64(t.Sub(DTNEpoch) / time.Millisecond)
+}
+
+// FromDTNTime converts milliseconds since the DTN epoch to a UTC time.
+func FromDTNTime(ms uint64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return DTNEpoch.Add(time.Duration(ms) * time.Millisecond)
+}
+
+// CBORCodec encodes bundles in the RFC 9171 CBOR wire format.
+type CBORCodec struct {
+	// OmitMetadata suppresses the ASGARD metadata block. Bundles decoded by
+	// peers then get an ID derived from source EID and creation timestamp.
+	OmitMetadata bool
+}
+
+// Name implements Codec.
+func (c CBORCodec) Name() string {
+	return "cbor"
+}
+
+// Encode implements Codec.
+func (c CBORCodec) Encode(w io.Writer, b *Bundle) error {
+	data, err := c.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// Decode implements Codec. It reads exactly one bundle from the stream.
+func (c CBORCodec) Decode(r io.Reader) (*Bundle, error) {
+	data, err := readCBORItem(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	return c.Unmarshal(data)
+}
+
+// Marshal implements Codec.
+func (c CBORCodec) Marshal(b *Bundle) ([]byte, error) {
+	if err := validateForEncoding(b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	var w cborWriter
+	w.writeRaw([]byte{cborIndefArray})
+
+	primary, err := EncodePrimaryBlock(b)
+	if err != nil {
+		return nil, err
+	}
+	w.writeRaw(primary)
+
+	blocks, err := c.extensionBlocks(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, blk := range blocks {
+		data, err := EncodeCanonicalBlock(blk)
+		if err != nil {
+			return nil, err
+		}
+		w.writeRaw(data)
+	}
+
+	payload, err := EncodeCanonicalBlock(CanonicalBlock{
+		Type:    BlockTypePayload,
+		Number:  PayloadBlockNumber,
+		CRCType: b.CRCType,
+		Data:    b.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.writeRaw(payload)
+	w.writeRaw([]byte{cborBreak})
+
+	return w.bytes(), nil
+}
+
+// Unmarshal implements Codec.
+func (c CBORCodec) Unmarshal(data []byte) (*Bundle, error) {
+	r := newCBORReader(data)
+	n, err := r.readArray()
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+
+	b, err := decodePrimaryBlock(r, data)
+	if err != nil {
+		return nil, fmt.Errorf("primary block: %w", err)
+	}
+	b.Priority = PriorityNormal
+
+	hasMetadata := false
+	hasPayload := false
+	for i := 1; n < 0 || i < n; i++ {
+		if n < 0 {
+			done, err := r.atBreak()
+			if err != nil {
+				return nil, fmt.Errorf("bundle: %w", err)
+			}
+			if done {
+				break
+			}
+		}
+
+		blk, err := decodeCanonicalBlock(r, data)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+		if hasPayload {
+			return nil, fmt.Errorf("block %d follows the payload block", blk.Number)
+		}
+
+		switch blk.Type {
+		case BlockTypePayload:
+			if blk.Number != PayloadBlockNumber {
+				return nil, fmt.Errorf("payload block has number %d", blk.Number)
+			}
+			b.Payload = blk.Data
+			hasPayload = true
+		case BlockTypePreviousNode:
+			eid, err := decodeEID(newCBORReader(blk.Data))
+			if err != nil {
+				return nil, fmt.Errorf("previous node block: %w", err)
+			}
+			b.PreviousNode = eid.String()
+		case BlockTypeBundleAge:
+			age, err := newCBORReader(blk.Data).readUint()
+			if err != nil {
+				return nil, fmt.Errorf("bundle age block: %w", err)
+			}
+			b.BundleAge = time.Duration(age) * time.Millisecond
+		case BlockTypeHopCount:
+			if err := decodeHopCount(blk.Data, b); err != nil {
+				return nil, fmt.Errorf("hop count block: %w", err)
+			}
+		case BlockTypeCustody:
+			eid, err := decodeEID(newCBORReader(blk.Data))
+			if err != nil {
+				return nil, fmt.Errorf("custody block: %w", err)
+			}
+			b.Custodian = eid.String()
+		case BlockTypeASGARDMetadata:
+			if err := decodeMetadata(blk.Data, b); err != nil {
+				return nil, fmt.Errorf("metadata block: %w", err)
+			}
+			hasMetadata = true
+		default:
+			b.Blocks = append(b.Blocks, blk)
+		}
+	}
+
+	if !hasPayload {
+		return nil, fmt.Errorf("bundle has no payload block")
+	}
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("bundle: %d trailing bytes", r.remaining())
+	}
+	if !hasMetadata {
+		b.ID = DeriveBundleID(b)
+	}
+
+	return b, nil
+}
+
+// DeriveBundleID returns a deterministic UUID from the BPv7 bundle identity
+// (source, creation timestamp, and fragment offset).
+func DeriveBundleID(b *Bundle) uuid.UUID {
+	name := fmt.Sprintf("%s|%d|%d|%d", b.SourceEID, DTNTime(b.CreationTimestamp), b.SequenceNumber, b.FragmentOffset)
+	return uuid.NewSHA1(bundleIDNamespace, []byte(name))
+}
+
+// extensionBlocks returns the extension blocks to emit between the primary
+// and payload blocks, with block numbers that do not collide with b.Blocks.
+func (c CBORCodec) extensionBlocks(b *Bundle) ([]CanonicalBlock, error) {
+	used := map[uint64]bool{0: true, PayloadBlockNumber: true}
+	for _, blk := range b.Blocks {
+		if used[blk.Number] {
+			return nil, fmt.Errorf("duplicate block number %d", blk.Number)
+		}
+		used[blk.Number] = true
+	}
+	next := uint64(2)
+	allocate := func() uint64 {
+		for used[next] {
+			next++
+		}
+		used[next] = true
+		return next
+	}
+
+	var blocks []CanonicalBlock
+
+	if !c.OmitMetadata {
+		var w cborWriter
+		w.writeArray(2)
+		idBytes, _ := b.ID.MarshalBinary()
+		w.writeBytes(idBytes)
+		w.writeUint(uint64(b.Priority))
+		blocks = append(blocks, CanonicalBlock{
+			Type: BlockTypeASGARDMetadata, Number: allocate(), CRCType: b.CRCType, Data: w.bytes(),
+		})
+	}
+
+	if b.PreviousNode != "" {
+		var w cborWriter
+		if err := encodeEIDString(&w, b.PreviousNode); err != nil {
+			return nil, fmt.Errorf("previous node: %w", err)
+		}
+		blocks = append(blocks, CanonicalBlock{
+			Type: BlockTypePreviousNode, Number: allocate(), CRCType: b.CRCType, Data: w.bytes(),
+		})
+	}
+
+	if b.Custodian != "" {
+		var w cborWriter
+		if err := encodeEIDString(&w, b.Custodian); err != nil {
+			return nil, fmt.Errorf("custodian: %w", err)
+		}
+		blocks = append(blocks, CanonicalBlock{
+			Type: BlockTypeCustody, Number: allocate(), Flags: BlockFlagReplicate, CRCType: b.CRCType, Data: w.bytes(),
+		})
+	}
+
+	if b.BundleAge > 0 {
+		var w cborWriter
+		w.writeUint(uint64(b.BundleAge / time.Millisecond))
+		blocks = append(blocks, CanonicalBlock{
+			Type: BlockTypeBundleAge, Number: allocate(), CRCType: b.CRCType, Data: w.bytes(),
+		})
+	}
+
+	if b.HopCount > 0 {
+		var w cborWriter
+		w.writeArray(2)
+		w.writeUint(uint64(MaxHopCount))
+		w.writeUint(uint64(b.HopCount))
+		blocks = append(blocks, CanonicalBlock{
+			Type: BlockTypeHopCount, Number: allocate(), CRCType: b.CRCType, Data: w.bytes(),
+		})
+	}
+
+	for _, blk := range b.Blocks {
+		blocks = append(blocks, blk)
+	}
+
+	return blocks, nil
+}
+
+// EncodePrimaryBlock returns the CBOR encoding of the bundle's primary block,
+// including its CRC when b.CRCType is non-zero.
+func EncodePrimaryBlock(b *Bundle) ([]byte, error) {
+	crcLen, err := crcLength(b.CRCType)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := b.BundleFlags
+	if b.IsFragment {
+		flags |= FlagIsFragment
+	} else {
+		flags &^= FlagIsFragment
+	}
+
+	fields := 8
+	if b.IsFragment {
+		fields += 2
+	}
+	if crcLen > 0 {
+		fields++
+	}
+
+	var w cborWriter
+	w.writeArray(fields)
+	w.writeUint(uint64(b.Version))
+	w.writeUint(flags)
+	w.writeUint(uint64(b.CRCType))
+	if err := encodeEIDString(&w, b.DestinationEID); err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+	if err := encodeEIDString(&w, b.SourceEID); err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	if err := encodeEIDString(&w, b.ReportTo); err != nil {
+		return nil, fmt.Errorf("report-to: %w", err)
+	}
+	w.writeArray(2)
+	w.writeUint(DTNTime(b.CreationTimestamp))
+	w.writeUint(b.SequenceNumber)
+	w.writeUint(uint64(b.Lifetime / time.Millisecond))
+	if b.IsFragment {
+		w.writeUint(b.FragmentOffset)
+		w.writeUint(b.TotalADULength)
+	}
+
+	return appendCRC(&w, b.CRCType, crcLen), nil
+}
+
+// EncodeCanonicalBlock returns the CBOR encoding of a canonical block.
+func EncodeCanonicalBlock(blk CanonicalBlock) ([]byte, error) {
+	crcLen, err := crcLength(blk.CRCType)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := 5
+	if crcLen > 0 {
+		fields++
+	}
+
+	var w cborWriter
+	w.writeArray(fields)
+	w.writeUint(blk.Type)
+	w.writeUint(blk.Number)
+	w.writeUint(blk.Flags)
+	w.writeUint(uint64(blk.CRCType))
+	w.writeBytes(blk.Data)
+
+	return appendCRC(&w, blk.CRCType, crcLen), nil
+}
+
+// appendCRC writes a zeroed CRC field, computes the CRC over the whole block
+// and patches it in place, as required by RFC 9171 Section 4.2.1.
+func appendCRC(w *cborWriter, crcType uint8, crcLen int) []byte {
+	if crcLen == 0 {
+		return w.bytes()
+	}
+	w.writeBytes(make([]byte, crcLen))
+	out := w.bytes()
+	copy(out[len(out)-crcLen:], computeCRC(crcType, out))
+	return out
+}
+
+// verifyCRC checks the CRC of an encoded block whose CRC value occupies the
+// final crcLen bytes of raw.
+func verifyCRC(crcType uint8, raw []byte, crcLen int) error {
+	if crcLen == 0 {
+		return nil
+	}
+	scratch := make([]byte, len(raw))
+	copy(scratch, raw)
+	received := scratch[len(scratch)-crcLen:]
+	want := make([]byte, crcLen)
+	copy(want, received)
+	for i := range received {
+		received[i] = 0
+	}
+	if !bytes.Equal(computeCRC(crcType, scratch), want) {
+		return fmt.Errorf("CRC mismatch")
+	}
+	return nil
+}
+
+func decodePrimaryBlock(r *cborReader, data []byte) (*Bundle, error) {
+	start := r.pos
+	n, err := r.readArray()
+	if err != nil {
+		return nil, err
+	}
+	if n < 8 || n > 11 {
+		return nil, fmt.Errorf("unexpected field count %d", n)
+	}
+
+	b := &Bundle{}
+	version, err := r.readUint()
+	if err != nil {
+		return nil, fmt.Errorf("version: %w", err)
+	}
+	if version != uint64(BPv7Version) {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+	b.Version = uint8(version)
+
+	if b.BundleFlags, err = r.readUint(); err != nil {
+		return nil, fmt.Errorf("flags: %w", err)
+	}
+	b.IsFragment = b.BundleFlags&FlagIsFragment != 0
+
+	crcType, err := r.readUint()
+	if err != nil {
+		return nil, fmt.Errorf("crc type: %w", err)
+	}
+	crcLen, err := crcLength(uint8(crcType))
+	if err != nil || crcType > 0xff {
+		return nil, fmt.Errorf("unsupported CRC type %d", crcType)
+	}
+	b.CRCType = uint8(crcType)
+
+	expected := 8
+	if b.IsFragment {
+		expected += 2
+	}
+	if crcLen > 0 {
+		expected++
+	}
+	if n != expected {
+		return nil, fmt.Errorf("field count %d does not match flags (expected %d)", n, expected)
+	}
+
+	eids := []*string{&b.DestinationEID, &b.SourceEID, &b.ReportTo}
+	for _, target := range eids {
+		eid, err := decodeEID(r)
+		if err != nil {
+			return nil, err
+		}
+		*target = eid.String()
+	}
+
+	tsLen, err := r.readArray()
+	if err != nil || tsLen != 2 {
+		return nil, fmt.Errorf("malformed creation timestamp")
+	}
+	createdMs, err := r.readUint()
+	if err != nil {
+		return nil, fmt.Errorf("creation time: %w", err)
+	}
+	b.CreationTimestamp = FromDTNTime(createdMs)
+	if b.SequenceNumber, err = r.readUint(); err != nil {
+		return nil, fmt.Errorf("sequence number: %w", err)
+	}
+
+	lifetimeMs, err := r.readUint()
+	if err != nil {
+		return nil, fmt.Errorf("lifetime: %w", err)
+	}
+	b.Lifetime = time.Duration(lifetimeMs) * time.Millisecond
+
+	if b.IsFragment {
+		if b.FragmentOffset, err = r.readUint(); err != nil {
+			return nil, fmt.Errorf("fragment offset: %w", err)
+		}
+		if b.TotalADULength, err = r.readUint(); err != nil {
+			return nil, fmt.Errorf("total ADU length: %w", err)
+		}
+	}
+
+	if crcLen > 0 {
+		crc, err := r.readBytes()
+		if err != nil || len(crc) != crcLen {
+			return nil, fmt.Errorf("malformed CRC field")
+		}
+		if err := verifyCRC(b.CRCType, data[start:r.pos], crcLen); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func decodeCanonicalBlock(r *cborReader, data []byte) (CanonicalBlock, error) {
+	start := r.pos
+	n, err := r.readArray()
+	if err != nil {
+		return CanonicalBlock{}, err
+	}
+	if n != 5 && n != 6 {
+		return CanonicalBlock{}, fmt.Errorf("unexpected field count %d", n)
+	}
+
+	var blk CanonicalBlock
+	if blk.Type, err = r.readUint(); err != nil {
+		return blk, fmt.Errorf("block type: %w", err)
+	}
+	if blk.Number, err = r.readUint(); err != nil {
+		return blk, fmt.Errorf("block number: %w", err)
+	}
+	if blk.Flags, err = r.readUint(); err != nil {
+		return blk, fmt.Errorf("block flags: %w", err)
+	}
+	crcType, err := r.readUint()
+	if err != nil {
+		return blk, fmt.Errorf("crc type: %w", err)
+	}
+	crcLen, err := crcLength(uint8(crcType))
+	if err != nil || crcType > 0xff {
+		return blk, fmt.Errorf("unsupported CRC type %d", crcType)
+	}
+	blk.CRCType = uint8(crcType)
+	if (crcLen > 0) != (n == 6) {
+		return blk, fmt.Errorf("field count %d does not match CRC type %d", n, crcType)
+	}
+
+	content, err := r.readBytes()
+	if err != nil {
+		return blk, fmt.Errorf("block data: %w", err)
+	}
+	blk.Data = append([]byte(nil), content...)
+
+	if crcLen > 0 {
+		crc, err := r.readBytes()
+		if err != nil || len(crc) != crcLen {
+			return blk, fmt.Errorf("malformed CRC field")
+		}
+		if err := verifyCRC(blk.CRCType, data[start:r.pos], crcLen); err != nil {
+			return blk, err
+		}
+	}
+
+	return blk, nil
+}
+
+func decodeHopCount(data []byte, b *Bundle) error {
+	r := newCBORReader(data)
+	n, err := r.readArray()
+	if err != nil {
+		return err
+	}
+	if n != 2 {
+		return fmt.Errorf("expected [limit, count], got %d elements", n)
+	}
+	if _, err := r.readUint(); err != nil {
+		return err
+	}
+	count, err := r.readUint()
+	if err != nil {
+		return err
+	}
+	if count > uint64(MaxHopCount) {
+		return fmt.Errorf("hop count %d exceeds maximum", count)
+	}
+	b.HopCount = uint32(count)
+	return nil
+}
+
+func decodeMetadata(data []byte, b *Bundle) error {
+	r := newCBORReader(data)
+	n, err := r.readArray()
+	if err != nil {
+		return err
+	}
+	if n != 2 {
+		return fmt.Errorf("expected [id, priority], got %d elements", n)
+	}
+	idBytes, err := r.readBytes()
+	if err != nil {
+		return err
+	}
+	id, err := uuid.FromBytes(idBytes)
+	if err != nil {
+		return err
+	}
+	priority, err := r.readUint()
+	if err != nil {
+		return err
+	}
+	if priority > uint64(PriorityExpedited) {
+		return fmt.Errorf("invalid priority %d", priority)
+	}
+	b.ID = id
+	b.Priority = uint8(priority)
+	return nil
+}
+
+// validateForEncoding checks the structural fields the wire format needs.
+// Unlike Validate it does not reject expired bundles, so custody copies and
+// status reports about old bundles can still be serialized.
+func validateForEncoding(b *Bundle) error {
+	if b.Version != BPv7Version {
+		return fmt.Errorf("invalid bundle version: %d (expected %d)", b.Version, BPv7Version)
+	}
+	if b.DestinationEID == "" {
+		return fmt.Errorf("destination EID cannot be empty")
+	}
+	if b.SourceEID == "" {
+		return fmt.Errorf("source EID cannot be empty")
+	}
+	if b.Priority > PriorityExpedited {
+		return fmt.Errorf("invalid priority: %d", b.Priority)
+	}
+	if !b.CreationTimestamp.IsZero() && !b.CreationTimestamp.After(DTNEpoch) {
+		return fmt.Errorf("creation timestamp precedes DTN epoch")
+	}
+	return nil
+}
+
+// PayloadOffset returns the byte offset at which payload data begins in a
+// CBOR-encoded bundle, along with the declared payload length. It tolerates
+// truncation after the payload block header, so it can be applied to a
+// partially transmitted bundle.
+func PayloadOffset(data []byte) (int, uint64, error) {
+	_, offset, length, err := locatePayload(data)
+	return offset, length, err
+}
+
+// locatePayload walks a (possibly truncated) CBOR bundle and returns the
+// start of the payload block, the start of its data, and the declared length.
+func locatePayload(data []byte) (int, int, uint64, error) {
+	r := newCBORReader(data)
+	if _, err := r.readArray(); err != nil {
+		return 0, 0, 0, err
+	}
+	if _, err := r.skip(); err != nil {
+		return 0, 0, 0, fmt.Errorf("primary block: %w", err)
+	}
+
+	for {
+		blockStart := r.pos
+		n, err := r.readArray()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if n != 5 && n != 6 {
+			return 0, 0, 0, fmt.Errorf("unexpected block field count %d", n)
+		}
+		blockType, err := r.readUint()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for i := 0; i < 3; i++ { // number, flags, CRC type
+			if _, err := r.readUint(); err != nil {
+				return 0, 0, 0, err
+			}
+		}
+
+		if blockType == BlockTypePayload {
+			major, info, length, err := r.readHead()
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			if major != cborMajorBytes || info == cborIndefinite {
+				return 0, 0, 0, fmt.Errorf("payload block data is not a definite byte string")
+			}
+			return blockStart, r.pos, length, nil
+		}
+
+		for i := 4; i < n; i++ { // data and optional CRC
+			if _, err := r.skip(); err != nil {
+				return 0, 0, 0, err
+			}
+		}
+	}
+}
+
+// UnmarshalPartial decodes a CBOR bundle that was cut off during transfer and
+// returns the received part of the payload as a fragment (RFC 9171 Section
+// 5.8 reactive fragmentation). The payload CRC cannot be verified.
+func UnmarshalPartial(data []byte) (*Bundle, error) {
+	blockStart, dataStart, declared, err := locatePayload(data)
+	if err != nil {
+		return nil, fmt.Errorf("partial bundle: %w", err)
+	}
+
+	available := uint64(len(data) - dataStart)
+	if available >= declared {
+		return CBOR.Unmarshal(data)
+	}
+	if available == 0 {
+		return nil, fmt.Errorf("partial bundle carries no payload bytes")
+	}
+
+	// Re-frame the received prefix with a truncated payload block.
+	var w cborWriter
+	w.writeRaw(data[:blockStart])
+	payload, err := EncodeCanonicalBlock(CanonicalBlock{
+		Type:   BlockTypePayload,
+		Number: PayloadBlockNumber,
+		Data:   data[dataStart:],
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.writeRaw(payload)
+	w.writeRaw([]byte{cborBreak})
+
+	b, err := CBOR.Unmarshal(w.bytes())
+	if err != nil {
+		return nil, fmt.Errorf("partial bundle: %w", err)
+	}
+	if b.BundleFlags&FlagMustNotFragment != 0 {
+		return nil, ErrMustNotFragment
+	}
+
+	if !b.IsFragment {
+		b.IsFragment = true
+		b.BundleFlags |= FlagIsFragment
+		b.FragmentOffset = 0
+		b.TotalADULength = declared
+	}
+	b.ID = fragmentID(b, b.FragmentOffset)
+
+	return b, nil
+}
@@ -25,25 +25,43 @@ const (
 	PriorityExpedited uint8 = 2 // Highest priority, critical data
 )
 
+// Bundle processing control flags (RFC 9171 Section 4.2.3).
+const (
+	FlagIsFragment          uint64 = 0x000001
+	FlagAdminRecord         uint64 = 0x000002
+	FlagMustNotFragment     uint64 = 0x000004
+	FlagCustodyRequested    uint64 = 0x000008 // BPv6 custody bit, reserved in RFC 9171; used for ASGARD custody transfer
+	FlagAppAckRequested     uint64 = 0x000020
+	FlagStatusTimeRequested uint64 = 0x000040
+	FlagReportReception     uint64 = 0x004000
+	FlagReportForwarding    uint64 = 0x010000
+	FlagReportDelivery      uint64 = 0x020000
+	FlagReportDeletion      uint64 = 0x040000
+)
+
 // Bundle represents a BPv7 bundle for delay-tolerant networking.
 // This is the core data structure for all inter-node communication in ASGARD.
 type Bundle struct {
-	ID                uuid.UUID     `json:"id"`
-	Version           uint8         `json:"version"`
-	BundleFlags       uint64        `json:"bundleFlags"`
-	DestinationEID    string        `json:"destinationEid"` // e.g., "dtn://earth/nysus"
-	SourceEID         string        `json:"sourceEid"`      // e.g., "dtn://mars/sat001"
-	ReportTo          string        `json:"reportTo"`       // Status report destination
-	CreationTimestamp time.Time     `json:"creationTimestamp"`
-	Lifetime          time.Duration `json:"lifetime"` // Bundle validity period
-	Payload           []byte        `json:"payload"`
-	CRCType           uint8         `json:"crcType"`      // 0=none, 1=CRC16, 2=CRC32
-	PreviousNode      string        `json:"previousNode"` // Last node that forwarded
-	HopCount          uint32        `json:"hopCount"`
-	Priority          uint8         `json:"priority"`
-	FragmentOffset    uint64        `json:"fragmentOffset,omitempty"`
-	TotalADULength    uint64        `json:"totalAduLength,omitempty"`
-	IsFragment        bool          `json:"isFragment"`
+	ID                uuid.UUID        `json:"id"`
+	Version           uint8            `json:"version"`
+	BundleFlags       uint64           `json:"bundleFlags"`
+	DestinationEID    string           `json:"destinationEid"` // e.g., "dtn://earth/nysus"
+	SourceEID         string           `json:"sourceEid"`      // e.g., "dtn://mars/sat001"
+	ReportTo          string           `json:"reportTo"`       // Status report destination
+	CreationTimestamp time.Time        `json:"creationTimestamp"`
+	Lifetime          time.Duration    `json:"lifetime"` // Bundle validity period
+	Payload           []byte           `json:"payload"`
+	CRCType           uint8            `json:"crcType"`      // 0=none, 1=CRC16, 2=CRC32
+	PreviousNode      string           `json:"previousNode"` // Last node that forwarded
+	HopCount          uint32           `json:"hopCount"`
+	Priority          uint8            `json:"priority"`
+	FragmentOffset    uint64           `json:"fragmentOffset,omitempty"`
+	TotalADULength    uint64           `json:"totalAduLength,omitempty"`
+	IsFragment        bool             `json:"isFragment"`
+	SequenceNumber    uint64           `json:"sequenceNumber,omitempty"` // Creation timestamp sequence number
+	BundleAge         time.Duration    `json:"bundleAge,omitempty"`      // Age carried in the bundle age block
+	Blocks            []CanonicalBlock `json:"blocks,omitempty"`         // Extension blocks not modelled as fields
+	Custodian         string           `json:"custodian,omitempty"`      // Current custodian when custody transfer is requested
 }
 
 // NewBundle creates a new bundle with sensible defaults for ASGARD operations.
@@ -55,13 +73,14 @@ func NewBundle(source, destination string, payload []byte) *Bundle {
 		DestinationEID:    destination,
 		SourceEID:         source,
 		ReportTo:          source,
-		CreationTimestamp: time.Now().UTC(),
-		Lifetime:          24 * time.Hour, // Default 24-hour lifetime
+		CreationTimestamp: time.Now().UTC().Truncate(time.Millisecond), // BPv7 timestamps have millisecond resolution
+		Lifetime:          24 * time.Hour,                              // Default 24-hour lifetime
 		Payload:           payload,
 		CRCType:           1, // CRC16 by default
 		HopCount:          0,
 		Priority:          PriorityNormal,
 		IsFragment:        false,
+		SequenceNumber:    nextSequenceNumber(),
 	}
 }
 
@@ -87,8 +106,12 @@ func (b *Bundle) Hash() string {
 
 // IsExpired checks if the bundle has exceeded its lifetime.
 func (b *Bundle) IsExpired() bool {
-	expiryTime := b.CreationTimestamp.Add(b.Lifetime)
-	return time.Now().UTC().After(expiryTime)
+	return b.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if the bundle has exceeded its lifetime at the given time.
+func (b *Bundle) IsExpiredAt(now time.Time) bool {
+	return now.After(b.ExpiresAt())
 }
 
 // ExpiresAt returns the expiration timestamp.
@@ -117,6 +140,11 @@ func (b *Bundle) IncrementHop(nodeID string) error {
 
 // Validate performs comprehensive bundle validation.
 func (b *Bundle) Validate() error {
+	return b.ValidateAt(time.Now())
+}
+
+// ValidateAt validates the bundle, judging expiry at the given time.
+func (b *Bundle) ValidateAt(now time.Time) error {
 	if b.Version != BPv7Version {
 		return fmt.Errorf("invalid bundle version: %d (expected %d)", b.Version, BPv7Version)
 	}
@@ -126,7 +154,7 @@ func (b *Bundle) Validate() error {
 	if b.SourceEID == "" {
 		return fmt.Errorf("source EID cannot be empty")
 	}
-	if b.IsExpired() {
+	if b.IsExpiredAt(now) {
 		return fmt.Errorf("bundle has expired at %s", b.ExpiresAt().Format(time.RFC3339))
 	}
 	if b.HopCount > MaxHopCount {
@@ -157,6 +185,14 @@ func (b *Bundle) Clone() *Bundle {
 	payloadCopy := make([]byte, len(b.Payload))
 	copy(payloadCopy, b.Payload)
 
+	var blocksCopy []CanonicalBlock
+	if len(b.Blocks) > 0 {
+		blocksCopy = make([]CanonicalBlock, len(b.Blocks))
+		for i, blk := range b.Blocks {
+			blocksCopy[i] = blk.Clone()
+		}
+	}
+
 	return &Bundle{
 		ID:                b.ID,
 		Version:           b.Version,
@@ -174,13 +210,17 @@ func (b *Bundle) Clone() *Bundle {
 		FragmentOffset:    b.FragmentOffset,
 		TotalADULength:    b.TotalADULength,
 		IsFragment:        b.IsFragment,
+		SequenceNumber:    b.SequenceNumber,
+		BundleAge:         b.BundleAge,
+		Blocks:            blocksCopy,
+		Custodian:         b.Custodian,
 	}
 }
 
 // Size returns the approximate size of the bundle in bytes.
 func (b *Bundle) Size() int {
 	// Header size + payload
-	headerSize := 64 + len(b.SourceEID) + len(b.DestinationEID) + len(b.ReportTo) + len(b.PreviousNode)
+	headerSize := 64 + len(b.SourceEID) + len(b.DestinationEID) + len(b.ReportTo) + len(b.PreviousNode) + len(b.Custodian)
 	return headerSize + len(b.Payload)
 }
 
@@ -0,0 +1,447 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CBOR major types (RFC 8949 Section 3.1).
+const (
+	cborMajorUint   byte = 0
+	cborMajorNegInt byte = 1
+	cborMajorBytes  byte = 2
+	cborMajorText   byte = 3
+	cborMajorArray  byte = 4
+	cborMajorMap    byte = 5
+	cborMajorTag    byte = 6
+	cborMajorSimple byte = 7
+)
+
+const (
+	cborFalse      byte = 20
+	cborTrue       byte = 21
+	cborIndefinite byte = 31
+	cborBreak      byte = 0xff
+	cborIndefArray byte = 0x9f
+)
+
+// maxCBORItemLength bounds byte strings and containers decoded from the wire.
+const maxCBORItemLength = 64 * 1024 * 1024
+
+// maxCBORDepth bounds how deeply arrays, maps and tags may nest, so a
+// crafted item cannot exhaust the stack. Bundles nest a few levels at most.
+const maxCBORDepth = 32
+
+var errCBORBreak = errors.New("cbor: unexpected break")
+
+// cborWriter produces the subset of deterministic CBOR used by BPv7.
+type cborWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *cborWriter) writeHead(major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		w.buf.WriteByte(m | byte(n))
+	case n <= 0xff:
+		w.buf.WriteByte(m | 24)
+		w.buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		w.buf.WriteByte(m | 25)
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		w.buf.Write(b[:])
+	case n <= 0xffffffff:
+		w.buf.WriteByte(m | 26)
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		w.buf.Write(b[:])
+	default:
+		w.buf.WriteByte(m | 27)
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		w.buf.Write(b[:])
+	}
+}
+
+func (w *cborWriter) writeUint(n uint64) {
+	w.writeHead(cborMajorUint, n)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.writeHead(cborMajorBytes, uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) writeText(s string) {
+	w.writeHead(cborMajorText, uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *cborWriter) writeArray(n int) {
+	w.writeHead(cborMajorArray, uint64(n))
+}
+
+func (w *cborWriter) writeBool(v bool) {
+	if v {
+		w.buf.WriteByte(cborMajorSimple<<5 | cborTrue)
+	} else {
+		w.buf.WriteByte(cborMajorSimple<<5 | cborFalse)
+	}
+}
+
+func (w *cborWriter) writeRaw(b []byte) {
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// cborReader decodes CBOR items from an in-memory buffer.
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func newCBORReader(data []byte) *cborReader {
+	return &cborReader{data: data}
+}
+
+func (r *cborReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *cborReader) peek() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return r.data[r.pos], nil
+}
+
+// readHead returns the major type, additional info and argument of the next item.
+func (r *cborReader) readHead() (byte, byte, uint64, error) {
+	if r.pos >= len(r.data) {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	initial := r.data[r.pos]
+	r.pos++
+	major := initial >> 5
+	info := initial & 0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == cborIndefinite:
+		if major == cborMajorUint || major == cborMajorNegInt || major == cborMajorTag {
+			return 0, 0, 0, fmt.Errorf("cbor: indefinite length not allowed for major type %d", major)
+		}
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("cbor: reserved additional info %d", info)
+	}
+
+	if r.remaining() < size {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	var n uint64
+	for _, b := range r.data[r.pos : r.pos+size] {
+		n = n<<8 | uint64(b)
+	}
+	r.pos += size
+	return major, info, n, nil
+}
+
+func (r *cborReader) readUint() (uint64, error) {
+	major, _, n, err := r.readHead()
+	if err != nil {
+		return 0, err
+	}
+	if major != cborMajorUint {
+		return 0, fmt.Errorf("cbor: expected unsigned integer, got major type %d", major)
+	}
+	return n, nil
+}
+
+func (r *cborReader) readBytes() ([]byte, error) {
+	major, info, n, err := r.readHead()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborMajorBytes {
+		return nil, fmt.Errorf("cbor: expected byte string, got major type %d", major)
+	}
+	if info == cborIndefinite {
+		return r.readChunks(cborMajorBytes)
+	}
+	return r.take(n)
+}
+
+func (r *cborReader) readText() (string, error) {
+	major, info, n, err := r.readHead()
+	if err != nil {
+		return "", err
+	}
+	if major != cborMajorText {
+		return "", fmt.Errorf("cbor: expected text string, got major type %d", major)
+	}
+	if info == cborIndefinite {
+		b, err := r.readChunks(cborMajorText)
+		return string(b), err
+	}
+	b, err := r.take(n)
+	return string(b), err
+}
+
+func (r *cborReader) readChunks(major byte) ([]byte, error) {
+	var out []byte
+	for {
+		b, err := r.peek()
+		if err != nil {
+			return nil, err
+		}
+		if b == cborBreak {
+			r.pos++
+			return out, nil
+		}
+		m, info, n, err := r.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || info == cborIndefinite {
+			return nil, fmt.Errorf("cbor: invalid chunk in indefinite string")
+		}
+		chunk, err := r.take(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+	}
+}
+
+func (r *cborReader) readBool() (bool, error) {
+	major, info, _, err := r.readHead()
+	if err != nil {
+		return false, err
+	}
+	if major != cborMajorSimple || (info != cborFalse && info != cborTrue) {
+		return false, fmt.Errorf("cbor: expected boolean, got major type %d", major)
+	}
+	return info == cborTrue, nil
+}
+
+// readArray returns the array length, or -1 for an indefinite-length array.
+func (r *cborReader) readArray() (int, error) {
+	major, info, n, err := r.readHead()
+	if err != nil {
+		return 0, err
+	}
+	if major != cborMajorArray {
+		return 0, fmt.Errorf("cbor: expected array, got major type %d", major)
+	}
+	if info == cborIndefinite {
+		return -1, nil
+	}
+	if n > maxCBORItemLength {
+		return 0, fmt.Errorf("cbor: array length %d too large", n)
+	}
+	return int(n), nil
+}
+
+// atBreak consumes a break marker if one is next.
+func (r *cborReader) atBreak() (bool, error) {
+	b, err := r.peek()
+	if err != nil {
+		return false, err
+	}
+	if b == cborBreak {
+		r.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// skip consumes one complete data item and returns its encoded bytes.
+func (r *cborReader) skip() ([]byte, error) {
+	start := r.pos
+	if err := r.skipItem(); err != nil {
+		return nil, err
+	}
+	return r.data[start:r.pos], nil
+}
+
+func (r *cborReader) skipItem() error {
+	return r.skipNested(0)
+}
+
+func (r *cborReader) skipNested(depth int) error {
+	if depth > maxCBORDepth {
+		return fmt.Errorf("cbor: nesting deeper than %d", maxCBORDepth)
+	}
+	b, err := r.peek()
+	if err != nil {
+		return err
+	}
+	if b == cborBreak {
+		return errCBORBreak
+	}
+	major, info, n, err := r.readHead()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborMajorUint, cborMajorNegInt, cborMajorSimple:
+		return nil
+	case cborMajorBytes, cborMajorText:
+		if info == cborIndefinite {
+			_, err := r.readChunks(major)
+			return err
+		}
+		_, err := r.take(n)
+		return err
+	case cborMajorTag:
+		return r.skipNested(depth + 1)
+	case cborMajorArray, cborMajorMap:
+		count := n
+		if major == cborMajorMap {
+			count *= 2
+		}
+		if info == cborIndefinite {
+			for {
+				done, err := r.atBreak()
+				if err != nil {
+					return err
+				}
+				if done {
+					return nil
+				}
+				if err := r.skipNested(depth + 1); err != nil {
+					return err
+				}
+			}
+		}
+		for i := uint64(0); i < count; i++ {
+			if err := r.skipNested(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cbor: unknown major type %d", major)
+}
+
+func (r *cborReader) take(n uint64) ([]byte, error) {
+	if n > maxCBORItemLength || uint64(r.remaining()) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	out := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return out, nil
+}
+
+// readCBORItem reads exactly one complete CBOR data item from a stream
+// without consuming any bytes past its end.
+func readCBORItem(r io.Reader) ([]byte, error) {
+	var out bytes.Buffer
+	if err := copyCBORItem(r, &out, false, 0); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func copyCBORItem(r io.Reader, out *bytes.Buffer, allowBreak bool, depth int) error {
+	if depth > maxCBORDepth {
+		return fmt.Errorf("cbor: nesting deeper than %d", maxCBORDepth)
+	}
+	var head [9]byte
+	if _, err := io.ReadFull(r, head[:1]); err != nil {
+		return err
+	}
+	out.WriteByte(head[0])
+	if head[0] == cborBreak {
+		if allowBreak {
+			return errCBORBreak
+		}
+		return fmt.Errorf("cbor: unexpected break")
+	}
+
+	major := head[0] >> 5
+	info := head[0] & 0x1f
+
+	var n uint64
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info >= 24 && info <= 27:
+		size := 1 << (info - 24)
+		if _, err := io.ReadFull(r, head[1:1+size]); err != nil {
+			return err
+		}
+		out.Write(head[1 : 1+size])
+		for _, b := range head[1 : 1+size] {
+			n = n<<8 | uint64(b)
+		}
+	case info == cborIndefinite:
+	default:
+		return fmt.Errorf("cbor: reserved additional info %d", info)
+	}
+
+	switch major {
+	case cborMajorUint, cborMajorNegInt, cborMajorSimple:
+		return nil
+	case cborMajorTag:
+		return copyCBORItem(r, out, false, depth+1)
+	case cborMajorBytes, cborMajorText:
+		if info == cborIndefinite {
+			return copyUntilBreak(r, out, depth+1)
+		}
+		if n > maxCBORItemLength {
+			return fmt.Errorf("cbor: string length %d too large", n)
+		}
+		_, err := io.CopyN(out, r, int64(n))
+		return err
+	case cborMajorArray, cborMajorMap:
+		if info == cborIndefinite {
+			return copyUntilBreak(r, out, depth+1)
+		}
+		count := n
+		if major == cborMajorMap {
+			count *= 2
+		}
+		if count > maxCBORItemLength {
+			return fmt.Errorf("cbor: container length %d too large", n)
+		}
+		for i := uint64(0); i < count; i++ {
+			if err := copyCBORItem(r, out, false, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cbor: unknown major type %d", major)
+}
+
+func copyUntilBreak(r io.Reader, out *bytes.Buffer, depth int) error {
+	for {
+		err := copyCBORItem(r, out, true, depth)
+		if errors.Is(err, errCBORBreak) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Codec converts bundles to and from a wire format.
+type Codec interface {
+	// Name returns the codec identifier used in configuration.
+	Name() string
+
+	// Encode writes a single bundle to the writer.
+	Encode(w io.Writer, b *Bundle) error
+
+	// Decode reads a single bundle from the reader.
+	Decode(r io.Reader) (*Bundle, error)
+
+	// Marshal serializes a bundle to bytes.
+	Marshal(b *Bundle) ([]byte, error)
+
+	// Unmarshal deserializes a bundle from bytes.
+	Unmarshal(data []byte) (*Bundle, error)
+}
+
+// Available codecs.
+var (
+	// Legacy is the original ASGARD length-prefixed binary layout.
+	Legacy Codec = LegacyCodec{}
+
+	// CBOR is the RFC 9171 wire format, interoperable with ION, µD3TN and DTN7-go.
+	CBOR Codec = CBORCodec{}
+)
+
+// CodecByName returns the codec registered under name ("legacy" or "cbor").
+func CodecByName(name string) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "legacy":
+		return Legacy, nil
+	case "cbor", "bpv7", "rfc9171":
+		return CBOR, nil
+	}
+	return nil, fmt.Errorf("unknown bundle codec: %q", name)
+}
+
+// DetectCodec guesses the codec of an encoded bundle from its first byte.
+// RFC 9171 bundles start with a CBOR array head; legacy bundles start with
+// the raw version byte.
+func DetectCodec(data []byte) Codec {
+	if len(data) > 0 && data[0]>>5 == cborMajorArray {
+		return CBOR
+	}
+	return Legacy
+}
+
+// LegacyCodec implements Codec using the original ASGARD binary layout.
+type LegacyCodec struct{}
+
+// Name implements Codec.
+func (LegacyCodec) Name() string {
+	return "legacy"
+}
+
+// Encode implements Codec.
+func (LegacyCodec) Encode(w io.Writer, b *Bundle) error {
+	return NewEncoder(w).encodeLegacy(b)
+}
+
+// Decode implements Codec.
+func (LegacyCodec) Decode(r io.Reader) (*Bundle, error) {
+	return NewDecoder(r).decodeLegacy()
+}
+
+// Marshal implements Codec.
+func (c LegacyCodec) Marshal(b *Bundle) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.Encode(&buf, b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements Codec.
+func (c LegacyCodec) Unmarshal(data []byte) (*Bundle, error) {
+	return c.Decode(bytes.NewReader(data))
+}
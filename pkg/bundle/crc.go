@@ -0,0 +1,80 @@
+package bundle
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// CRC types defined by RFC 9171 Section 4.2.1.
+const (
+	CRCNone  uint8 = 0
+	CRC16X25 uint8 = 1 // CRC-16 X.25
+	CRC32C   uint8 = 2 // CRC-32 Castagnoli
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crc16X25Table holds the CRC-16/X-25 remainder of every byte value.
+var crc16X25Table = func() (table [256]uint16) {
+	for i := range table {
+		crc := uint16(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc16X25 computes the CRC-16/X-25 checksum (reflected poly 0x1021,
+// init 0xFFFF, xorout 0xFFFF) used by BPv7 CRC type 1.
+func crc16X25(data []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc = (crc >> 8) ^ crc16X25Table[byte(crc)^b]
+	}
+	return ^crc
+}
+
+// crcLength returns the size in bytes of the CRC value for a CRC type.
+func crcLength(crcType uint8) (int, error) {
+	switch crcType {
+	case CRCNone:
+		return 0, nil
+	case CRC16X25:
+		return 2, nil
+	case CRC32C:
+		return 4, nil
+	}
+	return 0, fmt.Errorf("unsupported CRC type: %d", crcType)
+}
+
+// computeCRC returns the big-endian CRC of data for the given CRC type.
+func computeCRC(crcType uint8, data []byte) []byte {
+	switch crcType {
+	case CRC16X25:
+		out := make([]byte, 2)
+		binary.BigEndian.PutUint16(out, crc16X25(data))
+		return out
+	case CRC32C:
+		out := make([]byte, 4)
+		binary.BigEndian.PutUint32(out, crc32.Checksum(data, crc32cTable))
+		return out
+	}
+	return nil
+}
+
+// ChecksumCRC16 returns the CRC-16 X.25 checksum of data.
+func ChecksumCRC16(data []byte) uint16 {
+	return crc16X25(data)
+}
+
+// ChecksumCRC32C returns the CRC-32C checksum of data.
+func ChecksumCRC32C(data []byte) uint32 {
+	return crc32.Checksum(data, crc32cTable)
+}
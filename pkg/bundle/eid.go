@@ -0,0 +1,155 @@
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// URI scheme codes for endpoint IDs (RFC 9171 Section 4.2.5.1).
+const (
+	SchemeDTN uint64 = 1
+	SchemeIPN uint64 = 2
+)
+
+// NullEID is the null endpoint, used when no report-to endpoint is set.
+const NullEID = "dtn:none"
+
+// EID is a parsed BPv7 endpoint identifier.
+type EID struct {
+	Scheme  uint64
+	SSP     string // dtn scheme-specific part, e.g. "//earth/nysus"
+	Node    uint64 // ipn node number
+	Service uint64 // ipn service number
+}
+
+// ParseEID parses a "dtn:" or "ipn:" endpoint URI.
+func ParseEID(s string) (EID, error) {
+	switch {
+	case s == "" || s == NullEID:
+		return EID{Scheme: SchemeDTN}, nil
+	case strings.HasPrefix(s, "dtn:"):
+		ssp := strings.TrimPrefix(s, "dtn:")
+		if ssp == "" {
+			return EID{}, fmt.Errorf("empty dtn scheme-specific part: %q", s)
+		}
+		return EID{Scheme: SchemeDTN, SSP: ssp}, nil
+	case strings.HasPrefix(s, "ipn:"):
+		parts := strings.Split(strings.TrimPrefix(s, "ipn:"), ".")
+		if len(parts) != 2 {
+			return EID{}, fmt.Errorf("malformed ipn EID: %q", s)
+		}
+		node, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return EID{}, fmt.Errorf("malformed ipn node number in %q: %w", s, err)
+		}
+		service, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return EID{}, fmt.Errorf("malformed ipn service number in %q: %w", s, err)
+		}
+		return EID{Scheme: SchemeIPN, Node: node, Service: service}, nil
+	}
+	return EID{}, fmt.Errorf("unsupported EID scheme: %q", s)
+}
+
+// IsNull reports whether the EID is dtn:none.
+func (e EID) IsNull() bool {
+	return e.Scheme == SchemeDTN && e.SSP == ""
+}
+
+// String returns the URI form of the EID.
+func (e EID) String() string {
+	switch e.Scheme {
+	case SchemeDTN:
+		if e.SSP == "" {
+			return NullEID
+		}
+		return "dtn:" + e.SSP
+	case SchemeIPN:
+		return fmt.Sprintf("ipn:%d.%d", e.Node, e.Service)
+	}
+	return fmt.Sprintf("unknown-scheme-%d", e.Scheme)
+}
+
+// encodeCBOR writes the EID as a two-element [scheme, ssp] array.
+func (e EID) encodeCBOR(w *cborWriter) {
+	w.writeArray(2)
+	w.writeUint(e.Scheme)
+	switch e.Scheme {
+	case SchemeDTN:
+		if e.SSP == "" {
+			w.writeUint(0)
+		} else {
+			w.writeText(e.SSP)
+		}
+	case SchemeIPN:
+		w.writeArray(2)
+		w.writeUint(e.Node)
+		w.writeUint(e.Service)
+	}
+}
+
+func decodeEID(r *cborReader) (EID, error) {
+	n, err := r.readArray()
+	if err != nil {
+		return EID{}, fmt.Errorf("eid: %w", err)
+	}
+	if n != 2 {
+		return EID{}, fmt.Errorf("eid: expected 2 elements, got %d", n)
+	}
+	scheme, err := r.readUint()
+	if err != nil {
+		return EID{}, fmt.Errorf("eid scheme: %w", err)
+	}
+
+	switch scheme {
+	case SchemeDTN:
+		first, err := r.peek()
+		if err != nil {
+			return EID{}, err
+		}
+		if first>>5 == cborMajorUint {
+			v, err := r.readUint()
+			if err != nil {
+				return EID{}, err
+			}
+			if v != 0 {
+				return EID{}, fmt.Errorf("eid: invalid dtn ssp value %d", v)
+			}
+			return EID{Scheme: SchemeDTN}, nil
+		}
+		ssp, err := r.readText()
+		if err != nil {
+			return EID{}, fmt.Errorf("eid ssp: %w", err)
+		}
+		return EID{Scheme: SchemeDTN, SSP: ssp}, nil
+	case SchemeIPN:
+		n, err := r.readArray()
+		if err != nil {
+			return EID{}, fmt.Errorf("ipn ssp: %w", err)
+		}
+		if n != 2 {
+			return EID{}, fmt.Errorf("ipn ssp: expected 2 elements, got %d", n)
+		}
+		node, err := r.readUint()
+		if err != nil {
+			return EID{}, err
+		}
+		service, err := r.readUint()
+		if err != nil {
+			return EID{}, err
+		}
+		return EID{Scheme: SchemeIPN, Node: node, Service: service}, nil
+	}
+	return EID{}, fmt.Errorf("eid: unsupported scheme code %d", scheme)
+}
+
+// encodeEIDString parses and encodes an EID string, mapping "" to dtn:none.
+func encodeEIDString(w *cborWriter, s string) error {
+	eid, err := ParseEID(s)
+	if err != nil {
+		return err
+	}
+	eid.encodeCBOR(w)
+	return nil
+}
@@ -0,0 +1,191 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// MaxADULength bounds the total application data unit size accepted for
+// reassembly, protecting nodes from fragments that claim absurd lengths.
+const MaxADULength uint64 = 1 << 30
+
+var (
+	// ErrMustNotFragment is returned when fragmentation is requested for a
+	// bundle whose "must not fragment" flag is set.
+	ErrMustNotFragment = errors.New("bundle must not be fragmented")
+
+	// ErrIncompleteADU is returned by Reassemble when fragments do not cover
+	// the whole application data unit.
+	ErrIncompleteADU = errors.New("fragments do not cover the full ADU")
+)
+
+// sequenceCounter disambiguates bundles created by one node within the same
+// millisecond (RFC 9171 Section 4.2.7).
+var sequenceCounter atomic.Uint64
+
+func nextSequenceNumber() uint64 {
+	return sequenceCounter.Add(1)
+}
+
+// FragmentKey identifies the original bundle a fragment belongs to. All
+// fragments of one ADU share the same key.
+func (b *Bundle) FragmentKey() string {
+	return fmt.Sprintf("%s|%d|%d", b.SourceEID, b.CreationTimestamp.UnixNano(), b.SequenceNumber)
+}
+
+// ADUOffset returns the offset of the payload within the original ADU.
+func (b *Bundle) ADUOffset() uint64 {
+	if b.IsFragment {
+		return b.FragmentOffset
+	}
+	return 0
+}
+
+// ADULength returns the length of the original application data unit.
+func (b *Bundle) ADULength() uint64 {
+	if b.IsFragment {
+		return b.TotalADULength
+	}
+	return uint64(len(b.Payload))
+}
+
+// FragmentRange returns a fragment carrying payload bytes [start, end) of b.
+// Offsets are relative to b's payload; b may itself be a fragment.
+func FragmentRange(b *Bundle, start, end uint64) (*Bundle, error) {
+	if b.BundleFlags&FlagMustNotFragment != 0 {
+		return nil, ErrMustNotFragment
+	}
+	if start >= end || end > uint64(len(b.Payload)) {
+		return nil, fmt.Errorf("invalid fragment range [%d, %d) for payload of %d bytes", start, end, len(b.Payload))
+	}
+
+	frag := b.Clone()
+	frag.Payload = frag.Payload[start:end]
+	frag.FragmentOffset = b.ADUOffset() + start
+	frag.TotalADULength = b.ADULength()
+	frag.IsFragment = true
+	frag.BundleFlags |= FlagIsFragment
+	frag.ID = fragmentID(b, frag.FragmentOffset)
+
+	// RFC 9171 Section 5.8: only blocks flagged for replication appear in
+	// fragments other than the first.
+	if frag.FragmentOffset != 0 && len(frag.Blocks) > 0 {
+		kept := frag.Blocks[:0]
+		for _, blk := range frag.Blocks {
+			if blk.Flags&BlockFlagReplicate != 0 {
+				kept = append(kept, blk)
+			}
+		}
+		frag.Blocks = kept
+	}
+
+	return frag, nil
+}
+
+// Fragment splits b into fragments carrying at most maxPayload payload bytes.
+// A bundle that already fits is returned unchanged as the only element.
+func Fragment(b *Bundle, maxPayload int) ([]*Bundle, error) {
+	if maxPayload <= 0 {
+		return nil, fmt.Errorf("invalid fragment payload size: %d", maxPayload)
+	}
+	if len(b.Payload) <= maxPayload {
+		return []*Bundle{b}, nil
+	}
+	if b.BundleFlags&FlagMustNotFragment != 0 {
+		return nil, ErrMustNotFragment
+	}
+
+	total := uint64(len(b.Payload))
+	step := uint64(maxPayload)
+	fragments := make([]*Bundle, 0, (total+step-1)/step)
+	for start := uint64(0); start < total; start += step {
+		end := start + step
+		if end > total {
+			end = total
+		}
+		frag, err := FragmentRange(b, start, end)
+		if err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, frag)
+	}
+	return fragments, nil
+}
+
+// Reassemble rebuilds the original bundle from fragments sharing one
+// FragmentKey. Overlapping fragments (e.g. from reactive fragmentation) are
+// allowed; gaps yield ErrIncompleteADU.
+func Reassemble(fragments []*Bundle) (*Bundle, error) {
+	if len(fragments) == 0 {
+		return nil, ErrIncompleteADU
+	}
+
+	key := fragments[0].FragmentKey()
+	total := fragments[0].ADULength()
+	if total > MaxADULength {
+		return nil, fmt.Errorf("ADU length %d exceeds maximum %d", total, MaxADULength)
+	}
+
+	sorted := make([]*Bundle, len(fragments))
+	copy(sorted, fragments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ADUOffset() < sorted[j].ADUOffset()
+	})
+
+	var first *Bundle
+	covered := uint64(0)
+	for _, frag := range sorted {
+		if frag.FragmentKey() != key {
+			return nil, fmt.Errorf("fragment %s belongs to a different ADU", frag.ID)
+		}
+		if frag.ADULength() != total {
+			return nil, fmt.Errorf("fragment %s reports ADU length %d, expected %d", frag.ID, frag.ADULength(), total)
+		}
+		offset := frag.ADUOffset()
+		if offset+uint64(len(frag.Payload)) > total {
+			return nil, fmt.Errorf("fragment %s extends past end of ADU", frag.ID)
+		}
+		if offset > covered {
+			return nil, ErrIncompleteADU
+		}
+		if offset == 0 && first == nil {
+			first = frag
+		}
+		if end := offset + uint64(len(frag.Payload)); end > covered {
+			covered = end
+		}
+	}
+	if covered < total || first == nil {
+		return nil, ErrIncompleteADU
+	}
+
+	payload := make([]byte, total)
+	for _, frag := range sorted {
+		copy(payload[frag.ADUOffset():], frag.Payload)
+	}
+
+	whole := first.Clone()
+	whole.Payload = payload
+	whole.IsFragment = false
+	whole.FragmentOffset = 0
+	whole.TotalADULength = 0
+	whole.BundleFlags &^= FlagIsFragment
+	whole.ID = reassembledID(whole)
+
+	return whole, nil
+}
+
+// fragmentID derives a stable ID for the fragment of b starting at offset, so
+// fragments re-created after a restart keep the same identity.
+func fragmentID(b *Bundle, offset uint64) uuid.UUID {
+	return uuid.NewSHA1(bundleIDNamespace, []byte(fmt.Sprintf("%s|frag|%d", b.FragmentKey(), offset)))
+}
+
+// reassembledID derives the ID of a reassembled bundle from its identity.
+func reassembledID(b *Bundle) uuid.UUID {
+	return uuid.NewSHA1(bundleIDNamespace, []byte(b.FragmentKey()+"|adu"))
+}
@@ -13,26 +13,46 @@ import (
 
 // Encoder handles bundle serialization.
 type Encoder struct {
-	w io.Writer
+	w     io.Writer
+	codec Codec
 }
 
 // Decoder handles bundle deserialization.
 type Decoder struct {
-	r io.Reader
+	r     io.Reader
+	codec Codec
 }
 
-// NewEncoder creates a new bundle encoder.
+// NewEncoder creates a new bundle encoder using the legacy binary format.
 func NewEncoder(w io.Writer) *Encoder {
 	return &Encoder{w: w}
 }
 
-// NewDecoder creates a new bundle decoder.
+// NewDecoder creates a new bundle decoder using the legacy binary format.
 func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{r: r}
 }
 
-// Encode serializes a bundle to the writer in binary format.
+// NewEncoderWithCodec creates a bundle encoder for the given wire format.
+func NewEncoderWithCodec(w io.Writer, codec Codec) *Encoder {
+	return &Encoder{w: w, codec: codec}
+}
+
+// NewDecoderWithCodec creates a bundle decoder for the given wire format.
+func NewDecoderWithCodec(r io.Reader, codec Codec) *Decoder {
+	return &Decoder{r: r, codec: codec}
+}
+
+// Encode serializes a bundle to the writer using the encoder's codec.
 func (e *Encoder) Encode(b *Bundle) error {
+	if e.codec != nil {
+		return e.codec.Encode(e.w, b)
+	}
+	return e.encodeLegacy(b)
+}
+
+// encodeLegacy serializes a bundle in the legacy binary format.
+func (e *Encoder) encodeLegacy(b *Bundle) error {
 	if err := b.Validate(); err != nil {
 		return fmt.Errorf("invalid bundle: %w", err)
 	}
@@ -123,8 +143,16 @@ func (e *Encoder) writeString(s string) error {
 	return nil
 }
 
-// Decode deserializes a bundle from the reader.
+// Decode deserializes a bundle from the reader using the decoder's codec.
 func (d *Decoder) Decode() (*Bundle, error) {
+	if d.codec != nil {
+		return d.codec.Decode(d.r)
+	}
+	return d.decodeLegacy()
+}
+
+// decodeLegacy deserializes a bundle in the legacy binary format.
+func (d *Decoder) decodeLegacy() (*Bundle, error) {
 	b := &Bundle{}
 
 	// Read version
@@ -225,7 +253,7 @@ func (d *Decoder) readString() (string, error) {
 	return string(data), nil
 }
 
-// Marshal serializes a bundle to bytes.
+// Marshal serializes a bundle to bytes in the legacy binary format.
 func Marshal(b *Bundle) ([]byte, error) {
 	var buf bytes.Buffer
 	enc := NewEncoder(&buf)
@@ -235,10 +263,10 @@ func Marshal(b *Bundle) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// Unmarshal deserializes a bundle from bytes.
+// Unmarshal deserializes a bundle from bytes, detecting whether it uses the
+// legacy layout or the RFC 9171 CBOR encoding.
 func Unmarshal(data []byte) (*Bundle, error) {
-	dec := NewDecoder(bytes.NewReader(data))
-	return dec.Decode()
+	return DetectCodec(data).Unmarshal(data)
 }
 
 // MarshalJSON serializes a bundle to JSON format.
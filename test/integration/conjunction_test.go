@@ -0,0 +1,244 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/controlplane"
+	"github.com/asgard/pandora/internal/platform/conjunction"
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+func TestConjunctionProbabilityMethods(t *testing.T) {
+	// Isotropic covariance with a head-on miss has a closed form
+	sigma, hbr := 0.1, 0.02
+	plane := conjunction.EncounterPlane{Covariance: [2][2]float64{{sigma * sigma, 0}, {0, sigma * sigma}}}
+	want := 1 - math.Exp(-hbr*hbr/(2*sigma*sigma))
+	for _, method := range []conjunction.Method{conjunction.MethodFoster, conjunction.MethodAlfano} {
+		if got := plane.Probability(hbr, method); math.Abs(got-want)/want > 1e-6 {
+			t.Errorf("%s: Pc = %.9e, want %.9e", method, got, want)
+		}
+	}
+
+	// The two methods agree on a correlated, offset encounter
+	plane = conjunction.EncounterPlane{
+		Miss:       [2]float64{0.3, 0.05},
+		Covariance: [2][2]float64{{0.04, 0.012}, {0.012, 0.01}},
+	}
+	foster := plane.Probability(0.05, conjunction.MethodFoster)
+	alfano := plane.Probability(0.05, conjunction.MethodAlfano)
+	if foster <= 0 || math.Abs(foster-alfano)/foster > 1e-5 {
+		t.Errorf("Foster %.9e, Alfano %.9e", foster, alfano)
+	}
+
+	// Projection keeps the miss along the first axis and drops the
+	// along-track variance
+	cov := conjunction.Covariance{{0.01, 0, 0}, {0, 0.25, 0}, {0, 0, 0.04}}
+	ep := conjunction.NewEncounterPlane([3]float64{0.2, 0, 0}, [3]float64{0, 10, 0}, cov)
+	if ep.Miss != [2]float64{0.2, 0} || math.Abs(ep.Covariance[0][0]-0.01) > 1e-15 || math.Abs(ep.Covariance[1][1]-0.04) > 1e-15 {
+		t.Errorf("encounter plane = %+v", ep)
+	}
+}
+
+// recordingPublisher collects published control plane events.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []controlplane.CrossDomainEvent
+}
+
+func (p *recordingPublisher) PublishEvent(event controlplane.CrossDomainEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// crossingPair returns two ISS-like objects sharing a node whose planes
+// differ by 0.5°, so they pass close at every node crossing until the
+// differential J2 precession separates them, and a geostationary object the
+// apogee/perigee filter removes.
+func crossingPair(t *testing.T) (primary, secondary, geo *satellite.TLE) {
+	t.Helper()
+	base := satellite.OMM{
+		ObjectName: "PRIMARY", ObjectID: "2019-001A", NoradCatID: 90001,
+		Epoch:      time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC),
+		MeanMotion: 15.5, Eccentricity: 0.0001, Inclination: 51.6,
+		RAAN: 100, ArgPericenter: 0, MeanAnomaly: 90,
+	}
+	other := base
+	other.ObjectName, other.ObjectID, other.NoradCatID = "DEBRIS", "2019-002B", 90002
+	other.Inclination += 0.5
+	stationary := base
+	stationary.ObjectName, stationary.ObjectID, stationary.NoradCatID = "GEO", "2019-003A", 90003
+	stationary.MeanMotion, stationary.Inclination = 1.0027, 0.05
+
+	var err error
+	if primary, err = base.TLE(); err != nil {
+		t.Fatal(err)
+	}
+	if secondary, err = other.TLE(); err != nil {
+		t.Fatal(err)
+	}
+	if geo, err = stationary.TLE(); err != nil {
+		t.Fatal(err)
+	}
+	return primary, secondary, geo
+}
+
+func TestConjunctionScreening(t *testing.T) {
+	primary, secondary, geo := crossingPair(t)
+	start := time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)
+
+	cfg := conjunction.DefaultConfig()
+	cfg.Window = 3 * time.Hour
+	publisher := &recordingPublisher{}
+	screener := conjunction.NewScreener(cfg, publisher)
+
+	results, err := screener.Screen(context.Background(),
+		[]*satellite.TLE{primary}, []*satellite.TLE{primary, secondary, geo}, start)
+	if err != nil {
+		t.Fatalf("Screen: %v", err)
+	}
+	// Three hours is almost two orbits: three or four node crossings
+	if len(results) < 3 || len(results) > 4 {
+		t.Fatalf("found %d conjunctions, want 3-4", len(results))
+	}
+
+	pPrim, _ := satellite.NewPropagator(primary)
+	pSec, _ := satellite.NewPropagator(secondary)
+	distance := func(at time.Time) float64 {
+		a, _ := pPrim.PropagateState(at)
+		b, _ := pSec.PropagateState(at)
+		dx, dy, dz := a.Position[0]-b.Position[0], a.Position[1]-b.Position[1], a.Position[2]-b.Position[2]
+		return math.Sqrt(dx*dx + dy*dy + dz*dz)
+	}
+
+	for i, c := range results {
+		if c.Secondary.NoradID != 90002 {
+			t.Errorf("conjunction %d with %d; the geostationary object should be filtered", i, c.Secondary.NoradID)
+		}
+		if i > 0 && c.MissDistance < results[i-1].MissDistance {
+			t.Error("results not ordered by miss distance")
+		}
+		if c.MissDistance > cfg.ScreeningDistance {
+			t.Errorf("conjunction %d: miss %.3f km outside the screening distance", i, c.MissDistance)
+		}
+		// Crossing speed is 2 v sin(Δi/2)
+		if math.Abs(c.RelativeSpeed-0.0668) > 0.005 {
+			t.Errorf("conjunction %d: relative speed %.4f km/s", i, c.RelativeSpeed)
+		}
+		if d := distance(c.TCA); math.Abs(d-c.MissDistance) > 1e-9 ||
+			distance(c.TCA.Add(-time.Second)) < d || distance(c.TCA.Add(time.Second)) < d {
+			t.Errorf("conjunction %d: TCA %s is not the closest approach", i, c.TCA)
+		}
+		if c.Probability <= 0 || c.Probability > 1 {
+			t.Errorf("conjunction %d: Pc %.3e", i, c.Probability)
+		}
+	}
+	if results[0].MissDistance > 1 || results[0].Probability < 1e-3 {
+		t.Errorf("first node crossing: miss %.3f km, Pc %.3e", results[0].MissDistance, results[0].Probability)
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	highRisk := 0
+	for _, c := range results {
+		if c.Probability >= cfg.HighRiskProbability {
+			highRisk++
+		}
+	}
+	if len(publisher.events) != highRisk {
+		t.Fatalf("published %d events for %d high-risk conjunctions", len(publisher.events), highRisk)
+	}
+	ev := publisher.events[0]
+	if ev.Type != controlplane.EventSpaceConjunction || ev.Domain != controlplane.DomainSpace ||
+		ev.Severity != controlplane.SeverityCritical || !ev.RequiresAck {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.Payload["message_id"] != results[0].MessageID(cfg.Source) || ev.Payload["secondary_id"] != 90002 {
+		t.Errorf("event payload = %v", ev.Payload)
+	}
+}
+
+func TestConjunctionCDM(t *testing.T) {
+	primary, secondary, _ := crossingPair(t)
+	start := time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)
+	cfg := conjunction.DefaultConfig()
+	cfg.Window = time.Hour
+	cfg.Method = conjunction.MethodAlfano
+	results, err := conjunction.NewScreener(cfg, nil).Screen(context.Background(),
+		[]*satellite.TLE{primary}, []*satellite.TLE{secondary}, start)
+	if err != nil || len(results) == 0 {
+		t.Fatalf("Screen: %d results (%v)", len(results), err)
+	}
+	c := results[0]
+
+	var buf bytes.Buffer
+	if err := conjunction.WriteCDM(&buf, &c, "ASGARD", start); err != nil {
+		t.Fatalf("WriteCDM: %v", err)
+	}
+
+	fields := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			t.Fatalf("malformed CDM line %q", line)
+		}
+		value = strings.TrimSpace(value)
+		if i := strings.Index(value, " ["); i >= 0 {
+			value = value[:i]
+		}
+		fields[strings.TrimSpace(key)] = append(fields[strings.TrimSpace(key)], value)
+	}
+
+	first := func(key string) string {
+		if len(fields[key]) == 0 {
+			t.Fatalf("CDM missing %s", key)
+		}
+		return fields[key][0]
+	}
+	if first("CCSDS_CDM_VERS") != "1.0" || first("COLLISION_PROBABILITY_METHOD") != "ALFANO-2005" {
+		t.Errorf("CDM header:\n%s", buf.String())
+	}
+	if first("TCA") != c.TCA.UTC().Format("2006-01-02T15:04:05.000") {
+		t.Errorf("TCA %s, want %s", first("TCA"), c.TCA)
+	}
+	if miss, _ := strconv.ParseFloat(first("MISS_DISTANCE"), 64); math.Abs(miss-c.MissDistance*1000) > 0.05 {
+		t.Errorf("MISS_DISTANCE %s m, want %.1f", first("MISS_DISTANCE"), c.MissDistance*1000)
+	}
+	if pc, _ := strconv.ParseFloat(first("COLLISION_PROBABILITY"), 64); math.Abs(pc-c.Probability)/c.Probability > 1e-5 {
+		t.Errorf("COLLISION_PROBABILITY %s, want %.6e", first("COLLISION_PROBABILITY"), c.Probability)
+	}
+	if got := strings.Join(fields["OBJECT"], ","); got != "OBJECT1,OBJECT2" {
+		t.Errorf("OBJECT = %s", got)
+	}
+	if got := strings.Join(fields["OBJECT_DESIGNATOR"], ","); got != "90001,90002" {
+		t.Errorf("OBJECT_DESIGNATOR = %s", got)
+	}
+	if got := strings.Join(fields["INTERNATIONAL_DESIGNATOR"], ","); got != "2019-001A,2019-002B" {
+		t.Errorf("INTERNATIONAL_DESIGNATOR = %s", got)
+	}
+	covKeys := 0
+	for key, values := range fields {
+		if strings.HasPrefix(key, "C") && strings.Contains(key, "_") && key != "CCSDS_CDM_VERS" &&
+			key != "CREATION_DATE" && key != "CATALOG_NAME" && key != "COLLISION_PROBABILITY" &&
+			key != "COLLISION_PROBABILITY_METHOD" && key != "COVARIANCE_METHOD" {
+			if len(values) != 2 {
+				t.Errorf("%s appears %d times", key, len(values))
+			}
+			covKeys++
+		}
+	}
+	if covKeys != 21 {
+		t.Errorf("%d covariance keywords per object, want 21", covKeys)
+	}
+	if crr, _ := strconv.ParseFloat(first("CR_R"), 64); math.Abs(crr-c.Primary.Covariance[0][0]*1e6)/crr > 1e-6 {
+		t.Errorf("CR_R = %s m², want %.6e", first("CR_R"), c.Primary.Covariance[0][0]*1e6)
+	}
+}
@@ -0,0 +1,331 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+var (
+	testHMACKey = bytes.Repeat([]byte{0x1a}, 32)
+	testAESKey  = bytes.Repeat([]byte{0x71}, 32)
+)
+
+func TestBIBSignAndVerify(t *testing.T) {
+	for _, variant := range []uint64{bundle.HMACSHA256, bundle.HMACSHA384, bundle.HMACSHA512} {
+		b := bundle.NewBundle("ipn:2.1", "ipn:1.2", []byte("Ready to generate a 32-byte payload"))
+		if err := bundle.Sign(b, "ipn:2.1", testHMACKey, variant); err != nil {
+			t.Fatalf("variant %d: sign failed: %v", variant, err)
+		}
+
+		data, err := bundle.CBOR.Marshal(b)
+		if err != nil {
+			t.Fatalf("variant %d: marshal failed: %v", variant, err)
+		}
+		got, err := bundle.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("variant %d: unmarshal failed: %v", variant, err)
+		}
+
+		keys := bundle.NewKeyRing()
+		keys.SetKey("ipn:2.1", "*", testHMACKey)
+
+		// A forwarder verifies but keeps the BIB
+		forwarded := got.Clone()
+		if err := bundle.ProcessSecurity(forwarded, keys, false); err != nil {
+			t.Fatalf("variant %d: verifier rejected bundle: %v", variant, err)
+		}
+		if bib, _ := bundle.HasSecurity(forwarded); !bib {
+			t.Errorf("variant %d: verifier should keep the BIB", variant)
+		}
+
+		// The acceptor verifies and strips it
+		if err := bundle.ProcessSecurity(got, keys, true); err != nil {
+			t.Fatalf("variant %d: acceptor rejected bundle: %v", variant, err)
+		}
+		if bib, _ := bundle.HasSecurity(got); bib {
+			t.Errorf("variant %d: acceptor should remove the BIB", variant)
+		}
+	}
+}
+
+func TestBIBDetectsTampering(t *testing.T) {
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/nysus", []byte("command: deorbit=false"))
+	if err := bundle.Sign(b, "dtn://leo/sat001", testHMACKey, bundle.HMACSHA384); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := bundle.NewKeyRing()
+	keys.SetKey("dtn://leo/sat001", "dtn://earth/nysus", testHMACKey)
+
+	tampered := b.Clone()
+	tampered.Payload = []byte("command: deorbit=true!")
+	if err := bundle.ProcessSecurity(tampered, keys, true); !errors.Is(err, bundle.ErrIntegrityFailed) {
+		t.Errorf("expected integrity failure for modified payload, got %v", err)
+	}
+
+	redirected := b.Clone()
+	redirected.DestinationEID = "dtn://earth/attacker"
+	keys.SetKey("dtn://leo/sat001", "dtn://earth/attacker", testHMACKey)
+	if err := bundle.ProcessSecurity(redirected, keys, true); !errors.Is(err, bundle.ErrIntegrityFailed) {
+		t.Errorf("expected integrity failure for modified primary block, got %v", err)
+	}
+
+	wrongKey := bundle.NewKeyRing()
+	wrongKey.SetKey("*", "*", bytes.Repeat([]byte{0x00}, 32))
+	if err := bundle.ProcessSecurity(b.Clone(), wrongKey, true); !errors.Is(err, bundle.ErrIntegrityFailed) {
+		t.Errorf("expected integrity failure with wrong key, got %v", err)
+	}
+
+	if err := bundle.ProcessSecurity(b.Clone(), bundle.NewKeyRing(), true); !errors.Is(err, bundle.ErrSecurityKeyMissing) {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+}
+
+func TestBCBEncryptAndDecrypt(t *testing.T) {
+	for _, key := range [][]byte{testAESKey[:16], testAESKey} {
+		plaintext := []byte("silenus alert: wildfire at 34.05N 118.24W")
+		b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/nysus", append([]byte(nil), plaintext...))
+		if err := bundle.Encrypt(b, "dtn://leo/sat001", key); err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+		if bytes.Contains(b.Payload, []byte("wildfire")) {
+			t.Fatal("payload should be encrypted")
+		}
+		if err := bundle.Sign(b, "dtn://leo/sat001", testHMACKey, bundle.HMACSHA384); !errors.Is(err, bundle.ErrSecurityConflict) {
+			t.Errorf("signing an encrypted payload should conflict, got %v", err)
+		}
+
+		data, err := bundle.CBOR.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := bundle.Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys := bundle.NewKeyRing()
+		keys.SetKey("dtn://leo/sat001", "dtn://earth/nysus", key)
+
+		// Forwarders cannot read the payload and leave the BCB alone
+		relay := got.Clone()
+		if err := bundle.ProcessSecurity(relay, keys, false); err != nil || bytes.Equal(relay.Payload, plaintext) {
+			t.Errorf("forwarder should pass BCB through unchanged, err=%v", err)
+		}
+
+		corrupted := got.Clone()
+		corrupted.Payload[0] ^= 0x01
+		if err := bundle.ProcessSecurity(corrupted, keys, true); !errors.Is(err, bundle.ErrDecryptionFailed) {
+			t.Errorf("expected decryption failure for corrupted ciphertext, got %v", err)
+		}
+
+		if err := bundle.ProcessSecurity(got, keys, true); err != nil {
+			t.Fatalf("decrypt failed: %v", err)
+		}
+		if !bytes.Equal(got.Payload, plaintext) {
+			t.Errorf("decrypted payload = %q", got.Payload)
+		}
+		if _, bcb := bundle.HasSecurity(got); bcb {
+			t.Error("acceptor should remove the BCB")
+		}
+	}
+}
+
+func TestKeyRingWildcards(t *testing.T) {
+	keys := bundle.NewKeyRing()
+	keys.SetKey("*", "*", []byte("default"))
+	keys.SetKey("dtn://leo/sat001", "*", []byte("sat"))
+	keys.SetKey("dtn://leo/sat001", "dtn://earth/nysus", []byte("exact"))
+
+	tests := []struct{ src, dst, want string }{
+		{"dtn://leo/sat001", "dtn://earth/nysus", "exact"},
+		{"dtn://leo/sat001", "dtn://earth/ground001", "sat"},
+		{"dtn://leo/sat002", "dtn://earth/nysus", "default"},
+	}
+	for _, tt := range tests {
+		if key, ok := keys.Key(tt.src, tt.dst); !ok || string(key) != tt.want {
+			t.Errorf("Key(%s, %s) = %q, want %q", tt.src, tt.dst, key, tt.want)
+		}
+	}
+}
+
+func TestNodeEnforcesSecurityPolicy(t *testing.T) {
+	satConfig := dtn.DefaultNodeConfig()
+	sat, ground, _ := startNodePair(t, satConfig)
+
+	keys := bundle.NewKeyRing()
+	keys.SetKey("dtn://leo/sat001", "dtn://earth/ground001", testAESKey)
+	sat.SetSecurityPolicy(&dtn.SecurityPolicy{Keys: keys, EncryptOutbound: true})
+	ground.SetSecurityPolicy(&dtn.SecurityPolicy{Keys: keys, RequireSecurity: true})
+
+	if err := sat.CreateBundle("dtn://earth/ground001", []byte("encrypted telemetry"), bundle.PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ground.GetMetrics().BundlesReceived == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if ground.GetMetrics().SecurityFailures != 0 {
+		t.Fatal("protected bundle should pass the ground policy")
+	}
+
+	// An unprotected bundle is rejected by RequireSecurity
+	sat.SetSecurityPolicy(nil)
+	if err := sat.CreateBundle("dtn://earth/ground001", []byte("plaintext"), bundle.PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for ground.GetMetrics().SecurityFailures == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if ground.GetMetrics().SecurityFailures != 1 {
+		t.Error("unprotected bundle should fail the ground policy")
+	}
+}
+
+func TestNodeQuarantinesTamperedBundle(t *testing.T) {
+	storage := dtn.NewInMemoryStorage(100)
+	node := dtn.NewNode("ground001", "dtn://earth/ground001", storage, dtn.NewStaticRouter(), dtn.DefaultNodeConfig())
+	keys := bundle.NewKeyRing()
+	keys.SetKey("*", "*", testHMACKey)
+	node.SetSecurityPolicy(&dtn.SecurityPolicy{Keys: keys, FailureAction: dtn.SecurityActionQuarantine})
+	if err := node.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { node.Stop() })
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("original"))
+	if err := bundle.Sign(b, "dtn://leo/sat001", testHMACKey, bundle.HMACSHA256); err != nil {
+		t.Fatal(err)
+	}
+	b.Payload = []byte("modified")
+	if err := node.Receive(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	quarantined := waitForBundles(storage, dtn.StatusQuarantined, 1)
+	if len(quarantined) != 1 || quarantined[0].ID != b.ID {
+		t.Fatal("tampered bundle should be quarantined")
+	}
+	if delivered := waitForBundles(storage, dtn.StatusDelivered, 0); len(delivered) != 0 {
+		t.Error("tampered bundle must not be delivered")
+	}
+}
+
+// RFC 9173 Appendix A.1.3: BIB-HMAC-SHA2 over the payload with HMAC
+// 512/512, integrity scope 0 and key h'1a2b1a2b...'.
+const rfcBIBBlockHex = "850b0200005856" +
+	"810101018202820201828201078203008181820158403bdc69b3a34a2b5d3a8554368bd1e808" +
+	"f606219d2a10a846eae3886ae4ecc83c4ee550fdfb1cc636b904e2f1a73e303dcd4b6ccece00" +
+	"3e95e8164dcc89a156e1"
+
+// RFC 9173 Appendix A.2.3: BCB-AES-GCM over the payload with A128GCM, AAD
+// scope 0, IV "Twelve121212" and a content-encryption key wrapped with
+// h'6162...6f70'.
+const (
+	rfcBCBBlockHex = "850c0201005850" +
+		"8101020182028202018482014c5477656c7665313231323132820201820358" +
+		"1869c411276fecddc4780df42c8a2af89296fabf34d7fae7008204008181820150efa4b5ac01" +
+		"08e3816c5606479801bc04"
+	rfcEncryptedPayloadBlockHex = "850101000058233a09c1e63fe23a7f66a59c7303837241e070b02619fc" +
+		"59c5214a22f08cd70795e73e9a"
+)
+
+func rfcSecuredBundle(t *testing.T, blocks ...string) []byte {
+	t.Helper()
+	data := []byte{0x9f}
+	for _, blk := range append([]string{rfcPrimaryBlockHex}, blocks...) {
+		raw, err := hex.DecodeString(blk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, raw...)
+	}
+	return append(data, 0xff)
+}
+
+// checkRFCSecurityBlock checks that the security block of b decodes and
+// re-encodes to the published bytes.
+func checkRFCSecurityBlock(t *testing.T, b *bundle.Bundle, blockType uint64, source string) {
+	t.Helper()
+	for _, blk := range b.Blocks {
+		if blk.Type != blockType {
+			continue
+		}
+		asb, err := bundle.ParseSecurityBlock(blk.Data)
+		if err != nil {
+			t.Fatalf("security block %d: %v", blk.Number, err)
+		}
+		if asb.Source != source || len(asb.Targets) != 1 || asb.Targets[0] != bundle.PayloadBlockNumber {
+			t.Errorf("security block %d: source %s targets %v", blk.Number, asb.Source, asb.Targets)
+		}
+		encoded, err := asb.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, blk.Data) {
+			t.Errorf("security block %d re-encodes as %x, want %x", blk.Number, encoded, blk.Data)
+		}
+		return
+	}
+	t.Fatalf("no block of type %d", blockType)
+}
+
+func TestBIBRFC9173Vector(t *testing.T) {
+	b, err := bundle.Unmarshal(rfcSecuredBundle(t, rfcBIBBlockHex, rfcPayloadBlockHex))
+	if err != nil {
+		t.Fatalf("failed to decode RFC 9173 A.1.4 bundle: %v", err)
+	}
+	checkRFCSecurityBlock(t, b, bundle.BlockTypeBIB, "ipn:2.1")
+
+	keys := bundle.NewKeyRing()
+	keys.SetKey("ipn:2.1", "*", []byte{0x1a, 0x2b, 0x1a, 0x2b, 0x1a, 0x2b, 0x1a, 0x2b, 0x1a, 0x2b, 0x1a, 0x2b, 0x1a, 0x2b, 0x1a, 0x2b})
+
+	tampered := b.Clone()
+	tampered.Payload[0] ^= 0x01
+	if err := bundle.ProcessSecurity(tampered, keys, true); !errors.Is(err, bundle.ErrIntegrityFailed) {
+		t.Errorf("expected ErrIntegrityFailed for a modified payload, got %v", err)
+	}
+
+	if err := bundle.ProcessSecurity(b, keys, true); err != nil {
+		t.Fatalf("published BIB failed verification: %v", err)
+	}
+	if bib, _ := bundle.HasSecurity(b); bib {
+		t.Error("acceptor should remove the BIB")
+	}
+}
+
+func TestBCBRFC9173Vector(t *testing.T) {
+	b, err := bundle.Unmarshal(rfcSecuredBundle(t, rfcBCBBlockHex, rfcEncryptedPayloadBlockHex))
+	if err != nil {
+		t.Fatalf("failed to decode RFC 9173 A.2.4 bundle: %v", err)
+	}
+	checkRFCSecurityBlock(t, b, bundle.BlockTypeBCB, "ipn:2.1")
+
+	wrongKey := bundle.NewKeyRing()
+	wrongKey.SetKey("ipn:2.1", "*", []byte("qwertyuiopasdfgh"))
+	if err := bundle.ProcessSecurity(b.Clone(), wrongKey, true); !errors.Is(err, bundle.ErrDecryptionFailed) {
+		t.Errorf("expected ErrDecryptionFailed with the wrong key-encryption key, got %v", err)
+	}
+
+	keys := bundle.NewKeyRing()
+	keys.SetKey("ipn:2.1", "*", []byte("abcdefghijklmnop"))
+	if err := bundle.ProcessSecurity(b, keys, true); err != nil {
+		t.Fatalf("published BCB failed decryption: %v", err)
+	}
+	if got := string(b.Payload); got != "Ready to generate a 32-byte payload" {
+		t.Errorf("decrypted payload %q", got)
+	}
+	if _, bcb := bundle.HasSecurity(b); bcb {
+		t.Error("acceptor should remove the BCB")
+	}
+}
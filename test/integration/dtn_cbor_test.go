@@ -0,0 +1,271 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// RFC 9173 Appendix A.1.1: the unsecured example bundle used throughout the
+// BPSec default security context examples.
+const (
+	rfcPrimaryBlockHex = "88070000820282010282028202018202820201820018281a000f4240"
+	rfcPayloadBlockHex = "85010100005823526561647920746f2067656e657261746520612033322d62797465207061796c6f6164"
+)
+
+func rfcExampleBundle(t *testing.T) []byte {
+	t.Helper()
+	primary, err := hex.DecodeString(rfcPrimaryBlockHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := hex.DecodeString(rfcPayloadBlockHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte{0x9f}
+	data = append(data, primary...)
+	data = append(data, payload...)
+	return append(data, 0xff)
+}
+
+func TestCRCCheckValues(t *testing.T) {
+	check := []byte("123456789")
+	if got := bundle.ChecksumCRC16(check); got != 0x906e {
+		t.Errorf("CRC-16 X.25 = %#04x, want 0x906e", got)
+	}
+	if got := bundle.ChecksumCRC32C(check); got != 0xe3069283 {
+		t.Errorf("CRC-32C = %#08x, want 0xe3069283", got)
+	}
+}
+
+func TestParseEID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"dtn://earth/nysus", "dtn://earth/nysus", false},
+		{"dtn:none", "dtn:none", false},
+		{"", "dtn:none", false},
+		{"ipn:977.1", "ipn:977.1", false},
+		{"ipn:977", "", true},
+		{"http://example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		eid, err := bundle.ParseEID(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseEID(%q) expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEID(%q) error: %v", tt.in, err)
+			continue
+		}
+		if eid.String() != tt.want {
+			t.Errorf("ParseEID(%q) = %q, want %q", tt.in, eid.String(), tt.want)
+		}
+	}
+}
+
+func TestCBORDecodeRFCExample(t *testing.T) {
+	data := rfcExampleBundle(t)
+
+	b, err := bundle.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("failed to decode RFC example: %v", err)
+	}
+
+	if b.DestinationEID != "ipn:1.2" {
+		t.Errorf("destination = %s, want ipn:1.2", b.DestinationEID)
+	}
+	if b.SourceEID != "ipn:2.1" || b.ReportTo != "ipn:2.1" {
+		t.Errorf("source/report-to = %s/%s, want ipn:2.1", b.SourceEID, b.ReportTo)
+	}
+	if !b.CreationTimestamp.IsZero() || b.SequenceNumber != 40 {
+		t.Errorf("timestamp = %v/%d, want zero/40", b.CreationTimestamp, b.SequenceNumber)
+	}
+	if b.Lifetime != 1000*time.Second {
+		t.Errorf("lifetime = %v, want 1000s", b.Lifetime)
+	}
+	if string(b.Payload) != "Ready to generate a 32-byte payload" {
+		t.Errorf("unexpected payload: %q", b.Payload)
+	}
+	if b.ID != bundle.DeriveBundleID(b) {
+		t.Error("foreign bundle should get a derived ID")
+	}
+
+	// Re-encoding without ASGARD metadata must reproduce the RFC bytes.
+	codec := bundle.CBORCodec{OmitMetadata: true}
+	encoded, err := codec.Marshal(b)
+	if err != nil {
+		t.Fatalf("failed to re-encode: %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("re-encoded bundle differs:\n got %x\nwant %x", encoded, data)
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	for _, crcType := range []uint8{bundle.CRCNone, bundle.CRC16X25, bundle.CRC32C} {
+		b := bundle.NewBundle("dtn://mars/sat001", "ipn:42.7", []byte("telemetry frame"))
+		b.CreationTimestamp = b.CreationTimestamp.Truncate(time.Millisecond)
+		b.CRCType = crcType
+		b.Priority = bundle.PriorityExpedited
+		b.SequenceNumber = 3
+		b.BundleFlags = bundle.FlagReportDelivery
+		b.PreviousNode = "dtn://leo/relay1"
+		b.HopCount = 4
+		b.BundleAge = 1500 * time.Millisecond
+		b.Blocks = []bundle.CanonicalBlock{{Type: 200, Number: 9, CRCType: crcType, Data: []byte{1, 2, 3}}}
+
+		data, err := bundle.CBOR.Marshal(b)
+		if err != nil {
+			t.Fatalf("crc %d: marshal failed: %v", crcType, err)
+		}
+		if bundle.DetectCodec(data) != bundle.CBOR {
+			t.Errorf("crc %d: codec not detected as CBOR", crcType)
+		}
+
+		got, err := bundle.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("crc %d: unmarshal failed: %v", crcType, err)
+		}
+
+		if got.ID != b.ID || got.Priority != b.Priority {
+			t.Errorf("crc %d: metadata not preserved", crcType)
+		}
+		if got.SourceEID != b.SourceEID || got.DestinationEID != b.DestinationEID || got.ReportTo != b.ReportTo {
+			t.Errorf("crc %d: EIDs not preserved", crcType)
+		}
+		if !got.CreationTimestamp.Equal(b.CreationTimestamp) || got.Lifetime != b.Lifetime {
+			t.Errorf("crc %d: timestamps not preserved", crcType)
+		}
+		if got.PreviousNode != b.PreviousNode || got.HopCount != b.HopCount || got.BundleAge != b.BundleAge {
+			t.Errorf("crc %d: extension blocks not preserved", crcType)
+		}
+		if got.BundleFlags != b.BundleFlags || got.CRCType != crcType || got.SequenceNumber != 3 {
+			t.Errorf("crc %d: primary fields not preserved", crcType)
+		}
+		if len(got.Blocks) != 1 || got.Blocks[0].Number != 9 || !bytes.Equal(got.Blocks[0].Data, []byte{1, 2, 3}) {
+			t.Errorf("crc %d: unknown block not preserved: %+v", crcType, got.Blocks)
+		}
+		if !bytes.Equal(got.Payload, b.Payload) {
+			t.Errorf("crc %d: payload mismatch", crcType)
+		}
+	}
+}
+
+func TestCBORFragmentRoundTrip(t *testing.T) {
+	b := bundle.NewBundle("ipn:10.1", "ipn:20.1", []byte("chunk"))
+	b.IsFragment = true
+	b.FragmentOffset = 1024
+	b.TotalADULength = 4096
+
+	data, err := bundle.CBOR.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	got, err := bundle.CBOR.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if !got.IsFragment || got.FragmentOffset != 1024 || got.TotalADULength != 4096 {
+		t.Errorf("fragment fields not preserved: %+v", got)
+	}
+	if got.BundleFlags&bundle.FlagIsFragment == 0 {
+		t.Error("fragment flag should be set on the wire")
+	}
+}
+
+func TestCBORDetectsCorruption(t *testing.T) {
+	b := bundle.NewBundle("dtn://a/b", "dtn://c/d", []byte("integrity matters"))
+	b.CRCType = bundle.CRC32C
+
+	data, err := bundle.CBOR.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	idx := bytes.Index(data, []byte("integrity"))
+	if idx < 0 {
+		t.Fatal("payload not found in encoding")
+	}
+	data[idx] ^= 0xff
+
+	if _, err := bundle.CBOR.Unmarshal(data); err == nil {
+		t.Error("expected CRC mismatch on corrupted payload")
+	}
+}
+
+func TestCBORStreamDecoding(t *testing.T) {
+	var buf bytes.Buffer
+	enc := bundle.NewEncoderWithCodec(&buf, bundle.CBOR)
+
+	first := bundle.NewBundle("dtn://a/1", "dtn://b/1", []byte("first"))
+	second := bundle.NewBundle("dtn://a/2", "dtn://b/2", []byte("second"))
+	for _, b := range []*bundle.Bundle{first, second} {
+		if err := enc.Encode(b); err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+	}
+
+	dec := bundle.NewDecoderWithCodec(&buf, bundle.CBOR)
+	for _, want := range []*bundle.Bundle{first, second} {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if got.ID != want.ID || string(got.Payload) != string(want.Payload) {
+			t.Errorf("stream decode mismatch: got %s", got)
+		}
+	}
+}
+
+func TestCBORRejectsDeepNesting(t *testing.T) {
+	// An indefinite-length bundle array holding a million nested arrays.
+	data := append([]byte{0x9f}, bytes.Repeat([]byte{0x81}, 1<<20)...)
+	data = append(data, 0x00, 0xff)
+
+	_, err := bundle.NewDecoderWithCodec(bytes.NewReader(data), bundle.CBOR).Decode()
+	if err == nil || !strings.Contains(err.Error(), "nesting") {
+		t.Errorf("stream decode of deep nesting: got %v, want nesting error", err)
+	}
+	if _, err := bundle.CBOR.Unmarshal(data); err == nil {
+		t.Error("expected unmarshal of deep nesting to fail")
+	}
+}
+
+func TestLegacyCodecStillSupported(t *testing.T) {
+	b := bundle.NewBundle("dtn://a/b", "dtn://c/d", []byte("legacy"))
+
+	data, err := bundle.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if bundle.DetectCodec(data) != bundle.Legacy {
+		t.Error("legacy encoding should be detected as legacy")
+	}
+	got, err := bundle.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if got.ID != b.ID {
+		t.Error("legacy round trip lost bundle ID")
+	}
+
+	codec, err := bundle.CodecByName("cbor")
+	if err != nil || codec.Name() != "cbor" {
+		t.Errorf("CodecByName(cbor) = %v, %v", codec, err)
+	}
+	if _, err := bundle.CodecByName("protobuf"); err == nil {
+		t.Error("expected error for unknown codec")
+	}
+}
@@ -0,0 +1,262 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+const testContactPlan = `
+# Two relays between node 1 and node 4
+1
+a contact +0 +3600 1 2 100000
+a contact +0 +3600 2 1 100000
+a contact +0 +3600 1 3 100000
+a contact +600 +3600 2 4 100000 0.9
+a contact +60 +3600 3 4 1000
+a contact 2026/01/01-00:00:00 2026/01/01-01:00:00 4 5 500
+a range +0 +3600 1 2 1
+a range +0 +3600 3 4 2.5
+m production 1000000
+`
+
+func TestParseContactPlan(t *testing.T) {
+	epoch := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	contacts, err := dtn.ParseContactPlan(strings.NewReader(testContactPlan), epoch)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(contacts) != 6 {
+		t.Fatalf("expected 6 contacts, got %d", len(contacts))
+	}
+
+	c := contacts[0]
+	if c.From != "ipn:1" || c.To != "ipn:2" || !c.Start.Equal(epoch) || c.End.Sub(c.Start) != time.Hour {
+		t.Errorf("unexpected contact: %+v", c)
+	}
+	if c.OWLT != time.Second || contacts[1].OWLT != time.Second {
+		t.Error("range should apply to both directions")
+	}
+	if contacts[2].OWLT != 0 {
+		t.Error("contact without range should have zero OWLT")
+	}
+	if contacts[4].OWLT != 2500*time.Millisecond || contacts[4].Volume() != 1000*3540 {
+		t.Errorf("contact 3->4: owlt=%v volume=%d", contacts[4].OWLT, contacts[4].Volume())
+	}
+	if contacts[3].Confidence != 0.9 || contacts[0].Confidence != 1 {
+		t.Error("confidence not parsed")
+	}
+	if !contacts[5].Start.Equal(epoch) {
+		t.Errorf("absolute start = %v, want %v", contacts[5].Start, epoch)
+	}
+
+	if _, err := dtn.ParseContactPlan(strings.NewReader("a contact +0 +10 1 2"), epoch); err == nil {
+		t.Error("expected error for short contact line")
+	}
+	if _, err := dtn.ParseContactPlan(strings.NewReader("a contact +10 +0 1 2 100"), epoch); err == nil {
+		t.Error("expected error for contact ending before it starts")
+	}
+}
+
+// cgrNeighbors returns active neighbors for ipn nodes 2 and 3.
+func cgrNeighbors() map[string]*dtn.Neighbor {
+	return map[string]*dtn.Neighbor{
+		"relay2": {ID: "relay2", EID: "ipn:2.0", IsActive: true},
+		"relay3": {ID: "relay3", EID: "ipn:3.0", IsActive: true},
+	}
+}
+
+func newPlanRouter(t *testing.T, plan string) (*dtn.ContactGraphRouter, time.Time) {
+	t.Helper()
+	now := time.Now().UTC()
+	contacts, err := dtn.ParseContactPlan(strings.NewReader(plan), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := dtn.NewContactGraphRouter("ipn:1.0")
+	router.SetContactPlan(contacts)
+	return router, now
+}
+
+func TestCGRComputesEarliestArrivalRoutes(t *testing.T) {
+	router, now := newPlanRouter(t, testContactPlan)
+
+	routes := router.Routes("ipn:4.1", now)
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	if routes[0].NextHop != "ipn:3" || len(routes[0].Contacts) != 2 {
+		t.Errorf("best route should go through node 3, got %+v", routes[0])
+	}
+	want := now.Add(60*time.Second + 2500*time.Millisecond)
+	if !routes[0].ArrivalTime.Equal(want) {
+		t.Errorf("arrival = %v, want %v", routes[0].ArrivalTime, want)
+	}
+	if routes[1].NextHop != "ipn:2" || !routes[1].ArrivalTime.Equal(now.Add(600*time.Second)) {
+		t.Errorf("second route should go through node 2 at +600s, got %+v", routes[1])
+	}
+	if routes[1].Confidence != 0.9 {
+		t.Errorf("route confidence = %v, want 0.9", routes[1].Confidence)
+	}
+
+	b := bundle.NewBundle("ipn:1.0", "ipn:4.1", []byte("telemetry"))
+	nextHop, err := router.SelectNextHop(context.Background(), b, cgrNeighbors())
+	if err != nil {
+		t.Fatalf("routing failed: %v", err)
+	}
+	if nextHop != "relay3" {
+		t.Errorf("next hop = %s, want relay3", nextHop)
+	}
+
+	if routes := router.Routes("ipn:9.0", now); len(routes) != 0 {
+		t.Errorf("expected no route to unknown node, got %d", len(routes))
+	}
+}
+
+func TestCGRKShortestRoutes(t *testing.T) {
+	router, now := newPlanRouter(t, `
+a contact +0 +3600 1 2 0
+a contact +0 +3600 1 3 0
+a contact +0 +3600 2 3 0
+a contact +0 +3600 3 2 0
+a contact +10 +3600 2 4 0
+a contact +20 +3600 3 4 0
+`)
+	router.SetMaxRoutes(4)
+
+	routes := router.Routes("ipn:4.0", now)
+	if len(routes) != 4 {
+		t.Fatalf("expected 4 loop-free routes, got %d", len(routes))
+	}
+
+	seen := make(map[string]bool)
+	for i, route := range routes {
+		var path []string
+		for _, c := range route.Contacts {
+			path = append(path, c.From)
+		}
+		key := strings.Join(path, ">")
+		if seen[key] {
+			t.Errorf("duplicate route %s", key)
+		}
+		seen[key] = true
+		if i > 0 && route.ArrivalTime.Before(routes[i-1].ArrivalTime) {
+			t.Error("routes should be ordered by arrival time")
+		}
+	}
+	if !routes[0].ArrivalTime.Equal(now.Add(10*time.Second)) || len(routes[0].Contacts) != 2 {
+		t.Errorf("best route should be 1>2>4 at +10s, got %+v", routes[0])
+	}
+}
+
+func TestCGRBooksContactCapacity(t *testing.T) {
+	router, now := newPlanRouter(t, testContactPlan)
+
+	// Contact 3->4 carries 1000 B/s for 3540 s
+	payload := make([]byte, 2_000_000)
+	b := bundle.NewBundle("ipn:1.0", "ipn:4.1", payload)
+	nextHop, err := router.SelectNextHop(context.Background(), b, cgrNeighbors())
+	if err != nil || nextHop != "relay3" {
+		t.Fatalf("first bundle: next hop %s, err %v", nextHop, err)
+	}
+	if booked := router.BookedBytes("ipn:3.0", "ipn:4.0", now.Add(60*time.Second)); booked != int64(b.Size()) {
+		t.Errorf("booked = %d, want %d", booked, b.Size())
+	}
+
+	// The next bundle no longer fits through node 3 and goes via node 2
+	b2 := bundle.NewBundle("ipn:1.0", "ipn:4.1", payload)
+	nextHop, err = router.SelectNextHop(context.Background(), b2, cgrNeighbors())
+	if err != nil || nextHop != "relay2" {
+		t.Fatalf("second bundle: next hop %s, err %v", nextHop, err)
+	}
+
+	// Reloading the same plan keeps bookings
+	router.SetContactPlan(router.ContactPlan())
+	if booked := router.BookedBytes("ipn:3.0", "ipn:4.0", now.Add(60*time.Second)); booked == 0 {
+		t.Error("bookings should survive a plan reload")
+	}
+}
+
+func TestCGRReleasesBookingOnRerouteAndRelease(t *testing.T) {
+	router, now := newPlanRouter(t, testContactPlan)
+	start := now.Add(60 * time.Second)
+
+	payload := make([]byte, 2_000_000)
+	b := bundle.NewBundle("ipn:1.0", "ipn:4.1", payload)
+	for i := 0; i < 2; i++ {
+		// Routing the same bundle again replaces its booking
+		if nextHop, err := router.SelectNextHop(context.Background(), b, cgrNeighbors()); err != nil || nextHop != "relay3" {
+			t.Fatalf("pass %d: next hop %s, err %v", i, nextHop, err)
+		}
+	}
+	if booked := router.BookedBytes("ipn:3.0", "ipn:4.0", start); booked != int64(b.Size()) {
+		t.Errorf("booked after re-route = %d, want %d", booked, b.Size())
+	}
+
+	router.ReleaseBooking(b.ID)
+	if booked := router.BookedBytes("ipn:3.0", "ipn:4.0", start); booked != 0 {
+		t.Errorf("booked after release = %d, want 0", booked)
+	}
+
+	// The released capacity is available to the next bundle
+	b2 := bundle.NewBundle("ipn:1.0", "ipn:4.1", payload)
+	if nextHop, err := router.SelectNextHop(context.Background(), b2, cgrNeighbors()); err != nil || nextHop != "relay3" {
+		t.Fatalf("after release: next hop %s, err %v", nextHop, err)
+	}
+}
+
+func TestCGRWaitsForContactAndHonorsLifetime(t *testing.T) {
+	router, _ := newPlanRouter(t, `
+a contact +0 +3600 1 2 0
+a contact +300 +3600 2 4 0
+`)
+
+	b := bundle.NewBundle("ipn:1.0", "ipn:4.0", []byte("x"))
+	if _, err := router.SelectNextHop(context.Background(), b, cgrNeighbors()); err != nil {
+		t.Fatalf("first contact is open, routing failed: %v", err)
+	}
+
+	short := bundle.NewBundle("ipn:1.0", "ipn:4.0", []byte("x"))
+	short.SetLifetime(time.Minute)
+	if _, err := router.SelectNextHop(context.Background(), short, cgrNeighbors()); err == nil {
+		t.Error("bundle expiring before arrival should not be routed")
+	}
+
+	// Bundles are not returned to the node they came from
+	back := bundle.NewBundle("ipn:9.0", "ipn:4.0", []byte("x"))
+	back.PreviousNode = "ipn:2.0"
+	if _, err := router.SelectNextHop(context.Background(), back, cgrNeighbors()); err == nil {
+		t.Error("route back through the previous node should be rejected")
+	}
+
+	late, _ := newPlanRouter(t, "a contact +120 +3600 1 2 0\na contact +120 +3600 2 4 0")
+	if _, err := late.SelectNextHop(context.Background(), b, cgrNeighbors()); !errors.Is(err, dtn.ErrContactNotStarted) {
+		t.Errorf("expected ErrContactNotStarted, got %v", err)
+	}
+}
+
+func TestCGRInvalidatesRoutesOnPlanChange(t *testing.T) {
+	router, now := newPlanRouter(t, "a contact +0 +3600 1 2 0\na contact +0 +3600 2 4 0")
+	if routes := router.Routes("ipn:4.0", now); len(routes) != 1 || routes[0].NextHop != "ipn:2" {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+
+	contacts, _ := dtn.ParseContactPlan(strings.NewReader("a contact +0 +3600 1 3 0\na contact +0 +3600 3 4 0"), now)
+	router.SetContactPlan(contacts)
+	if routes := router.Routes("ipn:4.0", now); len(routes) != 1 || routes[0].NextHop != "ipn:3" {
+		t.Errorf("routes not recomputed after plan change: %+v", routes)
+	}
+
+	// Neighbor registrations add contacts from the local node
+	router.UpdateContactGraph("local", &dtn.Neighbor{ID: "relay2", EID: "ipn:2.0", IsActive: true})
+	contacts = append(contacts, dtn.Contact{From: "ipn:2", To: "ipn:5", Start: now, End: now.Add(time.Hour)})
+	router.SetContactPlan(contacts)
+	if routes := router.Routes("ipn:5.0", now); len(routes) != 1 || routes[0].NextHop != "ipn:2" {
+		t.Errorf("expected route over the registered neighbor, got %+v", routes)
+	}
+}
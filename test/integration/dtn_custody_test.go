@@ -0,0 +1,279 @@
+package integration_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+func TestAdminRecordRoundTrip(t *testing.T) {
+	subject := bundle.NewBundle("ipn:10.1", "dtn://earth/nysus", []byte("alert"))
+	subject.BundleFlags |= bundle.FlagStatusTimeRequested
+	subject.IsFragment = true
+	subject.FragmentOffset = 512
+	subject.TotalADULength = 2048
+
+	at := time.Now()
+	data, err := bundle.NewStatusReport(subject, bundle.FlagReportDelivery, bundle.ReasonNoInfo, at).Marshal()
+	if err != nil {
+		t.Fatalf("marshal status report: %v", err)
+	}
+	record, err := bundle.ParseAdminRecord(data)
+	if err != nil {
+		t.Fatalf("parse status report: %v", err)
+	}
+	report := record.StatusReport
+	if record.Type != bundle.AdminRecordStatusReport || report == nil {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+	if !report.Delivered.Asserted || report.Received.Asserted || report.Deleted.Asserted {
+		t.Errorf("wrong status items: %+v", report)
+	}
+	if !report.Delivered.Time.Equal(at.UTC().Truncate(time.Millisecond)) {
+		t.Errorf("delivery time = %v, want %v", report.Delivered.Time, at)
+	}
+	if report.Subject.Key() != bundle.RefOf(subject).Key() {
+		t.Errorf("subject = %s, want %s", report.Subject.Key(), bundle.RefOf(subject).Key())
+	}
+
+	data, err = bundle.NewCustodySignal(subject, false, bundle.ReasonDepletedStorage).Marshal()
+	if err != nil {
+		t.Fatalf("marshal custody signal: %v", err)
+	}
+	record, err = bundle.ParseAdminRecord(data)
+	if err != nil {
+		t.Fatalf("parse custody signal: %v", err)
+	}
+	if sig := record.CustodySignal; sig == nil || sig.Accepted || sig.Reason != bundle.ReasonDepletedStorage {
+		t.Errorf("unexpected custody signal: %+v", sig)
+	}
+
+	if _, err := bundle.ParseAdminRecord([]byte{0x82, 0x09, 0x80}); err == nil {
+		t.Error("expected error for unknown record type")
+	}
+}
+
+func TestCustodianSurvivesCBOR(t *testing.T) {
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("x"))
+	b.BundleFlags |= bundle.FlagCustodyRequested
+	b.Custodian = "dtn://leo/sat001"
+
+	data, err := bundle.CBOR.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := bundle.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Custodian != b.Custodian || got.BundleFlags&bundle.FlagCustodyRequested == 0 {
+		t.Errorf("custody fields lost: custodian=%q flags=%#x", got.Custodian, got.BundleFlags)
+	}
+}
+
+// startNodePair connects a satellite node to a ground node over TCPCL.
+func startNodePair(t *testing.T, satConfig dtn.NodeConfig) (sat, ground *dtn.Node, satStorage dtn.BundleStorage) {
+	t.Helper()
+
+	cfg := dtn.DefaultTCPCLConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	groundTransport := dtn.NewTCPCLTransport("dtn://earth/ground001", cfg)
+	ground = dtn.NewNodeWithTransport("ground001", "dtn://earth/ground001",
+		dtn.NewInMemoryStorage(1000), dtn.NewStaticRouter(), groundTransport, dtn.DefaultNodeConfig())
+	if err := ground.Start(); err != nil {
+		t.Fatalf("ground start failed: %v", err)
+	}
+	t.Cleanup(func() { ground.Stop() })
+
+	satStorage = dtn.NewInMemoryStorage(1000)
+	sat = dtn.NewNodeWithTransport("sat001", "dtn://leo/sat001",
+		satStorage, dtn.NewStaticRouter(), dtn.NewTCPCLTransport("dtn://leo/sat001", cfg), satConfig)
+	if err := sat.Start(); err != nil {
+		t.Fatalf("sat start failed: %v", err)
+	}
+	t.Cleanup(func() { sat.Stop() })
+
+	address := groundTransport.Addr().String()
+	sat.RegisterNeighbor(&dtn.Neighbor{ID: "ground001", EID: "dtn://earth/ground001", Address: address, IsActive: true})
+	if err := sat.ConnectNeighbor(context.Background(), "ground001", address); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	return sat, ground, satStorage
+}
+
+func TestNodeSendsStatusReports(t *testing.T) {
+	sat, _, _ := startNodePair(t, dtn.DefaultNodeConfig())
+
+	var mu sync.Mutex
+	reports := make(chan *bundle.StatusReport, 10)
+	sat.SetStatusReportHandler(func(r *bundle.StatusReport) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports <- r
+	})
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("alert"))
+	b.BundleFlags |= bundle.FlagReportReception | bundle.FlagReportDelivery | bundle.FlagReportForwarding | bundle.FlagStatusTimeRequested
+	if err := sat.Send(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	var received, forwarded, delivered bool
+	deadline := time.After(5 * time.Second)
+	for !(received && forwarded && delivered) {
+		select {
+		case r := <-reports:
+			if r.Subject.Key() != bundle.RefOf(b).Key() {
+				t.Errorf("report for unexpected subject %s", r.Subject.Key())
+			}
+			received = received || r.Received.Asserted
+			forwarded = forwarded || r.Forwarded.Asserted
+			delivered = delivered || r.Delivered.Asserted
+			if r.Delivered.Asserted && r.Delivered.Time.IsZero() {
+				t.Error("delivery report should carry the requested time")
+			}
+		case <-deadline:
+			t.Fatalf("missing reports: received=%t forwarded=%t delivered=%t", received, forwarded, delivered)
+		}
+	}
+}
+
+func TestNodeCustodyTransfer(t *testing.T) {
+	sat, _, satStorage := startNodePair(t, dtn.DefaultNodeConfig())
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("must arrive"))
+	b.BundleFlags |= bundle.FlagCustodyRequested
+	if err := sat.Send(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sat.GetMetrics().CustodyTransfers == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if sat.GetMetrics().CustodyTransfers != 1 {
+		t.Fatal("custody should transfer to the ground station")
+	}
+	if sat.CustodyCount() != 0 {
+		t.Error("no bundles should remain in custody")
+	}
+	if _, err := satStorage.Retrieve(context.Background(), b.ID); err == nil {
+		t.Error("released bundle should be removed from storage")
+	}
+}
+
+func TestNodeCustodyRetransmitsOnTimeout(t *testing.T) {
+	// The receiver is a bare convergence layer that never signals custody.
+	sink := startTCPCL(t, "dtn://earth/ground001", nil)
+
+	config := dtn.DefaultNodeConfig()
+	config.CustodyTimeout = 100 * time.Millisecond
+	config.RetryInterval = 50 * time.Millisecond
+	config.MaxRetries = 2
+
+	cfg := dtn.DefaultTCPCLConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	storage := dtn.NewInMemoryStorage(100)
+	sat := dtn.NewNodeWithTransport("sat001", "dtn://leo/sat001",
+		storage, dtn.NewStaticRouter(), dtn.NewTCPCLTransport("dtn://leo/sat001", cfg), config)
+	if err := sat.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sat.Stop() })
+
+	address := sink.Addr().String()
+	sat.RegisterNeighbor(&dtn.Neighbor{ID: "ground001", EID: "dtn://earth/ground001", Address: address, IsActive: true})
+	if err := sat.ConnectNeighbor(context.Background(), "ground001", address); err != nil {
+		t.Fatal(err)
+	}
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("retry me"))
+	b.BundleFlags |= bundle.FlagCustodyRequested
+	if err := sat.Send(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	copies := 0
+	timeout := time.After(5 * time.Second)
+	for copies < 3 {
+		select {
+		case got := <-sink.Receive():
+			if got.Custodian != "dtn://leo/sat001" {
+				t.Errorf("custodian = %q, want sender", got.Custodian)
+			}
+			copies++
+		case <-timeout:
+			t.Fatalf("expected original plus 2 retransmissions, got %d copies", copies)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sat.CustodyCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if sat.CustodyCount() != 0 {
+		t.Error("custody should be abandoned after MaxRetries")
+	}
+	if status, err := storage.GetStatus(context.Background(), b.ID); err != nil || status == dtn.StatusInTransit {
+		t.Errorf("bundle should leave custody, status=%s err=%v", status, err)
+	}
+}
+
+func TestNodeRestoresCustodyAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	cfg := dtn.DefaultFileStorageConfig(t.TempDir())
+
+	// A previous run sent the bundle and stopped before custody was accepted
+	storage := openFileStorage(t, cfg)
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("in flight at shutdown"))
+	b.BundleFlags |= bundle.FlagCustodyRequested
+	b.Custodian = "dtn://leo/sat001"
+	if err := storage.Store(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.UpdateStatus(ctx, b.ID, dtn.StatusInTransit); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := startTCPCL(t, "dtn://earth/ground001", nil)
+
+	config := dtn.DefaultNodeConfig()
+	config.CustodyTimeout = 100 * time.Millisecond
+	config.RetryInterval = 50 * time.Millisecond
+
+	storage = openFileStorage(t, cfg)
+	t.Cleanup(func() { storage.Close() })
+	tcpcl := dtn.DefaultTCPCLConfig()
+	tcpcl.ListenAddress = "127.0.0.1:0"
+	sat := dtn.NewNodeWithTransport("sat001", "dtn://leo/sat001",
+		storage, dtn.NewStaticRouter(), dtn.NewTCPCLTransport("dtn://leo/sat001", tcpcl), config)
+	if err := sat.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sat.Stop() })
+	if sat.CustodyCount() != 1 {
+		t.Fatalf("custody count after restart = %d, want 1", sat.CustodyCount())
+	}
+
+	address := sink.Addr().String()
+	sat.RegisterNeighbor(&dtn.Neighbor{ID: "ground001", EID: "dtn://earth/ground001", Address: address, IsActive: true})
+	if err := sat.ConnectNeighbor(ctx, "ground001", address); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-sink.Receive():
+		if got.ID != b.ID || got.Custodian != "dtn://leo/sat001" {
+			t.Errorf("retransmitted %s with custodian %q", got.ID, got.Custodian)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("restored custody bundle was not retransmitted")
+	}
+}
@@ -0,0 +1,183 @@
+package integration_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+)
+
+var emulatorEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// relayScenario has a ground station that reaches a second one only through
+// a satellite, whose downlink opens 30 minutes after the uplink closes.
+func relayScenario() dtn.Scenario {
+	at := func(d time.Duration) time.Time { return emulatorEpoch.Add(d) }
+	return dtn.Scenario{
+		Name:     "relay",
+		Start:    emulatorEpoch,
+		Duration: 2 * time.Hour,
+		Nodes: []dtn.ScenarioNode{
+			{ID: "ground1", EID: "ipn:1.0"},
+			{ID: "sat1", EID: "ipn:2.0"},
+			{ID: "ground2", EID: "ipn:3.0"},
+		},
+		Contacts: []dtn.Contact{
+			{From: "ground1", To: "sat1", Start: at(0), End: at(10 * time.Minute), Rate: 100_000, OWLT: 10 * time.Millisecond},
+			{From: "ipn:2", To: "ipn:3", Start: at(40 * time.Minute), End: at(50 * time.Minute), Rate: 100_000, OWLT: 10 * time.Millisecond},
+		},
+		Traffic: []dtn.TrafficFlow{
+			{From: "ground1", To: "ground2", Start: time.Minute, Interval: time.Minute, Count: 5, Size: 1000, Priority: 1},
+		},
+		Routers: []dtn.EmulatedRouter{dtn.EmulateCGR},
+	}
+}
+
+func TestEmulatorStoreAndForwardOverScheduledContacts(t *testing.T) {
+	emulator, err := dtn.NewEmulator(relayScenario())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := emulator.Run(context.Background(), dtn.EmulateCGR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.BundlesCreated != 5 || report.BundlesDelivered != 5 || report.DeliveryRatio != 1 {
+		t.Fatalf("delivered %d of %d bundles", report.BundlesDelivered, report.BundlesCreated)
+	}
+	// Bundles created 1-5 minutes in wait for the downlink at 40 minutes
+	if report.MaxLatency > 39*time.Minute+time.Second || report.MedianLatency < 36*time.Minute {
+		t.Errorf("latency median %v max %v does not match the contact schedule", report.MedianLatency, report.MaxLatency)
+	}
+	if report.Transmissions != 10 || report.Overhead != 2 {
+		t.Errorf("expected two hops per bundle, got %d transmissions (overhead %.2f)", report.Transmissions, report.Overhead)
+	}
+}
+
+func TestEmulatorLinkDelayAndBandwidth(t *testing.T) {
+	emulator, err := dtn.NewEmulator(dtn.Scenario{
+		Start:    emulatorEpoch,
+		Duration: time.Minute,
+		Nodes: []dtn.ScenarioNode{
+			{ID: "sat1", EID: "dtn://leo/sat001"},
+			{ID: "ground1", EID: "dtn://earth/ground001"},
+		},
+		Contacts: []dtn.Contact{{
+			From:  "sat1",
+			To:    "ground1",
+			Start: emulatorEpoch,
+			End:   emulatorEpoch.Add(time.Hour),
+			Rate:  10_000,
+			OWLT:  2 * time.Second,
+		}},
+		Traffic: []dtn.TrafficFlow{{From: "sat1", To: "ground1", Size: 50_000}},
+		Routers: []dtn.EmulatedRouter{dtn.EmulateStatic},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := emulator.Run(context.Background(), dtn.EmulateStatic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.BundlesDelivered != 1 {
+		t.Fatalf("bundle not delivered: %+v", report)
+	}
+	// 5 seconds on the wire at 10 kB/s plus 2 seconds of light time
+	if report.MeanLatency < 7*time.Second || report.MeanLatency > 7100*time.Millisecond {
+		t.Errorf("latency = %v, want about 7s", report.MeanLatency)
+	}
+	if report.BytesTransmitted <= 50_000 {
+		t.Errorf("transmitted %d bytes, less than the payload", report.BytesTransmitted)
+	}
+}
+
+func TestEmulatorFragmentsAcrossContacts(t *testing.T) {
+	scenario := dtn.Scenario{
+		Start:    emulatorEpoch,
+		Duration: 10 * time.Minute,
+		Nodes: []dtn.ScenarioNode{
+			{ID: "sat1", EID: "ipn:1.0"},
+			{ID: "ground1", EID: "ipn:2.0"},
+		},
+		Contacts: []dtn.Contact{
+			{From: "sat1", To: "ground1", Start: emulatorEpoch, End: emulatorEpoch.Add(10 * time.Second), Rate: 1000},
+			{From: "sat1", To: "ground1", Start: emulatorEpoch.Add(time.Minute), End: emulatorEpoch.Add(2 * time.Minute), Rate: 1000},
+		},
+		Traffic: []dtn.TrafficFlow{{From: "sat1", To: "ground1", Size: 15_000}},
+	}
+	emulator, err := dtn.NewEmulator(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := emulator.Run(context.Background(), dtn.EmulateCGR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.BundlesDelivered != 1 || report.FragmentsCreated == 0 {
+		t.Fatalf("expected delivery in fragments across both contacts: %+v", report)
+	}
+	if report.MeanLatency < time.Minute {
+		t.Errorf("latency %v: the bundle cannot fit the first 10s contact", report.MeanLatency)
+	}
+}
+
+func TestEmulatorComparesRoutersOnTLEScenario(t *testing.T) {
+	scenario, err := dtn.LoadScenario("../../configs/dtn/leo_relay.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenario.Contacts) == 0 {
+		t.Fatal("no contacts predicted from the scenario TLEs")
+	}
+	for _, c := range scenario.Contacts {
+		if c.From != "ipn:1.0" && c.To != "ipn:1.0" {
+			t.Fatalf("predicted contact %s -> %s does not involve the satellite", c.From, c.To)
+		}
+		if c.Rate != 125000 || c.OWLT != 20*time.Millisecond {
+			t.Fatalf("scenario link parameters not applied: %+v", c)
+		}
+	}
+
+	emulator, err := dtn.NewEmulator(*scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports, err := emulator.Compare(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byRouter := make(map[dtn.EmulatedRouter]*dtn.EmulatorReport)
+	for _, r := range reports {
+		byRouter[r.Router] = r
+	}
+	for _, kind := range []dtn.EmulatedRouter{dtn.EmulateCGR, dtn.EmulateEnergy, dtn.EmulateRL, dtn.EmulateStatic} {
+		if byRouter[kind] == nil || byRouter[kind].BundlesCreated != 48 {
+			t.Fatalf("missing or incomplete report for %s", kind)
+		}
+	}
+
+	cgr := byRouter[dtn.EmulateCGR]
+	if cgr.DeliveryRatio != 1 || cgr.Overhead != 2 {
+		t.Errorf("CGR should relay every bundle over exactly two hops: %+v", cgr)
+	}
+	// The satellite's battery is too low for bulk traffic
+	if energy := byRouter[dtn.EmulateEnergy]; energy.BundlesDelivered >= cgr.BundlesDelivered {
+		t.Errorf("energy-aware routing delivered %d bundles, expected fewer than CGR's %d", energy.BundlesDelivered, cgr.BundlesDelivered)
+	}
+
+	// Runs are deterministic
+	again, err := emulator.Run(context.Background(), dtn.EmulateStatic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, byRouter[dtn.EmulateStatic]) {
+		t.Errorf("repeated run differs:\n%+v\n%+v", again, byRouter[dtn.EmulateStatic])
+	}
+}
@@ -0,0 +1,314 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+func openFileStorage(t *testing.T, cfg dtn.FileStorageConfig) *dtn.FileBundleStorage {
+	t.Helper()
+	storage, err := dtn.NewFileBundleStorage(cfg)
+	if err != nil {
+		t.Fatalf("open file storage: %v", err)
+	}
+	return storage
+}
+
+func TestFileStorageSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	cfg := dtn.DefaultFileStorageConfig(t.TempDir())
+	storage := openFileStorage(t, cfg)
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/nysus", []byte("queued telemetry"))
+	b.BundleFlags |= bundle.FlagCustodyRequested
+	b.Custodian = "dtn://leo/sat001"
+	b.Priority = bundle.PriorityExpedited
+	b.Blocks = []bundle.CanonicalBlock{{Type: 200, Number: 5, Data: []byte{1, 2, 3}}}
+
+	frag := bundle.NewBundle("ipn:2.1", "ipn:1.1", []byte("fragment"))
+	frag.IsFragment = true
+	frag.FragmentOffset = 1024
+	frag.TotalADULength = 4096
+
+	gone := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/nysus", []byte("delivered"))
+
+	for _, x := range []*bundle.Bundle{b, frag, gone} {
+		if err := storage.Store(ctx, x); err != nil {
+			t.Fatalf("store failed: %v", err)
+		}
+	}
+	storage.UpdateStatus(ctx, b.ID, dtn.StatusDeferred)
+	storage.UpdateStatus(ctx, frag.ID, dtn.StatusReassembling)
+	storage.Delete(ctx, gone.ID)
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	storage = openFileStorage(t, cfg)
+	defer storage.Close()
+
+	if count, _ := storage.Count(ctx); count != 2 {
+		t.Fatalf("expected 2 bundles after restart, got %d", count)
+	}
+	got, err := storage.Retrieve(ctx, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Payload, b.Payload) || got.Custodian != b.Custodian || got.Priority != b.Priority ||
+		got.BundleFlags != b.BundleFlags || len(got.Blocks) != 1 || !got.CreationTimestamp.Equal(b.CreationTimestamp) {
+		t.Errorf("bundle changed across restart: %+v", got)
+	}
+	if status, _ := storage.GetStatus(ctx, b.ID); status != dtn.StatusDeferred {
+		t.Errorf("status = %s, want deferred", status)
+	}
+	got, err = storage.Retrieve(ctx, frag.ID)
+	if err != nil || !got.IsFragment || got.FragmentOffset != 1024 || got.TotalADULength != 4096 {
+		t.Errorf("fragment fields lost: %+v err=%v", got, err)
+	}
+	if _, err := storage.Retrieve(ctx, gone.ID); err == nil {
+		t.Error("deleted bundle should stay deleted")
+	}
+}
+
+func TestFileStorageTruncatesTornWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := dtn.DefaultFileStorageConfig(dir)
+	storage := openFileStorage(t, cfg)
+
+	b := bundle.NewBundle("dtn://a", "dtn://b", []byte("durable"))
+	if err := storage.Store(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	// Simulate a crash in the middle of appending a record
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segments) != 1 {
+		t.Fatalf("expected one segment, got %d", len(segments))
+	}
+	info, _ := os.Stat(segments[0])
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0xde, 0xad, 0xbe, 0xef, 0x00, 0x00, 0x10, 0x00, 0x01, 0x42})
+	f.Close()
+
+	storage = openFileStorage(t, cfg)
+	defer storage.Close()
+	if _, err := storage.Retrieve(ctx, b.ID); err != nil {
+		t.Fatalf("bundle before torn record lost: %v", err)
+	}
+	if after, _ := os.Stat(segments[0]); after.Size() != info.Size() {
+		t.Errorf("segment size = %d, want truncated to %d", after.Size(), info.Size())
+	}
+
+	next := bundle.NewBundle("dtn://a", "dtn://b", []byte("after recovery"))
+	if err := storage.Store(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := storage.Count(ctx); count != 2 {
+		t.Errorf("expected 2 bundles, got %d", count)
+	}
+}
+
+func TestFileStorageRejectsOversizedRecordLength(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := dtn.DefaultFileStorageConfig(dir)
+	storage := openFileStorage(t, cfg)
+
+	b := bundle.NewBundle("dtn://a", "dtn://b", []byte("durable"))
+	if err := storage.Store(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	// A torn header whose length field claims nearly 4 GiB
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	info, _ := os.Stat(segments[0])
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0xde, 0xad, 0xbe, 0xef, 0xff, 0xff, 0xff, 0xf0, 0x01, 0x42, 0x42})
+	f.Close()
+
+	storage = openFileStorage(t, cfg)
+	defer storage.Close()
+	if _, err := storage.Retrieve(ctx, b.ID); err != nil {
+		t.Fatalf("bundle before damaged record lost: %v", err)
+	}
+	if after, _ := os.Stat(segments[0]); after.Size() != info.Size() {
+		t.Errorf("segment size = %d, want truncated to %d", after.Size(), info.Size())
+	}
+}
+
+func TestFileStorageKeepsOldCopyWhenStoreFails(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := dtn.DefaultFileStorageConfig(dir)
+	cfg.SegmentSize = 256
+	storage := openFileStorage(t, cfg)
+
+	b := bundle.NewBundle("dtn://a", "dtn://b", bytes.Repeat([]byte("v1"), 100))
+	if err := storage.Store(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next record rolls to segment 2, which cannot be opened
+	blocker := filepath.Join(dir, "0000000000000002.seg")
+	if err := os.Mkdir(blocker, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	updated := b.Clone()
+	updated.Payload = bytes.Repeat([]byte("v2"), 100)
+	if err := storage.Store(ctx, updated); err == nil {
+		t.Fatal("store should fail when the segment cannot be created")
+	}
+
+	got, err := storage.Retrieve(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("old copy dropped by the failed store: %v", err)
+	}
+	if !bytes.Equal(got.Payload, b.Payload) {
+		t.Error("failed store replaced the stored payload")
+	}
+	if count, _ := storage.Count(ctx); count != 1 {
+		t.Errorf("expected 1 bundle, got %d", count)
+	}
+	storage.Close()
+
+	// The log agrees with the index after a restart
+	os.Remove(blocker)
+	storage = openFileStorage(t, cfg)
+	defer storage.Close()
+	if got, err := storage.Retrieve(ctx, b.ID); err != nil || !bytes.Equal(got.Payload, b.Payload) {
+		t.Errorf("old copy after restart: %v", err)
+	}
+}
+
+func TestFileStorageListAndPurge(t *testing.T) {
+	ctx := context.Background()
+	cfg := dtn.DefaultFileStorageConfig(t.TempDir())
+	cfg.Sync = dtn.SyncInterval
+	storage := openFileStorage(t, cfg)
+	defer storage.Close()
+
+	for _, p := range []uint8{bundle.PriorityBulk, bundle.PriorityExpedited, bundle.PriorityNormal} {
+		b, _ := bundle.NewPriorityBundle("dtn://a", "dtn://b", []byte("x"), p)
+		storage.Store(ctx, b)
+	}
+	expiring := bundle.NewBundle("dtn://a", "dtn://c", []byte("short-lived"))
+	expiring.SetLifetime(50 * time.Millisecond)
+	if err := storage.Store(ctx, expiring); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := storage.List(ctx, dtn.BundleFilter{DestinationEID: "dtn://b", OrderBy: "priority"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Priority != bundle.PriorityExpedited || list[2].Priority != bundle.PriorityBulk {
+		t.Errorf("bundles not ordered by priority")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	purged, err := storage.PurgeExpired(ctx)
+	if err != nil || purged != 1 {
+		t.Errorf("purged %d bundles, err %v", purged, err)
+	}
+	if count, _ := storage.Count(ctx); count != 3 {
+		t.Errorf("expected 3 bundles after purge, got %d", count)
+	}
+}
+
+func TestFileStorageQuotaEvictsLowestPriority(t *testing.T) {
+	ctx := context.Background()
+	cfg := dtn.DefaultFileStorageConfig(t.TempDir())
+	payload := make([]byte, 1000)
+
+	probe := bundle.NewBundle("dtn://a", "dtn://b", payload)
+	cfg.MaxBytes = int64(3*probe.Size() + 100)
+	storage := openFileStorage(t, cfg)
+	defer storage.Close()
+
+	bulk, _ := bundle.NewPriorityBundle("dtn://a", "dtn://b", payload, bundle.PriorityBulk)
+	normal, _ := bundle.NewPriorityBundle("dtn://a", "dtn://b", payload, bundle.PriorityNormal)
+	expedited, _ := bundle.NewPriorityBundle("dtn://a", "dtn://b", payload, bundle.PriorityExpedited)
+	newest, _ := bundle.NewPriorityBundle("dtn://a", "dtn://b", payload, bundle.PriorityNormal)
+
+	for _, b := range []*bundle.Bundle{bulk, normal, expedited, newest} {
+		if err := storage.Store(ctx, b); err != nil {
+			t.Fatalf("store failed: %v", err)
+		}
+	}
+
+	if _, err := storage.Retrieve(ctx, bulk.ID); err == nil {
+		t.Error("bulk bundle should be evicted first")
+	}
+	if storage.Bytes() > cfg.MaxBytes {
+		t.Errorf("stored %d bytes, quota %d", storage.Bytes(), cfg.MaxBytes)
+	}
+
+	huge := bundle.NewBundle("dtn://a", "dtn://b", make([]byte, 10_000))
+	if err := storage.Store(ctx, huge); err == nil {
+		t.Error("bundle larger than the quota should be rejected")
+	}
+}
+
+func TestFileStorageCompaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := dtn.DefaultFileStorageConfig(dir)
+	cfg.SegmentSize = 4096
+	cfg.CompactRatio = 0
+	storage := openFileStorage(t, cfg)
+
+	var keep []*bundle.Bundle
+	for i := 0; i < 40; i++ {
+		b := bundle.NewBundle("dtn://a", "dtn://b", bytes.Repeat([]byte{byte(i)}, 500))
+		if err := storage.Store(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		if i%10 == 0 {
+			keep = append(keep, b)
+			storage.UpdateStatus(ctx, b.ID, dtn.StatusDeferred)
+		} else {
+			storage.Delete(ctx, b.ID)
+		}
+	}
+
+	before, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err := storage.Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(after) >= len(before) {
+		t.Errorf("compaction kept %d of %d segments", len(after), len(before))
+	}
+	storage.Close()
+
+	storage = openFileStorage(t, cfg)
+	defer storage.Close()
+	if count, _ := storage.Count(ctx); count != len(keep) {
+		t.Fatalf("expected %d bundles after compaction, got %d", len(keep), count)
+	}
+	for _, b := range keep {
+		got, err := storage.Retrieve(ctx, b.ID)
+		if err != nil || !bytes.Equal(got.Payload, b.Payload) {
+			t.Errorf("bundle %s damaged by compaction: %v", b.ID, err)
+		}
+		if status, _ := storage.GetStatus(ctx, b.ID); status != dtn.StatusDeferred {
+			t.Errorf("status = %s after compaction, want deferred", status)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+func fragmentPayload(n int) []byte {
+	payload := make([]byte, n)
+	for i := range payload {
+		payload[i] = byte(i * 7)
+	}
+	return payload
+}
+
+func TestFragmentAndReassemble(t *testing.T) {
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", fragmentPayload(10000))
+	b.Blocks = []bundle.CanonicalBlock{
+		{Type: 200, Number: 5, Flags: bundle.BlockFlagReplicate, Data: []byte("keep")},
+		{Type: 201, Number: 6, Data: []byte("first-only")},
+	}
+
+	fragments, err := bundle.Fragment(b, 3000)
+	if err != nil {
+		t.Fatalf("fragment failed: %v", err)
+	}
+	if len(fragments) != 4 {
+		t.Fatalf("expected 4 fragments, got %d", len(fragments))
+	}
+	for i, frag := range fragments {
+		if !frag.IsFragment || frag.TotalADULength != 10000 || frag.FragmentOffset != uint64(i*3000) {
+			t.Errorf("fragment %d has wrong fragment fields: %+v", i, frag)
+		}
+		if frag.FragmentKey() != b.FragmentKey() {
+			t.Errorf("fragment %d does not share the ADU key", i)
+		}
+		wantBlocks := 2
+		if i > 0 {
+			wantBlocks = 1
+		}
+		if len(frag.Blocks) != wantBlocks {
+			t.Errorf("fragment %d carries %d extension blocks, want %d", i, len(frag.Blocks), wantBlocks)
+		}
+	}
+
+	// Fragments survive the wire and arrive out of order.
+	decoded := make([]*bundle.Bundle, len(fragments))
+	for i, frag := range fragments {
+		data, err := bundle.CBOR.Marshal(frag)
+		if err != nil {
+			t.Fatalf("marshal fragment %d: %v", i, err)
+		}
+		if decoded[i], err = bundle.Unmarshal(data); err != nil {
+			t.Fatalf("unmarshal fragment %d: %v", i, err)
+		}
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(decoded), func(i, j int) {
+		decoded[i], decoded[j] = decoded[j], decoded[i]
+	})
+
+	if _, err := bundle.Reassemble(decoded[:3]); !errors.Is(err, bundle.ErrIncompleteADU) {
+		t.Errorf("expected ErrIncompleteADU with a missing fragment, got %v", err)
+	}
+
+	whole, err := bundle.Reassemble(decoded)
+	if err != nil {
+		t.Fatalf("reassemble failed: %v", err)
+	}
+	if whole.IsFragment || !bytes.Equal(whole.Payload, b.Payload) {
+		t.Error("reassembled payload does not match original")
+	}
+	if len(whole.Blocks) != 2 {
+		t.Errorf("reassembled bundle should keep first fragment's blocks, got %d", len(whole.Blocks))
+	}
+}
+
+func TestFragmentRespectsMustNotFragment(t *testing.T) {
+	b := bundle.NewBundle("dtn://a/b", "dtn://c/d", fragmentPayload(4096))
+	b.BundleFlags |= bundle.FlagMustNotFragment
+
+	if _, err := bundle.Fragment(b, 1024); !errors.Is(err, bundle.ErrMustNotFragment) {
+		t.Errorf("expected ErrMustNotFragment, got %v", err)
+	}
+	if frags, err := bundle.Fragment(b, 8192); err != nil || len(frags) != 1 {
+		t.Error("a bundle that fits should pass through unfragmented")
+	}
+}
+
+func TestReactiveFragmentRecovery(t *testing.T) {
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", fragmentPayload(5000))
+	data, err := bundle.CBOR.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	offset, length, err := bundle.PayloadOffset(data)
+	if err != nil || length != 5000 {
+		t.Fatalf("PayloadOffset = %d, %d, %v", offset, length, err)
+	}
+
+	// The link drops after 2000 payload bytes were acknowledged.
+	acked := uint64(offset + 2000)
+	partial := &dtn.PartialTransferError{
+		Acked:         acked,
+		Total:         uint64(len(data)),
+		PayloadOffset: offset,
+		PayloadLength: length,
+	}
+	if partial.DeliveredPayload() != 2000 {
+		t.Fatalf("DeliveredPayload = %d, want 2000", partial.DeliveredPayload())
+	}
+
+	received, err := bundle.UnmarshalPartial(data[:acked])
+	if err != nil {
+		t.Fatalf("receiver could not recover partial bundle: %v", err)
+	}
+	if !received.IsFragment || received.FragmentOffset != 0 || len(received.Payload) != 2000 {
+		t.Fatalf("unexpected recovered fragment: offset=%d len=%d", received.FragmentOffset, len(received.Payload))
+	}
+
+	remainder, err := bundle.FragmentRange(b, partial.DeliveredPayload(), uint64(len(b.Payload)))
+	if err != nil {
+		t.Fatalf("sender could not build remainder: %v", err)
+	}
+
+	whole, err := bundle.Reassemble([]*bundle.Bundle{remainder, received})
+	if err != nil {
+		t.Fatalf("reassemble failed: %v", err)
+	}
+	if !bytes.Equal(whole.Payload, b.Payload) {
+		t.Error("reactive fragments did not rebuild the original payload")
+	}
+}
+
+func TestReassemblerUsesStorage(t *testing.T) {
+	storage := dtn.NewInMemoryStorage(100)
+	r := dtn.NewReassembler(storage)
+	ctx := context.Background()
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", fragmentPayload(3000))
+	fragments, err := bundle.Fragment(b, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, frag := range fragments[:2] {
+		whole, err := r.Add(ctx, frag)
+		if err != nil || whole != nil {
+			t.Fatalf("fragment %d: expected to wait, got %v, %v", i, whole, err)
+		}
+	}
+	if count, _ := storage.Count(ctx); count != 2 {
+		t.Errorf("expected 2 held fragments, got %d", count)
+	}
+
+	whole, err := r.Add(ctx, fragments[2])
+	if err != nil || whole == nil {
+		t.Fatalf("expected completed bundle, got %v, %v", whole, err)
+	}
+	if !bytes.Equal(whole.Payload, b.Payload) {
+		t.Error("reassembled payload mismatch")
+	}
+	if count, _ := storage.Count(ctx); count != 0 {
+		t.Errorf("fragments should be released after reassembly, %d remain", count)
+	}
+}
+
+func startFragmentNodes(t *testing.T, neighbor dtn.Neighbor) (*dtn.Node, dtn.BundleStorage, dtn.BundleStorage) {
+	t.Helper()
+	return startFragmentNodesWithConfig(t, neighbor, dtn.DefaultNodeConfig())
+}
+
+func startFragmentNodesWithConfig(t *testing.T, neighbor dtn.Neighbor, satConfig dtn.NodeConfig) (*dtn.Node, dtn.BundleStorage, dtn.BundleStorage) {
+	t.Helper()
+
+	cfg := dtn.DefaultTCPCLConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	groundStorage := dtn.NewInMemoryStorage(1000)
+	groundTransport := dtn.NewTCPCLTransport("dtn://earth/ground001", cfg)
+	ground := dtn.NewNodeWithTransport("ground001", "dtn://earth/ground001",
+		groundStorage, dtn.NewStaticRouter(), groundTransport, dtn.DefaultNodeConfig())
+	if err := ground.Start(); err != nil {
+		t.Fatalf("ground start failed: %v", err)
+	}
+	t.Cleanup(func() { ground.Stop() })
+
+	satStorage := dtn.NewInMemoryStorage(1000)
+	sat := dtn.NewNodeWithTransport("sat001", "dtn://leo/sat001",
+		satStorage, dtn.NewStaticRouter(), dtn.NewTCPCLTransport("dtn://leo/sat001", cfg), satConfig)
+	if err := sat.Start(); err != nil {
+		t.Fatalf("sat start failed: %v", err)
+	}
+	t.Cleanup(func() { sat.Stop() })
+
+	neighbor.ID = "ground001"
+	neighbor.EID = "dtn://earth/ground001"
+	neighbor.Address = groundTransport.Addr().String()
+	neighbor.IsActive = true
+	sat.RegisterNeighbor(&neighbor)
+	if err := sat.ConnectNeighbor(context.Background(), "ground001", neighbor.Address); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	return sat, satStorage, groundStorage
+}
+
+func waitForBundles(storage dtn.BundleStorage, status dtn.BundleStatus, want int) []*bundle.Bundle {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		bundles, _ := storage.List(context.Background(), dtn.BundleFilter{Status: status})
+		if len(bundles) >= want || time.Now().After(deadline) {
+			return bundles
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestNodeFragmentsForLinkMTU(t *testing.T) {
+	sat, _, groundStorage := startFragmentNodes(t, dtn.Neighbor{MTU: 2048})
+
+	payload := fragmentPayload(20000)
+	if err := sat.CreateBundle("dtn://earth/ground001", payload, bundle.PriorityNormal); err != nil {
+		t.Fatalf("create bundle failed: %v", err)
+	}
+
+	delivered := waitForBundles(groundStorage, dtn.StatusDelivered, 1)
+	if len(delivered) != 1 {
+		t.Fatalf("expected one reassembled bundle, got %d", len(delivered))
+	}
+	if delivered[0].IsFragment || !bytes.Equal(delivered[0].Payload, payload) {
+		t.Error("delivered bundle does not match original payload")
+	}
+	if metrics := sat.GetMetrics(); metrics.FragmentsCreated < 10 {
+		t.Errorf("expected at least 10 fragments, got %d", metrics.FragmentsCreated)
+	}
+}
+
+func TestNodeDefersFragmentsBeyondContactWindow(t *testing.T) {
+	now := time.Now()
+	sat, satStorage, groundStorage := startFragmentNodes(t, dtn.Neighbor{
+		Bandwidth:    2000,
+		ContactStart: now,
+		ContactEnd:   now.Add(2 * time.Second),
+	})
+
+	if err := sat.CreateBundle("dtn://earth/ground001", fragmentPayload(12000), bundle.PriorityNormal); err != nil {
+		t.Fatalf("create bundle failed: %v", err)
+	}
+
+	deferred := waitForBundles(satStorage, dtn.StatusDeferred, 1)
+	if len(deferred) == 0 {
+		t.Fatal("fragments beyond the contact window should be deferred")
+	}
+	for _, b := range deferred {
+		if !b.IsFragment {
+			t.Error("deferred bundles should be fragments")
+		}
+	}
+
+	held := waitForBundles(groundStorage, dtn.StatusReassembling, 1)
+	if len(held) == 0 {
+		t.Error("receiver should hold the fragments that fit in the window")
+	}
+}
+
+func TestNodeGivesUpOnBundleLargerThanLinkMTU(t *testing.T) {
+	config := dtn.DefaultNodeConfig()
+	config.RetryInterval = 20 * time.Millisecond
+	config.MaxRetries = 2
+	// The MTU cannot even carry the bundle's headers.
+	sat, satStorage, _ := startFragmentNodesWithConfig(t, dtn.Neighbor{MTU: 16}, config)
+
+	// Store the bundle as a relay would so retries can find it
+	b, err := bundle.NewPriorityBundle("dtn://leo/sat001", "dtn://earth/ground001", fragmentPayload(4000), bundle.PriorityNormal)
+	if err != nil {
+		t.Fatalf("create bundle failed: %v", err)
+	}
+	if err := satStorage.Store(context.Background(), b); err != nil {
+		t.Fatalf("store bundle failed: %v", err)
+	}
+	if err := sat.Send(context.Background(), b); err != nil {
+		t.Fatalf("send bundle failed: %v", err)
+	}
+
+	if given := waitForBundles(satStorage, dtn.StatusUndeliverable, 1); len(given) != 1 {
+		t.Fatal("bundle that can never fit the link should become undeliverable")
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, status := range []dtn.BundleStatus{dtn.StatusFailed, dtn.StatusPending, dtn.StatusDeferred} {
+		if bundles, _ := satStorage.List(context.Background(), dtn.BundleFilter{Status: status}); len(bundles) != 0 {
+			t.Errorf("%d bundles still %s after giving up", len(bundles), status)
+		}
+	}
+}
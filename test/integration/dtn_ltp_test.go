@@ -0,0 +1,264 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// LTP segment types as seen on the wire (RFC 5326 Section 3.1.3).
+func ltpSegmentType(data []byte) byte {
+	return data[0] & 0x0f
+}
+
+func isLTPCheckpoint(data []byte) bool {
+	typ := ltpSegmentType(data)
+	return typ >= 1 && typ <= 3
+}
+
+// startLTPPair connects two LTP engines over an emulated link. The sender
+// reaches the receiver as neighbor "ground001".
+func startLTPPair(t *testing.T, link dtn.LinkEmulatorConfig, mutate func(*dtn.LTPConfig)) (sat, ground *dtn.LTPTransport, emulator *dtn.LinkEmulator) {
+	t.Helper()
+	emulator = dtn.NewLinkEmulator(link)
+
+	start := func(nodeEID string) *dtn.LTPTransport {
+		conn, err := emulator.ListenPacket("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := dtn.DefaultLTPConfig()
+		cfg.Conn = conn
+		cfg.OWLT = link.Delay
+		cfg.TimerMargin = 20 * time.Millisecond
+		cfg.MaxSegmentSize = 512
+		if mutate != nil {
+			mutate(&cfg)
+		}
+		tr := dtn.NewLTPTransport(nodeEID, cfg)
+		if err := tr.Start(context.Background()); err != nil {
+			t.Fatalf("failed to start LTP transport: %v", err)
+		}
+		t.Cleanup(func() { tr.Stop() })
+		return tr
+	}
+
+	sat = start("ipn:1.0")
+	ground = start("ipn:2.0")
+	if sat.EngineID() != 1 || ground.EngineID() != 2 {
+		t.Errorf("engine IDs %d/%d should follow the ipn node numbers", sat.EngineID(), ground.EngineID())
+	}
+	if err := sat.Connect(context.Background(), "ground001", ground.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ground.Connect(context.Background(), "sat001", sat.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	return sat, ground, emulator
+}
+
+func TestLTPDeliversRedPartOverLossyLink(t *testing.T) {
+	sat, ground, link := startLTPPair(t, dtn.LinkEmulatorConfig{
+		Delay:  10 * time.Millisecond,
+		Jitter: 5 * time.Millisecond,
+		Loss:   0.15,
+		Seed:   7,
+	}, nil)
+
+	for i := 0; i < 3; i++ {
+		b := bundle.NewBundle("ipn:1.1", "ipn:2.1", bytes.Repeat([]byte{byte(i + 1)}, 16*1024))
+		if err := sat.Send(context.Background(), "ground001", b); err != nil {
+			t.Fatalf("bundle %d: %v", i, err)
+		}
+		got := receiveBundle(t, ground)
+		if got.ID != b.ID || !bytes.Equal(got.Payload, b.Payload) || got.IsFragment {
+			t.Fatalf("bundle %d corrupted in transit", i)
+		}
+	}
+
+	if stats := link.Stats(); stats.Dropped == 0 {
+		t.Errorf("expected the emulator to drop segments, stats %+v", stats)
+	}
+}
+
+func TestLTPCheckpointTimerFollowsOWLT(t *testing.T) {
+	var mu sync.Mutex
+	dropped := false
+	sat, ground, _ := startLTPPair(t, dtn.LinkEmulatorConfig{
+		Delay: 10 * time.Millisecond,
+		Drop: func(data []byte) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if !dropped && isLTPCheckpoint(data) {
+				dropped = true
+				return true
+			}
+			return false
+		},
+	}, nil)
+
+	// A longer light time stretches the retransmission timer to
+	// 2*100ms + 20ms margin
+	sat.SetOWLT("ground001", 100*time.Millisecond)
+
+	b := bundle.NewBundle("ipn:1.1", "ipn:2.1", make([]byte, 2000))
+	start := time.Now()
+	if err := sat.Send(context.Background(), "ground001", b); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if elapsed < 220*time.Millisecond {
+		t.Errorf("checkpoint retransmitted after %v, before the OWLT-based timeout", elapsed)
+	}
+	if got := receiveBundle(t, ground); !bytes.Equal(got.Payload, b.Payload) {
+		t.Error("payload mismatch after checkpoint retransmission")
+	}
+}
+
+func TestLTPGreenPartIsBestEffort(t *testing.T) {
+	var mu sync.Mutex
+	green := 0
+	sat, ground, _ := startLTPPair(t, dtn.LinkEmulatorConfig{
+		Delay: 5 * time.Millisecond,
+		Drop: func(data []byte) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if ltpSegmentType(data) == 4 {
+				green++
+				return green == 2 // lose the second green segment
+			}
+			return false
+		},
+	}, func(c *dtn.LTPConfig) { c.GreenBulk = true })
+
+	payload := fragmentPayload(4000)
+	bulk, _ := bundle.NewPriorityBundle("ipn:1.1", "ipn:2.1", payload, bundle.PriorityBulk)
+	if err := sat.Send(context.Background(), "ground001", bulk); err != nil {
+		t.Fatalf("green data should not need acknowledgement: %v", err)
+	}
+
+	// Only the payload prefix before the lost segment arrives
+	got := receiveBundle(t, ground)
+	if !got.IsFragment || got.FragmentOffset != 0 || got.TotalADULength != uint64(len(payload)) {
+		t.Fatalf("expected a leading fragment, got %+v", got)
+	}
+	if len(got.Payload) == 0 || len(got.Payload) >= len(payload) || !bytes.Equal(got.Payload, payload[:len(got.Payload)]) {
+		t.Errorf("fragment carries %d bytes that do not match the payload prefix", len(got.Payload))
+	}
+
+	// Other priorities stay red and arrive whole
+	normal := bundle.NewBundle("ipn:1.1", "ipn:2.1", payload)
+	if err := sat.Send(context.Background(), "ground001", normal); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveBundle(t, ground); got.IsFragment || !bytes.Equal(got.Payload, payload) {
+		t.Error("red bundle should be delivered intact")
+	}
+}
+
+func TestLTPCancelsAfterRetransmissionLimit(t *testing.T) {
+	sat, _, _ := startLTPPair(t, dtn.LinkEmulatorConfig{
+		Delay: 5 * time.Millisecond,
+		Drop:  func(data []byte) bool { return ltpSegmentType(data) == 8 }, // every report segment
+	}, func(c *dtn.LTPConfig) { c.MaxRetransmissions = 2 })
+
+	b := bundle.NewBundle("ipn:1.1", "ipn:2.1", []byte("never acknowledged"))
+	err := sat.Send(context.Background(), "ground001", b)
+	if !errors.Is(err, dtn.ErrLTPCancelled) {
+		t.Fatalf("expected ErrLTPCancelled, got %v", err)
+	}
+	var partial *dtn.PartialTransferError
+	if errors.As(err, &partial) {
+		t.Errorf("nothing was acknowledged, got %v", partial)
+	}
+
+	if err := sat.Send(context.Background(), "unknown", b); err == nil {
+		t.Error("send to an unregistered span should fail")
+	}
+}
+
+func TestLTPCancelledSessionYieldsReactiveFragment(t *testing.T) {
+	var mu sync.Mutex
+	data, cutOff := 0, false
+	sat, ground, _ := startLTPPair(t, dtn.LinkEmulatorConfig{
+		Delay: 5 * time.Millisecond,
+		Drop: func(seg []byte) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if ltpSegmentType(seg) > 3 {
+				return false
+			}
+			// Lose the third segment, then every retransmission
+			data++
+			if cutOff {
+				return true
+			}
+			if isLTPCheckpoint(seg) {
+				cutOff = true
+			}
+			return data == 3
+		},
+	}, func(c *dtn.LTPConfig) { c.MaxRetransmissions = 2 })
+
+	payload := fragmentPayload(4000)
+	b := bundle.NewBundle("ipn:1.1", "ipn:2.1", payload)
+	err := sat.Send(context.Background(), "ground001", b)
+
+	var partial *dtn.PartialTransferError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected a partial transfer, got %v", err)
+	}
+	if partial.Acked != 1024 || partial.NeighborID != "ground001" {
+		t.Errorf("acked %d bytes to %s, want the 1024 bytes before the gap", partial.Acked, partial.NeighborID)
+	}
+
+	got := receiveBundle(t, ground)
+	if !got.IsFragment || uint64(len(got.Payload)) != partial.DeliveredPayload() {
+		t.Errorf("receiver recovered %d payload bytes, sender reports %d", len(got.Payload), partial.DeliveredPayload())
+	}
+	if !bytes.Equal(got.Payload, payload[:len(got.Payload)]) {
+		t.Error("recovered fragment does not match the payload prefix")
+	}
+}
+
+func TestLTPRejectsWrappingDataOffset(t *testing.T) {
+	cfg := dtn.DefaultLTPConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	ground := dtn.NewLTPTransport("ipn:2.0", cfg)
+	if err := ground.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start LTP transport: %v", err)
+	}
+	defer ground.Stop()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Red data segment from engine 5, session 1, client 1 with offset
+	// 2^64-1 and two data bytes: offset+length wraps around to 1
+	segment := []byte{0x00, 0x05, 0x01, 0x00, 0x01}
+	segment = append(segment, 0x81, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+	segment = append(segment, 0x02, 'h', 'i')
+	if _, err := conn.WriteTo(segment, ground.Addr()); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1500)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("expected the session to be cancelled: %v", err)
+	}
+	if typ := ltpSegmentType(buf[:n]); typ != 14 {
+		t.Errorf("got segment type %d, want a cancel from the receiver", typ)
+	}
+}
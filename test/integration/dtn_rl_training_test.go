@@ -0,0 +1,203 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+// deadEndScenario offers the source a fast relay that never reaches the
+// destination and a slow one that does. Untrained, the router prefers the
+// fast relay and every bundle expires there.
+func deadEndScenario() dtn.Scenario {
+	at := func(d time.Duration) time.Time { return emulatorEpoch.Add(d) }
+	return dtn.Scenario{
+		Name:     "dead-end",
+		Start:    emulatorEpoch,
+		Duration: 2 * time.Hour,
+		Nodes: []dtn.ScenarioNode{
+			{ID: "src", EID: "ipn:1.0"},
+			{ID: "r1", EID: "ipn:2.0"},
+			{ID: "r2", EID: "ipn:3.0"},
+			{ID: "dst", EID: "ipn:4.0"},
+		},
+		Contacts: []dtn.Contact{
+			{From: "src", To: "r1", Start: at(0), End: at(2 * time.Hour), Rate: 1_000_000},
+			{From: "src", To: "r2", Start: at(0), End: at(2 * time.Hour), Rate: 100_000},
+			{From: "r2", To: "dst", Start: at(0), End: at(2 * time.Hour), Rate: 100_000},
+		},
+		Traffic: []dtn.TrafficFlow{
+			{From: "src", To: "dst", Start: time.Minute, Interval: time.Minute, Count: 20, Size: 500, Priority: 1, Lifetime: 30 * time.Minute},
+		},
+	}
+}
+
+func TestRLLearnerRewardsMoveScores(t *testing.T) {
+	cfg := dtn.DefaultRLLearningConfig()
+	cfg.Exploration = 0
+	learner := dtn.NewRLLearner(dtn.NewRLRoutingModel(), cfg)
+	agent := dtn.NewRLRoutingAgentWithModel("ipn:1.0", dtn.NewRLRoutingModel())
+	agent.SetLearner(learner)
+
+	neighbors := map[string]*dtn.Neighbor{
+		"n1": {ID: "n1", EID: "ipn:2.0", IsActive: true, LinkQuality: 1, Bandwidth: 1_000_000},
+	}
+	route := func(seq uint64) bundle.BundleRef {
+		b, err := bundle.NewPriorityBundle("ipn:1.0", "ipn:9.0", []byte("x"), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.SequenceNumber = seq
+		if _, err := agent.SelectNextHop(context.Background(), b, neighbors); err != nil {
+			t.Fatal(err)
+		}
+		return bundle.RefOf(b)
+	}
+	score := func() float64 {
+		var s float64
+		for _, w := range learner.Model().PriorityWeights["1"] {
+			s += w
+		}
+		return s
+	}
+
+	if !learner.Reward(route(1), dtn.RLDelivered, time.Minute) {
+		t.Fatal("delivery of a routed bundle was not rewarded")
+	}
+	if score() <= 0 {
+		t.Fatalf("delivery did not raise the neighbor's score: %v", learner.Model().PriorityWeights["1"])
+	}
+	if learner.Reward(bundle.BundleRef{SourceEID: "ipn:1.0"}, dtn.RLDelivered, 0) {
+		t.Error("rewarded a bundle the agent never routed")
+	}
+
+	before := score()
+	learner.Reward(route(2), dtn.RLExpired, 0)
+	if score() >= before {
+		t.Errorf("expiry did not lower the neighbor's score: %.3f -> %.3f", before, score())
+	}
+
+	// Deletion reports carry the outcome back to the source
+	before = score()
+	ref := route(3)
+	report := &bundle.StatusReport{Subject: ref, Reason: bundle.ReasonNoTimelyContact}
+	report.Deleted.Asserted = true
+	if !learner.RewardStatusReport(report) || score() >= before {
+		t.Error("deletion status report was not treated as a drop")
+	}
+
+	stats := learner.EndEpisode()
+	if stats.Delivered != 1 || stats.Expired != 1 || stats.Dropped != 1 {
+		t.Errorf("episode stats = %+v", stats)
+	}
+	if learner.Model().Episodes != 1 {
+		t.Errorf("model episodes = %d, want 1", learner.Model().Episodes)
+	}
+}
+
+func TestRLTrainingLearnsToAvoidDeadEnd(t *testing.T) {
+	scenario := deadEndScenario()
+
+	cfg := dtn.DefaultRLTrainingConfig()
+	cfg.Episodes = 15
+	cfg.Learning.Exploration = 0.5
+	cfg.Learning.ExplorationDecay = 0.8
+	cfg.CheckpointDir = t.TempDir()
+	cfg.CheckpointEvery = 5
+
+	model, stats, err := dtn.TrainRLRouter(context.Background(), scenario, nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != cfg.Episodes {
+		t.Fatalf("got %d episode stats, want %d", len(stats), cfg.Episodes)
+	}
+	if stats[0].Expired == 0 {
+		t.Fatalf("untrained router should lose bundles to the dead end: %+v", stats[0])
+	}
+	if model.Episodes != cfg.Episodes {
+		t.Errorf("model records %d episodes, want %d", model.Episodes, cfg.Episodes)
+	}
+
+	// Greedy routing with the trained model avoids the dead end
+	latest, err := dtn.LatestRLCheckpoint(cfg.CheckpointDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenario.Routers = []dtn.EmulatedRouter{dtn.EmulateRL}
+	scenario.RLModel = latest
+	emulator, err := dtn.NewEmulator(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := emulator.Run(context.Background(), dtn.EmulateRL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.DeliveryRatio != 1 {
+		t.Errorf("trained model delivered %.2f of bundles (first episode %.2f)", report.DeliveryRatio, stats[0].DeliveryRatio)
+	}
+}
+
+func TestRLCheckpointVersioning(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := dtn.LatestRLCheckpoint(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("empty directory: err = %v", err)
+	}
+
+	initial := dtn.NewRLRoutingModel()
+	initial.Version = 3
+	learner := dtn.NewRLLearner(initial, dtn.DefaultRLLearningConfig())
+
+	first, err := learner.Checkpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := learner.Checkpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(first) != "rl_router_v0004.json" || filepath.Base(second) != "rl_router_v0005.json" {
+		t.Fatalf("checkpoints %s, %s", first, second)
+	}
+
+	// A second learner resuming from an older model does not overwrite them
+	other := dtn.NewRLLearner(dtn.NewRLRoutingModel(), dtn.DefaultRLLearningConfig())
+	third, err := other.Checkpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(third) != "rl_router_v0006.json" {
+		t.Errorf("third checkpoint %s", third)
+	}
+
+	latest, err := dtn.LatestRLCheckpoint(dir)
+	if err != nil || latest != third {
+		t.Fatalf("latest = %s, %v", latest, err)
+	}
+	model, err := dtn.LoadRLRoutingModel(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Version != 5 || model.ParentVersion != 4 || model.TrainedAt == "" {
+		t.Errorf("checkpoint metadata: version %d parent %d trained %q", model.Version, model.ParentVersion, model.TrainedAt)
+	}
+}
+
+func TestRLModelRejectsUnknownFeatures(t *testing.T) {
+	model := dtn.NewRLRoutingModel()
+	model.FeatureOrder[0] = "queue_depth"
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := dtn.SaveRLRoutingModel(path, model); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dtn.LoadRLRoutingModel(path); err == nil {
+		t.Error("loaded a model using a feature the router does not compute")
+	}
+}
@@ -0,0 +1,378 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/orbital/od"
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/internal/platform/satellite"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+var schedulerEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// window builds a predicted contact minutes after schedulerEpoch.
+func window(satID int, station string, from, to int, startAz, endAz float64) dtn.PredictedContact {
+	start := schedulerEpoch.Add(time.Duration(from) * time.Minute)
+	end := schedulerEpoch.Add(time.Duration(to) * time.Minute)
+	return dtn.PredictedContact{
+		SatelliteID:   satID,
+		SatelliteName: "sat",
+		GroundStation: station,
+		StartTime:     start,
+		EndTime:       end,
+		DurationSec:   int(end.Sub(start).Seconds()),
+		MaxElevation:  45,
+		Quality:       0.65,
+		StartAzimuth:  startAz,
+		EndAzimuth:    endAz,
+	}
+}
+
+var schedulerSatellites = []dtn.SatelliteNode{
+	{NoradID: 1, Name: "one", EID: "dtn://one/main"},
+	{NoradID: 2, Name: "two", EID: "dtn://two/main"},
+}
+
+func TestPassSchedulerWeighsBacklogByPriority(t *testing.T) {
+	stations := []dtn.GroundStation{{ID: "gs_a", Name: "Alpha"}}
+	predicted := []dtn.PredictedContact{
+		window(1, "Alpha", 0, 10, 0, 90),
+		window(2, "Alpha", 1, 11, 0, 90),
+	}
+	cfg := dtn.DefaultPassSchedulerConfig()
+
+	bulk := dtn.Backlog{Bytes: [3]int64{100 << 20, 0, 0}}
+	expedited := dtn.Backlog{Bytes: [3]int64{0, 0, 10 << 20}}
+	plan := dtn.SchedulePasses(predicted, stations, schedulerSatellites, map[int]dtn.Backlog{1: bulk, 2: expedited}, schedulerEpoch, cfg)
+	if len(plan.Passes) != 1 || plan.Passes[0].SatelliteID != 2 {
+		t.Fatalf("plan = %+v, want only satellite 2 with expedited data", plan.Passes)
+	}
+	if plan.Unscheduled != 1 {
+		t.Errorf("unscheduled = %d, want 1", plan.Unscheduled)
+	}
+	if got := plan.Passes[0].PlannedBytes[bundle.PriorityExpedited]; got != 10<<20 {
+		t.Errorf("planned expedited bytes = %d", got)
+	}
+
+	// The same volume at bulk priority loses to the larger backlog
+	smallBulk := dtn.Backlog{Bytes: [3]int64{10 << 20, 0, 0}}
+	plan = dtn.SchedulePasses(predicted, stations, schedulerSatellites, map[int]dtn.Backlog{1: bulk, 2: smallBulk}, schedulerEpoch, cfg)
+	if len(plan.Passes) != 1 || plan.Passes[0].SatelliteID != 1 {
+		t.Fatalf("plan = %+v, want only satellite 1 with the larger backlog", plan.Passes)
+	}
+
+	// Without any backlog the antenna still serves the earlier pass
+	plan = dtn.SchedulePasses(predicted, stations, schedulerSatellites, nil, schedulerEpoch, cfg)
+	if len(plan.Passes) != 1 || plan.Passes[0].SatelliteID != 1 {
+		t.Fatalf("idle plan = %+v, want satellite 1", plan.Passes)
+	}
+}
+
+func TestPassSchedulerSlewAndConcurrency(t *testing.T) {
+	cfg := dtn.DefaultPassSchedulerConfig()
+
+	// Back-to-back passes 180° apart: the second loses the slew time
+	stations := []dtn.GroundStation{{ID: "gs_a", Name: "Alpha"}}
+	predicted := []dtn.PredictedContact{
+		window(1, "Alpha", 0, 10, 90, 0),
+		window(2, "Alpha", 10, 20, 180, 270),
+	}
+	plan := dtn.SchedulePasses(predicted, stations, schedulerSatellites, nil, schedulerEpoch, cfg)
+	if len(plan.Passes) != 2 {
+		t.Fatalf("scheduled %d passes, want 2", len(plan.Passes))
+	}
+	slew := cfg.SettleTime + 90*time.Second // 180° at 2°/s
+	if want := schedulerEpoch.Add(10*time.Minute + slew); !plan.Passes[1].Start.Equal(want) {
+		t.Errorf("second pass starts %s, want %s after slewing", plan.Passes[1].Start, want)
+	}
+	if !plan.Passes[1].AOS.Equal(schedulerEpoch.Add(10 * time.Minute)) {
+		t.Errorf("second pass AOS = %s", plan.Passes[1].AOS)
+	}
+	if plan.Passes[0].Start.After(plan.Passes[1].Start) {
+		t.Error("plan is not ordered by start time")
+	}
+
+	// A faster antenna needs less time
+	stations[0].SlewRate = 6
+	plan = dtn.SchedulePasses(predicted, stations, schedulerSatellites, nil, schedulerEpoch, cfg)
+	if want := schedulerEpoch.Add(10*time.Minute + cfg.SettleTime + 30*time.Second); !plan.Passes[1].Start.Equal(want) {
+		t.Errorf("second pass starts %s with a 6°/s antenna, want %s", plan.Passes[1].Start, want)
+	}
+
+	// Two antennas serve overlapping passes at once
+	stations = []dtn.GroundStation{{ID: "gs_a", Name: "Alpha", Antennas: 2}}
+	predicted = []dtn.PredictedContact{
+		window(1, "Alpha", 0, 10, 0, 90),
+		window(2, "Alpha", 2, 12, 0, 90),
+	}
+	plan = dtn.SchedulePasses(predicted, stations, schedulerSatellites, nil, schedulerEpoch, cfg)
+	if len(plan.Passes) != 2 || plan.Passes[0].Antenna == plan.Passes[1].Antenna {
+		t.Fatalf("plan = %+v, want both passes on separate antennas", plan.Passes)
+	}
+
+	// A satellite is served by one station at a time
+	stations = []dtn.GroundStation{{ID: "gs_a", Name: "Alpha"}, {ID: "gs_b", Name: "Bravo"}}
+	predicted = []dtn.PredictedContact{
+		window(1, "Alpha", 0, 10, 0, 90),
+		window(1, "Bravo", 5, 15, 0, 90),
+	}
+	plan = dtn.SchedulePasses(predicted, stations, schedulerSatellites, nil, schedulerEpoch, cfg)
+	if len(plan.Passes) != 1 {
+		t.Fatalf("scheduled %d overlapping passes of one satellite, want 1", len(plan.Passes))
+	}
+}
+
+func TestPassSchedulerPushesPlanToRouters(t *testing.T) {
+	tles, err := satellite.ReadTLEs(strings.NewReader(passCatalog))
+	if err != nil {
+		t.Fatalf("ReadTLEs: %v", err)
+	}
+	cfg := dtn.DefaultContactPredictorConfig()
+	cfg.Satellites = cfg.Satellites[:1] // ISS
+	cfg.GroundStations[0].Antennas = 2
+	predictor := dtn.NewContactPredictor(cfg)
+	if err := predictor.LoadTLE(satellite.NoradISS, tles[0]); err != nil {
+		t.Fatalf("LoadTLE: %v", err)
+	}
+
+	ctx := context.Background()
+	storage := dtn.NewInMemoryStorage(100)
+	var queued int64
+	for i, priority := range []uint8{bundle.PriorityBulk, bundle.PriorityNormal, bundle.PriorityExpedited} {
+		b, err := bundle.NewPriorityBundle("dtn://iss/main", "dtn://earth/gs_nyc", make([]byte, 1000*(i+1)), priority)
+		if err != nil {
+			t.Fatalf("NewPriorityBundle: %v", err)
+		}
+		if err := storage.Store(ctx, b); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+		queued += int64(b.Size())
+	}
+	backlog, err := dtn.MeasureBacklog(ctx, storage)
+	if err != nil {
+		t.Fatalf("MeasureBacklog: %v", err)
+	}
+	if backlog.Bundles != 3 || backlog.Total() != queued {
+		t.Fatalf("backlog = %+v, want 3 bundles of %d bytes", backlog, queued)
+	}
+
+	schedCfg := dtn.DefaultPassSchedulerConfig()
+	schedCfg.Horizon = 24 * time.Hour
+	scheduler := dtn.NewPassScheduler(predictor, schedCfg)
+	scheduler.AddBacklogSource(satellite.NoradISS, storage)
+	router := dtn.NewContactGraphRouter("dtn://earth/gs_nyc")
+	scheduler.AddRouter(router)
+
+	if scheduler.Plan() != nil {
+		t.Fatal("plan available before scheduling")
+	}
+	start := time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)
+	plan, err := scheduler.Schedule(ctx, start)
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if scheduler.Plan() != plan {
+		t.Error("Plan does not return the latest plan")
+	}
+	if len(plan.Passes) < 4 {
+		t.Fatalf("scheduled %d ISS passes in a day over three stations, want at least 4", len(plan.Passes))
+	}
+	if plan.Backlog[satellite.NoradISS].Total() != queued {
+		t.Errorf("plan backlog = %+v", plan.Backlog)
+	}
+
+	var planned int64
+	for i, pass := range plan.Passes {
+		if pass.Start.Before(pass.AOS) || pass.End.After(pass.LOS) || !pass.Start.Before(pass.End) {
+			t.Errorf("pass %d: link %s-%s outside visibility %s-%s", i, pass.Start, pass.End, pass.AOS, pass.LOS)
+		}
+		if i > 0 && pass.Start.Before(plan.Passes[i-1].Start) {
+			t.Errorf("pass %d starts before pass %d", i, i-1)
+		}
+		if i > 0 && pass.Start.Before(plan.Passes[i-1].End) {
+			t.Errorf("pass %d overlaps the previous ISS pass", i)
+		}
+		for _, n := range pass.PlannedBytes {
+			planned += n
+		}
+	}
+	if planned != queued {
+		t.Errorf("planned %d bytes, want the whole %d byte backlog", planned, queued)
+	}
+	if first := plan.Passes[0].PlannedBytes; first[bundle.PriorityExpedited] == 0 {
+		t.Errorf("first pass plans %v, want the expedited bundle drained first", first)
+	}
+
+	contacts := router.ContactPlan()
+	if len(contacts) != 2*len(plan.Passes) {
+		t.Fatalf("router holds %d contacts, want %d", len(contacts), 2*len(plan.Passes))
+	}
+	if routes := router.Routes("dtn://iss/main", start); len(routes) == 0 {
+		t.Error("router found no route to the ISS through the pass plan")
+	}
+}
+
+func TestPassPlanClientReportsBacklogAndLoadsPlan(t *testing.T) {
+	tles, err := satellite.ReadTLEs(strings.NewReader(passCatalog))
+	if err != nil {
+		t.Fatalf("ReadTLEs: %v", err)
+	}
+	cfg := dtn.DefaultContactPredictorConfig()
+	cfg.Satellites = cfg.Satellites[:1] // ISS
+	predictor := dtn.NewContactPredictor(cfg)
+	if err := predictor.LoadTLE(satellite.NoradISS, tles[0]); err != nil {
+		t.Fatalf("LoadTLE: %v", err)
+	}
+	schedCfg := dtn.DefaultPassSchedulerConfig()
+	schedCfg.Horizon = 24 * time.Hour
+	scheduler := dtn.NewPassScheduler(predictor, schedCfg)
+	start := time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)
+
+	// Stand-in for the Nysus pass plan and backlog endpoints
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/dtn/passplan", func(w http.ResponseWriter, r *http.Request) {
+		plan := scheduler.Plan()
+		if plan == nil {
+			http.Error(w, "no plan", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(plan)
+	})
+	mux.HandleFunc("/api/dtn/backlog", func(w http.ResponseWriter, r *http.Request) {
+		var report dtn.BacklogReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		scheduler.ReportBacklog(report.NoradID, report.Backlog)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	storage := dtn.NewInMemoryStorage(100)
+	b, err := bundle.NewPriorityBundle("dtn://iss/main", "dtn://earth/gs_nyc", make([]byte, 5000), bundle.PriorityExpedited)
+	if err != nil {
+		t.Fatalf("NewPriorityBundle: %v", err)
+	}
+	if err := storage.Store(ctx, b); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	router := dtn.NewContactGraphRouter("dtn://iss/main")
+	clientCfg := dtn.DefaultPassPlanClientConfig(server.URL + "/")
+	clientCfg.NoradID = satellite.NoradISS
+	client := dtn.NewPassPlanClient(clientCfg, router, storage)
+	static := []dtn.Contact{{From: "ipn:1.0", To: "ipn:2.0", Start: start, End: start.Add(time.Hour), Rate: 1000}}
+	client.SetStaticContacts(static)
+
+	// No plan yet, but the backlog is reported
+	if _, err := client.Sync(ctx); err == nil {
+		t.Fatal("sync succeeded before a plan was available")
+	}
+
+	plan, err := scheduler.Schedule(ctx, start)
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if got := plan.Backlog[satellite.NoradISS]; got.Total() != int64(b.Size()) || got.Bundles != 1 {
+		t.Errorf("plan backlog = %+v, want the reported %d byte bundle", got, b.Size())
+	}
+
+	fetched, err := client.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(fetched.Passes) != len(plan.Passes) || len(plan.Passes) == 0 {
+		t.Fatalf("fetched %d passes, want %d", len(fetched.Passes), len(plan.Passes))
+	}
+	if contacts := router.ContactPlan(); len(contacts) != len(static)+2*len(plan.Passes) {
+		t.Errorf("router holds %d contacts, want %d", len(contacts), len(static)+2*len(plan.Passes))
+	}
+}
+
+func TestOrbitDeterminationRefinesPassPlan(t *testing.T) {
+	tles, err := satellite.ReadTLEs(strings.NewReader(passCatalog))
+	if err != nil {
+		t.Fatalf("ReadTLEs: %v", err)
+	}
+	cfg := dtn.DefaultContactPredictorConfig()
+	cfg.Satellites = cfg.Satellites[:1] // ISS
+	schedCfg := dtn.DefaultPassSchedulerConfig()
+	schedCfg.Horizon = 24 * time.Hour
+	scheduler := dtn.NewPassScheduler(dtn.NewContactPredictor(cfg), schedCfg)
+	start := time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	plan, err := scheduler.Schedule(ctx, start)
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if len(plan.Passes) != 0 {
+		t.Fatalf("planned %d passes without an orbit", len(plan.Passes))
+	}
+
+	// Stand-in for the Nysus orbit endpoint
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/dtn/orbit", func(w http.ResponseWriter, r *http.Request) {
+		var payload bytes.Buffer
+		payload.ReadFrom(r.Body)
+		tle, err := dtn.ParseOrbitDetermination(payload.Bytes())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := scheduler.LoadTLE(tle.SatelliteID, tle); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := dtn.NewPassPlanClient(dtn.DefaultPassPlanClientConfig(server.URL), dtn.NewContactGraphRouter("dtn://earth/nysus"), dtn.NewInMemoryStorage(10))
+
+	// Other telemetry is not an orbit update
+	telemetry := []byte(`{"type":"telemetry","satellite_id":"sat001","battery_percent":80}`)
+	if dtn.IsOrbitDetermination(telemetry) {
+		t.Error("regular telemetry detected as an orbit determination")
+	}
+	if _, err := dtn.ParseOrbitDetermination(telemetry); !errors.Is(err, dtn.ErrNotOrbitDetermination) {
+		t.Errorf("expected ErrNotOrbitDetermination, got %v", err)
+	}
+	if err := client.ReportOrbit(ctx, telemetry); err == nil {
+		t.Error("the orbit endpoint accepted regular telemetry")
+	}
+
+	// The payload Silenus downlinks after each fit
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":         dtn.OrbitDeterminationType,
+		"satellite_id": "iss",
+		"timestamp":    start.Format(time.RFC3339Nano),
+		"estimate":     od.Estimate{Elements: &od.FittedElements{TLE: tles[0]}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dtn.IsOrbitDetermination(payload) {
+		t.Fatal("orbit determination payload not recognised")
+	}
+	if err := client.ReportOrbit(ctx, payload); err != nil {
+		t.Fatalf("ReportOrbit: %v", err)
+	}
+
+	plan, err = scheduler.Schedule(ctx, start)
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if len(plan.Passes) == 0 {
+		t.Error("the downlinked orbit did not produce any passes")
+	}
+}
@@ -0,0 +1,400 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+func startTCPCL(t *testing.T, nodeEID string, mutate func(*dtn.TCPCLConfig)) *dtn.TCPCLTransport {
+	t.Helper()
+	cfg := dtn.DefaultTCPCLConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	cfg.ConnectTimeout = 5 * time.Second
+	cfg.TransferTimeout = 5 * time.Second
+	if mutate != nil {
+		mutate(&cfg)
+	}
+
+	tr := dtn.NewTCPCLTransport(nodeEID, cfg)
+	if err := tr.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start TCPCL transport: %v", err)
+	}
+	t.Cleanup(func() { tr.Stop() })
+	return tr
+}
+
+func receiveBundle(t *testing.T, tr dtn.TransportAdapter) *bundle.Bundle {
+	t.Helper()
+	select {
+	case b := <-tr.Receive():
+		return b
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for bundle")
+	}
+	return nil
+}
+
+func TestTCPCLSegmentedTransferAndPeerIdentity(t *testing.T) {
+	var mu sync.Mutex
+	var peers []dtn.PeerInfo
+
+	server := startTCPCL(t, "dtn://earth/ground001", func(c *dtn.TCPCLConfig) {
+		c.KeepaliveInterval = time.Second
+	})
+	server.SetPeerHandler(func(p dtn.PeerInfo) {
+		mu.Lock()
+		peers = append(peers, p)
+		mu.Unlock()
+	})
+
+	client := startTCPCL(t, "dtn://leo/sat001", func(c *dtn.TCPCLConfig) {
+		c.MaxSegmentSize = 1000
+	})
+
+	ctx := context.Background()
+	if err := client.Connect(ctx, "ground001", server.Addr().String()); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	if !client.IsConnected("ground001") {
+		t.Fatal("client should report an active session")
+	}
+
+	payload := bytes.Repeat([]byte("imagery"), 2000) // ~14KB, many segments
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", payload)
+	if err := client.Send(ctx, "ground001", b); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	got := receiveBundle(t, server)
+	if got.ID != b.ID || !bytes.Equal(got.Payload, payload) {
+		t.Error("received bundle does not match sent bundle")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(peers) == 0 {
+		t.Fatal("server should report the inbound peer")
+	}
+	if peers[0].NodeEID != "dtn://leo/sat001" || !peers[0].Incoming || !peers[0].Connected {
+		t.Errorf("unexpected peer info: %+v", peers[0])
+	}
+	if !server.IsConnected("dtn://leo/sat001") {
+		t.Error("inbound session should be keyed by the peer node ID")
+	}
+}
+
+func TestTCPCLBindPeerNamesInboundSession(t *testing.T) {
+	server := startTCPCL(t, "dtn://earth/ground001", nil)
+	server.BindPeer("dtn://leo/sat001", "sat-1")
+	client := startTCPCL(t, "dtn://leo/sat001", nil)
+
+	if err := client.Connect(context.Background(), "ground001", server.Addr().String()); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !server.IsConnected("sat-1") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !server.IsConnected("sat-1") {
+		t.Fatal("inbound session should use the bound neighbor ID")
+	}
+
+	// Bundles flow back over the inbound session.
+	b := bundle.NewBundle("dtn://earth/ground001", "dtn://leo/sat001", []byte("ack"))
+	if err := server.Send(context.Background(), "sat-1", b); err != nil {
+		t.Fatalf("reverse send failed: %v", err)
+	}
+	if got := receiveBundle(t, client); got.ID != b.ID {
+		t.Error("reverse bundle mismatch")
+	}
+}
+
+func TestTCPCLTransferRefused(t *testing.T) {
+	server := startTCPCL(t, "dtn://earth/ground001", func(c *dtn.TCPCLConfig) {
+		c.TransferMRU = 512
+	})
+	client := startTCPCL(t, "dtn://leo/sat001", nil)
+
+	if err := client.Connect(context.Background(), "ground001", server.Addr().String()); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", make([]byte, 4096))
+	err := client.Send(context.Background(), "ground001", b)
+	if err == nil {
+		t.Fatal("expected oversized bundle to be rejected")
+	}
+
+	// The session survives a refused transfer.
+	small := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("ok"))
+	if err := client.Send(context.Background(), "ground001", small); err != nil {
+		t.Fatalf("small send failed after refusal: %v", err)
+	}
+	receiveBundle(t, server)
+}
+
+// rawTCPCLSession dials a TCPCL transport and completes the session
+// handshake by hand, so tests can send arbitrary transfer segments.
+func rawTCPCLSession(t *testing.T, addr, nodeID string) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	conn.Write([]byte{'d', 't', 'n', '!', 4, 0})
+	var hdr [6]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		t.Fatalf("read contact header: %v", err)
+	}
+
+	var init bytes.Buffer
+	init.WriteByte(0x07)
+	binary.Write(&init, binary.BigEndian, uint16(0))
+	binary.Write(&init, binary.BigEndian, uint64(1<<20))
+	binary.Write(&init, binary.BigEndian, uint64(1<<20))
+	binary.Write(&init, binary.BigEndian, uint16(len(nodeID)))
+	init.WriteString(nodeID)
+	binary.Write(&init, binary.BigEndian, uint32(0))
+	conn.Write(init.Bytes())
+
+	var peer struct {
+		Type        uint8
+		Keepalive   uint16
+		SegmentMRU  uint64
+		TransferMRU uint64
+		NodeIDLen   uint16
+	}
+	if err := binary.Read(conn, binary.BigEndian, &peer); err != nil || peer.Type != 0x07 {
+		t.Fatalf("read SESS_INIT: type 0x%02x, %v", peer.Type, err)
+	}
+	io.CopyN(io.Discard, conn, int64(peer.NodeIDLen)+4)
+	return conn
+}
+
+// writeRawSegment sends an XFER_SEGMENT, with the given transfer
+// extension items on start segments.
+func writeRawSegment(conn net.Conn, flags byte, id uint64, exts []byte, data []byte) {
+	var msg bytes.Buffer
+	msg.WriteByte(0x01)
+	msg.WriteByte(flags)
+	binary.Write(&msg, binary.BigEndian, id)
+	if flags&0x02 != 0 {
+		binary.Write(&msg, binary.BigEndian, uint32(len(exts)))
+		msg.Write(exts)
+	}
+	binary.Write(&msg, binary.BigEndian, uint64(len(data)))
+	msg.Write(data)
+	conn.Write(msg.Bytes())
+}
+
+func TestTCPCLRefusalKeepsSessionInSync(t *testing.T) {
+	server := startTCPCL(t, "dtn://earth/ground001", func(c *dtn.TCPCLConfig) {
+		c.MaxInboundXfers = 1
+	})
+	conn := rawTCPCLSession(t, server.Addr().String(), "dtn://leo/sat001")
+
+	readReply := func() (byte, byte, uint64) {
+		t.Helper()
+		var reply struct {
+			Type       uint8
+			Flags      uint8
+			TransferID uint64
+		}
+		if err := binary.Read(conn, binary.BigEndian, &reply); err != nil {
+			t.Fatalf("read reply: %v", err)
+		}
+		if reply.Type == 0x02 {
+			io.CopyN(io.Discard, conn, 8) // acknowledged length
+		}
+		return reply.Type, reply.Flags, reply.TransferID
+	}
+
+	// A critical extension we do not understand, with payload bytes that
+	// would parse as messages if left unread.
+	critical := []byte{0x01, 0x7f, 0xff, 0x00, 0x01, 0x00}
+	junk := bytes.Repeat([]byte{0x07}, 64)
+	writeRawSegment(conn, 0x02, 1, critical, junk)
+	if typ, reason, id := readReply(); typ != 0x03 || reason != byte(dtn.RefuseExtensionFail) || id != 1 {
+		t.Fatalf("got message 0x%02x reason 0x%02x for transfer %d, want extension refusal of 1", typ, reason, id)
+	}
+	// The rest of the refused transfer is dropped without a reply.
+	writeRawSegment(conn, 0x01, 1, nil, junk)
+
+	// Only one transfer may be in progress at a time.
+	writeRawSegment(conn, 0x02, 2, nil, []byte{0x9f})
+	if typ, _, id := readReply(); typ != 0x02 || id != 2 {
+		t.Fatalf("got message 0x%02x for transfer %d, want ack of 2", typ, id)
+	}
+	writeRawSegment(conn, 0x02, 3, nil, junk)
+	if typ, reason, id := readReply(); typ != 0x03 || reason != byte(dtn.RefuseNoResources) || id != 3 {
+		t.Fatalf("got message 0x%02x reason 0x%02x for transfer %d, want no-resources refusal of 3", typ, reason, id)
+	}
+
+	// Finishing transfer 2 delivers its bundle on the same session.
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("in sync"))
+	data, err := bundle.CBOR.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	writeRawSegment(conn, 0x01, 2, nil, data[1:])
+	if typ, _, id := readReply(); typ != 0x02 || id != 2 {
+		t.Fatalf("got message 0x%02x for transfer %d, want final ack of 2", typ, id)
+	}
+	if got := receiveBundle(t, server); string(got.Payload) != "in sync" {
+		t.Errorf("received payload %q", got.Payload)
+	}
+}
+
+func TestTCPCLSessionTermination(t *testing.T) {
+	server := startTCPCL(t, "dtn://earth/ground001", nil)
+	client := startTCPCL(t, "dtn://leo/sat001", nil)
+
+	if err := client.Connect(context.Background(), "ground001", server.Addr().String()); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	if err := client.Disconnect("ground001"); err != nil {
+		t.Fatalf("disconnect failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for server.IsConnected("dtn://leo/sat001") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if server.IsConnected("dtn://leo/sat001") {
+		t.Error("server session should end after SESS_TERM")
+	}
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("late"))
+	if err := client.Send(context.Background(), "ground001", b); err == nil {
+		t.Error("send after disconnect should fail")
+	}
+}
+
+func TestTCPCLRejectsBadContactHeader(t *testing.T) {
+	server := startTCPCL(t, "dtn://earth/ground001", nil)
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte{'d', 't', 'n', '!', 3, 0})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	reply := make([]byte, 9)
+	n, _ := conn.Read(reply)
+	if n < 6 || reply[4] != 4 {
+		t.Fatalf("expected a v4 contact header in reply, got %x", reply[:n])
+	}
+}
+
+func TestTCPCLWithTLS(t *testing.T) {
+	cert := selfSignedCert(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	server := startTCPCL(t, "dtn://earth/ground001", func(c *dtn.TCPCLConfig) {
+		c.TLSConfig = tlsConfig
+		c.RequireTLS = true
+	})
+	client := startTCPCL(t, "dtn://leo/sat001", func(c *dtn.TCPCLConfig) {
+		c.TLSConfig = tlsConfig
+	})
+
+	if err := client.Connect(context.Background(), "ground001", server.Addr().String()); err != nil {
+		t.Fatalf("TLS connect failed: %v", err)
+	}
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("secret"))
+	if err := client.Send(context.Background(), "ground001", b); err != nil {
+		t.Fatalf("send over TLS failed: %v", err)
+	}
+	if got := receiveBundle(t, server); got.ID != b.ID {
+		t.Error("bundle mismatch over TLS")
+	}
+
+	plain := startTCPCL(t, "dtn://leo/sat002", nil)
+	if err := plain.Connect(context.Background(), "ground001", server.Addr().String()); err == nil {
+		t.Error("server requiring TLS should reject a plaintext session")
+	}
+}
+
+func TestNodeRegistersTCPCLPeer(t *testing.T) {
+	cfg := dtn.DefaultTCPCLConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	serverTransport := dtn.NewTCPCLTransport("dtn://earth/ground001", cfg)
+	node := dtn.NewNodeWithTransport("ground001", "dtn://earth/ground001",
+		dtn.NewInMemoryStorage(100), dtn.NewStaticRouter(), serverTransport, dtn.DefaultNodeConfig())
+	if err := node.Start(); err != nil {
+		t.Fatalf("node start failed: %v", err)
+	}
+	t.Cleanup(func() { node.Stop() })
+
+	client := startTCPCL(t, "dtn://leo/sat001", nil)
+	if err := client.Connect(context.Background(), "ground001", serverTransport.Addr().String()); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if n, ok := node.GetNeighbors()["dtn://leo/sat001"]; ok && n.IsActive {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("node should register the TCPCL peer as a neighbor")
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "asgard-dtn-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
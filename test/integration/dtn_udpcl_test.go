@@ -0,0 +1,102 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/pkg/bundle"
+)
+
+func startUDPCL(t *testing.T, nodeEID string, mutate func(*dtn.UDPCLConfig)) *dtn.UDPCLTransport {
+	t.Helper()
+	cfg := dtn.DefaultUDPCLConfig()
+	cfg.ListenAddress = "127.0.0.1:0"
+	if mutate != nil {
+		mutate(&cfg)
+	}
+
+	tr := dtn.NewUDPCLTransport(nodeEID, cfg)
+	if err := tr.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start UDPCL transport: %v", err)
+	}
+	t.Cleanup(func() { tr.Stop() })
+	return tr
+}
+
+func TestUDPCLLoopback(t *testing.T) {
+	ground := startUDPCL(t, "dtn://earth/ground001", nil)
+	sat := startUDPCL(t, "dtn://leo/sat001", nil)
+
+	if err := sat.Connect(context.Background(), "ground001", ground.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if !sat.IsConnected("ground001") {
+		t.Error("neighbor should be registered after Connect")
+	}
+
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("beacon"))
+	if err := sat.Send(context.Background(), "ground001", b); err != nil {
+		t.Fatal(err)
+	}
+	got := receiveBundle(t, ground)
+	if got.ID != b.ID || !bytes.Equal(got.Payload, b.Payload) {
+		t.Errorf("received %+v, want %+v", got, b)
+	}
+
+	if err := sat.Disconnect("ground001"); err != nil || sat.IsConnected("ground001") {
+		t.Errorf("disconnect failed: %v", err)
+	}
+}
+
+func TestUDPCLOverLossyLink(t *testing.T) {
+	link := dtn.NewLinkEmulator(dtn.LinkEmulatorConfig{Delay: 20 * time.Millisecond, Seed: 1})
+	groundConn, _ := link.ListenPacket("")
+	satConn, _ := link.ListenPacket("")
+
+	ground := startUDPCL(t, "dtn://earth/ground001", func(c *dtn.UDPCLConfig) { c.Conn = groundConn })
+	sat := startUDPCL(t, "dtn://leo/sat001", func(c *dtn.UDPCLConfig) {
+		c.Conn = satConn
+		c.MaxDatagramSize = 1200
+	})
+	sat.Connect(context.Background(), "ground001", ground.Addr().String())
+
+	if mtu := sat.LinkMTU("ground001"); mtu != 1200 {
+		t.Errorf("LinkMTU = %d, want 1200", mtu)
+	}
+
+	start := time.Now()
+	b := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("small telemetry"))
+	if err := sat.Send(context.Background(), "ground001", b); err != nil {
+		t.Fatal(err)
+	}
+	got := receiveBundle(t, ground)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("bundle arrived after %v, link delay is 20ms", elapsed)
+	}
+	if !bytes.Equal(got.Payload, b.Payload) {
+		t.Error("payload mismatch")
+	}
+
+	big := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", make([]byte, 2000))
+	if err := sat.Send(context.Background(), "ground001", big); err == nil {
+		t.Error("bundle larger than the datagram limit should be rejected")
+	}
+
+	// UDP gives no delivery guarantee: a lost datagram is simply gone
+	link.SetLoss(1)
+	lost := bundle.NewBundle("dtn://leo/sat001", "dtn://earth/ground001", []byte("lost"))
+	if err := sat.Send(context.Background(), "ground001", lost); err != nil {
+		t.Fatalf("send over a lossy link should not fail: %v", err)
+	}
+	select {
+	case b := <-ground.Receive():
+		t.Errorf("unexpected bundle %s over a fully lossy link", b.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if stats := link.Stats(); stats.Dropped != 1 || stats.Delivered != 1 {
+		t.Errorf("link stats = %+v", stats)
+	}
+}
@@ -0,0 +1,171 @@
+package integration_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+func TestFramesTimeScales(t *testing.T) {
+	cases := []struct {
+		utc  time.Time
+		leap float64
+	}{
+		{time.Date(1972, 6, 30, 23, 59, 59, 0, time.UTC), 10},
+		{time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), 32},
+		{time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC), 36},
+		{time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), 37},
+	}
+	for _, tc := range cases {
+		if got := frames.TAIMinusUTC(tc.utc); got != tc.leap {
+			t.Errorf("TAI-UTC at %s = %.0f, want %.0f", tc.utc, got, tc.leap)
+		}
+		if back := frames.UTCFromTAI(frames.TAI(tc.utc)); !back.Equal(tc.utc) {
+			t.Errorf("UTC %s round-tripped through TAI to %s", tc.utc, back)
+		}
+	}
+
+	utc := time.Date(2004, 4, 6, 7, 51, 28, 386009000, time.UTC)
+	if d := frames.TT(utc).Sub(utc); d != 64184*time.Millisecond {
+		t.Errorf("TT-UTC = %s, want 64.184s", d)
+	}
+	if jd := frames.JulianDate(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)); jd != frames.JulianDateJ2000 {
+		t.Errorf("JD of J2000 = %.6f", jd)
+	}
+}
+
+func TestFramesEarthOrientationModels(t *testing.T) {
+	// SOFA reference values for the IAU 1976/1980/1982 models
+	if got := frames.GMST(2400000.5 + 53736.0); math.Abs(got-1.754174981860675096) > 1e-11 {
+		t.Errorf("GMST = %.15f", got)
+	}
+	if got := frames.MeanObliquity(2400000.5 + 54388.0); math.Abs(got-0.4090751347643816218) > 1e-14 {
+		t.Errorf("mean obliquity = %.16f", got)
+	}
+	p := frames.PrecessionMatrix(2400000.5 + 50123.9999)
+	for i, want := range []float64{0.9999995504328350733, 0.8696632209480960785e-3, 0.3779153474959888345e-3} {
+		if math.Abs(p[0][i]-want) > 1e-12 {
+			t.Errorf("precession[0][%d] = %.16e, want %.16e", i, p[0][i], want)
+		}
+	}
+	// The series is truncated at 1 mas; allow 5 mas against the full model
+	dpsi, deps := frames.Nutation(2400000.5 + 53736.0)
+	if math.Abs(dpsi+0.9643658353226563966e-5) > 2.5e-8 || math.Abs(deps-0.4060051006879713322e-4) > 2.5e-8 {
+		t.Errorf("nutation = %.6e, %.6e", dpsi, deps)
+	}
+}
+
+func TestFramesTEMETransforms(t *testing.T) {
+	// Vallado, Fundamentals of Astrodynamics, example 3-15
+	utc := time.Date(2004, 4, 6, 7, 51, 28, 386009000, time.UTC)
+	eop := frames.EOP{DUT1: -0.4399619, XP: -0.140682, YP: 0.333309, DDPsi: -0.052195, DDEps: -0.003875}
+	rTEME := [3]float64{5094.18016210, 6127.64465950, 6380.34453270}
+	vTEME := [3]float64{-4.746131487, 0.785818041, 5.531931288}
+
+	rITRF, vITRF := frames.TEMEToITRF(rTEME, vTEME, utc, eop)
+	assertVector(t, "ITRF r", rITRF, [3]float64{-1033.4793830, 7901.2952754, 6380.3565958}, 1e-6)
+	assertVector(t, "ITRF v", vITRF, [3]float64{-3.225636520, -2.872451450, 5.531924446}, 1e-7)
+
+	rGCRF, vGCRF := frames.TEMEToGCRF(rTEME, vTEME, utc, eop)
+	assertVector(t, "GCRF r", rGCRF, [3]float64{5102.508958, 6123.011401, 6378.136928}, 1e-3)
+
+	// Both routes to the Earth-fixed frame agree to within the 1994
+	// equation of the equinoxes terms TEME leaves out
+	rViaGCRF, vViaGCRF := frames.GCRFToITRF(rGCRF, vGCRF, utc, eop)
+	assertVector(t, "ITRF r via GCRF", rViaGCRF, rITRF, 1e-3)
+	assertVector(t, "ITRF v via GCRF", vViaGCRF, vITRF, 1e-6)
+
+	rBack, vBack := frames.ITRFToTEME(rITRF, vITRF, utc, eop)
+	assertVector(t, "TEME r round trip", rBack, rTEME, 1e-8)
+	assertVector(t, "TEME v round trip", vBack, vTEME, 1e-11)
+	rBack, vBack = frames.ITRFToGCRF(rViaGCRF, vViaGCRF, utc, eop)
+	assertVector(t, "GCRF r round trip", rBack, rGCRF, 1e-6)
+	assertVector(t, "GCRF v round trip", vBack, vGCRF, 1e-9)
+}
+
+func TestFramesGeodeticAndLookAngles(t *testing.T) {
+	for _, g := range []frames.Geodetic{
+		{Latitude: 0, Longitude: 0, Altitude: 0},
+		{Latitude: 51.4778, Longitude: -0.0015, Altitude: 0.046},
+		{Latitude: -33.9, Longitude: 151.2, Altitude: 35786},
+		{Latitude: 89.999, Longitude: 45, Altitude: 400},
+	} {
+		back := frames.ECEFToGeodetic(frames.GeodeticToECEF(g))
+		if math.Abs(back.Latitude-g.Latitude) > 1e-9 || math.Abs(back.Longitude-g.Longitude) > 1e-9 || math.Abs(back.Altitude-g.Altitude) > 1e-6 {
+			t.Errorf("%+v round-tripped to %+v", g, back)
+		}
+	}
+	if polar := frames.GeodeticToECEF(frames.Geodetic{Latitude: 90}); math.Abs(polar[2]-6356.752314245) > 1e-6 {
+		t.Errorf("polar radius %.6f km", polar[2])
+	}
+
+	site := frames.Geodetic{Latitude: 40.7128, Longitude: -74.0060, Altitude: 0.01}
+	overhead := frames.GeodeticToECEF(frames.Geodetic{Latitude: site.Latitude, Longitude: site.Longitude, Altitude: 500})
+	look := frames.Look(site, overhead, [3]float64{})
+	if look.Elevation < 89.999 || math.Abs(look.Range-499.99) > 1e-6 {
+		t.Errorf("overhead look = %+v", look)
+	}
+
+	// A target due north on the horizon plane, moving away
+	north := frames.GeodeticToECEF(frames.Geodetic{Latitude: site.Latitude + 10, Longitude: site.Longitude, Altitude: 0})
+	up := frames.GeodeticToECEF(site)
+	away := [3]float64{north[0] - up[0], north[1] - up[1], north[2] - up[2]}
+	look = frames.Look(site, north, away)
+	if math.Abs(look.Azimuth) > 1e-6 && math.Abs(look.Azimuth-360) > 1e-6 {
+		t.Errorf("azimuth to a point due north = %.6f", look.Azimuth)
+	}
+	if look.Elevation >= 0 || look.RangeRate <= 0 {
+		t.Errorf("ground point 10° north: elevation %.3f, range rate %.3f", look.Elevation, look.RangeRate)
+	}
+}
+
+func TestFramesSunPosition(t *testing.T) {
+	cases := []struct {
+		utc         time.Time
+		declination float64
+	}{
+		{time.Date(2020, 3, 20, 3, 50, 0, 0, time.UTC), 0},
+		{time.Date(2020, 6, 20, 21, 44, 0, 0, time.UTC), 23.44},
+		{time.Date(2020, 12, 21, 10, 2, 0, 0, time.UTC), -23.44},
+	}
+	for _, tc := range cases {
+		sun := frames.SunPosition(tc.utc)
+		dist := math.Sqrt(sun[0]*sun[0] + sun[1]*sun[1] + sun[2]*sun[2])
+		dec := math.Asin(sun[2]/dist) * 180 / math.Pi
+		if math.Abs(dec-tc.declination) > 0.02 {
+			t.Errorf("solar declination at %s = %.3f°, want %.2f°", tc.utc, dec, tc.declination)
+		}
+		if dist < 0.98*frames.AstronomicalUnit || dist > 1.02*frames.AstronomicalUnit {
+			t.Errorf("Sun distance %.0f km", dist)
+		}
+	}
+
+	// Near local noon at Greenwich on the June solstice the Sun is high
+	// and a satellite on the sunward side is lit; one directly behind the
+	// Earth is in shadow
+	noon := time.Date(2020, 6, 20, 12, 0, 0, 0, time.UTC)
+	if el := frames.SunElevation(frames.Geodetic{Latitude: 51.48}, noon); math.Abs(el-62) > 0.5 {
+		t.Errorf("Sun elevation at Greenwich noon = %.2f°", el)
+	}
+	sun := frames.SunPosition(noon)
+	dist := math.Sqrt(sun[0]*sun[0] + sun[1]*sun[1] + sun[2]*sun[2])
+	behind := [3]float64{-7000 * sun[0] / dist, -7000 * sun[1] / dist, -7000 * sun[2] / dist}
+	if frames.Sunlit(behind, sun) {
+		t.Error("satellite behind the Earth reported sunlit")
+	}
+	if !frames.Sunlit([3]float64{-behind[0], -behind[1], -behind[2]}, sun) {
+		t.Error("satellite on the sunward side reported in shadow")
+	}
+}
+
+func assertVector(t *testing.T, name string, got, want [3]float64, tol float64) {
+	t.Helper()
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > tol {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/orbital/geolocation"
+	"github.com/asgard/pandora/internal/orbital/tracking"
+	"github.com/asgard/pandora/internal/orbital/vision"
+	"github.com/asgard/pandora/internal/platform/frames"
+)
+
+var geoEpoch = time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC)
+
+// nadirPose is a satellite 500 km over lat/lon heading north-east.
+func nadirPose(lat, lon float64) geolocation.Pose {
+	return geolocation.PoseFromGeodetic(geoEpoch, lat, lon, 500, [3]float64{3, 6.5, 0})
+}
+
+// groundDistance returns the distance between two ellipsoid points, meters.
+func groundDistance(a, b geolocation.Point) float64 {
+	ea := frames.GeodeticToECEF(frames.Geodetic{Latitude: a.Latitude, Longitude: a.Longitude, Altitude: a.Altitude / 1000})
+	eb := frames.GeodeticToECEF(frames.Geodetic{Latitude: b.Latitude, Longitude: b.Longitude, Altitude: b.Altitude / 1000})
+	dx, dy, dz := ea[0]-eb[0], ea[1]-eb[1], ea[2]-eb[2]
+	return math.Sqrt(dx*dx+dy*dy+dz*dz) * 1000
+}
+
+func TestGeolocationNadirFootprint(t *testing.T) {
+	camera := geolocation.CameraFromFOV(1000, 1000, 10)
+	projector := geolocation.NewProjector(camera, nil)
+	pose := nadirPose(30, 10)
+
+	box := vision.BoundingBox{X: 450, Y: 450, Width: 100, Height: 100}
+	fp, err := projector.Project(pose, box)
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	nadir := geolocation.Point{Latitude: 30, Longitude: 10}
+	if d := groundDistance(fp.Center, nadir); d > 1 {
+		t.Errorf("centred box lands %.2f m from the sub-satellite point", d)
+	}
+	if fp.OffNadir > 1e-6 || fp.Incidence > 1e-3 {
+		t.Errorf("off nadir %.6f°, incidence %.6f°", fp.OffNadir, fp.Incidence)
+	}
+	if math.Abs(fp.SlantRange-500000) > 1 {
+		t.Errorf("slant range = %.1f m", fp.SlantRange)
+	}
+
+	wantGSD := 500000 * camera.PixelAngle()
+	if math.Abs(fp.GSD-wantGSD)/wantGSD > 0.01 {
+		t.Errorf("GSD = %.2f m, want %.2f m", fp.GSD, wantGSD)
+	}
+	if fp.Uncertainty < fp.GSD || fp.Uncertainty > 500 {
+		t.Errorf("uncertainty = %.1f m", fp.Uncertainty)
+	}
+
+	// A 100 pixel box is about 100 GSD across, drawn counter-clockwise
+	if len(fp.Polygon) != 8 {
+		t.Fatalf("polygon has %d points, want 8", len(fp.Polygon))
+	}
+	var diagonal float64
+	for _, a := range fp.Polygon {
+		for _, b := range fp.Polygon {
+			diagonal = math.Max(diagonal, groundDistance(a, b))
+		}
+	}
+	if want := 100 * math.Sqrt2 * wantGSD; math.Abs(diagonal-want) > 0.02*want {
+		t.Errorf("box diagonal = %.0f m, want %.0f m", diagonal, want)
+	}
+	var area float64
+	for i := range fp.Polygon {
+		a, b := fp.Polygon[i], fp.Polygon[(i+1)%len(fp.Polygon)]
+		area += a.Longitude*b.Latitude - b.Longitude*a.Latitude
+	}
+	if area <= 0 {
+		t.Error("footprint polygon is clockwise")
+	}
+
+	// Lines of sight past the limb miss the Earth
+	wide := geolocation.NewProjector(geolocation.CameraFromFOV(1000, 1000, 170), nil)
+	if _, err := wide.ProjectPixel(pose, 0, 500); !errors.Is(err, geolocation.ErrNoIntersection) {
+		t.Errorf("limb pixel error = %v, want ErrNoIntersection", err)
+	}
+}
+
+func TestGeolocationTerrainRelief(t *testing.T) {
+	camera := geolocation.CameraFromFOV(1000, 1000, 20)
+	pose := nadirPose(-20, 140)
+	flat := geolocation.NewProjector(camera, nil)
+	plateau := geolocation.NewProjector(camera, geolocation.EllipsoidHeight(2000))
+
+	edgeFlat, err := flat.ProjectPixel(pose, 1000, 500)
+	if err != nil {
+		t.Fatalf("ProjectPixel: %v", err)
+	}
+	edgeHigh, err := plateau.ProjectPixel(pose, 1000, 500)
+	if err != nil {
+		t.Fatalf("ProjectPixel: %v", err)
+	}
+	if math.Abs(edgeHigh.Altitude-2000) > 0.5 {
+		t.Errorf("plateau point at %.1f m, want 2000 m", edgeHigh.Altitude)
+	}
+
+	// Raised terrain pulls an off-nadir point towards nadir by h·tan(θ)
+	nadir := geolocation.Point{Latitude: -20, Longitude: 140}
+	shift := groundDistance(nadir, edgeFlat) - groundDistance(nadir, edgeHigh)
+	want := 2000 * math.Tan(10*math.Pi/180)
+	if math.Abs(shift-want) > 0.1*want {
+		t.Errorf("relief displacement %.0f m, want about %.0f m", shift, want)
+	}
+
+	// Terrain height error displaces off-nadir points by σh·tan(incidence)
+	plateau.Errors = geolocation.ErrorBudget{TerrainSigma: 16}
+	fp, err := plateau.Project(pose, vision.BoundingBox{X: 900, Y: 450, Width: 100, Height: 100})
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if want := 16 * math.Tan(fp.Incidence*math.Pi/180); fp.Incidence < 9 || math.Abs(fp.Uncertainty-want) > 1e-6 {
+		t.Errorf("terrain uncertainty %.2f m at %.1f° incidence, want %.2f m", fp.Uncertainty, fp.Incidence, want)
+	}
+}
+
+const testGrid = `ncols 3
+nrows 3
+xllcorner 10.0
+yllcorner 45.0
+cellsize 0.01
+NODATA_value -9999
+100 200 300
+400 500 600
+700 800 -9999
+`
+
+func TestGeolocationASCIIGrid(t *testing.T) {
+	grid, err := geolocation.ReadASCIIGrid(strings.NewReader(testGrid))
+	if err != nil {
+		t.Fatalf("ReadASCIIGrid: %v", err)
+	}
+	if grid.Rows != 3 || grid.Cols != 3 || grid.West != 10 || grid.South != 45 {
+		t.Fatalf("grid header = %+v", grid)
+	}
+
+	cases := []struct {
+		lat, lon, want float64
+	}{
+		{45.015, 10.015, 500},   // Centre cell
+		{45.025, 10.005, 100},   // North-west cell centre
+		{45.02, 10.015, 350},    // Between rows
+		{45.015, 10.01, 450},    // Between columns
+		{45.0275, 10.0025, 100}, // Edge clamps to the outer samples
+	}
+	for _, c := range cases {
+		h, err := grid.Height(c.lat, c.lon)
+		if err != nil || math.Abs(h-c.want) > 1e-6 {
+			t.Errorf("Height(%g, %g) = %g, %v; want %g", c.lat, c.lon, h, err, c.want)
+		}
+	}
+	if _, err := grid.Height(45.005, 10.025); !errors.Is(err, geolocation.ErrNoTerrain) {
+		t.Errorf("no-data corner error = %v", err)
+	}
+	if _, err := grid.Height(46, 10.015); !errors.Is(err, geolocation.ErrNoTerrain) {
+		t.Errorf("outside grid error = %v", err)
+	}
+
+	layered := geolocation.LayeredTerrain{grid, geolocation.EllipsoidHeight(-5)}
+	if h, err := layered.Height(46, 10); err != nil || h != -5 {
+		t.Errorf("layered fallback = %g, %v", h, err)
+	}
+
+	if _, err := geolocation.ReadASCIIGrid(strings.NewReader("ncols 2\nnrows 2\ncellsize 1\n1 2 3\n")); err == nil {
+		t.Error("accepted a short grid")
+	}
+}
+
+func TestGeolocationInertialAttitude(t *testing.T) {
+	// A quarter turn about +Z carries +X onto +Y
+	q := geolocation.Quaternion{W: math.Cos(math.Pi / 4), Z: math.Sin(math.Pi / 4)}
+	if v := q.Matrix().MulVec([3]float64{1, 0, 0}); math.Abs(v[1]-1) > 1e-12 {
+		t.Errorf("rotated +X = %v", v)
+	}
+
+	// An inertial attitude equal to the nadir frame projects like nadir
+	r := [3]float64{0, -4000, 5400}
+	v := [3]float64{7.5, 0, 0}
+	rITRF, vITRF := frames.GCRFToITRF(r, v, geoEpoch, frames.EOP{})
+	nadirITRF := geolocation.NadirAttitude(rITRF, vITRF)
+	bodyToGCRF := frames.GCRFToITRFMatrix(geoEpoch, frames.EOP{}).T().Mul(nadirITRF)
+
+	pose := geolocation.PoseFromInertial(geoEpoch, r, v, matrixQuaternion(bodyToGCRF), frames.EOP{})
+	projector := geolocation.NewProjector(geolocation.CameraFromFOV(640, 480, 8), nil)
+	got, err := projector.ProjectPixel(pose, 320, 240)
+	if err != nil {
+		t.Fatalf("ProjectPixel: %v", err)
+	}
+	sub := frames.ECEFToGeodetic(rITRF)
+	if d := groundDistance(got, geolocation.Point{Latitude: sub.Latitude, Longitude: sub.Longitude}); d > 1 {
+		t.Errorf("boresight lands %.2f m from the sub-satellite point", d)
+	}
+
+	// Image columns run along the ground track
+	ahead, err := projector.ProjectPixel(pose, 640, 240)
+	if err != nil {
+		t.Fatalf("ProjectPixel: %v", err)
+	}
+	a := frames.GeodeticToECEF(frames.Geodetic{Latitude: ahead.Latitude, Longitude: ahead.Longitude})
+	b := frames.GeodeticToECEF(frames.Geodetic{Latitude: got.Latitude, Longitude: got.Longitude})
+	if along := (a[0]-b[0])*vITRF[0] + (a[1]-b[1])*vITRF[1] + (a[2]-b[2])*vITRF[2]; along <= 0 {
+		t.Error("the right image edge does not lie ahead on the ground track")
+	}
+}
+
+// matrixQuaternion converts a rotation matrix to a quaternion.
+func matrixQuaternion(m frames.Matrix) geolocation.Quaternion {
+	w := math.Sqrt(math.Max(0, 1+m[0][0]+m[1][1]+m[2][2])) / 2
+	x := math.Copysign(math.Sqrt(math.Max(0, 1+m[0][0]-m[1][1]-m[2][2]))/2, m[2][1]-m[1][2])
+	y := math.Copysign(math.Sqrt(math.Max(0, 1-m[0][0]+m[1][1]-m[2][2]))/2, m[0][2]-m[2][0])
+	z := math.Copysign(math.Sqrt(math.Max(0, 1-m[0][0]-m[1][1]+m[2][2]))/2, m[1][0]-m[0][1])
+	return geolocation.Quaternion{W: w, X: x, Y: y, Z: z}
+}
+
+func TestGeolocatedAlertsGeoJSON(t *testing.T) {
+	alerts := make(chan tracking.Alert, 4)
+	tracker := tracking.NewTracker(
+		vision.AlertCriteria{MinConfidence: 0.5, AlertClasses: []string{"fire", "ship"}},
+		alerts,
+		nil, // Location comes from the footprint
+		func(context.Context) ([]byte, error) { return []byte("clip"), nil },
+	)
+	projector := geolocation.NewProjector(geolocation.CameraFromFOV(640, 480, 8), nil)
+	tracker.SetGeolocator(func(_ context.Context, det vision.Detection) (*geolocation.Footprint, error) {
+		return projector.Project(nadirPose(0.5, 179.99), det.BoundingBox)
+	})
+
+	detections := []vision.Detection{
+		{Class: "fire", Confidence: 0.9, BoundingBox: vision.BoundingBox{X: 0, Y: 0, Width: 640, Height: 480}},
+		{Class: "ship", Confidence: 0.8, BoundingBox: vision.BoundingBox{X: 300, Y: 200, Width: 20, Height: 10}},
+	}
+	for frame := 0; frame < tracking.DefaultTrackerConfig().ConfirmHits; frame++ {
+		if err := tracker.ProcessDetections(context.Background(), detections); err != nil {
+			t.Fatalf("ProcessDetections: %v", err)
+		}
+	}
+	close(alerts)
+	var batch []tracking.Alert
+	for alert := range alerts {
+		if alert.Footprint == nil {
+			t.Fatalf("%s alert has no footprint", alert.Type)
+		}
+		if !strings.Contains(alert.Location, "lat=0.") {
+			t.Errorf("alert location %q does not come from the footprint", alert.Location)
+		}
+		batch = append(batch, alert)
+	}
+	if len(batch) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(batch))
+	}
+	batch = append(batch, tracking.Alert{Type: "smoke"}) // Not geolocated
+
+	data, err := tracking.ExportGeoJSON(batch)
+	if err != nil {
+		t.Fatalf("ExportGeoJSON: %v", err)
+	}
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			ID       string `json:"id"`
+			Geometry struct {
+				Type        string         `json:"type"`
+				Coordinates [][][3]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("exported %q with %d features", fc.Type, len(fc.Features))
+	}
+	for i, f := range fc.Features {
+		if f.ID != batch[i].ID.String() || f.Properties["type"] != batch[i].Type {
+			t.Errorf("feature %d = %s %v", i, f.ID, f.Properties["type"])
+		}
+		if _, ok := f.Properties["gsd_m"]; !ok {
+			t.Errorf("feature %d has no GSD", i)
+		}
+		ring := f.Geometry.Coordinates[0]
+		if f.Geometry.Type != "Polygon" || len(ring) != 9 || ring[0] != ring[8] {
+			t.Fatalf("feature %d geometry is not a closed ring: %v", i, f.Geometry)
+		}
+		// The full-frame footprint straddles the antimeridian without
+		// wrapping round the globe
+		for j := 1; j < len(ring); j++ {
+			if math.Abs(ring[j][0]-ring[j-1][0]) > 1 {
+				t.Errorf("feature %d ring jumps from %.3f to %.3f", i, ring[j-1][0], ring[j][0])
+			}
+		}
+	}
+	full := fc.Features[0].Geometry.Coordinates[0]
+	east := math.Inf(-1)
+	for _, c := range full {
+		east = math.Max(east, c[0])
+	}
+	if east <= 180 {
+		t.Errorf("full-frame footprint does not cross the antimeridian (east edge %.3f)", east)
+	}
+}
@@ -0,0 +1,359 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asgard/pandora/internal/robotics/control"
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+)
+
+func poseDistance(a, b kinematics.Transform) (float64, float64) {
+	dp, dr := kinematics.PoseError(a, b)
+	return math.Sqrt(dp[0]*dp[0] + dp[1]*dp[1] + dp[2]*dp[2]), math.Sqrt(dr[0]*dr[0] + dr[1]*dr[1] + dr[2]*dr[2])
+}
+
+// randomJoints draws a configuration within the limits, away from the
+// ends of each range.
+func randomJoints(rng *rand.Rand, c *kinematics.Chain) []float64 {
+	q := make([]float64, c.DOF())
+	for i, j := range c.Joints {
+		lo, hi := math.Max(j.Lower, -math.Pi), math.Min(j.Upper, math.Pi)
+		margin := 0.1 * (hi - lo)
+		q[i] = lo + margin + rng.Float64()*(hi-lo-2*margin)
+	}
+	return q
+}
+
+func TestKinematicsZeroPoses(t *testing.T) {
+	cases := []struct {
+		model string
+		want  [3]float64
+	}{
+		// Published flange positions at the zero configuration
+		{"ur5e", [3]float64{-0.8172, -0.2329, 0.0628}},
+		{"franka", [3]float64{0.088, 0, 0.926}},
+		{"kinova-gen3", [3]float64{0, -0.0246, 1.1873}},
+	}
+	for _, tc := range cases {
+		chain, err := kinematics.Model(tc.model)
+		if err != nil {
+			t.Fatalf("Model(%s): %v", tc.model, err)
+		}
+		pose, err := chain.Forward(make([]float64, chain.DOF()))
+		if err != nil {
+			t.Fatalf("%s Forward: %v", tc.model, err)
+		}
+		for k := range tc.want {
+			if math.Abs(pose.P[k]-tc.want[k]) > 1e-3 {
+				t.Errorf("%s zero pose = %.4f, want %.4f", tc.model, pose.P, tc.want)
+				break
+			}
+		}
+	}
+	if _, err := kinematics.Model("scara"); !errors.Is(err, kinematics.ErrUnknownModel) {
+		t.Errorf("unknown model error = %v", err)
+	}
+}
+
+func TestKinematicsURAnalyticInverse(t *testing.T) {
+	rng := rand.New(rand.NewSource(21))
+	for _, model := range []string{"ur3e", "ur5e", "ur10e"} {
+		chain, _ := kinematics.Model(model)
+		for trial := 0; trial < 50; trial++ {
+			q := randomJoints(rng, chain)
+			target, _ := chain.Forward(q)
+			solutions, err := chain.InverseAnalytic(target)
+			if err != nil {
+				t.Fatalf("%s InverseAnalytic(%v): %v", model, q, err)
+			}
+			// Every reachable shoulder and elbow branch comes with both wrists
+			if len(solutions) < 2 || len(solutions)%2 != 0 {
+				t.Errorf("%s: %d solutions for a generic pose, want wrist pairs", model, len(solutions))
+			}
+			for _, s := range solutions {
+				pose, _ := chain.Forward(s)
+				if dp, dr := poseDistance(pose, target); dp > 1e-6 || dr > 1e-6 {
+					t.Fatalf("%s solution %v misses by %.2g m, %.2g rad", model, s, dp, dr)
+				}
+			}
+
+			// The closest solution to the original configuration is itself
+			got, err := chain.Inverse(target, q, kinematics.DefaultIKConfig())
+			if err != nil {
+				t.Fatalf("%s Inverse: %v", model, err)
+			}
+			for i := range q {
+				if math.Abs(got[i]-q[i]) > 1e-6 {
+					t.Fatalf("%s Inverse picked %v, seeded from %v", model, got, q)
+				}
+			}
+		}
+	}
+
+	ur5e := kinematics.UR5e()
+	if _, err := ur5e.InverseAnalytic(kinematics.Translation(2, 0, 0)); !errors.Is(err, kinematics.ErrNoSolution) {
+		t.Errorf("out-of-reach error = %v", err)
+	}
+	if _, err := kinematics.FrankaPanda().InverseAnalytic(kinematics.Identity()); !errors.Is(err, kinematics.ErrNoAnalyticSolver) {
+		t.Errorf("Franka analytic error = %v", err)
+	}
+}
+
+func TestKinematicsNumericalInverse(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	cfg := kinematics.DefaultIKConfig()
+	for _, model := range []string{"franka", "kinova-gen3", "ur5e"} {
+		chain, _ := kinematics.Model(model)
+		home := randomJoints(rng, chain)
+		for trial := 0; trial < 20; trial++ {
+			goal := randomJoints(rng, chain)
+			target, _ := chain.Forward(goal)
+			q, err := chain.InverseNumerical(target, home, cfg)
+			if err != nil {
+				t.Fatalf("%s InverseNumerical: %v", model, err)
+			}
+			if err := chain.CheckLimits(q); err != nil {
+				t.Errorf("%s solution violates limits: %v", model, err)
+			}
+			pose, _ := chain.Forward(q)
+			if dp, dr := poseDistance(pose, target); dp > cfg.PositionTolerance || dr > cfg.OrientationTolerance {
+				t.Errorf("%s solution misses by %.2g m, %.2g rad", model, dp, dr)
+			}
+		}
+	}
+
+	// Position-only targets, as VLA reach actions give
+	franka := kinematics.FrankaPanda()
+	posCfg := cfg
+	posCfg.PositionOnly = true
+	seed := []float64{0, -0.3, 0, -2.2, 0, 2, 0.8}
+	q, err := franka.Inverse(kinematics.Translation(0.5, -0.2, 0.3), seed, posCfg)
+	if err != nil {
+		t.Fatalf("position-only Inverse: %v", err)
+	}
+	if pose, _ := franka.Forward(q); math.Abs(pose.P[0]-0.5) > 1e-4 || math.Abs(pose.P[1]+0.2) > 1e-4 || math.Abs(pose.P[2]-0.3) > 1e-4 {
+		t.Errorf("position-only solution reaches %.4f", pose.P)
+	}
+	if _, err := franka.Inverse(kinematics.Translation(1.5, 0, 0.3), seed, posCfg); !errors.Is(err, kinematics.ErrNoSolution) {
+		t.Errorf("out-of-reach error = %v", err)
+	}
+	if _, err := franka.Inverse(kinematics.Identity(), seed[:3], posCfg); !errors.Is(err, kinematics.ErrJointCount) {
+		t.Errorf("short seed error = %v", err)
+	}
+}
+
+func TestKinematicsJacobian(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, model := range []string{"ur5e", "franka", "kinova-gen3"} {
+		chain, _ := kinematics.Model(model)
+		q := randomJoints(rng, chain)
+		jac, err := chain.Jacobian(q)
+		if err != nil {
+			t.Fatalf("Jacobian: %v", err)
+		}
+		base, _ := chain.Forward(q)
+		const h = 1e-6
+		for i := range q {
+			dq := append([]float64(nil), q...)
+			dq[i] += h
+			moved, _ := chain.Forward(dq)
+			dp, dr := kinematics.PoseError(base, moved)
+			for k := 0; k < 3; k++ {
+				if math.Abs(dp[k]/h-jac[k][i]) > 1e-4 || math.Abs(dr[k]/h-jac[k+3][i]) > 1e-4 {
+					t.Fatalf("%s Jacobian column %d differs from finite differences", model, i)
+				}
+			}
+		}
+	}
+}
+
+func TestKinematicsSingularities(t *testing.T) {
+	ur5e := kinematics.UR5e()
+	regular := []float64{0.3, -1.2, 1.5, -1.9, -1.57, 0.4}
+	wrist := []float64{0.3, -1.2, 1.5, -1.9, 0, 0.4}   // Joints 4 and 6 aligned
+	elbow := []float64{0.3, -1.2, 0, -1.9, -1.57, 0.4} // Arm stretched out
+
+	cond, _ := ur5e.Condition(regular)
+	if cond.Singular(0.02) || cond.Manipulability <= 0 {
+		t.Errorf("regular configuration flagged singular: %+v", cond)
+	}
+	for name, q := range map[string][]float64{"wrist": wrist, "elbow": elbow} {
+		c, _ := ur5e.Condition(q)
+		if !c.Singular(0.02) || c.MinSingularValue > 1e-6 || c.Manipulability > 1e-6 {
+			t.Errorf("%s singularity not detected: %+v", name, c)
+		}
+	}
+
+	// Cartesian velocity control tracks the twist away from singularities
+	twist := kinematics.Twist{Linear: [3]float64{0.05, -0.02, 0.03}, Angular: [3]float64{0, 0.1, 0}}
+	cmd, err := ur5e.ResolveTwist(regular, twist, kinematics.DefaultVelocityConfig())
+	if err != nil {
+		t.Fatalf("ResolveTwist: %v", err)
+	}
+	if cmd.NearSingularity || cmd.Scale != 1 {
+		t.Errorf("regular command = %+v", cmd)
+	}
+	jac, _ := ur5e.Jacobian(regular)
+	want := []float64{0.05, -0.02, 0.03, 0, 0.1, 0}
+	for r := 0; r < 6; r++ {
+		var v float64
+		for i, qd := range cmd.JointVelocities {
+			v += jac[r][i] * qd
+		}
+		if math.Abs(v-want[r]) > 1e-9 {
+			t.Fatalf("resolved twist row %d = %.6f, want %.6f", r, v, want[r])
+		}
+	}
+
+	// At the wrist singularity the command is damped, bounded and flagged
+	cmd, err = ur5e.ResolveTwist(wrist, twist, kinematics.DefaultVelocityConfig())
+	if err != nil {
+		t.Fatalf("ResolveTwist at singularity: %v", err)
+	}
+	if !cmd.NearSingularity {
+		t.Error("singular command not flagged")
+	}
+	for i, qd := range cmd.JointVelocities {
+		if math.IsNaN(qd) || math.Abs(qd) > ur5e.Joints[i].MaxVelocity+1e-9 {
+			t.Errorf("joint %d velocity %.3f at the singularity", i, qd)
+		}
+	}
+
+	// Fast twists are slowed uniformly to the joint velocity limits
+	fast := kinematics.Twist{Linear: [3]float64{5, 0, 0}}
+	cmd, _ = ur5e.ResolveTwist(regular, fast, kinematics.DefaultVelocityConfig())
+	if cmd.Scale >= 1 {
+		t.Errorf("5 m/s twist scale = %.3f", cmd.Scale)
+	}
+
+	// Servoing reaches a nearby pose
+	q := append([]float64(nil), regular...)
+	start, _ := ur5e.Forward(q)
+	goal := kinematics.Translation(0.05, 0.05, -0.05).Mul(start)
+	for step := 0; step < 400; step++ {
+		pose, _ := ur5e.Forward(q)
+		tw := kinematics.ServoTwist(pose, goal, 5, 0.25, 1)
+		cmd, err := ur5e.ResolveTwist(q, tw, kinematics.DefaultVelocityConfig())
+		if err != nil {
+			t.Fatalf("ResolveTwist: %v", err)
+		}
+		for i := range q {
+			q[i] += cmd.JointVelocities[i] * 0.008 // 125 Hz
+		}
+	}
+	end, _ := ur5e.Forward(q)
+	if dp, dr := poseDistance(end, goal); dp > 1e-4 || dr > 1e-4 {
+		t.Errorf("servo ended %.2g m, %.2g rad from the goal", dp, dr)
+	}
+}
+
+const testURDF = `<?xml version="1.0"?>
+<robot name="planar">
+  <link name="base"/><link name="l1"/><link name="l2"/><link name="flange"/><link name="tcp"/>
+  <joint name="mount" type="fixed">
+    <parent link="world"/><child link="base"/>
+  </joint>
+  <joint name="j1" type="revolute">
+    <parent link="base"/><child link="l1"/>
+    <origin xyz="0 0 0.1" rpy="0 0 0"/>
+    <axis xyz="0 0 1"/>
+    <limit lower="-1.5" upper="1.5" velocity="2"/>
+  </joint>
+  <joint name="j2" type="continuous">
+    <parent link="l1"/><child link="l2"/>
+    <origin xyz="0.3 0 0" rpy="0 0 1.5707963267948966"/>
+    <axis xyz="0 0 1"/>
+  </joint>
+  <joint name="j2_flange" type="fixed">
+    <parent link="l2"/><child link="flange"/>
+    <origin xyz="0.2 0 0"/>
+  </joint>
+  <joint name="flange_tcp" type="fixed">
+    <parent link="flange"/><child link="tcp"/>
+    <origin xyz="0.05 0 0"/>
+  </joint>
+</robot>`
+
+func TestKinematicsURDF(t *testing.T) {
+	chain, err := kinematics.ParseURDF(strings.NewReader(testURDF), "base", "tcp")
+	if err != nil {
+		t.Fatalf("ParseURDF: %v", err)
+	}
+	if chain.DOF() != 2 || chain.Joints[0].Upper != 1.5 || !math.IsInf(chain.Joints[1].Upper, 1) || chain.Joints[0].MaxVelocity != 2 {
+		t.Fatalf("parsed joints = %+v", chain.Joints)
+	}
+
+	// Planar two-link arm: the second link starts at a right angle
+	q := []float64{0.4, -0.7}
+	pose, _ := chain.Forward(q)
+	want := [3]float64{
+		0.3*math.Cos(q[0]) + 0.25*math.Cos(q[0]+math.Pi/2+q[1]),
+		0.3*math.Sin(q[0]) + 0.25*math.Sin(q[0]+math.Pi/2+q[1]),
+		0.1,
+	}
+	for k := range want {
+		if math.Abs(pose.P[k]-want[k]) > 1e-12 {
+			t.Fatalf("URDF pose = %v, want %v", pose.P, want)
+		}
+	}
+
+	if _, err := kinematics.ParseURDF(strings.NewReader(testURDF), "base", "gripper"); err == nil {
+		t.Error("parsed a chain to a missing link")
+	}
+	prismatic := strings.Replace(testURDF, `type="continuous"`, `type="prismatic"`, 1)
+	if _, err := kinematics.ParseURDF(strings.NewReader(prismatic), "base", "tcp"); err == nil {
+		t.Error("parsed a prismatic joint")
+	}
+}
+
+func TestKinematicsRemoteManipulatorJointGoals(t *testing.T) {
+	var got struct {
+		Position control.Vector3 `json:"position"`
+		Joints   []float64       `json:"joints"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hunoids/h1/manipulator/reach" {
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("decode reach: %v", err)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	manip, err := control.NewRemoteManipulator("h1", srv.URL)
+	if err != nil {
+		t.Fatalf("NewRemoteManipulator: %v", err)
+	}
+	if err := manip.SetArmModel("delta"); !errors.Is(err, kinematics.ErrUnknownModel) {
+		t.Errorf("SetArmModel(delta) = %v", err)
+	}
+	if err := manip.SetArmModel("ur5e"); err != nil {
+		t.Fatalf("SetArmModel: %v", err)
+	}
+
+	target := control.Vector3{X: 0.4, Y: -0.2, Z: 0.3}
+	if err := manip.ReachTo(context.Background(), target); err != nil {
+		t.Fatalf("ReachTo: %v", err)
+	}
+	if got.Position != target || len(got.Joints) != 6 {
+		t.Fatalf("reach payload = %+v", got)
+	}
+
+	// The joint goal puts the flange on the target pointing down
+	pose, _ := kinematics.UR5e().Forward(got.Joints)
+	if dp, _ := poseDistance(pose, kinematics.Translation(target.X, target.Y, target.Z).Mul(kinematics.RotY(math.Pi))); dp > 1e-4 {
+		t.Errorf("joint goal misses the target by %.4f m", dp)
+	}
+
+	if err := manip.ReachTo(context.Background(), control.Vector3{X: 3}); !errors.Is(err, kinematics.ErrNoSolution) {
+		t.Errorf("unreachable target error = %v", err)
+	}
+}
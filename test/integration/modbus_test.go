@@ -0,0 +1,451 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/control"
+	"github.com/asgard/pandora/internal/robotics/gripper"
+	"github.com/asgard/pandora/internal/robotics/modbus"
+)
+
+// fakeModbus is a Modbus TCP server over four 4096-entry tables. After
+// every write, onWrite lets a test simulate the device behind the
+// registers.
+type fakeModbus struct {
+	t  *testing.T
+	ln net.Listener
+
+	mu       sync.Mutex
+	coils    [4096]bool
+	discrete [4096]bool
+	holding  [4096]uint16
+	input    [4096]uint16
+	units    map[byte]int
+	stallAt  int // Holding register whose reads never answer, or -1
+	onWrite  func(f *fakeModbus)
+}
+
+func newFakeModbus(t *testing.T) *fakeModbus {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeModbus{t: t, ln: ln, units: map[byte]int{}, stallAt: -1}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.session(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeModbus) config() modbus.Config {
+	cfg := modbus.DefaultConfig("127.0.0.1")
+	cfg.Port = f.ln.Addr().(*net.TCPAddr).Port
+	cfg.Timeout = 200 * time.Millisecond
+	return cfg
+}
+
+func (f *fakeModbus) session(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var header [7]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		if binary.BigEndian.Uint16(header[2:4]) != 0 {
+			f.t.Errorf("protocol ID % x", header[2:4])
+			return
+		}
+		pdu := make([]byte, int(binary.BigEndian.Uint16(header[4:6]))-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			f.t.Errorf("short request: %v", err)
+			return
+		}
+		resp, stall := f.handle(header[6], pdu)
+		if stall {
+			continue
+		}
+		out := make([]byte, 7, 7+len(resp))
+		copy(out, header[:4])
+		binary.BigEndian.PutUint16(out[4:6], uint16(1+len(resp)))
+		out[6] = header[6]
+		if _, err := conn.Write(append(out, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeModbus) handle(unit byte, pdu []byte) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.units[unit]++
+	fn := pdu[0]
+	u16 := func(i int) int { return int(binary.BigEndian.Uint16(pdu[i:])) }
+	exception := func(code byte) []byte { return []byte{fn | 0x80, code} }
+	inRange := func(addr, n int) bool { return addr+n <= 4096 }
+
+	switch fn {
+	case 1, 2:
+		addr, n := u16(1), u16(3)
+		if !inRange(addr, n) {
+			return exception(2), false
+		}
+		table := &f.coils
+		if fn == 2 {
+			table = &f.discrete
+		}
+		packed := make([]byte, (n+7)/8)
+		for i := 0; i < n; i++ {
+			if table[addr+i] {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append([]byte{fn, byte(len(packed))}, packed...), false
+	case 3, 4:
+		addr, n := u16(1), u16(3)
+		if fn == 3 && addr == f.stallAt {
+			return nil, true
+		}
+		if !inRange(addr, n) {
+			return exception(2), false
+		}
+		table := &f.holding
+		if fn == 4 {
+			table = &f.input
+		}
+		resp := []byte{fn, byte(2 * n)}
+		for i := 0; i < n; i++ {
+			resp = binary.BigEndian.AppendUint16(resp, table[addr+i])
+		}
+		return resp, false
+	case 5:
+		addr, v := u16(1), u16(3)
+		if v != 0 && v != 0xFF00 {
+			return exception(3), false
+		}
+		f.coils[addr] = v == 0xFF00
+		return pdu, false
+	case 6:
+		f.holding[u16(1)] = uint16(u16(3))
+		f.written()
+		return pdu, false
+	case 15:
+		addr, n := u16(1), u16(3)
+		if int(pdu[5]) != (n+7)/8 {
+			f.t.Errorf("coil byte count %d for %d coils", pdu[5], n)
+		}
+		for i := 0; i < n; i++ {
+			f.coils[addr+i] = pdu[6+i/8]&(1<<(i%8)) != 0
+		}
+		return pdu[:5], false
+	case 16:
+		addr, n := u16(1), u16(3)
+		if !inRange(addr, n) {
+			return exception(2), false
+		}
+		if int(pdu[5]) != 2*n {
+			f.t.Errorf("register byte count %d for %d registers", pdu[5], n)
+		}
+		for i := 0; i < n; i++ {
+			f.holding[addr+i] = uint16(u16(6 + 2*i))
+		}
+		f.written()
+		return pdu[:5], false
+	case 23:
+		readAddr, readN, writeAddr, writeN := u16(1), u16(3), u16(5), u16(7)
+		if int(pdu[9]) != 2*writeN {
+			f.t.Errorf("read/write byte count %d for %d registers", pdu[9], writeN)
+		}
+		// Writes happen before the read
+		for i := 0; i < writeN; i++ {
+			f.holding[writeAddr+i] = uint16(u16(10 + 2*i))
+		}
+		f.written()
+		resp := []byte{fn, byte(2 * readN)}
+		for i := 0; i < readN; i++ {
+			resp = binary.BigEndian.AppendUint16(resp, f.input[readAddr+i])
+		}
+		return resp, false
+	}
+	return exception(1), false
+}
+
+func (f *fakeModbus) written() {
+	if f.onWrite != nil {
+		f.onWrite(f)
+	}
+}
+
+func TestModbusFunctionCodes(t *testing.T) {
+	fake := newFakeModbus(t)
+	ctx := context.Background()
+	client, err := modbus.Dial(ctx, fake.config())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.WriteSingleCoil(ctx, 3, true); err != nil {
+		t.Fatalf("WriteSingleCoil: %v", err)
+	}
+	pattern := []bool{true, false, true, true, false, false, false, false, true, true}
+	if err := client.WriteMultipleCoils(ctx, 10, pattern); err != nil {
+		t.Fatalf("WriteMultipleCoils: %v", err)
+	}
+	coils, err := client.ReadCoils(ctx, 3, 17)
+	if err != nil {
+		t.Fatalf("ReadCoils: %v", err)
+	}
+	if !coils[0] || coils[1] {
+		t.Errorf("coils 3-4 = %v", coils[:2])
+	}
+	for i, want := range pattern {
+		if coils[7+i] != want {
+			t.Fatalf("coil %d = %v, want %v", 10+i, coils[7+i], want)
+		}
+	}
+
+	fake.mu.Lock()
+	fake.discrete[100], fake.discrete[108] = true, true
+	fake.input[7] = 0xBEEF
+	fake.mu.Unlock()
+	inputs, err := client.ReadDiscreteInputs(ctx, 100, 9)
+	if err != nil || !inputs[0] || inputs[1] || !inputs[8] {
+		t.Errorf("ReadDiscreteInputs = %v, %v", inputs, err)
+	}
+	if regs, err := client.ReadInputRegisters(ctx, 7, 1); err != nil || regs[0] != 0xBEEF {
+		t.Errorf("ReadInputRegisters = %v, %v", regs, err)
+	}
+
+	if err := client.WriteSingleRegister(ctx, 20, 0x1234); err != nil {
+		t.Fatalf("WriteSingleRegister: %v", err)
+	}
+	if err := client.WriteMultipleRegisters(ctx, 21, []uint16{1, 2, 3}); err != nil {
+		t.Fatalf("WriteMultipleRegisters: %v", err)
+	}
+	regs, err := client.ReadHoldingRegisters(ctx, 20, 4)
+	if err != nil || regs[0] != 0x1234 || regs[3] != 3 {
+		t.Errorf("ReadHoldingRegisters = %v, %v", regs, err)
+	}
+	if regs, err := client.ReadWriteMultipleRegisters(ctx, 7, 1, 30, []uint16{9, 9}); err != nil || regs[0] != 0xBEEF {
+		t.Errorf("ReadWriteMultipleRegisters = %v, %v", regs, err)
+	}
+	fake.mu.Lock()
+	if fake.holding[31] != 9 {
+		t.Errorf("read/write did not write: %d", fake.holding[31])
+	}
+	fake.mu.Unlock()
+}
+
+func TestModbusErrors(t *testing.T) {
+	fake := newFakeModbus(t)
+	ctx := context.Background()
+	client, err := modbus.Dial(ctx, fake.config())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.ReadHoldingRegisters(ctx, 4095, 10)
+	var exc *modbus.Exception
+	if !errors.As(err, &exc) || exc.Code != modbus.IllegalDataAddress || exc.Function != modbus.FuncReadHoldingRegisters {
+		t.Errorf("out-of-range read error = %v", err)
+	}
+	if _, err := client.ReadHoldingRegisters(ctx, 0, 126); !errors.Is(err, modbus.ErrQuantity) {
+		t.Errorf("oversized read error = %v", err)
+	}
+	if err := client.WriteMultipleCoils(ctx, 0, nil); !errors.Is(err, modbus.ErrQuantity) {
+		t.Errorf("empty coil write error = %v", err)
+	}
+
+	// A stalled request times out, and the client reconnects for the next
+	fake.mu.Lock()
+	fake.stallAt = 50
+	fake.mu.Unlock()
+	start := time.Now()
+	if _, err := client.ReadHoldingRegisters(ctx, 50, 1); err == nil {
+		t.Fatal("stalled read succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timeout took %v", elapsed)
+	}
+	if _, err := client.ReadHoldingRegisters(ctx, 51, 1); err != nil {
+		t.Errorf("read after timeout: %v", err)
+	}
+}
+
+// robotiqDevice simulates a 2F-85 whose fingers stop on an object at
+// position count objectAt, answering on unit 9.
+func robotiqDevice(objectAt uint16) func(f *fakeModbus) {
+	return func(f *fakeModbus) {
+		action := f.holding[1000] >> 8
+		request := f.holding[1001] & 0xFF
+		var status, position uint16
+		if action&1 != 0 {
+			status = 1 | 3<<4 // Activated
+			position = f.input[2002] >> 8
+			if action&(1<<3) != 0 {
+				status |= 1 << 3
+				position = request
+				object := uint16(3)
+				if request > objectAt {
+					position, object = objectAt, 2
+				}
+				status |= object << 6
+			}
+		}
+		f.input[2000] = status << 8
+		f.input[2001] = 0
+		f.input[2002] = position << 8
+	}
+}
+
+func TestModbusRobotiqGripper(t *testing.T) {
+	fake := newFakeModbus(t)
+	fake.onWrite = robotiqDevice(150)
+	cfg := fake.config()
+	manip := control.NewRealManipulator(control.ManipulatorConfig{
+		Protocol:     "modbus",
+		Address:      cfg.Address,
+		Port:         cfg.Port,
+		GripperModel: "robotiq-2f85",
+		GripperForce: 60,
+	})
+	if err := manip.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	defer manip.Shutdown()
+
+	if err := manip.CloseGripper(); err != nil {
+		t.Fatalf("CloseGripper: %v", err)
+	}
+	status, err := manip.GetGripperStatus()
+	if err != nil || !status.ObjectDetected || status.Moving {
+		t.Fatalf("closed status = %+v, %v", status, err)
+	}
+	if width := status.Width; math.Abs(width-0.085*(1-150.0/255)) > 1e-9 {
+		t.Errorf("object width = %.4f m", width)
+	}
+	fake.mu.Lock()
+	command := fake.holding[1002]
+	units := fake.units[9]
+	fake.mu.Unlock()
+	if force := command & 0xFF; force != uint16(math.Round(255*(60-20)/215.0)) {
+		t.Errorf("force request = %d", force)
+	}
+	if units == 0 {
+		t.Errorf("no requests reached unit 9")
+	}
+
+	if err := manip.OpenGripper(); err != nil {
+		t.Fatalf("OpenGripper: %v", err)
+	}
+	if state, _ := manip.GetGripperState(); state != 1 {
+		t.Errorf("open state = %v", state)
+	}
+	if status, _ := manip.GetGripperStatus(); status.ObjectDetected {
+		t.Errorf("object still detected after opening")
+	}
+}
+
+// wsgDevice simulates a WSG-50 whose fingers meet an object width wide.
+func wsgDevice(object float32) func(f *fakeModbus) {
+	readFloat := func(regs []uint16) float32 {
+		return math.Float32frombits(uint32(regs[0])<<16 | uint32(regs[1]))
+	}
+	return func(f *fakeModbus) {
+		control := f.holding[0]
+		target := readFloat(f.holding[1:3])
+		status := control & 0x8000 // Echo the handshake
+		width := readFloat(f.input[1:3])
+		switch {
+		case control&(1<<1) != 0:
+			status |= 1 | 1<<1
+			width = 110
+		case control&(1<<3) != 0:
+			status |= 1 | 1<<1
+			width = target
+			if target < object {
+				width = object
+				status |= 1 << 4
+			}
+		case control&(1<<2) != 0, control&(1<<4) != 0:
+			status |= 1 | 1<<1
+			width = target
+		default:
+			status |= f.input[0] & 0x7FFF
+		}
+		bits := math.Float32bits(width)
+		f.input[0] = status
+		f.input[1], f.input[2] = uint16(bits>>16), uint16(bits)
+	}
+}
+
+func TestModbusWSGGripper(t *testing.T) {
+	fake := newFakeModbus(t)
+	fake.onWrite = wsgDevice(32)
+	ctx := context.Background()
+	client, err := modbus.Dial(ctx, fake.config())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	wsg, err := gripper.New("schunk-wsg-50", client)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := wsg.Activate(ctx); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if err := wsg.Grip(ctx, gripper.Command{Width: 0, Speed: 0.1, Force: 200}); err != nil {
+		t.Fatalf("Grip: %v", err)
+	}
+	status, err := gripper.WaitIdle(ctx, wsg, time.Millisecond)
+	if err != nil || !status.ObjectDetected || math.Abs(status.Width-0.032) > 1e-6 {
+		t.Fatalf("grip status = %+v, %v", status, err)
+	}
+	fake.mu.Lock()
+	force := math.Float32frombits(uint32(fake.holding[5])<<16 | uint32(fake.holding[6]))
+	handshake := fake.holding[0] & 0x8000
+	fake.mu.Unlock()
+	if force != 80 {
+		t.Errorf("force clamped to %v N, want 80", force)
+	}
+
+	if err := wsg.Move(ctx, gripper.Command{Width: wsg.MaxWidth(), Speed: 0.1}); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	fake.mu.Lock()
+	control := fake.holding[0]
+	fake.mu.Unlock()
+	if control&(1<<4) == 0 {
+		t.Errorf("opening on a held part sent control %#04x, want release", control)
+	}
+	if control&0x8000 == handshake {
+		t.Errorf("handshake bit not toggled")
+	}
+	status, _ = wsg.Status(ctx)
+	if status.ObjectDetected || math.Abs(status.Width-0.110) > 1e-6 {
+		t.Errorf("released status = %+v", status)
+	}
+
+	if _, err := gripper.New("barrett-hand", client); !errors.Is(err, gripper.ErrUnknownModel) {
+		t.Errorf("unknown model error = %v", err)
+	}
+}
@@ -0,0 +1,392 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/control"
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+	"github.com/asgard/pandora/internal/robotics/motion"
+	"github.com/asgard/pandora/internal/robotics/perception"
+)
+
+// UR5e configurations with the tool pointing down about 0.5 m out from
+// the base, a quarter turn apart about the base joint.
+var (
+	planStart = []float64{0, -math.Pi / 2, math.Pi / 2, -math.Pi / 2, -math.Pi / 2, 0}
+	planGoal  = []float64{math.Pi / 2, -math.Pi / 2, math.Pi / 2, -math.Pi / 2, -math.Pi / 2, 0}
+)
+
+// blockingOctree tracks one object where the tool passes halfway along the
+// direct motion from planStart to planGoal.
+func blockingOctree(t *testing.T, chain *kinematics.Chain) *perception.Octree {
+	t.Helper()
+	mid := make([]float64, len(planStart))
+	for i := range mid {
+		mid[i] = (planStart[i] + planGoal[i]) / 2
+	}
+	pose, err := chain.Forward(mid)
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	octree := perception.NewOctree(perception.Vector3{}, 4)
+	octree.Insert(&perception.TrackedObject{
+		ID:        "crate-1",
+		ClassType: perception.ObjectClass("crate"),
+		Position:  perception.Vector3{X: pose.P[0], Y: pose.P[1], Z: pose.P[2]},
+	})
+	return octree
+}
+
+// checkPath verifies a path joins start to goal and that every motion
+// along it is collision-free.
+func checkPath(t *testing.T, planner *motion.Planner, path motion.Path, start, goal []float64) {
+	t.Helper()
+	if len(path) < 2 {
+		t.Fatalf("path has %d waypoints", len(path))
+	}
+	for i := range start {
+		if math.Abs(path[0][i]-start[i]) > 1e-9 || math.Abs(path[len(path)-1][i]-goal[i]) > 1e-9 {
+			t.Fatalf("path runs %v to %v, want %v to %v", path[0], path[len(path)-1], start, goal)
+		}
+	}
+	for k := 1; k < len(path); k++ {
+		a, b := path[k-1], path[k]
+		for s := 0.0; s <= 1; s += 0.01 {
+			q := make([]float64, len(a))
+			for i := range q {
+				q[i] = a[i] + s*(b[i]-a[i])
+			}
+			if err := planner.Check(q); err != nil {
+				t.Fatalf("segment %d at %.2f: %v", k, s, err)
+			}
+		}
+	}
+}
+
+func TestMotionPlanningAroundObstacle(t *testing.T) {
+	chain := kinematics.UR5e()
+	env := &motion.Environment{
+		Objects: blockingOctree(t, chain),
+		Boxes: []motion.Box{
+			{Name: "table", Min: [3]float64{-1, -1, -0.5}, Max: [3]float64{1, 1, -0.05}},
+		},
+		ObjectRadius: 0.08,
+	}
+
+	// The direct motion is blocked
+	mid := make([]float64, len(planStart))
+	for i := range mid {
+		mid[i] = (planStart[i] + planGoal[i]) / 2
+	}
+	var planErr *motion.PlanningError
+	if err := motion.NewPlanner(chain, env, motion.DefaultConfig()).Check(mid); !errors.As(err, &planErr) || !strings.Contains(planErr.Obstacle, "crate-1") {
+		t.Fatalf("Check(mid) = %v, want a collision with crate-1", err)
+	}
+
+	for _, algorithm := range []motion.Algorithm{motion.RRTConnect, motion.RRTStar} {
+		cfg := motion.DefaultConfig()
+		cfg.Algorithm = algorithm
+		cfg.MaxIterations = 1500
+		cfg.Timeout = 5 * time.Second
+		planner := motion.NewPlanner(chain, env, cfg)
+		path, err := planner.Plan(context.Background(), planStart, planGoal)
+		if err != nil {
+			t.Fatalf("%s Plan: %v", algorithm, err)
+		}
+		checkPath(t, planner, path, planStart, planGoal)
+		if direct := math.Pi / 2; path.Length() < direct {
+			t.Errorf("%s path length %.3f shorter than the direct motion %.3f", algorithm, path.Length(), direct)
+		}
+		t.Logf("%s: %d waypoints, length %.3f rad", algorithm, len(path), path.Length())
+
+		traj, err := planner.Parameterize(path)
+		if err != nil {
+			t.Fatalf("%s Parameterize: %v", algorithm, err)
+		}
+		// The blended trajectory stays clear too
+		for at := time.Duration(0); at <= traj.Duration(); at += 5 * time.Millisecond {
+			if err := planner.Check(traj.Sample(at)); err != nil {
+				t.Fatalf("%s trajectory at %v: %v", algorithm, at, err)
+			}
+		}
+	}
+}
+
+func TestMotionPlanningFailures(t *testing.T) {
+	chain := kinematics.UR5e()
+	octree := blockingOctree(t, chain)
+	env := &motion.Environment{Objects: octree, ObjectRadius: 0.08}
+	cfg := motion.DefaultConfig()
+
+	startPose, err := chain.Forward(planStart)
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	octree.Insert(&perception.TrackedObject{
+		ID:        "person-7",
+		ClassType: perception.ClassHuman,
+		BoundingBox: perception.BoundingBox3D{
+			Min: perception.Vector3{X: startPose.P[0] - 0.1, Y: startPose.P[1] - 0.1, Z: startPose.P[2] - 0.1},
+			Max: perception.Vector3{X: startPose.P[0] + 0.1, Y: startPose.P[1] + 0.1, Z: startPose.P[2] + 0.1},
+		},
+		Position: perception.Vector3{X: startPose.P[0], Y: startPose.P[1], Z: startPose.P[2]},
+	})
+
+	expect := func(name string, err error, reason motion.Reason) *motion.PlanningError {
+		t.Helper()
+		var planErr *motion.PlanningError
+		if !errors.As(err, &planErr) {
+			t.Fatalf("%s: error %v is not a PlanningError", name, err)
+		}
+		if planErr.Reason != reason {
+			t.Fatalf("%s: reason %s, want %s (%v)", name, planErr.Reason, reason, err)
+		}
+		if !errors.Is(err, motion.ErrPlanningFailed) {
+			t.Errorf("%s: errors.Is(ErrPlanningFailed) = false", name)
+		}
+		return planErr
+	}
+
+	planner := motion.NewPlanner(chain, env, cfg)
+	_, err = planner.Plan(context.Background(), planStart, planGoal)
+	planErr := expect("start in collision", err, motion.ReasonStartInCollision)
+	if !strings.Contains(planErr.Obstacle, "person-7") || planErr.Details()["obstacle"] != planErr.Obstacle {
+		t.Errorf("start collision obstacle = %q, details %v", planErr.Obstacle, planErr.Details())
+	}
+
+	_, err = planner.Plan(context.Background(), planGoal, planStart)
+	expect("goal in collision", err, motion.ReasonGoalInCollision)
+
+	outside := append([]float64(nil), planGoal...)
+	outside[0] = 7
+	_, err = planner.Plan(context.Background(), planGoal, outside)
+	if planErr := expect("joint limits", err, motion.ReasonInvalidRequest); !errors.Is(planErr, kinematics.ErrJointLimits) {
+		t.Errorf("joint limit error does not wrap ErrJointLimits: %v", planErr)
+	}
+
+	_, err = planner.PlanToPose(context.Background(), planGoal, kinematics.Translation(3, 0, 0.5), kinematics.DefaultIKConfig())
+	if planErr := expect("unreachable", err, motion.ReasonUnreachable); !errors.Is(planErr, kinematics.ErrNoSolution) {
+		t.Errorf("unreachable error does not wrap ErrNoSolution: %v", planErr)
+	}
+
+	// Blocked direct motion with no budget to search around it
+	free := &motion.Environment{Objects: blockingOctree(t, chain), ObjectRadius: 0.08}
+	cfg.MaxIterations = 1
+	_, err = motion.NewPlanner(chain, free, cfg).Plan(context.Background(), planStart, planGoal)
+	if planErr := expect("no path", err, motion.ReasonNoPath); planErr.Iterations != 1 || planErr.Algorithm != motion.RRTConnect {
+		t.Errorf("no path error = %+v", planErr)
+	}
+
+	cfg = motion.DefaultConfig()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = motion.NewPlanner(chain, free, cfg).Plan(ctx, planStart, planGoal)
+	if planErr := expect("cancelled", err, motion.ReasonTimeout); !errors.Is(planErr, context.Canceled) {
+		t.Errorf("cancelled planning does not wrap context.Canceled: %v", planErr)
+	}
+}
+
+func TestMotionPlanningShortcut(t *testing.T) {
+	chain := kinematics.UR5e()
+	cfg := motion.DefaultConfig()
+	cfg.ShortcutIterations = 200
+	env := &motion.Environment{Objects: blockingOctree(t, chain), ObjectRadius: 0.08}
+	path, err := motion.NewPlanner(chain, env, cfg).Plan(context.Background(), planStart, planGoal)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	cfg.ShortcutIterations = 0
+	raw, err := motion.NewPlanner(chain, env, cfg).Plan(context.Background(), planStart, planGoal)
+	if err != nil {
+		t.Fatalf("Plan without shortcutting: %v", err)
+	}
+	if path.Length() > raw.Length()+1e-9 || len(path) > len(raw) {
+		t.Errorf("shortcut path %d waypoints %.3f rad, raw %d waypoints %.3f rad", len(path), path.Length(), len(raw), raw.Length())
+	}
+}
+
+func TestMotionTimeParameterization(t *testing.T) {
+	limits := motion.Limits{
+		Velocity:     []float64{1, 0.5, 1},
+		Acceleration: []float64{2, 2, 1},
+	}
+
+	// A straight move is a trapezoid limited by the slowest joint
+	straight, err := motion.TimeParameterize(motion.Path{{0, 0, 0}, {1, 1, 0}}, limits, 0.05)
+	if err != nil {
+		t.Fatalf("TimeParameterize: %v", err)
+	}
+	// Joint 1 covers 1 rad at 0.5 rad/s, accelerating at 2 rad/s²
+	want := 1/0.5 + 0.5/2
+	if got := straight.Duration().Seconds(); math.Abs(got-want) > 0.02*want {
+		t.Errorf("straight duration = %.3f s, want %.3f s", got, want)
+	}
+
+	path := motion.Path{{0, 0, 0}, {1, 0, 0}, {1, 1, 0.5}, {0, 1, 1}}
+	deviation := 0.05
+	traj, err := motion.TimeParameterize(path, limits, deviation)
+	if err != nil {
+		t.Fatalf("TimeParameterize: %v", err)
+	}
+	first, last := traj.Points[0], traj.Points[len(traj.Points)-1]
+	for i := range path[0] {
+		if math.Abs(first.Positions[i]-path[0][i]) > 1e-9 || math.Abs(last.Positions[i]-path[3][i]) > 1e-9 {
+			t.Fatalf("trajectory runs %v to %v", first.Positions, last.Positions)
+		}
+		if first.Velocities[i] != 0 || last.Velocities[i] != 0 {
+			t.Errorf("trajectory does not start and end at rest: %v, %v", first.Velocities, last.Velocities)
+		}
+	}
+
+	var prev time.Duration
+	for k, p := range traj.Points {
+		if p.Time < prev {
+			t.Fatalf("point %d time %v before %v", k, p.Time, prev)
+		}
+		prev = p.Time
+		for i := range p.Velocities {
+			if math.Abs(p.Velocities[i]) > limits.Velocity[i]*1.001 {
+				t.Fatalf("point %d joint %d velocity %.4f over %.4f", k, i, p.Velocities[i], limits.Velocity[i])
+			}
+			if math.Abs(p.Accelerations[i]) > limits.Acceleration[i]*1.05 {
+				t.Fatalf("point %d joint %d acceleration %.4f over %.4f", k, i, p.Accelerations[i], limits.Acceleration[i])
+			}
+		}
+	}
+
+	// Corners are cut by at most the blend deviation, and the arm does not
+	// stop at them
+	for _, corner := range path[1:3] {
+		closest := math.Inf(1)
+		var speed float64
+		for _, p := range traj.Points {
+			var d, v float64
+			for i := range corner {
+				d += (p.Positions[i] - corner[i]) * (p.Positions[i] - corner[i])
+				v += p.Velocities[i] * p.Velocities[i]
+			}
+			if d = math.Sqrt(d); d < closest {
+				closest, speed = d, math.Sqrt(v)
+			}
+		}
+		if closest > deviation*1.01 {
+			t.Errorf("corner %v passed at %.4f rad, more than %.2f", corner, closest, deviation)
+		}
+		if speed < 0.05 {
+			t.Errorf("corner %v passed at %.3f rad/s; blends should not stop", corner, speed)
+		}
+	}
+
+	if _, err := motion.TimeParameterize(path, motion.Limits{Velocity: []float64{1, 1, 1}, Acceleration: []float64{1, 0, 1}}, deviation); !errors.Is(err, motion.ErrLimits) {
+		t.Errorf("zero acceleration limit: err = %v, want ErrLimits", err)
+	}
+}
+
+func TestMotionPlanningManipulatorReach(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	chain := kinematics.UR5e()
+	manip, err := control.NewRemoteManipulator("hunoid-1", server.URL)
+	if err != nil {
+		t.Fatalf("NewRemoteManipulator: %v", err)
+	}
+	if err := manip.SetEnvironment(&motion.Environment{}, motion.DefaultConfig()); err == nil {
+		t.Fatal("SetEnvironment without an arm model succeeded")
+	}
+	if err := manip.SetArmModel("ur5e"); err != nil {
+		t.Fatalf("SetArmModel: %v", err)
+	}
+
+	target, err := chain.Forward(planGoal)
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	octree := perception.NewOctree(perception.Vector3{}, 4)
+	// The object being reached for is not an obstacle
+	octree.Insert(&perception.TrackedObject{
+		ID:        "bottle-3",
+		ClassType: perception.ObjectClass("bottle"),
+		Position:  perception.Vector3{X: target.P[0], Y: target.P[1], Z: target.P[2]},
+	})
+	cfg := motion.DefaultConfig()
+	cfg.MaxJointVelocity = 0.8
+	if err := manip.SetEnvironment(&motion.Environment{Objects: octree, ObjectRadius: 0.08}, cfg); err != nil {
+		t.Fatalf("SetEnvironment: %v", err)
+	}
+
+	goal := control.Vector3{X: target.P[0], Y: target.P[1], Z: target.P[2]}
+	if err := manip.ReachTo(context.Background(), goal); err != nil {
+		t.Fatalf("ReachTo: %v", err)
+	}
+	mu.Lock()
+	if len(requests) != 1 {
+		t.Fatalf("%d requests, want 1", len(requests))
+	}
+	var trajectory []struct {
+		Time       float64   `json:"time"`
+		Positions  []float64 `json:"positions"`
+		Velocities []float64 `json:"velocities"`
+	}
+	if err := json.Unmarshal(requests[0]["trajectory"], &trajectory); err != nil || len(trajectory) < 2 {
+		t.Fatalf("trajectory = %s (%v)", requests[0]["trajectory"], err)
+	}
+	mu.Unlock()
+	end := trajectory[len(trajectory)-1]
+	reached, err := chain.Forward(end.Positions)
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	if dp := math.Sqrt(math.Pow(reached.P[0]-goal.X, 2) + math.Pow(reached.P[1]-goal.Y, 2) + math.Pow(reached.P[2]-goal.Z, 2)); dp > 1e-3 {
+		t.Errorf("trajectory ends %.4f m from the target", dp)
+	}
+	for _, p := range trajectory {
+		for i, v := range p.Velocities {
+			if math.Abs(v) > cfg.MaxJointVelocity*1.001 {
+				t.Fatalf("t=%.2f joint %d velocity %.3f over %.3f", p.Time, i, v, cfg.MaxJointVelocity)
+			}
+		}
+	}
+
+	// A person standing on the target blocks the reach with a structured
+	// error
+	octree.Insert(&perception.TrackedObject{
+		ID:        "person-2",
+		ClassType: perception.ClassHuman,
+		BoundingBox: perception.BoundingBox3D{
+			Min: perception.Vector3{X: goal.X - 0.3, Y: goal.Y - 0.3, Z: goal.Z + 0.05},
+			Max: perception.Vector3{X: goal.X + 0.3, Y: goal.Y + 0.3, Z: goal.Z + 1.5},
+		},
+		Position: perception.Vector3{X: goal.X, Y: goal.Y, Z: goal.Z + 0.8},
+	})
+	err = manip.ReachTo(context.Background(), goal)
+	var planErr *motion.PlanningError
+	if !errors.As(err, &planErr) || planErr.Reason != motion.ReasonGoalInCollision || !strings.Contains(planErr.Obstacle, "person-2") {
+		t.Fatalf("blocked ReachTo = %v, want goal_in_collision with person-2", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Errorf("blocked reach sent a request")
+	}
+}
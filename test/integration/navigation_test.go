@@ -0,0 +1,519 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/control"
+	"github.com/asgard/pandora/internal/robotics/navigation"
+	"github.com/asgard/pandora/internal/robotics/perception"
+)
+
+// smallGridConfig is a 10 m × 10 m map centred on the origin.
+func smallGridConfig() navigation.GridConfig {
+	cfg := navigation.DefaultGridConfig()
+	cfg.Width, cfg.Height = 200, 200
+	cfg.OriginX, cfg.OriginY = -5, -5
+	return cfg
+}
+
+// wallGrid returns a map with a wall along x = 0 from y0 to y1.
+func wallGrid(t *testing.T, y0, y1 float64) *navigation.OccupancyGrid {
+	t.Helper()
+	grid := navigation.NewOccupancyGrid(smallGridConfig())
+	addWall(grid, y0, y1)
+	return grid
+}
+
+func addWall(grid *navigation.OccupancyGrid, y0, y1 float64) {
+	for y := y0; y <= y1; y += grid.Config().Resolution / 2 {
+		c, _ := grid.WorldToCell(0, y)
+		grid.SetProbability(c, 1)
+	}
+}
+
+func pathCost(path []navigation.Cell) float64 {
+	var cost float64
+	for i := 1; i < len(path); i++ {
+		cost += math.Hypot(float64(path[i].X-path[i-1].X), float64(path[i].Y-path[i-1].Y))
+	}
+	return cost
+}
+
+// checkCells verifies a path joins start to goal through free,
+// 8-connected cells.
+func checkCells(t *testing.T, m *navigation.Costmap, path []navigation.Cell, start, goal navigation.Cell) {
+	t.Helper()
+	if len(path) == 0 || path[0] != start || path[len(path)-1] != goal {
+		t.Fatalf("path does not join %v to %v", start, goal)
+	}
+	for i, c := range path {
+		if m.Blocked(c) {
+			t.Fatalf("path cell %v is blocked", c)
+		}
+		if i > 0 {
+			if dx, dy := c.X-path[i-1].X, c.Y-path[i-1].Y; dx*dx > 1 || dy*dy > 1 {
+				t.Fatalf("path jumps from %v to %v", path[i-1], c)
+			}
+		}
+	}
+}
+
+func TestOccupancyGridScanIntegration(t *testing.T) {
+	grid := navigation.NewOccupancyGrid(smallGridConfig())
+	cell := func(x, y float64) navigation.Cell {
+		c, ok := grid.WorldToCell(x, y)
+		if !ok {
+			t.Fatalf("(%.2f, %.2f) is off the map", x, y)
+		}
+		return c
+	}
+	if grid.State(cell(1, 1)) != navigation.Unknown {
+		t.Fatal("new grid cell is not unknown")
+	}
+
+	// The sensor faces +y, so objects ahead of it lie along the map's y
+	// axis and objects to its left along -x.
+	sensor := navigation.Pose2D{Yaw: math.Pi / 2}
+	scan := &perception.ScanResult360{
+		Timestamp: time.Now(),
+		Objects: []perception.TrackedObject{
+			{ID: "crate", ClassType: perception.ObjectClass("crate"), Position: perception.Vector3{X: 2}},
+			{ID: "walker", ClassType: perception.ObjectClass("human"), Position: perception.Vector3{Y: 2}, Velocity: perception.Vector3{X: 0.8}},
+		},
+	}
+	for i := 0; i < 5; i++ {
+		grid.InsertScan(sensor, scan)
+	}
+	if s := grid.State(cell(0, 2)); s != navigation.Occupied {
+		t.Errorf("static object cell state = %v, want occupied", s)
+	}
+	if s := grid.State(cell(0, 1)); s != navigation.Free {
+		t.Errorf("cell between sensor and static object state = %v, want free", s)
+	}
+	if s := grid.State(cell(-2, 0)); s == navigation.Occupied {
+		t.Error("moving object was mapped as occupied")
+	}
+	if s := grid.State(cell(-1, 0)); s != navigation.Free {
+		t.Errorf("cell between sensor and moving object state = %v, want free", s)
+	}
+	if p := grid.Probability(cell(0, 2)); p > 0.98 {
+		t.Errorf("occupied probability %.3f not clamped", p)
+	}
+	// The object's footprint reaches ObjectRadius towards the sensor.
+	if c, want := grid.Clearance(0, 1), 1-grid.Config().ObjectRadius; math.Abs(c-want) > 0.06 {
+		t.Errorf("clearance 1 m from object = %.3f, want %.3f", c, want)
+	}
+
+	// Repeated misses clear a cell that was hit once.
+	version := grid.Version()
+	grid.InsertRay(0, 0, 3, 0, true)
+	if grid.State(cell(3, 0)) != navigation.Occupied || grid.Version() == version {
+		t.Fatal("ray hit did not mark its end occupied")
+	}
+	for i := 0; i < 10; i++ {
+		grid.InsertRay(0, 0, 4, 0, false)
+	}
+	if s := grid.State(cell(3, 0)); s != navigation.Free {
+		t.Errorf("cell state after repeated misses = %v, want free", s)
+	}
+}
+
+func TestGlobalPlanners(t *testing.T) {
+	grid := wallGrid(t, -3, 2)
+	costmap := grid.Costmap(0.3, false)
+	start, _ := grid.WorldToCell(-2, 0)
+	goal, _ := grid.WorldToCell(2, 0)
+
+	astar, err := navigation.AStar(costmap, start, goal)
+	if err != nil {
+		t.Fatalf("AStar: %v", err)
+	}
+	checkCells(t, costmap, astar, start, goal)
+	dstar := navigation.NewDStarLite(costmap, start, goal)
+	path, err := dstar.Plan()
+	if err != nil {
+		t.Fatalf("DStarLite: %v", err)
+	}
+	checkCells(t, costmap, path, start, goal)
+	if math.Abs(pathCost(path)-pathCost(astar)) > 1e-9 {
+		t.Fatalf("D* Lite path cost %.3f, A* %.3f", pathCost(path), pathCost(astar))
+	}
+
+	// Close the gap the path went through after moving partway along it;
+	// the repaired plan must match planning afresh.
+	gapY := 2.5
+	if _, y := grid.CellToWorld(path[len(path)/2]); y < 0 {
+		gapY = -3.5
+	}
+	addWall(grid, gapY-2, gapY+2)
+	moved := path[10]
+	updated := grid.Costmap(0.3, false)
+	dstar.MoveStart(moved)
+	dstar.UpdateCosts(updated, updated.Diff(costmap))
+	repaired, err := dstar.Plan()
+	if err != nil {
+		t.Fatalf("DStarLite replan: %v", err)
+	}
+	checkCells(t, updated, repaired, moved, goal)
+	fresh, err := navigation.AStar(updated, moved, goal)
+	if err != nil {
+		t.Fatalf("AStar replan: %v", err)
+	}
+	if math.Abs(pathCost(repaired)-pathCost(fresh)) > 1e-9 {
+		t.Fatalf("repaired path cost %.3f, fresh %.3f", pathCost(repaired), pathCost(fresh))
+	}
+
+	// A wall right across the map leaves no way through.
+	addWall(grid, -5, 5)
+	sealed := grid.Costmap(0.3, false)
+	if _, err := navigation.AStar(sealed, start, goal); !errors.Is(err, navigation.ErrNoPath) {
+		t.Errorf("AStar across a sealed wall: got %v, want ErrNoPath", err)
+	}
+	dstar.UpdateCosts(sealed, sealed.Diff(updated))
+	if _, err := dstar.Plan(); !errors.Is(err, navigation.ErrNoPath) {
+		t.Errorf("DStarLite across a sealed wall: got %v, want ErrNoPath", err)
+	}
+	wall, _ := grid.WorldToCell(0, 0)
+	if _, err := navigation.AStar(sealed, start, wall); !errors.Is(err, navigation.ErrBlocked) {
+		t.Errorf("AStar into a wall: got %v, want ErrBlocked", err)
+	}
+}
+
+func TestDWAAvoidsMovingObstacle(t *testing.T) {
+	cfg := navigation.DefaultDWAConfig()
+	dwa := navigation.NewDWA(cfg)
+	open := func(x, y float64) float64 { return 3 }
+
+	cmd, ok := dwa.Plan(navigation.Pose2D{}, navigation.Velocity{}, 5, 0, navigation.Obstacles{Clearance: open})
+	if !ok || cmd.Linear <= 0 || math.Abs(cmd.Angular) > 1e-9 {
+		t.Fatalf("open-space command = %+v, %t; want straight ahead", cmd, ok)
+	}
+	if limit := cfg.MaxAccel * cfg.Period.Seconds(); cmd.Linear > limit+1e-9 {
+		t.Fatalf("speed %.3f from rest exceeds the dynamic window %.3f", cmd.Linear, limit)
+	}
+
+	// A walker comes head-on along the robot's line towards its target.
+	pose := navigation.Pose2D{}
+	var vel navigation.Velocity
+	walker := navigation.MovingObstacle{ID: "walker", X: 4, VX: -0.6, Radius: 0.6}
+	dt := cfg.Period.Seconds()
+	closest := math.Inf(1)
+	for step := 0; step < 150; step++ {
+		cmd, ok := dwa.Plan(pose, vel, 5, 0, navigation.Obstacles{Clearance: open, Moving: []navigation.MovingObstacle{walker}})
+		if !ok {
+			cmd = navigation.Velocity{}
+		}
+		if math.Abs(cmd.Linear-vel.Linear) > cfg.MaxAccel*dt+1e-9 {
+			t.Fatalf("step %d: speed change %.3f exceeds acceleration limit", step, cmd.Linear-vel.Linear)
+		}
+		vel = cmd
+		pose = navigation.Integrate(pose, cmd, dt)
+		walker.X += walker.VX * dt
+		walker.Y += walker.VY * dt
+		closest = math.Min(closest, math.Hypot(pose.X-walker.X, pose.Y-walker.Y))
+	}
+	if closest <= walker.Radius {
+		t.Fatalf("robot came within %.3f m of the walker, radius %.1f m", closest, walker.Radius)
+	}
+	if d := math.Hypot(5-pose.X, pose.Y); d > 0.5 {
+		t.Fatalf("robot ended %.2f m from its target at (%.2f, %.2f)", d, pose.X, pose.Y)
+	}
+}
+
+func TestMapSaveLoad(t *testing.T) {
+	grid := wallGrid(t, -1, 1)
+	for i := 0; i < 5; i++ {
+		grid.InsertRay(-2, -2, -2, 2, false)
+	}
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "lab.yaml")
+	if err := navigation.SaveMap(yamlPath, grid); err != nil {
+		t.Fatalf("SaveMap: %v", err)
+	}
+	meta, err := os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"image: lab.pgm", "resolution: 0.05", "origin: [-5, -5, 0.0]", "negate: 0"} {
+		if !strings.Contains(string(meta), want) {
+			t.Errorf("metadata missing %q:\n%s", want, meta)
+		}
+	}
+
+	loaded, err := navigation.LoadMap(yamlPath, navigation.DefaultGridConfig())
+	if err != nil {
+		t.Fatalf("LoadMap: %v", err)
+	}
+	w, h := loaded.Size()
+	if w != 200 || h != 200 || loaded.Config().OriginX != -5 || loaded.Config().OriginY != -5 {
+		t.Fatalf("loaded map %dx%d at (%.2f, %.2f)", w, h, loaded.Config().OriginX, loaded.Config().OriginY)
+	}
+	counts := map[navigation.CellState]int{}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := navigation.Cell{X: x, Y: y}
+			if got, want := loaded.State(c), grid.State(c); got != want {
+				t.Fatalf("cell %v loaded as %v, saved as %v", c, got, want)
+			}
+			counts[grid.State(c)]++
+		}
+	}
+	if counts[navigation.Free] == 0 || counts[navigation.Occupied] == 0 || counts[navigation.Unknown] == 0 {
+		t.Fatalf("round trip did not cover every state: %v", counts)
+	}
+
+	// A map written by hand: the first image row is the top of the map,
+	// and negate flips dark and light.
+	pgm := append([]byte("P5\n# hand made\n3 2\n255\n"), 0, 100, 254, 254, 254, 0)
+	if err := os.WriteFile(filepath.Join(dir, "tiny.pgm"), pgm, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tiny := "image: tiny.pgm\nresolution: 0.5\norigin: [1.0, 2.0, 0.0]  # corner\nnegate: 1\noccupied_thresh: 0.65\nfree_thresh: 0.196\n"
+	if err := os.WriteFile(filepath.Join(dir, "tiny.yaml"), []byte(tiny), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	small, err := navigation.LoadMap(filepath.Join(dir, "tiny.yaml"), navigation.DefaultGridConfig())
+	if err != nil {
+		t.Fatalf("LoadMap: %v", err)
+	}
+	want := map[navigation.Cell]navigation.CellState{
+		{X: 0, Y: 1}: navigation.Free,
+		{X: 1, Y: 1}: navigation.Unknown,
+		{X: 2, Y: 1}: navigation.Occupied,
+		{X: 2, Y: 0}: navigation.Free,
+	}
+	for c, state := range want {
+		if got := small.State(c); got != state {
+			t.Errorf("hand-made cell %v = %v, want %v", c, got, state)
+		}
+	}
+	if c, ok := small.WorldToCell(2.2, 2.9); !ok || c != (navigation.Cell{X: 2, Y: 1}) {
+		t.Errorf("WorldToCell(2.2, 2.9) = %v, %t", c, ok)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("image: tiny.pgm\norigin: [0, 0, 0]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := navigation.LoadMap(filepath.Join(dir, "bad.yaml"), navigation.DefaultGridConfig()); err == nil {
+		t.Error("LoadMap accepted metadata without a resolution")
+	}
+}
+
+// simBase is a mobile base that integrates velocity commands, recording
+// where it has been.
+type simBase struct {
+	mu      sync.Mutex
+	pose    navigation.Pose2D
+	cmd     navigation.Velocity
+	updated time.Time
+	trail   []navigation.Pose2D
+}
+
+func newSimBase(x, y float64) *simBase {
+	return &simBase{pose: navigation.Pose2D{X: x, Y: y}, updated: time.Now()}
+}
+
+// advance integrates the current command up to now. The caller holds the
+// lock.
+func (b *simBase) advance() {
+	now := time.Now()
+	b.pose = navigation.Integrate(b.pose, b.cmd, now.Sub(b.updated).Seconds())
+	b.updated = now
+	b.trail = append(b.trail, b.pose)
+}
+
+func (b *simBase) Initialize(ctx context.Context) error { return nil }
+
+func (b *simBase) GetCurrentPose() (control.Pose, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	sin, cos := math.Sincos(b.pose.Yaw / 2)
+	return control.Pose{
+		Position:    control.Vector3{X: b.pose.X, Y: b.pose.Y},
+		Orientation: control.Quaternion{W: cos, Z: sin},
+	}, nil
+}
+
+func (b *simBase) MoveTo(ctx context.Context, target control.Pose) error {
+	return errors.New("simulated base takes velocity commands")
+}
+
+func (b *simBase) SetVelocity(ctx context.Context, linear, angular float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	b.cmd = navigation.Velocity{Linear: linear, Angular: angular}
+	return nil
+}
+
+func (b *simBase) Stop() error {
+	return b.SetVelocity(context.Background(), 0, 0)
+}
+
+func (b *simBase) GetJointStates() ([]control.Joint, error)   { return nil, nil }
+func (b *simBase) SetJointPositions(map[string]float64) error { return nil }
+
+func (b *simBase) IsMoving() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cmd != navigation.Velocity{}
+}
+
+func TestNavigatorDrivesAroundWall(t *testing.T) {
+	grid := wallGrid(t, -1, 4)
+	base := newSimBase(-1.5, 0)
+	cfg := navigation.DefaultConfig()
+	cfg.DWA.MaxSpeed = 1.2
+	cfg.DWA.MaxAccel = 3
+	cfg.DWA.Period = 50 * time.Millisecond
+	nav := navigation.NewNavigator(base, grid, cfg)
+	var _ control.NavigationController = nav
+
+	if _, err := nav.GetCurrentGoal(); !errors.Is(err, navigation.ErrNoGoal) {
+		t.Fatalf("GetCurrentGoal before SetGoal: got %v, want ErrNoGoal", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	if err := nav.SetGoal(ctx, control.Pose{Position: control.Vector3{X: 0, Y: 2}}); !errors.Is(err, navigation.ErrBlocked) {
+		t.Fatalf("SetGoal inside the wall: got %v, want ErrBlocked", err)
+	}
+
+	goal := control.Pose{Position: control.Vector3{X: 1.5, Y: 0}, Orientation: control.Quaternion{W: 1}}
+	if err := nav.SetGoal(ctx, goal); err != nil {
+		t.Fatalf("SetGoal: %v", err)
+	}
+	status := nav.GetNavigationStatus()
+	if !status.Active || math.Abs(status.DistanceToGoal-3) > 0.1 || status.EstimatedTime <= 0 {
+		t.Fatalf("status after SetGoal = %+v", status)
+	}
+	if path := nav.Path(); len(path) < 2 {
+		t.Fatalf("path has %d points", len(path))
+	}
+	if err := nav.Wait(ctx); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if !nav.IsGoalReached() || nav.GetNavigationStatus().Active {
+		t.Fatal("goal not reported reached")
+	}
+
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	end := base.pose
+	if d := math.Hypot(end.X-1.5, end.Y); d > cfg.GoalTolerance+0.05 {
+		t.Errorf("robot stopped %.2f m from the goal", d)
+	}
+	for _, p := range base.trail {
+		if c := grid.Clearance(p.X, p.Y); c < cfg.RobotRadius*0.8 {
+			t.Fatalf("robot passed %.2f m from the wall at (%.2f, %.2f)", c, p.X, p.Y)
+		}
+	}
+	if base.cmd != (navigation.Velocity{}) {
+		t.Errorf("robot left moving at %+v", base.cmd)
+	}
+}
+
+func TestNavigatorCancel(t *testing.T) {
+	grid := navigation.NewOccupancyGrid(smallGridConfig())
+	base := newSimBase(0, 0)
+	nav := navigation.NewNavigator(base, grid, navigation.DefaultConfig())
+
+	// A walker crossing ahead is avoided but not mapped.
+	err := nav.UpdateScan(&perception.ScanResult360{
+		Timestamp: time.Now(),
+		Objects: []perception.TrackedObject{
+			{ID: "walker", Position: perception.Vector3{X: 1}, Velocity: perception.Vector3{Y: 0.5}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateScan: %v", err)
+	}
+	if c, _ := grid.WorldToCell(1, 0); grid.State(c) == navigation.Occupied {
+		t.Fatal("moving object was mapped")
+	}
+
+	ctx := context.Background()
+	if err := nav.SetGoal(ctx, control.Pose{Position: control.Vector3{X: 4}}); err != nil {
+		t.Fatalf("SetGoal: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if err := nav.CancelGoal(); err != nil {
+		t.Fatalf("CancelGoal: %v", err)
+	}
+	if err := nav.Wait(ctx); !errors.Is(err, navigation.ErrGoalCancelled) {
+		t.Fatalf("Wait after cancel: got %v, want ErrGoalCancelled", err)
+	}
+	if nav.IsGoalReached() || nav.GetNavigationStatus().Active {
+		t.Fatal("cancelled goal reported active or reached")
+	}
+	if goal, err := nav.GetCurrentGoal(); err != nil || goal.Position.X != 4 {
+		t.Fatalf("GetCurrentGoal after cancel = %+v, %v", goal, err)
+	}
+}
+
+// scanFeed is a robot perception source that sees one static crate.
+type scanFeed struct {
+	mu    sync.Mutex
+	calls int
+	fail  bool
+}
+
+func (f *scanFeed) GetScan() (*perception.ScanResult360, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail {
+		return nil, errors.New("perception offline")
+	}
+	return &perception.ScanResult360{
+		Timestamp: time.Now(),
+		Objects: []perception.TrackedObject{
+			{ID: "crate", Position: perception.Vector3{X: 2}},
+		},
+	}, nil
+}
+
+func TestNavigatorFollowsScans(t *testing.T) {
+	grid := navigation.NewOccupancyGrid(smallGridConfig())
+	base := newSimBase(0, 0)
+	nav := navigation.NewNavigator(base, grid, navigation.DefaultConfig())
+	feed := &scanFeed{fail: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		nav.FollowScans(ctx, feed, 5*time.Millisecond)
+		close(done)
+	}()
+
+	// Failures do not stop the feed
+	time.Sleep(30 * time.Millisecond)
+	feed.mu.Lock()
+	feed.fail = false
+	feed.mu.Unlock()
+
+	crate, _ := grid.WorldToCell(2, 0)
+	deadline := time.Now().Add(2 * time.Second)
+	for grid.State(crate) != navigation.Occupied {
+		if time.Now().After(deadline) {
+			t.Fatal("crate seen in the scans was never mapped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("FollowScans did not stop with its context")
+	}
+}
@@ -0,0 +1,276 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/orbital/hal"
+	"github.com/asgard/pandora/internal/orbital/od"
+	"github.com/asgard/pandora/internal/platform/dtn"
+	"github.com/asgard/pandora/internal/platform/frames"
+	"github.com/asgard/pandora/internal/platform/satellite"
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+var odSpacecraft = orbital.Spacecraft{
+	Mass:                    12,
+	DragArea:                0.06,
+	DragCoefficient:         2.2,
+	SRPArea:                 0.06,
+	ReflectivityCoefficient: 1.3,
+}
+
+// truthEphemeris flies a 500 km sun-synchronous orbit under the full force
+// model.
+func truthEphemeris(t *testing.T, span, step time.Duration) ([]orbital.StateVector, *orbital.NumericalPropagator) {
+	t.Helper()
+	prop := orbital.NewNumericalPropagator(orbital.StandardForceModel(odSpacecraft), orbital.DefaultNumericalConfig())
+	states, err := prop.Ephemeris(circularState(6878137, 97.4), orbitalEpoch.Add(span), step)
+	if err != nil {
+		t.Fatalf("truth ephemeris: %v", err)
+	}
+	return states, prop
+}
+
+// gpsFix renders a truth state as the receiver would report it, with
+// Earth-fixed position noise of sigma meters per axis.
+func gpsFix(s orbital.StateVector, rng *rand.Rand, sigma float64) (hal.GPSPosition, hal.GPSVelocity) {
+	r, v := frames.GCRFToITRF(
+		[3]float64{s.Position.X / 1000, s.Position.Y / 1000, s.Position.Z / 1000},
+		[3]float64{s.Velocity.X / 1000, s.Velocity.Y / 1000, s.Velocity.Z / 1000},
+		s.Timestamp, frames.EOP{})
+	for k := range r {
+		r[k] += rng.NormFloat64() * sigma / 1000
+	}
+	g := frames.ECEFToGeodetic(r)
+	pos := hal.GPSPosition{
+		Latitude:      g.Latitude,
+		Longitude:     g.Longitude,
+		Altitude:      g.Altitude * 1000,
+		HDOP:          1,
+		VDOP:          1.5,
+		NumSatellites: 8,
+		FixType:       "GPS",
+		Timestamp:     s.Timestamp,
+	}
+	vel := hal.GPSVelocity{VX: v[0] * 1000, VY: v[1] * 1000, VZ: v[2] * 1000, Timestamp: s.Timestamp}
+	return pos, vel
+}
+
+func TestOrbitDeterminationFromGPSFixes(t *testing.T) {
+	truth, prop := truthEphemeris(t, 90*time.Minute, 30*time.Second)
+	rng := rand.New(rand.NewSource(18))
+	fixCfg := od.DefaultFixConfig()
+
+	var obs []od.Observation
+	for i, s := range truth {
+		pos, _ := gpsFix(s, rng, 5)
+		if i == 40 {
+			pos.Altitude += 2000 // A multipath outlier
+		}
+		o, err := od.ObservationFromGPS(pos, hal.GPSVelocity{}, fixCfg)
+		if err != nil {
+			t.Fatalf("ObservationFromGPS: %v", err)
+		}
+		if o.HasVelocity {
+			t.Fatal("NMEA fix without ECEF velocity produced a velocity observation")
+		}
+		obs = append(obs, o)
+	}
+
+	cfg := od.DefaultConfig()
+	cfg.Spacecraft = odSpacecraft
+	determiner := od.NewDeterminer(satellite.OMM{
+		ObjectName: "SILENUS-1",
+		ObjectID:   "2026-001A",
+		NoradCatID: 99001,
+	}, cfg)
+	if _, err := determiner.Determine(obs[:10]); err == nil {
+		t.Error("Determine accepted a five minute arc")
+	}
+	est, err := determiner.Determine(obs)
+	if err != nil {
+		t.Fatalf("Determine: %v", err)
+	}
+	if est.Rejected != 1 || est.Used != len(obs)-1 {
+		t.Errorf("used %d and rejected %d fixes, want the outlier edited", est.Used, est.Rejected)
+	}
+	if est.RMS > 1.5 {
+		t.Errorf("weighted RMS = %.2f, want about 1", est.RMS)
+	}
+
+	last := truth[len(truth)-1]
+	if !est.State.Timestamp.Equal(last.Timestamp) {
+		t.Fatalf("solution epoch %s, want the last fix %s", est.State.Timestamp, last.Timestamp)
+	}
+	posErr := est.State.Position.Sub(last.Position).Magnitude()
+	velErr := est.State.Velocity.Sub(last.Velocity).Magnitude()
+	sigma := est.Covariance.PositionSigma()
+	if posErr > 15 || posErr > 4*sigma {
+		t.Errorf("position error %.2f m with formal sigma %.2f m", posErr, sigma)
+	}
+	if velErr > 0.05 {
+		t.Errorf("velocity error %.4f m/s", velErr)
+	}
+
+	// The fitted mean elements track the truth for the next few hours
+	tle := est.Elements.TLE
+	if tle.SatelliteID != 99001 || tle.Name != "SILENUS-1" || !tle.Epoch.Equal(last.Timestamp) {
+		t.Errorf("fitted TLE identity = %d %q %s", tle.SatelliteID, tle.Name, tle.Epoch)
+	}
+	if est.Elements.RMS > 1000 {
+		t.Errorf("mean elements fit the ephemeris to %.0f m", est.Elements.RMS)
+	}
+	sgp4, err := satellite.NewPropagator(tle)
+	if err != nil {
+		t.Fatalf("fitted TLE does not propagate: %v\n%s\n%s", err, tle.Line1, tle.Line2)
+	}
+	later, err := prop.Propagate(last, last.Timestamp.Add(6*time.Hour))
+	if err != nil {
+		t.Fatalf("Propagate: %v", err)
+	}
+	predicted, err := sgp4.PropagateState(later.Timestamp)
+	if err != nil {
+		t.Fatalf("PropagateState: %v", err)
+	}
+	r, _ := frames.TEMEToGCRF(predicted.Position, predicted.Velocity, later.Timestamp, frames.EOP{})
+	miss := orbital.Vector3{X: r[0] * 1000, Y: r[1] * 1000, Z: r[2] * 1000}.Sub(later.Position).Magnitude()
+	if miss > 2000 {
+		t.Errorf("fitted TLE is %.1f km off the truth six hours on", miss/1000)
+	}
+
+	// The estimate downlinks as JSON and feeds the ground contact predictor
+	data, err := json.Marshal(est)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var downlinked od.Estimate
+	if err := json.Unmarshal(data, &downlinked); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if downlinked.Elements.TLE.Line2 != tle.Line2 || downlinked.Covariance != est.Covariance {
+		t.Error("estimate does not survive the JSON round trip")
+	}
+	predictor := dtn.NewContactPredictor(dtn.DefaultContactPredictorConfig())
+	if err := predictor.LoadTLE(99001, downlinked.Elements.TLE); err != nil {
+		t.Fatalf("LoadTLE: %v", err)
+	}
+
+	// A second fit advances the element set number
+	again, err := determiner.Determine(obs)
+	if err != nil {
+		t.Fatalf("Determine: %v", err)
+	}
+	if again.Elements.OMM.ElementSetNo != est.Elements.OMM.ElementSetNo+1 {
+		t.Errorf("element set %d after %d", again.Elements.OMM.ElementSetNo, est.Elements.OMM.ElementSetNo)
+	}
+}
+
+func TestOrbitDeterminationEKF(t *testing.T) {
+	truth, prop := truthEphemeris(t, 60*time.Minute, 30*time.Second)
+	rng := rand.New(rand.NewSource(7))
+
+	start := truth[0]
+	initial := start
+	initial.Position = initial.Position.Add(orbital.Vector3{X: 150, Y: -100, Z: 80})
+	initial.Velocity = initial.Velocity.Add(orbital.Vector3{X: 0.1, Z: -0.15})
+	filter := od.NewEKF(prop, initial, od.DiagonalCovariance(300, 0.3), od.DefaultEKFConfig())
+
+	fixCfg := od.DefaultFixConfig()
+	for i, s := range truth[1:] {
+		pos, vel := gpsFix(s, rng, 5)
+		if i == 20 {
+			pos.Latitude += 0.1 // Roughly 11 km
+		}
+		o, err := od.ObservationFromGPS(pos, vel, fixCfg)
+		if err != nil {
+			t.Fatalf("ObservationFromGPS: %v", err)
+		}
+		if !o.HasVelocity {
+			t.Fatal("fix with ECEF velocity produced a position-only observation")
+		}
+		if _, err := filter.Update(o); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	accepted, rejected := filter.Counts()
+	if rejected != 1 || accepted != len(truth)-2 {
+		t.Errorf("accepted %d and rejected %d fixes, want only the outlier gated", accepted, rejected)
+	}
+
+	state, cov := filter.State()
+	last := truth[len(truth)-1]
+	posErr := state.Position.Sub(last.Position).Magnitude()
+	velErr := state.Velocity.Sub(last.Velocity).Magnitude()
+	if posErr > 10 || velErr > 0.05 {
+		t.Errorf("filter error %.2f m, %.4f m/s", posErr, velErr)
+	}
+	if cov.PositionSigma() > 10 || cov.PositionSigma() >= od.DiagonalCovariance(300, 0.3).PositionSigma() {
+		t.Errorf("position sigma %.2f m did not converge", cov.PositionSigma())
+	}
+
+	// Prediction grows the covariance
+	if err := filter.Predict(last.Timestamp.Add(30 * time.Minute)); err != nil {
+		t.Fatalf("Predict: %v", err)
+	}
+	if _, grown := filter.State(); grown.PositionSigma() <= cov.PositionSigma() {
+		t.Errorf("covariance did not grow over a prediction: %.2f m to %.2f m", cov.PositionSigma(), grown.PositionSigma())
+	}
+}
+
+type fakeGPS struct {
+	pos hal.GPSPosition
+	vel hal.GPSVelocity
+}
+
+func (f *fakeGPS) GetFullPosition() hal.GPSPosition { return f.pos }
+func (f *fakeGPS) GetFullVelocity() hal.GPSVelocity { return f.vel }
+
+func TestOrbitDeterminationFixCollector(t *testing.T) {
+	truth, _ := truthEphemeris(t, 2*time.Minute, 30*time.Second)
+	rng := rand.New(rand.NewSource(1))
+	gps := &fakeGPS{}
+	collector := od.NewFixCollector(gps, od.DefaultFixConfig(), 3)
+
+	if collector.Sample() {
+		t.Error("sampled a receiver without a fix")
+	}
+	for _, s := range truth {
+		gps.pos, gps.vel = gpsFix(s, rng, 0)
+		if !collector.Sample() {
+			t.Fatalf("fix at %s not recorded", s.Timestamp)
+		}
+		if collector.Sample() {
+			t.Error("the same fix was recorded twice")
+		}
+	}
+	fixes := collector.Observations()
+	if len(fixes) != 3 || !fixes[2].Time.Equal(truth[len(truth)-1].Timestamp) {
+		t.Fatalf("collector kept %d fixes, want the latest 3", len(fixes))
+	}
+	for i, o := range fixes {
+		want := truth[len(truth)-3+i]
+		if d := o.Position.Sub(want.Position).Magnitude(); d > 0.01 {
+			t.Errorf("fix %d is %.3f m from the truth", i, d)
+		}
+		if d := o.Velocity.Sub(want.Velocity).Magnitude(); d > 1e-4 {
+			t.Errorf("fix %d velocity is %.5f m/s from the truth", i, d)
+		}
+	}
+
+	gps.pos.Timestamp = gps.pos.Timestamp.Add(time.Second)
+	gps.pos.NumSatellites = 3
+	if collector.Sample() {
+		t.Error("recorded a fix from three satellites")
+	}
+	gps.pos.NumSatellites, gps.pos.FixType = 8, "none"
+	if collector.Sample() {
+		t.Error("recorded a fix without a solution")
+	}
+	if math.IsNaN(fixes[0].PositionSigma) || fixes[0].PositionSigma <= 0 {
+		t.Errorf("position sigma = %g", fixes[0].PositionSigma)
+	}
+}
@@ -0,0 +1,299 @@
+package integration_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/frames"
+	"github.com/asgard/pandora/internal/simulation/orbital"
+)
+
+var orbitalEpoch = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// circularState returns a circular orbit of radius r meters at inclination
+// inc degrees, starting on the ascending node along the x axis.
+func circularState(r, inc float64) orbital.StateVector {
+	v := math.Sqrt(orbital.EarthMu / r)
+	sinI, cosI := math.Sincos(inc * math.Pi / 180)
+	return orbital.StateVector{
+		Position:  orbital.Vector3{X: r},
+		Velocity:  orbital.Vector3{Y: v * cosI, Z: v * sinI},
+		Timestamp: orbitalEpoch,
+	}
+}
+
+func gravityField(t *testing.T, degree, order int) *orbital.GravityField {
+	t.Helper()
+	field, err := orbital.NewGravityField(orbital.EGM96(), degree, order)
+	if err != nil {
+		t.Fatalf("NewGravityField: %v", err)
+	}
+	field.GM = orbital.EarthMu
+	return field
+}
+
+func TestOrbitalGravityField(t *testing.T) {
+	model := orbital.EGM96()
+	field, err := orbital.NewGravityField(model, 2, 0)
+	if err != nil {
+		t.Fatalf("NewGravityField: %v", err)
+	}
+
+	// Degree 2 order 0 is the classical J2 acceleration about the ITRF pole
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	r := orbital.Vector3{X: 4000e3, Y: -3000e3, Z: 4500e3}
+	rot := frames.GCRFToITRFMatrix(at, frames.EOP{})
+	b := rot.MulVec([3]float64{r.X, r.Y, r.Z})
+	j2 := -model.C[2][0] * math.Sqrt(5)
+	rr := r.Magnitude()
+	z2 := b[2] * b[2] / (rr * rr)
+	f := -model.GM / (rr * rr * rr)
+	k := 1.5 * j2 * (model.Radius / rr) * (model.Radius / rr)
+	want := rot.T().MulVec([3]float64{
+		f * b[0] * (1 + k*(1-5*z2)),
+		f * b[1] * (1 + k*(1-5*z2)),
+		f * b[2] * (1 + k*(3-5*z2)),
+	})
+	got := field.Acceleration(at, r, orbital.Vector3{})
+	if d := got.Sub(orbital.Vector3{X: want[0], Y: want[1], Z: want[2]}).Magnitude(); d > 1e-12*got.Magnitude() {
+		t.Errorf("J2 acceleration differs from the closed form by %.3e m/s²", d)
+	}
+
+	// Tesserals through 4x4 perturb LEO by tens of µm/s²
+	full, err := orbital.NewGravityField(model, 4, 4)
+	if err != nil {
+		t.Fatalf("NewGravityField 4x4: %v", err)
+	}
+	if d := full.Acceleration(at, r, orbital.Vector3{}).Sub(got).Magnitude(); d < 1e-6 || d > 1e-3 {
+		t.Errorf("4x4 terms beyond J2 contribute %.3e m/s²", d)
+	}
+	if _, err := orbital.NewGravityField(model, 5, 0); err == nil {
+		t.Error("expected an error beyond the built-in model's degree")
+	}
+
+	const gfc = `product_type gravity_field
+modelname EGM96
+earth_gravity_constant 0.3986004415E+15
+radius 0.63781363E+07
+max_degree 3
+norm fully_normalized
+end_of_head
+gfc 2 0 -0.484165371736D-03 0.0 0 0
+gfc 2 2 0.243914352398E-05 -0.140016683654E-05 0 0
+gfc 3 3 0.721072657057E-06 0.141435626958E-05 0 0
+`
+	read, err := orbital.ReadGravityModel(strings.NewReader(gfc), 2)
+	if err != nil {
+		t.Fatalf("ReadGravityModel: %v", err)
+	}
+	if read.MaxDegree != 2 || read.GM != model.GM || read.Radius != model.Radius {
+		t.Errorf("model header = %s %d %g %g", read.Name, read.MaxDegree, read.GM, read.Radius)
+	}
+	if read.C[2][0] != model.C[2][0] || read.S[2][2] != model.S[2][2] {
+		t.Errorf("coefficients C20 %g S22 %g", read.C[2][0], read.S[2][2])
+	}
+}
+
+func TestOrbitalNumericalTwoBody(t *testing.T) {
+	prop := orbital.NewNumericalPropagator(gravityField(t, 0, 0), orbital.DefaultNumericalConfig())
+
+	// An eccentric orbit reaches apoapsis after half a period
+	a, e := 8000e3, 0.1
+	rp := a * (1 - e)
+	initial := orbital.StateVector{
+		Position:  orbital.Vector3{X: rp},
+		Velocity:  orbital.Vector3{Y: math.Sqrt(orbital.EarthMu * (1 + e) / rp)},
+		Timestamp: orbitalEpoch,
+	}
+	period := 2 * math.Pi * math.Sqrt(a*a*a/orbital.EarthMu)
+	half := orbitalEpoch.Add(time.Duration(period / 2 * float64(time.Second)))
+	apo, err := prop.Propagate(initial, half)
+	if err != nil {
+		t.Fatalf("Propagate: %v", err)
+	}
+	if d := apo.Position.Sub(orbital.Vector3{X: -a * (1 + e)}).Magnitude(); d > 0.1 {
+		t.Errorf("apoapsis off by %.3f m", d)
+	}
+
+	// Propagating back returns to the start
+	back, err := prop.Propagate(apo, orbitalEpoch)
+	if err != nil {
+		t.Fatalf("Propagate backwards: %v", err)
+	}
+	if d := back.Position.Sub(initial.Position).Magnitude(); d > 0.1 {
+		t.Errorf("round trip off by %.3f m", d)
+	}
+
+	// Energy is conserved along an ephemeris
+	states, err := prop.Ephemeris(initial, orbitalEpoch.Add(6*time.Hour), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Ephemeris: %v", err)
+	}
+	if len(states) != 37 || !states[36].Timestamp.Equal(orbitalEpoch.Add(6*time.Hour)) {
+		t.Fatalf("ephemeris has %d states", len(states))
+	}
+	energy := func(s orbital.StateVector) float64 {
+		v := s.Velocity.Magnitude()
+		return v*v/2 - orbital.EarthMu/s.Position.Magnitude()
+	}
+	for i, s := range states {
+		if d := math.Abs(energy(s)/energy(initial) - 1); d > 1e-9 {
+			t.Errorf("state %d: relative energy error %.2e", i, d)
+		}
+	}
+}
+
+func TestOrbitalJ2NodalRegression(t *testing.T) {
+	field := gravityField(t, 2, 0)
+	prop := orbital.NewNumericalPropagator(field, orbital.DefaultNumericalConfig())
+
+	r, inc := 6778e3, 51.6
+	initial := circularState(r, inc)
+	end := orbitalEpoch.Add(24 * time.Hour)
+	final, err := prop.Propagate(initial, end)
+	if err != nil {
+		t.Fatalf("Propagate: %v", err)
+	}
+
+	node := func(s orbital.StateVector) float64 {
+		h := s.Position.Cross(s.Velocity)
+		return math.Atan2(h.X, -h.Y)
+	}
+	drift := node(final) - node(initial)
+
+	j2 := -orbital.EGM96().C[2][0] * math.Sqrt(5)
+	n := math.Sqrt(orbital.EarthMu / (r * r * r))
+	want := -1.5 * n * j2 * (field.Radius / r) * (field.Radius / r) * math.Cos(inc*math.Pi/180) * 86400
+	if math.Abs(drift/want-1) > 0.02 {
+		t.Errorf("node drifted %.4f°/day, want %.4f°/day", drift*180/math.Pi, want*180/math.Pi)
+	}
+}
+
+func TestOrbitalStateTransitionMatrix(t *testing.T) {
+	prop := orbital.NewNumericalPropagator(gravityField(t, 2, 0), orbital.DefaultNumericalConfig())
+	initial := circularState(7000e3, 45)
+	end := orbitalEpoch.Add(time.Hour)
+
+	nominal, stm, err := prop.PropagateWithSTM(initial, end)
+	if err != nil {
+		t.Fatalf("PropagateWithSTM: %v", err)
+	}
+	plain, err := prop.Propagate(initial, end)
+	if err != nil {
+		t.Fatalf("Propagate: %v", err)
+	}
+	if d := nominal.Position.Sub(plain.Position).Magnitude(); d > 1e-3 {
+		t.Errorf("propagating the STM moved the trajectory by %.3e m", d)
+	}
+
+	delta := [6]float64{10, -5, 3, 0.01, 0, -0.005}
+	perturbed := initial
+	perturbed.Position = perturbed.Position.Add(orbital.Vector3{X: delta[0], Y: delta[1], Z: delta[2]})
+	perturbed.Velocity = perturbed.Velocity.Add(orbital.Vector3{X: delta[3], Y: delta[4], Z: delta[5]})
+	actual, err := prop.Propagate(perturbed, end)
+	if err != nil {
+		t.Fatalf("Propagate perturbed: %v", err)
+	}
+
+	var predicted [6]float64
+	for i := range predicted {
+		for j := range delta {
+			predicted[i] += stm[i][j] * delta[j]
+		}
+	}
+	dr := actual.Position.Sub(plain.Position)
+	miss := dr.Sub(orbital.Vector3{X: predicted[0], Y: predicted[1], Z: predicted[2]}).Magnitude()
+	if miss > 1e-3*dr.Magnitude() {
+		t.Errorf("STM predicted %.3f m of %.3f m displacement to within %.3e m", math.Sqrt(predicted[0]*predicted[0]+predicted[1]*predicted[1]+predicted[2]*predicted[2]), dr.Magnitude(), miss)
+	}
+}
+
+func TestOrbitalDragDecay(t *testing.T) {
+	sc := orbital.Spacecraft{Mass: 100, DragArea: 1, DragCoefficient: 2.2}
+	drag := &orbital.AtmosphericDrag{Spacecraft: sc, Atmosphere: orbital.ExponentialAtmosphere{}}
+	prop := orbital.NewNumericalPropagator(orbital.ForceModels{gravityField(t, 0, 0), drag}, orbital.DefaultNumericalConfig())
+
+	// Equatorial, so the geodetic altitude stays put
+	const req = 6378137.0
+	r := req + 400e3
+	initial := circularState(r, 0)
+	end := orbitalEpoch.Add(6 * time.Hour)
+	final, err := prop.Propagate(initial, end)
+	if err != nil {
+		t.Fatalf("Propagate: %v", err)
+	}
+	sma := func(s orbital.StateVector) float64 {
+		v := s.Velocity.Magnitude()
+		return 1 / (2/s.Position.Magnitude() - v*v/orbital.EarthMu)
+	}
+	got := sma(final) - sma(initial)
+
+	// da/dt = -B ρ a v_rel²/v for a circular orbit in a rotating atmosphere
+	v := math.Sqrt(orbital.EarthMu / r)
+	vRel := v - orbital.EarthRotationRate*r
+	b := sc.DragCoefficient * sc.DragArea / sc.Mass
+	want := -b * orbital.ExponentialDensity(400) * r * vRel * vRel / v * end.Sub(orbitalEpoch).Seconds()
+	if got >= 0 || math.Abs(got/want-1) > 0.05 {
+		t.Errorf("semi-major axis changed %.1f m in 6 h, want %.1f m", got, want)
+	}
+
+	low := circularState(req+150e3, 0)
+	states, err := prop.Ephemeris(low, low.Timestamp.Add(7*24*time.Hour), time.Hour)
+	if !errors.Is(err, orbital.ErrDecayed) {
+		t.Fatalf("Ephemeris from 150 km: %v, want ErrDecayed", err)
+	}
+	if len(states) < 2 || len(states) > 7*24 {
+		t.Errorf("decayed after %d hourly states", len(states))
+	}
+
+	// The full model keeps a 400 km orbit up for a day
+	full := orbital.NewNumericalPropagator(orbital.StandardForceModel(sc), orbital.DefaultNumericalConfig())
+	if _, err := full.Propagate(circularState(r, 51.6), orbitalEpoch.Add(24*time.Hour)); err != nil {
+		t.Errorf("Propagate with the standard force model: %v", err)
+	}
+}
+
+func TestOrbitalSurfaceAndThirdBodyForces(t *testing.T) {
+	at := time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)
+	sun := orbital.SunPosition(at)
+	toSun := sun.Normalize()
+
+	srp := &orbital.SolarRadiationPressure{Spacecraft: orbital.Spacecraft{Mass: 100, SRPArea: 1, ReflectivityCoefficient: 1.5}}
+	lit := srp.Acceleration(at, toSun.Scale(7000e3), orbital.Vector3{})
+	want := orbital.SolarPressure1AU * 1.5 * 1 / 100
+	if d := lit.Magnitude(); math.Abs(d/want-1) > 0.05 {
+		t.Errorf("SRP acceleration %.3e m/s², want about %.3e", d, want)
+	}
+	if lit.Dot(toSun) >= 0 {
+		t.Error("SRP pushes towards the Sun")
+	}
+	if shade := srp.Acceleration(at, toSun.Scale(-7000e3), orbital.Vector3{}); shade.Magnitude() != 0 {
+		t.Errorf("SRP in the Earth's shadow = %.3e m/s²", shade.Magnitude())
+	}
+
+	moon := orbital.MoonPosition(at)
+	if d := moon.Magnitude() / 1000; d < 356000 || d > 407000 {
+		t.Errorf("Moon distance %.0f km", d)
+	}
+
+	// Along the line to each body the tidal acceleration is about 2μr/d³
+	for _, body := range []struct {
+		name  string
+		force *orbital.ThirdBody
+		pos   orbital.Vector3
+	}{
+		{"Sun", orbital.SunGravity(), sun},
+		{"Moon", orbital.MoonGravity(), moon},
+	} {
+		r := 42164e3
+		d := body.pos.Magnitude()
+		want := 2 * body.force.Mu * r / (d * d * d)
+		got := body.force.Acceleration(at, body.pos.Normalize().Scale(r), orbital.Vector3{})
+		if math.Abs(got.Magnitude()/want-1) > 0.2 {
+			t.Errorf("%s tidal acceleration at GEO %.3e m/s², want about %.3e", body.name, got.Magnitude(), want)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package integration_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/robotics/control"
+	"github.com/asgard/pandora/internal/robotics/kinematics"
+	"github.com/asgard/pandora/internal/robotics/rtde"
+)
+
+var fakeRTDETypes = map[string]string{
+	"timestamp":        "DOUBLE",
+	"actual_q":         "VECTOR6D",
+	"actual_TCP_pose":  "VECTOR6D",
+	"actual_TCP_force": "VECTOR6D",
+	"robot_mode":       "INT32",
+	"safety_mode":      "INT32",
+	"runtime_state":    "UINT32",
+}
+
+// fakeRTDE is a UR controller speaking RTDE version 2. It checks the
+// framing and order of everything the client sends, and tracks servo
+// setpoints perfectly.
+type fakeRTDE struct {
+	t        *testing.T
+	ln       net.Listener
+	scriptLn net.Listener
+
+	rejectVersion bool
+	missing       string // Output variable to report as NOT_FOUND
+
+	mu        sync.Mutex
+	frequency float64
+	q         [6]float64
+	safety    int32
+	playing   bool
+	silent    bool // Stop streaming data packages
+	outputs   []string
+	inputs    []string
+	setpoints [][6]float64
+	modes     []int32
+	ticks     []int32
+	scripts   []string
+}
+
+func newFakeRTDE(t *testing.T, q [6]float64) *fakeRTDE {
+	t.Helper()
+	f := &fakeRTDE{t: t, q: q, safety: rtde.SafetyNormal}
+	var err error
+	if f.ln, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if f.scriptLn, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go f.serve()
+	go f.serveScripts()
+	t.Cleanup(func() {
+		f.ln.Close()
+		f.scriptLn.Close()
+	})
+	return f
+}
+
+func (f *fakeRTDE) config() rtde.Config {
+	cfg := rtde.DefaultConfig("127.0.0.1")
+	cfg.Port = f.ln.Addr().(*net.TCPAddr).Port
+	cfg.ScriptPort = f.scriptLn.Addr().(*net.TCPAddr).Port
+	cfg.Frequency = 500
+	return cfg
+}
+
+func (f *fakeRTDE) serveScripts() {
+	for {
+		conn, err := f.scriptLn.Accept()
+		if err != nil {
+			return
+		}
+		script, _ := io.ReadAll(conn)
+		conn.Close()
+		f.mu.Lock()
+		f.scripts = append(f.scripts, string(script))
+		f.playing = strings.Contains(string(script), "servoj(")
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeRTDE) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.session(conn)
+	}
+}
+
+func writeFakePackage(w io.Writer, typ byte, payload []byte) error {
+	buf := make([]byte, 3, 3+len(payload))
+	binary.BigEndian.PutUint16(buf, uint16(3+len(payload)))
+	buf[2] = typ
+	_, err := w.Write(append(buf, payload...))
+	return err
+}
+
+func (f *fakeRTDE) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var writeMu sync.Mutex
+	send := func(typ byte, payload []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return writeFakePackage(conn, typ, payload)
+	}
+
+	expect := []byte{'V', 'v', 'O', 'I', 'S'}
+	stop := make(chan struct{})
+	defer close(stop)
+	for {
+		var header [3]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+		size := int(binary.BigEndian.Uint16(header[:2]))
+		if size < 3 {
+			f.t.Errorf("package size %d smaller than its header", size)
+			return
+		}
+		payload := make([]byte, size-3)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			f.t.Errorf("short package %c: %v", header[2], err)
+			return
+		}
+		typ := header[2]
+		if len(expect) > 0 {
+			if typ != expect[0] {
+				f.t.Errorf("got package %c during setup, want %c", typ, expect[0])
+				return
+			}
+			expect = expect[1:]
+		}
+
+		switch typ {
+		case 'V':
+			if len(payload) != 2 || binary.BigEndian.Uint16(payload) != 2 {
+				f.t.Errorf("protocol version request % x", payload)
+			}
+			accepted := byte(1)
+			if f.rejectVersion {
+				accepted = 0
+			}
+			send('V', []byte{accepted})
+		case 'v':
+			if len(payload) != 0 {
+				f.t.Errorf("controller version request carries %d bytes", len(payload))
+			}
+			var version []byte
+			for _, n := range []uint32{5, 15, 0, 123} {
+				version = binary.BigEndian.AppendUint32(version, n)
+			}
+			// A text message in between must be skipped by the client
+			send('M', append(append([]byte{5}, "hello"...), append([]byte{3}, "fak"...)...))
+			send('v', version)
+		case 'O':
+			if len(payload) < 8 {
+				f.t.Errorf("output setup without a frequency")
+				return
+			}
+			f.mu.Lock()
+			f.frequency = math.Float64frombits(binary.BigEndian.Uint64(payload))
+			f.outputs = strings.Split(string(payload[8:]), ",")
+			f.mu.Unlock()
+			types := make([]string, len(f.outputs))
+			for i, name := range f.outputs {
+				if typ, ok := fakeRTDETypes[name]; ok && name != f.missing {
+					types[i] = typ
+				} else {
+					types[i] = "NOT_FOUND"
+				}
+			}
+			send('O', append([]byte{1}, strings.Join(types, ",")...))
+		case 'I':
+			f.inputs = strings.Split(string(payload), ",")
+			types := make([]string, len(f.inputs))
+			for i, name := range f.inputs {
+				switch {
+				case strings.HasPrefix(name, "input_int_register_"):
+					types[i] = "INT32"
+				case strings.HasPrefix(name, "input_double_register_"):
+					types[i] = "DOUBLE"
+				default:
+					types[i] = "NOT_FOUND"
+				}
+			}
+			send('I', append([]byte{2}, strings.Join(types, ",")...))
+		case 'S':
+			send('S', []byte{1})
+			go f.stream(send, stop)
+		case 'U':
+			f.input(payload)
+		case 'P':
+			send('P', []byte{1})
+		default:
+			f.t.Errorf("unexpected package %c", typ)
+		}
+	}
+}
+
+// input applies a data package for the input recipe: mode, tick and six
+// joint setpoints.
+func (f *fakeRTDE) input(payload []byte) {
+	if len(payload) != 1+4+4+6*8 || payload[0] != 2 {
+		f.t.Errorf("input data package of %d bytes for recipe %d", len(payload), payload[0])
+		return
+	}
+	mode := int32(binary.BigEndian.Uint32(payload[1:]))
+	tick := int32(binary.BigEndian.Uint32(payload[5:]))
+	var q [6]float64
+	for i := range q {
+		q[i] = math.Float64frombits(binary.BigEndian.Uint64(payload[9+8*i:]))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modes = append(f.modes, mode)
+	f.ticks = append(f.ticks, tick)
+	if mode == 1 {
+		if !f.playing {
+			f.t.Errorf("setpoint sent before the servo program")
+		}
+		f.setpoints = append(f.setpoints, q)
+		if f.safety == rtde.SafetyNormal {
+			f.q = q
+		}
+	}
+}
+
+func (f *fakeRTDE) stream(send func(byte, []byte) error, stop chan struct{}) {
+	ticker := time.NewTicker(2 * time.Millisecond)
+	defer ticker.Stop()
+	start := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		f.mu.Lock()
+		if f.silent {
+			f.mu.Unlock()
+			continue
+		}
+		runtime := uint32(1)
+		if f.playing {
+			runtime = rtde.RuntimePlaying
+		}
+		payload := []byte{1}
+		for _, name := range f.outputs {
+			switch name {
+			case "timestamp":
+				payload = binary.BigEndian.AppendUint64(payload, math.Float64bits(time.Since(start).Seconds()))
+			case "actual_q":
+				for _, v := range f.q {
+					payload = binary.BigEndian.AppendUint64(payload, math.Float64bits(v))
+				}
+			case "actual_TCP_pose":
+				for _, v := range [6]float64{0.4, -0.1, 0.3, 0, math.Pi, 0} {
+					payload = binary.BigEndian.AppendUint64(payload, math.Float64bits(v))
+				}
+			case "actual_TCP_force":
+				for _, v := range [6]float64{1, 2, -9.8, 0, 0, 0.1} {
+					payload = binary.BigEndian.AppendUint64(payload, math.Float64bits(v))
+				}
+			case "robot_mode":
+				payload = binary.BigEndian.AppendUint32(payload, 7) // Running
+			case "safety_mode":
+				payload = binary.BigEndian.AppendUint32(payload, uint32(f.safety))
+			case "runtime_state":
+				payload = binary.BigEndian.AppendUint32(payload, runtime)
+			}
+		}
+		f.mu.Unlock()
+		if send('U', payload) != nil {
+			return
+		}
+	}
+}
+
+func waitForState(t *testing.T, client *rtde.Client) rtde.State {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		state, err := client.State()
+		if err == nil {
+			return state
+		}
+		waiting := errors.Is(err, rtde.ErrNoState) || errors.Is(err, rtde.ErrStaleState)
+		if !waiting || time.Now().After(deadline) {
+			t.Fatalf("State: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+var urHome = [6]float64{0, -math.Pi / 2, math.Pi / 2, -math.Pi / 2, -math.Pi / 2, 0}
+
+func TestRTDEHandshakeAndState(t *testing.T) {
+	fake := newFakeRTDE(t, urHome)
+	client, err := rtde.Dial(context.Background(), fake.config())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	if v := client.Version(); v.String() != "5.15.0.123" {
+		t.Errorf("controller version = %s", v)
+	}
+	fake.mu.Lock()
+	if fake.frequency != 500 {
+		t.Errorf("output frequency = %v", fake.frequency)
+	}
+	if strings.Join(fake.outputs, ",") != strings.Join(rtde.Outputs, ",") {
+		t.Errorf("output recipe = %v", fake.outputs)
+	}
+	fake.mu.Unlock()
+
+	state := waitForState(t, client)
+	if state.JointPositions != urHome {
+		t.Errorf("joints = %v, want %v", state.JointPositions, urHome)
+	}
+	if state.TCPPose[2] != 0.3 || state.TCPForce[2] != -9.8 || state.RobotMode != 7 {
+		t.Errorf("state = %+v", state)
+	}
+	if !state.SafetyOK() || state.RuntimeState == rtde.RuntimePlaying {
+		t.Errorf("safety %d, runtime %d", state.SafetyMode, state.RuntimeState)
+	}
+}
+
+func TestRTDEServoTo(t *testing.T) {
+	fake := newFakeRTDE(t, urHome)
+	cfg := fake.config()
+	client, err := rtde.Dial(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	waitForState(t, client)
+
+	goal := urHome
+	goal[0] += 0.3
+	goal[2] -= 0.2
+	const maxVelocity = 3.0
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.ServoTo(ctx, goal, maxVelocity); err != nil {
+		t.Fatalf("ServoTo: %v", err)
+	}
+
+	// The final idle package may still be in flight
+	deadline := time.Now().Add(time.Second)
+	for {
+		fake.mu.Lock()
+		idle := fake.modes[len(fake.modes)-1] == 0
+		fake.mu.Unlock()
+		if idle || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.scripts) != 1 || !strings.Contains(fake.scripts[0], "read_input_float_register(24)") {
+		t.Fatalf("scripts = %q", fake.scripts)
+	}
+	if fake.q != goal {
+		t.Errorf("arm at %v, want %v", fake.q, goal)
+	}
+	if fake.modes[0] != 0 || fake.modes[len(fake.modes)-1] != 0 {
+		t.Errorf("servo not idle around the move: modes %v", fake.modes)
+	}
+	for i := 1; i < len(fake.ticks); i++ {
+		if fake.ticks[i] == fake.ticks[i-1] {
+			t.Fatalf("watchdog tick repeated at %d", i)
+		}
+	}
+
+	// Setpoints respect the velocity limit at the control rate
+	period := 1 / cfg.Frequency
+	prev := urHome
+	for _, q := range fake.setpoints {
+		for i := range q {
+			if math.Abs(q[i]-prev[i]) > maxVelocity*period*1.01 {
+				t.Fatalf("joint %d jumps %.4f rad in one cycle", i, q[i]-prev[i])
+			}
+		}
+		prev = q
+	}
+}
+
+func TestRTDESafetyStop(t *testing.T) {
+	fake := newFakeRTDE(t, urHome)
+	client, err := rtde.Dial(context.Background(), fake.config())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	waitForState(t, client)
+
+	fake.mu.Lock()
+	fake.safety = rtde.SafetyProtectiveStop
+	fake.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+
+	goal := urHome
+	goal[0] += 1
+	if err := client.ServoTo(context.Background(), goal, 1); !errors.Is(err, rtde.ErrSafetyStop) {
+		t.Errorf("ServoTo in protective stop = %v", err)
+	}
+}
+
+func TestRTDEStaleStateStopsServo(t *testing.T) {
+	fake := newFakeRTDE(t, urHome)
+	client, err := rtde.Dial(context.Background(), fake.config())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	waitForState(t, client)
+
+	// The connection stays open but the controller goes quiet
+	fake.mu.Lock()
+	fake.silent = true
+	fake.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := client.State(); !errors.Is(err, rtde.ErrStaleState) {
+		t.Errorf("State after the stream stopped = %v, want ErrStaleState", err)
+	}
+	goal := urHome
+	goal[0] += 1
+	if err := client.ServoTo(context.Background(), goal, 1); !errors.Is(err, rtde.ErrStaleState) {
+		t.Errorf("ServoTo on stale state = %v, want ErrStaleState", err)
+	}
+
+	fake.mu.Lock()
+	fake.silent = false
+	fake.mu.Unlock()
+	waitForState(t, client)
+}
+
+func TestRTDESetupRejected(t *testing.T) {
+	fake := newFakeRTDE(t, urHome)
+	fake.rejectVersion = true
+	if _, err := rtde.Dial(context.Background(), fake.config()); !errors.Is(err, rtde.ErrProtocolVersion) {
+		t.Errorf("rejected version error = %v", err)
+	}
+
+	fake = newFakeRTDE(t, urHome)
+	fake.missing = "actual_TCP_force"
+	_, err := rtde.Dial(context.Background(), fake.config())
+	if !errors.Is(err, rtde.ErrVariableNotFound) || !strings.Contains(err.Error(), "actual_TCP_force") {
+		t.Errorf("missing variable error = %v", err)
+	}
+}
+
+func TestRTDERealManipulatorReach(t *testing.T) {
+	fake := newFakeRTDE(t, urHome)
+	cfg := fake.config()
+	manip := control.NewRealManipulator(control.ManipulatorConfig{
+		Protocol:         "ur",
+		Address:          cfg.Address,
+		Port:             cfg.Port,
+		ScriptPort:       cfg.ScriptPort,
+		ArmModel:         "ur5e",
+		MaxJointVelocity: 3,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := manip.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	defer manip.Shutdown()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for manip.GetJointStates()[1] != urHome[1] {
+		if time.Now().After(deadline) {
+			t.Fatalf("joint states never arrived: %v", manip.GetJointStates())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if state, err := manip.GetURState(); err != nil || state.TCPForce[2] != -9.8 {
+		t.Errorf("GetURState = %+v, %v", state, err)
+	}
+
+	target := control.Vector3{X: 0.4, Y: -0.2, Z: 0.3}
+	if err := manip.ReachTo(ctx, target); err != nil {
+		t.Fatalf("ReachTo: %v", err)
+	}
+	fake.mu.Lock()
+	q := fake.q
+	fake.mu.Unlock()
+	pose, _ := kinematics.UR5e().Forward(q[:])
+	want := kinematics.Translation(target.X, target.Y, target.Z).Mul(kinematics.RotY(math.Pi))
+	if dp, dr := poseDistance(pose, want); dp > 1e-4 || dr > 1e-3 {
+		t.Errorf("arm ends %.4f m, %.4f rad from the target", dp, dr)
+	}
+}
@@ -0,0 +1,237 @@
+package integration_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+const (
+	issLine1 = "1 25544U 98067A   19343.69339541  .00001764  00000-0  38792-4 0  9991"
+	issLine2 = "2 25544  51.6439 211.2001 0007417  17.6667  85.6398 15.50103472202482"
+)
+
+// The ISS element set above in each OMM encoding
+var issOMMs = map[satellite.CatalogFormat]string{
+	satellite.FormatOMMKVN: `CCSDS_OMM_VERS = 2.0
+CREATION_DATE = 2019-12-10T00:00:00
+ORIGINATOR = 18 SPCS
+OBJECT_NAME = ISS (ZARYA)
+OBJECT_ID = 1998-067A
+CENTER_NAME = EARTH
+REF_FRAME = TEME
+TIME_SYSTEM = UTC
+MEAN_ELEMENT_THEORY = SGP4
+COMMENT element set from the public catalog
+EPOCH = 2019-12-09T16:38:29.363424
+MEAN_MOTION = 15.50103472 [rev/day]
+ECCENTRICITY = .0007417
+INCLINATION = 51.6439 [deg]
+RA_OF_ASC_NODE = 211.2001 [deg]
+ARG_OF_PERICENTER = 17.6667 [deg]
+MEAN_ANOMALY = 85.6398 [deg]
+EPHEMERIS_TYPE = 0
+CLASSIFICATION_TYPE = U
+NORAD_CAT_ID = 25544
+ELEMENT_SET_NO = 999
+REV_AT_EPOCH = 20248
+BSTAR = .38792E-4 [1/ER]
+MEAN_MOTION_DOT = .00001764 [rev/day**2]
+MEAN_MOTION_DDOT = 0 [rev/day**3]
+`,
+	satellite.FormatOMMXML: `<?xml version="1.0" encoding="UTF-8"?>
+<ndm xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<omm id="CCSDS_OMM_VERS" version="2.0">
+<header><CREATION_DATE/><ORIGINATOR/></header>
+<body><segment>
+<metadata><OBJECT_NAME>ISS (ZARYA)</OBJECT_NAME><OBJECT_ID>1998-067A</OBJECT_ID><CENTER_NAME>EARTH</CENTER_NAME><REF_FRAME>TEME</REF_FRAME><TIME_SYSTEM>UTC</TIME_SYSTEM><MEAN_ELEMENT_THEORY>SGP4</MEAN_ELEMENT_THEORY></metadata>
+<data><meanElements><EPOCH>2019-12-09T16:38:29.363424</EPOCH><MEAN_MOTION>15.50103472</MEAN_MOTION><ECCENTRICITY>.0007417</ECCENTRICITY><INCLINATION>51.6439</INCLINATION><RA_OF_ASC_NODE>211.2001</RA_OF_ASC_NODE><ARG_OF_PERICENTER>17.6667</ARG_OF_PERICENTER><MEAN_ANOMALY>85.6398</MEAN_ANOMALY></meanElements>
+<tleParameters><EPHEMERIS_TYPE>0</EPHEMERIS_TYPE><CLASSIFICATION_TYPE>U</CLASSIFICATION_TYPE><NORAD_CAT_ID>25544</NORAD_CAT_ID><ELEMENT_SET_NO>999</ELEMENT_SET_NO><REV_AT_EPOCH>20248</REV_AT_EPOCH><BSTAR>.38792E-4</BSTAR><MEAN_MOTION_DOT>.00001764</MEAN_MOTION_DOT><MEAN_MOTION_DDOT>0</MEAN_MOTION_DDOT></tleParameters></data>
+</segment></body>
+</omm>
+</ndm>
+`,
+	satellite.FormatOMMJSON: `[{"OBJECT_NAME":"ISS (ZARYA)","OBJECT_ID":"1998-067A","EPOCH":"2019-12-09T16:38:29.363424",
+"MEAN_MOTION":15.50103472,"ECCENTRICITY":0.0007417,"INCLINATION":51.6439,"RA_OF_ASC_NODE":211.2001,
+"ARG_OF_PERICENTER":17.6667,"MEAN_ANOMALY":85.6398,"EPHEMERIS_TYPE":0,"CLASSIFICATION_TYPE":"U",
+"NORAD_CAT_ID":25544,"ELEMENT_SET_NO":999,"REV_AT_EPOCH":20248,"BSTAR":"0.38792E-4",
+"MEAN_MOTION_DOT":1.764e-5,"MEAN_MOTION_DDOT":0}]`,
+}
+
+func TestSatelliteOMMToTLE(t *testing.T) {
+	for format, doc := range issOMMs {
+		detected, err := satellite.DetectCatalogFormat([]byte(doc))
+		if err != nil || detected != format {
+			t.Errorf("%s detected as %q (%v)", format, detected, err)
+		}
+		tles, err := satellite.ReadCatalog(strings.NewReader(doc), "")
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(tles) != 1 {
+			t.Fatalf("%s: read %d element sets", format, len(tles))
+		}
+		tle := tles[0]
+		if tle.Line1 != issLine1 || tle.Line2 != issLine2 {
+			t.Errorf("%s formatted as\n%s\n%s\nwant\n%s\n%s", format, tle.Line1, tle.Line2, issLine1, issLine2)
+		}
+		if tle.SatelliteID != 25544 || tle.Name != "ISS (ZARYA)" {
+			t.Errorf("%s: %d %q", format, tle.SatelliteID, tle.Name)
+		}
+	}
+
+	if _, err := satellite.ReadOMMKVN(strings.NewReader("CCSDS_OMM_VERS = 2.0\nOBJECT_NAME = X\nEPOCH = 2019-12-09T16:38:29\n")); err == nil {
+		t.Error("expected an error for an OMM without mean elements")
+	}
+}
+
+// issSeries builds n daily element sets following drag decay, with the
+// mean motion raised by burn rev/day from index burnAt on.
+func issSeries(t *testing.T, n, burnAt int, burn float64) []*satellite.TLE {
+	t.Helper()
+	const ndot = 1.764e-5
+	epoch := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	var tles []*satellite.TLE
+	for i := 0; i < n; i++ {
+		o := &satellite.OMM{
+			ObjectName:    "ISS (ZARYA)",
+			ObjectID:      "1998-067A",
+			Epoch:         epoch.Add(time.Duration(i) * 24 * time.Hour),
+			MeanMotion:    15.5 + 2*ndot*float64(i),
+			Eccentricity:  0.0007,
+			Inclination:   51.6439,
+			RAAN:          211.2,
+			ArgPericenter: 17.7,
+			MeanAnomaly:   85.6,
+			NoradCatID:    25544,
+			BStar:         3.8792e-5,
+			MeanMotionDot: ndot,
+		}
+		if i >= burnAt {
+			o.MeanMotion += burn
+		}
+		tle, err := o.TLE()
+		if err != nil {
+			t.Fatalf("OMM.TLE: %v", err)
+		}
+		tles = append(tles, tle)
+	}
+	return tles
+}
+
+func TestSatelliteCatalogImportAndManeuvers(t *testing.T) {
+	ctx := context.Background()
+	catalog := satellite.NewCatalog(satellite.NewMemoryCatalogStore(), satellite.DefaultCatalogConfig())
+
+	series := issSeries(t, 6, 4, -0.006)
+	// Import out of order; the catalog sorts by epoch
+	series[1], series[3] = series[3], series[1]
+	result, err := catalog.ImportTLEs(ctx, series)
+	if err != nil {
+		t.Fatalf("ImportTLEs: %v", err)
+	}
+	if result.Imported != 6 || result.Duplicates != 0 {
+		t.Errorf("import result %+v", result)
+	}
+	if len(result.Maneuvers) != 1 || !result.Maneuvers[0].Epoch.Equal(time.Date(2019, 12, 5, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("maneuvers = %+v, want the burn on Dec 5 only", result.Maneuvers)
+	}
+	if !strings.Contains(result.Maneuvers[0].ManeuverReason, "mean motion") {
+		t.Errorf("maneuver reason %q", result.Maneuvers[0].ManeuverReason)
+	}
+
+	// Re-importing the same file stores nothing new
+	var file strings.Builder
+	for _, tle := range series {
+		file.WriteString(tle.Name + "\n" + tle.Line1 + "\n" + tle.Line2 + "\n")
+	}
+	again, err := catalog.Import(ctx, strings.NewReader(file.String()), satellite.FormatTLE)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if again.Imported != 0 || again.Duplicates != 6 {
+		t.Errorf("re-import result %+v", again)
+	}
+
+	history, err := catalog.History(ctx, 25544, 0)
+	if err != nil || len(history) != 6 {
+		t.Fatalf("history = %d epochs (%v)", len(history), err)
+	}
+	for i := 1; i < len(history); i++ {
+		if !history[i].Epoch.Before(history[i-1].Epoch) {
+			t.Fatalf("history not newest first")
+		}
+	}
+
+	// An inclination change is a plane change
+	plane := &satellite.OMM{
+		ObjectName: "ISS (ZARYA)", ObjectID: "1998-067A", NoradCatID: 25544,
+		Epoch:      time.Date(2019, 12, 7, 12, 0, 0, 0, time.UTC),
+		MeanMotion: history[0].MeanMotion + 2*1.764e-5, Eccentricity: 0.0007,
+		Inclination: 51.70, RAAN: 211.2, ArgPericenter: 17.7, MeanAnomaly: 85.6,
+		MeanMotionDot: 1.764e-5,
+	}
+	tle, err := plane.TLE()
+	if err != nil {
+		t.Fatalf("OMM.TLE: %v", err)
+	}
+	result, err = catalog.ImportTLEs(ctx, []*satellite.TLE{tle})
+	if err != nil || len(result.Maneuvers) != 1 || !strings.Contains(result.Maneuvers[0].ManeuverReason, "inclination") {
+		t.Fatalf("plane change result %+v (%v)", result, err)
+	}
+
+	maneuvers, err := catalog.Maneuvers(ctx, time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC), 0)
+	if err != nil || len(maneuvers) != 2 || !maneuvers[0].Epoch.After(maneuvers[1].Epoch) {
+		t.Errorf("maneuvers = %+v (%v)", maneuvers, err)
+	}
+}
+
+func TestSatelliteCatalogStaleness(t *testing.T) {
+	ctx := context.Background()
+	catalog := satellite.NewCatalog(satellite.NewMemoryCatalogStore(), satellite.DefaultCatalogConfig())
+
+	molniya := []*satellite.TLE{{
+		SatelliteID: 8195,
+		Name:        "MOLNIYA 2-14",
+		Line1:       "1 08195U 75081A   06176.33215444  .00000099  00000-0  11873-3 0   813",
+		Line2:       "2 08195  64.1586 279.0717 6877146 264.7651  20.2257  2.00491383225656",
+	}}
+	if _, err := catalog.ImportTLEs(ctx, append(issSeries(t, 1, 1, 0), molniya...)); err != nil {
+		t.Fatalf("ImportTLEs: %v", err)
+	}
+
+	issEpoch := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	molniyaEpoch := time.Date(2006, 6, 25, 7, 58, 18, 0, time.UTC)
+
+	// LEO elements go stale after three days, deep-space after fourteen
+	entries, err := catalog.Latest(ctx, molniyaEpoch.Add(5*24*time.Hour))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Latest = %d entries (%v)", len(entries), err)
+	}
+	if entries[0].NoradID != 8195 || entries[0].Stale {
+		t.Errorf("Molniya five days after epoch: %+v", entries[0])
+	}
+
+	stale, err := catalog.Stale(ctx, issEpoch.Add(4*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Stale: %v", err)
+	}
+	if len(stale) != 2 {
+		t.Errorf("stale at ISS epoch + 4 days = %d objects, want 2", len(stale))
+	}
+	fresh, err := catalog.Get(ctx, 25544, issEpoch.Add(24*time.Hour))
+	if err != nil || fresh.Stale || fresh.AgeHours != 24 {
+		t.Errorf("ISS one day after epoch: %+v (%v)", fresh, err)
+	}
+
+	tles, err := catalog.TLEs(ctx)
+	if err != nil || len(tles) != 2 {
+		t.Fatalf("TLEs = %d (%v)", len(tles), err)
+	}
+	if _, err := satellite.NewPropagator(tles[0]); err != nil {
+		t.Errorf("catalog TLE does not propagate: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package integration_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+const passCatalog = `ISS (ZARYA)
+1 25544U 98067A   19343.69339541  .00001764  00000-0  38792-4 0  9991
+2 25544  51.6439 211.2001 0007417  17.6667  85.6398 15.50103472202482
+
+1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753
+2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667
+`
+
+func TestSatelliteReadTLEs(t *testing.T) {
+	tles, err := satellite.ReadTLEs(strings.NewReader(passCatalog))
+	if err != nil {
+		t.Fatalf("ReadTLEs: %v", err)
+	}
+	if len(tles) != 2 {
+		t.Fatalf("read %d TLEs, want 2", len(tles))
+	}
+	if tles[0].SatelliteID != 25544 || tles[0].Name != "ISS (ZARYA)" {
+		t.Errorf("first TLE = %d %q", tles[0].SatelliteID, tles[0].Name)
+	}
+	if tles[1].SatelliteID != 5 || tles[1].Name != "5" {
+		t.Errorf("two-line TLE = %d %q", tles[1].SatelliteID, tles[1].Name)
+	}
+	wantEpoch := time.Date(2019, 12, 9, 16, 38, 29, 363424000, time.UTC)
+	if d := tles[0].Epoch.Sub(wantEpoch); d > time.Microsecond || d < -time.Microsecond {
+		t.Errorf("epoch = %s, want %s", tles[0].Epoch, wantEpoch)
+	}
+
+	if _, err := satellite.ReadTLEs(strings.NewReader("ISS\n1 25544U 98067A   19343.69339541  .00001764  00000-0  38792-4 0  9991\n")); err == nil {
+		t.Error("expected an error for a missing line 2")
+	}
+}
+
+func TestSatellitePredictPasses(t *testing.T) {
+	tles, err := satellite.ReadTLEs(strings.NewReader(passCatalog))
+	if err != nil {
+		t.Fatalf("ReadTLEs: %v", err)
+	}
+	iss := tles[0]
+	observer := satellite.Observer{Latitude: 40.7128, Longitude: -74.0060, Altitude: 10}
+	start := time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)
+	cfg := satellite.DefaultPassConfig()
+
+	passes, err := satellite.PredictPasses(iss, observer, start, start.Add(48*time.Hour), cfg)
+	if err != nil {
+		t.Fatalf("PredictPasses: %v", err)
+	}
+	if len(passes) < 4 {
+		t.Fatalf("found %d ISS passes over New York in two days, want at least 4", len(passes))
+	}
+
+	p, err := satellite.NewPropagator(iss)
+	if err != nil {
+		t.Fatalf("NewPropagator: %v", err)
+	}
+	elevation := func(at time.Time) float64 {
+		look, err := p.Look(observer, at)
+		if err != nil {
+			t.Fatalf("Look: %v", err)
+		}
+		return look.Elevation
+	}
+
+	for i, pass := range passes {
+		if !pass.AOS.Time.Before(pass.TCA.Time) || !pass.TCA.Time.Before(pass.LOS.Time) {
+			t.Errorf("pass %d: AOS %s, TCA %s, LOS %s out of order", i, pass.AOS.Time, pass.TCA.Time, pass.LOS.Time)
+		}
+		if math.Abs(pass.AOS.Elevation-cfg.MinElevation) > 0.05 || math.Abs(pass.LOS.Elevation-cfg.MinElevation) > 0.05 {
+			t.Errorf("pass %d: AOS el %.3f, LOS el %.3f, want the %.0f° mask", i, pass.AOS.Elevation, pass.LOS.Elevation, cfg.MinElevation)
+		}
+		if pass.TCA.Elevation < cfg.MinElevation || pass.TCA.Elevation > 90 {
+			t.Errorf("pass %d: TCA elevation %.2f", i, pass.TCA.Elevation)
+		}
+		if pass.DurationSeconds <= 0 || pass.DurationSeconds > 15*60 {
+			t.Errorf("pass %d: duration %.0fs", i, pass.DurationSeconds)
+		}
+		if i > 0 && !passes[i-1].LOS.Time.Before(pass.AOS.Time) {
+			t.Errorf("pass %d overlaps the previous pass", i)
+		}
+		if pass.Visible && (pass.VisibleStart.Before(pass.AOS.Time) || pass.VisibleEnd.After(pass.LOS.Time)) {
+			t.Errorf("pass %d: visible window %s-%s outside the pass", i, pass.VisibleStart, pass.VisibleEnd)
+		}
+	}
+
+	// Brute-force the first pass at one-second steps
+	first := passes[0]
+	var maxEl float64
+	var maxAt time.Time
+	var rise, set time.Time
+	for at := first.AOS.Time.Add(-time.Minute); at.Before(first.LOS.Time.Add(time.Minute)); at = at.Add(time.Second) {
+		el := elevation(at)
+		if el >= cfg.MinElevation {
+			if rise.IsZero() {
+				rise = at
+			}
+			set = at
+		}
+		if el > maxEl {
+			maxEl, maxAt = el, at
+		}
+	}
+	if d := rise.Sub(first.AOS.Time); d < -time.Second || d > time.Second {
+		t.Errorf("AOS %s, brute force %s", first.AOS.Time, rise)
+	}
+	if d := set.Sub(first.LOS.Time); d < -time.Second || d > time.Second {
+		t.Errorf("LOS %s, brute force %s", first.LOS.Time, set)
+	}
+	if d := maxAt.Sub(first.TCA.Time); d < -2*time.Second || d > 2*time.Second || first.TCA.Elevation < maxEl-0.01 {
+		t.Errorf("TCA %s at %.3f°, brute force %s at %.3f°", first.TCA.Time, first.TCA.Elevation, maxAt, maxEl)
+	}
+
+	// No passes start inside the gaps
+	for i := 1; i < len(passes); i++ {
+		mid := passes[i-1].LOS.Time.Add(passes[i].AOS.Time.Sub(passes[i-1].LOS.Time) / 2)
+		if el := elevation(mid); el >= cfg.MinElevation {
+			t.Errorf("elevation %.2f° between passes %d and %d", el, i-1, i)
+		}
+	}
+
+	// What is overhead at the first TCA must include the ISS
+	above := satellite.SatellitesAbove(tles, observer, first.TCA.Time, cfg.MinElevation)
+	if len(above) == 0 || above[0].SatelliteID != 25544 {
+		t.Fatalf("SatellitesAbove at TCA = %+v", above)
+	}
+	if math.Abs(above[0].Elevation-first.TCA.Elevation) > 1e-6 || above[0].IntDesignator != "1998-067A" {
+		t.Errorf("overhead entry = %+v", above[0])
+	}
+}
+
+func TestSatellitePassCalendar(t *testing.T) {
+	aos := time.Date(2019, 12, 10, 22, 1, 2, 0, time.UTC)
+	passes := []satellite.Pass{{
+		SatelliteID: 25544,
+		Name:        "ISS (ZARYA), the long name of a space station; used to check line folding",
+		AOS:         satellite.PassPoint{Time: aos, Azimuth: 200, Elevation: 10},
+		TCA:         satellite.PassPoint{Time: aos.Add(3 * time.Minute), Azimuth: 130, Elevation: 54, Range: 520},
+		LOS:         satellite.PassPoint{Time: aos.Add(6 * time.Minute), Azimuth: 60, Elevation: 10},
+		Visible:     true,
+	}}
+
+	var buf bytes.Buffer
+	if err := satellite.WritePassCalendar(&buf, passes, time.Date(2019, 12, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("WritePassCalendar: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:25544-1576015262@asgard\r\n",
+		"DTSTAMP:20191210T000000Z\r\n",
+		"DTSTART:20191210T220102Z\r\n",
+		"DTEND:20191210T220702Z\r\n",
+		`ZARYA)\, the long`,
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("calendar missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Error("bare LF in calendar")
+	}
+	for _, line := range strings.Split(out, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("unfolded line of %d octets: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, `SUMMARY:ISS (ZARYA)\, the long name of a space station\; used to check line folding pass`) {
+		t.Errorf("summary not escaped and folded correctly:\n%s", unfolded)
+	}
+}
@@ -0,0 +1,197 @@
+package integration_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/asgard/pandora/internal/platform/satellite"
+)
+
+// Reference states from the Vallado test-case set (tcppver.out), TEME km
+// and km/s.
+var sgp4Vectors = []struct {
+	name         string
+	line1, line2 string
+	deepSpace    bool
+	states       []struct {
+		tsince float64
+		r, v   [3]float64
+	}
+}{
+	{
+		name:  "00005 near earth, e=0.186",
+		line1: "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+		line2: "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667",
+		states: []struct {
+			tsince float64
+			r, v   [3]float64
+		}{
+			{0, [3]float64{7022.46529266, -1400.08296755, 0.03995155}, [3]float64{1.893841015, 6.405893759, 4.534807250}},
+			{360, [3]float64{-7154.03120202, -3783.17682504, -3536.19412294}, [3]float64{4.741887409, -4.151817765, -2.093935425}},
+			{720, [3]float64{-7134.59340119, 6531.68641334, 3260.27186483}, [3]float64{-4.113793027, -2.911922039, -2.557327851}},
+			{1440, [3]float64{-938.55923943, -6268.18748831, -4294.02924751}, [3]float64{7.536105209, -0.427127707, 0.989878080}},
+			{2880, [3]float64{-8650.73082219, -1914.93811525, -3007.03603443}, [3]float64{3.067165127, -4.828384068, -2.515322836}},
+			{4320, [3]float64{-9060.47373569, 4658.70952502, 813.68673153}, [3]float64{-2.232832783, -4.110453490, -3.157345433}},
+		},
+	},
+	{
+		name:  "06251 near earth, normal drag",
+		line1: "1 06251U 62025E   06176.82412014  .00008885  00000-0  12808-3 0  3985",
+		line2: "2 06251  58.0579  54.0425 0030035 139.1568 221.1854 15.56387291  6774",
+		states: []struct {
+			tsince float64
+			r, v   [3]float64
+		}{
+			{0, [3]float64{3988.31022699, 5498.96657235, 0.90055879}, [3]float64{-3.290032738, 2.357652820, 6.496623475}},
+			{120, [3]float64{-3935.69800083, 409.10980837, 5471.33577327}, [3]float64{-3.374784183, -6.635211043, -1.942056221}},
+			{1440, [3]float64{-2777.14682335, -5663.16031708, -2462.54889123}, [3]float64{4.915493146, 0.123328992, -5.896495091}},
+			{2880, [3]float64{1159.27802897, 5056.60175495, 4353.49418579}, [3]float64{-5.968060341, -2.314790406, 4.230722669}},
+		},
+	},
+	{
+		name:  "28057 near earth, e < 1e-4",
+		line1: "1 28057U 03049A   06177.78615833  .00000060  00000-0  35940-4 0  1836",
+		line2: "2 28057  98.4283 247.6961 0000884  88.1964 271.9322 14.35478080140550",
+		states: []struct {
+			tsince float64
+			r, v   [3]float64
+		}{
+			{0, [3]float64{-2715.28237486, -6619.26436889, -0.01341443}, [3]float64{-1.008587273, 0.422782003, 7.385272942}},
+			{120, [3]float64{-1816.87920942, -1835.78762132, 6661.07926465}, [3]float64{2.325140071, 6.655669329, 2.463394512}},
+			{1440, [3]float64{688.16056594, 4124.87618964, 5794.55994449}, [3]float64{2.810973665, 5.479585563, -4.224866316}},
+			{2880, [3]float64{1788.42334580, 1990.50530957, -6640.59337725}, [3]float64{-2.074169091, -6.683381288, -2.562777776}},
+		},
+	},
+	{
+		name:      "08195 Molniya, 12h resonance",
+		line1:     "1 08195U 75081A   06176.33215444  .00000099  00000-0  11873-3 0   813",
+		line2:     "2 08195  64.1586 279.0717 6877146 264.7651  20.2257  2.00491383225656",
+		deepSpace: true,
+		states: []struct {
+			tsince float64
+			r, v   [3]float64
+		}{
+			{0, [3]float64{2349.89483350, -14785.93811562, 0.02119378}, [3]float64{2.721488096, -3.256811655, 4.498416672}},
+			{120, [3]float64{15223.91713658, -17852.95881713, 25280.39558224}, [3]float64{1.079041732, 0.875187372, 2.485682813}},
+			{1440, [3]float64{2890.80638268, -15446.43952300, 948.77010176}, [3]float64{2.654407490, -2.909344895, 4.486437362}},
+			{2880, [3]float64{3417.20931586, -16038.79510665, 1894.74934058}, [3]float64{2.585515864, -2.596818146, 4.456882556}},
+		},
+	},
+	{
+		name:      "11801 deep space, high drag",
+		line1:     "1 11801U          80230.29629788  .01431103  00000-0  14311-1      13",
+		line2:     "2 11801  46.7916 230.4354 7318036  47.4722  10.4117  2.28537848    13",
+		deepSpace: true,
+		states: []struct {
+			tsince float64
+			r, v   [3]float64
+		}{
+			{0, [3]float64{7473.37102491, 428.94748312, 5828.74846783}, [3]float64{5.107155391, 6.444680305, -0.186133297}},
+			{720, [3]float64{14271.29083858, 24110.44309009, -4725.76320143}, [3]float64{-0.320504528, 2.679841539, -2.084054355}},
+			{1440, [3]float64{9787.87836256, 33753.32249667, -15030.79874625}, [3]float64{-1.094251553, 0.923589906, -1.522311008}},
+		},
+	},
+}
+
+func TestSGP4MatchesValladoVectors(t *testing.T) {
+	for _, tc := range sgp4Vectors {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := satellite.NewPropagator(&satellite.TLE{Line1: tc.line1, Line2: tc.line2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.DeepSpace() != tc.deepSpace {
+				t.Errorf("DeepSpace() = %v, want %v", p.DeepSpace(), tc.deepSpace)
+			}
+			for _, want := range tc.states {
+				got, err := p.PropagateMinutes(want.tsince)
+				if err != nil {
+					t.Fatalf("t=%.0f: %v", want.tsince, err)
+				}
+				for i := 0; i < 3; i++ {
+					// Published to 8 decimals for position, 9 for velocity
+					if d := math.Abs(got.Position[i] - want.r[i]); d > 1e-6 {
+						t.Errorf("t=%.0f r[%d] = %.8f, want %.8f", want.tsince, i, got.Position[i], want.r[i])
+					}
+					if d := math.Abs(got.Velocity[i] - want.v[i]); d > 1e-8 {
+						t.Errorf("t=%.0f v[%d] = %.9f, want %.9f", want.tsince, i, got.Velocity[i], want.v[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSGP4TimeAndGeodetic(t *testing.T) {
+	// python-sgp4 reference propagation of the ISS
+	p, err := satellite.NewPropagator(&satellite.TLE{
+		Line1: "1 25544U 98067A   19343.69339541  .00001764  00000-0  38792-4 0  9991",
+		Line2: "2 25544  51.6439 211.2001 0007417  17.6667  85.6398 15.50103472202482",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEpoch := time.Date(2019, 12, 9, 16, 38, 29, 363424000, time.UTC)
+	if d := p.Epoch().Sub(wantEpoch); d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("epoch = %s, want %s", p.Epoch(), wantEpoch)
+	}
+
+	at := time.Date(2019, 12, 9, 20, 42, 9, 72000000, time.UTC) // JD 2458827.362605
+	state, err := p.PropagateState(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [3]float64{-6102.443276, -986.332016, -2820.313088}
+	for i := range want {
+		if d := math.Abs(state.Position[i] - want[i]); d > 1e-3 {
+			t.Errorf("r[%d] = %.6f, want %.6f", i, state.Position[i], want[i])
+		}
+	}
+
+	// Geodetic height must agree with the geocentric radius less the local
+	// WGS-84 ellipsoid radius
+	lat, _, alt, err := p.Propagate(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := math.Sqrt(state.Position[0]*state.Position[0] + state.Position[1]*state.Position[1] + state.Position[2]*state.Position[2])
+	phi := lat * math.Pi / 180
+	const a, b = 6378.137, 6356.752314245
+	ellipsoid := math.Sqrt((math.Pow(a*a*math.Cos(phi), 2) + math.Pow(b*b*math.Sin(phi), 2)) /
+		(math.Pow(a*math.Cos(phi), 2) + math.Pow(b*math.Sin(phi), 2)))
+	if d := math.Abs(r - ellipsoid - alt); d > 1 {
+		t.Errorf("altitude %.3f km inconsistent with radius %.3f km at latitude %.3f", alt, r, lat)
+	}
+}
+
+func TestSGP4ReportsDecay(t *testing.T) {
+	// Very low orbit with heavy drag re-enters within days
+	p, err := satellite.NewPropagator(&satellite.TLE{
+		Line1: "1 99999U 24001A   24001.00000000  .01000000  00000-0  10000-1 0  9990",
+		Line2: "2 99999  51.6000 100.0000 0005000  10.0000 350.0000 16.20000000    10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.PropagateMinutes(60); err != nil {
+		t.Fatalf("failed an hour after epoch: %v", err)
+	}
+
+	_, err = p.PropagateState(p.Epoch().Add(30 * 24 * time.Hour))
+	var sgp4Err satellite.SGP4Error
+	if !errors.As(err, &sgp4Err) {
+		t.Fatalf("propagating past re-entry: err = %v, want SGP4Error", err)
+	}
+	if _, _, _, err := p.Propagate(p.Epoch().Add(30 * 24 * time.Hour)); !errors.As(err, &sgp4Err) {
+		t.Errorf("Propagate after re-entry: err = %v, want SGP4Error", err)
+	}
+	positions, err := p.PropagateRange(p.Epoch(), 30*24*time.Hour, 24*time.Hour)
+	if !errors.As(err, &sgp4Err) {
+		t.Errorf("PropagateRange past re-entry: err = %v, want SGP4Error", err)
+	}
+	if len(positions) == 0 || len(positions) >= 30 {
+		t.Errorf("PropagateRange returned %d points, want only those before re-entry", len(positions))
+	}
+}
@@ -109,7 +109,10 @@ func TestPropagatorPosition(t *testing.T) {
 	}
 
 	// Propagate to current time
-	lat, lon, alt := propagator.Propagate(time.Now())
+	lat, lon, alt, err := propagator.Propagate(time.Now())
+	if err != nil {
+		t.Fatalf("propagate failed: %v", err)
+	}
 
 	// ISS should be at approximately 400-420 km altitude
 	if alt < 350 || alt > 500 {
@@ -146,7 +149,10 @@ func TestPropagatorRange(t *testing.T) {
 	}
 
 	// Generate 10-minute track
-	positions := propagator.PropagateRange(time.Now(), 10*time.Minute, time.Minute)
+	positions, err := propagator.PropagateRange(time.Now(), 10*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("propagate range failed: %v", err)
+	}
 
 	// Verify we got a reasonable number of positions
 	if len(positions) < 10 || len(positions) > 12 {
@@ -1,9 +1,14 @@
 package integration_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 
+	"github.com/asgard/pandora/internal/orbital/tracking"
 	"github.com/asgard/pandora/internal/orbital/vision"
+	"github.com/google/uuid"
 )
 
 func TestAlertCriteriaShouldAlert(t *testing.T) {
@@ -138,3 +143,196 @@ func TestMultipleDetections(t *testing.T) {
 		t.Errorf("expected 2 alerts, got %d", alertCount)
 	}
 }
+
+func newTestTracker(alerts chan tracking.Alert) *tracking.Tracker {
+	return tracking.NewTracker(
+		vision.AlertCriteria{MinConfidence: 0.85, AlertClasses: []string{"fire", "ship"}},
+		alerts,
+		func(context.Context) (string, error) { return "lat=0, lon=0", nil },
+		func(context.Context) ([]byte, error) { return []byte("clip"), nil },
+	)
+}
+
+func box(x, y int) vision.BoundingBox {
+	return vision.BoundingBox{X: x, Y: y, Width: 40, Height: 30}
+}
+
+func TestTrackerIgnoresSingleFrameFlicker(t *testing.T) {
+	alerts := make(chan tracking.Alert, 10)
+	tracker := newTestTracker(alerts)
+	ctx := context.Background()
+
+	// A one-frame fire-pixel flicker, then empty frames
+	tracker.ProcessDetections(ctx, []vision.Detection{{Class: "fire", Confidence: 0.95, BoundingBox: box(100, 100)}})
+	if tracks := tracker.Tracks(); len(tracks) != 1 || tracks[0].State != tracking.TrackTentative {
+		t.Fatalf("tracks after first frame = %+v", tracks)
+	}
+	for i := 0; i < 3; i++ {
+		tracker.ProcessDetections(ctx, nil)
+	}
+	if len(alerts) != 0 {
+		t.Error("single-frame flicker raised an alert")
+	}
+	if tracks := tracker.Tracks(); len(tracks) != 0 {
+		t.Errorf("tentative track survived: %+v", tracks)
+	}
+
+	// Flickers at unrelated positions never associate
+	for i := 0; i < 5; i++ {
+		tracker.ProcessDetections(ctx, []vision.Detection{{Class: "fire", Confidence: 0.95, BoundingBox: box(100+i*200, 100)}})
+	}
+	if len(alerts) != 0 {
+		t.Error("unassociated flickers raised an alert")
+	}
+}
+
+func TestTrackerConfirmsPersistentTargets(t *testing.T) {
+	alerts := make(chan tracking.Alert, 10)
+	tracker := newTestTracker(alerts)
+	ctx := context.Background()
+
+	// Two ships drifting across the frame side by side, plus weak fire
+	// detections that never accumulate enough confidence
+	for frame := 0; frame < 8; frame++ {
+		dx := frame * 12
+		tracker.ProcessDetections(ctx, []vision.Detection{
+			{Class: "ship", Confidence: 0.7, BoundingBox: box(100+dx, 100)},
+			{Class: "ship", Confidence: 0.75, BoundingBox: box(150+dx, 100)},
+			{Class: "fire", Confidence: 0.4, BoundingBox: box(400, 300)},
+		})
+		if frame == 1 && len(alerts) != 0 {
+			t.Fatal("alert raised before confirmation")
+		}
+	}
+
+	tracks := tracker.Tracks()
+	if len(tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3", len(tracks))
+	}
+	ships := 0
+	for _, tr := range tracks {
+		if tr.State != tracking.TrackConfirmed || tr.Hits != 8 {
+			t.Errorf("%s track %s: %d hits", tr.Class, tr.State, tr.Hits)
+		}
+		if tr.Class == "ship" {
+			ships++
+			if tr.Confidence < 0.99 || tr.AlertID == uuid.Nil {
+				t.Errorf("ship track confidence %.3f, alert %s", tr.Confidence, tr.AlertID)
+			}
+		} else if tr.AlertID != uuid.Nil || tr.Confidence > 0.1 {
+			t.Errorf("weak fire track raised an alert at confidence %.3f", tr.Confidence)
+		}
+	}
+	if ships != 2 {
+		t.Errorf("got %d ship tracks, want 2", ships)
+	}
+
+	// One alert per track, however long it persists
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want one per ship", len(alerts))
+	}
+	first, second := <-alerts, <-alerts
+	if first.TrackID == second.TrackID || first.Type != "ship" || first.Confidence < 0.85 {
+		t.Errorf("alerts = %+v, %+v", first, second)
+	}
+}
+
+func TestTrackerLosesTracks(t *testing.T) {
+	alerts := make(chan tracking.Alert, 10)
+	tracker := newTestTracker(alerts)
+	ctx := context.Background()
+	cfg := tracking.DefaultTrackerConfig()
+
+	fire := []vision.Detection{{Class: "fire", Confidence: 0.9, BoundingBox: box(200, 200)}}
+	for i := 0; i < cfg.ConfirmHits; i++ {
+		tracker.ProcessDetections(ctx, fire)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts after confirmation, want 1", len(alerts))
+	}
+
+	// Brief occlusion keeps the track and does not re-alert
+	tracker.ProcessDetections(ctx, nil)
+	tracker.ProcessDetections(ctx, fire)
+	if tracks := tracker.Tracks(); len(tracks) != 1 || tracks[0].State != tracking.TrackConfirmed {
+		t.Fatalf("track after occlusion = %+v", tracks)
+	}
+	if len(alerts) != 1 {
+		t.Error("re-acquired track alerted again")
+	}
+
+	for i := 0; i < cfg.MaxMisses; i++ {
+		tracker.ProcessDetections(ctx, nil)
+	}
+	tracks := tracker.Tracks()
+	if len(tracks) != 1 || tracks[0].State != tracking.TrackLost {
+		t.Fatalf("track after %d misses = %+v", cfg.MaxMisses, tracks)
+	}
+
+	// The fire reappearing is a new track, and lost tracks are dropped
+	for i := 0; i < cfg.ConfirmHits; i++ {
+		tracker.ProcessDetections(ctx, fire)
+	}
+	if len(alerts) != 2 {
+		t.Errorf("got %d alerts, want the new track to alert", len(alerts))
+	}
+	for i := 0; i < cfg.MaxMisses+cfg.LostRetention; i++ {
+		tracker.ProcessDetections(ctx, nil)
+	}
+	if tracks := tracker.Tracks(); len(tracks) != 0 {
+		t.Errorf("%d tracks remain", len(tracks))
+	}
+}
+
+func TestTrackerAlertAcknowledgements(t *testing.T) {
+	alerts := make(chan tracking.Alert, 10)
+	tracker := newTestTracker(alerts)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		tracker.ProcessDetections(ctx, []vision.Detection{{Class: "fire", Confidence: 0.9, BoundingBox: box(10, 10)}})
+	}
+	alert := <-alerts
+	if alert.Status != tracking.AlertStatusNew {
+		t.Fatalf("new alert status = %s", alert.Status)
+	}
+
+	// Acknowledgements round-trip through a bundle payload
+	ack := tracking.NewAlertAck(alert.ID, tracking.AlertStatusAcknowledged, "ops-1")
+	payload, _ := json.Marshal(ack)
+	decoded, err := tracking.DecodeAck(payload)
+	if err != nil {
+		t.Fatalf("DecodeAck: %v", err)
+	}
+	updated, err := tracker.HandleAck(decoded)
+	if err != nil || updated.Status != tracking.AlertStatusAcknowledged || updated.Operator != "ops-1" {
+		t.Fatalf("HandleAck = %+v, %v", updated, err)
+	}
+	if _, err := tracker.HandleAck(decoded); err != nil {
+		t.Errorf("duplicate acknowledgement: %v", err)
+	}
+
+	// Stages may be skipped but never reversed
+	if updated, err = tracker.HandleAck(tracking.NewAlertAck(alert.ID, tracking.AlertStatusResolved, "ops-2")); err != nil || updated.Status != tracking.AlertStatusResolved {
+		t.Fatalf("resolve = %+v, %v", updated, err)
+	}
+	if _, err := tracker.HandleAck(tracking.NewAlertAck(alert.ID, tracking.AlertStatusDispatched, "ops-1")); !errors.Is(err, tracking.ErrInvalidTransition) {
+		t.Errorf("late dispatch error = %v, want ErrInvalidTransition", err)
+	}
+	if _, err := tracker.HandleAck(tracking.NewAlertAck(uuid.New(), tracking.AlertStatusAcknowledged, "")); !errors.Is(err, tracking.ErrUnknownAlert) {
+		t.Errorf("unknown alert error = %v", err)
+	}
+	if got := tracker.Alerts(); len(got) != 1 || got[0].Status != tracking.AlertStatusResolved || got[0].VideoClip != nil {
+		t.Errorf("retained alerts = %+v", got)
+	}
+
+	for _, bad := range []string{
+		`{"type":"telemetry"}`,
+		`{"type":"alert_ack","status":"acknowledged"}`,
+		`{"type":"alert_ack","alert_id":"` + alert.ID.String() + `","status":"closed"}`,
+		`not json`,
+	} {
+		if _, err := tracking.DecodeAck([]byte(bad)); err == nil {
+			t.Errorf("DecodeAck accepted %s", bad)
+		}
+	}
+}